@@ -19,7 +19,10 @@ import (
 	"bee/cmd/commands"
 	_ "bee/cmd/commands/api"
 	_ "bee/cmd/commands/generate"
+	_ "bee/cmd/commands/migrate"
 	_ "bee/cmd/commands/run"
+	_ "bee/cmd/commands/script"
+	_ "bee/cmd/commands/seed"
 	_ "bee/cmd/commands/version"
 	"bee/utils"
 )
@@ -24,6 +24,7 @@ import (
 
 	"bee/cmd/commands"
 	"bee/cmd/commands/version"
+	"bee/generate"
 	beeLogger "bee/logger"
 	"bee/utils"
 )
@@ -35,7 +36,7 @@ var CmdApiApp = &commands.Command{
   The command 'api' creates a Beego API application.
 
   {{"Example:"|bold}}
-      $ bee api [appname]
+      $ bee api [appname] [-beego=v2]
 
   The command 'api' creates a folder named [appname] with the following structure:
 
@@ -112,6 +113,14 @@ controller:
   #     pos: pos11
   #     rule: Func ->HookApi(&v)->-1
   #     imports: xx_api/rules
+  #   Put:
+  #     imports: strings, xx_api/hooks
+  #     rules:
+  #       - authorize: {claim: role, in: [admin]}       # 默认pos: begin，claims不满足时返回403
+  #       - transform: {set: {Name: strings.TrimSpace(v.Name)}}  # 默认pos: before_save
+  #       - validate: {check: v.Age >= 18, message: 未满18岁, status: 422}
+  #         order: 1                                    # 同一pos点按order及书写顺序
+  #       - after_commit: {call: hooks.Changed(&v)}     # 默认pos: after_commit
 `
 var apiUseOrmMD = `代码生成：
 bee generate appcode -driver=mysql -conn="root:root@tcp(localhost:3306)/xxx" -level=1
@@ -144,7 +153,10 @@ bee g code -c="root:root@tcp(localhost:3306)/xxx"
 bee g rule
 `
 
+var beegoVersion string
+
 func init() {
+	CmdApiApp.Flag.StringVar(&beegoVersion, "beego", "v1", "Beego version of the application, either v1 or v2.")
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdApiApp)
 }
 
@@ -168,8 +180,16 @@ func createAPI(cmd *commands.Command, args []string) int {
 	beeLogger.Log.Info("Creating API...")
 
 	os.MkdirAll(appPath, 0755)
+	switch beegoVersion {
+	case "v1":
+	case "v2":
+		apiMain = generate.BeegoV2Source(apiMain)
+	default:
+		beeLogger.Log.Fatalf("Invalid beego version '%s'. Must be one of %v", beegoVersion, generate.BeegoVersions)
+	}
 	apiMain = strings.Replace(apiMain, "{{.Appname}}", packPath, -1)
 	_ = ioutil.WriteFile(path.Join(appPath, "main.go"), []byte(apiMain), 0666)
+	utils.FormatSourceCode(path.Join(appPath, "main.go"))
 	apiMDContent := strings.Replace(apiMD, "{{.Appname}}", appName, -1)
 	_ = ioutil.WriteFile(path.Join(appPath, "README.md"), []byte(apiMDContent), 0666)
 
@@ -24,8 +24,15 @@ import (
 	beeLogger "bee/logger"
 	"bee/utils"
 	"os"
+	"path/filepath"
 )
 
+var jsonCase, target, ormName, beegoVersion, driver, output string
+
+var checkRules bool
+
+var permFormat, permDiff, permTable string
+
 var CmdGenerate = &commands.Command{
 	UsageLine: "g [command]",
 	Short:     "Source code generator",
@@ -33,7 +40,54 @@ var CmdGenerate = &commands.Command{
   ▶ {{"To generate appcode based on an existing database:"|bold}}
 
      $ bee g code [-c="root:@tcp(127.0.0.1:3306)/test"]
+
+  ▶ {{"To also generate CSV/XLSX export and import endpoints for every table:"|bold}}
+
+     $ bee g code -export
+
+  ▶ {{"To use snake_case instead of camelCase in the json tags of the request and response structs:"|bold}}
+
+     $ bee g code -json=snake
+
+  ▶ {{"To generate handlers for Gin, Echo or net/http instead of beego controllers:"|bold}}
+
+     $ bee g code -target=gin
+
+  ▶ {{"To generate GORM models or sqlx repositories instead of beego orm models:"|bold}}
+
+     $ bee g code -orm=gorm
+
+  ▶ {{"To generate beego v2 code, which is the default when go.mod requires github.com/beego/beego/v2 (v2.0.2 or later):"|bold}}
+
+     $ bee g code -beego=v2
+
+  ▶ {{"To generate proto files and gRPC servers calling the models:"|bold}}
+
+     $ bee g proto [-c="root:@tcp(127.0.0.1:3306)/test"]
+
+  ▶ {{"To generate a GraphQL schema and resolvers calling the models:"|bold}}
+
+     $ bee g graphql [-c="root:@tcp(127.0.0.1:3306)/test"]
+
+  ▶ {{"To apply rules/rule.yml to the beego controllers, replacing or removing the code inserted by earlier runs:"|bold}}
+
      $ bee g rule
+
+  ▶ {{"To only check rules/rule.yml, its controllers, pos points and imports, without writing any file:"|bold}}
+
+     $ bee g rule -check
+
+  ▶ {{"To generate the MySQL or PostgreSQL CREATE TABLE statements of the beego orm models:"|bold}}
+
+     $ bee g ddl [./models] [-driver=mysql] [-o=database/schema.sql]
+
+  ▶ {{"To export the permission catalogue of the routes, grouped by controller, as JSON, CSV or SQL inserts:"|bold}}
+
+     $ bee g perms [-format=json] [-o=database/perms.json] [-table=rule_perm]
+
+  ▶ {{"To export the permissions added and removed since a previous JSON export:"|bold}}
+
+     $ bee g perms -diff=database/perms.json [-format=sql]
 `,
 	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
 	Run:    GenerateCode,
@@ -41,6 +95,17 @@ var CmdGenerate = &commands.Command{
 
 func init() {
 	CmdGenerate.Flag.Var(&generate.SQLConn, "c", "Connection string used by the SQLDriver to connect to a database instance.")
+	CmdGenerate.Flag.StringVar(&jsonCase, "json", "", "JSON tag style of the request and response structs, either camel or snake. Defaults to generate.json_case in Beefile, or camel.")
+	CmdGenerate.Flag.StringVar(&target, "target", "", "Web framework of the generated code, one of beego, gin, echo or stdlib. Defaults to generate.target in Beefile, or beego.")
+	CmdGenerate.Flag.StringVar(&ormName, "orm", "", "Persistence layer of the generated models, one of beego, gorm or sqlx. Defaults to generate.orm in Beefile, or beego.")
+	CmdGenerate.Flag.StringVar(&beegoVersion, "beego", "", "Beego version of the generated code, either v1 or v2. Defaults to generate.beego in Beefile, or the version required by go.mod.")
+	CmdGenerate.Flag.BoolVar(&generate.ExportCode, "export", false, "Generate CSV/XLSX export and import endpoints for every table.")
+	CmdGenerate.Flag.StringVar(&driver, "driver", "", "Database of the DDL generated by ddl, either mysql or postgres. Defaults to database.driver in Beefile, or mysql.")
+	CmdGenerate.Flag.StringVar(&output, "o", "", "Output file of ddl or perms. Defaults to database/schema.sql, database/perms.<format> or database/perms_diff.<format>.")
+	CmdGenerate.Flag.BoolVar(&checkRules, "check", false, "Only check rules/rule.yml, reporting every error with its line and column.")
+	CmdGenerate.Flag.StringVar(&permFormat, "format", "json", "Format of the permissions exported by perms, one of json, csv or sql.")
+	CmdGenerate.Flag.StringVar(&permDiff, "diff", "", "Previous JSON export of perms, only the permissions added and removed since it are exported.")
+	CmdGenerate.Flag.StringVar(&permTable, "table", "rule_perm", "Table of the SQL statements exported by perms.")
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdGenerate)
 }
 
@@ -50,13 +115,30 @@ func GenerateCode(cmd *commands.Command, args []string) int {
 	if len(args) < 1 {
 		appCode(cmd, args, currpath)
 		fixRule()
-	}else {
+	} else {
 		gCmd := args[0]
 		switch gCmd {
 		case "code":
 			appCode(cmd, args[1:], currpath)
+			// 重新生成的controller没有rule插入的代码，重新应用rule.yml
+			if utils.IsExist(filepath.Join(currpath, generate.RuleFilePath)) {
+				fixRule()
+			}
 		case "rule":
+			cmd.Flag.Parse(args[1:])
+			if checkRules {
+				checkRule()
+				return 0
+			}
 			fixRule()
+		case "proto":
+			protoCode(cmd, args[1:], currpath)
+		case "graphql":
+			graphqlCode(cmd, args[1:], currpath)
+		case "ddl":
+			ddlCode(cmd, args[1:])
+		case "perms":
+			permsCode(cmd, args[1:], currpath)
 		default:
 			appCode(cmd, args[1:], currpath)
 			fixRule()
@@ -68,6 +150,64 @@ func GenerateCode(cmd *commands.Command, args []string) int {
 }
 
 func appCode(cmd *commands.Command, args []string, currpath string) {
+	setOptions(cmd, args, currpath)
+	beeLogger.Log.Infof("Using '%s' as 'SQLConn'", generate.SQLConn)
+	generate.GenerateAppcode(generate.SQLConn.String(), currpath)
+}
+
+func protoCode(cmd *commands.Command, args []string, currpath string) {
+	setOptions(cmd, args, currpath)
+	beeLogger.Log.Infof("Using '%s' as 'SQLConn'", generate.SQLConn)
+	generate.GenerateProto(generate.SQLConn.String(), currpath)
+}
+
+func graphqlCode(cmd *commands.Command, args []string, currpath string) {
+	setOptions(cmd, args, currpath)
+	beeLogger.Log.Infof("Using '%s' as 'SQLConn'", generate.SQLConn)
+	generate.GenerateGraphQL(generate.SQLConn.String(), currpath)
+}
+
+// ddlCode 由models目录中的模型生成建表语句，目录之后也可以有flag，如 ddl ./models -driver=postgres
+func ddlCode(cmd *commands.Command, args []string) {
+	modelPath := "models"
+	cmd.Flag.Parse(args)
+	if args = cmd.Flag.Args(); len(args) > 0 {
+		modelPath = args[0]
+		cmd.Flag.Parse(args[1:])
+	}
+	if driver == "" {
+		driver = config.Conf.Database.Driver
+	}
+	if output == "" {
+		output = filepath.Join("database", "schema.sql")
+	}
+	generate.GenerateDDL(modelPath, driver, output)
+}
+
+// permsCode 导出路由的权限目录，或与上一次导出相比新增及删除的权限
+func permsCode(cmd *commands.Command, args []string, currpath string) {
+	cmd.Flag.Parse(args)
+	valid := false
+	for _, f := range generate.PermFormats {
+		if f == permFormat {
+			valid = true
+		}
+	}
+	if !valid {
+		beeLogger.Log.Fatalf("Invalid format '%s'. Must be one of %v", permFormat, generate.PermFormats)
+	}
+	if output == "" {
+		name := "perms."
+		if permDiff != "" {
+			name = "perms_diff."
+		}
+		output = filepath.Join("database", name+permFormat)
+	}
+	generate.GeneratePerms(currpath, permFormat, output, permDiff, permTable)
+}
+
+// setOptions 解析命令行参数，未指定的取Beefile中的值
+func setOptions(cmd *commands.Command, args []string, currpath string) {
 	cmd.Flag.Parse(args)
 	if generate.SQLConn == "" {
 		generate.SQLConn = utils.DocValue(config.Conf.Database.Conn)
@@ -75,11 +215,99 @@ func appCode(cmd *commands.Command, args []string, currpath string) {
 			generate.SQLConn = "root:@tcp(127.0.0.1:3306)/test"
 		}
 	}
-	beeLogger.Log.Infof("Using '%s' as 'SQLConn'", generate.SQLConn)
-	generate.GenerateAppcode(generate.SQLConn.String(), currpath)
+	if jsonCase == "" {
+		jsonCase = config.Conf.Generate.JSONCase
+	}
+	switch jsonCase {
+	case "":
+	case "camel", "snake":
+		generate.JSONCase = jsonCase
+	default:
+		beeLogger.Log.Fatalf("Invalid json case '%s'. Must be either [camel|snake]", jsonCase)
+	}
+	if target == "" {
+		target = config.Conf.Generate.Target
+	}
+	if target != "" {
+		valid := false
+		for _, t := range generate.Targets {
+			if t == target {
+				valid = true
+			}
+		}
+		if !valid {
+			beeLogger.Log.Fatalf("Invalid target '%s'. Must be one of %v", target, generate.Targets)
+		}
+		generate.Target = target
+	}
+	if ormName == "" {
+		ormName = config.Conf.Generate.ORM
+	}
+	if ormName != "" {
+		valid := false
+		for _, o := range generate.ORMs {
+			if o == ormName {
+				valid = true
+			}
+		}
+		if !valid {
+			beeLogger.Log.Fatalf("Invalid orm '%s'. Must be one of %v", ormName, generate.ORMs)
+		}
+		generate.ORM = ormName
+	}
+	setBeegoVersion(currpath)
 }
 
 func fixRule() {
+	// rule.yml中的规则是beego controller的代码
+	if target == "" {
+		target = config.Conf.Generate.Target
+	}
+	if target != "" && target != "beego" {
+		beeLogger.Log.Warnf("Rules are only applied to the beego target, skipped for '%s'", target)
+		return
+	}
+	currpath, _ := os.Getwd()
+	setBeegoVersion(currpath)
 	var fr generate.FixRule
 	fr.FixRule()
+}
+
+// checkRule 检查rule.yml，有错误时逐条输出并退出
+func checkRule() {
+	currpath, _ := os.Getwd()
+	setBeegoVersion(currpath)
+	var fr generate.FixRule
+	errs := fr.Check()
+	for _, err := range errs {
+		beeLogger.Log.Error(err.Error())
+	}
+	if len(errs) > 0 {
+		beeLogger.Log.Fatalf("%d errors in %s", len(errs), generate.RuleFilePath)
+	}
+	beeLogger.Log.Success("Rules are valid!")
+}
+
+// setBeegoVersion 取-beego、Beefile的generate.beego，都没有时按go.mod判断
+func setBeegoVersion(currpath string) {
+	if beegoVersion == "" {
+		beegoVersion = config.Conf.Generate.Beego
+	}
+	if beegoVersion == "" {
+		beegoVersion = generate.DetectBeego(currpath)
+	}
+	switch beegoVersion {
+	case "v1", "v2":
+		generate.Beego = beegoVersion
+	default:
+		beeLogger.Log.Fatalf("Invalid beego version '%s'. Must be one of %v", beegoVersion, generate.BeegoVersions)
+	}
+	if generate.Beego != "v2" {
+		return
+	}
+	if version, err := generate.CheckBeegoV2(currpath); err != nil {
+		beeLogger.Log.Fatalf("%s. Run go get github.com/beego/beego/v2@%s or later", err, generate.BeegoV2MinVersion)
+	} else if version == "" {
+		beeLogger.Log.Warnf("go.mod does not require github.com/beego/beego/v2, the generated code needs %s or later", generate.BeegoV2MinVersion)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,324 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"bee/cmd/commands"
+	"bee/cmd/commands/version"
+	"bee/config"
+	beeLogger "bee/logger"
+	"bee/migrate"
+	"bee/utils"
+)
+
+var CmdMigrate = &commands.Command{
+	UsageLine: "migrate [command]",
+	Short:     "Runs the database migrations",
+	Long: `
+  The migrations are timestamped files in database.dir of Beefile, database/migrations by
+  default. The applied versions are recorded in the bee_migrations table, and each migration
+  runs in a transaction together with its record. Note that MySQL commits DDL statements
+  implicitly: when a statement of a MySQL migration fails, the statements before it stay
+  applied although the migration is not recorded. Check the database before running it
+  again, and prefer one DDL statement per MySQL migration.
+
+  ▶ {{"To create VERSION_NAME.up.sql and VERSION_NAME.down.sql:"|bold}}
+
+     $ bee migrate create NAME
+
+  ▶ {{"To create a Go migration, run by go run with the up or down argument:"|bold}}
+
+     $ bee migrate create NAME -go
+
+  ▶ {{"To apply all pending migrations, or the next N:"|bold}}
+
+     $ bee migrate [up] [-n=N] [-driver=mysql] [-c="root:@tcp(127.0.0.1:3306)/test"] [-dir=database/migrations]
+
+  ▶ {{"To roll back the last migration, or the last N:"|bold}}
+
+     $ bee migrate down [-n=N]
+
+  ▶ {{"To roll back and apply the last migration again:"|bold}}
+
+     $ bee migrate redo
+
+  ▶ {{"To apply or roll back migrations until VERSION is the last applied one, 0 rolls back all:"|bold}}
+
+     $ bee migrate to VERSION
+
+  ▶ {{"To show the applied and pending migrations:"|bold}}
+
+     $ bee migrate status
+
+  ▶ {{"To save the schema of the database to a snapshot, database/schema.yml by default:"|bold}}
+
+     $ bee migrate snapshot [-snapshot=FILE]
+
+  ▶ {{"To create a migration from the snapshot to the schema of the database, and update the snapshot:"|bold}}
+
+     $ bee migrate diff [NAME] [-snapshot=FILE] [-allow-drop]
+
+  ▶ {{"To create a migration converging the database of -from, e.g. staging, to the database of -c:"|bold}}
+
+     $ bee migrate diff [NAME] -from="root:@tcp(staging:3306)/test" [-allow-drop]
+
+  The database of -c already has the changes of a migration created by diff, so the migration is
+  recorded as applied in its bee_migrations table. Run bee migrate up on the other databases.
+  Tables, columns, indexes and foreign keys are matched by name, so a rename is a drop and a create.
+  The migrations dropping tables or columns, or changing column types, are only created with -allow-drop.
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunMigration,
+}
+
+var (
+	conn   utils.DocValue
+	driver string
+	dir    string
+	count  int
+	goFile bool
+
+	fromConn  utils.DocValue
+	snapshot  string
+	allowDrop bool
+)
+
+func init() {
+	CmdMigrate.Flag.Var(&conn, "c", "Connection string of the database. Defaults to database.conn in Beefile.")
+	CmdMigrate.Flag.StringVar(&driver, "driver", "", "Database driver, either mysql or postgres. Defaults to database.driver in Beefile, or mysql.")
+	CmdMigrate.Flag.StringVar(&dir, "dir", "", "Directory of the migration files. Defaults to database.dir in Beefile, or database/migrations.")
+	CmdMigrate.Flag.IntVar(&count, "n", 0, "Number of migrations to apply by up, or to roll back by down.")
+	CmdMigrate.Flag.BoolVar(&goFile, "go", false, "Create a Go migration instead of SQL files.")
+	CmdMigrate.Flag.Var(&fromConn, "from", "Connection string of the database to converge by diff, instead of the snapshot.")
+	CmdMigrate.Flag.StringVar(&snapshot, "snapshot", "", "Schema snapshot file. Defaults to schema.yml in the parent of the migrations directory.")
+	CmdMigrate.Flag.BoolVar(&allowDrop, "allow-drop", false, "Allow diff to create migrations dropping tables or columns, or changing column types.")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdMigrate)
+}
+
+// RunMigration 执行migrate的子命令
+func RunMigration(cmd *commands.Command, args []string) int {
+	subCmd := "up"
+	if len(args) > 0 {
+		subCmd, args = args[0], args[1:]
+	}
+	args = parseArgs(cmd, args)
+	setOptions()
+
+	switch subCmd {
+	case "create":
+		if len(args) != 1 {
+			beeLogger.Log.Fatal("Usage: bee migrate create NAME [-go]")
+		}
+		files, err := migrate.Create(dir, args[0], goFile, driver)
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not create migration: %s", err)
+		}
+		for _, f := range files {
+			beeLogger.Log.Infof("Created %s", f)
+		}
+		return 0
+	case "snapshot", "diff":
+		return runSchema(subCmd, args)
+	}
+
+	m, err := migrate.Open(driver, conn.String(), dir)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to the database: %s", err)
+	}
+	defer m.Close()
+
+	switch subCmd {
+	case "up":
+		done, err := m.Up(count)
+		logMigrations("Applied", done)
+		if err != nil {
+			beeLogger.Log.Fatalf("Migration failed: %s", err)
+		}
+		if len(done) == 0 {
+			beeLogger.Log.Info("Nothing to migrate")
+		}
+	case "down":
+		done, err := m.Down(count)
+		logMigrations("Rolled back", done)
+		if err != nil {
+			beeLogger.Log.Fatalf("Rollback failed: %s", err)
+		}
+		if len(done) == 0 {
+			beeLogger.Log.Info("Nothing to roll back")
+		}
+	case "redo":
+		mg, err := m.Redo()
+		if err != nil {
+			beeLogger.Log.Fatalf("Redo failed: %s", err)
+		}
+		beeLogger.Log.Infof("Redone %s_%s", mg.Version, mg.Name)
+	case "to":
+		if len(args) != 1 {
+			beeLogger.Log.Fatal("Usage: bee migrate to VERSION")
+		}
+		up, down, err := m.To(args[0])
+		logMigrations("Rolled back", down)
+		logMigrations("Applied", up)
+		if err != nil {
+			beeLogger.Log.Fatalf("Migration failed: %s", err)
+		}
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not read the migrations: %s", err)
+		}
+		printStatus(statuses)
+		return 0
+	default:
+		beeLogger.Log.Fatalf("Unknown migrate command '%s'. Must be one of [create|up|down|redo|to|status|snapshot|diff]", subCmd)
+	}
+	beeLogger.Log.Success("Migration successful!")
+	return 0
+}
+
+// parseArgs 解析参数并返回其中的非flag参数，flag可以在非flag参数之后，如 create NAME -go
+func parseArgs(cmd *commands.Command, args []string) (rest []string) {
+	for {
+		cmd.Flag.Parse(args)
+		args = cmd.Flag.Args()
+		if len(args) == 0 {
+			return
+		}
+		rest, args = append(rest, args[0]), args[1:]
+	}
+}
+
+// setOptions 未指定的参数取Beefile中的值
+func setOptions() {
+	if conn == "" {
+		conn = utils.DocValue(config.Conf.Database.Conn)
+		if conn == "" {
+			conn = "root:@tcp(127.0.0.1:3306)/test"
+		}
+	}
+	if driver == "" {
+		driver = config.Conf.Database.Driver
+	}
+	if dir == "" {
+		dir = config.Conf.Database.Dir
+		if dir == "" {
+			dir = filepath.Join("database", "migrations")
+		}
+	}
+	if snapshot == "" {
+		snapshot = filepath.Join(filepath.Dir(dir), "schema.yml")
+	}
+}
+
+// runSchema 执行snapshot及diff子命令
+func runSchema(subCmd string, args []string) int {
+	to := readSchema(conn.String())
+	if subCmd == "snapshot" {
+		if err := to.Save(snapshot); err != nil {
+			beeLogger.Log.Fatalf("Could not save the snapshot: %s", err)
+		}
+		beeLogger.Log.Successf("Saved the schema of %d tables to %s", len(to.Tables), snapshot)
+		return 0
+	}
+
+	if len(args) > 1 {
+		beeLogger.Log.Fatal("Usage: bee migrate diff [NAME] [-from=CONN] [-snapshot=FILE] [-allow-drop]")
+	}
+	name := "schema_diff"
+	if len(args) == 1 {
+		name = args[0]
+	}
+	var from *migrate.Schema
+	if fromConn != "" {
+		from = readSchema(fromConn.String())
+	} else if _, err := os.Stat(snapshot); os.IsNotExist(err) {
+		beeLogger.Log.Warnf("Snapshot %s does not exist, comparing with an empty schema", snapshot)
+		from = &migrate.Schema{Driver: driver}
+	} else if from, err = migrate.LoadSchema(snapshot); err != nil {
+		beeLogger.Log.Fatalf("Could not read the snapshot: %s", err)
+	}
+	if from.Driver != "" && from.Driver != driver {
+		beeLogger.Log.Fatalf("Cannot compare a %s schema with a %s database", from.Driver, driver)
+	}
+
+	up := migrate.Diff(from, to)
+	if len(up) == 0 {
+		beeLogger.Log.Info("Schema is up to date, no migration created")
+		return 0
+	}
+	var destructive int
+	for _, c := range up {
+		if c.Destructive {
+			destructive++
+			beeLogger.Log.Warnf("%s: %s", c.Comment, c.SQL)
+		}
+	}
+	if destructive > 0 && !allowDrop {
+		beeLogger.Log.Fatalf("The migration has %d destructive changes, re-run with -allow-drop to create it", destructive)
+	}
+	m, err := migrate.Open(driver, conn.String(), dir)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to the database: %s", err)
+	}
+	defer m.Close()
+	files, err := migrate.CreateSQL(dir, name, migrate.SQL(up), migrate.SQL(migrate.Diff(to, from)))
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not create migration: %s", err)
+	}
+	for _, f := range files {
+		beeLogger.Log.Infof("Created %s", f)
+	}
+	// 迁移的变化已在-c的数据库中，记录为已执行，否则之后的up会再次执行
+	if err = m.MarkApplied(files[0]); err != nil {
+		beeLogger.Log.Fatalf("Could not record the migration as applied: %s", err)
+	}
+	beeLogger.Log.Infof("Recorded %s as applied", strings.TrimSuffix(filepath.Base(files[0]), ".down.sql"))
+	// 与快照比较时更新快照，迁移及快照一同提交
+	if fromConn == "" {
+		if err = to.Save(snapshot); err != nil {
+			beeLogger.Log.Fatalf("Could not save the snapshot: %s", err)
+		}
+		beeLogger.Log.Infof("Updated %s", snapshot)
+	}
+	beeLogger.Log.Successf("Created a migration with %d changes", len(up))
+	return 0
+}
+
+func readSchema(connStr string) *migrate.Schema {
+	db, err := migrate.Connect(driver, connStr)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to the database: %s", err)
+	}
+	defer db.Close()
+	s, err := migrate.ReadSchema(db, driver)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the schema: %s", err)
+	}
+	return s
+}
+
+func logMigrations(action string, migrations []*migrate.Migration) {
+	for _, mg := range migrations {
+		beeLogger.Log.Infof("%s %s_%s", action, mg.Version, mg.Name)
+	}
+}
+
+func printStatus(statuses []*migrate.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, st := range statuses {
+		status, appliedAt := "pending", ""
+		if st.Applied {
+			status, appliedAt = "applied", st.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if st.Missing {
+			status = "applied, file missing"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", st.Version, st.Name, status, appliedAt)
+	}
+	w.Flush()
+}
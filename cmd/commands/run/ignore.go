@@ -0,0 +1,184 @@
+package run
+
+import (
+	"bufio"
+	"os"
+	path "path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignorePattern .gitignore的一行或Beefile中watch.exclude的一项
+type ignorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// compileIgnore 按.gitignore的规则编译pattern：不含/时匹配任意层级的名字，否则相对base；
+// **匹配任意层级，以/结尾时只匹配目录，以!开头时重新包含
+func compileIgnore(pattern string) *ignorePattern {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return nil
+	}
+	p := &ignorePattern{}
+	if strings.HasPrefix(pattern, "!") {
+		p.negate, pattern = true, pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		p.dirOnly, pattern = true, strings.TrimRight(pattern, "/")
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				b.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			if j := strings.IndexByte(pattern[i:], ']'); j > 0 {
+				b.WriteString(strings.Replace(pattern[i:i+j+1], "[!", "[^", 1))
+				i += j
+			} else {
+				b.WriteString(`\[`)
+			}
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	prefix := "^(.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+	// 第二个分组为目录之下的部分，目录被忽略时其中的文件也被忽略
+	re, err := regexp.Compile(prefix + "(" + b.String() + ")(/.*)?$")
+	if err != nil {
+		return nil
+	}
+	p.re = re
+	return p
+}
+
+// match rel为相对pattern所在目录、以/分隔的路径
+func (p *ignorePattern) match(rel string, isDir bool) bool {
+	m := p.re.FindStringSubmatch(rel)
+	if m == nil {
+		return false
+	}
+	// dir/只匹配目录本身或其中的文件
+	return !p.dirOnly || isDir || m[len(m)-1] != ""
+}
+
+// watchFilter 不监视的目录及文件：.gitignore、Beefile中watch.exclude、-exclude，
+// 以及docs、swagger、vendor（没有-vendor时）和隐藏的目录
+type watchFilter struct {
+	root string
+	// excludes Beefile中watch.exclude的规则，相对root，先于root的.gitignore
+	excludes []*ignorePattern
+	// ignores 目录 => 其中.gitignore的规则
+	ignores map[string][]*ignorePattern
+}
+
+func newWatchFilter(root string, excludes []string) *watchFilter {
+	f := &watchFilter{root: root, ignores: make(map[string][]*ignorePattern)}
+	for _, e := range excludes {
+		if p := compileIgnore(e); p != nil {
+			f.excludes = append(f.excludes, p)
+		}
+	}
+	f.loadGitignore(root)
+	return f
+}
+
+// loadGitignore 读取dir中的.gitignore，遍历到该目录时调用，替换该目录之前读取的规则
+func (f *watchFilter) loadGitignore(dir string) {
+	file, err := os.Open(path.Join(dir, ".gitignore"))
+	if err != nil {
+		delete(f.ignores, dir)
+		return
+	}
+	defer file.Close()
+	var patterns []*ignorePattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if p := compileIgnore(scanner.Text()); p != nil {
+			patterns = append(patterns, p)
+		}
+	}
+	f.ignores[dir] = patterns
+}
+
+// forget 目录不再监视时丢弃其.gitignore的规则
+func (f *watchFilter) forget(dir string) {
+	delete(f.ignores, dir)
+}
+
+// excluded name是否不被监视
+func (f *watchFilter) excluded(name string, isDir bool) bool {
+	base := path.Base(name)
+	if isDir && (strings.HasPrefix(base, ".") && base != "." ||
+		strings.HasSuffix(base, "docs") || strings.HasSuffix(base, "swagger") ||
+		!vendorWatch && strings.HasSuffix(base, "vendor")) {
+		return true
+	}
+	if isExcluded(name) {
+		return true
+	}
+	// 由浅到深，后匹配的规则优先
+	ignored := false
+	for _, dir := range f.parents(name) {
+		rel, err := path.Rel(dir, name)
+		if err != nil {
+			continue
+		}
+		rel = path.ToSlash(rel)
+		var patterns []*ignorePattern
+		if dir == f.root {
+			patterns = append(patterns, f.excludes...)
+		}
+		patterns = append(patterns, f.ignores[dir]...)
+		for _, p := range patterns {
+			if p.match(rel, isDir) {
+				ignored = !p.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// parents root及name在root中的上级目录，由浅到深
+func (f *watchFilter) parents(name string) []string {
+	rel, err := path.Rel(f.root, path.Dir(name))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	dirs := []string{f.root}
+	if rel == "." {
+		return dirs
+	}
+	dir := f.root
+	for _, part := range strings.Split(path.ToSlash(rel), "/") {
+		dir = path.Join(dir, part)
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
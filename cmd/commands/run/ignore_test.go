@@ -0,0 +1,141 @@
+package run
+
+import (
+	"io/ioutil"
+	"os"
+	path "path/filepath"
+	"testing"
+)
+
+func TestCompileIgnore(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		isDir   bool
+		want    bool
+	}{
+		{"*.log", "a.log", false, true},
+		{"*.log", "sub/a.log", false, true},
+		{"*.log", "a.logx", false, false},
+		// 含/时相对.gitignore所在目录
+		{"/build", "build", true, true},
+		{"/build", "build/app", false, true},
+		{"/build", "src/build", true, false},
+		{"docs/api", "docs/api", true, true},
+		{"docs/api", "src/docs/api", true, false},
+		// 以/结尾时只匹配目录及其中的文件
+		{"tmp/", "tmp", true, true},
+		{"tmp/", "tmp", false, false},
+		{"tmp/", "tmp/a.go", false, true},
+		{"tmp/", "src/tmp", true, true},
+		{"**/gen", "gen", true, true},
+		{"**/gen", "a/b/gen", true, true},
+		{"a/**/b", "a/b", true, true},
+		{"a/**/b", "a/x/y/b", true, true},
+		{"a/**/b", "x/a/b", true, false},
+		{"?.go", "a.go", false, true},
+		{"?.go", "ab.go", false, false},
+		{"[!a]x", "bx", false, true},
+		{"[!a]x", "ax", false, false},
+		{`\#file`, "#file", false, true},
+	}
+	for _, tt := range tests {
+		p := compileIgnore(tt.pattern)
+		if p == nil {
+			t.Errorf("compileIgnore(%q) = nil", tt.pattern)
+			continue
+		}
+		if got := p.match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("compileIgnore(%q).match(%q, %v) = %v, want %v", tt.pattern, tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+	if p := compileIgnore("!keep.log"); p == nil || !p.negate || !p.match("keep.log", false) {
+		t.Errorf("compileIgnore(!keep.log) = %+v", p)
+	}
+	for _, pattern := range []string{"", "  ", "# comment", "/", "!"} {
+		if p := compileIgnore(pattern); p != nil {
+			t.Errorf("compileIgnore(%q) = %+v, want nil", pattern, p)
+		}
+	}
+}
+
+func writeGitignore(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path.Join(dir, ".gitignore"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// excludeCase name为相对root的路径
+type excludeCase struct {
+	name  string
+	isDir bool
+	want  bool
+}
+
+func TestWatchFilterExcluded(t *testing.T) {
+	root, err := ioutil.TempDir("", "bee-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	sub := path.Join(root, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeGitignore(t, root, "*.log\n!keep.log\ntmp/\n")
+	writeGitignore(t, sub, "!sub.log\n*.gen.go\n")
+
+	f := newWatchFilter(root, []string{"/build"})
+	f.loadGitignore(sub)
+	check := func(when string, tests []excludeCase) {
+		t.Helper()
+		for _, tt := range tests {
+			if got := f.excluded(path.Join(root, tt.name), tt.isDir); got != tt.want {
+				t.Errorf("%s: excluded(%s, %v) = %v, want %v", when, tt.name, tt.isDir, got, tt.want)
+			}
+		}
+	}
+	check("loaded", []excludeCase{
+		{"a.log", false, true},
+		{"keep.log", false, false},
+		{"sub/x.log", false, true},
+		// 下级目录的.gitignore优先
+		{"sub/sub.log", false, false},
+		{"sub/a.gen.go", false, true},
+		{"a.gen.go", false, false},
+		{"tmp", true, true},
+		{"tmp", false, false},
+		{"sub/tmp", true, true},
+		// watch.exclude相对root
+		{"build", true, true},
+		{"sub/build", true, false},
+		{".git", true, true},
+		{"docs", true, true},
+		{"vendor", true, true},
+		{"main.go", false, false},
+	})
+
+	// 修改后重新读取，替换之前的规则
+	writeGitignore(t, sub, "!sub.log\n")
+	f.loadGitignore(sub)
+	check("reloaded", []excludeCase{
+		{"sub/a.gen.go", false, false},
+		{"sub/sub.log", false, false},
+	})
+
+	if err := os.Remove(path.Join(sub, ".gitignore")); err != nil {
+		t.Fatal(err)
+	}
+	f.loadGitignore(sub)
+	check("removed", []excludeCase{
+		{"sub/sub.log", false, true},
+	})
+
+	writeGitignore(t, sub, "!sub.log\n")
+	f.loadGitignore(sub)
+	f.forget(sub)
+	check("forgotten", []excludeCase{
+		{"sub/sub.log", false, true},
+	})
+}
@@ -0,0 +1,135 @@
+package run
+
+import (
+	"io/ioutil"
+	"os"
+	path "path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fileWatcher 监视目录中文件的变化，为fsnotify或轮询
+type fileWatcher interface {
+	Add(dir string) error
+	Remove(dir string) error
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+	Close() error
+}
+
+// notifyWatcher fsnotify的Watcher
+type notifyWatcher struct {
+	w *fsnotify.Watcher
+}
+
+func (nw *notifyWatcher) Add(dir string) error          { return nw.w.Add(dir) }
+func (nw *notifyWatcher) Remove(dir string) error       { return nw.w.Remove(dir) }
+func (nw *notifyWatcher) Events() <-chan fsnotify.Event { return nw.w.Events }
+func (nw *notifyWatcher) Errors() <-chan error          { return nw.w.Errors }
+func (nw *notifyWatcher) Close() error                  { return nw.w.Close() }
+
+// pollWatcher 每隔interval比较一次目录中文件的修改时间及大小，用于inotify等不可用的文件系统
+type pollWatcher struct {
+	interval time.Duration
+	mu       sync.Mutex
+	dirs     map[string]map[string]os.FileInfo
+	events   chan fsnotify.Event
+	errors   chan error
+	done     chan struct{}
+}
+
+func newPollWatcher(interval time.Duration) *pollWatcher {
+	pw := &pollWatcher{interval: interval, dirs: make(map[string]map[string]os.FileInfo),
+		events: make(chan fsnotify.Event), errors: make(chan error), done: make(chan struct{})}
+	go pw.run()
+	return pw
+}
+
+func (pw *pollWatcher) Add(dir string) error {
+	entries, err := readEntries(dir)
+	if err != nil {
+		return err
+	}
+	pw.mu.Lock()
+	pw.dirs[dir] = entries
+	pw.mu.Unlock()
+	return nil
+}
+
+func (pw *pollWatcher) Remove(dir string) error {
+	pw.mu.Lock()
+	delete(pw.dirs, dir)
+	pw.mu.Unlock()
+	return nil
+}
+
+func (pw *pollWatcher) Events() <-chan fsnotify.Event { return pw.events }
+func (pw *pollWatcher) Errors() <-chan error          { return pw.errors }
+
+func (pw *pollWatcher) Close() error {
+	close(pw.done)
+	return nil
+}
+
+func (pw *pollWatcher) run() {
+	ticker := time.NewTicker(pw.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pw.done:
+			return
+		case <-ticker.C:
+		}
+		// 在锁外发送事件，接收方可以在处理事件时调用Add、Remove
+		for _, e := range pw.scan() {
+			select {
+			case pw.events <- e:
+			case <-pw.done:
+				return
+			}
+		}
+	}
+}
+
+// scan 比较所有目录当前的文件与上一次的，返回变化
+func (pw *pollWatcher) scan() (events []fsnotify.Event) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	for dir, old := range pw.dirs {
+		cur, err := readEntries(dir)
+		if err != nil {
+			// 目录已删除
+			delete(pw.dirs, dir)
+			events = append(events, fsnotify.Event{Name: dir, Op: fsnotify.Remove})
+			continue
+		}
+		for name, fi := range cur {
+			if prev, ok := old[name]; !ok {
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Create})
+			} else if !fi.IsDir() && (!fi.ModTime().Equal(prev.ModTime()) || fi.Size() != prev.Size()) {
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Write})
+			}
+		}
+		for name := range old {
+			if _, ok := cur[name]; !ok {
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Remove})
+			}
+		}
+		pw.dirs[dir] = cur
+	}
+	return
+}
+
+func readEntries(dir string) (map[string]os.FileInfo, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]os.FileInfo, len(fis))
+	for _, fi := range fis {
+		entries[path.Join(dir, fi.Name())] = fi
+	}
+	return entries, nil
+}
@@ -14,6 +14,7 @@
 package run
 
 import (
+	"flag"
 	"io/ioutil"
 	"os"
 	path "path/filepath"
@@ -28,11 +29,58 @@ import (
 )
 
 var CmdRun = &commands.Command{
-	UsageLine: "run",
+	UsageLine: "run [-main=*.go] [-tags=goBuildTags] [-runmode=BEEGO_RUNMODE] [-runargs=ARGS] [-exclude=PATH] [-ex=PACKAGE] [-vendor] [-gendoc]",
 	Short:     "Run the application by starting a local development server",
 	Long: `
 Run command will supervise the filesystem of the application for any changes, and recompile/restart it.
 
+  ▶ {{"To build only the given main files instead of the package:"|bold}}
+
+     $ bee run -main=main.go -main=wire_gen.go
+
+  ▶ {{"To pass build tags to go build, and set BEEGO_RUNMODE of the application:"|bold}}
+
+     $ bee run -tags="jsoniter netgo" -runmode=prod
+
+  ▶ {{"To run the application with extra arguments, instead of cmd_args in Beefile:"|bold}}
+
+     $ bee run -runargs="-port 8081 -v"
+
+  ▶ {{"To stop watching a path, which can be given more than once:"|bold}}
+
+     $ bee run -exclude=static/uploads
+
+  ▶ {{"To also watch an extra package in GOPATH, and the vendor folder:"|bold}}
+
+     $ bee run -ex=github.com/me/shared -vendor
+
+  ▶ {{"To generate the docs before every build:"|bold}}
+
+     $ bee run -gendoc
+
+Every option can also be set in the run section of Beefile, the flags take precedence:
+
+  run:
+    main: [main.go]
+    tags: "jsoniter"
+    runmode: dev
+    runargs: "-port 8081"
+    exclude: [static/uploads]
+    ex: [github.com/me/shared]
+    vendor: false
+    gendoc: false
+
+The hooks of Beefile run a script of scripts, or a shell command, with the envs of Beefile.
+A failing pre_build aborts the build like a compile error, and runs on_build_failure:
+
+  hooks:
+    pre_build: "go generate ./..."
+    post_build: rule
+    pre_start: ""
+    post_start: ""
+    on_build_failure: ""
+  scripts:
+    rule: "bee g rule"
 `,
 	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
 	Run:    RunApp,
@@ -40,7 +88,8 @@ Run command will supervise the filesystem of the application for any changes, an
 
 var (
 	mainFiles utils.ListOpts
-	gendoc    utils.DocValue
+	// Generate the docs before building
+	gendoc bool
 	// The flags list of the paths excluded from watching
 	excludedPaths utils.StrFlags
 	// Pass through to -tags arg of "go build"
@@ -61,14 +110,55 @@ var (
 	runargs string
 	// Extra directories
 	extraPackages utils.StrFlags
+	// Paths excluded by .gitignore, watch.exclude and the flags
+	appFilter *watchFilter
 )
-var started = make(chan bool)
 
 func init() {
+	CmdRun.Flag.Var(&mainFiles, "main", "Main go file to build, can be given more than once. Defaults to run.main in Beefile, or the package.")
+	CmdRun.Flag.StringVar(&buildTags, "tags", "", "Build tags passed to go build. Defaults to run.tags in Beefile.")
+	CmdRun.Flag.StringVar(&runmode, "runmode", "", "BEEGO_RUNMODE of the application, such as dev or prod. Defaults to run.runmode in Beefile.")
+	CmdRun.Flag.StringVar(&runargs, "runargs", "", "Extra args to run the application. Defaults to run.runargs in Beefile, or cmd_args.")
+	CmdRun.Flag.Var(&excludedPaths, "exclude", "Path excluded from watching, can be given more than once. Defaults to run.exclude in Beefile.")
+	CmdRun.Flag.Var(&excludedPaths, "e", "Short for -exclude.")
+	CmdRun.Flag.Var(&extraPackages, "ex", "Extra package in GOPATH to watch, can be given more than once. Defaults to run.ex in Beefile.")
+	CmdRun.Flag.BoolVar(&vendorWatch, "vendor", false, "Watch the vendor folder. Defaults to run.vendor in Beefile.")
+	CmdRun.Flag.BoolVar(&gendoc, "gendoc", false, "Generate the docs with bee generate docs before every build. Defaults to run.gendoc in Beefile.")
 	exit = make(chan bool)
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdRun)
 }
 
+// setRunOptions 未在命令行指定的选项取Beefile中run的值
+func setRunOptions(cmd *commands.Command) {
+	set := make(map[string]bool)
+	cmd.Flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	conf := config.Conf.Run
+	if !set["main"] {
+		mainFiles = conf.Main
+	}
+	if !set["tags"] {
+		buildTags = conf.Tags
+	}
+	if !set["runmode"] {
+		runmode = conf.Runmode
+	}
+	if !set["runargs"] {
+		runargs = conf.Runargs
+	}
+	if !set["exclude"] && !set["e"] {
+		excludedPaths = conf.Exclude
+	}
+	if !set["ex"] {
+		extraPackages = conf.Ex
+	}
+	if !set["vendor"] {
+		vendorWatch = conf.Vendor
+	}
+	if !set["gendoc"] {
+		gendoc = conf.Gendoc
+	}
+}
+
 // RunApp 找到要监视的文件，然后启动beego应用程序
 func RunApp(cmd *commands.Command, args []string) int {
 	// The default app path is the current working directory
@@ -88,6 +178,8 @@ func RunApp(cmd *commands.Command, args []string) int {
 		currentGoPath = appPath
 	}
 
+	currpath = appPath
+	setRunOptions(cmd)
 	beeLogger.Log.Infof("Using '%s' as 'appname'", appname)
 
 	beeLogger.Log.Debugf("Current path: %s", utils.FILE(), utils.LINE(), appPath)
@@ -102,6 +194,7 @@ func RunApp(cmd *commands.Command, args []string) int {
 		beeLogger.Log.Warnf("Using '%s' as 'runmode'", os.Getenv("BEEGO_RUNMODE"))
 	}
 
+	appFilter = newWatchFilter(appPath, config.Conf.Watch.Exclude)
 	var paths []string
 	readAppDirectories(appPath, &paths)
 
@@ -144,13 +237,8 @@ func RunApp(cmd *commands.Command, args []string) int {
 	if config.Conf.EnableReload {
 		startReloadServer()
 	}
-	if gendoc == "true" {
-		NewWatcher(paths, files, true)
-		AutoBuild(files, true)
-	} else {
-		NewWatcher(paths, files, false)
-		AutoBuild(files, false)
-	}
+	NewWatcher(paths, files, gendoc)
+	AutoBuild(files, gendoc)
 
 	for {
 		<-exit
@@ -158,41 +246,19 @@ func RunApp(cmd *commands.Command, args []string) int {
 	}
 }
 
+// readAppDirectories 把directory及其中所有没有被排除的目录加入paths
 func readAppDirectories(directory string, paths *[]string) {
 	fileInfos, err := ioutil.ReadDir(directory)
 	if err != nil {
 		return
 	}
+	appFilter.loadGitignore(directory)
+	*paths = append(*paths, directory)
 
-	useDirectory := false
 	for _, fileInfo := range fileInfos {
-		if strings.HasSuffix(fileInfo.Name(), "docs") {
-			continue
-		}
-		if strings.HasSuffix(fileInfo.Name(), "swagger") {
-			continue
-		}
-
-		if !vendorWatch && strings.HasSuffix(fileInfo.Name(), "vendor") {
-			continue
-		}
-
-		if isExcluded(path.Join(directory, fileInfo.Name())) {
-			continue
-		}
-
-		if fileInfo.IsDir() && fileInfo.Name()[0] != '.' {
-			readAppDirectories(directory+"/"+fileInfo.Name(), paths)
-			continue
-		}
-
-		if useDirectory {
-			continue
-		}
-
-		if path.Ext(fileInfo.Name()) == ".go" || (ifStaticFile(fileInfo.Name()) && config.Conf.EnableReload) {
-			*paths = append(*paths, directory)
-			useDirectory = true
+		name := path.Join(directory, fileInfo.Name())
+		if fileInfo.IsDir() && !appFilter.excluded(name, true) {
+			readAppDirectories(name, paths)
 		}
 	}
 }
@@ -211,7 +277,7 @@ func isExcluded(filePath string) bool {
 			break
 		}
 		if strings.HasPrefix(absFilePath, absP) {
-			beeLogger.Log.Infof("'%s' is not being watched", filePath)
+			beeLogger.Log.Debugf("'%s' is not being watched", utils.FILE(), utils.LINE(), filePath)
 			return true
 		}
 	}
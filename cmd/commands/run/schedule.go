@@ -0,0 +1,113 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultDebounce 最后一次文件变化之后等待的时间，Beefile中watch.debounce可修改
+const defaultDebounce = time.Second
+
+// buildScheduler 把debounce时间内的一批文件变化合并为一次构建，
+// 构建过程中又有变化时取消该构建，等变化停止后重新构建
+type buildScheduler struct {
+	debounce time.Duration
+	// build 构建并重启，changed为触发构建的文件，ctx取消时应尽快返回，
+	// 返回canceled表示因ctx取消而没有完成，这些文件并入下一次构建
+	build func(ctx context.Context, changed []string) (canceled bool)
+}
+
+func newBuildScheduler(debounce time.Duration, build func(ctx context.Context, changed []string) bool) *buildScheduler {
+	if debounce <= 0 {
+		debounce = defaultDebounce
+	}
+	return &buildScheduler{debounce: debounce, build: build}
+}
+
+// run 读取events中变化的文件直到events关闭，关闭时取消并等待进行中的构建。
+// 同一时间只有一个构建，状态只在这个goroutine中修改
+func (s *buildScheduler) run(events <-chan string) {
+	pending := make(map[string]bool)
+	var (
+		timer   *time.Timer
+		timeout <-chan time.Time
+		cancel  context.CancelFunc
+		// running 进行中的构建结束时收到其是否被取消
+		running chan bool
+		// building 进行中的构建的文件，构建被取消时并入下一次
+		building []string
+	)
+	// finish 构建结束，只有真正被取消时才把其文件并入下一次，已完成的构建即使ctx已取消也不再重复
+	finish := func(canceled bool) {
+		if canceled {
+			for _, name := range building {
+				pending[name] = true
+			}
+		}
+		cancel()
+		cancel, running, building = nil, nil, nil
+	}
+	for {
+		select {
+		case name, ok := <-events:
+			if !ok {
+				if timer != nil {
+					timer.Stop()
+				}
+				if cancel != nil {
+					cancel()
+					<-running
+				}
+				return
+			}
+			pending[name] = true
+			if cancel != nil {
+				// 构建可能已经完成，是否并入下一次由其结果决定
+				cancel()
+			}
+			if timer == nil {
+				timer = time.NewTimer(s.debounce)
+			} else {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(s.debounce)
+			}
+			timeout = timer.C
+		case <-timeout:
+			timeout = nil
+			if running != nil {
+				// 等待被取消的构建退出
+				finish(<-running)
+			}
+			changed := make([]string, 0, len(pending))
+			for name := range pending {
+				changed = append(changed, name)
+			}
+			sort.Strings(changed)
+			pending, building = make(map[string]bool), changed
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			running = make(chan bool, 1)
+			go func(done chan<- bool) {
+				done <- s.build(ctx, changed)
+			}(running)
+		case canceled := <-running:
+			finish(canceled)
+		}
+	}
+}
+
+// changedFiles 触发构建的文件，多于max个时只列出前max个
+func changedFiles(changed []string, max int) string {
+	if len(changed) <= max {
+		return strings.Join(changed, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(changed[:max], ", "), len(changed)-max)
+}
@@ -0,0 +1,129 @@
+package run
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testDebounce = 20 * time.Millisecond
+
+// fakeBuild 记录每次构建的文件。block为false时立即完成；否则等待ctx取消，
+// complete时之后再等待release并返回已完成，模拟取消之前已经完成的构建
+type fakeBuild struct {
+	started  chan []string
+	block    bool
+	complete bool
+	release  chan struct{}
+}
+
+func newFakeBuild(block, complete bool) *fakeBuild {
+	return &fakeBuild{started: make(chan []string, 10), block: block, complete: complete, release: make(chan struct{})}
+}
+
+func (fb *fakeBuild) build(ctx context.Context, changed []string) bool {
+	fb.started <- changed
+	if !fb.block {
+		return false
+	}
+	<-ctx.Done()
+	if fb.complete {
+		<-fb.release
+		return false
+	}
+	return true
+}
+
+func (fb *fakeBuild) next(t *testing.T) []string {
+	t.Helper()
+	select {
+	case changed := <-fb.started:
+		return changed
+	case <-time.After(2 * time.Second):
+		t.Fatal("no build started")
+		return nil
+	}
+}
+
+func (fb *fakeBuild) none(t *testing.T) {
+	t.Helper()
+	select {
+	case changed := <-fb.started:
+		t.Fatalf("unexpected build of %v", changed)
+	case <-time.After(5 * testDebounce):
+	}
+}
+
+// startScheduler 返回事件源及等待run返回的函数
+func startScheduler(fb *fakeBuild) (chan<- string, func()) {
+	events := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		newBuildScheduler(testDebounce, fb.build).run(events)
+	}()
+	return events, func() {
+		close(events)
+		<-done
+	}
+}
+
+func TestSchedulerCoalescesBurst(t *testing.T) {
+	fb := newFakeBuild(false, false)
+	events, stop := startScheduler(fb)
+	defer stop()
+
+	for _, name := range []string{"c.go", "a.go", "b.go", "a.go"} {
+		events <- name
+	}
+	if got, want := fb.next(t), []string{"a.go", "b.go", "c.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("build of %v, want %v", got, want)
+	}
+	fb.none(t)
+}
+
+func TestSchedulerCancelsRunningBuild(t *testing.T) {
+	fb := newFakeBuild(true, false)
+	events, stop := startScheduler(fb)
+	defer stop()
+
+	events <- "a.go"
+	if got := fb.next(t); !reflect.DeepEqual(got, []string{"a.go"}) {
+		t.Fatalf("first build of %v", got)
+	}
+	// 构建过程中的变化取消该构建，其文件并入下一次
+	events <- "b.go"
+	if got, want := fb.next(t), []string{"a.go", "b.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("build after cancel of %v, want %v", got, want)
+	}
+	fb.none(t)
+}
+
+func TestSchedulerKeepsCompletedBuild(t *testing.T) {
+	fb := newFakeBuild(true, true)
+	events, stop := startScheduler(fb)
+	defer stop()
+
+	events <- "a.go"
+	fb.next(t)
+	// ctx已被新的变化取消，但构建随后完成，只有新的文件需要再构建
+	events <- "b.go"
+	fb.release <- struct{}{}
+	if got, want := fb.next(t), []string{"b.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("build after a completed build of %v, want %v", got, want)
+	}
+	fb.none(t)
+	// 第二次构建在stop时取消后完成
+	close(fb.release)
+}
+
+func TestSchedulerStopCancelsBuild(t *testing.T) {
+	fb := newFakeBuild(true, false)
+	events, stop := startScheduler(fb)
+
+	events <- "a.go"
+	fb.next(t)
+	// 关闭事件源时取消并等待进行中的构建
+	stop()
+}
@@ -16,8 +16,11 @@ package run
 
 import (
 	"bytes"
+	"context"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
@@ -34,8 +37,6 @@ import (
 var (
 	cmd                 *exec.Cmd
 	state               sync.Mutex
-	eventTime           = make(map[string]int64)
-	scheduleTime        time.Time
 	watchExts           = config.Conf.WatchExts
 	watchExtsStatic     = config.Conf.WatchExtsStatic
 	ignoredFilesRegExps = []string{
@@ -47,72 +48,176 @@ var (
 	}
 )
 
-// NewWatcher starts an fsnotify Watcher on the specified paths
+// NewWatcher starts an fsnotify Watcher on the specified paths,
+// falling back to polling when fsnotify is not available
 func NewWatcher(paths []string, files []string, isgenerate bool) {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		beeLogger.Log.Fatalf("Failed to create watcher: %s", err)
+	var err error
+	debounce := defaultDebounce
+	if config.Conf.Watch.Debounce != "" {
+		if debounce, err = time.ParseDuration(config.Conf.Watch.Debounce); err != nil {
+			beeLogger.Log.Fatalf("Invalid watch.debounce '%s' in Beefile: %s", config.Conf.Watch.Debounce, err)
+		}
+	}
+	changes := make(chan string)
+	scheduler := newBuildScheduler(debounce, func(ctx context.Context, changed []string) bool {
+		beeLogger.Log.Infof("Rebuilding for %d changed files: %s", len(changed), changedFiles(changed, 5))
+		restarted, canceled := buildApp(ctx, files, isgenerate)
+		if restarted && config.Conf.EnableReload {
+			// Wait 100ms more before refreshing the browser
+			time.Sleep(100 * time.Millisecond)
+			sendReload(strings.Join(changed, ","))
+		}
+		return canceled
+	})
+	go scheduler.run(changes)
+
+	beeLogger.Log.Info("Initializing watcher...")
+	watcher := newFileWatcher(paths)
+	// 监视中的目录，启动后只在下面的goroutine中读写
+	watched := make(map[string]bool)
+	for _, p := range paths {
+		watched[p] = true
 	}
 
 	go func() {
+		// 文件的修改时间，只在这个goroutine中读写
+		eventTime := make(map[string]int64)
 		for {
 			select {
-			case e := <-watcher.Events:
-				isBuild := true
+			case e := <-watcher.Events():
+				if e.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+					delete(eventTime, e.Name)
+					if watched[e.Name] {
+						unwatchDir(watcher, watched, e.Name)
+						changes <- e.Name
+						continue
+					}
+				} else if e.Op&fsnotify.Create != 0 {
+					if fi, err := os.Stat(e.Name); err == nil && fi.IsDir() {
+						if !watched[e.Name] && !appFilter.excluded(e.Name, true) {
+							watchNewDir(watcher, watched, e.Name, changes)
+						}
+						continue
+					}
+				}
+
+				if filepath.Base(e.Name) == ".gitignore" && watched[filepath.Dir(e.Name)] {
+					// 重新读取该目录的.gitignore，之后的变化按新的规则排除
+					appFilter.loadGitignore(filepath.Dir(e.Name))
+					continue
+				}
 
 				if ifStaticFile(e.Name) && config.Conf.EnableReload {
 					sendReload(e.String())
 					continue
 				}
 				// Skip ignored files
-				if shouldIgnoreFile(e.Name) {
+				if shouldIgnoreFile(e.Name) || appFilter.excluded(e.Name, false) {
 					continue
 				}
 				if !shouldWatchFileWithExtension(e.Name) {
 					continue
 				}
 
-				mt := utils.GetFileModTime(e.Name)
-				if t := eventTime[e.Name]; mt == t {
-					beeLogger.Log.Hintf(colors.Bold("Skipping: ")+"%s", e.String())
-					isBuild = false
+				if e.Op&(fsnotify.Remove|fsnotify.Rename) == 0 {
+					mt := utils.GetFileModTime(e.Name)
+					if t, ok := eventTime[e.Name]; ok && mt == t {
+						beeLogger.Log.Hintf(colors.Bold("Skipping: ")+"%s", e.String())
+						continue
+					}
+					eventTime[e.Name] = mt
 				}
 
-				eventTime[e.Name] = mt
-
-				if isBuild {
-					beeLogger.Log.Hintf("Event fired: %s", e)
-					go func() {
-						// Wait 1s before autobuild until there is no file change.
-						scheduleTime = time.Now().Add(1 * time.Second)
-						time.Sleep(time.Until(scheduleTime))
-						AutoBuild(files, isgenerate)
-
-						if config.Conf.EnableReload {
-							// Wait 100ms more before refreshing the browser
-							time.Sleep(100 * time.Millisecond)
-							sendReload(e.String())
-						}
-					}()
-				}
-			case err := <-watcher.Errors:
+				beeLogger.Log.Hintf("Event fired: %s", e)
+				changes <- e.Name
+			case err := <-watcher.Errors():
 				beeLogger.Log.Warnf("Watcher error: %s", err.Error()) // No need to exit here
 			}
 		}
 	}()
+}
 
-	beeLogger.Log.Info("Initializing watcher...")
+// newFileWatcher 监视paths，fsnotify不可用或Beefile中watch.poll为true时轮询
+func newFileWatcher(paths []string) fileWatcher {
+	if !config.Conf.Watch.Poll {
+		w, err := fsnotify.NewWatcher()
+		if err == nil {
+			watcher := &notifyWatcher{w: w}
+			if err = addPaths(watcher, paths); err == nil {
+				return watcher
+			}
+			watcher.Close()
+		}
+		beeLogger.Log.Warnf("Failed to watch with fsnotify, polling instead: %s", err)
+	}
+	interval := time.Second
+	if config.Conf.Watch.PollInterval != "" {
+		var err error
+		if interval, err = time.ParseDuration(config.Conf.Watch.PollInterval); err != nil || interval <= 0 {
+			beeLogger.Log.Fatalf("Invalid watch.poll_interval '%s' in Beefile", config.Conf.Watch.PollInterval)
+		}
+	}
+	watcher := newPollWatcher(interval)
+	if err := addPaths(watcher, paths); err != nil {
+		beeLogger.Log.Fatalf("Failed to watch directory: %s", err)
+	}
+	return watcher
+}
+
+func addPaths(watcher fileWatcher, paths []string) error {
 	for _, path := range paths {
 		beeLogger.Log.Hintf(colors.Bold("Watching: ")+"%s", path)
-		err = watcher.Add(path)
-		if err != nil {
-			beeLogger.Log.Fatalf("Failed to watch directory: %s", err)
+		if err := watcher.Add(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchNewDir 监视新建的目录及其子目录，监视之前已在其中的文件也作为变化
+func watchNewDir(watcher fileWatcher, watched map[string]bool, dir string, changes chan<- string) {
+	var dirs []string
+	readAppDirectories(dir, &dirs)
+	for _, d := range dirs {
+		if watched[d] {
+			continue
+		}
+		beeLogger.Log.Hintf(colors.Bold("Watching: ")+"%s", d)
+		if err := watcher.Add(d); err != nil {
+			beeLogger.Log.Warnf("Failed to watch directory: %s", err)
+			continue
+		}
+		watched[d] = true
+		fis, _ := ioutil.ReadDir(d)
+		for _, fi := range fis {
+			name := filepath.Join(d, fi.Name())
+			if !fi.IsDir() && shouldWatchFileWithExtension(name) && !shouldIgnoreFile(name) && !appFilter.excluded(name, false) {
+				changes <- name
+			}
+		}
+	}
+}
+
+// unwatchDir 不再监视删除的目录及其子目录
+func unwatchDir(watcher fileWatcher, watched map[string]bool, dir string) {
+	for d := range watched {
+		if d == dir || strings.HasPrefix(d, dir+string(filepath.Separator)) {
+			// 目录删除时fsnotify已不再监视，忽略错误
+			watcher.Remove(d)
+			delete(watched, d)
+			appFilter.forget(d)
+			beeLogger.Log.Hintf(colors.Bold("Unwatching: ")+"%s", d)
 		}
 	}
 }
 
 // AutoBuild builds the specified set of files
 func AutoBuild(files []string, isgenerate bool) {
+	buildApp(context.Background(), files, isgenerate)
+}
+
+// buildApp 构建并重启应用，返回是否已重启；ctx取消时停止构建且不重启，canceled为true
+func buildApp(ctx context.Context, files []string, isgenerate bool) (restarted, canceled bool) {
 	state.Lock()
 	defer state.Unlock()
 
@@ -124,25 +229,36 @@ func AutoBuild(files []string, isgenerate bool) {
 		err    error
 		stderr bytes.Buffer
 	)
+	if err = runHook(ctx, "pre_build", config.Conf.Hooks.PreBuild); err != nil {
+		if ctx.Err() != nil {
+			return buildCanceled()
+		}
+		buildFailed("Failed to run the pre_build hook", err.Error())
+		return false, false
+	}
+
 	// For applications use full import path like "github.com/.../.."
 	// are able to use "go install" to reduce build time.
 	if config.Conf.GoInstall {
-		icmd := exec.Command(cmdName, "install", "-v")
+		icmd := exec.CommandContext(ctx, cmdName, "install", "-v")
 		icmd.Stdout = os.Stdout
 		icmd.Stderr = os.Stderr
 		icmd.Env = append(os.Environ(), "GOGC=off")
 		icmd.Run()
 	}
 
-	if isgenerate {
+	if isgenerate && ctx.Err() == nil {
 		beeLogger.Log.Info("Generating the docs...")
-		icmd := exec.Command("bee", "generate", "docs")
+		icmd := exec.CommandContext(ctx, "bee", "generate", "docs")
 		icmd.Env = append(os.Environ(), "GOGC=off")
 		err = icmd.Run()
+		if ctx.Err() != nil {
+			return buildCanceled()
+		}
 		if err != nil {
 			utils.Notify("", "Failed to generate the docs.")
 			beeLogger.Log.Errorf("Failed to generate the docs.")
-			return
+			return false, false
 		}
 		beeLogger.Log.Success("Docs generated!")
 	}
@@ -160,19 +276,54 @@ func AutoBuild(files []string, isgenerate bool) {
 		}
 		args = append(args, files...)
 
-		bcmd := exec.Command(cmdName, args...)
+		bcmd := exec.CommandContext(ctx, cmdName, args...)
 		bcmd.Env = append(os.Environ(), "GOGC=off")
 		bcmd.Stderr = &stderr
 		err = bcmd.Run()
+		if ctx.Err() != nil {
+			return buildCanceled()
+		}
 		if err != nil {
-			utils.Notify(stderr.String(), "Build Failed")
-			beeLogger.Log.Errorf("Failed to build the application: %s", stderr.String())
-			return
+			buildFailed("Failed to build the application", stderr.String())
+			return false, false
 		}
 	}
+	if ctx.Err() != nil {
+		return buildCanceled()
+	}
 
 	beeLogger.Log.Success("Built Successfully!")
+	if err = runHook(ctx, "post_build", config.Conf.Hooks.PostBuild); err != nil {
+		if ctx.Err() != nil {
+			return buildCanceled()
+		}
+		beeLogger.Log.Warnf("The post_build hook failed: %s", err)
+	}
 	Restart(appName)
+	return true, false
+}
+
+// runHook 执行Beefile中hooks的一个钩子，未配置时什么也不做
+func runHook(ctx context.Context, name, script string) error {
+	if script == "" {
+		return nil
+	}
+	beeLogger.Log.Infof("Running the %s hook: %s", name, script)
+	return utils.RunScript(ctx, script, currpath)
+}
+
+// buildFailed 通知并报告构建失败，然后执行on_build_failure钩子
+func buildFailed(msg, output string) {
+	utils.Notify(output, "Build Failed")
+	beeLogger.Log.Errorf("%s: %s", msg, output)
+	if err := runHook(context.Background(), "on_build_failure", config.Conf.Hooks.OnBuildFailure); err != nil {
+		beeLogger.Log.Warnf("The on_build_failure hook failed: %s", err)
+	}
+}
+
+func buildCanceled() (restarted, canceled bool) {
+	beeLogger.Log.Info("Build canceled by new changes")
+	return false, true
 }
 
 // Kill kills the running command process
@@ -210,16 +361,23 @@ func Kill() {
 	}
 }
 
-// Restart kills the running command process and starts it again
+// Restart kills the running command process and starts it again.
+// It is called with state locked, so the hooks and cmd are never used by two builds at once
 func Restart(appname string) {
 	beeLogger.Log.Debugf("Kill running process", utils.FILE(), utils.LINE())
 	Kill()
-	go Start(appname)
+	Start(appname)
 }
 
-// Start starts the command process
+// Start starts the command process and returns once it is running, with state locked
 func Start(appname string) {
 	beeLogger.Log.Infof("Restarting '%s'...", appname)
+	if err := runHook(context.Background(), "pre_start", config.Conf.Hooks.PreStart); err != nil {
+		cmd = nil
+		utils.Notify(err.Error(), "Start Failed")
+		beeLogger.Log.Errorf("The pre_start hook failed, '%s' is not started: %s", appname, err)
+		return
+	}
 	if !strings.Contains(appname, "./") {
 		appname = "./" + appname
 	}
@@ -236,9 +394,18 @@ func Start(appname string) {
 	}
 	cmd.Env = append(os.Environ(), config.Conf.Envs...)
 
-	go cmd.Run()
+	// Start之后cmd.Process已设置，下一次构建的Kill一定能结束该进程
+	if err := cmd.Start(); err != nil {
+		cmd = nil
+		utils.Notify(err.Error(), "Start Failed")
+		beeLogger.Log.Errorf("Failed to start '%s': %s", appname, err)
+		return
+	}
+	go cmd.Wait()
 	beeLogger.Log.Successf("'%s' is running...", appname)
-	started <- true
+	if err := runHook(context.Background(), "post_start", config.Conf.Hooks.PostStart); err != nil {
+		beeLogger.Log.Warnf("The post_start hook failed: %s", err)
+	}
 }
 
 func ifStaticFile(filename string) bool {
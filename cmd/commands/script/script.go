@@ -0,0 +1,89 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"bee/cmd/commands"
+	"bee/config"
+	beeLogger "bee/logger"
+	"bee/logger/colors"
+	"bee/utils"
+)
+
+var CmdScript = &commands.Command{
+	UsageLine: "script [name]",
+	Short:     "Runs a script of Beefile with the project's envs",
+	Long: `
+  The scripts are shell commands named in the scripts of Beefile, and run in the current
+  directory with the envs of Beefile. The same names can be used by the hooks of bee run:
+
+     scripts:
+       proto: "protoc --go_out=. proto/*.proto"
+       rule: "bee g rule"
+     hooks:
+       pre_build: "go generate ./..."
+       post_build: rule
+       pre_start: ""
+       post_start: ""
+       on_build_failure: ""
+
+  ▶ {{"To run a script:"|bold}}
+
+     $ bee script proto
+
+  ▶ {{"To list the scripts:"|bold}}
+
+     $ bee script
+`,
+	Run: RunScript,
+}
+
+func init() {
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdScript)
+}
+
+// RunScript 执行Beefile中名为args[0]的script，返回script的退出码；没有参数时列出所有script
+func RunScript(cmd *commands.Command, args []string) int {
+	if len(args) == 0 {
+		listScripts()
+		return 0
+	}
+	if len(args) > 1 {
+		beeLogger.Log.Fatalf("Too many arguments, only the name of a script is allowed: %s", strings.Join(args, " "))
+	}
+	name := args[0]
+	if _, ok := config.Conf.Scripts[name]; !ok {
+		beeLogger.Log.Fatalf("No script named '%s' in the scripts of Beefile", name)
+	}
+	currpath, _ := os.Getwd()
+	beeLogger.Log.Infof("Running '%s': %s", name, utils.ScriptCommand(name))
+	if err := utils.RunScript(context.Background(), name, currpath); err != nil {
+		beeLogger.Log.Errorf("Script '%s' failed: %s", name, err)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		return 1
+	}
+	return 0
+}
+
+func listScripts() {
+	if len(config.Conf.Scripts) == 0 {
+		beeLogger.Log.Info("No scripts in Beefile")
+		return
+	}
+	names := make([]string, 0, len(config.Conf.Scripts))
+	for name := range config.Conf.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		beeLogger.Log.Infof("%s: %s", colors.Bold(name), config.Conf.Scripts[name])
+	}
+}
@@ -0,0 +1,139 @@
+package seed
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bee/cmd/commands"
+	"bee/cmd/commands/version"
+	"bee/config"
+	"bee/generate"
+	beeLogger "bee/logger"
+	"bee/migrate"
+	"bee/utils"
+)
+
+var CmdSeed = &commands.Command{
+	UsageLine: "seed [load]",
+	Short:     "Populates the database with fake data or fixtures",
+	Long: `
+  The fake data is generated from the columns of every table: sizes, decimals, enum and set
+  values, nullability and timestamps. The tables are filled in the order of their relations,
+  so the relation columns refer to existing rows, and the m2m through tables are filled too.
+
+  ▶ {{"To insert N fake rows into every table, or into the tables of -tables:"|bold}}
+
+     $ bee seed [-n=10] [-tables=user,team] [-c="root:@tcp(127.0.0.1:3306)/test"]
+
+  ▶ {{"To generate the same rows again, on a database with the same data:"|bold}}
+
+     $ bee seed -n=1000 -seed=42
+
+  ▶ {{"To insert the YAML fixtures of database/fixtures, or of the given files and directories:"|bold}}
+
+     $ bee seed load [FILE|DIR...] [-clean] [-driver=mysql]
+
+  A fixture file is a list of rows, inserted into the table named by the file, e.g. team.yml:
+
+     - id: 1
+       name: alpha
+
+  or a map from table names to lists of rows, inserted in the order of the file. With -clean
+  the existing rows of the fixture tables are deleted first.
+
+  Fake data is only supported on MySQL, the tables are read from its information_schema. On
+  PostgreSQL bee seed stops with an error, use bee seed load with fixtures instead.
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunSeed,
+}
+
+var (
+	conn   utils.DocValue
+	driver string
+	count  int
+	seed   int64
+	tables string
+	clean  bool
+)
+
+func init() {
+	CmdSeed.Flag.Var(&conn, "c", "Connection string of the database. Defaults to database.conn in Beefile.")
+	CmdSeed.Flag.StringVar(&driver, "driver", "", "Database driver of load, either mysql or postgres. Defaults to database.driver in Beefile, or mysql.")
+	CmdSeed.Flag.IntVar(&count, "n", 10, "Number of fake rows inserted into every table.")
+	CmdSeed.Flag.Int64Var(&seed, "seed", 0, "Seed of the fake data, a random seed is used and printed when it is 0.")
+	CmdSeed.Flag.StringVar(&tables, "tables", "", "Comma separated tables to fill, all tables by default.")
+	CmdSeed.Flag.BoolVar(&clean, "clean", false, "Delete the existing rows of the fixture tables before load.")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdSeed)
+}
+
+// RunSeed 插入假数据，或执行load子命令插入固定数据
+func RunSeed(cmd *commands.Command, args []string) int {
+	args = parseArgs(cmd, args)
+	if conn == "" {
+		conn = utils.DocValue(config.Conf.Database.Conn)
+		if conn == "" {
+			conn = "root:@tcp(127.0.0.1:3306)/test"
+		}
+	}
+	if driver == "" {
+		driver = config.Conf.Database.Driver
+		if driver == "" {
+			driver = "mysql"
+		}
+	}
+
+	if len(args) > 0 && args[0] == "load" {
+		return loadFixtures(args[1:])
+	} else if len(args) > 0 {
+		beeLogger.Log.Fatalf("Unknown seed command '%s'. Must be load, or none to insert fake data", args[0])
+	}
+
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	beeLogger.Log.Infof("Seeding with -seed=%d", seed)
+	var only []string
+	if tables != "" {
+		only = strings.Split(tables, ",")
+	}
+	generate.Seed(driver, conn.String(), count, seed, only)
+	beeLogger.Log.Success("Seed successful!")
+	return 0
+}
+
+func loadFixtures(paths []string) int {
+	if len(paths) == 0 {
+		paths = []string{filepath.Join("database", "fixtures")}
+	}
+	fixtures, err := migrate.LoadFixtures(paths)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the fixtures: %s", err)
+	}
+	db, err := migrate.Connect(driver, conn.String())
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to the database: %s", err)
+	}
+	defer db.Close()
+	if err = migrate.InsertFixtures(db, driver, fixtures, clean); err != nil {
+		beeLogger.Log.Fatalf("Could not load the fixtures: %s", err)
+	}
+	for _, f := range fixtures {
+		beeLogger.Log.Infof("Loaded %d rows into %s", len(f.Rows), f.Table)
+	}
+	beeLogger.Log.Success("Fixtures loaded!")
+	return 0
+}
+
+// parseArgs 解析参数并返回其中的非flag参数，flag可以在非flag参数之后，如 load fixtures -clean
+func parseArgs(cmd *commands.Command, args []string) (rest []string) {
+	for {
+		cmd.Flag.Parse(args)
+		args = cmd.Flag.Args()
+		if len(args) == 0 {
+			return
+		}
+		rest, args = append(rest, args[0]), args[1:]
+	}
+}
@@ -118,6 +118,12 @@ func ShowShortVersionBanner() {
 }
 
 func GetBeegoVersion() string {
+	// go modules: the version required by go.mod of the current directory
+	if currpath, err := os.Getwd(); err == nil {
+		if module, v := utils.GetBeegoModVersion(currpath); module != "" {
+			return fmt.Sprintf("%s (%s)", strings.TrimPrefix(v, "v"), module)
+		}
+	}
 	re, err := regexp.Compile(`VERSION = "([0-9.]+)"`)
 	if err != nil {
 		return ""
@@ -159,7 +165,7 @@ func GetBeegoVersion() string {
 		}
 
 	}
-	return "Beego is not installed. Please do consider installing it first: https://github.com/astaxie/beego"
+	return "Beego is not installed. Please do consider installing it first: https://github.com/beego/beego"
 }
 
 func GetGoVersion() string {
@@ -35,6 +35,10 @@ var Conf = struct {
 	Envs               []string
 	Bale               bale
 	Database           database
+	Generate           generate
+	Run                run
+	Watch              watch
+	Hooks              hooks
 	EnableReload       bool              `json:"enable_reload" yaml:"enable_reload"`
 	EnableNotification bool              `json:"enable_notification" yaml:"enable_notification"`
 	Scripts            map[string]string `json:"scripts" yaml:"scripts"`
@@ -80,6 +84,43 @@ type database struct {
 	Dir    string
 }
 
+// generate holds the options of the generate command
+type generate struct {
+	JSONCase string `json:"json_case" yaml:"json_case"` // camel or snake
+	Target   string // beego, gin, echo or stdlib
+	ORM      string // beego, gorm or sqlx
+	Beego    string // v1 or v2, empty means the version required by go.mod
+}
+
+// run holds the options of the run command, the flags of bee run take precedence
+type run struct {
+	Main    []string // main go files
+	Tags    string   // -tags of go build
+	Runmode string   // BEEGO_RUNMODE
+	Runargs string   // extra args to run the application
+	Exclude []string // paths excluded from watching
+	Ex      []string // extra packages to watch
+	Vendor  bool     // watch the vendor folder
+	Gendoc  bool     // generate the docs before building
+}
+
+// watch holds the options of the file watcher of bee run
+type watch struct {
+	Debounce     string   // wait after the last change before building, such as 500ms, defaults to 1s
+	Exclude      []string // glob patterns of the paths not watched, in the syntax of .gitignore
+	Poll         bool     // poll the files instead of using inotify or the like
+	PollInterval string   `json:"poll_interval" yaml:"poll_interval"` // defaults to 1s
+}
+
+// hooks holds the lifecycle hooks of bee run, each is the name of a script in scripts or a shell command
+type hooks struct {
+	PreBuild       string `json:"pre_build" yaml:"pre_build"`               // before building, a failure aborts the build
+	PostBuild      string `json:"post_build" yaml:"post_build"`             // after a successful build
+	PreStart       string `json:"pre_start" yaml:"pre_start"`               // before starting the application, a failure keeps it stopped
+	PostStart      string `json:"post_start" yaml:"post_start"`             // after starting the application
+	OnBuildFailure string `json:"on_build_failure" yaml:"on_build_failure"` // after a failed build
+}
+
 // LoadConfig loads the bee tool configuration.
 // It looks for Beefile or bee.json in the current path,
 // and falls back to default configuration in case not found.
@@ -23,6 +23,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	beeLogger "bee/logger"
@@ -40,6 +41,18 @@ const (
 
 var SQLConn utils.DocValue
 
+// ExportCode 为每个表生成CSV/XLSX的导出导入接口
+var ExportCode bool
+
+// JSONCase DTO的json标签风格，camel或snake
+var JSONCase = "camel"
+
+// Target 生成代码使用的web框架，见Targets
+var Target = "beego"
+
+// ORM 生成的model使用的持久层，见ORMs
+var ORM = "beego"
+
 // DbTransformer 将数据库架构反向工程为静态go代码的接口
 type DbTransformer interface {
 	GetTableNames(conn *sql.DB) []string
@@ -56,7 +69,8 @@ type MysqlDB struct {
 type MvcPath struct {
 	ModelPath      string
 	DTOPath        string
-	ControllerPath string
+	ControllerPath string // 非beego的框架为handlers目录
+	MiddlewarePath string // 只用于非beego的框架
 	RouterPath     string
 }
 
@@ -105,6 +119,7 @@ type Table struct {
 	Name          string
 	Pk            string
 	Uk            []string
+	UniqueKeys    []*UniqueKey
 	Fk            map[string]*ForeignKey
 	Columns       []*Column
 	Comments      string
@@ -150,6 +165,12 @@ type Column struct {
 	// lyb<<
 }
 
+// UniqueKey 表的唯一键，多列的唯一键按列的顺序保存
+type UniqueKey struct {
+	Name    string
+	Columns []string
+}
+
 // ForeignKey 表的外键列
 type ForeignKey struct {
 	Name      string
@@ -179,6 +200,8 @@ type OrmTag struct {
 	ReverseMany bool
 	RelM2M      bool
 	Comment     string
+	Enum        []string // enum、set列的可选值，只用于校验，不写入标签
+	Set         bool
 
 	// lyb>>
 	M2M         bool
@@ -202,109 +225,6 @@ func (tb *Table) String() string {
 	return rv
 }
 
-// lyb>>
-// String 返回Table[DTO]结构的源代码字符串
-func (tb *Table) DTOString() string {
-	rv := fmt.Sprintf("// [%s]  %s\n", tb.Name, tb.Comments)
-	rv += fmt.Sprintf("type %s struct {\n", utils.CamelCase(tb.Name)+"_DTO")
-	for _, v := range tb.Columns {
-		if !v.IsNeed {
-			rv += ""
-		} else {
-			rv += v.DTOString() + "\n"
-		}
-	}
-	rv += "}\n"
-	return rv
-}
-
-// String 返回Table[DTO]结构的源代码字符串[DTO]
-func (tb *Table) RlString() string {
-	rv := fmt.Sprintf("type %s struct {\n", utils.CamelCase(tb.Name)+"_RL")
-	rv += fmt.Sprintf("%s %s %s", "Id", "int", "// 关联Id"+"\n")
-	rv += "}\n\n"
-	return rv
-}
-
-// String 返回Table[DTO]结构中字段的源代码字符串。它映射到数据库表中的列
-func (col *Column) DTOString() string {
-	if strings.Index(col.Type, "*") != -1 {
-		return fmt.Sprintf("%s %s %s", col.Name, col.Type+"_DTO", col.Tag.NoOrmString(true))
-	}
-	return fmt.Sprintf("%s %s %s", col.Name, col.Type, col.Tag.NoOrmString(false))
-}
-
-// String 返回一列的ORM标签字符串[DTO]
-func (tag *OrmTag) NoOrmString(typ_rl bool) string {
-	tagV := "/*"
-	if typ_rl {
-		tagV += fmt.Sprintf("说明:\"%s\"", tag.Comment)
-
-		if tag.RelFk || tag.RelOne {
-			tagV += fmt.Sprintf("，%s", "【不能传Null】")
-		}
-	} else {
-		tagV += fmt.Sprintf("字段名:\"%s\"", tag.Column)
-		if tag.Size != "" {
-			tagV += fmt.Sprintf("，长度:\"%s\"", tag.Size)
-		}
-		if tag.Default != "" {
-			tagV += fmt.Sprintf("，默认值:\"%s\"", tag.Default)
-		}
-		if tag.Comment != "" {
-			tagV += fmt.Sprintf("，注释:\"%s\"", tag.Comment)
-		}
-	}
-	tagV += "*/"
-	return tagV
-}
-
-// String 返回Table结构的json templ字符串[JSON]
-func (tb *Table) JSONString() string {
-	rv := fmt.Sprintf("/* [%s] json templ\n{\n", tb.Name)
-	for i, v := range tb.Columns {
-		if !v.IsNeed {
-			rv += ""
-		} else {
-			if i == 0 {
-				rv += v.JSONString()
-			} else {
-				rv += ",\n" + v.JSONString()
-			}
-		}
-	}
-	rv += "\n}\n*/\n\n"
-	return rv
-}
-
-// String 返回Table结构中字段的json templ字符串。它映射到数据库表中的列[JSON]
-func (col *Column) JSONString() string {
-	vvv := ""
-	if strings.Index(col.Type, "*") != -1 {
-		vvv = "{\"Id\": 0}"
-		if strings.Index(col.Type, "[]") != -1 {
-			vvv = "[{\"Id\": 0}]"
-		}
-	} else {
-		if strings.Index(col.Type, "int") != -1 {
-			vvv = "0"
-		}
-		if strings.Index(col.Type, "bool") != -1 {
-			vvv = "false"
-		}
-		if strings.Index(col.Type, "string") != -1 {
-			vvv = "\"\""
-		}
-		if strings.Index(col.Type, "time") != -1 {
-			vvv = "\"2020-01-02T03:04:05+08:00\""
-		}
-		if strings.Index(col.Type, "float") != -1 {
-			vvv = "0.00"
-		}
-	}
-	return fmt.Sprintf("    \"%s\": %s", col.Name, vvv)
-}
-
 // String 返回Table结构中字段的源代码字符串。它映射到数据库表中的列
 func (col *Column) String() string {
 	return fmt.Sprintf("%s %s %s", col.Name, col.Type, col.Tag.String())
@@ -382,6 +302,31 @@ func GenerateAppcode(connStr, currpath string) {
 
 // gen 生成数据库连接中的表，列和外键信息，并生成相应的golang源文件
 func gen(connStr string, mode byte, apppath string) {
+	writeApp(readTables(connStr), mode, apppath, getPackagePath(apppath))
+}
+
+// writeApp 在apppath中生成tables的代码，Target不是beego时生成handlers及middleware
+func writeApp(tables []*Table, mode byte, apppath string, pkgPath string) {
+	mvcPath := new(MvcPath)
+	mvcPath.ModelPath = path.Join(apppath, "models")
+	mvcPath.DTOPath = path.Join(mvcPath.ModelPath, "dto")
+	mvcPath.ControllerPath = path.Join(apppath, "controllers")
+	if Target != "beego" {
+		mvcPath.ControllerPath = path.Join(apppath, "handlers")
+		mvcPath.MiddlewarePath = path.Join(apppath, "middleware")
+	}
+	mvcPath.RouterPath = path.Join(apppath, "routers")
+	createPaths(mode, mvcPath)
+	generatedFiles = nil
+	writeSourceFiles(pkgPath, tables, mode, mvcPath)
+	if Beego == "v2" {
+		beeLogger.Log.Info("Converting to beego v2...")
+		writeBeegoV2Files(generatedFiles, mvcPath)
+	}
+}
+
+// readTables 读取数据库中所有表的列及关系
+func readTables(connStr string) []*Table {
 	db, err := sql.Open("mysql", connStr)
 	if err != nil {
 		beeLogger.Log.Fatalf("使用 '%s',连接mysql数据库失败 err: %s ", connStr, err)
@@ -395,15 +340,7 @@ func gen(connStr string, mode byte, apppath string) {
 
 	var tableNames []string
 	tableNames = trans.GetTableNames(db)
-	tables := getTableObjects(tableNames, db, trans)
-	mvcPath := new(MvcPath)
-	mvcPath.ModelPath = path.Join(apppath, "models")
-	mvcPath.DTOPath = path.Join(mvcPath.ModelPath, "dto")
-	mvcPath.ControllerPath = path.Join(apppath, "controllers")
-	mvcPath.RouterPath = path.Join(apppath, "routers")
-	createPaths(mode, mvcPath)
-	pkgPath := getPackagePath(apppath)
-	writeSourceFiles(pkgPath, tables, mode, mvcPath)
+	return getTableObjects(tableNames, db, trans)
 }
 
 // GetTableNames returns a slice of table names in the current database
@@ -425,6 +362,10 @@ func (*MysqlDB) GetTableNames(db *sql.DB) (tables []string) {
 
 // getTableObjects 将数据表反向工程为go对象
 func getTableObjects(tableNames []string, db *sql.DB, dbTransformer DbTransformer) (tables []*Table) {
+	// 关系是全局的，每次重新收集；表按名字排序，生成的代码与数据库返回的顺序无关
+	trlist, correcttrlist, trmap = nil, nil, make(map[string]TableRelation)
+	tableNames = append([]string(nil), tableNames...)
+	sort.Strings(tableNames)
 	// 如果一个表有一个复合主键或没有主键，我们还不能使用，这些表将被放入黑名单，这样其他结构就不会引用它
 	blackList := make(map[string]bool)
 	// 每个表的处理约束信息，还收集列入黑名单的表名
@@ -457,10 +398,17 @@ func getTableObjects(tableNames []string, db *sql.DB, dbTransformer DbTransforme
 		}
 	}
 
+	// 按键的顺序处理，关系列的顺序是确定的
+	trkeys := make([]string, 0, len(trmap))
+	for key := range trmap {
+		trkeys = append(trkeys, key)
+	}
+	sort.Strings(trkeys)
 	for _, tb := range tables {
 		//先处理逆向的关系，如所有表中找到名为RelationName的表，进入处理
 		//TableRelation中的isCorrect会被置为True
-		for _, tr := range trmap {
+		for _, key := range trkeys {
+			tr := trmap[key]
 			// correcttr.IsCorrect = true
 			if tb.Name == tr.RelationName {
 				var correcttr = tr
@@ -518,26 +466,28 @@ func (*MysqlDB) GetTbComments(db *sql.DB, table *Table) (Comments string) {
 func (*MysqlDB) GetConstraints(db *sql.DB, table *Table, blackList map[string]bool) {
 	rows, err := db.Query(
 		`SELECT
-			c.constraint_type, u.column_name, u.referenced_table_schema, u.referenced_table_name, referenced_column_name, u.ordinal_position
+			c.constraint_type, u.column_name, u.referenced_table_schema, u.referenced_table_name, referenced_column_name, u.ordinal_position, c.constraint_name
 		FROM
 			information_schema.table_constraints c
 		INNER JOIN
 			information_schema.key_column_usage u ON c.constraint_name = u.constraint_name
 		WHERE
-			c.table_schema = database() AND c.table_name = ? AND u.table_schema = database() AND u.table_name = ?`,
+			c.table_schema = database() AND c.table_name = ? AND u.table_schema = database() AND u.table_name = ?
+		ORDER BY
+			c.constraint_name, u.ordinal_position`,
 		table.Name, table.Name) //  u.position_in_unique_constraint,
 	if err != nil {
 		beeLogger.Log.Fatal("Could not query INFORMATION_SCHEMA for PK/UK/FK information")
 		return
 	}
 	for rows.Next() {
-		var constraintTypeBytes, columnNameBytes, refTableSchemaBytes, refTableNameBytes, refColumnNameBytes, refOrdinalPosBytes []byte
-		if err := rows.Scan(&constraintTypeBytes, &columnNameBytes, &refTableSchemaBytes, &refTableNameBytes, &refColumnNameBytes, &refOrdinalPosBytes); err != nil {
+		var constraintTypeBytes, columnNameBytes, refTableSchemaBytes, refTableNameBytes, refColumnNameBytes, refOrdinalPosBytes, constraintNameBytes []byte
+		if err := rows.Scan(&constraintTypeBytes, &columnNameBytes, &refTableSchemaBytes, &refTableNameBytes, &refColumnNameBytes, &refOrdinalPosBytes, &constraintNameBytes); err != nil {
 			beeLogger.Log.Fatal("Could not read INFORMATION_SCHEMA for PK/UK/FK information")
 		}
-		constraintType, columnName, refTableSchema, refTableName, refColumnName, refOrdinalPos :=
+		constraintType, columnName, refTableSchema, refTableName, refColumnName, refOrdinalPos, constraintName :=
 			string(constraintTypeBytes), string(columnNameBytes), string(refTableSchemaBytes),
-			string(refTableNameBytes), string(refColumnNameBytes), string(refOrdinalPosBytes)
+			string(refTableNameBytes), string(refColumnNameBytes), string(refOrdinalPosBytes), string(constraintNameBytes)
 		if constraintType == "PRIMARY KEY" {
 			if refOrdinalPos == "1" {
 				table.Pk = columnName
@@ -549,6 +499,11 @@ func (*MysqlDB) GetConstraints(db *sql.DB, table *Table, blackList map[string]bo
 			}
 		} else if constraintType == "UNIQUE" {
 			table.Uk = append(table.Uk, columnName)
+			if n := len(table.UniqueKeys); n > 0 && table.UniqueKeys[n-1].Name == constraintName {
+				table.UniqueKeys[n-1].Columns = append(table.UniqueKeys[n-1].Columns, columnName)
+			} else {
+				table.UniqueKeys = append(table.UniqueKeys, &UniqueKey{Name: constraintName, Columns: []string{columnName}})
+			}
 		} else if constraintType == "FOREIGN KEY" {
 			fk := new(ForeignKey)
 			fk.Name = columnName
@@ -666,8 +621,8 @@ func (mysqlDB *MysqlDB) GetColumns(db *sql.DB, table *Table, blackList map[strin
 				}
 				if isSQLTemporalType(dataType) {
 					tag.Type = dataType
-					//check auto_now, auto_now_add
-					if columnDefault == "CURRENT_TIMESTAMP" && extra == "on update CURRENT_TIMESTAMP" {
+					//check auto_now, auto_now_add，MySQL 8的extra为DEFAULT_GENERATED on update CURRENT_TIMESTAMP
+					if columnDefault == "CURRENT_TIMESTAMP" && strings.Contains(extra, "on update CURRENT_TIMESTAMP") {
 						tag.AutoNow = true
 					} else if columnDefault == "CURRENT_TIMESTAMP" {
 						tag.AutoNowAdd = true
@@ -678,6 +633,10 @@ func (mysqlDB *MysqlDB) GetColumns(db *sql.DB, table *Table, blackList map[strin
 				if isSQLDecimal(dataType) {
 					tag.Digits, tag.Decimals = extractDecimal(columnType)
 				}
+				if isSQLEnumType(dataType) {
+					tag.Enum = extractEnumValues(columnType)
+					tag.Set = dataType == "set"
+				}
 				if isSQLBinaryType(dataType) {
 					tag.Size = extractColSize(columnType)
 				}
@@ -806,6 +765,9 @@ func createPaths(mode byte, paths *MvcPath) {
 	}
 	if (mode & OController) == OController {
 		os.Mkdir(paths.ControllerPath, 0777)
+		if paths.MiddlewarePath != "" {
+			os.Mkdir(paths.MiddlewarePath, 0777)
+		}
 	}
 	if (mode & ORouter) == ORouter {
 		os.Mkdir(paths.RouterPath, 0777)
@@ -818,8 +780,26 @@ func createPaths(mode byte, paths *MvcPath) {
 func writeSourceFiles(pkgPath string, tables []*Table, mode byte, paths *MvcPath) {
 	if (OModel & mode) == OModel {
 		beeLogger.Log.Info("Creating model files...")
-		writeDTOModelFile(tables, paths.DTOPath)
+		writeDTOModelFile(tables, paths.DTOPath, pkgPath)
 		writeModelFiles(tables, paths.ModelPath)
+		writeValidateFiles(tables, paths.ModelPath)
+		if ORM != "beego" && Target == "beego" {
+			beeLogger.Log.Infof("Call models.OpenDB in main.go to connect the %s models", ORM)
+		}
+	}
+	if Target != "beego" {
+		if (OController & mode) == OController {
+			beeLogger.Log.Infof("Creating %s handler files...", Target)
+			writeHandlerFiles(tables, paths, pkgPath)
+		}
+		if (ORouter & mode) == ORouter {
+			beeLogger.Log.Infof("Creating %s router files...", Target)
+			writeTargetRouterFile(tables, paths.RouterPath, pkgPath)
+		}
+		if ExportCode {
+			beeLogger.Log.Warnf("-export is only supported by the beego target, skipped")
+		}
+		return
 	}
 	if (OController & mode) == OController {
 		beeLogger.Log.Info("Creating controller files...")
@@ -829,6 +809,16 @@ func writeSourceFiles(pkgPath string, tables []*Table, mode byte, paths *MvcPath
 		beeLogger.Log.Info("Creating router files...")
 		writeRouterFile(tables, paths.RouterPath, pkgPath)
 	}
+	if ExportCode && (OModel|OController)&mode == OModel|OController {
+		beeLogger.Log.Info("Creating export files...")
+		writeExportFiles(tables, paths, pkgPath)
+	}
+	if (OController|ORouter)&mode == OController|ORouter {
+		beeLogger.Log.Info("Creating route manifest...")
+		if err := WriteRouteManifest(path.Dir(paths.RouterPath)); err != nil {
+			beeLogger.Log.Warnf("Could not write the route manifest: %s", err)
+		}
+	}
 }
 
 // writeModelFiles 生成model文件
@@ -836,11 +826,21 @@ func writeModelFiles(tables []*Table, mPath string) {
 	// 补充一个LgPager文件
 	fpath := path.Join(mPath, "lg_pager.go")
 	_ = ioutil.WriteFile(fpath, []byte(ModelLgPager), 0666)
-	utils.FormatSourceCode(fpath)
+	formatGenerated(fpath)
+	// 批量操作的结果
+	fpath = path.Join(mPath, "lg_batch.go")
+	_ = ioutil.WriteFile(fpath, []byte(ModelLgBatch), 0666)
+	formatGenerated(fpath)
+	// 查询条件的解析及持久层的公共部分，GetAll和批量删除共用
+	writeORMFiles(mPath)
 
 	for _, tb := range tables {
 		filename := getFileName(tb.Name)
 		fpath := path.Join(mPath, filename+".go")
+		if ORM != "beego" {
+			writeFormattedFile(fpath, tb.ORMModelString(tables))
+			continue
+		}
 		var f *os.File
 		var err error
 		f, err = os.OpenFile(fpath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
@@ -940,13 +940,11 @@ func writeModelFiles(tables []*Table, mPath string) {
 					m2m := o.QueryM2M(m, "%s")
 					_, err = m2m.Clear()
 						if err != nil {
-							o.Rollback()
 							return
 						}
 					if len(m.%s) != 0 {
 						_, err = m2m.Add(m.%s)
 							if err != nil {
-								o.Rollback()
 								return
 							}
 						}
@@ -1007,6 +1005,7 @@ func writeModelFiles(tables []*Table, mPath string) {
 		fileStr = strings.Replace(fileStr, "{{every_rl_update}}", every_rl_update, -1)
 		fileStr = strings.Replace(fileStr, "{{every_rl_patch}}", every_rl_patch, -1)
 		fileStr = strings.Replace(fileStr, "{{every_m2m_part}}", every_m2m_part, -1)
+		fileStr = strings.Replace(fileStr, "{{upsert}}", tb.UpsertString(), -1)
 		fileStr = strings.Replace(fileStr, "{{modelName}}", utils.CamelCase(tb.Name), -1)
 		fileStr = strings.Replace(fileStr, "{{tableName}}", tb.Name, -1)
 
@@ -1023,26 +1022,10 @@ func writeModelFiles(tables []*Table, mPath string) {
 			beeLogger.Log.Fatalf("Could not write model file to '%s': %s", fpath, err)
 		}
 		utils.CloseFile(f)
-		utils.FormatSourceCode(fpath)
+		formatGenerated(fpath)
 	}
 }
 
-// writeDTOModelFile 生成TDO文件
-func writeDTOModelFile(tables []*Table, mPath string) {
-	filename := getFileName("dto_model")
-	fpath := path.Join(mPath, filename+".go")
-
-	fileStr := "package dto\nimport \"time\"\n"
-	for _, tb := range tables {
-		fileStr += tb.DTOString()
-		fileStr += tb.JSONString()
-	}
-
-	_ = ioutil.WriteFile(fpath, []byte(fileStr), 0666)
-
-	utils.FormatSourceCode(fpath)
-}
-
 // writeControllerFiles generates controller files
 func writeControllerFiles(tables []*Table, cPath string, pkgPath string) {
 	fpath := path.Join(cPath, "BaseController.go")
@@ -1050,7 +1033,13 @@ func writeControllerFiles(tables []*Table, cPath string, pkgPath string) {
 	// 只生成一次BaseController.go文件
 	if !utils.IsExist(fpath) {
 		_ = ioutil.WriteFile(fpath, []byte(BaseController), 0666)
-		utils.FormatSourceCode(fpath)
+		formatGenerated(fpath)
+	}
+	// BaseController使用RouteManifest，生成路由后再按路由更新
+	if fpath = path.Join(cPath, RouteGoFile); !utils.IsExist(fpath) {
+		if err := writeRouteGoFile(fpath, nil); err != nil {
+			beeLogger.Log.Warnf("Could not write the route manifest: %s", err)
+		}
 	}
 
 	for _, tb := range tables {
@@ -1067,30 +1056,27 @@ func writeControllerFiles(tables []*Table, cPath string, pkgPath string) {
 			continue
 		}
 
-		colNamesString := "[]string{"
-		for i, v := range tb.Columns {
-			if !(!v.IsNeed || v.Tag.ReverseOne) {
-				if i != 0 {
-					colNamesString += ","
-				}
-				colNamesString += "\"" + v.Name + "\""
-			}
-		}
-		colNamesString += "}"
 
-		fileStr := strings.Replace(CtrlTPL, "{{ctrlName}}", utils.CamelCase(tb.Name), -1)
+		upsertMapping, upsertCtrl := tb.UpsertCtrlString()
+		fileStr := strings.Replace(CtrlTPL, "{{upsertMapping}}", upsertMapping, -1)
+		fileStr = strings.Replace(fileStr, "{{upsertCtrl}}", upsertCtrl, -1)
+		exportMapping := ""
+		if ExportCode {
+			exportMapping = "c.Mapping(\"Export\", c.Export)\n\tc.Mapping(\"Import\", c.Import)"
+		}
+		fileStr = strings.Replace(fileStr, "{{exportMapping}}", exportMapping, -1)
+		fileStr = strings.Replace(fileStr, "{{ctrlName}}", utils.CamelCase(tb.Name), -1)
 		description := strings.Replace(tb.Comments, "表", "", -1)
 		if len(description) <= 0 {
 			description = tb.Name
 		}
 		fileStr = strings.Replace(fileStr, "{{Description}}", description, -1)
 		fileStr = strings.Replace(fileStr, "{{pkgPath}}", pkgPath, -1)
-		fileStr = strings.Replace(fileStr, "{{colNamesString}}", colNamesString, -1)
 		if _, err := f.WriteString(fileStr); err != nil {
 			beeLogger.Log.Fatalf("Could not write controller file to '%s': %s", fpath, err)
 		}
 		utils.CloseFile(f)
-		utils.FormatSourceCode(fpath)
+		formatGenerated(fpath)
 	}
 }
 
@@ -1122,7 +1108,7 @@ func writeRouterFile(tables []*Table, rPath string, pkgPath string) {
 		beeLogger.Log.Fatalf("Could not write router file to '%s': %s", fpath, err)
 	}
 	utils.CloseFile(f)
-	utils.FormatSourceCode(fpath)
+	formatGenerated(fpath)
 }
 
 func isSQLTemporalType(t string) bool {
@@ -1149,6 +1135,10 @@ func isSQLBitType(t string) bool {
 	return t == "bit"
 }
 
+func isSQLEnumType(t string) bool {
+	return t == "enum" || t == "set"
+}
+
 // extractColSize 提取字段大小：例如varchar（255）=> 255
 func extractColSize(colType string) string {
 	regex := regexp.MustCompile(`^[a-z]+\(([0-9]+)\)$`)
@@ -1156,9 +1146,13 @@ func extractColSize(colType string) string {
 	return size[1]
 }
 
+// extractIntSignness 提取整数的符号，MySQL 8.0.19起整数类型不带显示宽度：例如int(10) unsigned、int unsigned => unsigned
 func extractIntSignness(colType string) string {
-	regex := regexp.MustCompile(`(int|smallint|mediumint|bigint)\([0-9]+\)(.*)`)
+	regex := regexp.MustCompile(`int(\([0-9]+\))?(.*)`)
 	signRegex := regex.FindStringSubmatch(colType)
+	if signRegex == nil {
+		return ""
+	}
 	return strings.Trim(signRegex[2], " ")
 }
 
@@ -1170,6 +1164,15 @@ func extractDecimal(colType string) (digits string, decimals string) {
 	return
 }
 
+// extractEnumValues 提取enum、set的可选值：例如enum('on','off') => [on off]
+func extractEnumValues(colType string) (values []string) {
+	regex := regexp.MustCompile(`'((?:[^']|'')*)'`)
+	for _, m := range regex.FindAllStringSubmatch(colType, -1) {
+		values = append(values, strings.Replace(m[1], "''", "'", -1))
+	}
+	return
+}
+
 // getFileName 获取文件名，主要是为了处理_test的文件名
 func getFileName(tbName string) (filename string) {
 	// avoid test file
@@ -1246,6 +1249,191 @@ const (
 		return LgPage{PageNo: pageNo, PageSize: pageSize, TotalPage: tp, TotalCount: count, FirstPage: pageNo == 1, LastPage: pageNo == tp}
 	}
 	`
+	// 查询条件的设计
+	ModelLgQuery = `package models
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/astaxie/beego/orm"
+)
+
+// LgQueryCond 把query参数转换为orm的查询条件，没有条件时返回nil
+//  not_empty:column                       非空
+//  search:column1>value1|column2>value2   模糊或搜索，多组之间用^分隔
+//  dsearch:column1>value1|column2>value2  精确或搜索，多组之间用^分隔
+//  neq:column1>value1                     不等于
+//  column__isnull:true                    是否为空
+//  column__in:value1,value2               在列表中，值用逗号分隔
+//  column__op:value                       其它orm支持的表达式
+func LgQueryCond(query map[string]string) *orm.Condition {
+	if len(query) == 0 {
+		return nil
+	}
+	cond := orm.NewCondition()
+	search_arr_str := ""
+	dsearch_arr_str := ""
+	var co_arr []*orm.Condition
+	for k, v := range query {
+		v = strings.Replace(v, ".", "__", -1)
+		switch k {
+		case "not_empty":
+			cond1 := cond.And(v+"__isnull", false).AndNot(v, "")
+			co_arr = append(co_arr, cond1)
+		case "search":
+			search_arr_str = v
+		case "dsearch":
+			dsearch_arr_str = v
+		case "neq":
+			filed := strings.Split(v, ">")
+			if len(filed) == 2 {
+				filed[0] = strings.Replace(filed[0], ".", "__", -1)
+				cond1 := cond.AndNot(filed[0], filed[1])
+				co_arr = append(co_arr, cond1)
+			}
+		default:
+			k = strings.Replace(k, ".", "__", -1)
+			if strings.Contains(k, "isnull") {
+				cond1 := cond.And(k, (v == "true" || v == "1"))
+				co_arr = append(co_arr, cond1)
+			} else if strings.HasSuffix(k, "__in") {
+				cond1 := cond.And(k, strings.Split(v, ","))
+				co_arr = append(co_arr, cond1)
+			} else {
+				cond2 := cond.And(k, v)
+				co_arr = append(co_arr, cond2)
+			}
+		}
+	}
+	co_arr = append(co_arr, lgSearchConds(cond, search_arr_str, "__contains")...)
+	co_arr = append(co_arr, lgSearchConds(cond, dsearch_arr_str, "")...)
+
+	var co2 *orm.Condition
+	for _, item := range co_arr {
+		if co2 == nil {
+			co2 = cond.AndCond(item)
+		} else {
+			co2 = co2.AndCond(item)
+		}
+	}
+	return co2
+}
+
+// LgOrderBy 把sortby和order转换为orm的排序字段，order只有一个时用于所有sortby
+func LgOrderBy(sortby []string, order []string) (sortFields []string, err error) {
+	if len(sortby) != 0 {
+		if len(sortby) == len(order) {
+			// 1) for each sort field, there is an associated order
+			for i, v := range sortby {
+				orderby := ""
+				if order[i] == "desc" {
+					orderby = "-" + v
+				} else if order[i] == "asc" {
+					orderby = v
+				} else {
+					return nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
+				}
+				sortFields = append(sortFields, orderby)
+			}
+		} else if len(order) == 1 {
+			// 2) there is exactly one order, all the sorted fields will be sorted by this order
+			for _, v := range sortby {
+				orderby := ""
+				if order[0] == "desc" {
+					orderby = "-" + v
+				} else if order[0] == "asc" {
+					orderby = v
+				} else {
+					return nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
+				}
+				sortFields = append(sortFields, orderby)
+			}
+		} else {
+			return nil, errors.New("Error: 'sortby', 'order' sizes mismatch or 'order' size is not 1")
+		}
+	} else {
+		if len(order) != 0 {
+			return nil, errors.New("Error: unused 'order' fields")
+		}
+	}
+	return
+}
+
+// lgSearchConds 解析 column1>value1|column2>value2^column3>value3，每组内为或，组之间为与
+func lgSearchConds(cond *orm.Condition, str string, op string) (co_arr []*orm.Condition) {
+	if str == "" {
+		return
+	}
+	for _, v := range strings.Split(str, "^") {
+		var co1 *orm.Condition
+		for _, item := range strings.Split(v, "|") {
+			filed := strings.Split(item, ">")
+			if len(filed) == 2 {
+				key := filed[0] + op
+				value := filed[1]
+				if co1 == nil {
+					co1 = cond.And(key, value)
+				} else {
+					co1 = co1.Or(key, value)
+				}
+			}
+		}
+		if co1 != nil {
+			co_arr = append(co_arr, co1)
+		}
+	}
+	return
+}
+`
+	// 批量操作结果的设计
+	ModelLgBatch = `package models
+
+// LgBatchItem 批量操作中单条记录的结果，Skipped为前面的记录失败后未执行
+type LgBatchItem struct {
+	Index   int
+	Id      int
+	Ok      bool
+	Skipped bool
+	Error   string
+}
+
+// LgBatchResult 批量操作的结果，所有记录在同一个事务中执行，有一条失败则全部回滚，
+// 其后的记录不再执行
+type LgBatchResult struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Committed bool
+	Items     []*LgBatchItem
+}
+
+// Add 记录第index条记录的执行结果
+func (r *LgBatchResult) Add(index int, id int, err error) {
+	item := &LgBatchItem{Index: index, Id: id, Ok: err == nil}
+	if err != nil {
+		item.Error = err.Error()
+		r.Failed++
+	} else {
+		r.Succeeded++
+	}
+	r.Total++
+	r.Items = append(r.Items, item)
+}
+
+// Skip 已有记录失败时跳过第index条记录并返回true。PostgreSQL的事务出错后不能再执行语句，
+// 事务也会回滚，因此不再执行其后的记录
+func (r *LgBatchResult) Skip(index int, id int) bool {
+	if r.Failed == 0 {
+		return false
+	}
+	r.Items = append(r.Items, &LgBatchItem{Index: index, Id: id, Skipped: true, Error: "skipped, an earlier item failed"})
+	r.Skipped++
+	r.Total++
+	return true
+}
+`
 	// model模板
 	ModelTPL = `package models
 
@@ -1285,23 +1473,30 @@ func Add{{modelName}}(m *{{modelName}}) (id int64, err error) {
 // AddMulti{{modelName}} insert multi {{modelName}}s into database and returns
 // sum success nums.
 func AddMulti{{modelName}}(ms []*{{modelName}}) (successNums int64, err error) {
-	o := orm.NewOrm()
-	if len(ms) != 0 {
-		successNums, err = o.InsertMulti(len(ms), ms)
-		if err != nil {
-			return
-		}
-	} else {
-		successNums = 0
+	if len(ms) == 0 {
+		return
+	}
+	// 分批插入，在同一个事务中
+	o, err := lgBegin()
+	if err != nil {
+		return
 	}
+	successNums, err = o.InsertMulti(100, ms)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	err = o.Commit()
 	return
 }
 
 // Add{{modelName}}HasMany insert a new {{modelName}} and some items into database and returns
 // last inserted Id on success.
 func Add{{modelName}}HasMany(m *{{modelName}}) (id int64, err error) {
-	o := orm.NewOrm()
-	o.Begin()
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
 	id, err = o.Insert(m)
 	if err != nil {
 		o.Rollback()
@@ -1353,138 +1548,13 @@ func GetAll{{modelName}}(query map[string]string, fields []string, sortby []stri
 	qs := o.QueryTable(new({{modelName}}))
 	var count int64 = 0
 	// query k=v
-	if len(query) > 0 {
-		cond := orm.NewCondition()
-		search_arr_str := ""
-		dsearch_arr_str := ""
-		var co_arr []*orm.Condition
-		for k, v := range query {
-			v = strings.Replace(v, ".", "__", -1)
-			switch k {
-			//1.非空,示例:not_empty:column
-			case "not_empty":
-				cond1 := cond.And(v+"__isnull", false).AndNot(v, "")
-				co_arr = append(co_arr, cond1)
-			//2.或搜索,示例:search:column1>value1|column2>value2
-			case "search":
-				search_arr_str = v
-			case "dsearch":
-				dsearch_arr_str = v
-			//3.不等于,示例:neq:column1>value1
-			case "neq":
-				filed := strings.Split(v, ">")
-				if len(filed) == 2 {
-					filed[0] = strings.Replace(filed[0], ".", "__", -1)
-					cond1 := cond.AndNot(filed[0], filed[1])
-					co_arr = append(co_arr, cond1)
-				}
-			default:
-				k = strings.Replace(k, ".", "__", -1)
-				if strings.Contains(k, "isnull") {
-					cond1 := cond.And(k, (v == "true" || v == "1"))
-					co_arr = append(co_arr, cond1)
-				} else {
-					cond2 := cond.And(k, v)
-					co_arr = append(co_arr, cond2)
-				}
-			}
-		}
-
-		search_arr := strings.Split(search_arr_str, "^")
-		if len(search_arr) > 0 {
-			for _, v := range search_arr {
-				searchFields := strings.Split(v, "|")
-				var co1 *orm.Condition
-				for _, item := range searchFields {
-					filed := strings.Split(item, ">")
-					if len(filed) == 2 {
-						key := filed[0] + "__contains"
-						value := filed[1]
-						if co1 == nil {
-							tmp := cond.And(key, value)
-							co1 = tmp
-						} else {
-							co1 = co1.Or(key, value)
-						}
-					}
-				}
-				co_arr = append(co_arr, co1)
-			}
-		}
-
-		dsearch_arr := strings.Split(dsearch_arr_str, "^")
-		if len(dsearch_arr) > 0 {
-			for _, v := range dsearch_arr {
-				searchFields := strings.Split(v, "|")
-				var co1 *orm.Condition
-				for _, item := range searchFields {
-					filed := strings.Split(item, ">")
-					if len(filed) == 2 {
-						key := filed[0]
-						value := filed[1]
-						if co1 == nil {
-							tmp := cond.And(key, value)
-							co1 = tmp
-						} else {
-							co1 = co1.Or(key, value)
-						}
-					}
-				}
-				co_arr = append(co_arr, co1)
-			}
-		}
-
-		if len(co_arr) > 0 {
-			var co2 *orm.Condition
-			for _, item := range co_arr {
-				if co2 == nil {
-					tmp := cond.AndCond(item)
-					co2 = tmp
-				} else {
-					co2 = co2.AndCond(item)
-				}
-			}
-			qs = qs.SetCond(co2)
-		}
-
+	if cond := LgQueryCond(query); cond != nil {
+		qs = qs.SetCond(cond)
 	}
 	// order by:
-	var sortFields []string
-	if len(sortby) != 0 {
-		if len(sortby) == len(order) {
-			// 1) for each sort field, there is an associated order
-			for i, v := range sortby {
-				orderby := ""
-				if order[i] == "desc" {
-					orderby = "-" + v
-				} else if order[i] == "asc" {
-					orderby = v
-				} else {
-					return nil,nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
-				}
-				sortFields = append(sortFields, orderby)
-			}
-			qs = qs.OrderBy(sortFields...)
-		} else if len(sortby) != len(order) && len(order) == 1 {
-			// 2) there is exactly one order, all the sorted fields will be sorted by this order
-			for _, v := range sortby {
-				orderby := ""
-				if order[0] == "desc" {
-					orderby = "-" + v
-				} else if order[0] == "asc" {
-					orderby = v
-				} else {
-					return nil,nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
-				}
-				sortFields = append(sortFields, orderby)
-			}
-		} else if len(sortby) != len(order) && len(order) != 1 {
-			return nil,nil, errors.New("Error: 'sortby', 'order' sizes mismatch or 'order' size is not 1")
-		}
-	} else {
-		if len(order) != 0 {
-			return nil,nil, errors.New("Error: unused 'order' fields")
-		}
+	sortFields, err := LgOrderBy(sortby, order)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var l []{{modelName}}
@@ -1539,8 +1609,10 @@ func GetAll{{modelName}}(query map[string]string, fields []string, sortby []stri
 // Update{{modelName}} updates {{modelName}} by Id and returns error if
 // the record to be updated doesn't exist
 func Update{{modelName}}ById(m *{{modelName}}) (err error) {
-	o := orm.NewOrm()
-	o.Begin()
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
 	v := {{modelName}}{Id: m.Id}
 	
 	{{every_rl_update}}
@@ -1561,9 +1633,22 @@ func Update{{modelName}}ById(m *{{modelName}}) (err error) {
 // Patch{{modelName}} updates {{modelName}} by Id and returns error if
 // the record to be updated doesn't exist
 func Patch{{modelName}}ById(m *{{modelName}}, fields []string) (err error) {
-	o := orm.NewOrm()
-	o.Begin()
-	
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	err = patch{{modelName}}(o, m, fields)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	o.Commit()
+	return
+}
+
+// patch{{modelName}} updates the given fields of {{modelName}} inside the
+// transaction of o, relation fields are cleared and added again
+func patch{{modelName}}(o orm.Ormer, m *{{modelName}}, fields []string) (err error) {
 	for index, fname := range fields {
 		if fname == "" {
 			continue
@@ -1573,16 +1658,100 @@ func Patch{{modelName}}ById(m *{{modelName}}, fields []string) (err error) {
 		}
 		{{every_rl_patch}}
 	}
-	
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(fields) == 0 {
+		return
+	}
+
 	_, err = o.Update(m, fields...)
+	return
+}
+
+// UpdateMulti{{modelName}} updates several {{modelName}}s in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMulti{{modelName}}(ms []*{{modelName}}, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = o.Read(&{{modelName}}{Id: m.Id}); itemErr == nil {
+				itemErr = patch{{modelName}}(o, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(o)
+	return
+}
+
+// DeleteMulti{{modelName}}ByIds deletes {{modelName}}s by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMulti{{modelName}}ByIds(ids []int) (result *LgBatchResult, err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = delete{{modelName}}Batch(o, ids)
+	err = result.End(o)
+	return
+}
+
+// DeleteMulti{{modelName}}ByQuery deletes all {{modelName}}s matches the same query as
+// GetAll{{modelName}} in one transaction.
+func DeleteMulti{{modelName}}ByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	cond := LgQueryCond(query)
+	if cond == nil {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	o, err := lgBegin()
 	if err != nil {
+		return
+	}
+	var l []{{modelName}}
+	if _, err = o.QueryTable(new({{modelName}})).SetCond(cond).Limit(-1).All(&l, "Id"); err != nil {
 		o.Rollback()
 		return
 	}
-	o.Commit()
+	ids := make([]int, 0, len(l))
+	for _, v := range l {
+		ids = append(ids, v.Id)
+	}
+	result = delete{{modelName}}Batch(o, ids)
+	err = result.End(o)
 	return
 }
 
+// delete{{modelName}}Batch deletes {{modelName}}s one by one inside the transaction of o
+func delete{{modelName}}Batch(o orm.Ormer, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := o.Delete(&{{modelName}}{Id: id})
+		if err == nil && num == 0 {
+			err = orm.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+
 // Patch{{modelName}}M2MPart updates {{modelName}} by Id and returns error if
 // the record to be updated doesn't exist
 func Patch{{modelName}}M2MPartById(m *{{modelName}}, field string, AddIds, DelIds []int) (err error) {
@@ -1592,8 +1761,10 @@ func Patch{{modelName}}M2MPartById(m *{{modelName}}, field string, AddIds, DelId
 		err = errors.New("Add和Del不能同时为[]！")
 		return
 	}
-	o := orm.NewOrm()
-	o.Begin()
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
 
 	{{every_m2m_part}}
 	
@@ -1615,15 +1786,15 @@ func Delete{{modelName}}(id int) (err error) {
 	}
 	return
 }
-`
+{{upsert}}`
 	// controller模板
 	CtrlTPL = `package controllers
 
 import (
 	"{{pkgPath}}/models"
+	"{{pkgPath}}/models/dto"
 	"encoding/json"
 	"errors"
-	"regexp"
 	"strconv"
 	"strings"
 	"github.com/tidwall/gjson"
@@ -1644,6 +1815,11 @@ func (c *{{ctrlName}}Controller) URLMapping() {
 	c.Mapping("Patch", c.Patch)
 	c.Mapping("PatchM2MPart", c.PatchM2MPart)
 	c.Mapping("Delete", c.Delete)
+	c.Mapping("PutMulti", c.PutMulti)
+	c.Mapping("PatchMulti", c.PatchMulti)
+	c.Mapping("DeleteMulti", c.DeleteMulti)
+	{{upsertMapping}}
+	{{exportMapping}}
 }
 
 // @Description 新建{{Description}}
@@ -1652,13 +1828,20 @@ func (c *{{ctrlName}}Controller) Post() {
 	// pos11
 	jr := gjson.ParseBytes(c.Ctx.Input.RequestBody)
 	if jr.IsObject() {
-		var v models.{{ctrlName}}
-		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &v); err == nil {
+		var req dto.{{ctrlName}}CreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+			v := req.ToModel()
+			if err := v.Validate(); err != nil {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err
+				c.ServeJSON()
+				return
+			}
 			// pos12
-			if _, err := models.Add{{ctrlName}}HasMany(&v); err == nil {
+			if _, err := models.Add{{ctrlName}}HasMany(v); err == nil {
 				// pos13
 				c.Ctx.Output.SetStatus(201)
-				c.Data["json"] = v
+				c.Data["json"] = dto.New{{ctrlName}}Response(v)
 			} else {
 				c.Ctx.Output.SetStatus(400)
 				c.Data["json"] = err.Error()
@@ -1668,8 +1851,18 @@ func (c *{{ctrlName}}Controller) Post() {
 			c.Data["json"] = err.Error()
 		}
 	} else {
-		var vs []*models.{{ctrlName}}
-		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &vs); err == nil {
+		var reqs []*dto.{{ctrlName}}CreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &reqs); err == nil {
+			vs := make([]*models.{{ctrlName}}, len(reqs))
+			for i, req := range reqs {
+				vs[i] = req.ToModel()
+			}
+			if err := models.LgValidateAll(len(vs), func(i int) error { return vs[i].Validate() }); err != nil {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err
+				c.ServeJSON()
+				return
+			}
 			if successNums, err := models.AddMulti{{ctrlName}}(vs); err == nil {
 				c.Ctx.Output.SetStatus(201)
 				c.Data["json"] = successNums
@@ -1713,7 +1906,7 @@ func (c *{{ctrlName}}Controller) GetOne() {
 				}
 			}
 		}
-		c.Data["json"] = v
+		c.Data["json"] = dto.New{{ctrlName}}Response(v)
 	}
 	c.ServeJSON()
 }
@@ -1730,7 +1923,7 @@ func (c *{{ctrlName}}Controller) GetOne() {
 // @Param	page	query	string	false	"Page number of result set. Must be an integer"
 // @Param	load	query	string	false	"LoadRelatedOf. e.g. As,Bs,C ..."
 // @Param	getcounts	query	int	false	"GetCounts. e.g. 传1时仅返回记录数"
-// @Success 200 {object} models.{{ctrlName}}
+// @Success 200 {object} dto.{{ctrlName}}Response
 // @Failure 403
 // @router / [get]
 func (c *{{ctrlName}}Controller) GetAll() {
@@ -1738,7 +1931,6 @@ func (c *{{ctrlName}}Controller) GetAll() {
 	var sortby []string
 	var order []string
 	var load []string
-	var query = make(map[string]string)
 	var limit int64 = 10
 	var page int64 = 0
 	var offset int64
@@ -1750,17 +1942,12 @@ func (c *{{ctrlName}}Controller) GetAll() {
 	}
 
 	// query: k:v,k:v
-	if v := c.GetString("query"); v != "" {
-		for _, cond := range strings.Split(v, ",") {
-			kv := strings.SplitN(cond, ":", 2)
-			if len(kv) != 2 {
-				c.Data["json"] = errors.New("Error: invalid query key/value pair")
-				c.ServeJSON()
-				return
-			}
-			k, v := kv[0], kv[1]
-			query[k] = v
-		}
+	query, err := c.parseQuery()
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+		c.ServeJSON()
+		return
 	}
 
 	if getcounts == 1 {
@@ -1810,9 +1997,10 @@ func (c *{{ctrlName}}Controller) GetAll() {
 		c.Data["json"] = err.Error()
 	} else {
 		if pager != nil {
+			pager.List = dto.New{{ctrlName}}Responses(l)
 			c.Data["json"] = pager
 		} else {
-			c.Data["json"] = l
+			c.Data["json"] = dto.New{{ctrlName}}Responses(l)
 		}
 	}
 	c.ServeJSON()
@@ -1824,27 +2012,23 @@ func (c *{{ctrlName}}Controller) Put() {
 	idStr := c.Ctx.Input.Param(":id")
 	id, _ := strconv.Atoi(idStr)
 	v := models.{{ctrlName}}{Id: id}
-
-	raw := string(c.Ctx.Input.RequestBody)
-	fileds := []string{}
-	oriFields := {{colNamesString}}
-	
-	for _, oriFiled:= range oriFields {
-		re := regexp.MustCompile("\"" + oriFiled + "\":")
-		match := re.FindAllString(raw, -1)
-		if len(match) > 0 {
-			fileds = append(fileds, oriFiled)
-		} 
-	}
-	if len(fileds) == 0 {
-		c.Ctx.Output.SetStatus(400)
-		c.Data["json"] = "没有匹配字段！"
-		c.ServeJSON()
-		return
-	}
+	var req dto.{{ctrlName}}UpdateRequest
 
 	// pos41
-	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &v); err == nil {
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+		fileds := req.Apply(&v)
+		if len(fileds) == 0 {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = "没有匹配字段！"
+			c.ServeJSON()
+			return
+		}
+		if err := v.ValidateFields(fileds...); err != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err
+			c.ServeJSON()
+			return
+		}
 		// pos42
 		if err := models.Patch{{ctrlName}}ById(&v, fileds); err == nil {
 			// pos43
@@ -1866,27 +2050,23 @@ func (c *{{ctrlName}}Controller) Patch() {
 	idStr := c.Ctx.Input.Param(":id")
 	id, _ := strconv.Atoi(idStr)
 	v := models.{{ctrlName}}{Id: id}
-
-	raw := string(c.Ctx.Input.RequestBody)
-	fileds := []string{}
-	oriFields := {{colNamesString}}
-	
-	for _, oriFiled:= range oriFields {
-		re := regexp.MustCompile("\"" + oriFiled + "\":")
-		match := re.FindAllString(raw, -1)
-		if len(match) > 0 {
-			fileds = append(fileds, oriFiled)
-		} 
-	}
-	if len(fileds) == 0 {
-		c.Ctx.Output.SetStatus(400)
-		c.Data["json"] = "没有匹配字段！"
-		c.ServeJSON()
-		return
-	}
+	var req dto.{{ctrlName}}UpdateRequest
 
 	// pos51
-	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &v); err == nil {
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+		fileds := req.Apply(&v)
+		if len(fileds) == 0 {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = "没有匹配字段！"
+			c.ServeJSON()
+			return
+		}
+		if err := v.ValidateFields(fileds...); err != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err
+			c.ServeJSON()
+			return
+		}
 		// pos52
 		if err := models.Patch{{ctrlName}}ById(&v, fileds); err == nil {
 			// pos53
@@ -1954,7 +2134,128 @@ func (c *{{ctrlName}}Controller) Delete() {
 	}
 	c.ServeJSON()
 }
-`
+
+// @Description 批量替换{{Description}}，请求体为带Id的数组，未出现的字段置为零值
+// @router / [put]
+func (c *{{ctrlName}}Controller) PutMulti() {
+	// pos61
+	if vs, fields, err := c.unmarshalMulti(true); err == nil {
+		// pos62
+		if result, err := models.UpdateMulti{{ctrlName}}(vs, fields); err == nil {
+			// pos63
+			if !result.Committed {
+				c.Ctx.Output.SetStatus(400)
+			}
+			c.Data["json"] = result
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 批量修改{{Description}}，请求体为带Id的数组，只修改出现的字段
+// @router / [patch]
+func (c *{{ctrlName}}Controller) PatchMulti() {
+	// pos71
+	if vs, fields, err := c.unmarshalMulti(false); err == nil {
+		// pos72
+		if result, err := models.UpdateMulti{{ctrlName}}(vs, fields); err == nil {
+			// pos73
+			if !result.Committed {
+				c.Ctx.Output.SetStatus(400)
+			}
+			c.Data["json"] = result
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 批量删除{{Description}}，ids=1,2,3 或 query=k:v,k:v
+// @router / [delete]
+func (c *{{ctrlName}}Controller) DeleteMulti() {
+	var result *models.LgBatchResult
+	var err error
+	// pos81
+	if v := c.GetString("ids"); v != "" {
+		var ids []int
+		for _, idStr := range strings.Split(v, ",") {
+			id, e := strconv.Atoi(strings.TrimSpace(idStr))
+			if e != nil {
+				err = errors.New("Error: invalid id " + idStr)
+				break
+			}
+			ids = append(ids, id)
+		}
+		if err == nil {
+			result, err = models.DeleteMulti{{ctrlName}}ByIds(ids)
+		}
+	} else if v := c.GetString("query"); v != "" {
+		var query map[string]string
+		if query, err = c.parseQuery(); err == nil {
+			result, err = models.DeleteMulti{{ctrlName}}ByQuery(query)
+		}
+	} else {
+		err = errors.New("ids和query不能同时为空！")
+	}
+	if err == nil {
+		// pos82
+		if !result.Committed {
+			c.Ctx.Output.SetStatus(400)
+		}
+		c.Data["json"] = result
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// parseQuery 解析query参数: k:v,k:v
+func (c *{{ctrlName}}Controller) parseQuery() (map[string]string, error) {
+	var query = make(map[string]string)
+	if v := c.GetString("query"); v != "" {
+		for _, cond := range strings.Split(v, ",") {
+			kv := strings.SplitN(cond, ":", 2)
+			if len(kv) != 2 {
+				return nil, errors.New("Error: invalid query key/value pair")
+			}
+			k, v := kv[0], kv[1]
+			query[k] = v
+		}
+	}
+	return query, nil
+}
+
+// unmarshalMulti 解析批量修改的请求体，返回每条记录及要修改的字段：
+// full时为所有字段（请求体中没有的置为零值），否则为请求体中出现的字段
+func (c *{{ctrlName}}Controller) unmarshalMulti(full bool) (vs []*models.{{ctrlName}}, fields [][]string, err error) {
+	var reqs []*dto.{{ctrlName}}UpdateRequest
+	if err = json.Unmarshal(c.Ctx.Input.RequestBody, &reqs); err != nil {
+		return
+	}
+	for _, req := range reqs {
+		v := &models.{{ctrlName}}{Id: req.Id}
+		vs = append(vs, v)
+		if f := req.Apply(v); !full {
+			fields = append(fields, f)
+		} else {
+			fields = append(fields, dto.{{ctrlName}}UpdateFields)
+		}
+	}
+	return
+}
+{{upsertCtrl}}`
 	// router模板
 	RouterTPL = `// @APIVersion 1.0.0
 // @Title beego Test API
@@ -2174,8 +2475,8 @@ func init() {
 		if openJwt {
 			// 路径格式均为 请求类型@路径
 			route := RecoverRoute(ctx)
-			// 直接通过map查询是否忽略
-			if _, ok := ignoredTokenRouter[route]; ok {
+			// 直接通过map查询是否忽略，RouteManifest由bee按控制器注释中的@IgnoredToken生成
+			if _, ok := ignoredTokenRouter[route]; ok || RouteManifest[route].IgnoredToken {
 				return
 			}
 			// 验证JWT是否有效
@@ -2183,7 +2484,7 @@ func init() {
 			if jwtOk {
 				if openPerm {
 					// 直接通过map查询是否忽略
-					if _, ok := ignoredPermRouter[route]; ok {
+					if _, ok := ignoredPermRouter[route]; ok || RouteManifest[route].IgnoredPerm {
 						return
 					}
 					// todo:或通过缓存查询是否已有权限查询记录
@@ -0,0 +1,207 @@
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	beeLogger "bee/logger"
+	"bee/utils"
+)
+
+// BeegoVersions 支持的beego版本
+var BeegoVersions = []string{"v1", "v2"}
+
+// Beego 生成代码使用的beego版本，见BeegoVersions
+var Beego = "v1"
+
+// BeegoV2MinVersion 生成的v2代码需要的最低beego版本，orm.QueryExecutor自v2.0.2才有
+const BeegoV2MinVersion = "v2.0.2"
+
+// DetectBeego 按dir的go.mod判断beego版本，没有go.mod或未引用beego时为v1
+func DetectBeego(dir string) string {
+	if module, _ := utils.GetBeegoModVersion(dir); module == "github.com/beego/beego/v2" {
+		return "v2"
+	}
+	return "v1"
+}
+
+// CheckBeegoV2 返回dir的go.mod引用的beego v2的版本，低于BeegoV2MinVersion时返回错误，
+// 未引用beego v2时版本为空
+func CheckBeegoV2(dir string) (string, error) {
+	module, version := utils.GetBeegoModVersion(dir)
+	if module != "github.com/beego/beego/v2" {
+		return "", nil
+	}
+	if compareVersion(version, BeegoV2MinVersion) < 0 {
+		return version, fmt.Errorf("the beego v2 code needs github.com/beego/beego/v2 %s or later, but go.mod requires %s",
+			BeegoV2MinVersion, version)
+	}
+	return version, nil
+}
+
+// compareVersion 比较vX.Y.Z形式的版本，预发布版本及伪版本小于同一个X.Y.Z
+func compareVersion(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts v2.0.2 => [2 0 2 1]，最后一项预发布版本为0，正式版本为1
+func versionParts(v string) [4]int {
+	var rv [4]int
+	v = strings.TrimSuffix(strings.TrimPrefix(v, "v"), "+incompatible")
+	rv[3] = 1
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, rv[3] = v[:i], 0
+	}
+	for i, n := range strings.SplitN(v, ".", 3) {
+		rv[i], _ = strconv.Atoi(n)
+	}
+	return rv
+}
+
+// beegoV2Imports v1的包对应的v2的包
+var beegoV2Imports = [][2]string{
+	{`"github.com/astaxie/beego/orm"`, `"github.com/beego/beego/v2/client/orm"`},
+	{`"github.com/astaxie/beego/httplib"`, `"github.com/beego/beego/v2/client/httplib"`},
+	{`"github.com/astaxie/beego/context"`, `"github.com/beego/beego/v2/server/web/context"`},
+	{`"github.com/astaxie/beego/logs"`, `"github.com/beego/beego/v2/core/logs"`},
+	{`"github.com/astaxie/beego/validation"`, `"github.com/beego/beego/v2/core/validation"`},
+	{`"github.com/astaxie/beego"`, `"github.com/beego/beego/v2/server/web"`},
+}
+
+var (
+	beegoLogRegex         = regexp.MustCompile(`\bbeego\.(Emergency|Alert|Critical|Error|Warning|Warn|Notice|Informational|Info|Debug|Trace)\(`)
+	beegoIdentRegex       = regexp.MustCompile(`\bbeego\.([A-Z])`)
+	beegoConfigRegex      = regexp.MustCompile(`\bAppConfig\.String\(("[^"]*")\)`)
+	beegoFilterRegex      = regexp.MustCompile(`\bInsertFilter\((.*), (true|false), (true|false)\)`)
+	beegoDataBaseRegex    = regexp.MustCompile(`\borm\.RegisterDataBase\((.*), (\d+), (\d+)\)`)
+	beegoLoadRelatedRegex = regexp.MustCompile(`\bLoadRelated\((\w+), (\w+), args\)`)
+	webUsageRegex         = regexp.MustCompile(`\bweb\.`)
+)
+
+// beegoV2Idents 把代码片段中v1的beego包名及函数换成v2的，不处理import
+func beegoV2Idents(src string) string {
+	src = beegoLogRegex.ReplaceAllString(src, "logs.$1(")
+	src = beegoIdentRegex.ReplaceAllString(src, "web.$1")
+	// v2的AppConfig.String同时返回error，取不到时为空串与v1相同
+	src = beegoConfigRegex.ReplaceAllString(src, `AppConfig.DefaultString($1, "")`)
+	src = beegoFilterRegex.ReplaceAllString(src, "InsertFilter($1, web.WithReturnOnOutput($2), web.WithResetParams($3))")
+	src = beegoDataBaseRegex.ReplaceAllString(src, "orm.RegisterDataBase($1, orm.MaxIdleConnections($2), orm.MaxOpenConnections($3))")
+	// v2的Ormer不再有Commit、Rollback，事务外和事务内都用QueryExecutor
+	src = strings.Replace(src, "orm.Ormer", "orm.QueryExecutor", -1)
+	return src
+}
+
+// BeegoV2Source 把引用了beego v1的go源码改为beego v2的import及API，
+// 未引用beego v1的源码原样返回
+func BeegoV2Source(src string) string {
+	if !strings.Contains(src, `"github.com/astaxie/beego`) {
+		return src
+	}
+	for _, imp := range beegoV2Imports {
+		src = strings.Replace(src, imp[0], imp[1], -1)
+	}
+	src = beegoV2Idents(src)
+	if beegoLoadRelatedRegex.MatchString(src) {
+		// v2的LoadRelated只接受utils.KV的参数
+		src = beegoLoadRelatedRegex.ReplaceAllString(src, "LoadRelated($1, $2, args...)")
+		src = strings.Replace(src, "args ...interface{}) (int64, error)", "args ...utils.KV) (int64, error)", -1)
+		src = addImport(src, `"github.com/beego/beego/v2/core/utils"`)
+	}
+	if strings.Contains(src, "logs.") {
+		src = addImport(src, `"github.com/beego/beego/v2/core/logs"`)
+	}
+	// beego包只用来打日志时，web包已用不到
+	imp := `"github.com/beego/beego/v2/server/web"`
+	if len(webUsageRegex.FindAllString(src, -1)) == 0 && strings.Contains(src, imp) {
+		src = regexp.MustCompile(`(?m)^\s*`+regexp.QuoteMeta(imp)+`\n`).ReplaceAllString(src, "")
+	}
+	return src
+}
+
+// addImport 源码没有该import时加上
+func addImport(src string, imp string) string {
+	if strings.Contains(src, imp) {
+		return src
+	}
+	// 与已有的beego v2的import放在一组
+	if i := strings.Index(src, "\n\t\"github.com/beego/beego/v2/"); i >= 0 {
+		return src[:i] + "\n\t" + imp + src[i:]
+	}
+	if strings.Contains(src, "import (") {
+		return strings.Replace(src, "import (", "import (\n\t"+imp, 1)
+	}
+	re := regexp.MustCompile(`(?m)^import (.+)$`)
+	if loc := re.FindStringSubmatchIndex(src); loc != nil {
+		return src[:loc[0]] + "import (\n\t" + imp + "\n\t" + src[loc[2]:loc[3]] + "\n)" + src[loc[1]:]
+	}
+	return src
+}
+
+// writeBeegoFile 写入生成的go文件并格式化，beego v2时改为v2的import及API
+func writeBeegoFile(fpath string, fileStr string) {
+	if Beego == "v2" {
+		fileStr = BeegoV2Source(fileStr)
+	}
+	writeFormattedFile(fpath, fileStr)
+}
+
+// generatedFiles 本次生成写入的go文件，beego v2时只转换这些文件
+var generatedFiles []string
+
+// formatGenerated 格式化生成的go文件并记录在generatedFiles中
+func formatGenerated(fpath string) {
+	generatedFiles = append(generatedFiles, fpath)
+	utils.FormatSourceCode(fpath)
+}
+
+// writeBeegoV2Files 把生成的go文件files改为beego v2。目录中其它仍引用beego v1的文件不是bee生成的，
+// 如只生成一次的BaseController.go，只提示手工修改
+func writeBeegoV2Files(files []string, paths *MvcPath) {
+	generated := make(map[string]bool)
+	for _, fpath := range files {
+		generated[filepath.Clean(fpath)] = true
+	}
+	dirs := []string{paths.ModelPath, paths.ControllerPath, paths.RouterPath}
+	if paths.MiddlewarePath != "" {
+		dirs = append(dirs, paths.MiddlewarePath)
+	}
+	for _, dir := range dirs {
+		_ = filepath.Walk(dir, func(fpath string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !strings.HasSuffix(fpath, ".go") || generated[filepath.Clean(fpath)] {
+				return nil
+			}
+			if data, err := ioutil.ReadFile(fpath); err == nil && strings.Contains(string(data), `"github.com/astaxie/beego`) {
+				beeLogger.Log.Warnf("'%s' was not generated by bee and still imports beego v1, convert it by hand", fpath)
+			}
+			return nil
+		})
+	}
+	for _, fpath := range files {
+		data, err := ioutil.ReadFile(fpath)
+		if err != nil {
+			continue
+		}
+		src := BeegoV2Source(string(data))
+		if src == string(data) {
+			continue
+		}
+		if err := ioutil.WriteFile(fpath, []byte(src), 0666); err != nil {
+			beeLogger.Log.Fatalf("Could not write beego v2 code to '%s': %s", fpath, err)
+		}
+		utils.FormatSourceCode(fpath)
+	}
+}
@@ -0,0 +1,62 @@
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckBeegoV2(t *testing.T) {
+	tests := []struct {
+		gomod   string
+		version string
+		err     bool
+	}{
+		{"", "", false},
+		{"require github.com/astaxie/beego v1.12.3\n", "", false},
+		{"require github.com/beego/beego/v2 v2.0.1\n", "v2.0.1", true},
+		// v2.0.2之前的伪版本
+		{"require github.com/beego/beego/v2 v2.0.2-0.20210101000000-abcdefabcdef\n", "v2.0.2-0.20210101000000-abcdefabcdef", true},
+		{"require (\n\tgithub.com/beego/beego/v2 v2.0.2\n)\n", "v2.0.2", false},
+		{"require github.com/beego/beego/v2 v2.1.0 // indirect\n", "v2.1.0", false},
+	}
+	for _, tt := range tests {
+		dir, err := ioutil.TempDir("", "bee-beego")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if err = ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module app\n\n"+tt.gomod), 0644); err != nil {
+			t.Fatal(err)
+		}
+		version, err := CheckBeegoV2(dir)
+		if version != tt.version || (err != nil) != tt.err {
+			t.Errorf("%q: CheckBeegoV2() = %q, %v, want %q and error %t", tt.gomod, version, err, tt.version, tt.err)
+		}
+	}
+}
+
+func TestWriteBeegoV2FilesOnlyGenerated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bee-beego")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := "package models\n\nimport \"github.com/astaxie/beego/orm\"\n\nvar o orm.Ormer\n"
+	generated, hand := filepath.Join(dir, "user.go"), filepath.Join(dir, "hand.go")
+	for _, fpath := range []string{generated, hand} {
+		if err = ioutil.WriteFile(fpath, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeBeegoV2Files([]string{generated}, &MvcPath{ModelPath: dir})
+	if data, _ := ioutil.ReadFile(generated); !strings.Contains(string(data), `"github.com/beego/beego/v2/client/orm"`) {
+		t.Errorf("generated file not converted:\n%s", data)
+	}
+	if data, _ := ioutil.ReadFile(hand); string(data) != src {
+		t.Errorf("hand-written file changed:\n%s", data)
+	}
+}
@@ -0,0 +1,564 @@
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	beeLogger "bee/logger"
+	"bee/migrate"
+)
+
+// ddlModel 从models的源码中解析出的beego orm模型
+type ddlModel struct {
+	Name   string
+	Table  string
+	Fields []*ddlField
+}
+
+// ddlField 模型的字段，Tags为orm标签中的选项，没有参数的选项值为""
+type ddlField struct {
+	Name        string
+	Type        string // 字段类型的源码，如int、time.Time、*User、[]*Role
+	Tags        map[string]string
+	Description string
+}
+
+// ddlHints bee g生成的_validate.go中标签之外的信息：enum/set的可选值、唯一键、不能为空的关联
+type ddlHints struct {
+	values   map[string][]string // 变量名 => 可选值
+	sets     map[string]bool     // 用lgSetValid校验的变量名
+	uniques  map[string][][]string
+	required map[string]bool // 模型名.字段名
+}
+
+// ddlTypes 与typeMappingMysql相反的映射，使bee g由生成的表得到相同的Go类型
+var ddlTypes = map[string]map[string]string{
+	"mysql": {
+		"int": "int", "int8": "tinyint", "int16": "smallint", "int32": "mediumint", "int64": "bigint",
+		"uint": "int unsigned", "uint8": "tinyint unsigned", "uint16": "smallint unsigned",
+		"uint32": "mediumint unsigned", "uint64": "bigint unsigned",
+		"bool": "tinyint(1)", "float32": "float", "float64": "double", "string": "text", "time.Time": "datetime",
+	},
+	"postgres": {
+		"int": "integer", "int8": "smallint", "int16": "smallint", "int32": "integer", "int64": "bigint",
+		"uint": "bigint", "uint8": "smallint", "uint16": "integer", "uint32": "bigint", "uint64": "bigint",
+		"bool": "boolean", "float32": "real", "float64": "double precision", "string": "text",
+		"time.Time": "timestamp without time zone",
+	},
+}
+
+// postgresTimeTypes type(...)标签对应的PostgreSQL类型
+var postgresTimeTypes = map[string]string{
+	"date": "date", "datetime": "timestamp without time zone", "timestamp": "timestamp without time zone",
+	"time": "time without time zone",
+}
+
+// GenerateDDL 解析modelPath中带beego orm标签的模型，生成driver的CREATE TABLE语句写入outFile。
+// 关系按README的约定生成：rel(fk)为X_id列及其外键，rel(one)为X_id及X_one列，rel(m2m)为A_has_X中间表。
+// 列按模型字段的顺序，bee g生成的模型中关联字段在最后
+func GenerateDDL(modelPath, driver, outFile string) {
+	if _, ok := ddlTypes[driver]; !ok {
+		beeLogger.Log.Fatalf("Unsupported driver '%s', must be one of %v", driver, migrate.Drivers)
+	}
+	models, hints, err := parseModels(modelPath)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not parse the models: %s", err)
+	}
+	if len(models) == 0 {
+		beeLogger.Log.Fatalf("No beego orm models found in '%s'", modelPath)
+	}
+	schema := ddlSchema(models, hints, driver)
+	sql := "-- Generated by bee g ddl from " + filepath.ToSlash(modelPath) + "\n" +
+		migrate.SQL(migrate.Diff(&migrate.Schema{}, schema)) + postgresComments(schema)
+	if err = os.MkdirAll(filepath.Dir(outFile), 0755); err != nil {
+		beeLogger.Log.Fatalf("Could not create the directory of '%s': %s", outFile, err)
+	}
+	if err = ioutil.WriteFile(outFile, []byte(sql), 0644); err != nil {
+		beeLogger.Log.Fatalf("Could not write '%s': %s", outFile, err)
+	}
+	beeLogger.Log.Infof("Wrote %d tables to %s", len(schema.Tables), outFile)
+}
+
+// parseModels 解析目录中的Go源码，有orm标签的结构体为模型
+func parseModels(dir string) (models []*ddlModel, hints *ddlHints, err error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	hints = &ddlHints{values: map[string][]string{}, sets: map[string]bool{},
+		uniques: map[string][][]string{}, required: map[string]bool{}}
+	tableNames := make(map[string]string)
+	var files []string
+	fileOf := make(map[string]*ast.File)
+	for _, pkg := range pkgs {
+		for name, f := range pkg.Files {
+			files = append(files, name)
+			fileOf[name] = f
+		}
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		for _, decl := range fileOf[name].Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				models = append(models, parseModelDecl(decl, hints)...)
+			case *ast.FuncDecl:
+				parseModelFunc(decl, hints, tableNames)
+			}
+		}
+	}
+	for _, m := range models {
+		m.Table = tableNames[m.Name]
+		if m.Table == "" {
+			m.Table = snakeString(m.Name)
+		}
+	}
+	return models, hints, nil
+}
+
+// parseModelDecl 解析结构体及enum/set可选值的变量
+func parseModelDecl(decl *ast.GenDecl, hints *ddlHints) (models []*ddlModel) {
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			m := &ddlModel{Name: spec.Name.Name}
+			isModel := false
+			// 与beego orm一致，除orm:"-"外导出的字段都映射为列
+			for _, f := range st.Fields.List {
+				if len(f.Names) == 0 || !f.Names[0].IsExported() {
+					continue
+				}
+				var tag string
+				if f.Tag != nil {
+					tag, _ = strconv.Unquote(f.Tag.Value)
+				}
+				orm, ok := reflect.StructTag(tag).Lookup("orm")
+				isModel = isModel || ok
+				if orm == "-" {
+					continue
+				}
+				m.Fields = append(m.Fields, &ddlField{Name: f.Names[0].Name, Type: exprString(f.Type),
+					Tags: parseOrmTag(orm), Description: reflect.StructTag(tag).Get("description")})
+			}
+			if isModel {
+				models = append(models, m)
+			}
+		case *ast.ValueSpec:
+			// var UserStatusValues = []string{"on", "off"}
+			for i, name := range spec.Names {
+				if i >= len(spec.Values) || !strings.HasSuffix(name.Name, "Values") {
+					continue
+				}
+				lit, ok := spec.Values[i].(*ast.CompositeLit)
+				if !ok {
+					continue
+				}
+				var values []string
+				for _, elt := range lit.Elts {
+					if s, ok := stringLit(elt); ok {
+						values = append(values, s)
+					}
+				}
+				hints.values[name.Name] = values
+			}
+		}
+	}
+	return
+}
+
+// parseModelFunc 解析TableName方法的表名，及_validate.go中的唯一键、不能为空的关联和set的校验
+func parseModelFunc(decl *ast.FuncDecl, hints *ddlHints, tableNames map[string]string) {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 || decl.Body == nil {
+		return
+	}
+	model := strings.TrimPrefix(exprString(decl.Recv.List[0].Type), "*")
+	switch decl.Name.Name {
+	case "TableName":
+		if len(decl.Body.List) == 1 {
+			if ret, ok := decl.Body.List[0].(*ast.ReturnStmt); ok && len(ret.Results) == 1 {
+				if s, ok := stringLit(ret.Results[0]); ok {
+					tableNames[model] = s
+				}
+			}
+		}
+	case "validateColumns":
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BinaryExpr:
+				// if m.Team == nil || m.Team.Id == 0
+				if sel, ok := n.X.(*ast.SelectorExpr); ok && n.Op == token.EQL && exprString(n.Y) == "nil" {
+					hints.required[model+"."+sel.Sel.Name] = true
+				}
+			case *ast.CallExpr:
+				if exprString(n.Fun) == "lgSetValid" && len(n.Args) == 2 {
+					hints.sets[exprString(n.Args[1])] = true
+				}
+			}
+			return true
+		})
+	case "validateUnique":
+		// 每个唯一键为一个if lgChecks(fields, "A", "B")
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || exprString(call.Fun) != "lgChecks" || len(call.Args) < 2 {
+				return true
+			}
+			var key []string
+			for _, arg := range call.Args[1:] {
+				if s, ok := stringLit(arg); ok {
+					key = append(key, s)
+				}
+			}
+			hints.uniques[model] = append(hints.uniques[model], key)
+			return true
+		})
+	}
+}
+
+// parseOrmTag column(id);auto;pk => {column: id, auto: "", pk: ""}
+func parseOrmTag(tag string) map[string]string {
+	rv := make(map[string]string)
+	for _, opt := range strings.Split(tag, ";") {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			continue
+		}
+		if i := strings.Index(opt, "("); i > 0 && strings.HasSuffix(opt, ")") {
+			rv[opt[:i]] = opt[i+1 : len(opt)-1]
+		} else {
+			rv[opt] = ""
+		}
+	}
+	return rv
+}
+
+func exprString(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	case *ast.StarExpr:
+		return "*" + exprString(e.X)
+	case *ast.ArrayType:
+		return "[]" + exprString(e.Elt)
+	}
+	return ""
+}
+
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// ddlSchema 将模型转换为表结构，按表名排序
+func ddlSchema(models []*ddlModel, hints *ddlHints, driver string) *migrate.Schema {
+	tableOf := make(map[string]string)
+	for _, m := range models {
+		tableOf[m.Name] = m.Table
+	}
+	// refTable 关联字段类型对应的表，模型不在目录中时按beego orm的规则
+	refTable := func(typ string) string {
+		name := strings.TrimLeft(typ, "[]*")
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if t, ok := tableOf[name]; ok {
+			return t
+		}
+		return snakeString(name)
+	}
+	// refColumn 关联的表的主键列，模型不在目录中时为id
+	refColumn := func(table string) string {
+		for _, m := range models {
+			if m.Table == table {
+				return ddlPkColumn(m)
+			}
+		}
+		return "id"
+	}
+	schema := &migrate.Schema{Driver: driver}
+	for _, m := range models {
+		t := &migrate.SchemaTable{Name: m.Table}
+		// 与beego orm一致，没有pk标签时Id字段为自增主键
+		hasPk := ddlHasPk(m)
+		columnOf := make(map[string]string)
+		for _, f := range m.Fields {
+			if _, ok := f.Tags["reverse"]; ok {
+				continue
+			}
+			switch f.Tags["rel"] {
+			case "m2m":
+				// 中间表A_has_X，X为当前表
+				through := f.Tags["rel_table"]
+				if through == "" {
+					through = refTable(f.Type) + "_has_" + m.Table
+				}
+				if schema.Table(through) == nil && !ddlHasModel(models, through) {
+					schema.Tables = append(schema.Tables, ddlThroughTable(through, driver))
+				}
+				continue
+			case "fk", "one":
+				col := f.Tags["column"]
+				if col == "" {
+					col = snakeString(f.Name) + "_id"
+				}
+				// 中间表的模型没有_validate.go，其关联列与ddlThroughTable一样不能为空
+				_, null := f.Tags["null"]
+				t.Columns = append(t.Columns, &migrate.SchemaColumn{Name: col, Type: ddlTypes[driver]["int"],
+					Nullable: null && !hints.required[m.Name+"."+f.Name] && !strings.Contains(m.Table, "_has_")})
+				t.Indexes = append(t.Indexes, ddlIndex(t.Name, []string{col}, false))
+				ref := refTable(f.Type)
+				t.ForeignKeys = append(t.ForeignKeys, ddlForeignKey(t.Name, col, ref, refColumn(ref), f.Tags["on_delete"]))
+				if f.Tags["rel"] == "one" {
+					// X_one只是一对一关系的标识，bee g不生成字段
+					t.Columns = append(t.Columns, &migrate.SchemaColumn{Name: strings.TrimSuffix(col, "_id") + "_one",
+						Type: ddlTypes[driver]["int"], Nullable: true})
+				}
+				columnOf[f.Name] = col
+				continue
+			}
+			col := ddlColumn(m, f, hints, driver)
+			if col == nil {
+				continue
+			}
+			columnOf[f.Name] = col.Name
+			t.Columns = append(t.Columns, col)
+			if _, ok := f.Tags["pk"]; ok || !hasPk && f.Name == "Id" {
+				t.Indexes = append(t.Indexes, ddlPrimary(t.Name, col.Name, driver))
+				if _, ok := f.Tags["auto"]; ok || !hasPk {
+					ddlAuto(t.Name, col, driver)
+				}
+			}
+			if _, ok := f.Tags["unique"]; ok {
+				t.Indexes = append(t.Indexes, ddlIndex(t.Name, []string{col.Name}, true))
+			} else if _, ok := f.Tags["index"]; ok {
+				t.Indexes = append(t.Indexes, ddlIndex(t.Name, []string{col.Name}, false))
+			}
+		}
+		for _, key := range hints.uniques[m.Name] {
+			var cols []string
+			for _, field := range key {
+				cols = append(cols, columnOf[field])
+			}
+			if idx := ddlIndex(t.Name, cols, true); t.Index(idx.Name) == nil {
+				t.Indexes = append(t.Indexes, idx)
+			}
+		}
+		// 与唯一键的列相同时，不再需要X_id上的普通索引
+		var indexes []*migrate.SchemaIndex
+		for _, idx := range t.Indexes {
+			if idx.Unique || t.Index(ddlIndex(t.Name, idx.Columns, true).Name) == nil {
+				indexes = append(indexes, idx)
+			}
+		}
+		t.Indexes = indexes
+		sort.Slice(t.Indexes, func(i, j int) bool { return t.Indexes[i].Name < t.Indexes[j].Name })
+		schema.Tables = append(schema.Tables, t)
+	}
+	sort.Slice(schema.Tables, func(i, j int) bool { return schema.Tables[i].Name < schema.Tables[j].Name })
+	return schema
+}
+
+// ddlColumn 普通字段的列，不支持的类型返回nil
+func ddlColumn(m *ddlModel, f *ddlField, hints *ddlHints, driver string) *migrate.SchemaColumn {
+	types := ddlTypes[driver]
+	col := &migrate.SchemaColumn{Name: f.Tags["column"], Type: types[f.Type], Comment: f.Description}
+	if col.Name == "" {
+		col.Name = snakeString(f.Name)
+	}
+	if col.Type == "" {
+		beeLogger.Log.Warnf("Skipped %s.%s, type '%s' is not supported", m.Name, f.Name, f.Type)
+		return nil
+	}
+	_, col.Nullable = f.Tags["null"]
+	switch f.Type {
+	case "string":
+		if values, ok := hints.values[m.Name+f.Name+"Values"]; ok && driver == "mysql" {
+			quoted := make([]string, len(values))
+			for i, v := range values {
+				quoted[i] = "'" + strings.Replace(v, "'", "''", -1) + "'"
+			}
+			col.Type = "enum(" + strings.Join(quoted, ",") + ")"
+			if hints.sets[m.Name+f.Name+"Values"] {
+				col.Type = "set(" + strings.Join(quoted, ",") + ")"
+			}
+		} else if typ := f.Tags["type"]; typ != "" {
+			col.Type = typ
+		} else if size := f.Tags["size"]; size != "" {
+			col.Type = "varchar(" + size + ")"
+			if driver == "postgres" {
+				col.Type = "character varying(" + size + ")"
+			}
+		}
+	case "float32", "float64":
+		if digits, decimals := f.Tags["digits"], f.Tags["decimals"]; digits != "" && decimals != "" {
+			col.Type = "decimal(" + digits + "," + decimals + ")"
+			if driver == "postgres" {
+				col.Type = "numeric(" + digits + "," + decimals + ")"
+			}
+		}
+	case "time.Time":
+		if typ := f.Tags["type"]; typ != "" {
+			col.Type = typ
+			if driver == "postgres" && postgresTimeTypes[typ] != "" {
+				col.Type = postgresTimeTypes[typ]
+			}
+		}
+		_, autoNow := f.Tags["auto_now"]
+		_, autoNowAdd := f.Tags["auto_now_add"]
+		if autoNow || autoNowAdd {
+			def := "CURRENT_TIMESTAMP"
+			col.Default = &def
+		}
+		if autoNow && driver == "mysql" {
+			col.Extra = "on update CURRENT_TIMESTAMP"
+		}
+	}
+	if def, ok := f.Tags["default"]; ok && col.Default == nil {
+		if driver == "postgres" {
+			def = postgresDefault(def, col.Type)
+		}
+		col.Default = &def
+	}
+	return col
+}
+
+var numberRegex = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// postgresDefault PostgreSQL的默认值为表达式，字符串需加引号
+func postgresDefault(def, typ string) string {
+	switch {
+	case typ == "boolean":
+		return strconv.FormatBool(def == "1" || strings.EqualFold(def, "true"))
+	case numberRegex.MatchString(def) || strings.EqualFold(def, "CURRENT_TIMESTAMP"):
+		return def
+	}
+	return "'" + strings.Replace(def, "'", "''", -1) + "'"
+}
+
+func ddlHasModel(models []*ddlModel, table string) bool {
+	for _, m := range models {
+		if m.Table == table {
+			return true
+		}
+	}
+	return false
+}
+
+// ddlPkColumn 模型的主键列，没有pk标签时为Id字段的列
+func ddlPkColumn(m *ddlModel) string {
+	for _, f := range m.Fields {
+		if _, ok := f.Tags["pk"]; ok || f.Name == "Id" && !ddlHasPk(m) {
+			if col := f.Tags["column"]; col != "" {
+				return col
+			}
+			return snakeString(f.Name)
+		}
+	}
+	return "id"
+}
+
+func ddlHasPk(m *ddlModel) bool {
+	for _, f := range m.Fields {
+		if _, ok := f.Tags["pk"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ddlAuto 自增主键，PostgreSQL按serial列的默认值生成
+func ddlAuto(table string, col *migrate.SchemaColumn, driver string) {
+	if driver == "postgres" {
+		def := fmt.Sprintf("nextval('%s_%s_seq'::regclass)", table, col.Name)
+		col.Default = &def
+	} else {
+		col.Extra = "auto_increment"
+	}
+}
+
+func ddlPrimary(table, column, driver string) *migrate.SchemaIndex {
+	name := "PRIMARY"
+	if driver == "postgres" {
+		name = table + "_pkey"
+	}
+	return &migrate.SchemaIndex{Name: name, Columns: []string{column}, Primary: true, Unique: true}
+}
+
+// ddlIndex PostgreSQL的索引名在schema中唯一，所以都带上表名
+func ddlIndex(table string, columns []string, unique bool) *migrate.SchemaIndex {
+	prefix := "idx_"
+	if unique {
+		prefix = "uk_"
+	}
+	return &migrate.SchemaIndex{Name: prefix + table + "_" + strings.Join(columns, "_"), Columns: columns, Unique: unique}
+}
+
+// ddlOnDelete beego orm的on_delete对应的外键动作，do_nothing及没有on_delete时不设置
+var ddlOnDelete = map[string]string{"cascade": "CASCADE", "set_null": "SET NULL", "set_default": "SET DEFAULT"}
+
+// ddlForeignKey column引用refTable的主键refColumn的外键
+func ddlForeignKey(table, column, refTable, refColumn, onDelete string) *migrate.SchemaForeignKey {
+	return &migrate.SchemaForeignKey{Name: "fk_" + table + "_" + column, Columns: []string{column},
+		RefTable: refTable, RefColumns: []string{refColumn}, OnDelete: ddlOnDelete[onDelete]}
+}
+
+// ddlThroughTable 没有模型的中间表A_has_X(id, A_id, X_id)
+func ddlThroughTable(name, driver string) *migrate.SchemaTable {
+	i := strings.LastIndex(name, "_has_")
+	if i <= 0 {
+		beeLogger.Log.Warnf("Through table '%s' does not follow the A_has_X convention, bee g will not detect the relation", name)
+		i = len(name)
+	}
+	intType := ddlTypes[driver]["int"]
+	id := &migrate.SchemaColumn{Name: "id", Type: intType}
+	ddlAuto(name, id, driver)
+	t := &migrate.SchemaTable{Name: name, Columns: []*migrate.SchemaColumn{id},
+		Indexes: []*migrate.SchemaIndex{ddlPrimary(name, "id", driver)}}
+	if i < len(name) {
+		a, x := name[:i]+"_id", name[i+5:]+"_id"
+		t.Columns = append(t.Columns, &migrate.SchemaColumn{Name: a, Type: intType},
+			&migrate.SchemaColumn{Name: x, Type: intType})
+		t.Indexes = append(t.Indexes, ddlIndex(name, []string{a, x}, true), ddlIndex(name, []string{x}, false))
+		t.ForeignKeys = append(t.ForeignKeys, ddlForeignKey(name, a, name[:i], "id", ""), ddlForeignKey(name, x, name[i+5:], "id", ""))
+	}
+	return t
+}
+
+// postgresComments PostgreSQL的列备注需单独的COMMENT语句
+func postgresComments(schema *migrate.Schema) string {
+	if schema.Driver != "postgres" {
+		return ""
+	}
+	var b strings.Builder
+	for _, t := range schema.Tables {
+		for _, c := range t.Columns {
+			if c.Comment != "" {
+				fmt.Fprintf(&b, "COMMENT ON COLUMN \"%s\".\"%s\" IS '%s';\n", t.Name, c.Name, strings.Replace(c.Comment, "'", "''", -1))
+			}
+		}
+	}
+	return b.String()
+}
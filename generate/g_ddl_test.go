@@ -0,0 +1,92 @@
+package generate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// intWidthRegex int(11)等整数类型的显示宽度
+var intWidthRegex = regexp.MustCompile(`^((tiny|small|medium|big)?int)\(\d+\)`)
+
+// TestDDLGolden 由golden的models生成的表与goldenColumns的表相同，但X_id、X_one列在其他列之后，按名字排序
+func TestDDLGolden(t *testing.T) {
+	models, hints, err := parseModels("testdata/golden/beego/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := ddlSchema(models, hints, "mysql")
+	var tables []string
+	for _, tb := range schema.Tables {
+		tables = append(tables, tb.Name)
+	}
+	var wantTables []string
+	for name := range goldenColumns {
+		wantTables = append(wantTables, name)
+	}
+	sort.Strings(wantTables)
+	if !reflect.DeepEqual(tables, wantTables) {
+		t.Fatalf("ddlSchema() tables = %v, want %v", tables, wantTables)
+	}
+
+	for _, name := range wantTables {
+		tb := schema.Table(name)
+		var got, want, rels []string
+		for _, c := range tb.Columns {
+			def := ""
+			if c.Default != nil {
+				def = *c.Default
+			}
+			got = append(got, fmt.Sprintf("%s %s null=%v default=%q %s %q", c.Name, c.Type, c.Nullable, def, c.Extra, c.Comment))
+		}
+		for _, c := range goldenColumns[name] {
+			col := fmt.Sprintf("%s %s null=%v default=%q %s %q",
+				c[0], intWidthRegex.ReplaceAllString(c[2], "$1"), c[3] == "YES", c[4], c[5], c[6])
+			if strings.HasSuffix(c[0], "_id") || strings.HasSuffix(c[0], "_one") {
+				rels = append(rels, col)
+			} else {
+				want = append(want, col)
+			}
+		}
+		sort.Strings(rels)
+		want = append(want, rels...)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s columns:\n%s\nwant:\n%s", name, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+
+		var uniques, wantUniques []string
+		for _, idx := range tb.Indexes {
+			if idx.Unique && !idx.Primary {
+				uniques = append(uniques, strings.Join(idx.Columns, ","))
+			}
+		}
+		for _, c := range goldenConstraints[name] {
+			if c[0] == "UNIQUE" {
+				wantUniques = append(wantUniques, c[1])
+			}
+		}
+		if !reflect.DeepEqual(uniques, wantUniques) {
+			t.Errorf("%s unique keys = %v, want %v", name, uniques, wantUniques)
+		}
+	}
+
+	// rel(fk)、rel(one)的外键
+	var fks []string
+	for _, tb := range schema.Tables {
+		for _, fk := range tb.ForeignKeys {
+			fks = append(fks, fmt.Sprintf("%s.%v -> %s.%v", tb.Name, fk.Columns, fk.RefTable, fk.RefColumns))
+		}
+	}
+	wantFks := []string{
+		"profile.[user_id] -> user.[id]",
+		"user.[team_id] -> team.[id]",
+		"user_has_role.[role_id] -> role.[id]",
+		"user_has_role.[user_id] -> user.[id]",
+	}
+	if !reflect.DeepEqual(fks, wantFks) {
+		t.Errorf("foreign keys = %v, want %v", fks, wantFks)
+	}
+}
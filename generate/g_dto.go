@@ -0,0 +1,228 @@
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"bee/utils"
+)
+
+// writeDTOModelFile 生成新建、修改的请求及响应结构，以及与model之间的转换
+func writeDTOModelFile(tables []*Table, mPath string, pkgPath string) {
+	fpath := path.Join(mPath, getFileName("dto_model")+".go")
+
+	var body []string
+	importTime := false
+	// 生成DTO的model，关系字段只转换为这些model的响应
+	dtos := make(map[string]bool)
+	for _, tb := range tables {
+		if tb.Pk != "" && !strings.Contains(tb.Name, "_has_") {
+			dtos[utils.CamelCase(tb.Name)] = true
+		}
+	}
+	for _, tb := range tables {
+		if !dtos[utils.CamelCase(tb.Name)] {
+			continue
+		}
+		for _, col := range tb.SQLColumns() {
+			if col.Type == "time.Time" {
+				importTime = true
+			}
+		}
+		body = append(body, strings.Replace(tb.DTOString(dtos), "{{modelName}}", utils.CamelCase(tb.Name), -1))
+	}
+
+	fileStr := "package dto\n\nimport (\n"
+	if importTime {
+		fileStr += "\"time\"\n\n"
+	}
+	fileStr += fmt.Sprintf("\"%s/models\"\n)\n", pkgPath)
+	fileStr += strings.Join(body, "")
+
+	_ = ioutil.WriteFile(fpath, []byte(fileStr), 0666)
+	formatGenerated(fpath)
+}
+
+// DTOString 返回表的请求、响应结构及转换函数的源代码，dtos为生成了DTO的model
+func (tb *Table) DTOString(dtos map[string]bool) string {
+	var create, update, response []string
+	var toModel, toModelRel, apply, updateNames, newResponse, newResponseRel, fieldNames []string
+	for _, col := range tb.SQLColumns() {
+		name, typ := col.Field, col.Type
+		if col.Rel {
+			name, typ = col.Field+"Id", "int"
+		}
+		comment := ""
+		if col.Tag.Comment != "" {
+			comment = " // " + col.Tag.Comment
+		}
+		jsonName := dtoJSONName(col)
+
+		response = append(response, fmt.Sprintf("%s %s `json:\"%s\"`%s", name, typ, jsonName, comment))
+		if col.Rel {
+			newResponseRel = append(newResponseRel, fmt.Sprintf("if m.%s != nil {\n\tr.%s = m.%s.Id\n}", col.Field, name, col.Field))
+			fieldNames = append(fieldNames, fmt.Sprintf("case %q:\nif v, ok := v.(%s); ok && v != nil {\n\tr[%q] = v.Id\n} else {\n\tr[%q] = 0\n}",
+				col.Field, strings.Replace(col.Type, "*", "*models.", 1), jsonName, jsonName))
+		} else {
+			newResponse = append(newResponse, fmt.Sprintf("%s: m.%s,", name, col.Field))
+			fieldNames = append(fieldNames, fmt.Sprintf("case %q:\nr[%q] = v", col.Field, jsonName))
+		}
+
+		// 自增主键及自动维护的时间不能由请求设置
+		if col.Pk && col.Auto || col.AutoNow || col.AutoNowAdd {
+			continue
+		}
+		create = append(create, fmt.Sprintf("%s %s `json:\"%s\"`%s", name, typ, jsonName, comment))
+		if col.Rel {
+			toModelRel = append(toModelRel, fmt.Sprintf("if r.%s != 0 {\n\tm.%s = &models.%s{Id: r.%s}\n}", name, col.Field, strings.TrimPrefix(col.Type, "*"), name))
+		} else {
+			toModel = append(toModel, fmt.Sprintf("%s: r.%s,", col.Field, name))
+		}
+
+		// 主键由路径或批量修改的Id指定
+		if col.Pk {
+			continue
+		}
+		update = append(update, fmt.Sprintf("%s *%s `json:\"%s,omitempty\"`%s", name, typ, jsonName, comment))
+		value := "*r." + name
+		if col.Rel {
+			value = fmt.Sprintf("&models.%s{Id: *r.%s}", strings.TrimPrefix(col.Type, "*"), name)
+		}
+		updateNames = append(updateNames, fmt.Sprintf("%q,", col.Field))
+		apply = append(apply, fmt.Sprintf("if r.%s != nil {\n\tm.%s = %s\n\tfields = append(fields, %q)\n}", name, col.Field, value, col.Field))
+	}
+
+	// load加载的关系，未加载时为空
+	for _, col := range tb.Columns {
+		if !col.IsNeed || !(col.Tag.ReverseMany || col.Tag.ReverseOne || col.Tag.M2M || col.Tag.RelM2M) {
+			continue
+		}
+		many := strings.HasPrefix(col.Type, "[]")
+		model := strings.TrimLeft(col.Type, "[]*")
+		if !dtos[model] {
+			continue
+		}
+		jsonName := lowerFirst(col.Name)
+		if JSONCase == "snake" {
+			jsonName = snakeString(col.Name)
+		}
+		if many {
+			response = append(response, fmt.Sprintf("%s []*%sResponse `json:\"%s,omitempty\"`", col.Name, model, jsonName))
+			newResponseRel = append(newResponseRel, fmt.Sprintf("for _, v := range m.%s {\n\tr.%s = append(r.%s, New%sResponse(v))\n}", col.Name, col.Name, col.Name, model))
+		} else {
+			response = append(response, fmt.Sprintf("%s *%sResponse `json:\"%s,omitempty\"`", col.Name, model, jsonName))
+			newResponseRel = append(newResponseRel, fmt.Sprintf("if m.%s != nil {\n\tr.%s = New%sResponse(m.%s)\n}", col.Name, col.Name, model, col.Name))
+		}
+	}
+
+	rv := strings.Replace(DTOTPL, "{{createFields}}", strings.Join(create, "\n"), -1)
+	rv = strings.Replace(rv, "{{updateFields}}", strings.Join(update, "\n"), -1)
+	rv = strings.Replace(rv, "{{responseFields}}", strings.Join(response, "\n"), -1)
+	rv = strings.Replace(rv, "{{toModel}}", strings.Join(toModel, "\n"), -1)
+	rv = strings.Replace(rv, "{{toModelRel}}", strings.Join(toModelRel, "\n"), -1)
+	rv = strings.Replace(rv, "{{apply}}", strings.Join(apply, "\n"), -1)
+	rv = strings.Replace(rv, "{{updateNames}}", strings.Join(updateNames, "\n"), -1)
+	rv = strings.Replace(rv, "{{newResponse}}", strings.Join(newResponse, "\n"), -1)
+	rv = strings.Replace(rv, "{{newResponseRel}}", strings.Join(newResponseRel, "\n"), -1)
+	rv = strings.Replace(rv, "{{fieldNames}}", strings.Join(fieldNames, "\n"), -1)
+	rv = strings.Replace(rv, "{{tableName}}", tb.Name, -1)
+	rv = strings.Replace(rv, "{{tableComment}}", tb.Comments, -1)
+	return rv
+}
+
+// dtoJSONName 按JSONCase返回json标签：camel为teamId，snake为team_id
+func dtoJSONName(col *SQLColumn) string {
+	if col.Pk {
+		return "id"
+	}
+	if JSONCase == "snake" {
+		return col.Column
+	}
+	if col.Rel {
+		return lowerFirst(col.Field) + "Id"
+	}
+	return lowerFirst(col.Field)
+}
+
+const (
+	// DTO模板
+	DTOTPL = `
+// {{modelName}}CreateRequest is the body to create a {{modelName}}, [{{tableName}}] {{tableComment}}
+type {{modelName}}CreateRequest struct {
+	{{createFields}}
+}
+
+// {{modelName}}UpdateRequest is the body to update a {{modelName}}, only the fields present
+// are updated. Id is only used by the batch update.
+type {{modelName}}UpdateRequest struct {
+	Id int ` + "`json:\"id,omitempty\"`" + `
+	{{updateFields}}
+}
+
+// {{modelName}}Response is the {{modelName}} returned to the client, with the relations loaded by load
+type {{modelName}}Response struct {
+	{{responseFields}}
+}
+
+// ToModel converts r to a models.{{modelName}}
+func (r *{{modelName}}CreateRequest) ToModel() *models.{{modelName}} {
+	m := &models.{{modelName}}{
+		{{toModel}}
+	}
+	{{toModelRel}}
+	return m
+}
+
+// {{modelName}}UpdateFields are the fields replaced by a full update, such as the batch PUT
+var {{modelName}}UpdateFields = []string{
+	{{updateNames}}
+}
+
+// Apply sets the fields present in r on m, and returns the names of these fields
+func (r *{{modelName}}UpdateRequest) Apply(m *models.{{modelName}}) (fields []string) {
+	{{apply}}
+	return
+}
+
+// New{{modelName}}Response converts m to a {{modelName}}Response
+func New{{modelName}}Response(m *models.{{modelName}}) *{{modelName}}Response {
+	r := &{{modelName}}Response{
+		{{newResponse}}
+	}
+	{{newResponseRel}}
+	return r
+}
+
+// New{{modelName}}Responses converts the result of models.GetAll{{modelName}}, the items
+// trimmed by fields are keyed by the json names of {{modelName}}Response
+func New{{modelName}}Responses(ml []interface{}) []interface{} {
+	rs := make([]interface{}, len(ml))
+	for i, v := range ml {
+		switch v := v.(type) {
+		case models.{{modelName}}:
+			rs[i] = New{{modelName}}Response(&v)
+		case map[string]interface{}:
+			rs[i] = new{{modelName}}Fields(v)
+		default:
+			rs[i] = v
+		}
+	}
+	return rs
+}
+
+// new{{modelName}}Fields renames the fields of m to the json names of {{modelName}}Response
+func new{{modelName}}Fields(m map[string]interface{}) map[string]interface{} {
+	r := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		switch k {
+		{{fieldNames}}
+		default:
+			r[k] = v
+		}
+	}
+	return r
+}
+`
+)
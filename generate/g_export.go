@@ -0,0 +1,652 @@
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	beeLogger "bee/logger"
+	"bee/utils"
+)
+
+// writeExportFiles 生成CSV/XLSX导出导入的model和controller文件
+func writeExportFiles(tables []*Table, paths *MvcPath, pkgPath string) {
+	fpath := path.Join(paths.ModelPath, "lg_export.go")
+	_ = ioutil.WriteFile(fpath, []byte(ModelLgExport), 0666)
+	formatGenerated(fpath)
+	fpath = path.Join(paths.ControllerPath, "lg_export.go")
+	_ = ioutil.WriteFile(fpath, []byte(CtrlLgExport), 0666)
+	formatGenerated(fpath)
+
+	for _, tb := range tables {
+		if tb.Pk == "" || strings.Contains(tb.Name, "_has_") {
+			continue
+		}
+		filename := getFileName(tb.Name) + "_export.go"
+		modelName := utils.CamelCase(tb.Name)
+
+		fileStr := strings.Replace(ExportModelTPL, "{{exportFields}}", tb.exportFields(), -1)
+		fileStr = strings.Replace(fileStr, "{{cellCases}}", tb.exportCellCases(), -1)
+		fileStr = strings.Replace(fileStr, "{{setCases}}", tb.exportSetCases(), -1)
+		fileStr = strings.Replace(fileStr, "{{modelName}}", modelName, -1)
+		writeFormattedFile(path.Join(paths.ModelPath, filename), fileStr)
+
+		description := strings.Replace(tb.Comments, "表", "", -1)
+		if len(description) <= 0 {
+			description = tb.Name
+		}
+		fileStr = strings.Replace(ExportCtrlTPL, "{{ctrlName}}", modelName, -1)
+		fileStr = strings.Replace(fileStr, "{{Description}}", description, -1)
+		fileStr = strings.Replace(fileStr, "{{tableName}}", tb.Name, -1)
+		fileStr = strings.Replace(fileStr, "{{pkgPath}}", pkgPath, -1)
+		writeFormattedFile(path.Join(paths.ControllerPath, filename), fileStr)
+	}
+}
+
+// writeFormattedFile 写入go文件并格式化
+func writeFormattedFile(fpath string, fileStr string) {
+	f, err := os.OpenFile(fpath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		beeLogger.Log.Warnf("%s", err)
+		return
+	}
+	if _, err := f.WriteString(fileStr); err != nil {
+		beeLogger.Log.Fatalf("Could not write export file to '%s': %s", fpath, err)
+	}
+	utils.CloseFile(f)
+	formatGenerated(fpath)
+}
+
+// exportColumns 可导出的列，自增主键只导出不导入
+func (tb *Table) exportColumns() []*SQLColumn {
+	return tb.SQLColumns()
+}
+
+// exportFields 字段名及列名
+func (tb *Table) exportFields() string {
+	var fields, columns []string
+	for _, col := range tb.exportColumns() {
+		fields = append(fields, fmt.Sprintf("%q", col.Field))
+		columns = append(columns, fmt.Sprintf("%q", col.Column))
+	}
+	rv := fmt.Sprintf("// {{modelName}}ExportFields lists the fields can be exported and imported\nvar {{modelName}}ExportFields = []string{%s}\n\n", strings.Join(fields, ", "))
+	rv += fmt.Sprintf("// {{modelName}}ExportColumns lists the columns of {{modelName}}ExportFields\nvar {{modelName}}ExportColumns = []string{%s}\n\n", strings.Join(columns, ", "))
+
+	fieldOf := make(map[string]string)
+	var required []string
+	for _, col := range tb.exportColumns() {
+		fieldOf[col.Column] = col.Field
+		if !(col.Pk && col.Auto) && importRequired(col) {
+			required = append(required, fmt.Sprintf("%q", col.Field))
+		}
+	}
+	var uniqueKeys []string
+	keys, _ := tb.modelUniqueKeys()
+	for _, key := range keys {
+		var names []string
+		for _, c := range key {
+			names = append(names, fmt.Sprintf("%q", fieldOf[c]))
+		}
+		uniqueKeys = append(uniqueKeys, "{"+strings.Join(names, ", ")+"}")
+	}
+	rv += fmt.Sprintf("// {{modelName}}ImportRequired lists the fields an import file must have\nvar {{modelName}}ImportRequired = []string{%s}\n\n", strings.Join(required, ", "))
+	rv += fmt.Sprintf("// {{modelName}}ImportUniqueKeys lists the fields of each unique key, which must not repeat in an import file\nvar {{modelName}}ImportUniqueKeys = [][]string{%s}\n", strings.Join(uniqueKeys, ", "))
+	return rv
+}
+
+// exportCellCases 导出时每个字段的值，关系字段导出关联对象的Id
+func (tb *Table) exportCellCases() string {
+	var cases []string
+	for _, col := range tb.exportColumns() {
+		if col.Rel {
+			cases = append(cases, fmt.Sprintf("case %q:\n\tif m.%s != nil {\n\t\tcells[i] = m.%s.Id\n\t}", col.Field, col.Field, col.Field))
+		} else {
+			cases = append(cases, fmt.Sprintf("case %q:\n\tcells[i] = m.%s", col.Field, col.Field))
+		}
+	}
+	return strings.Join(cases, "\n")
+}
+
+// exportSetCases 导入时按列的类型、长度、是否为空解析每个字段
+func (tb *Table) exportSetCases() string {
+	var cases []string
+	for _, col := range tb.exportColumns() {
+		// 自增主键由数据库生成
+		if col.Pk && col.Auto {
+			continue
+		}
+		rv := fmt.Sprintf("case %q:\n", col.Field)
+		rv += fmt.Sprintf("if raw == \"\" {\n%s\n}\n", importEmptyAction(col))
+		rv += importParse(col)
+		cases = append(cases, rv)
+	}
+	return strings.Join(cases, "\n")
+}
+
+// importEmptyAction 空单元格：自动时间及可为空的列保留零值，有默认值的列使用默认值，否则报错
+func importEmptyAction(col *SQLColumn) string {
+	switch {
+	case col.AutoNow || col.AutoNowAdd || col.Tag.Null:
+		return "return nil"
+	case !importRequired(col):
+		return fmt.Sprintf("raw = %q", col.Tag.Default)
+	}
+	return fmt.Sprintf("return errors.New(\"%s is required\")", col.Field)
+}
+
+// importRequired 导入时必须有值的列，即不是自动时间、不可为空且没有可用的默认值
+func importRequired(col *SQLColumn) bool {
+	if col.AutoNow || col.AutoNowAdd || col.Tag.Null {
+		return false
+	}
+	return col.Tag.Default == "" || col.Rel || col.Type == "time.Time"
+}
+
+// importParse 把字符串转换为字段的类型
+func importParse(col *SQLColumn) string {
+	if col.Rel {
+		return fmt.Sprintf(`v, err := strconv.Atoi(raw)
+if err != nil {
+	return errors.New("%s must be an Id")
+}
+m.%s = &%s{Id: v}`, col.Field, col.Field, strings.TrimPrefix(col.Type, "*"))
+	}
+
+	switch col.Type {
+	case "string":
+		return fmt.Sprintf("m.%s = raw", col.Field)
+	case "time.Time":
+		return fmt.Sprintf("v, err := lgParseTime(raw)\nif err != nil {\n\treturn errors.New(\"%s must be a time, e.g. 2006-01-02 15:04:05\")\n}\nm.%s = v", col.Field, col.Field)
+	case "bool":
+		return fmt.Sprintf("v, err := strconv.ParseBool(raw)\nif err != nil {\n\treturn errors.New(\"%s must be true or false\")\n}\nm.%s = v", col.Field, col.Field)
+	case "float32", "float64":
+		return fmt.Sprintf("v, err := strconv.ParseFloat(raw, %s)\nif err != nil {\n\treturn errors.New(\"%s must be a number\")\n}\nm.%s = %s(v)",
+			strings.TrimPrefix(col.Type, "float"), col.Field, col.Field, col.Type)
+	}
+
+	parse, msg := "ParseInt", "an integer"
+	if strings.HasPrefix(col.Type, "uint") {
+		parse, msg = "ParseUint", "an unsigned integer"
+	}
+	bits := strings.TrimLeft(col.Type, "uint")
+	if bits == "" {
+		bits = "0"
+	}
+	return fmt.Sprintf("v, err := strconv.%s(raw, 10, %s)\nif err != nil {\n\treturn errors.New(\"%s must be %s\")\n}\nm.%s = %s(v)",
+		parse, bits, col.Field, msg, col.Field, col.Type)
+}
+
+const (
+	// 导出导入的公共部分
+	ModelLgExport = `package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lgExportBatch 导出时每批读取的记录数
+const lgExportBatch = 500
+
+// lgTimeLayouts 导入时支持的时间格式
+var lgTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02", time.RFC3339, "2006/01/02 15:04:05", "2006/01/02", "15:04:05"}
+
+func lgParseTime(raw string) (t time.Time, err error) {
+	for _, layout := range lgTimeLayouts {
+		if t, err = time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// lgImportFields 把表头对应到字段，表头可以是字段名或列名，不区分大小写
+func lgImportFields(header []string, fields []string, columns []string) ([]string, error) {
+	var rv []string
+	for _, h := range header {
+		h = strings.TrimSpace(h)
+		field := ""
+		for i := range fields {
+			if strings.EqualFold(h, fields[i]) || strings.EqualFold(h, columns[i]) {
+				field = fields[i]
+				break
+			}
+		}
+		if field == "" {
+			return nil, errors.New("Error: unknown column '" + h + "'")
+		}
+		rv = append(rv, field)
+	}
+	return rv, nil
+}
+
+// lgMissingFields 表头缺少的必须字段，在解析各行之前报告
+func lgMissingFields(fields []string, required []string) error {
+	var missing []string
+	for _, r := range required {
+		found := false
+		for _, f := range fields {
+			found = found || f == r
+		}
+		if !found {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("Error: missing required columns " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// lgImportKeys 检查文件中唯一键的值是否重复，seen为各唯一键已出现的值到行
+type lgImportKeys struct {
+	keys  [][]int
+	seen  []map[string]int
+	names []string
+}
+
+// newLgImportKeys 只检查列都在表头中的唯一键
+func newLgImportKeys(fields []string, uniqueKeys [][]string) *lgImportKeys {
+	k := &lgImportKeys{}
+	for _, key := range uniqueKeys {
+		var cols []int
+		for _, name := range key {
+			for j, f := range fields {
+				if f == name {
+					cols = append(cols, j)
+				}
+			}
+		}
+		if len(cols) == len(key) {
+			k.keys = append(k.keys, cols)
+			k.seen = append(k.seen, make(map[string]int))
+			k.names = append(k.names, strings.Join(key, ", "))
+		}
+	}
+	return k
+}
+
+// check 返回与前面某行唯一键的值相同的错误，否则记录第index行的值
+func (k *lgImportKeys) check(index int, row []string) error {
+	values := make([]string, len(k.keys))
+	for i, cols := range k.keys {
+		var parts []string
+		for _, j := range cols {
+			if j < len(row) {
+				parts = append(parts, strings.TrimSpace(row[j]))
+			} else {
+				parts = append(parts, "")
+			}
+		}
+		values[i] = strings.Join(parts, "\x00")
+		if prev, ok := k.seen[i][values[i]]; ok {
+			return errors.New(k.names[i] + " duplicates row " + strconv.Itoa(prev))
+		}
+	}
+	for i, v := range values {
+		k.seen[i][v] = index
+	}
+	return nil
+}
+
+// lgEmptyRow 整行为空的不导入
+func lgEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+`
+	// 导出导入的model模板
+	ExportModelTPL = `package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+{{exportFields}}
+
+// Export{{modelName}} reads all {{modelName}} matches the same query as GetAll{{modelName}} in the
+// order of Id batch by batch, and calls fn with each of them. Each batch starts after the last
+// Id of the previous one, so rows written during the export are neither skipped nor exported twice.
+func Export{{modelName}}(query map[string]string, fn func(m *{{modelName}}) error) (err error) {
+	q := make(map[string]string, len(query)+1)
+	for k, v := range query {
+		q[k] = v
+	}
+	for {
+		// 读取所有字段，由{{modelName}}Cells取出fields
+		l, _, err := GetAll{{modelName}}(q, nil, []string{"Id"}, []string{"asc"}, 0, lgExportBatch, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, item := range l {
+			v := item.({{modelName}})
+			if err = fn(&v); err != nil {
+				return err
+			}
+			q["Id__gt"] = strconv.Itoa(v.Id)
+		}
+		if len(l) < lgExportBatch {
+			return nil
+		}
+	}
+}
+
+// {{modelName}}Cells returns the values of fields of m, relations are exported as their Id
+func {{modelName}}Cells(m *{{modelName}}, fields []string) []interface{} {
+	cells := make([]interface{}, len(fields))
+	for i, field := range fields {
+		switch field {
+		{{cellCases}}
+		}
+	}
+	return cells
+}
+
+// set{{modelName}}Field parses raw as the value of field and checks it against the column
+func set{{modelName}}Field(m *{{modelName}}, field string, raw string) error {
+	switch field {
+	{{setCases}}
+	}
+	return nil
+}
+
+// Import{{modelName}} parses rows under header as {{modelName}}s and inserts them by
+// AddMulti{{modelName}}. Nothing is inserted unless every row is valid. The header must
+// have the required fields, and the values of a unique key must not repeat in rows.
+func Import{{modelName}}(header []string, rows [][]string) (result *LgBatchResult, err error) {
+	fields, err := lgImportFields(header, {{modelName}}ExportFields, {{modelName}}ExportColumns)
+	if err != nil {
+		return
+	}
+	if err = lgMissingFields(fields, {{modelName}}ImportRequired); err != nil {
+		return
+	}
+	keys := newLgImportKeys(fields, {{modelName}}ImportUniqueKeys)
+	result = &LgBatchResult{}
+	var ms []*{{modelName}}
+	for i, row := range rows {
+		if lgEmptyRow(row) {
+			continue
+		}
+		m := new({{modelName}})
+		var msgs []string
+		for j, field := range fields {
+			raw := ""
+			if j < len(row) {
+				raw = strings.TrimSpace(row[j])
+			}
+			if e := set{{modelName}}Field(m, field, raw); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if len(msgs) == 0 {
+			if e := m.ValidateFields(fields...); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if e := keys.check(i, row); e != nil {
+			msgs = append(msgs, e.Error())
+		}
+		var rowErr error
+		if len(msgs) > 0 {
+			rowErr = errors.New(strings.Join(msgs, "; "))
+		}
+		result.Add(i, 0, rowErr)
+		ms = append(ms, m)
+	}
+	if result.Failed > 0 || len(ms) == 0 {
+		return
+	}
+	if _, err = AddMulti{{modelName}}(ms); err == nil {
+		result.Committed = true
+	}
+	return
+}
+`
+	// 导出导入的controller公共部分
+	CtrlLgExport = `package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+	"github.com/astaxie/beego/context"
+)
+
+// lgSheetWriter 导出时逐行写入，csv直接写入响应，xlsx在Close时写入
+type lgSheetWriter interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+// newLgSheetWriter 按format创建导出的writer，并设置下载的响应头
+func newLgSheetWriter(ctx *context.Context, format string, name string) (lgSheetWriter, error) {
+	filename := name + "_" + time.Now().Format("20060102150405")
+	switch format {
+	case "", "csv":
+		ctx.Output.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Output.Header("Content-Disposition", "attachment; filename="+filename+".csv")
+		// 写入BOM，Excel才能识别utf-8
+		if _, err := ctx.ResponseWriter.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+		return &lgCSVWriter{w: csv.NewWriter(ctx.ResponseWriter)}, nil
+	case "xlsx":
+		f := excelize.NewFile()
+		sw, err := f.NewStreamWriter("Sheet1")
+		if err != nil {
+			return nil, err
+		}
+		ctx.Output.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		ctx.Output.Header("Content-Disposition", "attachment; filename="+filename+".xlsx")
+		return &lgXLSXWriter{f: f, sw: sw, w: ctx.ResponseWriter}, nil
+	}
+	return nil, errors.New("Error: Invalid format. Must be either [csv|xlsx]")
+}
+
+type lgCSVWriter struct {
+	w *csv.Writer
+}
+
+func (l *lgCSVWriter) Write(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = lgCellString(v)
+	}
+	return l.w.Write(record)
+}
+
+func (l *lgCSVWriter) Close() error {
+	l.w.Flush()
+	return l.w.Error()
+}
+
+type lgXLSXWriter struct {
+	f   *excelize.File
+	sw  *excelize.StreamWriter
+	w   io.Writer
+	row int
+}
+
+func (l *lgXLSXWriter) Write(row []interface{}) error {
+	l.row++
+	cell, err := excelize.CoordinatesToCellName(1, l.row)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		if t, ok := v.(time.Time); ok {
+			values[i] = lgCellString(t)
+		} else {
+			values[i] = v
+		}
+	}
+	return l.sw.SetRow(cell, values)
+}
+
+func (l *lgXLSXWriter) Close() error {
+	if err := l.sw.Flush(); err != nil {
+		return err
+	}
+	return l.f.Write(l.w)
+}
+
+// lgCellString 单元格的文本，零值时间为空
+func lgCellString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(v)
+}
+
+func lgContains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// readLgSheet 读取上传的csv或xlsx文件，返回表头和数据行
+func readLgSheet(r *http.Request, key string) (header []string, rows [][]string, err error) {
+	file, fh, err := r.FormFile(key)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var records [][]string
+	if strings.HasSuffix(strings.ToLower(fh.Filename), ".xlsx") {
+		var f *excelize.File
+		if f, err = excelize.OpenReader(file); err != nil {
+			return
+		}
+		if records, err = f.GetRows(f.GetSheetList()[0]); err != nil {
+			return
+		}
+	} else {
+		cr := csv.NewReader(file)
+		cr.FieldsPerRecord = -1
+		if records, err = cr.ReadAll(); err != nil {
+			return
+		}
+	}
+	if len(records) == 0 {
+		return nil, nil, errors.New("Error: empty file")
+	}
+	header = records[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\xEF\xBB\xBF")
+	}
+	return header, records[1:], nil
+}
+`
+	// 导出导入的controller模板
+	ExportCtrlTPL = `package controllers
+
+import (
+	"{{pkgPath}}/models"
+	"strings"
+
+	"github.com/astaxie/beego"
+)
+
+// @Description 导出{{Description}}，format=csv|xlsx，query、fields与GetAll一致，按Id排序
+// @router /export [get]
+func (c *{{ctrlName}}Controller) Export() {
+	fields := models.{{ctrlName}}ExportFields
+
+	query, err := c.parseQuery()
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+		c.ServeJSON()
+		return
+	}
+	// fields: col1,col2
+	if v := c.GetString("fields"); v != "" {
+		fields = strings.Split(v, ",")
+		for _, f := range fields {
+			if !lgContains(models.{{ctrlName}}ExportFields, f) {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = "Error: invalid field " + f
+				c.ServeJSON()
+				return
+			}
+		}
+	}
+
+	w, err := newLgSheetWriter(c.Ctx, c.GetString("format"), "{{tableName}}")
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+		c.ServeJSON()
+		return
+	}
+	header := make([]interface{}, len(fields))
+	for i, f := range fields {
+		header[i] = f
+	}
+	if err = w.Write(header); err == nil {
+		err = models.Export{{ctrlName}}(query, func(m *models.{{ctrlName}}) error {
+			return w.Write(models.{{ctrlName}}Cells(m, fields))
+		})
+	}
+	if e := w.Close(); err == nil {
+		err = e
+	}
+	// 响应已经开始写入，只能记录错误
+	if err != nil {
+		beego.Error("Export {{tableName}} failed:", err)
+	}
+}
+
+// @Description 导入{{Description}}，上传字段名为file的csv或xlsx文件，第一行为表头
+// @router /import [post]
+func (c *{{ctrlName}}Controller) Import() {
+	// pos101
+	header, rows, err := readLgSheet(c.Ctx.Request, "file")
+	if err == nil {
+		// pos102
+		if result, err := models.Import{{ctrlName}}(header, rows); err == nil {
+			// pos103
+			if !result.Committed {
+				c.Ctx.Output.SetStatus(400)
+			}
+			c.Data["json"] = result
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+`
+)
@@ -1,22 +1,26 @@
 package generate
 
 import (
+	beeLogger "bee/logger"
 	"bee/utils"
-	"encoding/json"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"io/ioutil"
 	"os"
-	"path"
-	"regexp"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
+// FixRule 按rules/rule.yml修改route及controller，Rules为nil时读取rule.yml
 type FixRule struct {
+	Rules *RuleFile
 }
 
 type CodeRule struct {
 	Name string
-	Args []string
+	Args []RuleArg
 	Func string
 	Back string
 	Pre  string
@@ -27,242 +31,243 @@ type CodeApi struct {
 	Pos     string
 	Rule    string
 	Imports string
+	Code    *CodeRule
+	// Rules 要插入的规则，rule的写法也是其中的一条
+	Rules []*ApiRule
+
+	// at rule.yml中api及其pos、rule、imports的位置
+	at map[string]yamlPos
 }
 
 type CodeController struct {
 	Name string
 	Apis []*CodeApi
+
+	at yamlPos
 }
 
-// FixRule 生成Rule
+// FixRule 生成Rule，rule.yml或controller有错误时不修改任何文件
 func (fr *FixRule) FixRule() string {
+	currpath, _ := os.Getwd()
+	if !utils.IsExist(filepath.Join(currpath, RuleFilePath)) {
+		beeLogger.Log.Warnf("%s not found, no rules applied", RuleFilePath)
+		return ""
+	}
+	if errs := fr.Check(); len(errs) > 0 {
+		for _, err := range errs {
+			beeLogger.Log.Error(err.Error())
+		}
+		beeLogger.Log.Fatalf("%d errors in the rules, no file is modified", len(errs))
+	}
 	fr.FixRouteAndAddCtls()
 	fr.FixController()
-	fr.GetRouterJson()
+	if err := WriteRouteManifest(currpath); err != nil {
+		beeLogger.Log.Warnf("Could not write the route manifest: %s", err)
+	}
 
 	return ""
 }
 
+// Check 读取rule.yml，检查其结构、rule的语法及每条rule的controller、pos点和import
+func (fr *FixRule) Check() []error {
+	currpath, _ := os.Getwd()
+	rf, errs := LoadRules(filepath.Join(currpath, RuleFilePath))
+	if rf == nil {
+		return errs
+	}
+	rf.Path = RuleFilePath
+	for _, err := range errs {
+		if re, ok := err.(*RuleError); ok {
+			re.File = RuleFilePath
+		}
+	}
+	fr.Rules = rf
+	return append(errs, rf.Check(currpath)...)
+}
+
 // FixRouteAndAddCtls 修改api名，把rule.yml的controller、rulecontroller添加到Route中
 func (fr *FixRule) FixRouteAndAddCtls() string {
 	currpath, _ := os.Getwd()
-	tplFile := currpath + "/rules/rule.yml"
-	tplNsKey := "route.api"
-	tplCtrKey := "route.controller"
-	tplRuleCtrKey := "route.rulecontroller"
-
-	var cmdH utils.CmdHelper
-	tplCtrs := strings.Split(cmdH.GetTpl(tplFile, tplCtrKey), ",")
-	tplRuleCtrs := strings.Split(cmdH.GetTpl(tplFile, tplRuleCtrKey), ",")
-
-	// 修改api为yml文件中的api
-	routerFile := currpath + "/routers/router.go"
-	routerStr := `:= beego.NewNamespace("/` + cmdH.GetTpl(tplFile, tplNsKey) + `"`
+	if fr.Rules == nil {
+		if errs := fr.Check(); len(errs) > 0 {
+			return errs[0].Error()
+		}
+	}
+	routePartialTPL, ctrlTPLForRoute := strings.TrimPrefix(RoutePartialTPL, "// posrouter\n"), CtrlTPLForRoute
+	if Beego == "v2" {
+		routePartialTPL, ctrlTPLForRoute = beegoV2Idents(routePartialTPL), BeegoV2Source(CtrlTPLForRoute)
+	}
+	routerFile := filepath.Join(currpath, "routers", "router.go")
 	routerFileByte, err := ioutil.ReadFile(routerFile)
 	if err != nil {
 		return err.Error()
 	}
-	routerFileStr := string(routerFileByte)
-	var newRouterFile string
-	// 获取当前的api名 然后修改为rule.yml中的
-	re := regexp.MustCompile(`:= beego.NewNamespace\("/(?s:(.*?))"`)
-	oldApi := re.FindAllString(string(routerFileByte), -1)
-	newRouterFile = strings.Replace(routerFileStr, oldApi[0], routerStr, -1)
-	err = ioutil.WriteFile(routerFile, []byte(newRouterFile), 0666)
+	af, err := parser.ParseFile(token.NewFileSet(), routerFile, routerFileByte, 0)
 	if err != nil {
 		return err.Error()
 	}
+	routes := namespaceRoutes(af)
 
-	// 添加controller
-	for _, tpl := range tplCtrs {
-		if strings.Contains(newRouterFile, "/"+strings.ToLower(tpl)) || tpl == "" {
-			continue
-		}
-		tplStr := strings.Title(tpl)
-		ctrStr := strings.Replace(CtrlTPLForRoute, "{{ctrlName}}", tplStr, -1)
-		routerStr := strings.Replace(RoutePartialTPL, "{{Route}}", tplStr, -1)
-		routerStr = strings.Replace(routerStr, "{{route}}", strings.ToLower(tplStr), -1)
-
-		if strings.Contains(newRouterFile, `// posrouter`) {
-			newRouterFile = strings.Replace(newRouterFile, `// posrouter`, routerStr, 1)
-			err := ioutil.WriteFile(routerFile, []byte(newRouterFile), 0666)
-			if err != nil {
-				return err.Error()
-			}
-		}
-		// 添加 controller文件
-		ctrFile := currpath + "/controllers/" + strings.ToLower(tplStr) + `.go`
-		if !utils.IsExist(ctrFile) {
-			_ = ioutil.WriteFile(ctrFile, []byte(ctrStr), 0666)
-		}
+	// controller的名字及路径，rulecontroller加上Rule、rule_前缀
+	var ctrls [][2]string
+	for _, tpl := range fr.Rules.Controllers {
+		ctrls = append(ctrls, [2]string{strings.Title(tpl), strings.ToLower(tpl)})
 	}
-
-	// 添加rulecontroller
-	for _, tpl := range tplRuleCtrs {
-		if strings.Contains(newRouterFile, "/rule_"+strings.ToLower(tpl)) || tpl == "" {
-			continue
-		}
-		tplStr := strings.Title(tpl)
-		ctrStr := strings.Replace(CtrlTPLForRoute, "{{ctrlName}}", "Rule"+tplStr, -1)
-		routerStr := strings.Replace(RoutePartialTPL, "{{Route}}", "Rule"+tplStr, -1)
-		routerStr = strings.Replace(routerStr, "{{route}}", "rule_"+strings.ToLower(tplStr), -1)
-		if strings.Contains(newRouterFile, `// posrouter`) {
-			newRouterFile = strings.Replace(newRouterFile, `// posrouter`, routerStr, 1)
-			_ = ioutil.WriteFile(routerFile, []byte(newRouterFile), 0666)
+	for _, tpl := range fr.Rules.RuleControllers {
+		ctrls = append(ctrls, [2]string{"Rule" + strings.Title(tpl), "rule_" + strings.ToLower(tpl)})
+	}
+	var entries []string
+	for _, ctrl := range ctrls {
+		ctrlName, route := ctrl[0], ctrl[1]
+		if !routes["/"+route] {
+			routes["/"+route] = true
+			routerStr := strings.Replace(routePartialTPL, "{{Route}}", ctrlName, -1)
+			entries = append(entries, strings.Replace(routerStr, "{{route}}", route, -1))
 		}
 		// 添加 controller文件
-		ctrFile := currpath + "/controllers/rule_" + strings.ToLower(tplStr) + `.go`
+		ctrFile := filepath.Join(currpath, "controllers", route+".go")
 		if !utils.IsExist(ctrFile) {
-			err := ioutil.WriteFile(ctrFile, []byte(ctrStr), 0666)
-			if err != nil {
+			ctrStr := strings.Replace(ctrlTPLForRoute, "{{ctrlName}}", ctrlName, -1)
+			if err := ioutil.WriteFile(ctrFile, []byte(ctrStr), 0666); err != nil {
 				return err.Error()
 			}
 		}
 	}
-	utils.FormatSourceCode(routerFile)
+
+	// 修改api为yml文件中的api
+	prefix := ""
+	if fr.Rules.Api != "" {
+		prefix = "/" + fr.Rules.Api
+	}
+	newRouterFile, err := editNamespace(string(routerFileByte), prefix, entries)
+	if err != nil {
+		return routerFile + ": " + err.Error()
+	}
+	if newRouterFile != string(routerFileByte) {
+		if err = ioutil.WriteFile(routerFile, []byte(newRouterFile), 0666); err != nil {
+			return err.Error()
+		}
+		utils.FormatSourceCode(routerFile)
+	}
 	return ""
 }
 
-// FixController 添加rule.yml的controllers到对应的controllers中
+// rule插入的代码块及import的标记，代码块以controller.api.pos为键，
+// 再次执行时先去掉所有标记的代码块及import，再按rule.yml重新插入
+const (
+	ruleBeginMark  = "// rule:begin "
+	ruleEndMark    = "// rule:end "
+	ruleImportMark = "// rule:import"
+)
+
+// FixController 添加rule.yml的controllers到对应的controllers中，
+// 已插入的代码块按rule.yml更新，rule.yml中没有的代码块被删除
 func (fr *FixRule) FixController() string {
 	currpath, _ := os.Getwd()
-	tplFile := currpath + "/rules/rule.yml"
-	tplKey := "controller"
-
-	var cmdH utils.CmdHelper
-	tpls := cmdH.GetTplSet(tplFile, tplKey)
-
-	for ctrName, tpl := range tpls {
-		ctrFile := currpath + "/controllers/" + strings.ToLower(ctrName) + ".go"
-		apiTpls := tpl.(map[string]interface{})
-		var apis []*CodeApi
-
-		for apiName, tpl2 := range apiTpls {
-			var posImports []string
-			jsonString, _ := json.Marshal(tpl2)
-			api1 := &CodeApi{}
-			json.Unmarshal(jsonString, api1)
-			api := &CodeApi{Name: apiName, Pos: "// " + api1.Pos, Rule: api1.Rule, Imports: api1.Imports}
-			apis = append(apis, api)
-			imports := strings.Split(api.Imports, ",")
-			posImports = append(posImports, imports...)
-			rule := &CodeRule{}
-			rule.Name = strings.Split(api.Rule, " ")[0]
-			rule.Func = strings.Split(strings.Split(api.Rule, " ")[1], "->")[1]
-			rule.Back = "-1"
-			if len(strings.Split(strings.Split(api.Rule, " ")[1], "->")) > 2 {
-				rule.Back = strings.Split(strings.Split(api.Rule, " ")[1], "->")[2]
-			}
-			rule.Args = strings.Split(strings.Split(strings.Split(api.Rule, " ")[1], "->")[0], ",")
-			ruleTpl := ""
-			if rule.Name == "Func" {
-				ruleTpl = api.Pos + "\n" + rule.Func + "\n"
-				if rule.Back != "-1" {
-					ruleTpl = rule.Back + "=" + ruleTpl
-				}
-			} else {
-				ruleNameLower := strings.ToLower(rule.Name)
-				fields := ""
-				for _, arg := range rule.Args {
-					fieldName := strings.Replace(strings.Split(arg, ":")[0], "&#58;", ":", -1)
-					fieldValue := strings.Replace(strings.Split(arg, ":")[1], "&#58;", ":", -1)
-					fields = fields + fieldName + ": " + fieldValue + "," + "\n"
-				}
-				ruleTpl = api.Pos + "\n" + ruleNameLower + ` := &rules.` + rule.Name + `Rule{` + "\n" + fields + `}` + "\n"
-
-				if rule.Back != "-1" {
-					ruleTpl = ruleTpl + rule.Back + ` = ` + ruleNameLower + `.` + rule.Func + "\n\t"
-				} else {
-					ruleTpl = ruleTpl + ruleNameLower + `.` + rule.Func
-				}
-			}
-
-			// 修改添加 import 依赖
-			ctrFileByte, _ := ioutil.ReadFile(ctrFile)
-			newCtrFileStr := string(ctrFileByte)
-			for _, pim := range posImports {
-				if strings.Contains(newCtrFileStr, pim) {
-					continue
-				}
-				newCtrFileStr = strings.Replace(newCtrFileStr, "// posimport", "// posimport\n"+`"`+pim+`"`, -1)
-				_ = ioutil.WriteFile(ctrFile, []byte(newCtrFileStr), 0666)
+	if fr.Rules == nil {
+		if errs := fr.Check(); len(errs) > 0 {
+			return errs[0].Error()
+		}
+	}
+	files := fr.Rules.ruleControllers(currpath)
+	ctrFiles, _ := filepath.Glob(filepath.Join(currpath, "controllers", "*.go"))
+	sort.Strings(ctrFiles)
+
+	for _, ctrFile := range ctrFiles {
+		ctrFileByte, err := ioutil.ReadFile(ctrFile)
+		if err != nil {
+			return err.Error()
+		}
+		oldCtrFileStr := string(ctrFileByte)
+		newCtrFileStr := stripRuleBlocks(oldCtrFileStr)
+		for _, ctr := range fr.Rules.Rules {
+			if f, ok := files[ctr.Name]; !ok || f.path != ctrFile {
+				continue
 			}
-
-			// 添加pos点
-			if strings.Contains(newCtrFileStr, api.Pos) {
-				newCtrFileStr = strings.Replace(newCtrFileStr, api.Pos, ruleTpl, -1)
-				_ = ioutil.WriteFile(ctrFile, []byte(newCtrFileStr), 0666)
+			if newCtrFileStr, err = applyRules(newCtrFileStr, ctr); err != nil {
+				return ctrFile + ": " + err.Error()
 			}
 		}
+		if newCtrFileStr == oldCtrFileStr {
+			continue
+		}
+		if err = ioutil.WriteFile(ctrFile, []byte(newCtrFileStr), 0666); err != nil {
+			return err.Error()
+		}
 		utils.FormatSourceCode(ctrFile)
 	}
 	return ""
 }
 
-type RtJson struct {
-	ApiBaseUrl string
-	Rts        []*Rt
-}
-
-// Rt 路由定义
-type Rt struct {
-	Title      string
-	Url        string
-	MethodType string
-	MUrl       string
-}
-
-// GetRouterJson 添加RouterJson文件
-func (fr *FixRule) GetRouterJson() string {
-	currpath, _ := os.Getwd()
-	var cmdH utils.CmdHelper
-	tplFile := currpath + "/rules/rule.yml"
-	tplNsKey := "route.api"
-	tplNs := cmdH.GetTpl(tplFile, tplNsKey)
-	rtJson := &RtJson{
-		ApiBaseUrl: "/" + tplNs,
+// applyRules 插入controller的import及代码块，api的每个pos点一个代码块，其中的规则按order及rule.yml中的顺序
+func applyRules(src string, ctr *CodeController) (string, error) {
+	// 修改添加 import 依赖
+	var imports []string
+	for _, api := range ctr.Apis {
+		imports = append(imports, api.ImportList()...)
 	}
-	routerFile := currpath + "/routers/router.go"
-
-	routerFileByte, _ := ioutil.ReadFile(routerFile)
-	re := regexp.MustCompile(`"/(?s:(.*?))"`)
-	ctrs := re.FindAllString(string(routerFileByte), -1)
-	for _, v := range ctrs[1:] {
-		ctr := v[2 : len(v)-1]
-		ctrFile := currpath + "/controllers/" + ctr + ".go"
-		ctrFileByte, _ := ioutil.ReadFile(ctrFile)
-		// re = regexp.MustCompile(`// @router(?s:(.*?))]`)
-		re = regexp.MustCompile(`// @Description(?s:(.*?))]`)
-		ctrRouters := re.FindAllString(string(ctrFileByte), -1)
-		for _, ctrRoute := range ctrRouters {
-			re = regexp.MustCompile(`// @Description(?s:.*?)\n`)
-			findStr := re.FindAllString(ctrRoute, -1)
-			description := strings.TrimSpace(findStr[0][15:])
-			re = regexp.MustCompile(`// @router(?s:(.*?))]`)
-			findStr = re.FindAllString(ctrRoute, -1)
-			route := findStr[0][2 : len(findStr[0])-1]
-			uri := strings.TrimSpace(route[strings.Index(route, "/"):strings.Index(route, "[")])
-			methodType := route[strings.Index(route, "[")+1:]
-			rtOne := new(Rt)
-			rtOne.Title = description
-			rtOne.Url = fmt.Sprintf("/%s%s", ctr, uri)
-			rtOne.MethodType = strings.ToLower(methodType)
-			rtOne.MUrl = methodType + "@" + rtOne.Url
-			rtJson.Rts = append(rtJson.Rts, rtOne)
-		}
+	src, err := addImports(src, imports, ruleImportMark)
+	if err != nil {
+		return src, err
 	}
 
-	rtByte, err := json.MarshalIndent(rtJson, "", "      ")
+	fset := token.NewFileSet()
+	af, err := parser.ParseFile(fset, "", src, parser.ParseComments)
 	if err != nil {
-		fmt.Println("转换route数据失败:", err.Error())
+		return src, err
+	}
+	// 添加pos点
+	var edits []srcEdit
+	for _, api := range ctr.Apis {
+		fn := findMethod(af, ctr.Name, api.Name)
+		if fn == nil {
+			return src, fmt.Errorf("no method %s", api.Name)
+		}
+		anchors, rules := api.sortedRules()
+		for _, anchor := range anchors {
+			key := ctr.Name + "." + api.Name + "." + anchor
+			block := ruleBeginMark + key + "\n"
+			for _, r := range rules[anchor] {
+				block += r.Code
+			}
+			edit, ok := anchorEdit(fset, af, fn, anchor, block+ruleEndMark+key)
+			if !ok {
+				return src, fmt.Errorf("pos point %s not found in %s", anchor, api.Name)
+			}
+			edits = append(edits, edit)
+		}
 	}
+	return applyEdits(src, edits), nil
+}
 
-	rtJsonFile := currpath + "/routers/router.json"
-	_ = ioutil.WriteFile(rtJsonFile, rtByte, 0666)
-	os.MkdirAll(path.Join(currpath,"/static/router"),0777)
-	rtJsonFile = currpath + "/static/router/router.json"
-	_ = ioutil.WriteFile(rtJsonFile, rtByte, 0666)
-	return ""
+// stripRuleBlocks 去掉rule插入的代码块及import，没有结束标记的代码块保留，import块为空时一并去掉
+func stripRuleBlocks(src string) string {
+	lines := strings.SplitAfter(src, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasSuffix(line, ruleImportMark) && strings.HasPrefix(line, `"`) {
+			continue
+		}
+		if strings.HasPrefix(line, ruleBeginMark) {
+			end := ruleEndMark + strings.TrimPrefix(line, ruleBeginMark)
+			j := i + 1
+			for j < len(lines) && strings.TrimSpace(lines[j]) != end {
+				j++
+			}
+			if j < len(lines) {
+				i = j
+				continue
+			}
+		}
+		// rule添加的import块只剩下括号时一并去掉
+		if line == ")" && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "import (" {
+			out = out[:len(out)-1]
+			continue
+		}
+		out = append(out, lines[i])
+	}
+	return strings.Join(out, "")
 }
 
 const (
@@ -0,0 +1,332 @@
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 用go/ast定位要修改的位置，再在源码的对应位置插入代码，
+// 源码中的注释和格式保持不变，修改后由FormatSourceCode格式化
+
+// srcEdit 在源码的offset处插入text
+type srcEdit struct {
+	offset int
+	text   string
+}
+
+// applyEdits 插入所有的修改，offset相同时按edits中的顺序
+func applyEdits(src string, edits []srcEdit) string {
+	order := make([]int, len(edits))
+	for i := range order {
+		order[i] = i
+	}
+	// 从后往前插入，前面的位置不变
+	sort.Slice(order, func(i, j int) bool {
+		a, b := edits[order[i]], edits[order[j]]
+		if a.offset != b.offset {
+			return a.offset > b.offset
+		}
+		return order[i] > order[j]
+	})
+	for _, i := range order {
+		e := edits[i]
+		src = src[:e.offset] + e.text + src[e.offset:]
+	}
+	return src
+}
+
+// addImports 添加源码中还没有的import，comment不为空时作为行尾注释。
+// 有// posimport时加在其后，否则加在第一个import块中，没有import块时新建
+func addImports(src string, imports []string, comment string) (string, error) {
+	fset := token.NewFileSet()
+	af, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return src, err
+	}
+	have := make(map[string]bool)
+	for _, spec := range af.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+			have[p] = true
+		}
+	}
+	if comment != "" {
+		comment = " " + comment
+	}
+	var lines string
+	for _, imp := range imports {
+		if !have[imp] {
+			have[imp] = true
+			lines += "\n\t" + strconv.Quote(imp) + comment
+		}
+	}
+	if lines == "" {
+		return src, nil
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	for _, decl := range af.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT || !gd.Lparen.IsValid() {
+			continue
+		}
+		at := offset(gd.Lparen) + 1
+		for _, cg := range af.Comments {
+			for _, c := range cg.List {
+				if c.Pos() > gd.Lparen && c.End() < gd.Rparen && strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == "posimport" {
+					at = offset(c.End())
+				}
+			}
+		}
+		return applyEdits(src, []srcEdit{{at, lines}}), nil
+	}
+	at := offset(af.Name.End())
+	if len(af.Imports) > 0 {
+		// import "x" 的形式，在其后另加一个import块
+		for _, decl := range af.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+				at = offset(gd.End())
+			}
+		}
+	}
+	return applyEdits(src, []srcEdit{{at, "\n\nimport (" + lines + "\n)"}}), nil
+}
+
+// anchorEdit 方法fn中anchor处插入code的修改。anchor为方法中// anchor注释的名字，或：
+//
+//	begin 方法的开头
+//	end 最后的c.ServeJSON()或return之前
+//	before_save 第一个models.Add、Patch、Update、Upsert、Delete等调用的语句之前
+//	after_commit 该调用成功后，即if ...; err == nil {之内的开头
+//	after_load 第一个models.Get调用的语句之后，为if ...; err == nil时在其内的开头
+//
+// 同名的注释优先
+func anchorEdit(fset *token.FileSet, af *ast.File, fn *ast.FuncDecl, anchor, code string) (srcEdit, bool) {
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	code = strings.TrimSuffix(code, "\n")
+	if c := findComment(af, anchor, fn.Body.Lbrace, fn.Body.Rbrace); c != nil {
+		return srcEdit{offset(c.End()), "\n" + code}, true
+	}
+	switch anchor {
+	case "begin":
+		return srcEdit{offset(fn.Body.Lbrace) + 1, "\n" + code}, true
+	case "end":
+		at := fn.Body.Rbrace
+		if n := len(fn.Body.List); n > 0 && isResponseStmt(fn.Body.List[n-1]) {
+			at = fn.Body.List[n-1].Pos()
+		}
+		return srcEdit{offset(at), code + "\n"}, true
+	case "before_save":
+		if stmt := findModelsStmt(fn.Body, modelsWrites); stmt != nil {
+			return srcEdit{offset(stmt.Pos()), code + "\n"}, true
+		}
+	case "after_commit":
+		if body := successBody(findModelsStmt(fn.Body, modelsWrites)); body != nil {
+			return srcEdit{offset(body.Lbrace) + 1, "\n" + code}, true
+		}
+	case "after_load":
+		stmt := findModelsStmt(fn.Body, []string{"Get"})
+		if body := successBody(stmt); body != nil {
+			return srcEdit{offset(body.Lbrace) + 1, "\n" + code}, true
+		} else if stmt != nil {
+			return srcEdit{offset(stmt.End()), "\n" + code}, true
+		}
+	}
+	return srcEdit{}, false
+}
+
+// modelsWrites 修改数据的models函数名的前缀
+var modelsWrites = []string{"Add", "Patch", "Update", "Upsert", "Delete"}
+
+// findModelsStmt 按代码顺序找到第一个调用了名字以prefixes开头的models函数的语句，
+// if语句只看其初始化语句及条件，调用在块中时返回块中的语句
+func findModelsStmt(block *ast.BlockStmt, prefixes []string) ast.Stmt {
+	for _, stmt := range block.List {
+		var head []ast.Node
+		var blocks []*ast.BlockStmt
+		switch s := stmt.(type) {
+		case *ast.IfStmt:
+			head = []ast.Node{s.Init, s.Cond}
+			blocks = append(blocks, s.Body)
+			for els := s.Else; els != nil; {
+				switch e := els.(type) {
+				case *ast.BlockStmt:
+					blocks, els = append(blocks, e), nil
+				case *ast.IfStmt:
+					blocks, els = append(blocks, e.Body), e.Else
+				}
+			}
+		case *ast.BlockStmt:
+			blocks = append(blocks, s)
+		case *ast.ForStmt:
+			blocks = append(blocks, s.Body)
+		case *ast.RangeStmt:
+			blocks = append(blocks, s.Body)
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			// case中的语句不在块中，不作为插入的位置
+		default:
+			head = []ast.Node{stmt}
+		}
+		for _, n := range head {
+			if n != nil && callsModels(n, prefixes) {
+				return stmt
+			}
+		}
+		for _, b := range blocks {
+			if found := findModelsStmt(b, prefixes); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// callsModels n中是否调用了名字以prefixes开头的models函数，不包括函数字面量中的调用
+func callsModels(n ast.Node, prefixes []string) (found bool) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok || found {
+			return false
+		}
+		if call, ok := n.(*ast.CallExpr); ok {
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+				if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "models" {
+					for _, p := range prefixes {
+						if strings.HasPrefix(sel.Sel.Name, p) {
+							found = true
+						}
+					}
+				}
+			}
+		}
+		return !found
+	})
+	return
+}
+
+// successBody stmt为if ...; err == nil {时返回其块
+func successBody(stmt ast.Stmt) *ast.BlockStmt {
+	s, ok := stmt.(*ast.IfStmt)
+	if !ok {
+		return nil
+	}
+	if cond, ok := s.Cond.(*ast.BinaryExpr); ok && cond.Op == token.EQL {
+		x, xok := cond.X.(*ast.Ident)
+		y, yok := cond.Y.(*ast.Ident)
+		if xok && yok && x.Name == "err" && y.Name == "nil" {
+			return s.Body
+		}
+	}
+	return nil
+}
+
+// isResponseStmt 是否为return或c.ServeJSON()等输出响应的语句
+func isResponseStmt(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+				return strings.HasPrefix(sel.Sel.Name, "Serve")
+			}
+		}
+	}
+	return false
+}
+
+// findComment 找到from、to之间内容为// name的注释
+func findComment(af *ast.File, name string, from, to token.Pos) *ast.Comment {
+	for _, cg := range af.Comments {
+		for _, c := range cg.List {
+			if c.Pos() > from && c.End() <= to && strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// findNamespaceCall 找到第一个NewNamespace调用
+func findNamespaceCall(af *ast.File) (ns *ast.CallExpr) {
+	ast.Inspect(af, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && ns == nil && callName(call) == "NewNamespace" {
+			ns = call
+		}
+		return ns == nil
+	})
+	return
+}
+
+// namespaceRoutes 所有NSNamespace及NewNamespace的路径，包括嵌套的
+func namespaceRoutes(af *ast.File) map[string]bool {
+	routes := make(map[string]bool)
+	ast.Inspect(af, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && len(call.Args) > 0 {
+			if name := callName(call); name == "NSNamespace" || name == "NewNamespace" {
+				if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if p, err := strconv.Unquote(lit.Value); err == nil {
+						routes[p] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+	return routes
+}
+
+func callName(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	case *ast.Ident:
+		return fun.Name
+	}
+	return ""
+}
+
+// editNamespace 把第一个NewNamespace的路径改为prefix（为空时不改），并在其参数最后添加entries
+func editNamespace(src, prefix string, entries []string) (string, error) {
+	fset := token.NewFileSet()
+	af, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return src, err
+	}
+	ns := findNamespaceCall(af)
+	if ns == nil || len(ns.Args) == 0 {
+		return src, fmt.Errorf("no NewNamespace call found")
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	var edits []srcEdit
+	if lit, ok := ns.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING && prefix != "" {
+		if old, _ := strconv.Unquote(lit.Value); old != prefix {
+			start, end := offset(lit.Pos()), offset(lit.End())
+			src = src[:start] + strconv.Quote(prefix) + src[end:]
+			return editNamespace(src, "", entries)
+		}
+	}
+	if len(entries) == 0 {
+		return src, nil
+	}
+	// 最后一个参数后没有逗号时先加上
+	last := offset(ns.Args[len(ns.Args)-1].End())
+	if !startsWithComma(src[last:offset(ns.Rparen)]) {
+		edits = append(edits, srcEdit{last, ","})
+	}
+	edits = append(edits, srcEdit{offset(ns.Rparen), "\n" + strings.Join(entries, "\n") + "\n"})
+	return applyEdits(src, edits), nil
+}
+
+// startsWithComma 跳过空白及注释后的第一个token是否为逗号
+func startsWithComma(src string) bool {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	s.Init(fset.AddFile("", fset.Base(), len(src)), []byte(src), nil, 0)
+	_, tok, _ := s.Scan()
+	return tok == token.COMMA
+}
@@ -0,0 +1,174 @@
+package generate
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestApplyEdits(t *testing.T) {
+	// offset相同时按edits中的顺序
+	got := applyEdits("abcdef", []srcEdit{{3, "1"}, {0, "<"}, {3, "2"}, {6, ">"}})
+	if want := "<abc12def>"; got != want {
+		t.Errorf("applyEdits() = %q, want %q", got, want)
+	}
+}
+
+func TestAddImports(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		imports []string
+		comment string
+		want    string
+	}{
+		{"block", "package a\n\nimport (\n\t\"fmt\"\n)\n",
+			[]string{"fmt", "strings"}, "",
+			"package a\n\nimport (\n\t\"strings\"\n\t\"fmt\"\n)\n"},
+		{"posimport", "package a\n\nimport (\n\t\"fmt\"\n\t// posimport\n\t\"os\"\n)\n",
+			[]string{"strings", "sort"}, "// rule:import",
+			"package a\n\nimport (\n\t\"fmt\"\n\t// posimport\n\t\"strings\" // rule:import\n\t\"sort\" // rule:import\n\t\"os\"\n)\n"},
+		// 只加在第一个import块中
+		{"two blocks", "package a\n\nimport \"fmt\"\n\nimport (\n\t\"os\"\n)\n",
+			[]string{"strings"}, "",
+			"package a\n\nimport \"fmt\"\n\nimport (\n\t\"strings\"\n\t\"os\"\n)\n"},
+		{"single line import", "package a\n\nimport \"fmt\"\n\nfunc f() {}\n",
+			[]string{"strings"}, "",
+			"package a\n\nimport \"fmt\"\n\nimport (\n\t\"strings\"\n)\n\nfunc f() {}\n"},
+		{"no import", "package a\n\nfunc f() {}\n",
+			[]string{"strings"}, "",
+			"package a\n\nimport (\n\t\"strings\"\n)\n\nfunc f() {}\n"},
+		{"already imported", "package a\n\nimport s \"strings\"\n",
+			[]string{"strings"}, "",
+			"package a\n\nimport s \"strings\"\n"},
+	}
+	for _, tt := range tests {
+		got, err := addImports(tt.src, tt.imports, tt.comment)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: addImports() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := addImports("package", []string{"fmt"}, ""); err == nil {
+		t.Error("addImports() of a bad source succeeded")
+	}
+}
+
+const anchorTestSource = `package controllers
+
+func (c *UserController) Post() {
+	// pos11
+	if _, err := models.AddUser(&v); err == nil {
+		c.Ctx.Output.SetStatus(201)
+	}
+	c.ServeJSON()
+}
+
+func (c *UserController) GetOne() {
+	v, err := models.GetUserById(id)
+	c.Data["json"] = v
+}
+
+func (c *UserController) Put() {
+	if v, err := models.GetUserById(id); err == nil {
+		for _, f := range fields {
+			if err := models.UpdateUserById(&v); err == nil {
+				c.Data["json"] = "OK"
+			}
+		}
+	}
+	return
+}
+
+func (c *UserController) Delete() {
+	f := func() { models.DeleteUser(id) }
+	switch id {
+	case 0:
+		models.DeleteUser(id)
+	}
+}
+`
+
+func TestAnchorEdit(t *testing.T) {
+	tests := []struct {
+		method string
+		anchor string
+		want   string // 插入处前后的源码，插入的代码为X()
+		ok     bool
+	}{
+		{"Post", "pos11", "// pos11\nX()\n\tif _, err", true},
+		{"Post", "begin", "Post() {\nX()\n\t// pos11", true},
+		{"Post", "end", "}\n\tX()\nc.ServeJSON()", true},
+		{"Post", "before_save", "// pos11\n\tX()\nif _, err := models.AddUser", true},
+		{"Post", "after_commit", "err == nil {\nX()\n\t\tc.Ctx.Output.SetStatus(201)", true},
+		{"GetOne", "end", "= v\nX()\n}", true},
+		{"GetOne", "after_load", "models.GetUserById(id)\nX()\n\tc.Data", true},
+		{"GetOne", "before_save", "", false},
+		// 调用在if、for的块中时找到块中的语句
+		{"Put", "after_load", "models.GetUserById(id); err == nil {\nX()\n\t\tfor", true},
+		{"Put", "before_save", "range fields {\n\t\t\tX()\nif err := models.UpdateUserById", true},
+		{"Put", "after_commit", "UpdateUserById(&v); err == nil {\nX()\n\t\t\t\tc.Data", true},
+		{"Put", "end", "}\n\tX()\nreturn\n}", true},
+		// 函数字面量及case中的调用不算
+		{"Delete", "before_save", "", false},
+		{"Delete", "pos99", "", false},
+	}
+	for _, tt := range tests {
+		fset := token.NewFileSet()
+		af, err := parser.ParseFile(fset, "", anchorTestSource, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn := findMethod(af, "User", tt.method)
+		e, ok := anchorEdit(fset, af, fn, tt.anchor, "X()\n")
+		if ok != tt.ok {
+			t.Errorf("%s %s: anchorEdit() ok = %v", tt.method, tt.anchor, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		got := applyEdits(anchorTestSource, []srcEdit{e})
+		if !strings.Contains(got, tt.want) || strings.Count(got, "X()") != 1 {
+			t.Errorf("%s %s: anchorEdit() inserted at %d:\n%s", tt.method, tt.anchor, e.offset, got)
+		}
+	}
+}
+
+func TestEditNamespace(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		prefix  string
+		entries []string
+		want    string
+	}{
+		{"prefix and entries",
+			"ns := beego.NewNamespace(\"/v1\",\n\tbeego.NSRouter(\"/a\", &A{}),\n)",
+			"/api", []string{"beego.NSRouter(\"/b\", &B{}),"},
+			"ns := beego.NewNamespace(\"/api\",\n\tbeego.NSRouter(\"/a\", &A{}),\n\nbeego.NSRouter(\"/b\", &B{}),\n)"},
+		{"no trailing comma",
+			"ns := beego.NewNamespace(\"/v1\", beego.NSRouter(\"/a\", &A{}) /* a */)",
+			"", []string{"beego.NSRouter(\"/b\", &B{}),"},
+			"ns := beego.NewNamespace(\"/v1\", beego.NSRouter(\"/a\", &A{}), /* a */\nbeego.NSRouter(\"/b\", &B{}),\n)"},
+		// 嵌套的NSNamespace不修改，entries加在最外层
+		{"nested namespaces",
+			"ns := beego.NewNamespace(\"/v1\",\n\tbeego.NSNamespace(\"/user\",\n\t\tbeego.NSInclude(&U{}),\n\t),\n)",
+			"/v2", []string{"beego.NSNamespace(\"/team\", beego.NSInclude(&T{})),"},
+			"ns := beego.NewNamespace(\"/v2\",\n\tbeego.NSNamespace(\"/user\",\n\t\tbeego.NSInclude(&U{}),\n\t),\n\nbeego.NSNamespace(\"/team\", beego.NSInclude(&T{})),\n)"},
+		{"same prefix",
+			"ns := beego.NewNamespace(\"/v1\")",
+			"/v1", nil,
+			"ns := beego.NewNamespace(\"/v1\")"},
+	}
+	for _, tt := range tests {
+		src := "package routers\n\nfunc init() {\n" + tt.src + "\n}\n"
+		got, err := editNamespace(src, tt.prefix, tt.entries)
+		if want := "package routers\n\nfunc init() {\n" + tt.want + "\n}\n"; err != nil || got != want {
+			t.Errorf("%s: editNamespace() = %q, %v, want %q", tt.name, got, err, want)
+		}
+	}
+	if _, err := editNamespace("package routers\n", "/v1", nil); err == nil {
+		t.Error("editNamespace() without NewNamespace succeeded")
+	}
+}
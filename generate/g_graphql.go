@@ -0,0 +1,1116 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	beeLogger "bee/logger"
+	"bee/utils"
+)
+
+// graphScalar model字段的go类型对应的GraphQL类型、resolver中的go类型及过滤条件
+// GraphQL的Int只有32位，64位的整数用Float
+var graphScalar = map[string][3]string{
+	"int":       {"Int", "int32", "IntFilter"},
+	"int8":      {"Int", "int32", "IntFilter"},
+	"int16":     {"Int", "int32", "IntFilter"},
+	"int32":     {"Int", "int32", "IntFilter"},
+	"int64":     {"Float", "float64", "FloatFilter"},
+	"uint":      {"Float", "float64", "FloatFilter"},
+	"uint8":     {"Int", "int32", "IntFilter"},
+	"uint16":    {"Int", "int32", "IntFilter"},
+	"uint32":    {"Float", "float64", "FloatFilter"},
+	"uint64":    {"Float", "float64", "FloatFilter"},
+	"float32":   {"Float", "float64", "FloatFilter"},
+	"float64":   {"Float", "float64", "FloatFilter"},
+	"string":    {"String", "string", "StringFilter"},
+	"bool":      {"Boolean", "bool", "BooleanFilter"},
+	"time.Time": {"Time", "graphql.Time", "TimeFilter"},
+}
+
+// GenerateGraphQL 由数据库的表生成GraphQL的schema及调用models的resolver
+func GenerateGraphQL(connStr, apppath string) {
+	writeGraphQLFiles(readTables(connStr), apppath, getPackagePath(apppath))
+}
+
+// writeGraphQLFiles 生成graph下的schema.graphql及resolver
+func writeGraphQLFiles(tables []*Table, apppath string, pkgPath string) {
+	graphPath := path.Join(apppath, "graph")
+	os.Mkdir(graphPath, 0777)
+
+	beeLogger.Log.Info("Creating GraphQL schema...")
+	fpath := path.Join(graphPath, "schema.graphql")
+	if err := os.WriteFile(fpath, []byte(GraphQLString(tables)), 0666); err != nil {
+		beeLogger.Log.Fatalf("Could not write GraphQL schema to '%s': %s", fpath, err)
+	}
+
+	beeLogger.Log.Info("Creating GraphQL resolver files...")
+	for _, tb := range graphTables(tables) {
+		fileStr := strings.Replace(tb.GraphQLResolverString(tables), "{{pkgPath}}", pkgPath, -1)
+		writeBeegoFile(path.Join(graphPath, getFileName(tb.Name)+".go"), fileStr)
+	}
+	notFound := ormNotFound[ORM]
+	fileStr := strings.Replace(GraphQLLgTPL, "{{notFoundImport}}", notFound[0], -1)
+	fileStr = strings.Replace(fileStr, "{{notFound}}", notFound[1], -1)
+	fileStr = strings.Replace(fileStr, "{{pkgPath}}", pkgPath, -1)
+	writeBeegoFile(path.Join(graphPath, "lg_graph.go"), fileStr)
+
+	beeLogger.Log.Infof("Serve graph.Handler() at a route, e.g. beego.Handler(\"/graphql\", graph.Handler())")
+}
+
+// graphTables 返回有主键的表，不包括m2m的中间表
+func graphTables(tables []*Table) (rv []*Table) {
+	for _, tb := range tables {
+		if tb.Pk == "" || strings.Contains(tb.Name, "_has_") {
+			continue
+		}
+		rv = append(rv, tb)
+	}
+	return
+}
+
+// graphField GraphQL类型的一个字段
+type graphField struct {
+	Name    string // GraphQL中的字段名
+	Field   string // model的字段名
+	Type    string // model的go类型
+	Column  string // query中的列名，fk为 字段名__id
+	Comment string
+	Pk      bool
+	Auto    bool // 自增主键及自动维护的时间，不能修改
+	Rel     *ormRelation
+	RefKey  string // reverseOne、reverseMany的关联表中指向本表的字段在query中的名字
+}
+
+// graphFields 返回表在GraphQL中的字段，关联表不在graphTables中或不能分组加载的关系不生成
+func (tb *Table) graphFields(tables []*Table) (fields []*graphField) {
+	models := make(map[string]*Table)
+	for _, t := range graphTables(tables) {
+		models[utils.CamelCase(t.Name)] = t
+	}
+	rels := make(map[string]*ormRelation)
+	for _, rel := range tb.ormRelations(tables) {
+		rels[rel.Field] = rel
+	}
+	for _, col := range tb.SQLColumns() {
+		f := &graphField{Name: lowerFirst(col.Field), Field: col.Field, Type: col.Type, Column: col.Column, Comment: col.Tag.Comment,
+			Pk: col.Pk, Auto: col.Pk || col.AutoNow || col.AutoNowAdd}
+		if col.Rel {
+			rel := rels[col.Field]
+			if rel == nil || models[rel.Model] == nil {
+				continue
+			}
+			f.Column, f.Rel = snakeString(col.Field)+"__id", rel
+		} else if _, ok := graphScalar[col.Type]; !ok {
+			beeLogger.Log.Warnf("Unsupported type %s of %s.%s, skipped", col.Type, tb.Name, col.Field)
+			continue
+		}
+		fields = append(fields, f)
+	}
+	for _, rel := range tb.ormRelations(tables) {
+		ref := models[rel.Model]
+		if ref == nil || rel.Kind == "fk" || rel.Kind == "one" {
+			continue
+		}
+		f := &graphField{Name: lowerFirst(rel.Field), Field: rel.Field, Rel: rel}
+		if rel.Kind == "reverseOne" || rel.Kind == "reverseMany" {
+			// 按关联表中指向本表的字段分组
+			for _, col := range ref.SQLColumns() {
+				if col.Rel && col.Field == rel.RefField {
+					f.RefKey = snakeString(col.Field) + "__id"
+				}
+			}
+			if f.RefKey == "" {
+				beeLogger.Log.Warnf("%s.%s has no field %s, relation %s.%s skipped", ref.Name, rel.RefField, rel.RefField, tb.Name, rel.Field)
+				continue
+			}
+		}
+		fields = append(fields, f)
+	}
+	return
+}
+
+// graphType 字段在GraphQL中的类型
+func (f *graphField) graphType() string {
+	switch {
+	case f.Pk:
+		return "ID!"
+	case f.Rel == nil && f.Type == "time.Time":
+		// 零值的时间为null
+		return "Time"
+	case f.Rel == nil:
+		return graphScalar[f.Type][0] + "!"
+	case f.Rel.Kind == "fk" || f.Rel.Kind == "one" || f.Rel.Kind == "reverseOne":
+		return f.Rel.Model
+	}
+	return "[" + f.Rel.Model + "!]!"
+}
+
+// graphFilter 字段的过滤条件，关系只有fk、one可以过滤
+func (f *graphField) graphFilter() string {
+	switch {
+	case f.Pk:
+		return "IDFilter"
+	case f.Rel == nil:
+		return graphScalar[f.Type][2]
+	case f.Rel.Kind == "fk" || f.Rel.Kind == "one":
+		return "IDFilter"
+	}
+	return ""
+}
+
+// graphInput 字段在新建、修改时的GraphQL类型，不能修改的为空
+func (f *graphField) graphInput() string {
+	switch {
+	case f.Auto:
+		return ""
+	case f.Rel == nil:
+		return graphScalar[f.Type][0]
+	case f.Rel.Kind == "fk" || f.Rel.Kind == "one":
+		return "ID"
+	case f.Rel.Kind == "m2m":
+		return "[ID!]"
+	}
+	return ""
+}
+
+// GraphQLString 返回所有表的GraphQL schema
+func GraphQLString(tables []*Table) string {
+	var queries, mutations, types []string
+	for _, tb := range graphTables(tables) {
+		modelName := utils.CamelCase(tb.Name)
+		lowerName := lowerFirst(modelName)
+		plural := lowerFirst(protoPlural(modelName))
+		queries = append(queries,
+			fmt.Sprintf("# %s gets a %s by id, null if it doesn't exist", lowerName, modelName),
+			fmt.Sprintf("%s(id: ID!): %s", lowerName, modelName),
+			fmt.Sprintf("# %s returns a page of %ss, first defaults to 10", plural, modelName),
+			fmt.Sprintf("%s(filter: %sFilter, sortby: [String!], order: [String!], first: Int, after: String): %sConnection!", plural, modelName, modelName))
+		mutations = append(mutations,
+			fmt.Sprintf("create%s(input: %sInput!): %s!", modelName, modelName, modelName),
+			fmt.Sprintf("# update%s updates the fields present in input", modelName),
+			fmt.Sprintf("update%s(id: ID!, input: %sInput!): %s!", modelName, modelName, modelName),
+			fmt.Sprintf("delete%s(id: ID!): Boolean!", modelName))
+
+		var fields, filters, inputs []string
+		for _, f := range tb.graphFields(tables) {
+			line := f.Name + ": " + f.graphType()
+			if f.Comment != "" {
+				line += " # " + f.Comment
+			}
+			fields = append(fields, line)
+			if filter := f.graphFilter(); filter != "" {
+				filters = append(filters, f.Name+": "+filter)
+			}
+			if input := f.graphInput(); input != "" {
+				inputs = append(inputs, f.Name+": "+input)
+			}
+		}
+		tpl := strings.Replace(GraphQLTypeTPL, "{{fields}}", strings.Join(fields, "\n  "), -1)
+		tpl = strings.Replace(tpl, "{{filters}}", strings.Join(filters, "\n  "), -1)
+		tpl = strings.Replace(tpl, "{{inputs}}", strings.Join(inputs, "\n  "), -1)
+		tpl = strings.Replace(tpl, "{{modelName}}", modelName, -1)
+		tpl = strings.Replace(tpl, "{{tableName}}", tb.Name, -1)
+		tpl = strings.Replace(tpl, "{{tableComment}}", tb.Comments, -1)
+		types = append(types, tpl)
+	}
+	rv := strings.Replace(GraphQLTPL, "{{queries}}", strings.Join(queries, "\n  "), -1)
+	rv = strings.Replace(rv, "{{mutations}}", strings.Join(mutations, "\n  "), -1)
+	rv = strings.Replace(rv, "{{types}}", strings.Join(types, ""), -1)
+	return rv
+}
+
+// GraphQLResolverString 返回表的resolver，关系在列表中第一次读取时为整个列表一次查询
+func (tb *Table) GraphQLResolverString(tables []*Table) string {
+	modelName := utils.CamelCase(tb.Name)
+	lowerName := lowerFirst(modelName)
+	var getters, filterFields, filterQuery, inputFields, applies []string
+	for _, f := range tb.graphFields(tables) {
+		if filter := f.graphFilter(); filter != "" {
+			filterFields = append(filterFields, fmt.Sprintf("%s *lg%s", f.Field, filter))
+			filterQuery = append(filterQuery, fmt.Sprintf("f.%s.query(q, %q)", f.Field, f.Column))
+		}
+		if f.Rel != nil {
+			getters = append(getters, f.relGetter(lowerName))
+			switch f.Rel.Kind {
+			case "fk", "one":
+				inputFields = append(inputFields, fmt.Sprintf("%s *graphql.ID", f.Field))
+				applies = append(applies, fmt.Sprintf(graphApplyFkTPL, f.Field, f.Field, f.Field, f.Rel.Model, f.Field))
+			case "m2m":
+				inputFields = append(inputFields, fmt.Sprintf("%s *[]graphql.ID", f.Field))
+				applies = append(applies, fmt.Sprintf(graphApplyM2MTPL, f.Field, f.Field, f.Field, f.Field, f.Field, f.Rel.Model, f.Field))
+			}
+			continue
+		}
+		scalar := graphScalar[f.Type]
+		switch {
+		case f.Pk:
+			getters = append(getters, fmt.Sprintf("func (r *%sResolver) %s() graphql.ID {\n\treturn lgID(r.m.%s)\n}\n", lowerName, f.Field, f.Field))
+		case f.Type == "time.Time":
+			getters = append(getters, fmt.Sprintf("func (r *%sResolver) %s() *graphql.Time {\n\treturn lgTime(r.m.%s)\n}\n", lowerName, f.Field, f.Field))
+		case scalar[1] != f.Type:
+			getters = append(getters, fmt.Sprintf("func (r *%sResolver) %s() %s {\n\treturn %s(r.m.%s)\n}\n", lowerName, f.Field, scalar[1], scalar[1], f.Field))
+		default:
+			getters = append(getters, fmt.Sprintf("func (r *%sResolver) %s() %s {\n\treturn r.m.%s\n}\n", lowerName, f.Field, scalar[1], f.Field))
+		}
+		if f.Auto {
+			continue
+		}
+		inputFields = append(inputFields, fmt.Sprintf("%s *%s", f.Field, scalar[1]))
+		value := "*in." + f.Field
+		switch {
+		case f.Type == "time.Time":
+			value = "in." + f.Field + ".Time"
+		case scalar[1] != f.Type:
+			value = f.Type + "(*in." + f.Field + ")"
+		}
+		applies = append(applies, fmt.Sprintf("if in.%s != nil {\n\tm.%s = %s\n\tfields = append(fields, %q)\n}", f.Field, f.Field, value, f.Field))
+	}
+	rv := strings.Replace(GraphQLResolverTPL, "{{getters}}", strings.Join(getters, "\n"), -1)
+	rv = strings.Replace(rv, "{{filterFields}}", strings.Join(filterFields, "\n"), -1)
+	rv = strings.Replace(rv, "{{filterQuery}}", strings.Join(filterQuery, "\n"), -1)
+	rv = strings.Replace(rv, "{{inputFields}}", strings.Join(inputFields, "\n"), -1)
+	rv = strings.Replace(rv, "{{applies}}", strings.Join(applies, "\n"), -1)
+	rv = strings.Replace(rv, "{{plural}}", protoPlural(modelName), -1)
+	rv = strings.Replace(rv, "{{modelName}}", modelName, -1)
+	rv = strings.Replace(rv, "{{lowerName}}", lowerName, -1)
+	return rv
+}
+
+// relGetter 返回关系字段的resolver，第一次读取时查询整个列表的关联记录并按本表的记录分组
+func (f *graphField) relGetter(lowerName string) string {
+	rel := f.Rel
+	refName := lowerFirst(rel.Model)
+	var load, key string
+	switch rel.Kind {
+	case "fk", "one":
+		load = fmt.Sprintf(graphLoadFkTPL, f.Field, f.Field, refName, rel.RefPk, rel.Model)
+		key = fmt.Sprintf("r.m.%s.Id", f.Field)
+	case "reverseOne", "reverseMany":
+		load = fmt.Sprintf(graphLoadReverseTPL, refName, f.RefKey, rel.Model, rel.RefField, rel.RefField)
+		key = "r.m.Id"
+	case "m2m":
+		load = fmt.Sprintf(graphLoadM2MTPL, rel.Through, rel.JoinCol, rel.JoinRef, refName, rel.RefPk, rel.Model, refName)
+		key = "r.m.Id"
+	}
+	rv := graphRelOneTPL
+	if rel.Kind == "reverseMany" || rel.Kind == "m2m" {
+		rv = graphRelManyTPL
+	}
+	if rel.Kind == "fk" || rel.Kind == "one" {
+		rv = strings.Replace(rv, "{{nilCheck}}", fmt.Sprintf("if r.m.%s == nil {\n\treturn nil, nil\n}\n", f.Field), -1)
+	} else {
+		rv = strings.Replace(rv, "{{nilCheck}}", "", -1)
+	}
+	rv = strings.Replace(rv, "{{load}}", load, -1)
+	rv = strings.Replace(rv, "{{key}}", key, -1)
+	rv = strings.Replace(rv, "{{field}}", f.Field, -1)
+	rv = strings.Replace(rv, "{{refName}}", refName, -1)
+	rv = strings.Replace(rv, "{{lowerName}}", lowerName, -1)
+	return rv
+}
+
+const (
+	// 关系字段为一条记录的resolver
+	graphRelOneTPL = `
+// {{field}} is loaded for all the records of the list with one query
+func (r *{{lowerName}}Resolver) {{field}}() (*{{refName}}Resolver, error) {
+	{{nilCheck}}v, err := r.batch.load("{{field}}", func() (interface{}, error) {
+		{{load}}
+	})
+	if err != nil {
+		return nil, lgError(err)
+	}
+	if l := v.(map[int][]*{{refName}}Resolver)[{{key}}]; len(l) > 0 {
+		return l[0], nil
+	}
+	return nil, nil
+}
+`
+	// 关系字段为多条记录的resolver
+	graphRelManyTPL = `
+// {{field}} is loaded for all the records of the list with one query
+func (r *{{lowerName}}Resolver) {{field}}() ([]*{{refName}}Resolver, error) {
+	v, err := r.batch.load("{{field}}", func() (interface{}, error) {
+		{{load}}
+	})
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return v.(map[int][]*{{refName}}Resolver)[{{key}}], nil
+}
+`
+	// fk、one按关联记录的主键分组
+	graphLoadFkTPL = `var ids []int
+	for _, m := range r.batch.ms {
+		if m.%s != nil {
+			ids = append(ids, m.%s.Id)
+		}
+	}
+	return %sGroupsBy(%q, ids, func(m *models.%s) int { return m.Id })`
+	// reverseOne、reverseMany按关联记录中指向本表的字段分组
+	graphLoadReverseTPL = `return %sGroupsBy(%q, r.batch.ids(), func(m *models.%s) int {
+		if m.%s == nil {
+			return 0
+		}
+		return m.%s.Id
+	})`
+	// m2m先查询中间表，再按主键查询关联记录
+	graphLoadM2MTPL = `links, err := models.LgThroughIds(%q, %q, %q, r.batch.ids())
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for _, l := range links {
+		ids = append(ids, l...)
+	}
+	refs, err := %sGroupsBy(%q, ids, func(m *models.%s) int { return m.Id })
+	if err != nil {
+		return nil, err
+	}
+	rv := make(map[int][]*%sResolver)
+	for id, l := range links {
+		for _, refId := range l {
+			rv[id] = append(rv[id], refs[refId]...)
+		}
+	}
+	return rv, nil`
+	// input中fk、one的关联记录
+	graphApplyFkTPL = `if in.%s != nil {
+	id, err := lgInt(*in.%s)
+	if err != nil {
+		return nil, err
+	}
+	m.%s = &models.%s{Id: id}
+	fields = append(fields, %q)
+}`
+	// input中m2m的关联记录，替换原有的关联
+	graphApplyM2MTPL = `if in.%s != nil {
+	m.%s = nil
+	for _, v := range *in.%s {
+		id, err := lgInt(v)
+		if err != nil {
+			return nil, err
+		}
+		m.%s = append(m.%s, &models.%s{Id: id})
+	}
+	fields = append(fields, %q)
+}`
+)
+
+const (
+	// GraphQL schema的公共部分
+	GraphQLTPL = `schema {
+  query: Query
+  mutation: Mutation
+}
+
+# Time is an RFC 3339 time
+scalar Time
+
+# PageInfo is the Relay page info of a connection
+type PageInfo {
+  hasNextPage: Boolean!
+  hasPreviousPage: Boolean!
+  startCursor: String
+  endCursor: String
+}
+
+# StringFilter matches a String column, each condition is the column__op of the REST query
+input StringFilter {
+  exact: String
+  iexact: String
+  contains: String
+  icontains: String
+  startswith: String
+  istartswith: String
+  endswith: String
+  iendswith: String
+  in: [String!]
+  isnull: Boolean
+}
+
+# IntFilter matches an Int column
+input IntFilter {
+  exact: Int
+  gt: Int
+  gte: Int
+  lt: Int
+  lte: Int
+  in: [Int!]
+  isnull: Boolean
+}
+
+# FloatFilter matches a Float column
+input FloatFilter {
+  exact: Float
+  gt: Float
+  gte: Float
+  lt: Float
+  lte: Float
+  in: [Float!]
+  isnull: Boolean
+}
+
+# BooleanFilter matches a Boolean column
+input BooleanFilter {
+  exact: Boolean
+  isnull: Boolean
+}
+
+# TimeFilter matches a Time column
+input TimeFilter {
+  exact: Time
+  gt: Time
+  gte: Time
+  lt: Time
+  lte: Time
+  isnull: Boolean
+}
+
+# IDFilter matches the id or a relation by its id
+input IDFilter {
+  exact: ID
+  in: [ID!]
+  isnull: Boolean
+}
+
+type Query {
+  {{queries}}
+}
+
+type Mutation {
+  {{mutations}}
+}
+{{types}}`
+	// 每个表的类型、分页、过滤条件及输入
+	GraphQLTypeTPL = `
+# {{modelName}} [{{tableName}}] {{tableComment}}
+type {{modelName}} {
+  {{fields}}
+}
+
+# {{modelName}}Connection is a page of {{modelName}}s
+type {{modelName}}Connection {
+  edges: [{{modelName}}Edge!]!
+  nodes: [{{modelName}}!]!
+  pageInfo: PageInfo!
+  totalCount: Int!
+}
+
+type {{modelName}}Edge {
+  cursor: String!
+  node: {{modelName}}!
+}
+
+# {{modelName}}Filter has the conditions of the REST query, all of them must match.
+# search and dsearch are column1>value1|column2>value2 matched by contains and exact,
+# groups separated by ^ must all match; notEmpty is a column, neq is column>value
+input {{modelName}}Filter {
+  search: String
+  dsearch: String
+  notEmpty: String
+  neq: String
+  {{filters}}
+}
+
+# {{modelName}}Input has the fields to create or update a {{modelName}}
+input {{modelName}}Input {
+  {{inputs}}
+}
+`
+	// resolver的公共函数、过滤条件及分页
+	GraphQLLgTPL = `package graph
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"{{pkgPath}}/models"
+
+	{{notFoundImport}}
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// Resolver is the root resolver of the queries and mutations of all tables
+type Resolver struct{}
+
+// Schema parses schema.graphql with the root Resolver, it panics if they don't match
+func Schema() *graphql.Schema {
+	return graphql.MustParseSchema(schema, &Resolver{})
+}
+
+// Handler serves the GraphQL queries posted as JSON, e.g. beego.Handler("/graphql", graph.Handler())
+func Handler() http.Handler {
+	return &relay.Handler{Schema: Schema()}
+}
+
+// lgLoads 列表中关系的加载结果，同一个关系只查询一次
+type lgLoads struct {
+	mu    sync.Mutex
+	loads map[string]*lgLoad
+}
+
+type lgLoad struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// load 第一次读取name时调用fn，并发的读取等待同一个结果
+func (l *lgLoads) load(name string, fn func() (interface{}, error)) (interface{}, error) {
+	l.mu.Lock()
+	if l.loads == nil {
+		l.loads = make(map[string]*lgLoad)
+	}
+	ld, ok := l.loads[name]
+	if !ok {
+		ld = &lgLoad{}
+		l.loads[name] = ld
+	}
+	l.mu.Unlock()
+	ld.once.Do(func() { ld.value, ld.err = fn() })
+	return ld.value, ld.err
+}
+
+// lgGraphError carries the code and the invalid fields in the extensions of the GraphQL error
+type lgGraphError struct {
+	error
+	extensions map[string]interface{}
+}
+
+func (e *lgGraphError) Extensions() map[string]interface{} {
+	return e.extensions
+}
+
+// lgError adds the code of the errors of models, validation errors carry the invalid fields
+func lgError(err error) error {
+	var ve *models.LgValidationError
+	if errors.As(err, &ve) {
+		var fields []map[string]string
+		for _, fe := range ve.Errors {
+			fields = append(fields, map[string]string{"field": fe.Field, "message": fe.Message})
+		}
+		return &lgGraphError{err, map[string]interface{}{"code": "BAD_USER_INPUT", "fields": fields}}
+	}
+	if lgNotFound(err) {
+		return &lgGraphError{err, map[string]interface{}{"code": "NOT_FOUND"}}
+	}
+	return err
+}
+
+// lgNotFound 记录是否不存在
+func lgNotFound(err error) bool {
+	return {{notFound}}
+}
+
+func lgID(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+func lgInt(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", id)
+	}
+	return n, nil
+}
+
+// lgTime 零值的时间为null
+func lgTime(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: t}
+}
+
+// lgIds 去重后用逗号连接，为query中__in的值
+func lgIds(ids []int) string {
+	seen := make(map[int]bool)
+	var l []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			l = append(l, strconv.Itoa(id))
+		}
+	}
+	return strings.Join(l, ",")
+}
+
+func lgStrings(l *[]string) []string {
+	if l == nil {
+		return nil
+	}
+	return *l
+}
+
+// lgPut 把过滤条件的值v加到query的key中，v为nil时忽略
+func lgPut(q map[string]string, key string, v interface{}) {
+	if reflect.ValueOf(v).IsNil() {
+		return
+	}
+	var l []string
+	switch v := v.(type) {
+	case *string:
+		l = []string{*v}
+	case *int32:
+		l = []string{strconv.FormatInt(int64(*v), 10)}
+	case *float64:
+		l = []string{strconv.FormatFloat(*v, 'f', -1, 64)}
+	case *bool:
+		l = []string{"0"}
+		if *v {
+			l = []string{"1"}
+		}
+	case *graphql.Time:
+		l = []string{v.Time.In(time.Local).Format("2006-01-02 15:04:05")}
+	case *graphql.ID:
+		l = []string{string(*v)}
+	case *[]string:
+		l = *v
+	case *[]int32:
+		for _, n := range *v {
+			l = append(l, strconv.FormatInt(int64(n), 10))
+		}
+	case *[]float64:
+		for _, n := range *v {
+			l = append(l, strconv.FormatFloat(n, 'f', -1, 64))
+		}
+	case *[]graphql.ID:
+		for _, id := range *v {
+			l = append(l, string(id))
+		}
+	}
+	q[key] = strings.Join(l, ",")
+}
+
+type lgStringFilter struct {
+	Exact, Iexact, Contains, Icontains, Startswith, Istartswith, Endswith, Iendswith *string
+	In                                                                                *[]string
+	Isnull                                                                            *bool
+}
+
+// query adds the conditions of f on key to q
+func (f *lgStringFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__iexact", f.Iexact)
+	lgPut(q, key+"__contains", f.Contains)
+	lgPut(q, key+"__icontains", f.Icontains)
+	lgPut(q, key+"__startswith", f.Startswith)
+	lgPut(q, key+"__istartswith", f.Istartswith)
+	lgPut(q, key+"__endswith", f.Endswith)
+	lgPut(q, key+"__iendswith", f.Iendswith)
+	lgPut(q, key+"__in", f.In)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgIntFilter struct {
+	Exact, Gt, Gte, Lt, Lte *int32
+	In                      *[]int32
+	Isnull                  *bool
+}
+
+func (f *lgIntFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__gt", f.Gt)
+	lgPut(q, key+"__gte", f.Gte)
+	lgPut(q, key+"__lt", f.Lt)
+	lgPut(q, key+"__lte", f.Lte)
+	lgPut(q, key+"__in", f.In)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgFloatFilter struct {
+	Exact, Gt, Gte, Lt, Lte *float64
+	In                      *[]float64
+	Isnull                  *bool
+}
+
+func (f *lgFloatFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__gt", f.Gt)
+	lgPut(q, key+"__gte", f.Gte)
+	lgPut(q, key+"__lt", f.Lt)
+	lgPut(q, key+"__lte", f.Lte)
+	lgPut(q, key+"__in", f.In)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgBooleanFilter struct {
+	Exact, Isnull *bool
+}
+
+func (f *lgBooleanFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgTimeFilter struct {
+	Exact, Gt, Gte, Lt, Lte *graphql.Time
+	Isnull                  *bool
+}
+
+func (f *lgTimeFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__gt", f.Gt)
+	lgPut(q, key+"__gte", f.Gte)
+	lgPut(q, key+"__lt", f.Lt)
+	lgPut(q, key+"__lte", f.Lte)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgIDFilter struct {
+	Exact  *graphql.ID
+	In     *[]graphql.ID
+	Isnull *bool
+}
+
+func (f *lgIDFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__in", f.In)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+// lgCursor 游标为记录在查询结果中的位置
+func lgCursor(offset int64) string {
+	return base64.StdEncoding.EncodeToString([]byte("cursor:" + strconv.FormatInt(offset, 10)))
+}
+
+// lgOffset 返回after之后的位置，after为nil时为0
+func lgOffset(after *string) (int64, error) {
+	if after == nil {
+		return 0, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(*after)
+	if err == nil && strings.HasPrefix(string(b), "cursor:") {
+		if n, e := strconv.ParseInt(strings.TrimPrefix(string(b), "cursor:"), 10, 64); e == nil && n >= 0 {
+			return n + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor %q", *after)
+}
+
+// lgLimit first为nil时与REST的GetAll相同取10条
+func lgLimit(first *int32) (int64, error) {
+	if first == nil {
+		return 10, nil
+	}
+	if *first <= 0 {
+		return 0, errors.New("first must be positive")
+	}
+	return int64(*first), nil
+}
+
+// lgPageInfo is the page info of n records from offset of total
+type lgPageInfo struct {
+	offset int64
+	n      int
+	total  int64
+}
+
+func (p *lgPageInfo) HasNextPage() bool {
+	return p.offset+int64(p.n) < p.total
+}
+
+func (p *lgPageInfo) HasPreviousPage() bool {
+	return p.offset > 0
+}
+
+func (p *lgPageInfo) StartCursor() *string {
+	if p.n == 0 {
+		return nil
+	}
+	c := lgCursor(p.offset)
+	return &c
+}
+
+func (p *lgPageInfo) EndCursor() *string {
+	if p.n == 0 {
+		return nil
+	}
+	c := lgCursor(p.offset + int64(p.n) - 1)
+	return &c
+}
+`
+	// 每个表的resolver
+	GraphQLResolverTPL = `package graph
+
+import (
+	"{{pkgPath}}/models"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// {{lowerName}}Resolver resolves the fields of a {{modelName}}
+type {{lowerName}}Resolver struct {
+	m     *models.{{modelName}}
+	batch *{{lowerName}}Batch
+}
+
+{{getters}}
+
+// {{lowerName}}Batch is the {{modelName}}s of a list, a relation is loaded for the whole
+// list the first time it's resolved instead of once per {{modelName}}
+type {{lowerName}}Batch struct {
+	lgLoads
+	ms []*models.{{modelName}}
+}
+
+// new{{modelName}}Batch wraps the {{modelName}}s returned by the functions of models
+func new{{modelName}}Batch(l []interface{}) *{{lowerName}}Batch {
+	b := &{{lowerName}}Batch{}
+	for _, v := range l {
+		m := v.(models.{{modelName}})
+		b.ms = append(b.ms, &m)
+	}
+	return b
+}
+
+func (b *{{lowerName}}Batch) resolvers() []*{{lowerName}}Resolver {
+	rv := make([]*{{lowerName}}Resolver, len(b.ms))
+	for i, m := range b.ms {
+		rv[i] = &{{lowerName}}Resolver{m: m, batch: b}
+	}
+	return rv
+}
+
+func (b *{{lowerName}}Batch) ids() []int {
+	ids := make([]int, len(b.ms))
+	for i, m := range b.ms {
+		ids[i] = m.Id
+	}
+	return ids
+}
+
+// {{lowerName}}Of resolves a single {{modelName}}
+func {{lowerName}}Of(m *models.{{modelName}}) *{{lowerName}}Resolver {
+	return new{{modelName}}Batch([]interface{}{*m}).resolvers()[0]
+}
+
+// {{lowerName}}GroupsBy loads the {{modelName}}s whose key is in ids with one query, grouped by group
+func {{lowerName}}GroupsBy(key string, ids []int, group func(m *models.{{modelName}}) int) (map[int][]*{{lowerName}}Resolver, error) {
+	rv := make(map[int][]*{{lowerName}}Resolver)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	l, _, err := models.GetAll{{modelName}}(map[string]string{key + "__in": lgIds(ids)}, nil, nil, nil, 0, -1, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range new{{modelName}}Batch(l).resolvers() {
+		id := group(v.m)
+		rv[id] = append(rv[id], v)
+	}
+	return rv, nil
+}
+
+// {{lowerName}}Connection is a page of {{modelName}}s from offset
+type {{lowerName}}Connection struct {
+	nodes  []*{{lowerName}}Resolver
+	offset int64
+	total  int64
+}
+
+func (c *{{lowerName}}Connection) Edges() []*{{lowerName}}Edge {
+	edges := make([]*{{lowerName}}Edge, len(c.nodes))
+	for i, n := range c.nodes {
+		edges[i] = &{{lowerName}}Edge{cursor: lgCursor(c.offset + int64(i)), node: n}
+	}
+	return edges
+}
+
+func (c *{{lowerName}}Connection) Nodes() []*{{lowerName}}Resolver {
+	return c.nodes
+}
+
+func (c *{{lowerName}}Connection) PageInfo() *lgPageInfo {
+	return &lgPageInfo{offset: c.offset, n: len(c.nodes), total: c.total}
+}
+
+func (c *{{lowerName}}Connection) TotalCount() int32 {
+	return int32(c.total)
+}
+
+type {{lowerName}}Edge struct {
+	cursor string
+	node   *{{lowerName}}Resolver
+}
+
+func (e *{{lowerName}}Edge) Cursor() string {
+	return e.cursor
+}
+
+func (e *{{lowerName}}Edge) Node() *{{lowerName}}Resolver {
+	return e.node
+}
+
+// {{lowerName}}Filter is the {{modelName}}Filter input
+type {{lowerName}}Filter struct {
+	Search   *string
+	Dsearch  *string
+	NotEmpty *string
+	Neq      *string
+	{{filterFields}}
+}
+
+// query converts f to the query of GetAll{{modelName}}
+func (f *{{lowerName}}Filter) query() map[string]string {
+	q := make(map[string]string)
+	if f == nil {
+		return q
+	}
+	lgPut(q, "search", f.Search)
+	lgPut(q, "dsearch", f.Dsearch)
+	lgPut(q, "not_empty", f.NotEmpty)
+	lgPut(q, "neq", f.Neq)
+	{{filterQuery}}
+	return q
+}
+
+// {{lowerName}}Input is the {{modelName}}Input input
+type {{lowerName}}Input struct {
+	{{inputFields}}
+}
+
+// apply sets the fields present in the input to m and returns them
+func (in *{{lowerName}}Input) apply(m *models.{{modelName}}) (fields []string, err error) {
+	{{applies}}
+	return fields, nil
+}
+
+// {{modelName}} gets a {{modelName}} by id, null if it doesn't exist
+func (r *Resolver) {{modelName}}(args struct{ Id graphql.ID }) (*{{lowerName}}Resolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.Get{{modelName}}ById(id)
+	if lgNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return {{lowerName}}Of(m), nil
+}
+
+// {{plural}} returns a page of {{modelName}}s matching filter after the cursor after
+func (r *Resolver) {{plural}}(args struct {
+	Filter *{{lowerName}}Filter
+	Sortby *[]string
+	Order  *[]string
+	First  *int32
+	After  *string
+}) (*{{lowerName}}Connection, error) {
+	limit, err := lgLimit(args.First)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := lgOffset(args.After)
+	if err != nil {
+		return nil, err
+	}
+	l, pager, err := models.GetAll{{modelName}}(args.Filter.query(), nil, lgStrings(args.Sortby), lgStrings(args.Order), offset, limit, nil, 1)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return &{{lowerName}}Connection{nodes: new{{modelName}}Batch(l).resolvers(), offset: offset, total: pager.Page.TotalCount}, nil
+}
+
+// Create{{modelName}} validates the input and adds the {{modelName}} together with its relations
+func (r *Resolver) Create{{modelName}}(args struct{ Input {{lowerName}}Input }) (*{{lowerName}}Resolver, error) {
+	m := &models.{{modelName}}{}
+	if _, err := args.Input.apply(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, lgError(err)
+	}
+	if _, err := models.Add{{modelName}}HasMany(m); err != nil {
+		return nil, lgError(err)
+	}
+	m, err := models.Get{{modelName}}ById(m.Id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return {{lowerName}}Of(m), nil
+}
+
+// Update{{modelName}} validates and updates the fields present in the input
+func (r *Resolver) Update{{modelName}}(args struct {
+	Id    graphql.ID
+	Input {{lowerName}}Input
+}) (*{{lowerName}}Resolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.Get{{modelName}}ById(id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	fields, err := args.Input.apply(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		if err := m.ValidateFields(fields...); err != nil {
+			return nil, lgError(err)
+		}
+		if err := models.Patch{{modelName}}ById(m, fields); err != nil {
+			return nil, lgError(err)
+		}
+	}
+	if m, err = models.Get{{modelName}}ById(id); err != nil {
+		return nil, lgError(err)
+	}
+	return {{lowerName}}Of(m), nil
+}
+
+// Delete{{modelName}} deletes a {{modelName}} by id
+func (r *Resolver) Delete{{modelName}}(args struct{ Id graphql.ID }) (bool, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := models.Get{{modelName}}ById(id); err != nil {
+		return false, lgError(err)
+	}
+	if err := models.Delete{{modelName}}(id); err != nil {
+		return false, lgError(err)
+	}
+	return true, nil
+}
+`
+)
@@ -0,0 +1,2143 @@
+package generate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"bee/utils"
+)
+
+// ORMs 可选的持久层，beego为beego orm，gorm为gorm.io/gorm，sqlx为github.com/jmoiron/sqlx
+var ORMs = []string{"beego", "gorm", "sqlx"}
+
+// ormRelation gorm、sqlx中关系字段对应的表及列
+type ormRelation struct {
+	Field    string // 字段名
+	Model    string // 关联的model
+	Kind     string // fk、one、reverseOne、reverseMany、m2m
+	Column   string // fk、one为本表的列，reverseOne、reverseMany为关联表的列
+	RefField string // reverseOne、reverseMany中关联表指向本表的字段
+	RefPk    string // 关联表的主键
+	Through  string // m2m的中间表
+	JoinCol  string // 中间表中本表的列
+	JoinRef  string // 中间表中关联表的列
+}
+
+// ormNotFound 各持久层记录不存在的import及判断
+var ormNotFound = map[string][2]string{
+	"beego": {`"github.com/astaxie/beego/orm"`, "err == orm.ErrNoRows"},
+	"gorm":  {`"gorm.io/gorm"`, "errors.Is(err, gorm.ErrRecordNotFound)"},
+	"sqlx":  {`"database/sql"`, "errors.Is(err, sql.ErrNoRows)"},
+}
+
+// ormRelations 返回表的关系字段，关联表按model名在tables中查找
+func (tb *Table) ormRelations(tables []*Table) (rels []*ormRelation) {
+	tableOf := func(model string) *Table {
+		for _, t := range tables {
+			if utils.CamelCase(t.Name) == model {
+				return t
+			}
+		}
+		return nil
+	}
+	sqlCols := make(map[string]*SQLColumn)
+	for _, col := range tb.SQLColumns() {
+		sqlCols[col.Field] = col
+	}
+	modelName := utils.CamelCase(tb.Name)
+	for _, v := range tb.Columns {
+		if !v.IsNeed {
+			continue
+		}
+		rel := &ormRelation{Field: v.Name, Model: strings.TrimLeft(v.Type, "[]*"), RefPk: "id"}
+		ref := tableOf(rel.Model)
+		if ref != nil && ref.Pk != "" {
+			rel.RefPk = ref.Pk
+		}
+		switch {
+		case v.Tag.RelFk || v.Tag.RelOne:
+			rel.Kind = "fk"
+			if v.Tag.RelOne {
+				rel.Kind = "one"
+			}
+			rel.Column = sqlCols[v.Name].Column
+		case v.Tag.M2M:
+			// 中间表的列约定为 表名_id
+			rel.Kind = "m2m"
+			rel.Through = v.Tag.M2MThroungh
+			refName := snakeString(rel.Model)
+			if ref != nil {
+				refName = ref.Name
+			}
+			if rel.Through == "" {
+				rel.Through = tb.Name + "_has_" + refName
+			}
+			rel.JoinCol = tb.Name + "_id"
+			rel.JoinRef = refName + "_id"
+		case v.Tag.ReverseOne || v.Tag.ReverseMany:
+			rel.Kind = "reverseMany"
+			if v.Tag.ReverseOne {
+				rel.Kind = "reverseOne"
+			}
+			// 关联表中与本表同名的关系字段
+			rel.RefField = modelName
+			rel.Column = snakeString(modelName) + "_id"
+			if ref != nil {
+				for _, col := range ref.SQLColumns() {
+					if col.Rel && col.Field == modelName {
+						rel.Column = col.Column
+					}
+				}
+			}
+		default:
+			continue
+		}
+		rels = append(rels, rel)
+	}
+	return
+}
+
+// ormColumnsString 返回query、sortby可用的字段名及列名到列的对应，关系字段还可以用 Team__Id、team__id
+func (tb *Table) ormColumnsString() string {
+	var lines []string
+	seen := make(map[string]bool)
+	add := func(key string, column string) {
+		if !seen[key] {
+			seen[key] = true
+			lines = append(lines, fmt.Sprintf("%q: %q,", key, column))
+		}
+	}
+	for _, col := range tb.SQLColumns() {
+		add(col.Field, col.Column)
+		add(col.Column, col.Column)
+		if col.Rel {
+			add(col.Field+"__Id", col.Column)
+			add(snakeString(col.Field)+"__id", col.Column)
+		}
+	}
+	return fmt.Sprintf("// {{modelName}}Columns maps the fields and columns accepted by query and sortby to the columns\nvar {{modelName}}Columns = map[string]string{\n%s\n}\n",
+		strings.Join(lines, "\n"))
+}
+
+// GormString 返回gorm的model结构，fk、one的关系字段另有保存外键的 字段名Id
+func (tb *Table) GormString(tables []*Table) string {
+	rels := make(map[string]*ormRelation)
+	for _, rel := range tb.ormRelations(tables) {
+		rels[rel.Field] = rel
+	}
+	rv := fmt.Sprintf("type %s struct {\n", utils.CamelCase(tb.Name))
+	for _, v := range tb.Columns {
+		if !v.IsNeed {
+			continue
+		}
+		rel, ok := rels[v.Name]
+		if !ok {
+			rv += fmt.Sprintf("%s %s `%s%s`\n", v.Name, v.Type, v.Tag.gormTag(), descriptionTag(v.Tag))
+			continue
+		}
+		var gormTag string
+		switch rel.Kind {
+		case "fk", "one":
+			gormTag = fmt.Sprintf("foreignKey:%sId", v.Name)
+		case "reverseOne", "reverseMany":
+			gormTag = fmt.Sprintf("foreignKey:%sId", rel.RefField)
+		case "m2m":
+			gormTag = fmt.Sprintf("many2many:%s;joinForeignKey:%s;joinReferences:%s", rel.Through, utils.CamelCase(rel.JoinCol), utils.CamelCase(rel.JoinRef))
+		}
+		rv += fmt.Sprintf("%s %s `gorm:\"%s\"%s`\n", v.Name, v.Type, gormTag, descriptionTag(v.Tag))
+		if rel.Kind == "fk" || rel.Kind == "one" {
+			rv += fmt.Sprintf("%sId *int `gorm:\"column:%s\" json:\"-\"`\n", v.Name, rel.Column)
+		}
+	}
+	rv += "}\n"
+	return rv
+}
+
+// SqlxString 返回sqlx的model结构，关系字段不是列，db标签为-
+func (tb *Table) SqlxString() string {
+	rv := fmt.Sprintf("type %s struct {\n", utils.CamelCase(tb.Name))
+	for _, v := range tb.Columns {
+		if !v.IsNeed {
+			continue
+		}
+		column := v.Tag.Column
+		if v.Tag.RelFk || v.Tag.RelOne || v.Tag.ReverseOne || v.Tag.ReverseMany || v.Tag.M2M || v.Tag.RelM2M {
+			column = "-"
+		}
+		rv += fmt.Sprintf("%s %s `db:\"%s\"%s`\n", v.Name, v.Type, column, descriptionTag(v.Tag))
+	}
+	rv += "}\n"
+	return rv
+}
+
+// gormTag 列的gorm标签
+func (tag *OrmTag) gormTag() string {
+	opts := []string{"column:" + tag.Column}
+	if tag.Pk {
+		opts = append(opts, "primaryKey")
+		if tag.Auto {
+			opts = append(opts, "autoIncrement")
+		} else {
+			opts = append(opts, "autoIncrement:false")
+		}
+	}
+	if tag.Type != "" {
+		opts = append(opts, "type:"+tag.Type)
+	} else if tag.Decimals != "" {
+		opts = append(opts, fmt.Sprintf("type:decimal(%s,%s)", tag.Digits, tag.Decimals))
+	}
+	if tag.Size != "" {
+		opts = append(opts, "size:"+tag.Size)
+	}
+	if !tag.Null && !tag.Pk {
+		opts = append(opts, "not null")
+	}
+	if tag.AutoNowAdd {
+		opts = append(opts, "autoCreateTime")
+	}
+	if tag.AutoNow {
+		opts = append(opts, "autoUpdateTime")
+	}
+	// 标签中不能含有;和引号
+	if tag.Default != "" && !strings.ContainsAny(tag.Default, ";\"`") {
+		opts = append(opts, "default:"+tag.Default)
+	}
+	return fmt.Sprintf("gorm:\"%s\"", strings.Join(opts, ";"))
+}
+
+// descriptionTag 与beego的model一样，注释写入description标签
+func descriptionTag(tag *OrmTag) string {
+	if tag.Comment == "" {
+		return ""
+	}
+	return fmt.Sprintf(" description:\"%s\"", tag.Comment)
+}
+
+// writeORMFiles 写入持久层的公共文件，gorm、sqlx另有按SQL生成的查询条件
+func writeORMFiles(mPath string) {
+	query := ModelLgQuery
+	if ORM != "beego" {
+		query = ModelLgQuerySQL
+	}
+	writeFormattedFile(path.Join(mPath, "lg_query.go"), query)
+	lgOrm := ormTPLs[ORM]
+	if ORM == "beego" && Beego == "v2" {
+		lgOrm = ModelLgOrmBeegoV2
+	}
+	writeFormattedFile(path.Join(mPath, "lg_orm.go"), lgOrm)
+}
+
+// ormMainInit 返回非beego框架的main中连接数据库的import及init代码
+func ormMainInit() (imports string, init string) {
+	if ORM == "beego" {
+		return "\"github.com/astaxie/beego/orm\"\n_ \"github.com/go-sql-driver/mysql\"",
+			"orm.RegisterDriver(\"mysql\", orm.DRMySQL)\norm.RegisterDataBase(\"default\", \"mysql\", os.Getenv(\"SQLCONN\"), 1000, 2000)"
+	}
+	return "\"{{pkgPath}}/models\"", "if err := models.OpenDB(os.Getenv(\"SQLCONN\")); err != nil {\npanic(err)\n}"
+}
+
+// ORMModelString 返回gorm或sqlx的model代码，导出的函数与beego的model相同
+func (tb *Table) ORMModelString(tables []*Table) string {
+	modelName := utils.CamelCase(tb.Name)
+	if tb.Pk == "" || strings.Contains(tb.Name, "_has_") {
+		modelStruct := tb.SqlxString()
+		if ORM == "gorm" {
+			modelStruct = tb.GormString(tables)
+		}
+		rv := strings.Replace(StructModelTPL, "{{modelStruct}}", modelStruct, 1)
+		importTimePkg := ""
+		if tb.ImportTimePkg {
+			importTimePkg = "import \"time\"\n"
+		}
+		return strings.Replace(rv, "{{importTimePkg}}", importTimePkg, -1)
+	}
+
+	var imports []string
+	if tb.ImportTimePkg {
+		imports = append(imports, "\"time\"")
+	}
+	upsert := tb.UpsertString()
+	if upsert != "" {
+		imports = append(imports, "\"strings\"")
+	}
+	var rv string
+	if ORM == "gorm" {
+		rv = tb.gormModelString(tables)
+	} else {
+		rv = tb.sqlxModelString(tables)
+	}
+	rv = strings.Replace(rv, "{{importPkgs}}", strings.Join(imports, "\n"), -1)
+	rv = strings.Replace(rv, "{{columnsMap}}", tb.ormColumnsString(), -1)
+	rv = strings.Replace(rv, "{{upsert}}", upsert, -1)
+	rv = strings.Replace(rv, "{{pkColumn}}", tb.Pk, -1)
+	rv = strings.Replace(rv, "{{modelName}}", modelName, -1)
+	rv = strings.Replace(rv, "{{lowerName}}", lowerFirst(modelName), -1)
+	rv = strings.Replace(rv, "{{tableName}}", tb.Name, -1)
+	return rv
+}
+
+// gormModelString 填写gorm的model模板中与关系及列有关的部分
+func (tb *Table) gormModelString(tables []*Table) string {
+	var hasManyOmit, beforeSave, afterFind, rlUpdate, rlPatch, m2mPart []string
+	var reverse []string
+	for _, rel := range tb.ormRelations(tables) {
+		switch rel.Kind {
+		case "fk", "one":
+			hasManyOmit = append(hasManyOmit, fmt.Sprintf("%q", rel.Field))
+			beforeSave = append(beforeSave, fmt.Sprintf("if t.%s != nil {\n\tid := t.%s.Id\n\tt.%sId = &id\n}", rel.Field, rel.Field, rel.Field))
+			afterFind = append(afterFind, fmt.Sprintf("if t.%s == nil && t.%sId != nil {\n\tt.%s = &%s{Id: *t.%sId}\n}", rel.Field, rel.Field, rel.Field, rel.Model, rel.Field))
+			rlPatch = append(rlPatch, fmt.Sprintf("case %q:\n\tcolumns = append(columns, \"%sId\")", rel.Field, rel.Field))
+		case "reverseOne", "reverseMany":
+			reverse = append(reverse, fmt.Sprintf("%q", rel.Field))
+		case "m2m":
+			hasManyOmit = append(hasManyOmit, fmt.Sprintf("\"%s.*\"", rel.Field))
+			replace := fmt.Sprintf(`if m.%s != nil {
+				if err = tx.Model(m).Omit("%s.*").Association(%q).Replace(m.%s); err != nil {
+					return
+				}
+			}`, rel.Field, rel.Field, rel.Field, rel.Field)
+			rlUpdate = append(rlUpdate, "// m2m_update\n"+replace)
+			rlPatch = append(rlPatch, fmt.Sprintf("case %q:\n\t// m2m_patch\n\t%s", rel.Field, replace))
+			m2mPart = append(m2mPart, fmt.Sprintf(`case %q:
+			for _, did := range DelIds {
+				if err := tx.Model(m).Association(%q).Delete(&%s{Id: did}); err != nil {
+					return err
+				}
+			}
+			for _, aid := range AddIds {
+				// 已关联的不再加入
+				if tx.Model(m).Where(lgQuote(%q)+"."+lgQuote(%q)+" = ?", aid).Association(%q).Count() > 0 {
+					continue
+				}
+				if err := tx.Model(m).Omit("%s.*").Association(%q).Append(&%s{Id: aid}); err != nil {
+					return err
+				}
+			}`, rel.Field, rel.Field, rel.Model, tableNameOf(tables, rel.Model), rel.RefPk, rel.Field, rel.Field, rel.Field, rel.Model))
+		}
+	}
+	if len(reverse) > 0 {
+		rlPatch = append(rlPatch, fmt.Sprintf("case %s:\n\t// 反向的关系在关联表中，不修改", strings.Join(reverse, ", ")))
+	}
+
+	relHooks := ""
+	if len(beforeSave) > 0 {
+		relHooks = fmt.Sprintf(`
+// BeforeSave keeps the foreign key columns in line with the relation fields
+func (t *{{modelName}}) BeforeSave(tx *gorm.DB) error {
+	%s
+	return nil
+}
+
+// AfterFind sets the relation fields to their Id like beego orm, Preload loads the rest
+func (t *{{modelName}}) AfterFind(tx *gorm.DB) error {
+	%s
+	return nil
+}
+`, strings.Join(beforeSave, "\n"), strings.Join(afterFind, "\n"))
+	}
+
+	// 整行修改时auto_now_add的列不修改，按字段修改时auto_now的列一起修改
+	updateOmit := []string{"clause.Associations"}
+	var autoNow string
+	for _, col := range tb.SQLColumns() {
+		if col.AutoNowAdd {
+			updateOmit = append(updateOmit, fmt.Sprintf("%q", col.Field))
+		}
+		if col.AutoNow {
+			autoNow += fmt.Sprintf(", %q", col.Field)
+		}
+	}
+
+	rv := strings.Replace(GormModelTPL, "{{modelStruct}}", tb.GormString(tables), 1)
+	rv = strings.Replace(rv, "{{relHooks}}", relHooks, -1)
+	rv = strings.Replace(rv, "{{hasManyOmit}}", strings.Join(hasManyOmit, ", "), -1)
+	rv = strings.Replace(rv, "{{updateOmit}}", strings.Join(updateOmit, ", "), -1)
+	selectColumns := "columns"
+	if autoNow != "" {
+		selectColumns = "append(columns" + autoNow + ")"
+	}
+	rv = strings.Replace(rv, "{{selectColumns}}", selectColumns, -1)
+	rv = strings.Replace(rv, "{{rlUpdate}}", strings.Join(rlUpdate, "\n"), -1)
+	rv = strings.Replace(rv, "{{rlPatch}}", strings.Join(rlPatch, "\n"), -1)
+	rv = strings.Replace(rv, "{{m2mPart}}", strings.Join(m2mPart, "\n"), -1)
+	return rv
+}
+
+// sqlxModelString 填写sqlx的model模板中与关系及列有关的部分
+func (tb *Table) sqlxModelString(tables []*Table) string {
+	// 查询的列及scan的顺序一致
+	var columnList, scanVars, scanDest, scanRels, valueCases []string
+	var insertFields, updateFields, insertNow, updateNow []string
+	for _, col := range tb.SQLColumns() {
+		columnList = append(columnList, fmt.Sprintf("%q", col.Column))
+		value := "m." + col.Field
+		switch {
+		case col.Rel:
+			name := lowerFirst(col.Field) + "Id"
+			scanVars = append(scanVars, fmt.Sprintf("var %s sql.NullInt64", name))
+			scanDest = append(scanDest, "&"+name)
+			scanRels = append(scanRels, fmt.Sprintf("if %s.Valid {\n\tm.%s = &%s{Id: int(%s.Int64)}\n}", name, col.Field, strings.TrimPrefix(col.Type, "*"), name))
+		case col.Tag.Null:
+			scanDest = append(scanDest, fmt.Sprintf("lgNull(&m.%s)", col.Field))
+		default:
+			scanDest = append(scanDest, "&m."+col.Field)
+		}
+		if col.Type == "time.Time" {
+			value = fmt.Sprintf("lgTime(m.%s)", col.Field)
+		}
+		if col.Rel {
+			valueCases = append(valueCases, fmt.Sprintf("case %q:\n\tcolumns = append(columns, %q)\n\tvar v interface{}\n\tif m.%s != nil {\n\t\tv = m.%s.Id\n\t}\n\targs = append(args, v)",
+				col.Field, col.Column, col.Field, col.Field))
+		} else {
+			valueCases = append(valueCases, fmt.Sprintf("case %q:\n\tcolumns = append(columns, %q)\n\targs = append(args, %s)", col.Field, col.Column, value))
+		}
+
+		if col.Pk && col.Auto {
+			continue
+		}
+		insertFields = append(insertFields, fmt.Sprintf("%q", col.Field))
+		if col.AutoNow || col.AutoNowAdd {
+			insertNow = append(insertNow, fmt.Sprintf("m.%s = now", col.Field))
+		}
+		if col.AutoNow {
+			updateNow = append(updateNow, fmt.Sprintf("m.%s = now\nfields = append(fields[:len(fields):len(fields)], %q)", col.Field, col.Field))
+		}
+		if !col.Pk && !col.AutoNow && !col.AutoNowAdd {
+			updateFields = append(updateFields, fmt.Sprintf("%q", col.Field))
+		}
+	}
+	if len(insertNow) > 0 {
+		insertNow = append([]string{"now := time.Now()"}, insertNow...)
+	}
+	if len(updateNow) > 0 {
+		updateNow = append([]string{"now := time.Now()"}, updateNow...)
+	}
+	setId := "m.Id = int(id)"
+	for _, col := range tb.SQLColumns() {
+		if col.Pk && !col.Auto {
+			setId = "id = int64(m.Id)"
+		}
+	}
+
+	var loadCases, rlAdd, rlUpdate, rlPatch, m2mPart, reverse []string
+	for _, rel := range tb.ormRelations(tables) {
+		q := func(name string) string { return fmt.Sprintf("lgQuote(%q)", name) }
+		switch rel.Kind {
+		case "fk", "one":
+			loadCases = append(loadCases, fmt.Sprintf(`case %q:
+			if t.%s == nil {
+				return 0, nil
+			}
+			v, err := get%s(DB, t.%s.Id)
+			if err != nil {
+				return 0, err
+			}
+			t.%s = v
+			return 1, nil`, rel.Field, rel.Field, rel.Model, rel.Field, rel.Field))
+		case "reverseOne", "reverseMany":
+			set := fmt.Sprintf("t.%s = l", rel.Field)
+			if rel.Kind == "reverseOne" {
+				set = fmt.Sprintf("if len(l) > 0 {\n\tt.%s = l[0]\n}", rel.Field)
+			}
+			loadCases = append(loadCases, fmt.Sprintf(`case %q:
+			l, err := query%s(DB, " WHERE "+%s+" = ?", t.Id)
+			if err != nil {
+				return 0, err
+			}
+			%s
+			return int64(len(l)), nil`, rel.Field, rel.Model, q(rel.Column), set))
+			if rel.Kind == "reverseOne" {
+				rlAdd = append(rlAdd, fmt.Sprintf(`if m.%s != nil {
+					m.%s.%s = &{{modelName}}{Id: m.Id}
+					if _, err := insert%s(tx, m.%s); err != nil {
+						return err
+					}
+				}`, rel.Field, rel.Field, rel.RefField, rel.Model, rel.Field))
+			} else {
+				rlAdd = append(rlAdd, fmt.Sprintf(`// o2m_add
+				for _, v := range m.%s {
+					v.%s = &{{modelName}}{Id: m.Id}
+					if _, err := insert%s(tx, v); err != nil {
+						return err
+					}
+				}`, rel.Field, rel.RefField, rel.Model))
+			}
+			reverse = append(reverse, fmt.Sprintf("%q", rel.Field))
+		case "m2m":
+			link := fmt.Sprintf("%q, %q, %q", rel.Through, rel.JoinCol, rel.JoinRef)
+			loadCases = append(loadCases, fmt.Sprintf(`case %q:
+			l, err := query%s(DB, " WHERE "+%s+" IN (SELECT "+%s+" FROM "+%s+" WHERE "+%s+" = ?)", t.Id)
+			if err != nil {
+				return 0, err
+			}
+			t.%s = l
+			return int64(len(l)), nil`, rel.Field, rel.Model, q(rel.RefPk), q(rel.JoinRef), q(rel.Through), q(rel.JoinCol), rel.Field))
+			rlAdd = append(rlAdd, fmt.Sprintf(`// m2m_add
+				for _, v := range m.%s {
+					if err := lgLink(tx, %s, m.Id, v.Id); err != nil {
+						return err
+					}
+				}`, rel.Field, link))
+			replace := fmt.Sprintf(`if m.%s != nil {
+				if err = lgUnlinkAll(tx, %q, %q, m.Id); err != nil {
+					return
+				}
+				for _, v := range m.%s {
+					if err = lgLink(tx, %s, m.Id, v.Id); err != nil {
+						return
+					}
+				}
+			}`, rel.Field, rel.Through, rel.JoinCol, rel.Field, link)
+			rlUpdate = append(rlUpdate, "// m2m_update\n"+replace)
+			rlPatch = append(rlPatch, fmt.Sprintf("case %q:\n\t// m2m_patch\n\t%s", rel.Field, replace))
+			m2mPart = append(m2mPart, fmt.Sprintf(`case %q:
+			for _, did := range DelIds {
+				if err := lgUnlink(tx, %s, m.Id, did); err != nil {
+					return err
+				}
+			}
+			for _, aid := range AddIds {
+				// 已关联的不再加入
+				linked, err := lgLinked(tx, %s, m.Id, aid)
+				if err == nil && !linked {
+					err = lgLink(tx, %s, m.Id, aid)
+				}
+				if err != nil {
+					return err
+				}
+			}`, rel.Field, link, link, link))
+		}
+	}
+	if len(reverse) > 0 {
+		rlPatch = append(rlPatch, fmt.Sprintf("case %s:\n\t// 反向的关系在关联表中，不修改", strings.Join(reverse, ", ")))
+	}
+
+	rv := strings.Replace(SqlxModelTPL, "{{modelStruct}}", tb.SqlxString(), 1)
+	rv = strings.Replace(rv, "{{columnList}}", strings.Join(columnList, ", "), -1)
+	rv = strings.Replace(rv, "{{insertFields}}", strings.Join(insertFields, ", "), -1)
+	rv = strings.Replace(rv, "{{updateFields}}", strings.Join(updateFields, ", "), -1)
+	rv = strings.Replace(rv, "{{scanVars}}", strings.Join(scanVars, "\n"), -1)
+	rv = strings.Replace(rv, "{{scanDest}}", strings.Join(scanDest, ", "), -1)
+	rv = strings.Replace(rv, "{{scanRels}}", strings.Join(scanRels, "\n"), -1)
+	rv = strings.Replace(rv, "{{valueCases}}", strings.Join(valueCases, "\n"), -1)
+	rv = strings.Replace(rv, "{{insertNow}}", strings.Join(insertNow, "\n"), -1)
+	rv = strings.Replace(rv, "{{updateNow}}", strings.Join(updateNow, "\n"), -1)
+	rv = strings.Replace(rv, "{{setId}}", setId, -1)
+	rv = strings.Replace(rv, "{{loadCases}}", strings.Join(loadCases, "\n"), -1)
+	rv = strings.Replace(rv, "{{rlAdd}}", strings.Join(rlAdd, "\n"), -1)
+	rv = strings.Replace(rv, "{{rlUpdate}}", strings.Join(rlUpdate, "\n"), -1)
+	rv = strings.Replace(rv, "{{rlPatch}}", strings.Join(rlPatch, "\n"), -1)
+	rv = strings.Replace(rv, "{{m2mPart}}", strings.Join(m2mPart, "\n"), -1)
+	return rv
+}
+
+// tableNameOf 按model名返回表名
+func tableNameOf(tables []*Table, model string) string {
+	for _, t := range tables {
+		if utils.CamelCase(t.Name) == model {
+			return t.Name
+		}
+	}
+	return snakeString(model)
+}
+
+// ormTPLs 各持久层的公共文件lg_orm.go
+var ormTPLs = map[string]string{
+	"beego": ModelLgOrmBeego,
+	"gorm":  ModelLgOrmGorm,
+	"sqlx":  ModelLgOrmSqlx,
+}
+
+const (
+	// gorm、sqlx按SQL生成的查询条件，写法与LgQueryCond相同
+	ModelLgQuerySQL = `package models
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// lgOps 支持的表达式及对应的SQL，i开头的忽略大小写
+var lgOps = map[string]string{
+	"exact":       "= ?",
+	"iexact":      "= LOWER(?)",
+	"contains":    "LIKE ?",
+	"icontains":   "LIKE LOWER(?)",
+	"startswith":  "LIKE ?",
+	"istartswith": "LIKE LOWER(?)",
+	"endswith":    "LIKE ?",
+	"iendswith":   "LIKE LOWER(?)",
+	"gt":          "> ?",
+	"gte":         ">= ?",
+	"lt":          "< ?",
+	"lte":         "<= ?",
+	"in":          "IN",
+	"isnull":      "IS NULL",
+}
+
+// LgQueryWhere 把query参数转换为SQL条件及参数，没有条件时返回空字符串
+// columns为可用的字段名、列名到列的对应，其它字段返回错误
+//  not_empty:column                       非空
+//  search:column1>value1|column2>value2   模糊或搜索，多组之间用^分隔
+//  dsearch:column1>value1|column2>value2  精确或搜索，多组之间用^分隔
+//  neq:column1>value1                     不等于
+//  column__isnull:true                    是否为空
+//  column__op:value                       op见lgOps，in的值用逗号分隔
+func LgQueryWhere(query map[string]string, columns map[string]string) (where string, args []interface{}, err error) {
+	// 按key排序，相同的query生成相同的SQL
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var conds []string
+	for _, k := range keys {
+		v := query[k]
+		switch k {
+		case "not_empty":
+			column, e := lgColumn(columns, v)
+			if e != nil {
+				return "", nil, e
+			}
+			conds = append(conds, column+" IS NOT NULL AND "+column+" <> ?")
+			args = append(args, "")
+		case "search", "dsearch":
+			op := "contains"
+			if k == "dsearch" {
+				op = "exact"
+			}
+			for _, group := range strings.Split(v, "^") {
+				var ors []string
+				for _, item := range strings.Split(group, "|") {
+					filed := strings.Split(item, ">")
+					if len(filed) != 2 {
+						continue
+					}
+					cond, a, e := lgExpr(columns, filed[0], op, filed[1])
+					if e != nil {
+						return "", nil, e
+					}
+					ors = append(ors, cond)
+					args = append(args, a...)
+				}
+				if len(ors) > 0 {
+					conds = append(conds, strings.Join(ors, " OR "))
+				}
+			}
+		case "neq":
+			filed := strings.Split(v, ">")
+			if len(filed) == 2 {
+				column, e := lgColumn(columns, filed[0])
+				if e != nil {
+					return "", nil, e
+				}
+				conds = append(conds, column+" <> ?")
+				args = append(args, filed[1])
+			}
+		default:
+			name, op := k, "exact"
+			if i := strings.LastIndex(k, "__"); i >= 0 {
+				if _, ok := lgOps[k[i+2:]]; ok {
+					name, op = k[:i], k[i+2:]
+				}
+			}
+			cond, a, e := lgExpr(columns, name, op, v)
+			if e != nil {
+				return "", nil, e
+			}
+			conds = append(conds, cond)
+			args = append(args, a...)
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return "(" + strings.Join(conds, ") AND (") + ")", args, nil
+}
+
+// lgExpr 返回name op value的SQL条件
+func lgExpr(columns map[string]string, name string, op string, value string) (cond string, args []interface{}, err error) {
+	column, err := lgColumn(columns, name)
+	if err != nil {
+		return
+	}
+	escaped := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+	switch op {
+	case "isnull":
+		if value == "true" || value == "1" {
+			return column + " IS NULL", nil, nil
+		}
+		return column + " IS NOT NULL", nil, nil
+	case "in":
+		values := strings.Split(value, ",")
+		marks := make([]string, len(values))
+		for i, v := range values {
+			marks[i] = "?"
+			args = append(args, v)
+		}
+		return column + " IN (" + strings.Join(marks, ", ") + ")", args, nil
+	case "contains", "icontains":
+		value = "%" + escaped + "%"
+	case "startswith", "istartswith":
+		value = escaped + "%"
+	case "endswith", "iendswith":
+		value = "%" + escaped
+	}
+	if strings.HasPrefix(op, "i") {
+		column = "LOWER(" + column + ")"
+	}
+	return column + " " + lgOps[op], []interface{}{value}, nil
+}
+
+// lgColumn 返回字段对应的列，dot-notation的Team.Id与Team__Id相同
+func lgColumn(columns map[string]string, name string) (string, error) {
+	column, ok := columns[strings.Replace(name, ".", "__", -1)]
+	if !ok {
+		return "", errors.New("Error: unknown field '" + name + "'")
+	}
+	return lgQuote(column), nil
+}
+
+// LgOrderBy 把sortby和order转换为排序字段，降序的字段以-开头，order只有一个时用于所有sortby
+func LgOrderBy(sortby []string, order []string) (sortFields []string, err error) {
+	if len(sortby) != 0 {
+		if len(sortby) == len(order) {
+			// 1) for each sort field, there is an associated order
+			for i, v := range sortby {
+				orderby := ""
+				if order[i] == "desc" {
+					orderby = "-" + v
+				} else if order[i] == "asc" {
+					orderby = v
+				} else {
+					return nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
+				}
+				sortFields = append(sortFields, orderby)
+			}
+		} else if len(order) == 1 {
+			// 2) there is exactly one order, all the sorted fields will be sorted by this order
+			for _, v := range sortby {
+				orderby := ""
+				if order[0] == "desc" {
+					orderby = "-" + v
+				} else if order[0] == "asc" {
+					orderby = v
+				} else {
+					return nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
+				}
+				sortFields = append(sortFields, orderby)
+			}
+		} else {
+			return nil, errors.New("Error: 'sortby', 'order' sizes mismatch or 'order' size is not 1")
+		}
+	} else {
+		if len(order) != 0 {
+			return nil, errors.New("Error: unused 'order' fields")
+		}
+	}
+	return
+}
+
+// LgOrderSQL 把sortby和order转换为ORDER BY后的SQL，没有排序时返回空字符串
+func LgOrderSQL(sortby []string, order []string, columns map[string]string) (string, error) {
+	sortFields, err := LgOrderBy(sortby, order)
+	if err != nil {
+		return "", err
+	}
+	var rv []string
+	for _, v := range sortFields {
+		direction := " ASC"
+		if strings.HasPrefix(v, "-") {
+			v, direction = v[1:], " DESC"
+		}
+		column, err := lgColumn(columns, v)
+		if err != nil {
+			return "", err
+		}
+		rv = append(rv, column+direction)
+	}
+	return strings.Join(rv, ", "), nil
+}
+
+// lgCheckFields fields必须是t的字段
+func lgCheckFields(t reflect.Type, fields []string) error {
+	for _, fname := range fields {
+		if _, ok := t.FieldByName(fname); !ok {
+			return errors.New("Error: unknown field '" + fname + "'")
+		}
+	}
+	return nil
+}
+`
+	// beego orm的事务及唯一键查询
+	ModelLgOrmBeego = `package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego/orm"
+)
+
+// lgBegin 开启事务
+func lgBegin() (orm.Ormer, error) {
+	o := orm.NewOrm()
+	return o, o.Begin()
+}
+
+// LgTx runs fn in a transaction, which is rolled back when fn returns an error or panics
+func LgTx(fn func(o orm.Ormer) error) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			o.Rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(o); err != nil {
+		o.Rollback()
+		return
+	}
+	return o.Commit()
+}
+
+// End 全部成功时提交事务，否则回滚
+func (r *LgBatchResult) End(o orm.Ormer) (err error) {
+	if r.Failed > 0 {
+		return o.Rollback()
+	}
+	if err = o.Commit(); err == nil {
+		r.Committed = true
+	}
+	return
+}
+
+// lgExists 是否存在其它记录，id不为0时排除自身
+func lgExists(qs orm.QuerySeter, id int) bool {
+	if id != 0 {
+		qs = qs.Exclude("Id", id)
+	}
+	return qs.Exist()
+}
+
+// LgThroughIds 一次查询中间表through中column为ids的关联，返回id到refColumn的对应
+func LgThroughIds(through string, column string, refColumn string, ids []int) (map[int][]int, error) {
+	rv := make(map[int][]int)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	o := orm.NewOrm()
+	quote := "` + "`" + `"
+	if o.Driver().Type() == orm.DRPostgres {
+		quote = "\""
+	}
+	var lists []orm.ParamsList
+	query := "SELECT " + quote + column + quote + ", " + quote + refColumn + quote + " FROM " + quote + through + quote +
+		" WHERE " + quote + column + quote + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+	if _, err := o.Raw(query, ids).ValuesList(&lists); err != nil {
+		return nil, err
+	}
+	for _, l := range lists {
+		id, _ := strconv.Atoi(fmt.Sprint(l[0]))
+		refId, _ := strconv.Atoi(fmt.Sprint(l[1]))
+		rv[id] = append(rv[id], refId)
+	}
+	return rv, nil
+}
+`
+	// beego v2 orm的事务及唯一键查询
+	ModelLgOrmBeegoV2 = `package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// lgBegin 开启事务
+func lgBegin() (orm.TxOrmer, error) {
+	return orm.NewOrm().Begin()
+}
+
+// LgTx runs fn in a transaction, which is rolled back when fn returns an error or panics
+func LgTx(fn func(o orm.TxOrmer) error) error {
+	return orm.NewOrm().DoTx(func(ctx context.Context, o orm.TxOrmer) error {
+		return fn(o)
+	})
+}
+
+// End 全部成功时提交事务，否则回滚
+func (r *LgBatchResult) End(o orm.TxOrmer) (err error) {
+	if r.Failed > 0 {
+		return o.Rollback()
+	}
+	if err = o.Commit(); err == nil {
+		r.Committed = true
+	}
+	return
+}
+
+// lgExists 是否存在其它记录，id不为0时排除自身
+func lgExists(qs orm.QuerySeter, id int) bool {
+	if id != 0 {
+		qs = qs.Exclude("Id", id)
+	}
+	return qs.Exist()
+}
+
+// LgThroughIds 一次查询中间表through中column为ids的关联，返回id到refColumn的对应
+func LgThroughIds(through string, column string, refColumn string, ids []int) (map[int][]int, error) {
+	rv := make(map[int][]int)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	o := orm.NewOrm()
+	quote := "` + "`" + `"
+	if o.Driver().Type() == orm.DRPostgres {
+		quote = "\""
+	}
+	var lists []orm.ParamsList
+	query := "SELECT " + quote + column + quote + ", " + quote + refColumn + quote + " FROM " + quote + through + quote +
+		" WHERE " + quote + column + quote + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+	if _, err := o.Raw(query, ids).ValuesList(&lists); err != nil {
+		return nil, err
+	}
+	for _, l := range lists {
+		id, _ := strconv.Atoi(fmt.Sprint(l[0]))
+		refId, _ := strconv.Atoi(fmt.Sprint(l[1]))
+		rv[id] = append(rv[id], refId)
+	}
+	return rv, nil
+}
+`
+	// gorm的连接、事务及唯一键查询
+	ModelLgOrmGorm = `package models
+
+import (
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// DB is the connection used by the models, opened by OpenDB. Assign it directly
+// to use a database other than MySQL.
+var DB *gorm.DB
+
+// OpenDB opens DB on the MySQL dsn, parseTime is always on for the time.Time fields
+func OpenDB(dsn string) error {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return err
+	}
+	cfg.ParseTime = true
+	DB, err = gorm.Open(gormmysql.Open(cfg.FormatDSN()), &gorm.Config{})
+	return err
+}
+
+func lgPostgres() bool {
+	return DB.Dialector.Name() == "postgres"
+}
+
+// lgQuote 按数据库引用表名、列名
+func lgQuote(name string) string {
+	if lgPostgres() {
+		return "\"" + name + "\""
+	}
+	return "` + "`" + `" + name + "` + "`" + `"
+}
+
+// lgWhere where为空时不加条件
+func lgWhere(db *gorm.DB, where string, args []interface{}) *gorm.DB {
+	if where == "" {
+		return db
+	}
+	return db.Where(where, args...)
+}
+
+// End 全部成功时提交事务，否则回滚
+func (r *LgBatchResult) End(tx *gorm.DB) (err error) {
+	if r.Failed > 0 {
+		return tx.Rollback().Error
+	}
+	if err = tx.Commit().Error; err == nil {
+		r.Committed = true
+	}
+	return
+}
+
+// lgExists 是否存在columns的值为args的其它记录，id不为0时排除自身
+func lgExists(table string, pk string, columns []string, args []interface{}, id int) bool {
+	var conds []string
+	for _, column := range columns {
+		conds = append(conds, lgQuote(column)+" = ?")
+	}
+	if id != 0 {
+		conds = append(conds, lgQuote(pk)+" <> ?")
+		args = append(args, id)
+	}
+	var count int64
+	DB.Table(table).Where(strings.Join(conds, " AND "), args...).Count(&count)
+	return count > 0
+}
+
+// LgThroughIds 一次查询中间表through中column为ids的关联，返回id到refColumn的对应
+func LgThroughIds(through string, column string, refColumn string, ids []int) (map[int][]int, error) {
+	rv := make(map[int][]int)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	rows, err := DB.Table(through).Select(lgQuote(column)+", "+lgQuote(refColumn)).Where(lgQuote(column)+" IN ?", ids).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, refId int
+		if err := rows.Scan(&id, &refId); err != nil {
+			return nil, err
+		}
+		rv[id] = append(rv[id], refId)
+	}
+	return rv, rows.Err()
+}
+`
+	// sqlx的连接、事务、读写及唯一键查询
+	ModelLgOrmSqlx = `package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// DB is the connection used by the models, opened by OpenDB. Assign it directly
+// to use a database other than MySQL.
+var DB *sqlx.DB
+
+// OpenDB opens DB on the MySQL dsn, parseTime is always on for the time.Time fields
+func OpenDB(dsn string) error {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return err
+	}
+	cfg.ParseTime = true
+	DB, err = sqlx.Open("mysql", cfg.FormatDSN())
+	return err
+}
+
+func lgPostgres() bool {
+	switch DB.DriverName() {
+	case "postgres", "pgx":
+		return true
+	}
+	return false
+}
+
+// lgQuote 按数据库引用表名、列名
+func lgQuote(name string) string {
+	if lgPostgres() {
+		return "\"" + name + "\""
+	}
+	return "` + "`" + `" + name + "` + "`" + `"
+}
+
+func lgColumnList(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = lgQuote(column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func lgSelect(table string, columns []string) string {
+	return "SELECT " + lgColumnList(columns) + " FROM " + lgQuote(table)
+}
+
+// lgWhere where为空时不加条件
+func lgWhere(where string) string {
+	if where == "" {
+		return ""
+	}
+	return " WHERE " + where
+}
+
+// lgLimit limit不大于0时不限制条数
+func lgLimit(limit int64, offset int64) string {
+	if limit <= 0 {
+		if offset <= 0 {
+			return ""
+		}
+		limit = math.MaxInt64
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+}
+
+type lgScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// lgNullable 可为空的列，NULL时取零值
+type lgNullable struct {
+	dest interface{}
+}
+
+func lgNull(dest interface{}) sql.Scanner {
+	return lgNullable{dest}
+}
+
+func (n lgNullable) Scan(src interface{}) error {
+	v := reflect.ValueOf(n.dest).Elem()
+	if src == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	// 非NULL时按database/sql的规则转换
+	switch v.Kind() {
+	case reflect.String:
+		var s sql.NullString
+		if err := s.Scan(src); err != nil {
+			return err
+		}
+		v.SetString(s.String)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i sql.NullInt64
+		if err := i.Scan(src); err != nil {
+			return err
+		}
+		v.SetInt(i.Int64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var i sql.NullInt64
+		if err := i.Scan(src); err != nil {
+			return err
+		}
+		v.SetUint(uint64(i.Int64))
+	case reflect.Float32, reflect.Float64:
+		var f sql.NullFloat64
+		if err := f.Scan(src); err != nil {
+			return err
+		}
+		v.SetFloat(f.Float64)
+	case reflect.Bool:
+		var b sql.NullBool
+		if err := b.Scan(src); err != nil {
+			return err
+		}
+		v.SetBool(b.Bool)
+	default:
+		var t sql.NullTime
+		if err := t.Scan(src); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t.Time))
+	}
+	return nil
+}
+
+// lgTime 零值的时间写入NULL
+func lgTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+func lgMarks(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// lgInsert 新增一行并返回主键，PostgreSQL通过RETURNING取得
+func lgInsert(q sqlx.Ext, table string, pk string, columns []string, args []interface{}) (id int64, err error) {
+	query := "INSERT INTO " + lgQuote(table) + " (" + lgColumnList(columns) + ") VALUES (" + lgMarks(len(columns)) + ")"
+	if lgPostgres() {
+		err = q.QueryRowx(q.Rebind(query+" RETURNING "+lgQuote(pk)), args...).Scan(&id)
+		return
+	}
+	res, err := q.Exec(q.Rebind(query), args...)
+	if err != nil {
+		return
+	}
+	return res.LastInsertId()
+}
+
+// lgUpdate 按主键修改columns，返回修改的行数
+func lgUpdate(q sqlx.Ext, table string, pk string, id int, columns []string, args []interface{}) (int64, error) {
+	sets := make([]string, len(columns))
+	for i, column := range columns {
+		sets[i] = lgQuote(column) + " = ?"
+	}
+	query := "UPDATE " + lgQuote(table) + " SET " + strings.Join(sets, ", ") + " WHERE " + lgQuote(pk) + " = ?"
+	res, err := q.Exec(q.Rebind(query), append(args, id)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// lgDelete 按主键删除，返回删除的行数
+func lgDelete(q sqlx.Ext, table string, pk string, id int) (int64, error) {
+	res, err := q.Exec(q.Rebind("DELETE FROM "+lgQuote(table)+" WHERE "+lgQuote(pk)+" = ?"), id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// lgLinked 中间表through中是否已有id与refId的关联
+func lgLinked(q sqlx.Ext, through string, column string, refColumn string, id int, refId int) (bool, error) {
+	var count int64
+	err := sqlx.Get(q, &count, q.Rebind("SELECT COUNT(*) FROM "+lgQuote(through)+" WHERE "+lgQuote(column)+" = ? AND "+lgQuote(refColumn)+" = ?"), id, refId)
+	return count > 0, err
+}
+
+// lgLink 在中间表through中加入id与refId的关联
+func lgLink(q sqlx.Ext, through string, column string, refColumn string, id int, refId int) error {
+	_, err := q.Exec(q.Rebind("INSERT INTO "+lgQuote(through)+" ("+lgColumnList([]string{column, refColumn})+") VALUES (?, ?)"), id, refId)
+	return err
+}
+
+// lgUnlink 删除中间表through中id与refId的关联
+func lgUnlink(q sqlx.Ext, through string, column string, refColumn string, id int, refId int) error {
+	_, err := q.Exec(q.Rebind("DELETE FROM "+lgQuote(through)+" WHERE "+lgQuote(column)+" = ? AND "+lgQuote(refColumn)+" = ?"), id, refId)
+	return err
+}
+
+// lgUnlinkAll 删除中间表through中id的所有关联
+func lgUnlinkAll(q sqlx.Ext, through string, column string, id int) error {
+	_, err := q.Exec(q.Rebind("DELETE FROM "+lgQuote(through)+" WHERE "+lgQuote(column)+" = ?"), id)
+	return err
+}
+
+// LgThroughIds 一次查询中间表through中column为ids的关联，返回id到refColumn的对应
+func LgThroughIds(through string, column string, refColumn string, ids []int) (map[int][]int, error) {
+	rv := make(map[int][]int)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	query := "SELECT " + lgColumnList([]string{column, refColumn}) + " FROM " + lgQuote(through) + " WHERE " + lgQuote(column) + " IN (" + lgMarks(len(ids)) + ")"
+	rows, err := DB.Query(DB.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, refId int
+		if err := rows.Scan(&id, &refId); err != nil {
+			return nil, err
+		}
+		rv[id] = append(rv[id], refId)
+	}
+	return rv, rows.Err()
+}
+
+// lgTransaction 在事务中执行fn，fn返回错误时回滚
+func lgTransaction(fn func(tx *sqlx.Tx) error) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// End 全部成功时提交事务，否则回滚
+func (r *LgBatchResult) End(tx *sqlx.Tx) (err error) {
+	if r.Failed > 0 {
+		return tx.Rollback()
+	}
+	if err = tx.Commit(); err == nil {
+		r.Committed = true
+	}
+	return
+}
+
+// lgExists 是否存在columns的值为args的其它记录，id不为0时排除自身
+func lgExists(table string, pk string, columns []string, args []interface{}, id int) bool {
+	var conds []string
+	for _, column := range columns {
+		conds = append(conds, lgQuote(column)+" = ?")
+	}
+	if id != 0 {
+		conds = append(conds, lgQuote(pk)+" <> ?")
+		args = append(args, id)
+	}
+	var count int64
+	DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote(table)+" WHERE "+strings.Join(conds, " AND ")), args...)
+	return count > 0
+}
+`
+)
+
+const (
+	// gorm的model模板
+	GormModelTPL = `package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	{{importPkgs}}
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+{{modelStruct}}
+
+func (t *{{modelName}}) TableName() string {
+	return "{{tableName}}"
+}
+
+{{columnsMap}}
+{{relHooks}}
+// LoadRelatedOf loads the relation r of t by Preload, args are the conditions of r
+func (t *{{modelName}}) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	if err := DB.Preload(r, args...).Take(t).Error; err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// Add{{modelName}} insert a new {{modelName}} into database and returns
+// last inserted Id on success.
+func Add{{modelName}}(m *{{modelName}}) (id int64, err error) {
+	err = DB.Omit(clause.Associations).Create(m).Error
+	id = int64(m.Id)
+	return
+}
+
+// AddMulti{{modelName}} insert multi {{modelName}}s into database and returns
+// sum success nums.
+func AddMulti{{modelName}}(ms []*{{modelName}}) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 分批插入，在同一个事务中
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit(clause.Associations).CreateInBatches(ms, 100).Error
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// Add{{modelName}}HasMany insert a new {{modelName}} and some items into database and returns
+// last inserted Id on success.
+func Add{{modelName}}HasMany(m *{{modelName}}) (id int64, err error) {
+	// 一对多、一对一的对象一起新增，多对多只新增中间表
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit({{hasManyOmit}}).Create(m).Error
+	})
+	id = int64(m.Id)
+	return
+}
+
+// Get{{modelName}}ById retrieves {{modelName}} by Id. Returns error if
+// Id doesn't exist
+func Get{{modelName}}ById(id int) (v *{{modelName}}, err error) {
+	v = &{{modelName}}{}
+	if err = DB.Take(v, id).Error; err == nil {
+		return v, nil
+	}
+	return nil, err
+}
+
+// Get{{modelName}}Counts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func Get{{modelName}}Counts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, {{modelName}}Columns)
+	if err != nil {
+		return
+	}
+	err = lgWhere(DB.Model(&{{modelName}}{}), where, args).Count(&count).Error
+	return
+}
+
+// GetAll{{modelName}} retrieves all {{modelName}} matches certain condition. Returns empty list if
+// no records exist
+func GetAll{{modelName}}(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, {{modelName}}Columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, {{modelName}}Columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf({{modelName}}{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	qs := lgWhere(DB.Model(&{{modelName}}{}), where, args).Session(&gorm.Session{})
+	var count int64 = 0
+	if page == 1 {
+		qs.Count(&count)
+	}
+	if orderBy != "" {
+		qs = qs.Order(orderBy)
+	}
+	for _, lo := range load {
+		qs = qs.Preload(lo)
+	}
+
+	var l []{{modelName}}
+	if err = qs.Limit(int(limit)).Offset(int(offset)).Find(&l).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			ml = append(ml, v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// Update{{modelName}} updates {{modelName}} by Id and returns error if
+// the record to be updated doesn't exist
+func Update{{modelName}}ById(m *{{modelName}}) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) (err error) {
+		// ascertain id exists in the database
+		if err = tx.Take(&{{modelName}}{}, m.Id).Error; err != nil {
+			return
+		}
+		{{rlUpdate}}
+		return tx.Model(m).Select("*").Omit({{updateOmit}}).Updates(m).Error
+	})
+}
+
+// Patch{{modelName}} updates {{modelName}} by Id and returns error if
+// the record to be updated doesn't exist
+func Patch{{modelName}}ById(m *{{modelName}}, fields []string) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return patch{{modelName}}(tx, m, fields)
+	})
+}
+
+// patch{{modelName}} updates the given fields of {{modelName}} inside the
+// transaction of tx, many to many fields are replaced
+func patch{{modelName}}(tx *gorm.DB, m *{{modelName}}, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+		{{rlPatch}}
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(columns) == 0 {
+		return
+	}
+	return tx.Model(m).Select({{selectColumns}}).Omit(clause.Associations).Updates(m).Error
+}
+
+// UpdateMulti{{modelName}} updates several {{modelName}}s in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMulti{{modelName}}(ms []*{{modelName}}, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = tx.Take(&{{modelName}}{}, m.Id).Error; itemErr == nil {
+				itemErr = patch{{modelName}}(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMulti{{modelName}}ByIds deletes {{modelName}}s by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMulti{{modelName}}ByIds(ids []int) (result *LgBatchResult, err error) {
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = delete{{modelName}}Batch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMulti{{modelName}}ByQuery deletes all {{modelName}}s matches the same query as
+// GetAll{{modelName}} in one transaction.
+func DeleteMulti{{modelName}}ByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, {{modelName}}Columns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Model(&{{modelName}}{}).Where(where, args...).Pluck("{{pkColumn}}", &ids).Error; err != nil {
+		tx.Rollback()
+		return
+	}
+	result = delete{{modelName}}Batch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// delete{{modelName}}Batch deletes {{modelName}}s one by one inside the transaction of tx
+func delete{{modelName}}Batch(tx *gorm.DB, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		res := tx.Delete(&{{modelName}}{}, id)
+		err := res.Error
+		if err == nil && res.RowsAffected == 0 {
+			err = gorm.ErrRecordNotFound
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// Patch{{modelName}}M2MPart updates {{modelName}} by Id and returns error if
+// the record to be updated doesn't exist
+func Patch{{modelName}}M2MPartById(m *{{modelName}}, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		switch field {
+		{{m2mPart}}
+		}
+		return nil
+	})
+}
+
+// Delete{{modelName}} deletes {{modelName}} by Id and returns error if
+// the record to be deleted doesn't exist
+func Delete{{modelName}}(id int) (err error) {
+	v := {{modelName}}{}
+	// ascertain id exists in the database
+	if err = DB.Take(&v, id).Error; err == nil {
+		res := DB.Delete(&{{modelName}}{}, id)
+		if err = res.Error; err == nil {
+			fmt.Println("Number of records deleted in database:", res.RowsAffected)
+		}
+	}
+	return
+}
+{{upsert}}
+`
+	// sqlx的model模板
+	SqlxModelTPL = `package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	{{importPkgs}}
+	"github.com/jmoiron/sqlx"
+)
+
+{{modelStruct}}
+
+func (t *{{modelName}}) TableName() string {
+	return "{{tableName}}"
+}
+
+{{columnsMap}}
+// the columns read by scan{{modelName}}, and the fields written by insert and update
+var (
+	{{lowerName}}ColumnList   = []string{ {{columnList}} }
+	{{lowerName}}InsertFields = []string{ {{insertFields}} }
+	{{lowerName}}UpdateFields = []string{ {{updateFields}} }
+)
+
+// scan{{modelName}} reads a row in the order of {{lowerName}}ColumnList, NULL is read as the zero value
+func scan{{modelName}}(s lgScanner) (*{{modelName}}, error) {
+	m := &{{modelName}}{}
+	{{scanVars}}
+	if err := s.Scan({{scanDest}}); err != nil {
+		return nil, err
+	}
+	{{scanRels}}
+	return m, nil
+}
+
+// query{{modelName}} reads the {{modelName}}s selected by tail, the SQL after FROM
+func query{{modelName}}(q sqlx.Queryer, tail string, args ...interface{}) (ms []*{{modelName}}, err error) {
+	rows, err := q.Query(DB.Rebind(lgSelect("{{tableName}}", {{lowerName}}ColumnList)+tail), args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		m, err := scan{{modelName}}(rows)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, rows.Err()
+}
+
+// get{{modelName}} reads the {{modelName}} by Id, returns sql.ErrNoRows if it doesn't exist
+func get{{modelName}}(q sqlx.Queryer, id int) (*{{modelName}}, error) {
+	return scan{{modelName}}(q.QueryRowx(DB.Rebind(lgSelect("{{tableName}}", {{lowerName}}ColumnList)+" WHERE "+lgQuote("{{pkColumn}}")+" = ?"), id))
+}
+
+// values{{modelName}} returns the columns and values of fields of m
+func values{{modelName}}(m *{{modelName}}, fields []string) (columns []string, args []interface{}, err error) {
+	for _, field := range fields {
+		switch field {
+		{{valueCases}}
+		default:
+			return nil, nil, errors.New("Error: unknown field '" + field + "'")
+		}
+	}
+	return
+}
+
+// insert{{modelName}} inserts m by q, a connection or a transaction
+func insert{{modelName}}(q sqlx.Ext, m *{{modelName}}) (id int64, err error) {
+	{{insertNow}}
+	columns, args, err := values{{modelName}}(m, {{lowerName}}InsertFields)
+	if err != nil {
+		return
+	}
+	if id, err = lgInsert(q, "{{tableName}}", "{{pkColumn}}", columns, args); err == nil {
+		{{setId}}
+	}
+	return
+}
+
+// update{{modelName}} updates fields of m by q, the auto_now fields are always updated
+func update{{modelName}}(q sqlx.Ext, m *{{modelName}}, fields []string) error {
+	{{updateNow}}
+	columns, args, err := values{{modelName}}(m, fields)
+	if err != nil {
+		return err
+	}
+	_, err = lgUpdate(q, "{{tableName}}", "{{pkColumn}}", m.Id, columns, args)
+	return err
+}
+
+// LoadRelatedOf loads the relation r of t
+func (t *{{modelName}}) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	switch r {
+	{{loadCases}}
+	}
+	return 0, errors.New("Error: unknown relation '" + r + "'")
+}
+
+// Add{{modelName}} insert a new {{modelName}} into database and returns
+// last inserted Id on success.
+func Add{{modelName}}(m *{{modelName}}) (id int64, err error) {
+	return insert{{modelName}}(DB, m)
+}
+
+// AddMulti{{modelName}} insert multi {{modelName}}s into database and returns
+// sum success nums.
+func AddMulti{{modelName}}(ms []*{{modelName}}) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 逐条插入，在同一个事务中
+	err = lgTransaction(func(tx *sqlx.Tx) error {
+		for _, m := range ms {
+			if _, err := insert{{modelName}}(tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// Add{{modelName}}HasMany insert a new {{modelName}} and some items into database and returns
+// last inserted Id on success.
+func Add{{modelName}}HasMany(m *{{modelName}}) (id int64, err error) {
+	err = lgTransaction(func(tx *sqlx.Tx) (err error) {
+		if id, err = insert{{modelName}}(tx, m); err != nil {
+			return
+		}
+		{{rlAdd}}
+		return nil
+	})
+	return
+}
+
+// Get{{modelName}}ById retrieves {{modelName}} by Id. Returns error if
+// Id doesn't exist
+func Get{{modelName}}ById(id int) (v *{{modelName}}, err error) {
+	return get{{modelName}}(DB, id)
+}
+
+// Get{{modelName}}Counts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func Get{{modelName}}Counts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, {{modelName}}Columns)
+	if err != nil {
+		return
+	}
+	err = DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("{{tableName}}")+lgWhere(where)), args...)
+	return
+}
+
+// GetAll{{modelName}} retrieves all {{modelName}} matches certain condition. Returns empty list if
+// no records exist
+func GetAll{{modelName}}(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, {{modelName}}Columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, {{modelName}}Columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf({{modelName}}{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	var count int64 = 0
+	if page == 1 {
+		DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("{{tableName}}")+lgWhere(where)), args...)
+	}
+	tail := lgWhere(where)
+	if orderBy != "" {
+		tail += " ORDER BY " + orderBy
+	}
+
+	l, err := query{{modelName}}(DB, tail+lgLimit(limit, offset), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			for _, lo := range load {
+				v.LoadRelatedOf(lo)
+			}
+			ml = append(ml, *v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(*v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// Update{{modelName}} updates {{modelName}} by Id and returns error if
+// the record to be updated doesn't exist
+func Update{{modelName}}ById(m *{{modelName}}) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) (err error) {
+		// ascertain id exists in the database
+		if _, err = get{{modelName}}(tx, m.Id); err != nil {
+			return
+		}
+		{{rlUpdate}}
+		return update{{modelName}}(tx, m, {{lowerName}}UpdateFields)
+	})
+}
+
+// Patch{{modelName}} updates {{modelName}} by Id and returns error if
+// the record to be updated doesn't exist
+func Patch{{modelName}}ById(m *{{modelName}}, fields []string) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		return patch{{modelName}}(tx, m, fields)
+	})
+}
+
+// patch{{modelName}} updates the given fields of {{modelName}} inside the
+// transaction of tx, many to many fields are replaced
+func patch{{modelName}}(tx *sqlx.Tx, m *{{modelName}}, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+		{{rlPatch}}
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新
+	if len(columns) == 0 {
+		return
+	}
+	return update{{modelName}}(tx, m, columns)
+}
+
+// UpdateMulti{{modelName}} updates several {{modelName}}s in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMulti{{modelName}}(ms []*{{modelName}}, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if _, itemErr = get{{modelName}}(tx, m.Id); itemErr == nil {
+				itemErr = patch{{modelName}}(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMulti{{modelName}}ByIds deletes {{modelName}}s by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMulti{{modelName}}ByIds(ids []int) (result *LgBatchResult, err error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = delete{{modelName}}Batch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMulti{{modelName}}ByQuery deletes all {{modelName}}s matches the same query as
+// GetAll{{modelName}} in one transaction.
+func DeleteMulti{{modelName}}ByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, {{modelName}}Columns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Select(&ids, DB.Rebind("SELECT "+lgQuote("{{pkColumn}}")+" FROM "+lgQuote("{{tableName}}")+lgWhere(where)), args...); err != nil {
+		tx.Rollback()
+		return
+	}
+	result = delete{{modelName}}Batch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// delete{{modelName}}Batch deletes {{modelName}}s one by one inside the transaction of tx
+func delete{{modelName}}Batch(tx *sqlx.Tx, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := lgDelete(tx, "{{tableName}}", "{{pkColumn}}", id)
+		if err == nil && num == 0 {
+			err = sql.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// Patch{{modelName}}M2MPart updates {{modelName}} by Id and returns error if
+// the record to be updated doesn't exist
+func Patch{{modelName}}M2MPartById(m *{{modelName}}, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		switch field {
+		{{m2mPart}}
+		}
+		return nil
+	})
+}
+
+// Delete{{modelName}} deletes {{modelName}} by Id and returns error if
+// the record to be deleted doesn't exist
+func Delete{{modelName}}(id int) (err error) {
+	// ascertain id exists in the database
+	if _, err = get{{modelName}}(DB, id); err == nil {
+		var num int64
+		if num, err = lgDelete(DB, "{{tableName}}", "{{pkColumn}}", id); err == nil {
+			fmt.Println("Number of records deleted in database:", num)
+		}
+	}
+	return
+}
+{{upsert}}
+`
+	// gorm按某个唯一键upsert
+	GormUpsertByTPL = `
+// Upsert{{modelName}}{{byName}} inserts m, or updates the {{modelName}} with the same
+// ({{byColumns}}), and returns the Id on success. ({{byColumns}}) is the only unique key
+// of the table, so the conflict handled by MySQL is always on it.
+func Upsert{{modelName}}{{byName}}(m *{{modelName}}) (id int64, err error) {
+	err = DB.Transaction(func(tx *gorm.DB) (err error) {
+		id, err = upsert{{modelName}}{{byName}}(tx, m)
+		return
+	})
+	return
+}
+
+func upsert{{modelName}}{{byName}}(tx *gorm.DB, m *{{modelName}}) (id int64, err error) {
+	args := upsert{{modelName}}Args(m)
+	if tx.Dialector.Name() == "postgres" {
+		err = tx.Raw({{pgSQL}}, args...).Row().Scan(&id)
+	} else if err = tx.Exec({{mysqlSQL}}, args...).Error; err == nil {
+		// LAST_INSERT_ID按连接保存，所以要在事务中读取
+		err = tx.Raw("SELECT LAST_INSERT_ID()").Row().Scan(&id)
+	}
+	if err != nil {
+		return
+	}
+	// 重新读取，修改时m中的字段不一定与数据库一致
+	m.Id = int(id)
+	err = tx.Take(m).Error
+	return
+}
+`
+	// gorm按by选择唯一键upsert
+	GormUpsertTPL = `
+// {{modelName}}UniqueKeys lists the unique keys accepted by the by argument of Upsert{{modelName}}
+var {{modelName}}UniqueKeys = []string{ {{uniqueKeys}} }
+
+func upsert{{modelName}}Func(by string) (func(*gorm.DB, *{{modelName}}) (int64, error), error) {
+	switch by {
+	{{upsertCases}}
+	}
+	return nil, errors.New("Error: unknown unique key '" + by + "', must be one of " + strings.Join({{modelName}}UniqueKeys, " | "))
+}
+
+// Upsert{{modelName}} inserts or updates m by the unique key by, the columns of by are
+// separated by ",", an empty by means the first unique key.
+func Upsert{{modelName}}(m *{{modelName}}, by string) (id int64, err error) {
+	upsert, err := upsert{{modelName}}Func(by)
+	if err != nil {
+		return
+	}
+	// 唯一键冲突时修改，只校验列
+	if err = m.ValidateColumns(); err != nil {
+		return
+	}
+	err = DB.Transaction(func(tx *gorm.DB) (err error) {
+		id, err = upsert(tx, m)
+		return
+	})
+	return
+}
+
+// UpsertMulti{{modelName}} inserts or updates several {{modelName}}s by the unique key by in
+// one transaction. Nothing is committed unless every item succeeds, and the items after
+// the first failed one are skipped.
+func UpsertMulti{{modelName}}(ms []*{{modelName}}, by string) (result *LgBatchResult, err error) {
+	upsert, err := upsert{{modelName}}Func(by)
+	if err != nil {
+		return
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		itemErr := m.ValidateColumns()
+		if itemErr == nil {
+			_, itemErr = upsert(tx, m)
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+`
+	// sqlx按某个唯一键upsert
+	SqlxUpsertByTPL = `
+// Upsert{{modelName}}{{byName}} inserts m, or updates the {{modelName}} with the same
+// ({{byColumns}}), and returns the Id on success. ({{byColumns}}) is the only unique key
+// of the table, so the conflict handled by MySQL is always on it.
+func Upsert{{modelName}}{{byName}}(m *{{modelName}}) (id int64, err error) {
+	return upsert{{modelName}}{{byName}}(DB, m)
+}
+
+func upsert{{modelName}}{{byName}}(q sqlx.Ext, m *{{modelName}}) (id int64, err error) {
+	args := upsert{{modelName}}Args(m)
+	if lgPostgres() {
+		err = q.QueryRowx(q.Rebind({{pgSQL}}), args...).Scan(&id)
+	} else {
+		res, e := q.Exec({{mysqlSQL}}, args...)
+		if err = e; err == nil {
+			id, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return
+	}
+	// 重新读取，修改时m中的字段不一定与数据库一致
+	v, err := get{{modelName}}(q, int(id))
+	if err == nil {
+		*m = *v
+	}
+	return
+}
+`
+	// sqlx按by选择唯一键upsert
+	SqlxUpsertTPL = `
+// {{modelName}}UniqueKeys lists the unique keys accepted by the by argument of Upsert{{modelName}}
+var {{modelName}}UniqueKeys = []string{ {{uniqueKeys}} }
+
+func upsert{{modelName}}Func(by string) (func(sqlx.Ext, *{{modelName}}) (int64, error), error) {
+	switch by {
+	{{upsertCases}}
+	}
+	return nil, errors.New("Error: unknown unique key '" + by + "', must be one of " + strings.Join({{modelName}}UniqueKeys, " | "))
+}
+
+// Upsert{{modelName}} inserts or updates m by the unique key by, the columns of by are
+// separated by ",", an empty by means the first unique key.
+func Upsert{{modelName}}(m *{{modelName}}, by string) (id int64, err error) {
+	upsert, err := upsert{{modelName}}Func(by)
+	if err != nil {
+		return
+	}
+	// 唯一键冲突时修改，只校验列
+	if err = m.ValidateColumns(); err != nil {
+		return
+	}
+	return upsert(DB, m)
+}
+
+// UpsertMulti{{modelName}} inserts or updates several {{modelName}}s by the unique key by in
+// one transaction. Nothing is committed unless every item succeeds, and the items after
+// the first failed one are skipped.
+func UpsertMulti{{modelName}}(ms []*{{modelName}}, by string) (result *LgBatchResult, err error) {
+	upsert, err := upsert{{modelName}}Func(by)
+	if err != nil {
+		return
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		itemErr := m.ValidateColumns()
+		if itemErr == nil {
+			_, itemErr = upsert(tx, m)
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+`
+)
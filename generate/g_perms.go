@@ -0,0 +1,265 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	beeLogger "bee/logger"
+)
+
+// PermFormats bee g perms支持的输出格式
+var PermFormats = []string{"json", "csv", "sql"}
+
+// Perm 一个权限，Perm为 请求类型@路径，与BaseController的VerifyPerm提交给权限中心的一致
+type Perm struct {
+	Perm        string
+	Verb        string
+	Url         string
+	Method      string
+	Description string `json:",omitempty"`
+}
+
+// PermGroup 一个控制器的权限
+type PermGroup struct {
+	Controller string
+	Perms      []*Perm
+}
+
+// PermCatalogue 权限目录，不包括@IgnoredToken、@IgnoredPerm的路由
+type PermCatalogue struct {
+	ApiBaseUrl  string
+	Controllers []*PermGroup
+}
+
+// PermDiff 与上一次导出的权限目录相比新增及删除的权限
+type PermDiff struct {
+	Added   []*PermGroup
+	Removed []*PermGroup
+}
+
+// NewPermCatalogue 由路由清单得到权限目录，控制器按名字、权限按路径及请求类型排序
+func NewPermCatalogue(m *RouteManifest) *PermCatalogue {
+	groups := make(map[string]*PermGroup)
+	seen := make(map[string]bool)
+	m.walk(func(rc *RouteController, cm *RouteMethod) {
+		if cm.IgnoredToken || cm.IgnoredPerm {
+			return
+		}
+		for i, route := range cm.Routes {
+			if seen[route] {
+				continue
+			}
+			seen[route] = true
+			g, ok := groups[rc.Name]
+			if !ok {
+				g = &PermGroup{Controller: rc.Name}
+				groups[rc.Name] = g
+			}
+			g.Perms = append(g.Perms, &Perm{Perm: route, Verb: cm.Verbs[i], Url: cm.Url, Method: cm.Name, Description: cm.Description})
+		}
+	})
+	c := &PermCatalogue{ApiBaseUrl: m.ApiBaseUrl}
+	for _, g := range groups {
+		c.Controllers = append(c.Controllers, g)
+	}
+	c.sort()
+	return c
+}
+
+func (c *PermCatalogue) sort() {
+	sort.Slice(c.Controllers, func(i, j int) bool { return c.Controllers[i].Controller < c.Controllers[j].Controller })
+	for _, g := range c.Controllers {
+		sort.Slice(g.Perms, func(i, j int) bool {
+			a, b := g.Perms[i], g.Perms[j]
+			if a.Url != b.Url {
+				return a.Url < b.Url
+			}
+			return a.Verb < b.Verb
+		})
+	}
+}
+
+// perms 权限 => 所在的控制器及权限
+func (c *PermCatalogue) perms() map[string]*PermGroup {
+	rv := make(map[string]*PermGroup)
+	for _, g := range c.Controllers {
+		for _, p := range g.Perms {
+			rv[p.Perm] = &PermGroup{Controller: g.Controller, Perms: []*Perm{p}}
+		}
+	}
+	return rv
+}
+
+// DiffPerms 比较两次导出的权限目录，权限按Perm对应，描述的修改不算新增或删除
+func DiffPerms(old, cur *PermCatalogue) *PermDiff {
+	before, after := old.perms(), cur.perms()
+	return &PermDiff{Added: groupPerms(after, before), Removed: groupPerms(before, after)}
+}
+
+// groupPerms from中不在except中的权限，按控制器分组并排序
+func groupPerms(from, except map[string]*PermGroup) []*PermGroup {
+	c := &PermCatalogue{Controllers: []*PermGroup{}}
+	groups := make(map[string]*PermGroup)
+	for perm, pg := range from {
+		if _, ok := except[perm]; ok {
+			continue
+		}
+		g, ok := groups[pg.Controller]
+		if !ok {
+			g = &PermGroup{Controller: pg.Controller}
+			groups[pg.Controller] = g
+			c.Controllers = append(c.Controllers, g)
+		}
+		g.Perms = append(g.Perms, pg.Perms...)
+	}
+	c.sort()
+	return c.Controllers
+}
+
+// LoadPermCatalogue 读取bee g perms导出的JSON
+func LoadPermCatalogue(fpath string) (*PermCatalogue, error) {
+	b, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	c := &PermCatalogue{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON export of bee g perms: %s", fpath, err)
+	}
+	return c, nil
+}
+
+// GeneratePerms 按routers/router.go及controllers导出权限目录，diffFile不为空时导出与其相比新增及删除的权限。
+// format为json、csv或sql，sql为table的insert语句，删除的权限为delete语句
+func GeneratePerms(currpath, format, outFile, diffFile, table string) {
+	m, warnings, err := BuildRouteManifest(filepath.Join(currpath, "routers", "router.go"), filepath.Join(currpath, "controllers"), currpath)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the routes: %s", err)
+	}
+	for _, w := range warnings {
+		beeLogger.Log.Warn(w)
+	}
+	c := NewPermCatalogue(m)
+	var out []byte
+	summary := fmt.Sprintf("%d permissions of %d controllers", countPerms(c.Controllers), len(c.Controllers))
+	if diffFile == "" {
+		out, err = c.Export(format, table)
+	} else {
+		var old *PermCatalogue
+		if old, err = LoadPermCatalogue(diffFile); err != nil {
+			beeLogger.Log.Fatalf("Could not read the previous export: %s", err)
+		}
+		d := DiffPerms(old, c)
+		summary = fmt.Sprintf("%d permissions added and %d removed since %s", countPerms(d.Added), countPerms(d.Removed), diffFile)
+		out, err = d.Export(format, table)
+	}
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not export the permissions: %s", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(outFile), 0755); err != nil {
+		beeLogger.Log.Fatalf("Could not create the directory of '%s': %s", outFile, err)
+	}
+	if err = ioutil.WriteFile(outFile, out, 0644); err != nil {
+		beeLogger.Log.Fatalf("Could not write '%s': %s", outFile, err)
+	}
+	beeLogger.Log.Infof("Wrote %s to %s", summary, outFile)
+}
+
+func countPerms(groups []*PermGroup) (n int) {
+	for _, g := range groups {
+		n += len(g.Perms)
+	}
+	return
+}
+
+// Export 导出权限目录
+func (c *PermCatalogue) Export(format, table string) ([]byte, error) {
+	switch format {
+	case "json":
+		return marshalPerms(c)
+	case "csv":
+		return permsCSV(c.Controllers, "")
+	case "sql":
+		return []byte("-- Generated by bee g perms\n" + permsSQL(c.Controllers, table, "INSERT")), nil
+	}
+	return nil, fmt.Errorf("unsupported format '%s', must be one of %v", format, PermFormats)
+}
+
+// Export 导出新增及删除的权限，csv的第一列为+或-
+func (d *PermDiff) Export(format, table string) ([]byte, error) {
+	switch format {
+	case "json":
+		return marshalPerms(d)
+	case "csv":
+		added, err := permsCSV(d.Added, "+")
+		if err != nil {
+			return nil, err
+		}
+		removed, err := permsCSV(d.Removed, "-")
+		if err != nil {
+			return nil, err
+		}
+		// 只保留一个表头
+		return append(added, removed[bytes.IndexByte(removed, '\n')+1:]...), nil
+	case "sql":
+		return []byte("-- Generated by bee g perms -diff\n" + permsSQL(d.Removed, table, "DELETE") + permsSQL(d.Added, table, "INSERT")), nil
+	}
+	return nil, fmt.Errorf("unsupported format '%s', must be one of %v", format, PermFormats)
+}
+
+func marshalPerms(v interface{}) ([]byte, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// permsCSV change不为空时作为第一列
+func permsCSV(groups []*PermGroup, change string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{"controller", "perm", "verb", "url", "method", "description"}
+	if change != "" {
+		header = append([]string{"change"}, header...)
+	}
+	w.Write(header)
+	for _, g := range groups {
+		for _, p := range g.Perms {
+			record := []string{g.Controller, p.Perm, p.Verb, p.Url, p.Method, p.Description}
+			if change != "" {
+				record = append([]string{change}, record...)
+			}
+			w.Write(record)
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// permsSQL 每个权限一条INSERT或DELETE语句
+func permsSQL(groups []*PermGroup, table, stmt string) string {
+	var b strings.Builder
+	for _, g := range groups {
+		for _, p := range g.Perms {
+			if stmt == "DELETE" {
+				fmt.Fprintf(&b, "DELETE FROM %s WHERE perm = %s;\n", table, sqlQuote(p.Perm))
+				continue
+			}
+			fmt.Fprintf(&b, "INSERT INTO %s (controller, perm, verb, url, method, description) VALUES (%s, %s, %s, %s, %s, %s);\n",
+				table, sqlQuote(g.Controller), sqlQuote(p.Perm), sqlQuote(p.Verb), sqlQuote(p.Url), sqlQuote(p.Method), sqlQuote(p.Description))
+		}
+	}
+	return b.String()
+}
+
+func sqlQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
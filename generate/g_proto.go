@@ -0,0 +1,532 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	beeLogger "bee/logger"
+	"bee/utils"
+)
+
+// protoScalar model字段的go类型对应的proto类型及pb中的go类型
+var protoScalar = map[string][2]string{
+	"int":       {"int64", "int64"},
+	"int8":      {"int32", "int32"},
+	"int16":     {"int32", "int32"},
+	"int32":     {"int32", "int32"},
+	"int64":     {"int64", "int64"},
+	"uint":      {"uint64", "uint64"},
+	"uint8":     {"uint32", "uint32"},
+	"uint16":    {"uint32", "uint32"},
+	"uint32":    {"uint32", "uint32"},
+	"uint64":    {"uint64", "uint64"},
+	"float32":   {"float", "float32"},
+	"float64":   {"double", "float64"},
+	"string":    {"string", "string"},
+	"bool":      {"bool", "bool"},
+	"time.Time": {"google.protobuf.Timestamp", ""},
+}
+
+// GenerateProto 由数据库的表生成proto文件及调用models的gRPC服务
+func GenerateProto(connStr, apppath string) {
+	writeProtoFiles(readTables(connStr), apppath, getPackagePath(apppath))
+}
+
+// writeProtoFiles 生成proto文件及grpcserver下的服务
+func writeProtoFiles(tables []*Table, apppath string, pkgPath string) {
+	protoName := strings.Replace(path.Base(pkgPath), "-", "_", -1)
+
+	protoPath := path.Join(apppath, "proto")
+	serverPath := path.Join(apppath, "grpcserver")
+	os.Mkdir(protoPath, 0777)
+	os.Mkdir(serverPath, 0777)
+
+	beeLogger.Log.Info("Creating proto files...")
+	fpath := path.Join(protoPath, protoName+".proto")
+	if err := os.WriteFile(fpath, []byte(ProtoString(tables, protoName, pkgPath)), 0666); err != nil {
+		beeLogger.Log.Fatalf("Could not write proto file to '%s': %s", fpath, err)
+	}
+
+	beeLogger.Log.Info("Creating gRPC server files...")
+	var registers []string
+	for _, tb := range tables {
+		if tb.Pk == "" || strings.Contains(tb.Name, "_has_") {
+			continue
+		}
+		registers = append(registers, fmt.Sprintf("pb.Register%sServiceServer(s, &%sServer{})", utils.CamelCase(tb.Name), utils.CamelCase(tb.Name)))
+		fileStr := strings.Replace(tb.ProtoServerString(tables), "{{pkgPath}}", pkgPath, -1)
+		writeBeegoFile(path.Join(serverPath, getFileName(tb.Name)+".go"), fileStr)
+	}
+	notFound := ormNotFound[ORM]
+	fileStr := strings.Replace(ProtoLgServerTPL, "{{registers}}", strings.Join(registers, "\n"), -1)
+	fileStr = strings.Replace(fileStr, "{{notFoundImport}}", notFound[0], -1)
+	fileStr = strings.Replace(fileStr, "{{notFound}}", notFound[1], -1)
+	fileStr = strings.Replace(fileStr, "{{pkgPath}}", pkgPath, -1)
+	writeBeegoFile(path.Join(serverPath, "lg_server.go"), fileStr)
+
+	beeLogger.Log.Infof("Run protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative proto/%s.proto", protoName)
+}
+
+// protoField proto消息的一个字段
+type protoField struct {
+	Name    string // proto中的字段名
+	GoName  string // pb中的字段名
+	Field   string // model的字段名
+	Type    string // model的go类型
+	Comment string
+	Auto    bool // 自增主键及自动维护的时间，不能修改
+	Rel     *ormRelation
+}
+
+// protoFields 返回表在proto中的字段：列及关系，关系为关联表的消息
+func (tb *Table) protoFields(tables []*Table) (fields []*protoField) {
+	rels := make(map[string]*ormRelation)
+	for _, rel := range tb.ormRelations(tables) {
+		rels[rel.Field] = rel
+	}
+	for _, col := range tb.SQLColumns() {
+		f := &protoField{Name: col.Column, Field: col.Field, Type: col.Type, Comment: col.Tag.Comment,
+			Auto: col.Pk || col.AutoNow || col.AutoNowAdd}
+		if col.Rel {
+			f.Name, f.Rel = snakeString(col.Field), rels[col.Field]
+		}
+		fields = append(fields, f)
+	}
+	for _, rel := range tb.ormRelations(tables) {
+		if rel.Kind == "fk" || rel.Kind == "one" {
+			continue
+		}
+		fields = append(fields, &protoField{Name: snakeString(rel.Field), Field: rel.Field, Rel: rel})
+	}
+	for _, f := range fields {
+		f.GoName = protoGoName(f.Name)
+	}
+	return
+}
+
+// protoType 字段在proto中的类型
+func (f *protoField) protoType() string {
+	if f.Rel == nil {
+		return protoScalar[f.Type][0]
+	}
+	if f.Rel.Kind == "fk" || f.Rel.Kind == "one" || f.Rel.Kind == "reverseOne" {
+		return f.Rel.Model
+	}
+	return "repeated " + f.Rel.Model
+}
+
+// ProtoString 返回所有表的消息及CRUD服务，在一个文件中以免关系字段循环import
+func ProtoString(tables []*Table, protoName string, pkgPath string) string {
+	var body []string
+	imports := []string{"google/protobuf/empty.proto", "google/protobuf/field_mask.proto"}
+	for _, tb := range tables {
+		if tb.Pk == "" || strings.Contains(tb.Name, "_has_") {
+			continue
+		}
+		modelName := utils.CamelCase(tb.Name)
+		var lines []string
+		for i, f := range tb.protoFields(tables) {
+			typ := f.protoType()
+			if typ == "" {
+				beeLogger.Log.Warnf("Unsupported type %s of %s.%s, skipped", f.Type, tb.Name, f.Field)
+				continue
+			}
+			if typ == "google.protobuf.Timestamp" && !inStrings(imports, "google/protobuf/timestamp.proto") {
+				imports = append(imports, "google/protobuf/timestamp.proto")
+			}
+			line := fmt.Sprintf("%s %s = %d;", typ, f.Name, i+1)
+			if f.Comment != "" {
+				line += " // " + f.Comment
+			}
+			lines = append(lines, line)
+		}
+		msg := strings.Replace(ProtoMessageTPL, "{{fields}}", strings.Join(lines, "\n  "), -1)
+		msg = strings.Replace(msg, "{{modelName}}", modelName, -1)
+		msg = strings.Replace(msg, "{{lowerName}}", tb.Name, -1)
+		msg = strings.Replace(msg, "{{plural}}", protoPlural(modelName), -1)
+		msg = strings.Replace(msg, "{{tableName}}", tb.Name, -1)
+		msg = strings.Replace(msg, "{{tableComment}}", tb.Comments, -1)
+		body = append(body, msg)
+	}
+	sort.Strings(imports)
+	var importLines []string
+	for _, imp := range imports {
+		importLines = append(importLines, fmt.Sprintf("import %q;", imp))
+	}
+
+	rv := strings.Replace(ProtoTPL, "{{imports}}", strings.Join(importLines, "\n"), -1)
+	rv = strings.Replace(rv, "{{messages}}", strings.Join(body, ""), -1)
+	rv = strings.Replace(rv, "{{protoName}}", protoName, -1)
+	rv = strings.Replace(rv, "{{pkgPath}}", pkgPath, -1)
+	return rv
+}
+
+// ProtoServerString 返回表的gRPC服务及model与消息的转换
+func (tb *Table) ProtoServerString(tables []*Table) string {
+	modelName := utils.CamelCase(tb.Name)
+	lowerName := lowerFirst(modelName)
+	var toProto, toProtoRel, fromProto, fromProtoRel, maskFields, allFields []string
+	for _, f := range tb.protoFields(tables) {
+		if f.Rel != nil {
+			refName := lowerFirst(f.Rel.Model)
+			switch f.Rel.Kind {
+			case "fk", "one":
+				// 新建、修改时只用关联对象的Id
+				toProtoRel = append(toProtoRel, fmt.Sprintf("if m.%s != nil {\n\tp.%s = %sToProto(m.%s)\n}", f.Field, f.GoName, refName, f.Field))
+				fromProtoRel = append(fromProtoRel, fmt.Sprintf("if p.%s != nil {\n\tm.%s = &models.%s{Id: int(p.%s.Id)}\n}", f.GoName, f.Field, f.Rel.Model, f.GoName))
+				maskFields = append(maskFields, fmt.Sprintf("%q: %q,", f.Name, f.Field), fmt.Sprintf("%q: %q,", f.Name+".id", f.Field))
+				allFields = append(allFields, fmt.Sprintf("%q,", f.Field))
+			case "reverseOne":
+				toProtoRel = append(toProtoRel, fmt.Sprintf("if m.%s != nil {\n\tp.%s = %sToProto(m.%s)\n}", f.Field, f.GoName, refName, f.Field))
+				fromProtoRel = append(fromProtoRel, fmt.Sprintf("if p.%s != nil {\n\tm.%s = %sFromProto(p.%s)\n}", f.GoName, f.Field, refName, f.GoName))
+			case "reverseMany":
+				// 新建时一并新建的子记录
+				toProtoRel = append(toProtoRel, fmt.Sprintf("for _, v := range m.%s {\n\tp.%s = append(p.%s, %sToProto(v))\n}", f.Field, f.GoName, f.GoName, refName))
+				fromProtoRel = append(fromProtoRel, fmt.Sprintf("for _, v := range p.%s {\n\tm.%s = append(m.%s, %sFromProto(v))\n}", f.GoName, f.Field, f.Field, refName))
+			case "m2m":
+				toProtoRel = append(toProtoRel, fmt.Sprintf("for _, v := range m.%s {\n\tp.%s = append(p.%s, %sToProto(v))\n}", f.Field, f.GoName, f.GoName, refName))
+				fromProtoRel = append(fromProtoRel, fmt.Sprintf("for _, v := range p.%s {\n\tm.%s = append(m.%s, &models.%s{Id: int(v.Id)})\n}", f.GoName, f.Field, f.Field, f.Rel.Model))
+				maskFields = append(maskFields, fmt.Sprintf("%q: %q,", f.Name, f.Field))
+			}
+			continue
+		}
+		scalar, ok := protoScalar[f.Type]
+		if !ok {
+			continue
+		}
+		if f.Type == "time.Time" {
+			toProto = append(toProto, fmt.Sprintf("%s: lgTimestamp(m.%s),", f.GoName, f.Field))
+			fromProto = append(fromProto, fmt.Sprintf("%s: lgTime(p.%s),", f.Field, f.GoName))
+		} else if scalar[1] != f.Type {
+			toProto = append(toProto, fmt.Sprintf("%s: %s(m.%s),", f.GoName, scalar[1], f.Field))
+			fromProto = append(fromProto, fmt.Sprintf("%s: %s(p.%s),", f.Field, f.Type, f.GoName))
+		} else {
+			toProto = append(toProto, fmt.Sprintf("%s: m.%s,", f.GoName, f.Field))
+			fromProto = append(fromProto, fmt.Sprintf("%s: p.%s,", f.Field, f.GoName))
+		}
+		if f.Auto {
+			continue
+		}
+		maskFields = append(maskFields, fmt.Sprintf("%q: %q,", f.Name, f.Field))
+		allFields = append(allFields, fmt.Sprintf("%q,", f.Field))
+	}
+	rv := strings.Replace(ProtoServerTPL, "{{toProto}}", strings.Join(toProto, "\n"), -1)
+	rv = strings.Replace(rv, "{{toProtoRel}}", strings.Join(toProtoRel, "\n"), -1)
+	rv = strings.Replace(rv, "{{fromProto}}", strings.Join(fromProto, "\n"), -1)
+	rv = strings.Replace(rv, "{{fromProtoRel}}", strings.Join(fromProtoRel, "\n"), -1)
+	rv = strings.Replace(rv, "{{maskFields}}", strings.Join(maskFields, "\n"), -1)
+	rv = strings.Replace(rv, "{{allFields}}", strings.Join(allFields, "\n"), -1)
+	rv = strings.Replace(rv, "{{plural}}", protoPlural(modelName), -1)
+	rv = strings.Replace(rv, "{{modelName}}", modelName, -1)
+	rv = strings.Replace(rv, "{{lowerName}}", lowerName, -1)
+	rv = strings.Replace(rv, "{{tableName}}", tb.Name, -1)
+	return rv
+}
+
+// protoGoName 与protoc-gen-go相同的字段名转换，如 created_at => CreatedAt
+func protoGoName(s string) string {
+	isLower := func(c byte) bool { return 'a' <= c && c <= 'z' }
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isLower(s[i+1]):
+		case isDigit(c):
+			b = append(b, c)
+		default:
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isLower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+// protoPlural 英文复数，用于List方法名
+func protoPlural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsAny(s[len(s)-2:len(s)-1], "aeiou"):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
+
+const (
+	// proto文件模板
+	ProtoTPL = `syntax = "proto3";
+
+package {{protoName}};
+
+{{imports}}
+
+option go_package = "{{pkgPath}}/proto;pb";
+
+// GetRequest gets a record by id, load lists the relations to load
+message GetRequest {
+  int64 id = 1;
+  repeated string load = 2;
+}
+
+// ListRequest has the same query, sortby, order, offset, limit and load as the REST GetAll,
+// e.g. query {"name__icontains": "a"}, sortby ["id"], order ["desc"]
+message ListRequest {
+  map<string, string> query = 1;
+  repeated string sortby = 2;
+  repeated string order = 3;
+  int64 offset = 4;
+  int64 limit = 5;
+  repeated string load = 6;
+}
+
+// DeleteRequest deletes a record by id
+message DeleteRequest {
+  int64 id = 1;
+}
+{{messages}}`
+	// 每个表的消息及服务
+	ProtoMessageTPL = `
+// {{modelName}} [{{tableName}}] {{tableComment}}
+message {{modelName}} {
+  {{fields}}
+}
+
+// {{modelName}}List is a page of {{modelName}}s and the total count matched
+message {{modelName}}List {
+  repeated {{modelName}} items = 1;
+  int64 total = 2;
+}
+
+// Update{{modelName}}Request updates the fields in update_mask, every column is
+// updated when update_mask is empty
+message Update{{modelName}}Request {
+  {{modelName}} {{lowerName}} = 1;
+  google.protobuf.FieldMask update_mask = 2;
+}
+
+// {{modelName}}Service is the CRUD of {{modelName}}
+service {{modelName}}Service {
+  rpc Create{{modelName}}({{modelName}}) returns ({{modelName}});
+  rpc Get{{modelName}}(GetRequest) returns ({{modelName}});
+  rpc List{{plural}}(ListRequest) returns ({{modelName}}List);
+  rpc Update{{modelName}}(Update{{modelName}}Request) returns ({{modelName}});
+  rpc Delete{{modelName}}(DeleteRequest) returns (google.protobuf.Empty);
+}
+`
+	// gRPC服务的公共函数及注册
+	ProtoLgServerTPL = `package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"{{pkgPath}}/models"
+	pb "{{pkgPath}}/proto"
+
+	{{notFoundImport}}
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Register registers the services of all tables on s
+func Register(s *grpc.Server) {
+	{{registers}}
+}
+
+// lgStatus converts the errors of models to gRPC status errors, validation errors
+// carry the invalid fields as BadRequest details
+func lgStatus(err error) error {
+	var ve *models.LgValidationError
+	if errors.As(err, &ve) {
+		br := &errdetails.BadRequest{}
+		for _, fe := range ve.Errors {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message})
+		}
+		st := status.New(codes.InvalidArgument, ve.Error())
+		if ds, e := st.WithDetails(br); e == nil {
+			st = ds
+		}
+		return st.Err()
+	}
+	if {{notFound}} {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// lgMaskFields 把update_mask的路径换成model的字段，为空时返回all
+func lgMaskFields(mask *fieldmaskpb.FieldMask, fields map[string]string, all []string) ([]string, error) {
+	if len(mask.GetPaths()) == 0 {
+		return all, nil
+	}
+	var rv []string
+	for _, p := range mask.GetPaths() {
+		f, ok := fields[p]
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("unknown field %q in update_mask", p))
+		}
+		rv = append(rv, f)
+	}
+	return rv, nil
+}
+
+// lgTimestamp 零值的时间为nil
+func lgTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// lgTime nil为零值的时间
+func lgTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime().In(time.Local)
+}
+`
+	// 每个表的gRPC服务
+	ProtoServerTPL = `package grpcserver
+
+import (
+	"context"
+
+	"{{pkgPath}}/models"
+	pb "{{pkgPath}}/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// {{modelName}}Server implements pb.{{modelName}}ServiceServer by the functions of models
+type {{modelName}}Server struct {
+	pb.Unimplemented{{modelName}}ServiceServer
+}
+
+// {{lowerName}}MaskFields update_mask的路径对应的字段
+var {{lowerName}}MaskFields = map[string]string{
+	{{maskFields}}
+}
+
+// {{lowerName}}AllFields update_mask为空时修改的字段
+var {{lowerName}}AllFields = []string{
+	{{allFields}}
+}
+
+// Create{{modelName}} validates in and adds it together with its relations
+func (s *{{modelName}}Server) Create{{modelName}}(ctx context.Context, in *pb.{{modelName}}) (*pb.{{modelName}}, error) {
+	m := {{lowerName}}FromProto(in)
+	if err := m.Validate(); err != nil {
+		return nil, lgStatus(err)
+	}
+	if _, err := models.Add{{modelName}}HasMany(m); err != nil {
+		return nil, lgStatus(err)
+	}
+	return {{lowerName}}ToProto(m), nil
+}
+
+// Get{{modelName}} gets a {{modelName}} by id and loads the relations in load
+func (s *{{modelName}}Server) Get{{modelName}}(ctx context.Context, in *pb.GetRequest) (*pb.{{modelName}}, error) {
+	m, err := models.Get{{modelName}}ById(int(in.Id))
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	for _, lo := range in.Load {
+		if _, err := m.LoadRelatedOf(lo); err != nil {
+			return nil, lgStatus(err)
+		}
+	}
+	return {{lowerName}}ToProto(m), nil
+}
+
+// List{{plural}} returns a page of {{modelName}}s, limit defaults to 10 like the REST GetAll
+func (s *{{modelName}}Server) List{{plural}}(ctx context.Context, in *pb.ListRequest) (*pb.{{modelName}}List, error) {
+	limit := in.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	l, pager, err := models.GetAll{{modelName}}(in.Query, nil, in.Sortby, in.Order, in.Offset, limit, in.Load, 1)
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	out := &pb.{{modelName}}List{Total: pager.Page.TotalCount}
+	for _, v := range l {
+		m := v.(models.{{modelName}})
+		out.Items = append(out.Items, {{lowerName}}ToProto(&m))
+	}
+	return out, nil
+}
+
+// Update{{modelName}} updates the fields in update_mask of an existing {{modelName}}
+func (s *{{modelName}}Server) Update{{modelName}}(ctx context.Context, in *pb.Update{{modelName}}Request) (*pb.{{modelName}}, error) {
+	if in.{{modelName}} == nil {
+		return nil, status.Error(codes.InvalidArgument, "{{tableName}} is required")
+	}
+	fields, err := lgMaskFields(in.UpdateMask, {{lowerName}}MaskFields, {{lowerName}}AllFields)
+	if err != nil {
+		return nil, err
+	}
+	m := {{lowerName}}FromProto(in.{{modelName}})
+	if _, err := models.Get{{modelName}}ById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := m.ValidateFields(fields...); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.Patch{{modelName}}ById(m, fields); err != nil {
+		return nil, lgStatus(err)
+	}
+	if m, err = models.Get{{modelName}}ById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	return {{lowerName}}ToProto(m), nil
+}
+
+// Delete{{modelName}} deletes a {{modelName}} by id
+func (s *{{modelName}}Server) Delete{{modelName}}(ctx context.Context, in *pb.DeleteRequest) (*emptypb.Empty, error) {
+	if _, err := models.Get{{modelName}}ById(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.Delete{{modelName}}(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// {{lowerName}}ToProto converts m to a pb.{{modelName}}, relations are converted when loaded
+func {{lowerName}}ToProto(m *models.{{modelName}}) *pb.{{modelName}} {
+	p := &pb.{{modelName}}{
+		{{toProto}}
+	}
+	{{toProtoRel}}
+	return p
+}
+
+// {{lowerName}}FromProto converts p to a models.{{modelName}}
+func {{lowerName}}FromProto(p *pb.{{modelName}}) *models.{{modelName}} {
+	m := &models.{{modelName}}{
+		{{fromProto}}
+	}
+	{{fromProtoRel}}
+	return m
+}
+`
+)
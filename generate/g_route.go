@@ -0,0 +1,515 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	beeLogger "bee/logger"
+	"bee/utils"
+)
+
+// 路由清单由routers/router.go的语法树及控制器方法的注释得到，
+// 输出为router.json及控制器包中的lg_routes.go，BaseController按其中的鉴权要求跳过验证
+
+// RouteManifest 路由清单
+type RouteManifest struct {
+	ApiBaseUrl string
+	// Rts 所有路由的平铺列表，Url不含ApiBaseUrl
+	Rts        []*Rt
+	Namespaces []*RouteNamespace
+}
+
+// Rt 路由定义
+type Rt struct {
+	Title      string
+	Url        string
+	MethodType string
+	MUrl       string
+}
+
+// RouteNamespace NewNamespace或NSNamespace，Path为相对上一级的路径
+type RouteNamespace struct {
+	Path        string
+	Namespaces  []*RouteNamespace  `json:",omitempty"`
+	Controllers []*RouteController `json:",omitempty"`
+}
+
+// RouteController 命名空间中的控制器，File为相对项目的路径
+type RouteController struct {
+	Name    string
+	File    string
+	Methods []*RouteMethod
+}
+
+// RouteMethod 控制器方法的一个路由，Route为 请求类型@完整路径 的形式，与BaseController中的一致
+type RouteMethod struct {
+	Name         string
+	Title        string `json:",omitempty"`
+	Description  string `json:",omitempty"`
+	Verbs        []string
+	Path         string
+	Url          string
+	Routes       []string
+	Params       []*RouteParam `json:",omitempty"`
+	IgnoredToken bool
+	IgnoredPerm  bool
+}
+
+// RouteParam 方法注释中的@Param
+type RouteParam struct {
+	Name        string
+	In          string
+	Type        string
+	Required    bool
+	Description string `json:",omitempty"`
+}
+
+// routeDoc 控制器方法的注释
+type routeDoc struct {
+	title, description string
+	routers            [][2]string // 路径及请求类型
+	params             []*RouteParam
+	ignoredToken       bool
+	ignoredPerm        bool
+}
+
+// ctrlType 控制器类型及其方法的注释
+type ctrlType struct {
+	file    string
+	methods []string
+	docs    map[string]*routeDoc
+}
+
+// BuildRouteManifest 解析routerFile中的命名空间及ctrlDir中的控制器，无法解析的部分作为警告返回
+func BuildRouteManifest(routerFile, ctrlDir, currpath string) (*RouteManifest, []string, error) {
+	fset := token.NewFileSet()
+	af, err := parser.ParseFile(fset, routerFile, nil, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := &routeBuilder{fset: fset, currpath: currpath}
+	if b.ctrls, err = parseCtrlTypes(ctrlDir, currpath); err != nil {
+		return nil, nil, err
+	}
+	m := &RouteManifest{}
+	root := &RouteNamespace{}
+	ast.Inspect(af, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		switch callName(call) {
+		case "NewNamespace":
+			if ns := b.namespace(call, ""); ns != nil {
+				if m.ApiBaseUrl == "" {
+					m.ApiBaseUrl = ns.Path
+				}
+				m.Namespaces = append(m.Namespaces, ns)
+			}
+			return false
+		case "Include", "Router":
+			// beego.Include、beego.Router，不在命名空间中
+			b.entry(root, call, "")
+			return false
+		}
+		return true
+	})
+	if len(root.Controllers) > 0 {
+		m.Namespaces = append(m.Namespaces, root)
+	}
+	base := strings.TrimSuffix(m.ApiBaseUrl, "/")
+	m.walk(func(_ *RouteController, cm *RouteMethod) {
+		url := cm.Url
+		if base != "" && strings.HasPrefix(url, base+"/") {
+			url = strings.TrimPrefix(url, base)
+		}
+		for _, verb := range cm.Verbs {
+			m.Rts = append(m.Rts, &Rt{Title: cm.Description, Url: url, MethodType: verb, MUrl: verb + "@" + url})
+		}
+	})
+	return m, b.warnings, nil
+}
+
+// walk 按顺序访问所有的方法
+func (m *RouteManifest) walk(fn func(*RouteController, *RouteMethod)) {
+	var visit func(nss []*RouteNamespace)
+	visit = func(nss []*RouteNamespace) {
+		for _, ns := range nss {
+			for _, c := range ns.Controllers {
+				for _, cm := range c.Methods {
+					fn(c, cm)
+				}
+			}
+			visit(ns.Namespaces)
+		}
+	}
+	visit(m.Namespaces)
+}
+
+type routeBuilder struct {
+	fset     *token.FileSet
+	currpath string
+	ctrls    map[string]*ctrlType
+	warnings []string
+}
+
+func (b *routeBuilder) warnf(pos token.Pos, format string, args ...interface{}) {
+	p := b.fset.Position(pos)
+	if rel, err := filepath.Rel(b.currpath, p.Filename); err == nil {
+		p.Filename = rel
+	}
+	b.warnings = append(b.warnings, fmt.Sprintf("%s:%d:%d: %s", p.Filename, p.Line, p.Column, fmt.Sprintf(format, args...)))
+}
+
+// namespace 读取NewNamespace或NSNamespace的路径及参数，prefix为上一级的完整路径
+func (b *routeBuilder) namespace(call *ast.CallExpr, prefix string) *RouteNamespace {
+	if len(call.Args) == 0 {
+		return nil
+	}
+	p, ok := stringLit(call.Args[0])
+	if !ok {
+		b.warnf(call.Args[0].Pos(), "namespace path is not a string literal, skipped")
+		return nil
+	}
+	ns := &RouteNamespace{Path: p}
+	for _, arg := range call.Args[1:] {
+		if c, ok := arg.(*ast.CallExpr); ok {
+			b.entry(ns, c, prefix+p)
+		}
+	}
+	return ns
+}
+
+// entry 命名空间中的一个参数：NSNamespace、NSInclude、NSRouter，或命名空间外的Include、Router
+func (b *routeBuilder) entry(ns *RouteNamespace, call *ast.CallExpr, prefix string) {
+	switch callName(call) {
+	case "NSNamespace":
+		if child := b.namespace(call, prefix); child != nil {
+			ns.Namespaces = append(ns.Namespaces, child)
+		}
+	case "NSInclude", "Include":
+		for _, arg := range call.Args {
+			if rc := b.include(arg, prefix); rc != nil {
+				ns.Controllers = append(ns.Controllers, rc)
+			}
+		}
+	case "NSRouter", "Router":
+		if rc := b.router(call, prefix); rc != nil {
+			ns.Controllers = append(ns.Controllers, rc)
+		}
+	}
+}
+
+// controller 找到&controllers.XController{}对应的控制器
+func (b *routeBuilder) controller(expr ast.Expr) (string, *ctrlType) {
+	if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		expr = u.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		b.warnf(expr.Pos(), "controller is not a composite literal, skipped")
+		return "", nil
+	}
+	var name string
+	switch t := lit.Type.(type) {
+	case *ast.SelectorExpr:
+		name = t.Sel.Name
+	case *ast.Ident:
+		name = t.Name
+	}
+	ct, ok := b.ctrls[name]
+	if !ok {
+		b.warnf(expr.Pos(), "controller %s not found in the controllers", name)
+		return "", nil
+	}
+	return name, ct
+}
+
+// include NSInclude的控制器，路由为方法注释中的@router
+func (b *routeBuilder) include(expr ast.Expr, prefix string) *RouteController {
+	name, ct := b.controller(expr)
+	if ct == nil {
+		return nil
+	}
+	rc := &RouteController{Name: name, File: ct.file}
+	for _, method := range ct.methods {
+		doc := ct.docs[method]
+		for _, r := range doc.routers {
+			rc.Methods = append(rc.Methods, newRouteMethod(method, doc, r[0], strings.Split(r[1], ","), prefix))
+		}
+	}
+	return rc
+}
+
+// router NSRouter(path, &X{}, "get:Method;post:Other")的路由，没有映射时为Get、Post等同名的方法
+func (b *routeBuilder) router(call *ast.CallExpr, prefix string) *RouteController {
+	if len(call.Args) < 2 {
+		return nil
+	}
+	p, ok := stringLit(call.Args[0])
+	if !ok {
+		b.warnf(call.Args[0].Pos(), "router path is not a string literal, skipped")
+		return nil
+	}
+	name, ct := b.controller(call.Args[1])
+	if ct == nil {
+		return nil
+	}
+	rc := &RouteController{Name: name, File: ct.file}
+	add := func(verbs []string, method string) {
+		doc, ok := ct.docs[method]
+		if !ok {
+			b.warnf(call.Pos(), "method %s of %s not found", method, name)
+			return
+		}
+		rc.Methods = append(rc.Methods, newRouteMethod(method, doc, p, verbs, prefix))
+	}
+	if len(call.Args) < 3 {
+		for _, method := range ct.methods {
+			if verb := strings.ToLower(method); isHTTPVerb(verb) {
+				add([]string{verb}, method)
+			}
+		}
+		return rc
+	}
+	mapping, ok := stringLit(call.Args[2])
+	if !ok {
+		b.warnf(call.Args[2].Pos(), "router mapping is not a string literal, skipped")
+		return nil
+	}
+	for _, m := range strings.Split(mapping, ";") {
+		kv := strings.SplitN(strings.TrimSpace(m), ":", 2)
+		if len(kv) != 2 {
+			b.warnf(call.Args[2].Pos(), "invalid router mapping %q", m)
+			continue
+		}
+		add(strings.Split(kv[0], ","), strings.TrimSpace(kv[1]))
+	}
+	return rc
+}
+
+func newRouteMethod(name string, doc *routeDoc, p string, verbs []string, prefix string) *RouteMethod {
+	cm := &RouteMethod{Name: name, Title: doc.title, Description: doc.description, Path: p, Url: prefix + p,
+		Params: doc.params, IgnoredToken: doc.ignoredToken, IgnoredPerm: doc.ignoredPerm}
+	for _, verb := range verbs {
+		verb = strings.ToLower(strings.TrimSpace(verb))
+		if verb == "" {
+			continue
+		}
+		cm.Verbs = append(cm.Verbs, verb)
+		cm.Routes = append(cm.Routes, verb+"@"+cm.Url)
+	}
+	return cm
+}
+
+func isHTTPVerb(s string) bool {
+	switch s {
+	case "get", "post", "put", "patch", "delete", "head", "options":
+		return true
+	}
+	return false
+}
+
+// parseCtrlTypes 解析ctrlDir中所有的控制器类型，按类型名而不是文件名查找
+func parseCtrlTypes(ctrlDir, currpath string) (map[string]*ctrlType, error) {
+	files, err := filepath.Glob(filepath.Join(ctrlDir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	ctrls := make(map[string]*ctrlType)
+	get := func(name string) *ctrlType {
+		if ctrls[name] == nil {
+			ctrls[name] = &ctrlType{docs: make(map[string]*routeDoc)}
+		}
+		return ctrls[name]
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		af, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		rel := file
+		if r, err := filepath.Rel(currpath, file); err == nil {
+			rel = r
+		}
+		for _, decl := range af.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						get(ts.Name.Name).file = filepath.ToSlash(rel)
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil || len(d.Recv.List) == 0 {
+					continue
+				}
+				recv := d.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if id, ok := recv.(*ast.Ident); ok {
+					ct := get(id.Name)
+					ct.methods = append(ct.methods, d.Name.Name)
+					ct.docs[d.Name.Name] = parseRouteDoc(d.Doc)
+				}
+			}
+		}
+	}
+	return ctrls, nil
+}
+
+// parseRouteDoc 读取@Title、@Description、@Param、@router、@IgnoredToken、@IgnoredPerm，
+// 不以@开头的行接在上一个@Title或@Description之后
+func parseRouteDoc(cg *ast.CommentGroup) *routeDoc {
+	doc := &routeDoc{}
+	if cg == nil {
+		return doc
+	}
+	var last *string
+	for _, line := range strings.Split(cg.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "@") {
+			if last != nil && line != "" {
+				*last = strings.TrimSpace(*last + " " + line)
+			}
+			continue
+		}
+		last = nil
+		key, value := line, ""
+		if i := strings.IndexAny(line, " \t"); i > 0 {
+			key, value = line[:i], strings.TrimSpace(line[i:])
+		}
+		switch strings.ToLower(key) {
+		case "@title":
+			doc.title, last = value, &doc.title
+		case "@description":
+			doc.description, last = value, &doc.description
+		case "@param":
+			if p := parseRouteParam(value); p != nil {
+				doc.params = append(doc.params, p)
+			}
+		case "@router":
+			p, verbs := value, "get"
+			if i := strings.Index(value, "["); i >= 0 {
+				p, verbs = strings.TrimSpace(value[:i]), strings.Trim(value[i:], "[] ")
+			}
+			if p != "" {
+				doc.routers = append(doc.routers, [2]string{p, verbs})
+			}
+		case "@ignoredtoken":
+			doc.ignoredToken = true
+		case "@ignoredperm":
+			doc.ignoredPerm = true
+		}
+	}
+	return doc
+}
+
+// parseRouteParam name in type required "description"
+func parseRouteParam(s string) *RouteParam {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return nil
+	}
+	p := &RouteParam{Name: fields[0], In: fields[1]}
+	if len(fields) > 2 {
+		p.Type = fields[2]
+	}
+	if len(fields) > 3 {
+		p.Required, _ = strconv.ParseBool(fields[3])
+	}
+	if len(fields) > 4 {
+		desc := strings.TrimSpace(s[strings.Index(s, fields[4]):])
+		if d, err := strconv.Unquote(desc); err == nil {
+			desc = d
+		}
+		p.Description = desc
+	}
+	return p
+}
+
+// WriteRouteManifest 按routers/router.go及controllers生成routers/router.json、static/router/router.json
+// 及controllers/lg_routes.go，解析失败时不修改文件
+func WriteRouteManifest(currpath string) error {
+	m, warnings, err := BuildRouteManifest(path.Join(currpath, "routers", "router.go"), path.Join(currpath, "controllers"), currpath)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		beeLogger.Log.Warn(w)
+	}
+	b, err := json.MarshalIndent(m, "", "      ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(currpath, "routers", "router.json"), b, 0666); err != nil {
+		return err
+	}
+	os.MkdirAll(path.Join(currpath, "static", "router"), 0777)
+	if err := ioutil.WriteFile(path.Join(currpath, "static", "router", "router.json"), b, 0666); err != nil {
+		return err
+	}
+	return writeRouteGoFile(path.Join(currpath, "controllers", RouteGoFile), m)
+}
+
+// RouteGoFile 控制器包中路由清单的文件名
+const RouteGoFile = "lg_routes.go"
+
+// writeRouteGoFile 生成RouteManifest变量，m为nil时为空的清单
+func writeRouteGoFile(fpath string, m *RouteManifest) error {
+	var lines []string
+	seen := make(map[string]bool)
+	if m != nil {
+		m.walk(func(rc *RouteController, cm *RouteMethod) {
+			for _, route := range cm.Routes {
+				if seen[route] {
+					continue
+				}
+				seen[route] = true
+				lines = append(lines, fmt.Sprintf("%s: {Controller: %s, Method: %s, Description: %s, IgnoredToken: %t, IgnoredPerm: %t},",
+					strconv.Quote(route), strconv.Quote(rc.Name), strconv.Quote(cm.Name), strconv.Quote(cm.Description), cm.IgnoredToken, cm.IgnoredPerm))
+			}
+		})
+	}
+	sort.Strings(lines)
+	src := strings.Replace(RouteManifestTPL, "{{routes}}", strings.Join(lines, "\n"), 1)
+	if err := ioutil.WriteFile(fpath, []byte(src), 0666); err != nil {
+		return err
+	}
+	utils.FormatSourceCode(fpath)
+	return nil
+}
+
+const RouteManifestTPL = `// Code generated by bee from routers/router.go and the controller comments. DO NOT EDIT.
+
+package controllers
+
+// RouteInfo 路由对应的方法及鉴权要求
+type RouteInfo struct {
+	Controller   string
+	Method       string
+	Description  string
+	IgnoredToken bool
+	IgnoredPerm  bool
+}
+
+// RouteManifest 请求类型@路径 => 路由，方法注释中有@IgnoredToken、@IgnoredPerm时跳过JWT、权限验证
+var RouteManifest = map[string]RouteInfo{
+{{routes}}
+}
+`
@@ -0,0 +1,184 @@
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const routeTestRouter = `package routers
+
+import (
+	"app/controllers"
+
+	"github.com/astaxie/beego"
+)
+
+func init() {
+	ns := beego.NewNamespace("/v1",
+		beego.NSNamespace("/user",
+			beego.NSInclude(
+				&controllers.UserController{},
+			),
+			beego.NSNamespace("/admin",
+				beego.NSRouter("/stats", &controllers.AdminController{}, "get,post:Stats"),
+			),
+		),
+		beego.NSNamespace("/rule_order",
+			beego.NSInclude(&controllers.RuleOrderController{}),
+		),
+		beego.NSNamespace("/missing",
+			beego.NSInclude(&controllers.MissingController{}),
+		),
+	)
+	beego.AddNamespace(ns)
+	beego.Router("/health", &controllers.HealthController{})
+}
+`
+
+var routeTestControllers = map[string]string{
+	"user.go": `package controllers
+
+type UserController struct {
+	BaseController
+}
+
+// Post ...
+// @Title Post
+// @Description create User
+//   and return its Id
+// @Param	body		body 	models.User	true		"body for User content"
+// @router / [post]
+func (c *UserController) Post() {
+}
+
+/*
+@router /:id [get]
+*/
+func (c *UserController) GetOne() {
+}
+
+// @IgnoredToken
+// @router /login [post,options]
+func (c *UserController) Login() {
+}
+
+// helper 没有@router
+func (c *UserController) helper() {
+}
+`,
+	// 文件名与路由及控制器名不同
+	"rule_order_ctrl.go": `package controllers
+
+type RuleOrderController struct {
+	BaseController
+}
+
+// @Description delete an order
+// @IgnoredPerm
+// @router /:id [delete]
+func (c *RuleOrderController) Delete() {
+}
+`,
+	"admin.go": `package controllers
+
+type AdminController struct {
+	BaseController
+}
+
+// @Description statistics
+func (c *AdminController) Stats() {
+}
+`,
+	"health.go": `package controllers
+
+type HealthController struct {
+	BaseController
+}
+
+func (c *HealthController) Get() {
+}
+`,
+}
+
+// writeRouteProject 新建有routers/router.go及controllers的项目，返回项目目录
+func writeRouteProject(t *testing.T, router string, ctrls map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "bee-route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	files := map[string]string{filepath.Join("routers", "router.go"): router}
+	for name, src := range ctrls {
+		files[filepath.Join("controllers", name)] = src
+	}
+	for name, src := range files {
+		fpath := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fpath, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBuildRouteManifest(t *testing.T) {
+	dir := writeRouteProject(t, routeTestRouter, routeTestControllers)
+	m, warnings, err := BuildRouteManifest(filepath.Join(dir, "routers", "router.go"), filepath.Join(dir, "controllers"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	m.walk(func(rc *RouteController, cm *RouteMethod) {
+		got = append(got, fmt.Sprintf("%s %s.%s %v %q %q token=%v perm=%v params=%d",
+			rc.File, rc.Name, cm.Name, cm.Routes, cm.Title, cm.Description, cm.IgnoredToken, cm.IgnoredPerm, len(cm.Params)))
+	})
+	want := []string{
+		`controllers/user.go UserController.Post [post@/v1/user/] "Post" "create User and return its Id" token=false perm=false params=1`,
+		`controllers/user.go UserController.GetOne [get@/v1/user/:id] "" "" token=false perm=false params=0`,
+		`controllers/user.go UserController.Login [post@/v1/user/login options@/v1/user/login] "" "" token=true perm=false params=0`,
+		`controllers/admin.go AdminController.Stats [get@/v1/user/admin/stats post@/v1/user/admin/stats] "" "statistics" token=false perm=false params=0`,
+		`controllers/rule_order_ctrl.go RuleOrderController.Delete [delete@/v1/rule_order/:id] "" "delete an order" token=false perm=true params=0`,
+		`controllers/health.go HealthController.Get [get@/health] "" "" token=false perm=false params=0`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildRouteManifest() methods:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if m.ApiBaseUrl != "/v1" {
+		t.Errorf("ApiBaseUrl = %q, want /v1", m.ApiBaseUrl)
+	}
+	// Rts的Url不含ApiBaseUrl，命名空间外的路由不变
+	var rts []string
+	for _, rt := range m.Rts {
+		rts = append(rts, rt.MUrl)
+	}
+	wantRts := []string{"post@/user/", "get@/user/:id", "post@/user/login", "options@/user/login",
+		"get@/user/admin/stats", "post@/user/admin/stats", "delete@/rule_order/:id", "get@/health"}
+	if !reflect.DeepEqual(rts, wantRts) {
+		t.Errorf("Rts = %v, want %v", rts, wantRts)
+	}
+
+	wantWarnings := []string{filepath.Join("routers", "router.go") + ":23:21: controller MissingController not found in the controllers"}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("warnings = %q, want %q", warnings, wantWarnings)
+	}
+}
+
+func TestBuildRouteManifestEmpty(t *testing.T) {
+	// 没有命名空间及注释时为空的清单
+	dir := writeRouteProject(t, "package routers\n\nfunc init() {\n}\n", map[string]string{
+		"user.go": "package controllers\n\ntype UserController struct{}\n\nfunc (c *UserController) Post() {}\n",
+	})
+	m, warnings, err := BuildRouteManifest(filepath.Join(dir, "routers", "router.go"), filepath.Join(dir, "controllers"), dir)
+	if err != nil || len(m.Namespaces) != 0 || len(m.Rts) != 0 || len(warnings) != 0 {
+		t.Errorf("BuildRouteManifest() = %+v, %v, %v", m, warnings, err)
+	}
+}
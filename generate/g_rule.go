@@ -0,0 +1,724 @@
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"bee/utils"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// RuleFilePath rule.yml相对项目目录的路径
+var RuleFilePath = filepath.Join("rules", "rule.yml")
+
+// RuleFile 解析后的rule.yml，controller保持文件中的顺序
+type RuleFile struct {
+	Path            string
+	Api             string
+	Controllers     []string
+	RuleControllers []string
+	Rules           []*CodeController
+}
+
+// RuleArg rule中struct的一个字段
+type RuleArg struct {
+	Name  string
+	Value string
+}
+
+// RuleError rule.yml中的错误，Line、Column从1开始，为0时表示整个文件
+type RuleError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *RuleError) Error() string {
+	if e.Line == 0 {
+		return e.File + ": " + e.Msg
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// yamlPos YAML中键及其值的位置，值在下一行时与键的位置相同
+type yamlPos struct {
+	Line, Column           int
+	ValueLine, ValueColumn int
+	Quoted                 bool
+}
+
+var (
+	identRegex  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	importRegex = regexp.MustCompile(`^[A-Za-z0-9_.~+\-]+(/[A-Za-z0-9_.~+\-]+)*$`)
+	moduleRegex = regexp.MustCompile(`(?m)^module\s+"?([^"\s]+)"?`)
+)
+
+// LoadRules 读取并校验rule.yml的结构和每条rule的语法，不检查controller文件
+func LoadRules(fpath string) (*RuleFile, []error) {
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, []error{err}
+	}
+	var doc yaml.MapSlice
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		// yaml.v2的错误带有行号
+		return nil, []error{&RuleError{File: fpath, Msg: strings.TrimPrefix(err.Error(), "yaml: ")}}
+	}
+	rl := &ruleLoader{file: &RuleFile{Path: fpath}, pos: yamlPositions(data)}
+	for _, item := range doc {
+		key := fmt.Sprint(item.Key)
+		switch key {
+		case "route":
+			rl.loadRoute(item.Value)
+		case "controller":
+			rl.loadControllers(item.Value)
+		default:
+			rl.errorf(key, false, 0, "unknown key %q, must be route or controller", key)
+		}
+	}
+	return rl.file, rl.errs
+}
+
+type ruleLoader struct {
+	file *RuleFile
+	pos  map[string]yamlPos
+	errs []error
+}
+
+// errorf 记录path处的错误，atValue为true时位置为值中的第offset个字节
+func (rl *ruleLoader) errorf(path string, atValue bool, offset int, format string, args ...interface{}) {
+	e := &RuleError{File: rl.file.Path, Msg: fmt.Sprintf(format, args...)}
+	// {a: b}等行内的值中的键没有位置，取其所在的键的位置
+	for _, ok := rl.pos[path]; !ok && strings.Contains(path, "."); _, ok = rl.pos[path] {
+		path, offset = path[:strings.LastIndex(path, ".")], 0
+	}
+	if p, ok := rl.pos[path]; ok {
+		e.Line, e.Column = p.Line, p.Column
+		if atValue {
+			e.Line, e.Column = p.ValueLine, p.ValueColumn+offset
+			if p.Quoted {
+				e.Column++
+			}
+		}
+	}
+	rl.errs = append(rl.errs, e)
+}
+
+// scalar 取标量的值，nil为空串
+func (rl *ruleLoader) scalar(path string, v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", true
+	case yaml.MapSlice, []interface{}:
+		rl.errorf(path, false, 0, "%s must be a string", path)
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// mapping 取映射，nil为空的映射
+func (rl *ruleLoader) mapping(path string, v interface{}) (yaml.MapSlice, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, true
+	case yaml.MapSlice:
+		return v, true
+	}
+	rl.errorf(path, false, 0, "%s must be a mapping", path)
+	return nil, false
+}
+
+func (rl *ruleLoader) loadRoute(v interface{}) {
+	route, _ := rl.mapping("route", v)
+	for _, item := range route {
+		key := fmt.Sprint(item.Key)
+		path := "route." + key
+		value, ok := rl.scalar(path, item.Value)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "api":
+			rl.file.Api = strings.TrimSpace(value)
+		case "controller", "rulecontroller":
+			var names []string
+			offset := 0
+			for _, name := range strings.Split(value, ",") {
+				if n := strings.TrimSpace(name); n != "" {
+					if !identRegex.MatchString(n) {
+						rl.errorf(path, true, offset+strings.Index(name, n), "invalid controller name %q", n)
+					}
+					names = append(names, n)
+				}
+				offset += len(name) + 1
+			}
+			if key == "controller" {
+				rl.file.Controllers = names
+			} else {
+				rl.file.RuleControllers = names
+			}
+		default:
+			rl.errorf(path, false, 0, "unknown key %q, must be api, controller or rulecontroller", key)
+		}
+	}
+}
+
+func (rl *ruleLoader) loadControllers(v interface{}) {
+	ctrls, _ := rl.mapping("controller", v)
+	seen := make(map[string]bool)
+	for _, item := range ctrls {
+		ctrName := fmt.Sprint(item.Key)
+		ctrPath := "controller." + ctrName
+		if !identRegex.MatchString(ctrName) {
+			rl.errorf(ctrPath, false, 0, "invalid controller name %q", ctrName)
+			continue
+		}
+		if seen[strings.ToLower(ctrName)] {
+			rl.errorf(ctrPath, false, 0, "duplicate controller %s", ctrName)
+			continue
+		}
+		seen[strings.ToLower(ctrName)] = true
+		ctr := &CodeController{Name: ctrName, at: rl.pos[ctrPath]}
+		apis, _ := rl.mapping(ctrPath, item.Value)
+		for _, apiItem := range apis {
+			apiName := fmt.Sprint(apiItem.Key)
+			// 代码块以controller.api.pos为键，api不能重复
+			if seen[strings.ToLower(ctrName+"."+apiName)] {
+				rl.errorf(ctrPath+"."+apiName, false, 0, "duplicate api %s of %s", apiName, ctrName)
+				continue
+			}
+			seen[strings.ToLower(ctrName+"."+apiName)] = true
+			if api := rl.loadApi(ctrPath, apiName, apiItem.Value); api != nil {
+				ctr.Apis = append(ctr.Apis, api)
+			}
+		}
+		rl.file.Rules = append(rl.file.Rules, ctr)
+	}
+}
+
+func (rl *ruleLoader) loadApi(ctrPath, apiName string, v interface{}) *CodeApi {
+	apiPath := ctrPath + "." + apiName
+	if !identRegex.MatchString(apiName) {
+		rl.errorf(apiPath, false, 0, "invalid api name %q", apiName)
+		return nil
+	}
+	fields, ok := rl.mapping(apiPath, v)
+	if !ok {
+		return nil
+	}
+	api := &CodeApi{Name: apiName, at: map[string]yamlPos{"": rl.pos[apiPath]}}
+	valid := true
+	for _, item := range fields {
+		key := fmt.Sprint(item.Key)
+		path := apiPath + "." + key
+		var value string
+		if strings.ToLower(key) != "rules" {
+			if value, ok = rl.scalar(path, item.Value); !ok {
+				valid = false
+				continue
+			}
+		}
+		// viper不区分键的大小写，这里保持一致
+		switch strings.ToLower(key) {
+		case "pos":
+			if value = strings.TrimSpace(value); !identRegex.MatchString(value) {
+				rl.errorf(path, true, 0, "invalid pos %q, must be the name of a pos point such as pos11, or begin or end", value)
+				valid = false
+			}
+			api.Pos = "// " + value
+		case "rule":
+			api.Rule = value
+			code, err := ParseRule(value)
+			if err != nil {
+				se := err.(*RuleSyntaxError)
+				rl.errorf(path, true, se.Offset, "rule: %s", se.Msg)
+				valid = false
+			}
+			api.Code = code
+		case "rules":
+			rules, ok := rl.loadRules(path, item.Value)
+			if !ok {
+				valid = false
+			}
+			api.Rules = append(api.Rules, rules...)
+		case "imports":
+			api.Imports = value
+			offset := 0
+			for _, imp := range strings.Split(value, ",") {
+				if p := strings.TrimSpace(imp); p != "" {
+					if !importRegex.MatchString(p) {
+						rl.errorf(path, true, offset+strings.Index(imp, p), "invalid import path %q", p)
+						valid = false
+					}
+				}
+				offset += len(imp) + 1
+			}
+		default:
+			rl.errorf(path, false, 0, "unknown key %q, must be pos, rule, rules or imports", key)
+			valid = false
+			continue
+		}
+		api.at[strings.ToLower(key)] = rl.pos[path]
+	}
+	_, hasRule := api.at["rule"]
+	_, hasRules := api.at["rules"]
+	switch {
+	case !hasRule && !hasRules:
+		rl.errorf(apiPath, false, 0, "%s has neither rule nor rules", apiName)
+		valid = false
+	case hasRule && api.Pos == "":
+		rl.errorf(apiPath, false, 0, "%s has no pos for its rule", apiName)
+		valid = false
+	case !hasRule && api.Pos != "":
+		rl.errorf(apiPath+".pos", false, 0, "pos is only for rule, set pos in each item of rules instead")
+		valid = false
+	}
+	if !valid {
+		return nil
+	}
+	if hasRule {
+		// rule的写法在其他规则之前
+		api.Rules = append([]*ApiRule{{Kind: "rule", Pos: strings.TrimPrefix(api.Pos, "// "), Code: api.Code.Code(), at: api.at["pos"]}}, api.Rules...)
+	}
+	return api
+}
+
+// ImportList rule的import路径
+func (api *CodeApi) ImportList() (imports []string) {
+	for _, imp := range strings.Split(api.Imports, ",") {
+		if imp = strings.TrimSpace(imp); imp != "" {
+			imports = append(imports, imp)
+		}
+	}
+	return
+}
+
+// yamlPositions 返回每个键的位置，键为以.连接的路径，列表项为其序号，
+// 如controller.User.Post.rules.0.validate。只用于错误信息
+func yamlPositions(data []byte) map[string]yamlPos {
+	positions := make(map[string]yamlPos)
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return positions
+	}
+	lines := strings.Split(string(data), "\n")
+	var walk func(prefix string, n *yamlv3.Node)
+	walk = func(prefix string, n *yamlv3.Node) {
+		switch n.Kind {
+		case yamlv3.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				path := prefix + key.Value
+				positions[path] = valuePos(key, value, lines)
+				walk(path+".", value)
+			}
+		case yamlv3.SequenceNode:
+			for i, item := range n.Content {
+				path := prefix + strconv.Itoa(i)
+				positions[path] = valuePos(item, item, lines)
+				walk(path+".", item)
+			}
+		}
+	}
+	walk("", doc.Content[0])
+	return positions
+}
+
+// valuePos key及其值value的位置，值为映射、列表或为空时取键的位置，|、>的值取其第一行
+func valuePos(key, value *yamlv3.Node, lines []string) yamlPos {
+	p := yamlPos{Line: key.Line, Column: key.Column, ValueLine: key.Line, ValueColumn: key.Column}
+	quoted := value.Style&(yamlv3.DoubleQuotedStyle|yamlv3.SingleQuotedStyle) != 0
+	if value.Kind != yamlv3.ScalarNode || value.Value == "" && !quoted {
+		return p
+	}
+	p.ValueLine, p.ValueColumn, p.Quoted = value.Line, value.Column, quoted
+	if value.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) != 0 {
+		for i := value.Line; i < len(lines); i++ {
+			if trimmed := strings.TrimLeft(lines[i], " "); strings.TrimSpace(trimmed) != "" {
+				p.ValueLine, p.ValueColumn = i+1, len(lines[i])-len(trimmed)+1
+				break
+			}
+		}
+	}
+	return p
+}
+
+// RuleSyntaxError rule的语法错误，Offset为rule中出错的字节位置
+type RuleSyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *RuleSyntaxError) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Msg)
+}
+
+// ParseRule 解析rule，格式为 Name k:v,...->Func()->target 或 Func ->call->target。
+// Name为rules包中去掉Rule后缀的struct名，值中的:可以写作&#58;，target为-1或省略时不赋值
+func ParseRule(rule string) (*CodeRule, error) {
+	fail := func(offset int, format string, args ...interface{}) (*CodeRule, error) {
+		return nil, &RuleSyntaxError{Offset: offset, Msg: fmt.Sprintf(format, args...)}
+	}
+	start := len(rule) - len(strings.TrimLeft(rule, " \t"))
+	end := strings.IndexAny(rule[start:], " \t")
+	if strings.TrimSpace(rule) == "" {
+		return fail(0, "empty rule")
+	}
+	if end < 0 {
+		return fail(len(rule), "expected a space after the rule name %q", rule[start:])
+	}
+	r := &CodeRule{Name: rule[start : start+end], Back: "-1"}
+	if !identRegex.MatchString(r.Name) {
+		return fail(start, "invalid rule name %q", r.Name)
+	}
+	parts := splitTopLevel(rule, start+end, "->")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fail(start+end, "expected %s", ruleGrammar(r.Name))
+	}
+
+	args := parts[0]
+	if r.Name == "Func" {
+		if s := strings.TrimSpace(args.text); s != "" {
+			return fail(args.offset+strings.Index(args.text, s), "Func takes no arguments, expected %s", ruleGrammar(r.Name))
+		}
+	} else {
+		for _, arg := range splitTopLevel(args.text, 0, ",") {
+			s := strings.TrimSpace(arg.text)
+			offset := args.offset + arg.offset + strings.Index(arg.text, s)
+			if s == "" {
+				if len(strings.TrimSpace(args.text)) == 0 {
+					break
+				}
+				return fail(offset, "empty argument")
+			}
+			colon := strings.Index(s, ":")
+			if colon < 0 {
+				return fail(offset, "expected field:value, got %q", s)
+			}
+			name, value := strings.TrimSpace(s[:colon]), strings.TrimSpace(s[colon+1:])
+			if !identRegex.MatchString(name) {
+				return fail(offset, "invalid field name %q", name)
+			}
+			value = strings.Replace(value, "&#58;", ":", -1)
+			if err := checkExpr(value); err != "" {
+				return fail(offset+colon+1, "invalid value of %s: %s", name, err)
+			}
+			r.Args = append(r.Args, RuleArg{Name: name, Value: value})
+		}
+	}
+
+	call := parts[1]
+	r.Func = strings.TrimSpace(call.text)
+	if r.Func == "" {
+		return fail(call.offset, "missing the call, expected %s", ruleGrammar(r.Name))
+	}
+	expr, err := parser.ParseExpr(r.Func)
+	if err != nil {
+		return fail(call.offset+strings.Index(call.text, r.Func), "invalid call %q: %s", r.Func, exprError(err))
+	}
+	if _, ok := expr.(*ast.CallExpr); !ok {
+		return fail(call.offset+strings.Index(call.text, r.Func), "%q is not a call", r.Func)
+	}
+
+	if len(parts) == 3 {
+		target := parts[2]
+		if back := strings.TrimSpace(target.text); back != "" && back != "-1" {
+			if err := checkExpr(back); err != "" {
+				return fail(target.offset+strings.Index(target.text, back), "invalid target %q: %s", back, err)
+			}
+			r.Back = back
+		}
+	}
+	return r, nil
+}
+
+func ruleGrammar(name string) string {
+	if name == "Func" {
+		return "Func ->call()->target"
+	}
+	return name + " field:value,...->Method()->target"
+}
+
+func checkExpr(s string) string {
+	if s == "" {
+		return "empty expression"
+	}
+	if _, err := parser.ParseExpr(s); err != nil {
+		return exprError(err)
+	}
+	return ""
+}
+
+// exprError 去掉parser错误中的位置前缀
+func exprError(err error) string {
+	msg := err.Error()
+	if i := strings.Index(msg, ": "); i >= 0 && strings.HasPrefix(msg, "1:") {
+		return msg[i+2:]
+	}
+	return msg
+}
+
+type textPart struct {
+	offset int
+	text   string
+}
+
+// splitTopLevel 从s[from:]开始按sep分割，括号内及字符串中的sep不分割，offset为部分在s中的位置
+func splitTopLevel(s string, from int, sep string) (parts []textPart) {
+	depth, start := 0, from
+	var quote byte
+	for i := from; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, textPart{start, s[start:i]})
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	return append(parts, textPart{start, s[start:]})
+}
+
+// Code 在pos点之后插入的代码
+func (r *CodeRule) Code() string {
+	if r.Name == "Func" {
+		if r.Back != "-1" {
+			return r.Back + " = " + r.Func + "\n"
+		}
+		return r.Func + "\n"
+	}
+	ruleNameLower := strings.ToLower(r.Name)
+	fields := ""
+	for _, arg := range r.Args {
+		fields = fields + arg.Name + ": " + arg.Value + "," + "\n"
+	}
+	code := ruleNameLower + ` := &rules.` + r.Name + `Rule{` + "\n" + fields + `}` + "\n"
+	if r.Back != "-1" {
+		return code + r.Back + ` = ` + ruleNameLower + `.` + r.Func + "\n"
+	}
+	return code + ruleNameLower + `.` + r.Func + "\n"
+}
+
+// ruleController rule要修改的controller文件，src为现有的内容或将由route生成的内容
+type ruleController struct {
+	path    string
+	src     string
+	pending bool
+}
+
+// ruleControllers 按route.controller、route.rulecontroller及controllers目录找到每个controller的文件
+func (rf *RuleFile) ruleControllers(currpath string) map[string]*ruleController {
+	ctrDir := filepath.Join(currpath, "controllers")
+	files := make(map[string]*ruleController)
+	byType := make(map[string]string)
+	goFiles, _ := filepath.Glob(filepath.Join(ctrDir, "*.go"))
+	sort.Strings(goFiles)
+	for _, f := range goFiles {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		af, err := parser.ParseFile(token.NewFileSet(), f, data, 0)
+		if err != nil {
+			continue
+		}
+		for _, decl := range af.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+				for _, spec := range gd.Specs {
+					name := strings.ToLower(spec.(*ast.TypeSpec).Name.Name)
+					if _, ok := byType[name]; !ok && strings.HasSuffix(name, "controller") {
+						byType[name] = f
+					}
+				}
+			}
+		}
+	}
+
+	for _, ctr := range rf.Rules {
+		lower := strings.ToLower(ctr.Name)
+		fpath := filepath.Join(ctrDir, lower+".go")
+		if !utils.IsExist(fpath) {
+			if f, ok := byType[strings.ToLower(strings.Replace(ctr.Name, "_", "", -1))+"controller"]; ok {
+				fpath = f
+			}
+		}
+		if data, err := ioutil.ReadFile(fpath); err == nil {
+			files[ctr.Name] = &ruleController{path: fpath, src: string(data)}
+			continue
+		}
+		// 尚未生成的controller按FixRouteAndAddCtls的模板检查
+		for _, name := range rf.Controllers {
+			if strings.ToLower(name) == lower {
+				files[ctr.Name] = &ruleController{path: fpath, src: routeCtrlSource(strings.Title(name)), pending: true}
+			}
+		}
+		for _, name := range rf.RuleControllers {
+			// 文件为rule_name.go，controller为RuleNameController
+			if route := "rule_" + strings.ToLower(name); route == lower || "rule"+strings.ToLower(name) == lower {
+				files[ctr.Name] = &ruleController{path: filepath.Join(ctrDir, route+".go"), src: routeCtrlSource("Rule" + strings.Title(name)), pending: true}
+			}
+		}
+	}
+	return files
+}
+
+func routeCtrlSource(ctrlName string) string {
+	src := CtrlTPLForRoute
+	if Beego == "v2" {
+		src = BeegoV2Source(src)
+	}
+	return strings.Replace(src, "{{ctrlName}}", ctrlName, -1)
+}
+
+// Check 检查每条rule的controller、方法、pos点及import，不修改任何文件
+func (rf *RuleFile) Check(currpath string) (errs []error) {
+	module := projectModule(currpath)
+	files := rf.ruleControllers(currpath)
+	rel := func(p string) string {
+		if r, err := filepath.Rel(currpath, p); err == nil {
+			return r
+		}
+		return p
+	}
+	errorAt := func(api *CodeApi, key string, offset int, format string, args ...interface{}) {
+		p := api.at[key]
+		if p.Quoted {
+			offset++
+		}
+		errs = append(errs, &RuleError{File: rf.Path, Line: p.ValueLine, Column: p.ValueColumn + offset, Msg: fmt.Sprintf(format, args...)})
+	}
+	for _, ctr := range rf.Rules {
+		cf, ok := files[ctr.Name]
+		if !ok {
+			errs = append(errs, &RuleError{File: rf.Path, Line: ctr.at.Line, Column: ctr.at.Column,
+				Msg: fmt.Sprintf("controller %s not found in controllers, nor in route.controller or route.rulecontroller", ctr.Name)})
+			continue
+		}
+		fset := token.NewFileSet()
+		af, err := parser.ParseFile(fset, cf.path, cf.src, parser.ParseComments)
+		if err != nil {
+			errs = append(errs, &RuleError{File: rel(cf.path), Msg: err.Error()})
+			continue
+		}
+		for _, api := range ctr.Apis {
+			fn := findMethod(af, ctr.Name, api.Name)
+			if fn == nil {
+				errorAt(api, "", 0, "%s has no method %s", rel(cf.path), api.Name)
+				continue
+			}
+			for _, r := range api.Rules {
+				if _, ok := anchorEdit(fset, af, fn, r.Pos, ""); ok {
+					continue
+				}
+				errs = append(errs, &RuleError{File: rf.Path, Line: r.at.ValueLine, Column: r.at.ValueColumn,
+					Msg: anchorError(fset, af, r.Pos, api.Name, rel(cf.path))})
+			}
+			if needsClaims(api) && !strings.Contains(cf.src, "BaseController") {
+				p := api.at[""]
+				errs = append(errs, &RuleError{File: rf.Path, Line: p.Line, Column: p.Column,
+					Msg: fmt.Sprintf("authorize needs ParseClaims of BaseController, which %s does not embed", rel(cf.path))})
+			}
+			for _, imp := range api.ImportList() {
+				if msg := checkImport(imp, currpath, module); msg != "" {
+					errorAt(api, "imports", strings.Index(api.Imports, imp), "%s", msg)
+				}
+			}
+		}
+	}
+	return
+}
+
+// anchorError 找不到pos点的原因
+func anchorError(fset *token.FileSet, af *ast.File, anchor, method, file string) string {
+	switch anchor {
+	case "before_save", "after_commit":
+		return fmt.Sprintf("pos point %s not found in %s of %s, which has no models.%s call in an if ...; err == nil", anchor, method, file, strings.Join(modelsWrites, "/"))
+	case "after_load":
+		return fmt.Sprintf("pos point %s not found in %s of %s, which has no models.Get call", anchor, method, file)
+	}
+	if c := findComment(af, anchor, af.Pos(), af.End()); c != nil {
+		return fmt.Sprintf("pos point %s is not in %s of %s, it is at line %d", anchor, method, file, fset.Position(c.Pos()).Line)
+	}
+	return fmt.Sprintf("pos point %s not found in %s of %s, nor is it one of %s", anchor, method, file, strings.Join(RuleAnchors, ", "))
+}
+
+func needsClaims(api *CodeApi) bool {
+	for _, r := range api.Rules {
+		if r.Kind == "authorize" {
+			return true
+		}
+	}
+	return false
+}
+
+// findMethod 找到controller的方法，controller及方法名不区分大小写
+func findMethod(af *ast.File, ctrName, method string) *ast.FuncDecl {
+	recvName := strings.Replace(ctrName, "_", "", -1) + "Controller"
+	for _, decl := range af.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 || fn.Body == nil || !strings.EqualFold(fn.Name.Name, method) {
+			continue
+		}
+		typ := fn.Recv.List[0].Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		if ident, ok := typ.(*ast.Ident); ok && strings.EqualFold(ident.Name, recvName) {
+			return fn
+		}
+	}
+	return nil
+}
+
+// projectModule 项目的go.mod中的module，没有go.mod时为项目目录名
+func projectModule(currpath string) string {
+	if data, err := ioutil.ReadFile(filepath.Join(currpath, "go.mod")); err == nil {
+		if m := moduleRegex.FindSubmatch(data); m != nil {
+			return string(m[1])
+		}
+	}
+	return filepath.Base(currpath)
+}
+
+// checkImport 项目内的包须存在目录，标准库的包须存在，其他第三方包不检查
+func checkImport(imp, currpath, module string) string {
+	if imp == module || strings.HasPrefix(imp, module+"/") {
+		dir := filepath.Join(currpath, filepath.FromSlash(strings.TrimPrefix(imp, module)))
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			return fmt.Sprintf("import %q: no directory %s in the project", imp, dir)
+		}
+		return ""
+	}
+	if first := strings.Split(imp, "/")[0]; !strings.Contains(first, ".") {
+		if _, err := build.Import(imp, currpath, build.FindOnly); err != nil {
+			return fmt.Sprintf("import %q is neither in the project %s nor in the standard library", imp, module)
+		}
+	}
+	return ""
+}
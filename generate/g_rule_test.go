@@ -0,0 +1,279 @@
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule   string
+		want   *CodeRule
+		offset int
+		err    string
+	}{
+		{rule: "Length min:1,max:10->Check(v.Name)->err",
+			want: &CodeRule{Name: "Length", Args: []RuleArg{{"min", "1"}, {"max", "10"}}, Func: "Check(v.Name)", Back: "err"}},
+		{rule: `Match pattern:"a&#58;b",msg:f(1, 2)->Check(v)`,
+			want: &CodeRule{Name: "Match", Args: []RuleArg{{"pattern", `"a:b"`}, {"msg", "f(1, 2)"}}, Func: "Check(v)", Back: "-1"}},
+		{rule: "Func ->log.Println(v)->-1",
+			want: &CodeRule{Name: "Func", Func: "log.Println(v)", Back: "-1"}},
+		{rule: "  ", offset: 0, err: "empty rule"},
+		{rule: "Length", offset: 6, err: "expected a space after the rule name"},
+		{rule: "9x a:1->F()", offset: 0, err: `invalid rule name "9x"`},
+		{rule: "Length min:1", offset: 6, err: "expected Length field:value,...->Method()->target"},
+		{rule: "Length min:1,,max:2->F()", offset: 13, err: "empty argument"},
+		{rule: "Length min->F()", offset: 7, err: `expected field:value, got "min"`},
+		{rule: "Length min:(1->F()", offset: 6, err: "expected Length"},
+		{rule: "Length min:1+->F()", offset: 11, err: "invalid value of min"},
+		{rule: "Func a:1->F()", offset: 5, err: "Func takes no arguments"},
+		{rule: "Length min:1-> ->x", offset: 14, err: "missing the call"},
+		{rule: "Length min:1->F->x", offset: 14, err: `"F" is not a call`},
+		{rule: "Length min:1->F()->a b", offset: 19, err: `invalid target "a b"`},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.rule)
+		if tt.err == "" {
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRule(%q) = %+v, %v, want %+v", tt.rule, got, err, tt.want)
+			}
+			continue
+		}
+		se, ok := err.(*RuleSyntaxError)
+		if !ok || se.Offset != tt.offset || !strings.Contains(se.Msg, tt.err) {
+			t.Errorf("ParseRule(%q) error = %v, want offset %d: %s", tt.rule, err, tt.offset, tt.err)
+		}
+	}
+}
+
+// writeRuleFile 在dir中写入rule.yml
+func writeRuleFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	fpath := filepath.Join(dir, "rule.yml")
+	if err := ioutil.WriteFile(fpath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fpath
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "bee-rule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// errorStrings 错误信息，去掉文件名
+func errorStrings(errs []error, fpath string) []string {
+	var rv []string
+	for _, err := range errs {
+		rv = append(rv, strings.TrimPrefix(err.Error(), fpath+":"))
+	}
+	return rv
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := tempDir(t)
+	tests := []struct {
+		name string
+		yml  string
+		want []string
+	}{
+		{"valid", `route:
+  controller: user, team
+controller:
+  user:
+    Post:
+      pos: pos11
+      rule: "Length min:1->Check(v.Name)->err"
+      imports: fmt
+    Put:
+      rules:
+        - validate: {check: "v.Name != \"\""}
+`, nil},
+		{"unknown keys", `routes: {}
+controller:
+  user:
+    Post:
+      pos: pos11
+      rule: Length min:1->Check(v)
+      import: fmt
+`, []string{
+			`1:1: unknown key "routes", must be route or controller`,
+			`7:7: unknown key "import", must be pos, rule, rules or imports`,
+		}},
+		// 错误的位置为rule.yml中的行号和列号，引号中的值从引号后开始
+		{"bad values", `route:
+  controller: user, 9team
+controller:
+  user:
+    Post:
+      pos: "pos 11"
+      rule: "Length min->Check(v)"
+      imports: fmt, "a b"
+`, []string{
+			`2:21: invalid controller name "9team"`,
+			`6:13: invalid pos "pos 11", must be the name of a pos point such as pos11, or begin or end`,
+			`7:21: rule: expected field:value, got "min"`,
+			`8:21: invalid import path "\"a b\""`,
+		}},
+		{"block rule", `controller:
+  user:
+    Post:
+      pos: pos11
+      rule: |
+        Length min->Check(v)
+`, []string{
+			`6:16: rule: expected field:value, got "min"`,
+		}},
+		{"missing rule", `controller:
+  user:
+    Post:
+      pos: pos11
+    Put:
+      pos: pos11
+      rules:
+        - validate: {check: ok}
+  user:
+    Get: {}
+`, []string{
+			`3:5: Post has neither rule nor rules`,
+			`6:7: pos is only for rule, set pos in each item of rules instead`,
+			`9:3: duplicate controller user`,
+		}},
+		{"rules item", `controller:
+  user:
+    Post:
+      rules:
+        - pos: begin
+        - unknown: {}
+          order: x
+`, []string{
+			`5:11: rule has none of ` + ruleKindNames(),
+			`6:11: unknown key "unknown", must be pos, order or one of ` + ruleKindNames(),
+			`7:18: order must be an integer`,
+		}},
+	}
+	for _, tt := range tests {
+		fpath := writeRuleFile(t, dir, tt.yml)
+		_, errs := LoadRules(fpath)
+		if got := errorStrings(errs, fpath); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: LoadRules() errors:\n%s\nwant:\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+
+	// 缩进错误由YAML解析报告，带有行号
+	fpath := writeRuleFile(t, dir, "controller:\n  user:\n    Post:\n      pos: pos11\n     rule: x\n")
+	if _, errs := LoadRules(fpath); len(errs) != 1 || errs[0].Error() != fpath+": line 4: did not find expected key" {
+		t.Errorf("LoadRules() of a wrong indentation: %v", errs)
+	}
+}
+
+const ruleTestController = `package controllers
+
+import "fmt"
+
+type UserController struct {
+}
+
+// Post ...
+func (c *UserController) Post() {
+	// pos11
+	fmt.Println()
+}
+
+func (c *UserController) Put() {
+	// pos12
+}
+`
+
+func TestRuleFileCheck(t *testing.T) {
+	dir := tempDir(t)
+	if err := os.MkdirAll(filepath.Join(dir, "controllers"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "utils"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "controllers", "user.go"), []byte(ruleTestController), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		yml  string
+		want []string
+	}{
+		{"valid", `route:
+  controller: team
+controller:
+  user:
+    post:
+      pos: pos11
+      rule: Length min:1->Check(v)
+      imports: fmt, app/utils, github.com/x/y
+  team:
+    Post:
+      pos: pos11
+      rule: Func ->f()
+`, nil},
+		{"unknown controller", `controller:
+  order:
+    Post:
+      pos: pos11
+      rule: Func ->f()
+`, []string{
+			"2:3: controller order not found in controllers, nor in route.controller or route.rulecontroller",
+		}},
+		{"unknown method", `controller:
+  user:
+    Delete:
+      pos: pos11
+      rule: Func ->f()
+`, []string{
+			"3:5: controllers/user.go has no method Delete",
+		}},
+		{"unknown pos", `controller:
+  user:
+    Post:
+      pos: pos12
+      rule: Func ->f()
+    Put:
+      pos: pos99
+      rule: Func ->f()
+`, []string{
+			"4:12: pos point pos12 is not in Post of controllers/user.go, it is at line 15",
+			"7:12: pos point pos99 not found in Put of controllers/user.go, nor is it one of " + strings.Join(RuleAnchors, ", "),
+		}},
+		{"bad import", `controller:
+  user:
+    Post:
+      pos: pos11
+      rule: Func ->f()
+      imports: "fmt, app/missing, nosuchstd"
+`, []string{
+			`6:22: import "app/missing": no directory ` + filepath.Join(dir, "missing") + " in the project",
+			`6:35: import "nosuchstd" is neither in the project app nor in the standard library`,
+		}},
+	}
+	for _, tt := range tests {
+		fpath := writeRuleFile(t, dir, tt.yml)
+		rf, errs := LoadRules(fpath)
+		if len(errs) > 0 {
+			t.Errorf("%s: LoadRules() errors: %v", tt.name, errs)
+			continue
+		}
+		if got := errorStrings(rf.Check(dir), fpath); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Check() errors:\n%s\nwant:\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RuleKinds rules中可用的规则类型，及其默认的pos点
+var RuleKinds = [][2]string{
+	{"validate", "before_save"},
+	{"transform", "before_save"},
+	{"authorize", "begin"},
+	{"after_commit", "after_commit"},
+}
+
+// RuleAnchors 按方法的语法树确定位置的pos点，pos点也可以是方法中// 名字的注释
+var RuleAnchors = []string{"begin", "end", "before_save", "after_commit", "after_load"}
+
+// ApiRule 在api的一个pos点插入的规则，pos点相同时按Order及rule.yml中的顺序插入
+type ApiRule struct {
+	Kind  string
+	Pos   string
+	Order int
+	Code  string
+
+	at yamlPos
+}
+
+// sortedRules 按pos点分组并排序的规则，pos点按第一次出现的顺序
+func (api *CodeApi) sortedRules() (anchors []string, rules map[string][]*ApiRule) {
+	rules = make(map[string][]*ApiRule)
+	for _, r := range api.Rules {
+		if _, ok := rules[r.Pos]; !ok {
+			anchors = append(anchors, r.Pos)
+		}
+		rules[r.Pos] = append(rules[r.Pos], r)
+	}
+	for _, rs := range rules {
+		sort.SliceStable(rs, func(i, j int) bool { return rs[i].Order < rs[j].Order })
+	}
+	return
+}
+
+// loadRules 读取api的rules列表，每一项为一个规则类型及其参数，可另有pos、order
+func (rl *ruleLoader) loadRules(path string, v interface{}) (rules []*ApiRule, ok bool) {
+	list, isList := v.([]interface{})
+	if !isList {
+		rl.errorf(path, false, 0, "rules must be a list")
+		return nil, false
+	}
+	ok = true
+	for i, item := range list {
+		itemPath := path + "." + strconv.Itoa(i)
+		fields, isMap := item.(yaml.MapSlice)
+		if !isMap {
+			rl.errorf(itemPath, false, 0, "rule must be a mapping with one of %s", ruleKindNames())
+			ok = false
+			continue
+		}
+		r := &ApiRule{at: rl.pos[itemPath]}
+		var args interface{}
+		unknown := false
+		for _, f := range fields {
+			key := fmt.Sprint(f.Key)
+			fieldPath := itemPath + "." + key
+			switch key {
+			case "pos":
+				value, _ := rl.scalar(fieldPath, f.Value)
+				if r.Pos = strings.TrimSpace(value); !identRegex.MatchString(r.Pos) {
+					rl.errorf(fieldPath, true, 0, "invalid pos %q, must be one of %s or the name of a pos point", r.Pos, strings.Join(RuleAnchors, ", "))
+					ok = false
+				}
+			case "order":
+				order, isInt := f.Value.(int)
+				if !isInt {
+					rl.errorf(fieldPath, true, 0, "order must be an integer")
+					ok = false
+				}
+				r.Order = order
+			default:
+				if defaultPos := ruleKindPos(key); defaultPos == "" {
+					rl.errorf(fieldPath, false, 0, "unknown key %q, must be pos, order or one of %s", key, ruleKindNames())
+					ok, unknown = false, true
+				} else if r.Kind != "" {
+					rl.errorf(fieldPath, false, 0, "rule has both %s and %s, add another item to the list", r.Kind, key)
+					ok = false
+				} else {
+					r.Kind, args = key, f.Value
+				}
+			}
+		}
+		if r.Kind == "" {
+			if !unknown {
+				rl.errorf(itemPath, false, 0, "rule has none of %s", ruleKindNames())
+				ok = false
+			}
+			continue
+		}
+		if r.Pos == "" {
+			r.Pos = ruleKindPos(r.Kind)
+		}
+		kindPath := itemPath + "." + r.Kind
+		argMap, isMap := args.(yaml.MapSlice)
+		if !isMap {
+			rl.errorf(kindPath, true, 0, "%s must be a mapping of its arguments", r.Kind)
+			ok = false
+			continue
+		}
+		a := &ruleArgs{rl: rl, path: kindPath, args: make(map[string]interface{})}
+		for _, arg := range argMap {
+			a.names = append(a.names, fmt.Sprint(arg.Key))
+			a.args[fmt.Sprint(arg.Key)] = arg.Value
+		}
+		switch r.Kind {
+		case "validate":
+			r.Code = a.validateCode()
+		case "transform":
+			r.Code = a.transformCode()
+		case "authorize":
+			r.Code = a.authorizeCode()
+		case "after_commit":
+			r.Code = a.afterCommitCode()
+		}
+		if !a.ok() {
+			ok = false
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return
+}
+
+func ruleKindPos(kind string) string {
+	for _, k := range RuleKinds {
+		if k[0] == kind {
+			return k[1]
+		}
+	}
+	return ""
+}
+
+func ruleKindNames() string {
+	var names []string
+	for _, k := range RuleKinds {
+		names = append(names, k[0])
+	}
+	return strings.Join(names, ", ")
+}
+
+// ruleArgs 一个规则的参数，读取时检查参数的类型及未知的参数
+type ruleArgs struct {
+	rl    *ruleLoader
+	path  string
+	names []string
+	args  map[string]interface{}
+	used  map[string]bool
+	bad   bool
+}
+
+func (a *ruleArgs) errorf(name string, format string, args ...interface{}) {
+	a.rl.errorf(a.path+"."+name, true, 0, format, args...)
+	a.bad = true
+}
+
+func (a *ruleArgs) use(name string) (interface{}, bool) {
+	if a.used == nil {
+		a.used = make(map[string]bool)
+	}
+	a.used[name] = true
+	v, ok := a.args[name]
+	return v, ok
+}
+
+// ok 检查未知的参数，返回参数是否都正确
+func (a *ruleArgs) ok() bool {
+	for _, name := range a.names {
+		if !a.used[name] {
+			var known []string
+			for k := range a.used {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			a.rl.errorf(a.path+"."+name, false, 0, "unknown argument %q, must be one of %s", name, strings.Join(known, ", "))
+			a.bad = true
+		}
+	}
+	return !a.bad
+}
+
+// expr Go表达式参数
+func (a *ruleArgs) expr(name string) string {
+	v, ok := a.use(name)
+	if !ok {
+		return ""
+	}
+	s, isStr := v.(string)
+	if !isStr {
+		s = fmt.Sprint(v)
+	}
+	if msg := checkExpr(strings.TrimSpace(s)); msg != "" {
+		a.errorf(name, "invalid %s %q: %s", name, s, msg)
+	}
+	return strings.TrimSpace(s)
+}
+
+// call 函数调用参数
+func (a *ruleArgs) call(name string) string {
+	s := a.expr(name)
+	if expr, err := parser.ParseExpr(s); err == nil {
+		if _, isCall := expr.(*ast.CallExpr); !isCall {
+			a.errorf(name, "%s %q is not a call", name, s)
+		}
+	}
+	return s
+}
+
+func (a *ruleArgs) str(name, def string) string {
+	v, ok := a.use(name)
+	if !ok || v == nil {
+		return def
+	}
+	switch v.(type) {
+	case yaml.MapSlice, []interface{}:
+		a.errorf(name, "%s must be a string", name)
+		return def
+	}
+	return fmt.Sprint(v)
+}
+
+// status 4xx的状态码参数
+func (a *ruleArgs) status(def int) int {
+	v, ok := a.use("status")
+	if !ok {
+		return def
+	}
+	if code, isInt := v.(int); isInt && code >= 400 && code < 500 {
+		return code
+	}
+	a.errorf("status", "status must be a 4xx status code, got %v", v)
+	return def
+}
+
+// reject 不满足条件时返回状态码及信息
+func reject(cond string, status int, msg string) string {
+	return "if " + cond + " {\n" +
+		"c.Ctx.Output.SetStatus(" + strconv.Itoa(status) + ")\n" +
+		"c.Data[\"json\"] = " + msg + "\n" +
+		"c.ServeJSON()\n" +
+		"return\n" +
+		"}\n"
+}
+
+// validateCode check为须成立的条件，或call为返回error的调用，不满足时返回4xx及message
+func (a *ruleArgs) validateCode() string {
+	check, call := a.expr("check"), a.call("call")
+	status := a.status(400)
+	msg := a.str("message", "")
+	switch {
+	case check != "" && call != "":
+		a.errorf("call", "validate takes either check or call, not both")
+	case check != "":
+		if msg == "" {
+			msg = "参数不合法！"
+		}
+		return reject("!("+check+")", status, strconv.Quote(msg))
+	case call != "":
+		if msg == "" {
+			return reject("err := "+call+"; err != nil", status, "err.Error()")
+		}
+		return reject("err := "+call+"; err != nil", status, strconv.Quote(msg))
+	default:
+		a.rl.errorf(a.path, false, 0, "validate needs check or call")
+		a.bad = true
+	}
+	return ""
+}
+
+// transformCode set为字段到表达式的映射，按顺序赋值给v的字段；call为修改v的调用
+func (a *ruleArgs) transformCode() string {
+	code := ""
+	if v, ok := a.use("set"); ok {
+		fields, isMap := v.(yaml.MapSlice)
+		if !isMap {
+			a.errorf("set", "set must be a mapping from the fields of v to expressions")
+		}
+		for _, f := range fields {
+			name, value := fmt.Sprint(f.Key), strings.TrimSpace(fmt.Sprint(f.Value))
+			if !identRegex.MatchString(name) {
+				a.errorf("set", "invalid field %q", name)
+			} else if msg := checkExpr(value); msg != "" {
+				a.errorf("set", "invalid value of %s %q: %s", name, value, msg)
+			}
+			code += "v." + name + " = " + value + "\n"
+		}
+	}
+	if call := a.call("call"); call != "" {
+		code += call + "\n"
+	}
+	if code == "" && !a.bad {
+		a.rl.errorf(a.path, false, 0, "transform needs set or call")
+		a.bad = true
+	}
+	return code
+}
+
+// authorizeCode claim的值须在in中，check为可使用claims的条件，不满足时返回403及message
+func (a *ruleArgs) authorizeCode() string {
+	var conds []string
+	claim := a.str("claim", "")
+	if in, ok := a.use("in"); ok {
+		values, isList := in.([]interface{})
+		if !isList {
+			values = []interface{}{in}
+		}
+		var or []string
+		for _, v := range values {
+			// claims由JSON解析，数字为float64
+			switch v := v.(type) {
+			case string:
+				or = append(or, "claims["+strconv.Quote(claim)+"] == "+strconv.Quote(v))
+			case int, float64:
+				or = append(or, "claims["+strconv.Quote(claim)+"] == float64("+fmt.Sprint(v)+")")
+			case bool:
+				or = append(or, "claims["+strconv.Quote(claim)+"] == "+strconv.FormatBool(v))
+			default:
+				a.errorf("in", "in must be a list of strings, numbers or booleans")
+			}
+		}
+		if claim == "" {
+			a.errorf("in", "in needs claim")
+		}
+		if len(or) > 0 {
+			conds = append(conds, "("+strings.Join(or, " || ")+")")
+		}
+	} else if claim != "" {
+		conds = append(conds, "claims["+strconv.Quote(claim)+"] != nil")
+	}
+	if check := a.expr("check"); check != "" {
+		conds = append(conds, "("+check+")")
+	}
+	status := a.status(403)
+	msg := a.str("message", "没有权限！")
+	if len(conds) == 0 {
+		if !a.bad {
+			a.rl.errorf(a.path, false, 0, "authorize needs claim or check")
+			a.bad = true
+		}
+		return ""
+	}
+	return reject("claims := c.ParseClaims(); claims == nil || !("+strings.Join(conds, " && ")+")", status, strconv.Quote(msg))
+}
+
+// afterCommitCode call为保存成功后执行的调用
+func (a *ruleArgs) afterCommitCode() string {
+	call := a.call("call")
+	if call == "" && !a.bad {
+		a.rl.errorf(a.path, false, 0, "after_commit needs call")
+		a.bad = true
+	}
+	return call + "\n"
+}
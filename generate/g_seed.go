@@ -0,0 +1,457 @@
+package generate
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	beeLogger "bee/logger"
+	"bee/migrate"
+	"bee/utils"
+)
+
+// seedBatch 每条INSERT语句的行数
+const seedBatch = 100
+
+// seedRetries 唯一键冲突时重新生成一行的次数，仍冲突则跳过该行
+const seedRetries = 20
+
+// seedEpoch 时间列的取值在此之前的两年内，不用当前时间，使-seed相同时数据相同
+var seedEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var seedWords = strings.Fields(`lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor
+	incididunt ut labore et dolore magna aliqua enim ad minim veniam quis nostrud exercitation ullamco laboris
+	nisi aliquip ex ea commodo consequat duis aute irure in reprehenderit voluptate velit esse cillum fugiat
+	nulla pariatur excepteur sint occaecat cupidatat non proident sunt culpa qui officia deserunt mollit anim`)
+
+// seedTable 待填充的表，parents为关系列 => 关联的表
+type seedTable struct {
+	*Table
+	cols    []*SQLColumn
+	parents map[string]string
+	uniques [][]string
+}
+
+type seeder struct {
+	db   *sql.DB
+	rnd  *rand.Rand
+	pks  map[string]string  // 表名 => 主键
+	ids  map[string][]int64 // 表中已有的主键，关系列从中取值
+	seen map[string]bool    // 唯一键的值
+}
+
+// Seed 按列的类型、长度、小数位、enum/set的可选值及是否可为空，为每个表插入n行假数据。
+// 表按一对多、一对一关系排序，关系列取关联表中已有的Id，多对多的中间表也会填充。
+// seed相同且数据库的数据相同时，生成的数据相同；only不为空时只填充其中的表。
+// 表结构从MySQL的information_schema读取，其他数据库不支持
+func Seed(driver, connStr string, n int, seed int64, only []string) {
+	if err := seedDriver(driver); err != nil {
+		beeLogger.Log.Fatalf("%s", err)
+	}
+	tables := readTables(connStr)
+	db, err := sql.Open("mysql", connStr)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to the database: %s", err)
+	}
+	defer db.Close()
+
+	s := &seeder{db: db, rnd: rand.New(rand.NewSource(seed)), pks: make(map[string]string),
+		ids: make(map[string][]int64), seen: make(map[string]bool)}
+	for _, tb := range tables {
+		s.pks[tb.Name] = tb.Pk
+	}
+	for _, st := range seedOrder(tables) {
+		if st.Name == migrate.Table || len(only) > 0 && !inStrings(only, st.Name) {
+			continue
+		}
+		inserted, skipped, err := s.seedTable(st, n)
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not seed %s: %s", st.Name, err)
+		}
+		if skipped > 0 {
+			beeLogger.Log.Warnf("Skipped %d rows of %s, no unique values left", skipped, st.Name)
+		}
+		beeLogger.Log.Infof("Seeded %d rows into %s", inserted, st.Name)
+	}
+}
+
+// seedDriver 检查假数据是否支持driver，表结构的读取及SQL中的反引号只适用于MySQL
+func seedDriver(driver string) error {
+	if driver != "mysql" {
+		return fmt.Errorf("fake data is only supported on MySQL, not '%s'. Use 'bee seed load' with fixtures on other databases", driver)
+	}
+	return nil
+}
+
+// seedOrder 按关系拓扑排序，被关联的表在前；有环时剩余的表按表名排在最后
+func seedOrder(tables []*Table) (order []*seedTable) {
+	tableOf := make(map[string]*Table)
+	for _, tb := range tables {
+		tableOf[utils.CamelCase(tb.Name)] = tb
+	}
+	pending := make(map[string]*seedTable)
+	var names []string
+	for _, tb := range tables {
+		st := &seedTable{Table: tb, cols: tb.SQLColumns(), parents: make(map[string]string)}
+		for _, rel := range tb.ormRelations(tables) {
+			if ref := tableOf[rel.Model]; ref != nil && (rel.Kind == "fk" || rel.Kind == "one") {
+				st.parents[rel.Column] = ref.Name
+				// 一对一的关联列不重复
+				if rel.Kind == "one" {
+					st.uniques = append(st.uniques, []string{rel.Column})
+				}
+			}
+		}
+		for _, uk := range tb.UniqueKeys {
+			st.uniques = append(st.uniques, uk.Columns)
+		}
+		// 中间表的关联对不重复
+		if strings.Contains(tb.Name, "_has_") && len(st.parents) > 1 {
+			var key []string
+			for col := range st.parents {
+				key = append(key, col)
+			}
+			sort.Strings(key)
+			st.uniques = append(st.uniques, key)
+		}
+		pending[tb.Name] = st
+		names = append(names, tb.Name)
+	}
+	sort.Strings(names)
+	for len(pending) > 0 {
+		progress := false
+		for _, name := range names {
+			st, ok := pending[name]
+			if !ok {
+				continue
+			}
+			ready := true
+			for _, parent := range st.parents {
+				if _, waiting := pending[parent]; waiting && parent != name {
+					ready = false
+				}
+			}
+			if ready {
+				order = append(order, st)
+				delete(pending, name)
+				progress = true
+			}
+		}
+		if !progress {
+			for _, name := range names {
+				if st, ok := pending[name]; ok {
+					beeLogger.Log.Warnf("%s is in a relation cycle, its relation columns may be null", name)
+					order = append(order, st)
+					delete(pending, name)
+				}
+			}
+		}
+	}
+	return
+}
+
+// seedTable 插入n行，返回插入及因唯一键冲突跳过的行数
+func (s *seeder) seedTable(st *seedTable, n int) (inserted int, skipped int, err error) {
+	for _, parent := range st.parents {
+		if _, ok := s.ids[parent]; !ok {
+			if s.ids[parent], err = s.tableIds(parent); err != nil {
+				return
+			}
+		}
+	}
+	var offset int
+	if err = s.db.QueryRow("SELECT COUNT(*) FROM `" + st.Name + "`").Scan(&offset); err != nil {
+		return
+	}
+	var cols []*SQLColumn
+	for _, col := range st.cols {
+		if !col.Auto {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		return 0, 0, nil
+	}
+	if err = s.loadUniques(st); err != nil {
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return
+	}
+	var rows [][]interface{}
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		if _, err := tx.Exec(seedInsertSQL(st.Name, cols, len(rows)), flatten(rows)...); err != nil {
+			return err
+		}
+		inserted += len(rows)
+		rows = rows[:0]
+		return nil
+	}
+	for i := 0; i < n; i++ {
+		row := s.uniqueRow(st, cols, offset+i+1)
+		if row == nil {
+			skipped++
+			continue
+		}
+		if rows = append(rows, row); len(rows) == seedBatch {
+			if err = flush(); err != nil {
+				tx.Rollback()
+				return
+			}
+		}
+	}
+	if err = flush(); err != nil {
+		tx.Rollback()
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	if st.Pk != "" {
+		s.ids[st.Name], err = s.tableIds(st.Name)
+	}
+	return
+}
+
+// uniqueRow 生成一行，唯一键冲突时重新生成
+func (s *seeder) uniqueRow(st *seedTable, cols []*SQLColumn, serial int) []interface{} {
+	for try := 0; try < seedRetries; try++ {
+		row := make([]interface{}, len(cols))
+		values := make(map[string]interface{})
+		for i, col := range cols {
+			row[i] = s.value(st, col, serial)
+			values[col.Column] = row[i]
+		}
+		if keys, ok := s.uniqueKeys(st, values); ok {
+			for _, key := range keys {
+				s.seen[key] = true
+			}
+			return row
+		}
+	}
+	return nil
+}
+
+// uniqueKeys 返回行的唯一键的值，有重复时ok为false；含NULL的唯一键不会重复
+func (s *seeder) uniqueKeys(st *seedTable, values map[string]interface{}) (keys []string, ok bool) {
+	for _, uk := range st.uniques {
+		key := st.Name + ":" + strings.Join(uk, ",")
+		for _, c := range uk {
+			if values[c] == nil {
+				key = ""
+				break
+			}
+			key += "\x00" + fmt.Sprint(values[c])
+		}
+		if key == "" {
+			continue
+		}
+		if s.seen[key] {
+			return nil, false
+		}
+		keys = append(keys, key)
+	}
+	return keys, true
+}
+
+// loadUniques 读取表中已有的唯一键的值
+func (s *seeder) loadUniques(st *seedTable) error {
+	for _, uk := range st.uniques {
+		rows, err := s.db.Query("SELECT `" + strings.Join(uk, "`, `") + "` FROM `" + st.Name + "`")
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			vals := make([]sql.NullString, len(uk))
+			ptrs := make([]interface{}, len(uk))
+			for i := range vals {
+				ptrs[i] = &vals[i]
+			}
+			if err = rows.Scan(ptrs...); err != nil {
+				rows.Close()
+				return err
+			}
+			values := make(map[string]interface{})
+			for i, c := range uk {
+				if vals[i].Valid {
+					values[c] = vals[i].String
+				}
+			}
+			keys, _ := s.uniqueKeys(&seedTable{Table: st.Table, uniques: [][]string{uk}}, values)
+			for _, key := range keys {
+				s.seen[key] = true
+			}
+		}
+		rows.Close()
+	}
+	return nil
+}
+
+func (s *seeder) tableIds(table string) (ids []int64, err error) {
+	pk := s.pks[table]
+	if pk == "" {
+		return nil, nil
+	}
+	rows, err := s.db.Query("SELECT `" + pk + "` FROM `" + table + "` ORDER BY `" + pk + "`")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// value 列的假数据，serial为行在表中的序号，用于唯一的字符串列
+func (s *seeder) value(st *seedTable, col *SQLColumn, serial int) interface{} {
+	tag := col.Tag
+	if parent, ok := st.parents[col.Column]; ok {
+		ids := s.ids[parent]
+		if len(ids) == 0 || tag.Null && s.rnd.Intn(10) == 0 {
+			// 关联表为空时只能为NULL，不可为空的列由数据库报错
+			return nil
+		}
+		return ids[s.rnd.Intn(len(ids))]
+	}
+	if col.Pk {
+		return serial
+	}
+	if tag.Null && s.rnd.Intn(10) == 0 {
+		return nil
+	}
+	switch {
+	case len(tag.Enum) > 0:
+		if !tag.Set {
+			return tag.Enum[s.rnd.Intn(len(tag.Enum))]
+		}
+		var values []string
+		for _, v := range tag.Enum {
+			if s.rnd.Intn(2) == 0 {
+				values = append(values, v)
+			}
+		}
+		return strings.Join(values, ",")
+	case col.Type == "time.Time":
+		t := seedEpoch.Add(-time.Duration(s.rnd.Int63n(int64(2 * 365 * 24 * time.Hour))))
+		switch tag.Type {
+		case "date":
+			return t.Format("2006-01-02")
+		case "time":
+			return t.Format("15:04:05")
+		}
+		return t.Format("2006-01-02 15:04:05")
+	case col.Type == "string":
+		return s.fakeString(col.Column, tag.Size, serial, s.isUnique(st, col.Column))
+	case strings.HasPrefix(col.Type, "float"):
+		if tag.Decimals != "" {
+			digits, _ := strconv.Atoi(tag.Digits)
+			decimals, _ := strconv.Atoi(tag.Decimals)
+			max := math.Min(math.Pow10(digits-decimals), 10000)
+			return strconv.FormatFloat(s.rnd.Float64()*max, 'f', decimals, 64)
+		}
+		return math.Round(s.rnd.Float64()*100000) / 100
+	case col.Type == "uint64" && tag.Size != "":
+		// bit(n)
+		return s.rnd.Intn(2)
+	case strings.Contains(strings.ToLower(col.Column), "year"):
+		return 1990 + s.rnd.Intn(40)
+	case strings.HasSuffix(col.Type, "int8"):
+		return s.rnd.Intn(101)
+	case strings.Contains(col.Type, "int"):
+		if s.isUnique(st, col.Column) {
+			return serial
+		}
+		return s.rnd.Intn(1001)
+	case col.Type == "bool":
+		return s.rnd.Intn(2)
+	}
+	return nil
+}
+
+func (s *seeder) isUnique(st *seedTable, column string) bool {
+	for _, uk := range st.uniques {
+		if inStrings(uk, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeString 按列名生成类似的数据，唯一的列带上序号，超过size时截断
+func (s *seeder) fakeString(column, size string, serial int, unique bool) string {
+	name := strings.ToLower(column)
+	words := func(min, max int) string {
+		n := min + s.rnd.Intn(max-min+1)
+		ws := make([]string, n)
+		for i := range ws {
+			ws[i] = seedWords[s.rnd.Intn(len(seedWords))]
+		}
+		return strings.Join(ws, " ")
+	}
+	var v, suffix string
+	switch {
+	case strings.Contains(name, "email"):
+		v, suffix = seedWords[s.rnd.Intn(len(seedWords))], fmt.Sprintf("%d@example.com", serial)
+	case strings.Contains(name, "url") || strings.Contains(name, "link") || strings.Contains(name, "website"):
+		v, suffix = "https://example.com/"+seedWords[s.rnd.Intn(len(seedWords))], fmt.Sprintf("/%d", serial)
+	case strings.Contains(name, "phone") || strings.Contains(name, "mobile") || strings.Contains(name, "tel"):
+		v = fmt.Sprintf("1%010d", s.rnd.Int63n(1e10))
+	case strings.HasSuffix(name, "ip"):
+		v = fmt.Sprintf("10.%d.%d.%d", s.rnd.Intn(256), s.rnd.Intn(256), 1+s.rnd.Intn(254))
+	case strings.Contains(name, "code") || strings.Contains(name, "sku") || strings.HasSuffix(name, "_no"):
+		v = strings.ToUpper(strconv.FormatInt(s.rnd.Int63n(1<<40), 36))
+	case strings.Contains(name, "name") || strings.Contains(name, "title"):
+		v = strings.Title(words(1, 3))
+	case size == "":
+		// text
+		v = words(8, 40) + "."
+	default:
+		v = words(2, 8)
+	}
+	if unique && suffix == "" {
+		suffix = fmt.Sprintf("-%d", serial)
+	}
+	// 截断时保留唯一的后缀
+	if max, err := strconv.Atoi(size); err == nil {
+		keep := max - len(suffix)
+		if keep < 0 {
+			keep, suffix = 0, suffix[len(suffix)-max:]
+		}
+		if r := []rune(v); len(r) > keep {
+			v = string(r[:keep])
+		}
+	}
+	return v + suffix
+}
+
+func seedInsertSQL(table string, cols []*SQLColumn, n int) string {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = "`" + col.Column + "`"
+	}
+	marks := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ") + ")"
+	return "INSERT INTO `" + table + "` (" + strings.Join(names, ", ") + ") VALUES " +
+		strings.TrimSuffix(strings.Repeat(marks+", ", n), ", ")
+}
+
+func flatten(rows [][]interface{}) (args []interface{}) {
+	for _, row := range rows {
+		args = append(args, row...)
+	}
+	return
+}
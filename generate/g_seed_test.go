@@ -0,0 +1,110 @@
+package generate
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// goldenTables 由goldenColumns读取的表
+func goldenTables(t *testing.T) []*Table {
+	t.Helper()
+	db, err := sql.Open("goldenmysql", "profile,role,team,user,user_has_role")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	trans := &MysqlDB{}
+	return getTableObjects(trans.GetTableNames(db), db, trans)
+}
+
+func seedOrderNames(order []*seedTable) (names []string) {
+	for _, st := range order {
+		names = append(names, st.Name)
+	}
+	return
+}
+
+func TestSeedOrder(t *testing.T) {
+	tables := goldenTables(t)
+	order := seedOrder(tables)
+	// 被关联的表在前，可以同时插入的表按表名排序
+	want := []string{"role", "team", "user", "user_has_role", "profile"}
+	if got := seedOrderNames(order); !reflect.DeepEqual(got, want) {
+		t.Fatalf("seedOrder() = %v, want %v", got, want)
+	}
+	parents := make(map[string]map[string]string)
+	for _, st := range order {
+		parents[st.Name] = st.parents
+	}
+	wantParents := map[string]map[string]string{
+		"role":          {},
+		"team":          {},
+		"user":          {"team_id": "team"},
+		"user_has_role": {"user_id": "user", "role_id": "role"},
+		"profile":       {"user_id": "user"},
+	}
+	if !reflect.DeepEqual(parents, wantParents) {
+		t.Errorf("seedOrder() parents = %v, want %v", parents, wantParents)
+	}
+
+	// team与user互相关联时，环中及依赖环的表按表名排在最后
+	for _, tb := range tables {
+		if tb.Name == "team" {
+			tb.Columns = append(tb.Columns, &Column{Name: "Owner", Type: "*User", IsNeed: true,
+				Tag: &OrmTag{Column: "owner_id", RelFk: true, Null: true}})
+		}
+	}
+	want = []string{"role", "profile", "team", "user", "user_has_role"}
+	if got := seedOrderNames(seedOrder(tables)); !reflect.DeepEqual(got, want) {
+		t.Errorf("seedOrder() of a cycle = %v, want %v", got, want)
+	}
+}
+
+func TestSeedDriver(t *testing.T) {
+	if err := seedDriver("mysql"); err != nil {
+		t.Errorf("seedDriver(mysql) = %v", err)
+	}
+	if err := seedDriver("postgres"); err == nil || !strings.Contains(err.Error(), "only supported on MySQL, not 'postgres'") {
+		t.Errorf("seedDriver(postgres) = %v", err)
+	}
+}
+
+// seedRows 按seed生成每个表的n行，关联表的Id为1..n
+func seedRows(tables []*Table, seed int64, n int) []string {
+	s := &seeder{rnd: rand.New(rand.NewSource(seed)), pks: make(map[string]string),
+		ids: make(map[string][]int64), seen: make(map[string]bool)}
+	var rows []string
+	for _, st := range seedOrder(tables) {
+		var cols []*SQLColumn
+		for _, col := range st.cols {
+			if !col.Auto {
+				cols = append(cols, col)
+			}
+		}
+		for i := 1; i <= n; i++ {
+			rows = append(rows, fmt.Sprintf("%s %v", st.Name, s.uniqueRow(st, cols, i)))
+			s.ids[st.Name] = append(s.ids[st.Name], int64(i))
+		}
+	}
+	return rows
+}
+
+func TestSeedReproducible(t *testing.T) {
+	tables := goldenTables(t)
+	first := seedRows(tables, 42, 20)
+	if second := seedRows(tables, 42, 20); !reflect.DeepEqual(first, second) {
+		t.Errorf("rows of the same seed differ:\n%s\n%s", strings.Join(first, "\n"), strings.Join(second, "\n"))
+	}
+	if other := seedRows(tables, 43, 20); reflect.DeepEqual(first, other) {
+		t.Error("rows of a different seed are the same")
+	}
+	for _, row := range first {
+		if strings.HasSuffix(row, " []") {
+			t.Errorf("no unique row generated: %s", row)
+		}
+	}
+}
@@ -0,0 +1,1101 @@
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	beeLogger "bee/logger"
+	"bee/utils"
+)
+
+// Targets 支持的web框架，beego使用controller，其它的使用handlers、middleware
+var Targets = []string{"beego", "gin", "echo", "stdlib"}
+
+// targetTemplates 每个web框架各自的适配、中间件、路由及main模板
+type targetTemplates struct {
+	Adapter    string
+	Middleware string
+	Router     string
+	Route      string // 一条路由，{{METHOD}} {{path}} {{action}} {{table}}
+	Main       string
+}
+
+var targetTPLs = map[string]*targetTemplates{
+	"gin": {
+		Adapter:    GinAdapterTPL,
+		Middleware: GinMiddlewareTPL,
+		Router:     GinRouterTPL,
+		Route:      `g.{{METHOD}}("{{path}}", handlers.Gin(h.{{action}}))`,
+		Main:       GinMainTPL,
+	},
+	"echo": {
+		Adapter:    EchoAdapterTPL,
+		Middleware: EchoMiddlewareTPL,
+		Router:     EchoRouterTPL,
+		Route:      `g.{{METHOD}}("{{path}}", handlers.Echo(h.{{action}}))`,
+		Main:       EchoMainTPL,
+	},
+	"stdlib": {
+		Adapter:    StdAdapterTPL,
+		Middleware: StdMiddlewareTPL,
+		Router:     StdRouterTPL,
+		Route:      `mux.Handle("{{METHOD}} /api/{{table}}{{path}}", middleware.Auth(handlers.Std(h.{{action}})))`,
+		Main:       StdMainTPL,
+	},
+}
+
+// handlerRoute 每个表的路由，与beego controller的@router一致
+type handlerRoute struct {
+	Method string
+	Path   string
+	Action string
+}
+
+var handlerRoutes = []handlerRoute{
+	{"post", "", "Post"},
+	{"get", "/:id", "GetOne"},
+	{"get", "", "GetAll"},
+	{"put", "/:id", "Put"},
+	{"patch", "/:id", "Patch"},
+	{"patch", "/m2m/part/:id", "PatchM2MPart"},
+	{"delete", "/:id", "Delete"},
+	{"put", "", "PutMulti"},
+	{"patch", "", "PatchMulti"},
+	{"delete", "", "DeleteMulti"},
+}
+
+// writeHandlerFiles 生成非beego框架的handler、middleware文件
+func writeHandlerFiles(tables []*Table, paths *MvcPath, pkgPath string) {
+	tpls := targetTPLs[Target]
+
+	fpath := path.Join(paths.ControllerPath, "lg_context.go")
+	writeFormattedFile(fpath, strings.Replace(HandlerContextTPL, "{{pkgPath}}", pkgPath, -1))
+	fpath = path.Join(paths.ControllerPath, "lg_"+Target+".go")
+	writeFormattedFile(fpath, strings.Replace(tpls.Adapter, "{{pkgPath}}", pkgPath, -1))
+	fpath = path.Join(paths.MiddlewarePath, "lg_auth.go")
+	writeFormattedFile(fpath, MiddlewareAuthTPL)
+	fpath = path.Join(paths.MiddlewarePath, "lg_"+Target+".go")
+	writeFormattedFile(fpath, tpls.Middleware)
+
+	for _, tb := range tables {
+		if tb.Pk == "" || strings.Contains(tb.Name, "_has_") {
+			continue
+		}
+		description := strings.Replace(tb.Comments, "表", "", -1)
+		if len(description) <= 0 {
+			description = tb.Name
+		}
+		fileStr := strings.Replace(HandlerTPL, "{{upsertHandler}}", tb.UpsertHandlerString(), -1)
+		fileStr = strings.Replace(fileStr, "{{ctrlName}}", utils.CamelCase(tb.Name), -1)
+		fileStr = strings.Replace(fileStr, "{{Description}}", description, -1)
+		fileStr = strings.Replace(fileStr, "{{pkgPath}}", pkgPath, -1)
+		writeFormattedFile(path.Join(paths.ControllerPath, getFileName(tb.Name)+".go"), fileStr)
+	}
+}
+
+// writeTargetRouterFile 生成非beego框架的路由，main.go不存在时一并生成
+func writeTargetRouterFile(tables []*Table, rPath string, pkgPath string) {
+	tpls := targetTPLs[Target]
+	var routes []string
+	for _, tb := range tables {
+		if tb.Pk == "" || strings.Contains(tb.Name, "_has_") {
+			continue
+		}
+		tableRoutes := handlerRoutes
+		if keys, _ := tb.upsertKeys(); len(keys) > 0 {
+			tableRoutes = append(tableRoutes, handlerRoute{"put", "/upsert", "Upsert"})
+		}
+		var lines []string
+		for _, r := range tableRoutes {
+			p := r.Path
+			if Target == "stdlib" {
+				p = strings.Replace(p, ":id", "{id}", -1)
+			}
+			line := strings.Replace(tpls.Route, "{{METHOD}}", strings.ToUpper(r.Method), -1)
+			line = strings.Replace(line, "{{path}}", p, -1)
+			line = strings.Replace(line, "{{action}}", r.Action, -1)
+			line = strings.Replace(line, "{{table}}", tb.Name, -1)
+			lines = append(lines, line)
+		}
+		group := ""
+		if Target != "stdlib" {
+			group = fmt.Sprintf("g := api.Group(\"/%s\")\n", tb.Name)
+		}
+		routes = append(routes, fmt.Sprintf("{\nh := &handlers.%sHandler{}\n%s%s\n}", utils.CamelCase(tb.Name), group, strings.Join(lines, "\n")))
+	}
+
+	fileStr := strings.Replace(tpls.Router, "{{routes}}", strings.Join(routes, "\n"), -1)
+	fileStr = strings.Replace(fileStr, "{{pkgPath}}", pkgPath, -1)
+	writeFormattedFile(filepath.Join(rPath, "router.go"), fileStr)
+
+	// 与beego一样，main.go只生成一次
+	mainPath := filepath.Join(filepath.Dir(rPath), "main.go")
+	if !utils.IsExist(mainPath) {
+		dbImports, dbInit := ormMainInit()
+		fileStr = strings.Replace(tpls.Main, "{{dbImports}}", dbImports, -1)
+		fileStr = strings.Replace(fileStr, "{{dbInit}}", dbInit, -1)
+		_ = ioutil.WriteFile(mainPath, []byte(strings.Replace(fileStr, "{{pkgPath}}", pkgPath, -1)), 0666)
+		formatGenerated(mainPath)
+		beeLogger.Log.Infof("Creating %s for the %s target", mainPath, Target)
+	}
+}
+
+// UpsertHandlerString 返回按唯一键新增或修改的handler代码
+func (tb *Table) UpsertHandlerString() string {
+	keys, _ := tb.upsertKeys()
+	if len(keys) == 0 {
+		return ""
+	}
+	var keyNames []string
+	for _, key := range keys {
+		keyNames = append(keyNames, strings.Join(key, ","))
+	}
+	return strings.Replace(HandlerUpsertTPL, "{{uniqueKeys}}", strings.Join(keyNames, " | "), -1)
+}
+
+const (
+	// handler的上下文及公共函数，各框架共用
+	HandlerContextTPL = `package handlers
+
+import (
+	"{{pkgPath}}/models"
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Context 请求上下文，handler不依赖具体的web框架，由lg_gin.go等适配文件实现
+type Context interface {
+	// Param 路径参数
+	Param(name string) string
+	// Query 查询参数
+	Query(name string) string
+	// Body 请求体，可以多次读取
+	Body() []byte
+	// JSON 以json返回v
+	JSON(status int, v interface{})
+	// Claims JWT解析后的claims，未启用JWT时为nil
+	Claims() map[string]interface{}
+}
+
+// lgError 返回400，校验错误以json对象返回，其它错误返回错误信息
+func lgError(c Context, err error) {
+	if ve, ok := err.(*models.LgValidationError); ok {
+		c.JSON(400, ve)
+		return
+	}
+	c.JSON(400, err.Error())
+}
+
+// lgIsObject 请求体是否为对象，否则作为数组处理
+func lgIsObject(body []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+// lgSplit 逗号分隔的查询参数
+func lgSplit(c Context, name string) []string {
+	if v := c.Query(name); v != "" {
+		return strings.Split(v, ",")
+	}
+	return nil
+}
+
+// lgInt64 整数的查询参数，没有或格式不对时返回def
+func lgInt64(c Context, name string, def int64) int64 {
+	if v, err := strconv.ParseInt(c.Query(name), 10, 64); err == nil {
+		return v
+	}
+	return def
+}
+
+// lgParseQuery query: k:v,k:v
+func lgParseQuery(c Context) (map[string]string, error) {
+	var query = make(map[string]string)
+	for _, cond := range lgSplit(c, "query") {
+		kv := strings.SplitN(cond, ":", 2)
+		if len(kv) != 2 {
+			return nil, errors.New("Error: invalid query key/value pair")
+		}
+		query[kv[0]] = kv[1]
+	}
+	return query, nil
+}
+`
+	// 每个表的handler，各框架共用
+	HandlerTPL = `package handlers
+
+import (
+	"{{pkgPath}}/models"
+	"{{pkgPath}}/models/dto"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// {{ctrlName}}Handler operations for {{ctrlName}}
+type {{ctrlName}}Handler struct{}
+
+// Post 新建{{Description}}，请求体为对象或数组
+func (h *{{ctrlName}}Handler) Post(c Context) {
+	body := c.Body()
+	if lgIsObject(body) {
+		var req dto.{{ctrlName}}CreateRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			lgError(c, err)
+			return
+		}
+		v := req.ToModel()
+		if err := v.Validate(); err != nil {
+			lgError(c, err)
+			return
+		}
+		if _, err := models.Add{{ctrlName}}HasMany(v); err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(201, dto.New{{ctrlName}}Response(v))
+		return
+	}
+
+	var reqs []*dto.{{ctrlName}}CreateRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	vs := make([]*models.{{ctrlName}}, len(reqs))
+	for i, req := range reqs {
+		vs[i] = req.ToModel()
+	}
+	if err := models.LgValidateAll(len(vs), func(i int) error { return vs[i].Validate() }); err != nil {
+		lgError(c, err)
+		return
+	}
+	successNums, err := models.AddMulti{{ctrlName}}(vs)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(201, successNums)
+}
+
+// GetOne 获取{{Description}}信息，load=关系1,关系2
+func (h *{{ctrlName}}Handler) GetOne(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v, err := models.Get{{ctrlName}}ById(id)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	for _, lo := range lgSplit(c, "load") {
+		if _, err := v.LoadRelatedOf(lo); err != nil {
+			lgError(c, err)
+			return
+		}
+	}
+	c.JSON(200, dto.New{{ctrlName}}Response(v))
+}
+
+// GetAll 搜索{{Description}}信息，参数与beego的GetAll一致
+func (h *{{ctrlName}}Handler) GetAll(c Context) {
+	query, err := lgParseQuery(c)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	if lgInt64(c, "getcounts", 0) == 1 {
+		nums, err := models.Get{{ctrlName}}Counts(query)
+		if err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(200, nums)
+		return
+	}
+
+	l, pager, err := models.GetAll{{ctrlName}}(query, lgSplit(c, "fields"), lgSplit(c, "sortby"), lgSplit(c, "order"),
+		lgInt64(c, "offset", 0), lgInt64(c, "limit", 10), lgSplit(c, "load"), lgInt64(c, "page", 0))
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	if pager != nil {
+		pager.List = dto.New{{ctrlName}}Responses(l)
+		c.JSON(200, pager)
+	} else {
+		c.JSON(200, dto.New{{ctrlName}}Responses(l))
+	}
+}
+
+// Put 修改{{Description}}，只修改请求体中的字段
+func (h *{{ctrlName}}Handler) Put(c Context) {
+	h.update(c)
+}
+
+// Patch 修改{{Description}}，只修改请求体中的字段
+func (h *{{ctrlName}}Handler) Patch(c Context) {
+	h.update(c)
+}
+
+func (h *{{ctrlName}}Handler) update(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v := models.{{ctrlName}}{Id: id}
+	var req dto.{{ctrlName}}UpdateRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		lgError(c, err)
+		return
+	}
+	fields := req.Apply(&v)
+	if len(fields) == 0 {
+		c.JSON(400, "没有匹配字段！")
+		return
+	}
+	if err := v.ValidateFields(fields...); err != nil {
+		lgError(c, err)
+		return
+	}
+	if err := models.Patch{{ctrlName}}ById(&v, fields); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// PatchM2MPart 修改{{Description}}的关系，m2m_field=字段，请求体为{"Add": [], "Del": []}
+func (h *{{ctrlName}}Handler) PatchM2MPart(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v := models.{{ctrlName}}{Id: id}
+	m2mField := c.Query("m2m_field")
+	if m2mField == "" {
+		c.JSON(400, "m2m_field不能为空！")
+		return
+	}
+	AddOrDelIds := struct {
+		Add []int
+		Del []int
+	}{}
+	if err := json.Unmarshal(c.Body(), &AddOrDelIds); err != nil {
+		lgError(c, err)
+		return
+	}
+	if err := models.Patch{{ctrlName}}M2MPartById(&v, m2mField, AddOrDelIds.Add, AddOrDelIds.Del); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// Delete 删除{{Description}}
+func (h *{{ctrlName}}Handler) Delete(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := models.Delete{{ctrlName}}(id); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// PutMulti 批量替换{{Description}}，请求体为带Id的数组，未出现的字段置为零值
+func (h *{{ctrlName}}Handler) PutMulti(c Context) {
+	h.updateMulti(c, true)
+}
+
+// PatchMulti 批量修改{{Description}}，请求体为带Id的数组，只修改出现的字段
+func (h *{{ctrlName}}Handler) PatchMulti(c Context) {
+	h.updateMulti(c, false)
+}
+
+// updateMulti full时修改所有字段，否则只修改请求体中出现的字段
+func (h *{{ctrlName}}Handler) updateMulti(c Context, full bool) {
+	var reqs []*dto.{{ctrlName}}UpdateRequest
+	if err := json.Unmarshal(c.Body(), &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	var vs []*models.{{ctrlName}}
+	var fields [][]string
+	for _, req := range reqs {
+		v := &models.{{ctrlName}}{Id: req.Id}
+		vs = append(vs, v)
+		if f := req.Apply(v); !full {
+			fields = append(fields, f)
+		} else {
+			fields = append(fields, dto.{{ctrlName}}UpdateFields)
+		}
+	}
+	result, err := models.UpdateMulti{{ctrlName}}(vs, fields)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
+
+// DeleteMulti 批量删除{{Description}}，ids=1,2,3 或 query=k:v,k:v
+func (h *{{ctrlName}}Handler) DeleteMulti(c Context) {
+	var result *models.LgBatchResult
+	var err error
+	if ids := lgSplit(c, "ids"); ids != nil {
+		var intIds []int
+		for _, idStr := range ids {
+			id, e := strconv.Atoi(strings.TrimSpace(idStr))
+			if e != nil {
+				lgError(c, errors.New("Error: invalid id "+idStr))
+				return
+			}
+			intIds = append(intIds, id)
+		}
+		result, err = models.DeleteMulti{{ctrlName}}ByIds(intIds)
+	} else if c.Query("query") != "" {
+		var query map[string]string
+		if query, err = lgParseQuery(c); err == nil {
+			result, err = models.DeleteMulti{{ctrlName}}ByQuery(query)
+		}
+	} else {
+		err = errors.New("Error: ids or query is required")
+	}
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
+{{upsertHandler}}`
+	// 按唯一键upsert的handler
+	HandlerUpsertTPL = `
+// Upsert 按唯一键新增或修改{{Description}}，by可选 {{uniqueKeys}}
+func (h *{{ctrlName}}Handler) Upsert(c Context) {
+	by := c.Query("by")
+	body := c.Body()
+	if lgIsObject(body) {
+		var req dto.{{ctrlName}}CreateRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			lgError(c, err)
+			return
+		}
+		v := req.ToModel()
+		if _, err := models.Upsert{{ctrlName}}(v, by); err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(200, dto.New{{ctrlName}}Response(v))
+		return
+	}
+
+	var reqs []*dto.{{ctrlName}}CreateRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	vs := make([]*models.{{ctrlName}}, len(reqs))
+	for i, req := range reqs {
+		vs[i] = req.ToModel()
+	}
+	result, err := models.UpsertMulti{{ctrlName}}(vs, by)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
+`
+	// 签名、JWT及权限验证，与beego的BaseController一致，配置从环境变量读取
+	MiddlewareAuthTPL = `package middleware
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ClaimsKey JWT解析后的claims保存在上下文中的键
+const ClaimsKey = "JWTClaims"
+
+var (
+	JWT_PUBLIC_KEY          []byte
+	appkey, accessSecret    string
+	openApiSign             bool
+	openJwt                 bool
+	openPerm                bool
+	CENTER_SERVICE          string
+)
+
+func init() {
+	appkey = os.Getenv("APPKEY")
+	// accessSecret用于签名
+	accessSecret = os.Getenv("ACCESS_SECRET")
+	CENTER_SERVICE = os.Getenv("CENTER_SERVICE")
+	// 三个开关，分别是 API签名验证、JWT合法性验证及解析、路由权限验证
+	openApiSign, _ = strconv.ParseBool(os.Getenv("OPEN_API_SIGN"))
+	openJwt, _ = strconv.ParseBool(os.Getenv("OPEN_JWT"))
+	openPerm, _ = strconv.ParseBool(os.Getenv("OPEN_PERM"))
+	// 当启用JWT时，才读取公钥
+	if openJwt {
+		keyFile := os.Getenv("JWT_PUBLIC_KEY_FILE")
+		if keyFile == "" {
+			keyFile = "keys/jwt_public_key.pem"
+		}
+		fd, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			panic(err)
+		}
+		JWT_PUBLIC_KEY = fd
+	}
+}
+
+// Ignored FilterToken
+var IgnoredTokenRouter = map[string]bool{
+	"post@/api/user/login":       true,
+	"post@/api/user/login/oauth": true,
+}
+
+// Ignored PermRouter
+var IgnoredPermRouter = map[string]bool{
+	"post@/api/user/login":         true,
+	"post@/api/user/login/refresh": true,
+	"post@/api/user/login/oauth":   true,
+}
+
+// lgRoute 路径格式均为 请求类型@路径，路径参数为 :name
+func lgRoute(method string, pattern string) string {
+	return strings.ToLower(method) + "@" + pattern
+}
+
+// verify 依次验证签名、JWT及权限，失败时已写入错误响应，返回false
+func verify(w http.ResponseWriter, r *http.Request, route string) (claims map[string]interface{}, ok bool) {
+	if openApiSign && !VerifySign(w, r) {
+		return nil, false
+	}
+	if !openJwt {
+		return nil, true
+	}
+	// 直接通过map查询是否忽略
+	if IgnoredTokenRouter[route] {
+		return nil, true
+	}
+	if claims, ok = VerifyToken(w, r); !ok {
+		return
+	}
+	if !openPerm || IgnoredPermRouter[route] {
+		return claims, true
+	}
+	return claims, VerifyPerm(w, route, claims)
+}
+
+func VerifyToken(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	kv := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(kv) != 2 || kv[0] != "Bearer" {
+		log.Println("Authorization格式不对或Token为空！")
+		http.Error(w, "Authorization格式不对或Token为空！", http.StatusUnauthorized)
+		return nil, false
+	}
+	tokenString := kv[1]
+
+	// Parse token
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// 必要的验证 RS256
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		// 必要的验证 'iss' claim
+		iss := "https://atomintl.auth0.com/"
+		checkIss := token.Claims.(jwt.MapClaims).VerifyIssuer(iss, false)
+		if !checkIss {
+			return token, errors.New("Invalid issuer.")
+		}
+		return jwt.ParseRSAPublicKeyFromPEM(JWT_PUBLIC_KEY)
+	})
+	if err != nil {
+		log.Println("Parse token error:", err)
+		if ve, ok := err.(*jwt.ValidationError); ok {
+			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
+				http.Error(w, "Token 格式有误！", http.StatusUnauthorized)
+			} else if ve.Errors&(jwt.ValidationErrorExpired|jwt.ValidationErrorNotValidYet) != 0 {
+				http.Error(w, "Token 已过期！", http.StatusUnauthorized)
+			} else {
+				http.Error(w, "验证Token的过程中发生其他错误！", http.StatusUnauthorized)
+			}
+		} else {
+			http.Error(w, "无法处理此Token！", http.StatusUnauthorized)
+		}
+		return nil, false
+	}
+	if !token.Valid {
+		log.Println("Token invalid:", tokenString)
+		http.Error(w, "Token 不合法:"+tokenString, http.StatusUnauthorized)
+		return nil, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		log.Println("转换为jwt.MapClaims失败")
+		http.Error(w, "无法处理此Token！", http.StatusUnauthorized)
+		return nil, false
+	}
+	var claimsMIF = make(map[string]interface{})
+	jsonM, _ := json.Marshal(&claims)
+	json.Unmarshal(jsonM, &claimsMIF)
+	claimsMIF["JWTToken"] = tokenString
+	return claimsMIF, true
+}
+
+func VerifyPerm(w http.ResponseWriter, route string, cls map[string]interface{}) bool {
+	subT, _ := cls["sub_type"].(string)
+	subV, _ := cls["sub_value"].(string)
+	if subT == "" {
+		http.Error(w, "JWT中的SubType不能为空！", http.StatusUnauthorized)
+		return false
+	}
+	v := &struct {
+		SubType  string
+		SubValue string
+		Perm     string
+		Ops      string
+		AppId    string
+	}{SubType: subT, SubValue: subV, Perm: route, Ops: "999"}
+	jsonM, _ := json.Marshal(v)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Post(CENTER_SERVICE+"/rule_perm/check", "application/json", bytes.NewReader(jsonM))
+	if err != nil {
+		http.Error(w, "请求权限检查出错！", http.StatusUnauthorized)
+		return false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		http.Error(w, "没有权限！", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// 验证签名，读取请求体后重新放回
+func VerifySign(w http.ResponseWriter, r *http.Request) bool {
+	bd, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(bd))
+	req := r.URL.Query()
+	app_key, sn, ts := req.Get("app_key"), req.Get("sn"), req.Get("ts")
+
+	// 判断app_key
+	if app_key == "" || app_key != appkey {
+		http.Error(w, "app_key错误，请核对提交应用key!", http.StatusForbidden)
+		return false
+	}
+
+	// 验证过期时间
+	timestamp := time.Now().Unix()
+	exp := int64(600)
+	tsInt, _ := strconv.ParseInt(ts, 10, 64)
+	if tsInt > timestamp || timestamp-tsInt >= exp {
+		http.Error(w, "ts错误，请求已过期!", http.StatusForbidden)
+		return false
+	}
+
+	// 验证签名
+	if sn == "" || sn != createSignMD5(req, bd, accessSecret) {
+		http.Error(w, "sn错误，请核对签名!", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// 创建MD5签名
+func createSignMD5(params url.Values, body []byte, AS string) string {
+	// 自定义 MD5 组合
+	return EncodeStrMd5(AS + createEncryptStr(params) + EncodeByteMd5(body) + AS)
+}
+
+func createEncryptStr(params url.Values) string {
+	var key []string
+	for k := range params {
+		if k != "sn" && k != "debug" {
+			key = append(key, k)
+		}
+	}
+	sort.Strings(key)
+	var pairs []string
+	for _, k := range key {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", k, params.Get(k)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// Encode string to md5 hex value
+func EncodeStrMd5(str string) string {
+	return EncodeByteMd5([]byte(str))
+}
+
+func EncodeByteMd5(b []byte) string {
+	m := md5.New()
+	m.Write(b)
+	return hex.EncodeToString(m.Sum(nil))
+}
+`
+	// gin的适配
+	GinAdapterTPL = `package handlers
+
+import (
+	"{{pkgPath}}/middleware"
+	"io/ioutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ginContext struct {
+	c    *gin.Context
+	body []byte
+}
+
+func (g *ginContext) Param(name string) string { return g.c.Param(name) }
+
+func (g *ginContext) Query(name string) string { return g.c.Query(name) }
+
+func (g *ginContext) Body() []byte {
+	if g.body == nil {
+		g.body, _ = ioutil.ReadAll(g.c.Request.Body)
+	}
+	return g.body
+}
+
+func (g *ginContext) JSON(status int, v interface{}) { g.c.JSON(status, v) }
+
+func (g *ginContext) Claims() map[string]interface{} {
+	cl, _ := g.c.Get(middleware.ClaimsKey)
+	clmap, _ := cl.(map[string]interface{})
+	return clmap
+}
+
+// Gin 把handler转换为gin.HandlerFunc
+func Gin(h func(Context)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h(&ginContext{c: c})
+	}
+}
+`
+	// gin的中间件
+	GinMiddlewareTPL = `package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Auth 签名、JWT及权限验证的gin中间件
+func Auth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := verify(c.Writer, c.Request, lgRoute(c.Request.Method, c.FullPath()))
+		if !ok {
+			c.Abort()
+			return
+		}
+		if claims != nil {
+			c.Set(ClaimsKey, claims)
+		}
+		c.Next()
+	}
+}
+`
+	// gin的路由
+	GinRouterTPL = `package routers
+
+import (
+	"{{pkgPath}}/handlers"
+	"{{pkgPath}}/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewRouter 注册所有表的路由
+func NewRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Logger(), gin.Recovery(), middleware.Auth())
+	api := r.Group("/api")
+
+	{{routes}}
+	return r
+}
+`
+	// gin的main
+	GinMainTPL = `package main
+
+import (
+	"{{pkgPath}}/routers"
+	"os"
+
+	{{dbImports}}
+)
+
+func init() {
+	{{dbInit}}
+}
+
+func main() {
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+	if err := routers.NewRouter().Run(addr); err != nil {
+		panic(err)
+	}
+}
+`
+	// echo的适配
+	EchoAdapterTPL = `package handlers
+
+import (
+	"{{pkgPath}}/middleware"
+	"io/ioutil"
+
+	"github.com/labstack/echo/v4"
+)
+
+type echoContext struct {
+	c    echo.Context
+	body []byte
+}
+
+func (e *echoContext) Param(name string) string { return e.c.Param(name) }
+
+func (e *echoContext) Query(name string) string { return e.c.QueryParam(name) }
+
+func (e *echoContext) Body() []byte {
+	if e.body == nil {
+		e.body, _ = ioutil.ReadAll(e.c.Request().Body)
+	}
+	return e.body
+}
+
+func (e *echoContext) JSON(status int, v interface{}) { _ = e.c.JSON(status, v) }
+
+func (e *echoContext) Claims() map[string]interface{} {
+	clmap, _ := e.c.Get(middleware.ClaimsKey).(map[string]interface{})
+	return clmap
+}
+
+// Echo 把handler转换为echo.HandlerFunc
+func Echo(h func(Context)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		h(&echoContext{c: c})
+		return nil
+	}
+}
+`
+	// echo的中间件
+	EchoMiddlewareTPL = `package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// Auth 签名、JWT及权限验证的echo中间件
+func Auth() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := verify(c.Response(), c.Request(), lgRoute(c.Request().Method, c.Path()))
+			if !ok {
+				return nil
+			}
+			if claims != nil {
+				c.Set(ClaimsKey, claims)
+			}
+			return next(c)
+		}
+	}
+}
+`
+	// echo的路由
+	EchoRouterTPL = `package routers
+
+import (
+	"{{pkgPath}}/handlers"
+	"{{pkgPath}}/middleware"
+
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+)
+
+// NewRouter 注册所有表的路由
+func NewRouter() *echo.Echo {
+	e := echo.New()
+	e.Use(echomw.Logger(), echomw.Recover(), middleware.Auth())
+	api := e.Group("/api")
+
+	{{routes}}
+	return e
+}
+`
+	// echo的main
+	EchoMainTPL = `package main
+
+import (
+	"{{pkgPath}}/routers"
+	"os"
+
+	{{dbImports}}
+)
+
+func init() {
+	{{dbInit}}
+}
+
+func main() {
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+	e := routers.NewRouter()
+	e.Logger.Fatal(e.Start(addr))
+}
+`
+	// net/http的适配，需要go1.22及以上的ServeMux
+	StdAdapterTPL = `package handlers
+
+import (
+	"{{pkgPath}}/middleware"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+type stdContext struct {
+	w    http.ResponseWriter
+	r    *http.Request
+	body []byte
+}
+
+func (s *stdContext) Param(name string) string { return s.r.PathValue(name) }
+
+func (s *stdContext) Query(name string) string { return s.r.URL.Query().Get(name) }
+
+func (s *stdContext) Body() []byte {
+	if s.body == nil {
+		s.body, _ = ioutil.ReadAll(s.r.Body)
+	}
+	return s.body
+}
+
+func (s *stdContext) JSON(status int, v interface{}) {
+	s.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	s.w.WriteHeader(status)
+	_ = json.NewEncoder(s.w).Encode(v)
+}
+
+func (s *stdContext) Claims() map[string]interface{} { return middleware.Claims(s.r) }
+
+// Std 把handler转换为http.Handler
+func Std(h func(Context)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h(&stdContext{w: w, r: r})
+	})
+}
+`
+	// net/http的中间件
+	StdMiddlewareTPL = `package middleware
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type claimsKey struct{}
+
+var stdParam = regexp.MustCompile(` + "`" + `\{(\w+)\.*\}` + "`" + `)
+
+// Claims 返回Auth保存在请求中的JWT claims
+func Claims(r *http.Request) map[string]interface{} {
+	clmap, _ := r.Context().Value(claimsKey{}).(map[string]interface{})
+	return clmap
+}
+
+// Auth 签名、JWT及权限验证，需要注册在每个路由上才能取得路由的pattern
+func Auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// GET /api/user/{id} => /api/user/:id
+		pattern := r.Pattern
+		if i := strings.Index(pattern, " "); i >= 0 {
+			pattern = pattern[i+1:]
+		}
+		pattern = stdParam.ReplaceAllString(pattern, ":$1")
+		claims, ok := verify(w, r, lgRoute(r.Method, pattern))
+		if !ok {
+			return
+		}
+		if claims != nil {
+			r = r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+`
+	// net/http的路由
+	StdRouterTPL = `package routers
+
+import (
+	"{{pkgPath}}/handlers"
+	"{{pkgPath}}/middleware"
+	"net/http"
+)
+
+// NewRouter 注册所有表的路由
+func NewRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	{{routes}}
+	return mux
+}
+`
+	// net/http的main
+	StdMainTPL = `package main
+
+import (
+	"{{pkgPath}}/routers"
+	"log"
+	"net/http"
+	"os"
+
+	{{dbImports}}
+)
+
+func init() {
+	{{dbInit}}
+}
+
+func main() {
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+	log.Fatal(http.ListenAndServe(addr, routers.NewRouter()))
+}
+`
+)
@@ -0,0 +1,416 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+
+	beeLogger "bee/logger"
+	"bee/utils"
+)
+
+// SQLColumn 模型字段与数据库列的对应关系，用于生成手写SQL的代码
+type SQLColumn struct {
+	Column     string
+	Field      string
+	Type       string
+	Rel        bool // 关系字段，值为关联对象的Id
+	Pk         bool
+	Auto       bool
+	AutoNow    bool
+	AutoNowAdd bool
+	Tag        *OrmTag // 列的标签，关系字段为对应的 _id 列的标签
+}
+
+// SQLColumns 返回表中对应数据库列的字段，一对多、多对多及反向一对一的字段不在表中，不返回
+func (tb *Table) SQLColumns() (cols []*SQLColumn) {
+	for _, v := range tb.Columns {
+		if !v.IsNeed || v.Tag.ReverseMany || v.Tag.ReverseOne || v.Tag.M2M || v.Tag.RelM2M {
+			continue
+		}
+		col := &SQLColumn{
+			Column:     v.Tag.Column,
+			Field:      v.Name,
+			Type:       v.Type,
+			Pk:         v.Tag.Pk,
+			Auto:       v.Tag.Auto,
+			AutoNow:    v.Tag.AutoNow,
+			AutoNowAdd: v.Tag.AutoNowAdd,
+			Tag:        v.Tag,
+		}
+		if v.Tag.RelFk || v.Tag.RelOne {
+			col.Rel = true
+			// 与beego orm一致，关系字段默认的列名为 字段名_id
+			if col.Column == "" {
+				col.Column = snakeString(v.Name) + "_id"
+			}
+			for _, hidden := range tb.Columns {
+				if !hidden.IsNeed && hidden.Tag != nil && hidden.Tag.Column == col.Column {
+					col.Tag = hidden.Tag
+				}
+			}
+		}
+		cols = append(cols, col)
+	}
+	return
+}
+
+// UpsertString 返回按唯一键新增或修改的model代码，没有可用的唯一键时返回空字符串
+func (tb *Table) UpsertString() string {
+	keys, skipped := tb.upsertKeys()
+	for _, reason := range skipped {
+		beeLogger.Log.Warnf("Skipping upsert of '%s': %s", tb.Name, reason)
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	var insertCols []*SQLColumn
+	for _, col := range tb.SQLColumns() {
+		if col.Pk && col.Auto {
+			continue
+		}
+		insertCols = append(insertCols, col)
+	}
+
+	// 参数的顺序与insertCols一致
+	var args, vars []string
+	for _, col := range insertCols {
+		if col.Rel {
+			name := lowerFirst(col.Field) + "Id"
+			vars = append(vars, fmt.Sprintf("var %s interface{}\n\tif m.%s != nil {\n\t\t%s = m.%s.Id\n\t}", name, col.Field, name, col.Field))
+			args = append(args, name)
+		} else if col.Type == "time.Time" {
+			name := lowerFirst(col.Field)
+			vars = append(vars, fmt.Sprintf("var %s interface{}\n\tif !m.%s.IsZero() {\n\t\t%s = m.%s\n\t}", name, col.Field, name, col.Field))
+			args = append(args, name)
+		} else {
+			args = append(args, "m."+col.Field)
+		}
+	}
+	var now []string
+	for _, col := range insertCols {
+		if col.AutoNow || col.AutoNowAdd {
+			now = append(now, fmt.Sprintf("m.%s = now", col.Field))
+		}
+	}
+	if len(now) > 0 {
+		now = append([]string{"now := time.Now()"}, now...)
+	}
+
+	rv := strings.Replace(UpsertArgsTPL, "{{argVars}}", strings.Join(append(now, vars...), "\n\t"), -1)
+	rv = strings.Replace(rv, "{{args}}", strings.Join(args, ", "), -1)
+
+	// 与model一样按持久层选择模板
+	upsertByTPL, upsertTPL := UpsertByTPL, UpsertTPL
+	switch ORM {
+	case "gorm":
+		upsertByTPL, upsertTPL = GormUpsertByTPL, GormUpsertTPL
+	case "sqlx":
+		upsertByTPL, upsertTPL = SqlxUpsertByTPL, SqlxUpsertTPL
+	}
+	var cases, keyNames []string
+	for i, key := range keys {
+		funcName := "By" + upsertKeyName(key)
+		byName := strings.Join(key, ",")
+		keyNames = append(keyNames, fmt.Sprintf("%q", byName))
+		caseStr := fmt.Sprintf("case %q:", byName)
+		if i == 0 {
+			caseStr = fmt.Sprintf("case \"\", %q:", byName)
+		}
+		cases = append(cases, caseStr+"\n\t\treturn upsert{{modelName}}"+funcName+", nil")
+
+		byTpl := strings.Replace(upsertByTPL, "{{byName}}", funcName, -1)
+		byTpl = strings.Replace(byTpl, "{{byColumns}}", strings.Join(key, ", "), -1)
+		byTpl = strings.Replace(byTpl, "{{mysqlSQL}}", fmt.Sprintf("%q", tb.upsertMysqlSQL(insertCols, key)), -1)
+		byTpl = strings.Replace(byTpl, "{{pgSQL}}", fmt.Sprintf("%q", tb.upsertPgSQL(insertCols, key)), -1)
+		rv += byTpl
+	}
+	rv += strings.Replace(upsertTPL, "{{upsertCases}}", strings.Join(cases, "\n\t"), -1)
+	rv = strings.Replace(rv, "{{uniqueKeys}}", strings.Join(keyNames, ", "), -1)
+	return rv
+}
+
+// UpsertCtrlString 返回按唯一键新增或修改的controller代码
+func (tb *Table) UpsertCtrlString() (mapping string, action string) {
+	keys, _ := tb.upsertKeys()
+	if len(keys) == 0 {
+		return "", ""
+	}
+	var keyNames []string
+	for _, key := range keys {
+		keyNames = append(keyNames, strings.Join(key, ","))
+	}
+	action = strings.Replace(UpsertCtrlTPL, "{{uniqueKeys}}", strings.Join(keyNames, " | "), -1)
+	return `c.Mapping("Upsert", c.Upsert)`, action
+}
+
+// upsertKeys 返回可用于upsert的唯一键，skipped为跳过的原因。MySQL的ON DUPLICATE KEY UPDATE
+// 与任一唯一键或主键冲突时都会修改那一行，不能限定为by的列，所以只有主键自增且只有一个唯一键的表才生成upsert
+func (tb *Table) upsertKeys() (keys [][]string, skipped []string) {
+	keys, names := tb.modelUniqueKeys()
+	for _, name := range names {
+		skipped = append(skipped, fmt.Sprintf("unique key '%s' has columns not in the model", name))
+	}
+	if len(keys) == 0 {
+		return
+	}
+	autoPk := false
+	for _, col := range tb.SQLColumns() {
+		autoPk = autoPk || col.Pk && col.Auto
+	}
+	if len(tb.UniqueKeys) > 1 || !autoPk {
+		skipped = append(skipped, "ON DUPLICATE KEY UPDATE of MySQL may update the row of another unique key, "+
+			"upsert needs an auto increment primary key and a single unique key")
+		return nil, skipped
+	}
+	return
+}
+
+// modelUniqueKeys 返回列都是模型中字段的唯一键，skipped为其余唯一键的名字
+func (tb *Table) modelUniqueKeys() (keys [][]string, skipped []string) {
+	if tb.Pk == "" || strings.Contains(tb.Name, "_has_") {
+		return
+	}
+	columns := make(map[string]bool)
+	for _, col := range tb.SQLColumns() {
+		columns[col.Column] = true
+	}
+	for _, uk := range tb.UniqueKeys {
+		usable := true
+		for _, c := range uk.Columns {
+			if !columns[c] {
+				usable = false
+				break
+			}
+		}
+		if !usable {
+			skipped = append(skipped, uk.Name)
+			continue
+		}
+		keys = append(keys, uk.Columns)
+	}
+	return
+}
+
+// upsertMysqlSQL INSERT ... ON DUPLICATE KEY UPDATE，通过LAST_INSERT_ID(id)在修改时也能取到Id。
+// VALUES(col)自MySQL 8.0.20不再推荐，但代替它的行别名（VALUES (...) AS new）需要8.0.19，
+// MySQL 5.7及MariaDB都不支持，所以仍用VALUES(col)
+func (tb *Table) upsertMysqlSQL(cols []*SQLColumn, key []string) string {
+	var names, marks []string
+	updates := []string{fmt.Sprintf("`%s` = LAST_INSERT_ID(`%s`)", tb.Pk, tb.Pk)}
+	for _, col := range cols {
+		names = append(names, "`"+col.Column+"`")
+		marks = append(marks, "?")
+		if !col.AutoNowAdd && !inStrings(key, col.Column) {
+			updates = append(updates, fmt.Sprintf("`%s` = VALUES(`%s`)", col.Column, col.Column))
+		}
+	}
+	return fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
+		tb.Name, strings.Join(names, ", "), strings.Join(marks, ", "), strings.Join(updates, ", "))
+}
+
+// upsertPgSQL INSERT ... ON CONFLICT DO UPDATE ... RETURNING id
+func (tb *Table) upsertPgSQL(cols []*SQLColumn, key []string) string {
+	var names, marks, updates, conflicts []string
+	for _, col := range cols {
+		names = append(names, `"`+col.Column+`"`)
+		marks = append(marks, "?")
+		if !col.AutoNowAdd && !inStrings(key, col.Column) {
+			updates = append(updates, fmt.Sprintf(`"%s" = EXCLUDED."%s"`, col.Column, col.Column))
+		}
+	}
+	for _, c := range key {
+		conflicts = append(conflicts, `"`+c+`"`)
+	}
+	// 没有可修改的列时也要DO UPDATE，DO NOTHING不会返回Id
+	if len(updates) == 0 {
+		updates = append(updates, fmt.Sprintf(`"%s" = EXCLUDED."%s"`, key[0], key[0]))
+	}
+	return fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s RETURNING "%s"`,
+		tb.Name, strings.Join(names, ", "), strings.Join(marks, ", "), strings.Join(conflicts, ", "),
+		strings.Join(updates, ", "), tb.Pk)
+}
+
+// upsertKeyName tenant_id,code => TenantIdAndCode
+func upsertKeyName(key []string) string {
+	var names []string
+	for _, c := range key {
+		names = append(names, utils.CamelCase(c))
+	}
+	return strings.Join(names, "And")
+}
+
+// snakeString 与beego orm的命名规则一致：UserGroup => user_group
+func snakeString(s string) string {
+	data := make([]byte, 0, len(s)*2)
+	j := false
+	for i := 0; i < len(s); i++ {
+		d := s[i]
+		if i > 0 && d >= 'A' && d <= 'Z' && j {
+			data = append(data, '_')
+		}
+		if d != '_' {
+			j = true
+		}
+		data = append(data, d)
+	}
+	return strings.ToLower(string(data))
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func inStrings(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// upsert的参数
+	UpsertArgsTPL = `
+// upsert{{modelName}}Args returns the values of the columns inserted by upsert
+func upsert{{modelName}}Args(m *{{modelName}}) []interface{} {
+	{{argVars}}
+	return []interface{}{ {{args}} }
+}
+`
+	// 按某个唯一键upsert
+	UpsertByTPL = `
+// Upsert{{modelName}}{{byName}} inserts m, or updates the {{modelName}} with the same
+// ({{byColumns}}), and returns the Id on success. ({{byColumns}}) is the only unique key
+// of the table, so the conflict handled by MySQL is always on it.
+func Upsert{{modelName}}{{byName}}(m *{{modelName}}) (id int64, err error) {
+	return upsert{{modelName}}{{byName}}(orm.NewOrm(), m)
+}
+
+func upsert{{modelName}}{{byName}}(o orm.Ormer, m *{{modelName}}) (id int64, err error) {
+	args := upsert{{modelName}}Args(m)
+	if o.Driver().Type() == orm.DRPostgres {
+		err = o.Raw({{pgSQL}}, args...).QueryRow(&id)
+	} else {
+		res, e := o.Raw({{mysqlSQL}}, args...).Exec()
+		if err = e; err == nil {
+			id, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return
+	}
+	// 重新读取，修改时m中的字段不一定与数据库一致
+	m.Id = int(id)
+	err = o.Read(m)
+	return
+}
+`
+	// 按by选择唯一键upsert
+	UpsertTPL = `
+// {{modelName}}UniqueKeys lists the unique keys accepted by the by argument of Upsert{{modelName}}
+var {{modelName}}UniqueKeys = []string{ {{uniqueKeys}} }
+
+func upsert{{modelName}}Func(by string) (func(orm.Ormer, *{{modelName}}) (int64, error), error) {
+	switch by {
+	{{upsertCases}}
+	}
+	return nil, errors.New("Error: unknown unique key '" + by + "', must be one of " + strings.Join({{modelName}}UniqueKeys, " | "))
+}
+
+// Upsert{{modelName}} inserts or updates m by the unique key by, the columns of by are
+// separated by ",", an empty by means the first unique key.
+func Upsert{{modelName}}(m *{{modelName}}, by string) (id int64, err error) {
+	upsert, err := upsert{{modelName}}Func(by)
+	if err != nil {
+		return
+	}
+	// 唯一键冲突时修改，只校验列
+	if err = m.ValidateColumns(); err != nil {
+		return
+	}
+	return upsert(orm.NewOrm(), m)
+}
+
+// UpsertMulti{{modelName}} inserts or updates several {{modelName}}s by the unique key by in
+// one transaction. Nothing is committed unless every item succeeds, and the items after
+// the first failed one are skipped.
+func UpsertMulti{{modelName}}(ms []*{{modelName}}, by string) (result *LgBatchResult, err error) {
+	upsert, err := upsert{{modelName}}Func(by)
+	if err != nil {
+		return
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		itemErr := m.ValidateColumns()
+		if itemErr == nil {
+			_, itemErr = upsert(o, m)
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(o)
+	return
+}
+`
+	// 按唯一键upsert的controller
+	UpsertCtrlTPL = `
+// @Description 按唯一键新增或修改{{Description}}，by可选 {{uniqueKeys}}
+// @router /upsert [put]
+func (c *{{ctrlName}}Controller) Upsert() {
+	by := c.GetString("by")
+	// pos91
+	jr := gjson.ParseBytes(c.Ctx.Input.RequestBody)
+	if jr.IsObject() {
+		var req dto.{{ctrlName}}CreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+			v := req.ToModel()
+			// pos92
+			if _, err := models.Upsert{{ctrlName}}(v, by); err == nil {
+				// pos93
+				c.Data["json"] = dto.New{{ctrlName}}Response(v)
+			} else if ve, ok := err.(*models.LgValidationError); ok {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = ve
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		var reqs []*dto.{{ctrlName}}CreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &reqs); err == nil {
+			vs := make([]*models.{{ctrlName}}, len(reqs))
+			for i, req := range reqs {
+				vs[i] = req.ToModel()
+			}
+			if result, err := models.UpsertMulti{{ctrlName}}(vs, by); err == nil {
+				if !result.Committed {
+					c.Ctx.Output.SetStatus(400)
+				}
+				c.Data["json"] = result
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	}
+	c.ServeJSON()
+}
+`
+)
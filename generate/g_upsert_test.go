@@ -0,0 +1,90 @@
+package generate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func upsertTable(uks ...*UniqueKey) *Table {
+	return &Table{
+		Name:       "user",
+		Pk:         "id",
+		UniqueKeys: uks,
+		Columns: []*Column{
+			{Name: "Id", Type: "int", IsNeed: true, Tag: &OrmTag{Column: "id", Pk: true, Auto: true}},
+			{Name: "Email", Type: "string", IsNeed: true, Tag: &OrmTag{Column: "email"}},
+			{Name: "Name", Type: "string", IsNeed: true, Tag: &OrmTag{Column: "name"}},
+			{Name: "Team", Type: "*Team", IsNeed: true, Tag: &OrmTag{Column: "team_id", RelFk: true}},
+			{Name: "CreatedAt", Type: "time.Time", IsNeed: true, Tag: &OrmTag{Column: "created_at", AutoNowAdd: true}},
+			{Name: "Roles", Type: "[]*Role", IsNeed: true, Tag: &OrmTag{M2M: true}},
+		},
+	}
+}
+
+func TestUpsertSQL(t *testing.T) {
+	tb := upsertTable(&UniqueKey{Name: "uk_email", Columns: []string{"email"}})
+	var cols []*SQLColumn
+	for _, col := range tb.SQLColumns() {
+		if !col.Auto {
+			cols = append(cols, col)
+		}
+	}
+	tests := []struct {
+		dialect string
+		key     []string
+		got     func([]*SQLColumn, []string) string
+		want    string
+	}{
+		{"mysql", []string{"email"}, tb.upsertMysqlSQL,
+			"INSERT INTO `user` (`email`, `name`, `team_id`, `created_at`) VALUES (?, ?, ?, ?) " +
+				"ON DUPLICATE KEY UPDATE `id` = LAST_INSERT_ID(`id`), `name` = VALUES(`name`), `team_id` = VALUES(`team_id`)"},
+		{"postgres", []string{"email"}, tb.upsertPgSQL,
+			`INSERT INTO "user" ("email", "name", "team_id", "created_at") VALUES (?, ?, ?, ?) ` +
+				`ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name", "team_id" = EXCLUDED."team_id" RETURNING "id"`},
+		// 所有列都在唯一键中时PostgreSQL也要DO UPDATE才能返回Id
+		{"postgres", []string{"email", "name", "team_id"}, tb.upsertPgSQL,
+			`INSERT INTO "user" ("email", "name", "team_id", "created_at") VALUES (?, ?, ?, ?) ` +
+				`ON CONFLICT ("email", "name", "team_id") DO UPDATE SET "email" = EXCLUDED."email" RETURNING "id"`},
+	}
+	for _, tt := range tests {
+		if got := tt.got(cols, tt.key); got != tt.want {
+			t.Errorf("%s upsert by %v:\n got %s\nwant %s", tt.dialect, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestUpsertKeys(t *testing.T) {
+	email := &UniqueKey{Name: "uk_email", Columns: []string{"email"}}
+	name := &UniqueKey{Name: "uk_name", Columns: []string{"name"}}
+	phone := &UniqueKey{Name: "uk_phone", Columns: []string{"phone"}}
+
+	noAuto := upsertTable(email)
+	noAuto.Columns[0].Tag = &OrmTag{Column: "id", Pk: true}
+
+	tests := []struct {
+		name     string
+		tb       *Table
+		keys     [][]string
+		skipped  int
+		validate [][]string
+	}{
+		{"single unique key", upsertTable(email), [][]string{{"email"}}, 0, [][]string{{"email"}}},
+		// 与另一个唯一键冲突时MySQL会修改那一行，不生成upsert，但仍校验两个唯一键
+		{"two unique keys", upsertTable(email, name), nil, 1, [][]string{{"email"}, {"name"}}},
+		{"primary key not auto", noAuto, nil, 1, [][]string{{"email"}}},
+		{"column not in the model", upsertTable(phone), nil, 1, nil},
+		{"no unique key", upsertTable(), nil, 0, nil},
+	}
+	for _, tt := range tests {
+		keys, skipped := tt.tb.upsertKeys()
+		if !reflect.DeepEqual(keys, tt.keys) || len(skipped) != tt.skipped {
+			t.Errorf("%s: upsertKeys() = %v, %q, want %v and %d skipped", tt.name, keys, skipped, tt.keys, tt.skipped)
+		}
+		if validate, _ := tt.tb.modelUniqueKeys(); !reflect.DeepEqual(validate, tt.validate) {
+			t.Errorf("%s: modelUniqueKeys() = %v, want %v", tt.name, validate, tt.validate)
+		}
+		if s := tt.tb.UpsertString(); (s != "") != (tt.keys != nil) {
+			t.Errorf("%s: UpsertString() generated %d bytes", tt.name, len(s))
+		}
+	}
+}
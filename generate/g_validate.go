@@ -0,0 +1,302 @@
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"bee/utils"
+)
+
+// writeValidateFiles 按列的长度、是否为空、小数位数、enum/set及唯一键生成model的校验代码
+func writeValidateFiles(tables []*Table, mPath string) {
+	fpath := path.Join(mPath, "lg_validate.go")
+	_ = ioutil.WriteFile(fpath, []byte(ModelLgValidate), 0666)
+	formatGenerated(fpath)
+
+	for _, tb := range tables {
+		if tb.Pk == "" || strings.Contains(tb.Name, "_has_") {
+			continue
+		}
+		modelName := utils.CamelCase(tb.Name)
+		imports, enums, checks := tb.validateColumns()
+		unique := tb.validateUnique()
+		if unique != "" && ORM == "beego" {
+			imports += "\n\"github.com/astaxie/beego/orm\""
+		}
+
+		fileStr := strings.Replace(ValidateTPL, "{{validateImports}}", imports, -1)
+		fileStr = strings.Replace(fileStr, "{{enumValues}}", enums, -1)
+		fileStr = strings.Replace(fileStr, "{{columnChecks}}", checks, -1)
+		fileStr = strings.Replace(fileStr, "{{uniqueChecks}}", unique, -1)
+		fileStr = strings.Replace(fileStr, "{{modelName}}", modelName, -1)
+		writeFormattedFile(path.Join(mPath, getFileName(tb.Name)+"_validate.go"), fileStr)
+	}
+}
+
+// validateColumns 每一列的校验，返回需要的import、enum/set的可选值及校验代码
+func (tb *Table) validateColumns() (imports string, enums string, checks string) {
+	var needUtf8 bool
+	var enumVars, rv []string
+	for _, col := range tb.SQLColumns() {
+		if col.Pk {
+			continue
+		}
+		tag := col.Tag
+		check := ""
+		switch {
+		case col.Rel:
+			if !tag.Null {
+				check = fmt.Sprintf("if m.%s == nil || m.%s.Id == 0 {\n\tve.Add(%q, \"%s is required\")\n}", col.Field, col.Field, col.Field, col.Field)
+			}
+		case len(tag.Enum) > 0:
+			values := make([]string, len(tag.Enum))
+			for i, v := range tag.Enum {
+				values[i] = fmt.Sprintf("%q", v)
+			}
+			varName := "{{modelName}}" + col.Field + "Values"
+			enumVars = append(enumVars, fmt.Sprintf("var %s = []string{%s}", varName, strings.Join(values, ", ")))
+			msg := fmt.Sprintf("\"%s must be one of \" + strings.Join(%s, \", \")", col.Field, varName)
+			if tag.Set {
+				check = fmt.Sprintf("if !lgSetValid(m.%s, %s) {\n\tve.Add(%q, %s)\n}", col.Field, varName, col.Field, msg)
+			} else if tag.Null {
+				check = fmt.Sprintf("if m.%s != \"\" && !lgEnumValid(m.%s, %s) {\n\tve.Add(%q, %s)\n}", col.Field, col.Field, varName, col.Field, msg)
+			} else {
+				check = fmt.Sprintf("if !lgEnumValid(m.%s, %s) {\n\tve.Add(%q, %s)\n}", col.Field, varName, col.Field, msg)
+			}
+		case col.Type == "string" && tag.Size != "":
+			needUtf8 = true
+			check = fmt.Sprintf("if utf8.RuneCountInString(m.%s) > %s {\n\tve.Add(%q, \"%s exceeds %s characters\")\n}", col.Field, tag.Size, col.Field, col.Field, tag.Size)
+		case col.Type == "time.Time":
+			if !tag.Null && !tag.AutoNow && !tag.AutoNowAdd && tag.Default == "" {
+				check = fmt.Sprintf("if m.%s.IsZero() {\n\tve.Add(%q, \"%s is required\")\n}", col.Field, col.Field, col.Field)
+			}
+		case strings.HasPrefix(col.Type, "float") && tag.Decimals != "":
+			check = fmt.Sprintf("if !lgDecimalValid(float64(m.%s), %s, %s) {\n\tve.Add(%q, \"%s must fit decimal(%s,%s)\")\n}",
+				col.Field, tag.Digits, tag.Decimals, col.Field, col.Field, tag.Digits, tag.Decimals)
+		}
+		if check != "" {
+			rv = append(rv, fmt.Sprintf("if lgChecks(fields, %q) {\n%s\n}", col.Field, check))
+		}
+	}
+	if len(enumVars) > 0 {
+		imports += "\"strings\"\n"
+		enums = "// the values allowed by the enum and set columns\n" + strings.Join(enumVars, "\n") + "\n"
+	}
+	if needUtf8 {
+		imports += "\"unicode/utf8\"\n"
+	}
+	return imports, enums, strings.Join(rv, "\n")
+}
+
+// validateUnique 唯一键的校验，只有唯一键的字段都在fields中时才查询
+func (tb *Table) validateUnique() string {
+	keys, _ := tb.modelUniqueKeys()
+	fieldOf := make(map[string]*SQLColumn)
+	for _, col := range tb.SQLColumns() {
+		fieldOf[col.Column] = col
+	}
+	var rv []string
+	for _, key := range keys {
+		var names, quoted, filters, guards, columns, values []string
+		for _, c := range key {
+			col := fieldOf[c]
+			names = append(names, col.Field)
+			quoted = append(quoted, fmt.Sprintf("%q", col.Field))
+			columns = append(columns, fmt.Sprintf("%q", col.Column))
+			if col.Rel {
+				// 关联对象为空时由数据库判断
+				guards = append(guards, fmt.Sprintf("m.%s != nil", col.Field))
+				filters = append(filters, fmt.Sprintf(".Filter(%q, m.%s.Id)", col.Field, col.Field))
+				values = append(values, fmt.Sprintf("m.%s.Id", col.Field))
+			} else {
+				filters = append(filters, fmt.Sprintf(".Filter(%q, m.%s)", col.Field, col.Field))
+				values = append(values, "m."+col.Field)
+			}
+		}
+		cond := fmt.Sprintf("lgChecks(fields, %s)", strings.Join(quoted, ", "))
+		if len(guards) > 0 {
+			cond += " && " + strings.Join(guards, " && ")
+		}
+		msg := names[0] + " already exists"
+		if len(names) > 1 {
+			msg = strings.Join(names, " and ") + " already exist"
+		}
+		if ORM != "beego" {
+			// gorm、sqlx按列查询
+			rv = append(rv, fmt.Sprintf("if %s {\n\tif lgExists(%q, %q, []string{%s}, []interface{}{%s}, m.Id) {\n\t\tve.Add(%q, %q)\n\t}\n}",
+				cond, tb.Name, tb.Pk, strings.Join(columns, ", "), strings.Join(values, ", "), strings.Join(names, ","), msg))
+			continue
+		}
+		rv = append(rv, fmt.Sprintf("if %s {\n\tqs := o.QueryTable(new({{modelName}}))%s\n\tif lgExists(qs, m.Id) {\n\t\tve.Add(%q, %q)\n\t}\n}",
+			cond, strings.Join(filters, ""), strings.Join(names, ","), msg))
+	}
+	if len(rv) == 0 {
+		return ""
+	}
+	if ORM != "beego" {
+		return strings.Join(rv, "\n")
+	}
+	return "o := orm.NewOrm()\n" + strings.Join(rv, "\n")
+}
+
+const (
+	// 校验的公共部分
+	ModelLgValidate = `package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// LgFieldError 字段的校验错误
+type LgFieldError struct {
+	Field   string ` + "`json:\"field\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// LgValidationError 校验错误，controller直接作为json返回
+type LgValidationError struct {
+	Errors []*LgFieldError ` + "`json:\"errors\"`" + `
+}
+
+func (e *LgValidationError) Error() string {
+	var msgs []string
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add 添加一个字段的错误
+func (e *LgValidationError) Add(field string, message string) {
+	e.Errors = append(e.Errors, &LgFieldError{Field: field, Message: message})
+}
+
+// Err 没有错误时返回nil
+func (e *LgValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// LgValidateAll 校验n个对象，字段名及错误信息前加上下标
+func LgValidateAll(n int, validate func(i int) error) error {
+	ve := &LgValidationError{}
+	for i := 0; i < n; i++ {
+		err := validate(i)
+		if err == nil {
+			continue
+		}
+		e, ok := err.(*LgValidationError)
+		if !ok {
+			return err
+		}
+		for _, fe := range e.Errors {
+			ve.Add(fmt.Sprintf("[%d].%s", i, fe.Field), fmt.Sprintf("[%d] %s", i, fe.Message))
+		}
+	}
+	return ve.Err()
+}
+
+// lgChecks fields为空时校验所有字段，否则只校验fields中的字段
+func lgChecks(fields []string, names ...string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	for _, name := range names {
+		found := false
+		for _, f := range fields {
+			if f == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func lgEnumValid(v string, values []string) bool {
+	for _, value := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// lgSetValid set的值为逗号分隔的可选值，可以为空
+func lgSetValid(v string, values []string) bool {
+	if v == "" {
+		return true
+	}
+	for _, item := range strings.Split(v, ",") {
+		if !lgEnumValid(item, values) {
+			return false
+		}
+	}
+	return true
+}
+
+// lgDecimalValid 整数部分不超过digits-decimals位，小数部分不超过decimals位
+func lgDecimalValid(v float64, digits int, decimals int) bool {
+	if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) >= math.Pow10(digits-decimals) {
+		return false
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.Index(s, "."); i >= 0 {
+		return len(s)-i-1 <= decimals
+	}
+	return true
+}
+`
+	// 校验的model模板
+	ValidateTPL = `package models
+
+import (
+	{{validateImports}}
+)
+
+{{enumValues}}
+
+// Validate checks every field of m against its column and the unique keys
+func (m *{{modelName}}) Validate() error {
+	return m.ValidateFields()
+}
+
+// ValidateFields checks fields of m against their columns and the unique keys made
+// up of fields, all fields are checked when fields is empty.
+func (m *{{modelName}}) ValidateFields(fields ...string) error {
+	ve := &LgValidationError{}
+	m.validateColumns(ve, fields)
+	// 列的校验通过后再查询唯一键
+	if len(ve.Errors) == 0 {
+		m.validateUnique(ve, fields)
+	}
+	return ve.Err()
+}
+
+// ValidateColumns checks fields of m against their columns only, without querying
+// the unique keys, all fields are checked when fields is empty.
+func (m *{{modelName}}) ValidateColumns(fields ...string) error {
+	ve := &LgValidationError{}
+	m.validateColumns(ve, fields)
+	return ve.Err()
+}
+
+func (m *{{modelName}}) validateColumns(ve *LgValidationError, fields []string) {
+	{{columnChecks}}
+}
+
+func (m *{{modelName}}) validateUnique(ve *LgValidationError, fields []string) {
+	{{uniqueChecks}}
+}
+`
+)
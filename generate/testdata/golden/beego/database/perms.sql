@@ -0,0 +1,53 @@
+-- Generated by bee g perms
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'delete@/api/profile/', 'delete', '/api/profile/', 'DeleteMulti', '批量删除profile，ids=1,2,3 或 query=k:v,k:v');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'get@/api/profile/', 'get', '/api/profile/', 'GetAll', '搜索profile信息');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'patch@/api/profile/', 'patch', '/api/profile/', 'PatchMulti', '批量修改profile，请求体为带Id的数组，只修改出现的字段');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'post@/api/profile/', 'post', '/api/profile/', 'Post', '新建profile');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'put@/api/profile/', 'put', '/api/profile/', 'PutMulti', '批量替换profile，请求体为带Id的数组，未出现的字段置为零值');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'delete@/api/profile/:id', 'delete', '/api/profile/:id', 'Delete', '删除profile');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'get@/api/profile/:id', 'get', '/api/profile/:id', 'GetOne', '获取profile信息');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'patch@/api/profile/:id', 'patch', '/api/profile/:id', 'Patch', '修改profile');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'put@/api/profile/:id', 'put', '/api/profile/:id', 'Put', '修改profile');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'get@/api/profile/export', 'get', '/api/profile/export', 'Export', '导出profile，format=csv|xlsx，query、fields与GetAll一致，按Id排序');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'post@/api/profile/import', 'post', '/api/profile/import', 'Import', '导入profile，上传字段名为file的csv或xlsx文件，第一行为表头');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('ProfileController', 'patch@/api/profile/m2m/part/:id', 'patch', '/api/profile/m2m/part/:id', 'PatchM2MPart', '修改profile的关系');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'delete@/api/role/', 'delete', '/api/role/', 'DeleteMulti', '批量删除role，ids=1,2,3 或 query=k:v,k:v');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'get@/api/role/', 'get', '/api/role/', 'GetAll', '搜索role信息');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'patch@/api/role/', 'patch', '/api/role/', 'PatchMulti', '批量修改role，请求体为带Id的数组，只修改出现的字段');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'post@/api/role/', 'post', '/api/role/', 'Post', '新建role');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'put@/api/role/', 'put', '/api/role/', 'PutMulti', '批量替换role，请求体为带Id的数组，未出现的字段置为零值');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'delete@/api/role/:id', 'delete', '/api/role/:id', 'Delete', '删除role');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'get@/api/role/:id', 'get', '/api/role/:id', 'GetOne', '获取role信息');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'patch@/api/role/:id', 'patch', '/api/role/:id', 'Patch', '修改role');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'put@/api/role/:id', 'put', '/api/role/:id', 'Put', '修改role');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'get@/api/role/export', 'get', '/api/role/export', 'Export', '导出role，format=csv|xlsx，query、fields与GetAll一致，按Id排序');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'post@/api/role/import', 'post', '/api/role/import', 'Import', '导入role，上传字段名为file的csv或xlsx文件，第一行为表头');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'patch@/api/role/m2m/part/:id', 'patch', '/api/role/m2m/part/:id', 'PatchM2MPart', '修改role的关系');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RoleController', 'put@/api/role/upsert', 'put', '/api/role/upsert', 'Upsert', '按唯一键新增或修改role，by可选 name');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RulePermController', 'post@/api/rule_perm/', 'post', '/api/rule_perm/', 'Post', 'create RulePerm');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('RulePermController', 'get@/api/rule_perm/:id', 'get', '/api/rule_perm/:id', 'GetOne', 'get RulePerm by id');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'delete@/api/team/', 'delete', '/api/team/', 'DeleteMulti', '批量删除team，ids=1,2,3 或 query=k:v,k:v');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'get@/api/team/', 'get', '/api/team/', 'GetAll', '搜索team信息');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'patch@/api/team/', 'patch', '/api/team/', 'PatchMulti', '批量修改team，请求体为带Id的数组，只修改出现的字段');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'post@/api/team/', 'post', '/api/team/', 'Post', '新建team');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'put@/api/team/', 'put', '/api/team/', 'PutMulti', '批量替换team，请求体为带Id的数组，未出现的字段置为零值');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'delete@/api/team/:id', 'delete', '/api/team/:id', 'Delete', '删除team');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'get@/api/team/:id', 'get', '/api/team/:id', 'GetOne', '获取team信息');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'patch@/api/team/:id', 'patch', '/api/team/:id', 'Patch', '修改team');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'put@/api/team/:id', 'put', '/api/team/:id', 'Put', '修改team');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'get@/api/team/export', 'get', '/api/team/export', 'Export', '导出team，format=csv|xlsx，query、fields与GetAll一致，按Id排序');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'post@/api/team/import', 'post', '/api/team/import', 'Import', '导入team，上传字段名为file的csv或xlsx文件，第一行为表头');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('TeamController', 'patch@/api/team/m2m/part/:id', 'patch', '/api/team/m2m/part/:id', 'PatchM2MPart', '修改team的关系');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'delete@/api/user/', 'delete', '/api/user/', 'DeleteMulti', '批量删除user，ids=1,2,3 或 query=k:v,k:v');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'get@/api/user/', 'get', '/api/user/', 'GetAll', '搜索user信息');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'patch@/api/user/', 'patch', '/api/user/', 'PatchMulti', '批量修改user，请求体为带Id的数组，只修改出现的字段');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'post@/api/user/', 'post', '/api/user/', 'Post', '新建user');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'put@/api/user/', 'put', '/api/user/', 'PutMulti', '批量替换user，请求体为带Id的数组，未出现的字段置为零值');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'delete@/api/user/:id', 'delete', '/api/user/:id', 'Delete', '删除user');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'get@/api/user/:id', 'get', '/api/user/:id', 'GetOne', '获取user信息');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'patch@/api/user/:id', 'patch', '/api/user/:id', 'Patch', '修改user');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'put@/api/user/:id', 'put', '/api/user/:id', 'Put', '修改user');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'get@/api/user/export', 'get', '/api/user/export', 'Export', '导出user，format=csv|xlsx，query、fields与GetAll一致，按Id排序');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'post@/api/user/import', 'post', '/api/user/import', 'Import', '导入user，上传字段名为file的csv或xlsx文件，第一行为表头');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'patch@/api/user/m2m/part/:id', 'patch', '/api/user/m2m/part/:id', 'PatchM2MPart', '修改user的关系');
+INSERT INTO rule_perm (controller, perm, verb, url, method, description) VALUES ('UserController', 'put@/api/user/upsert', 'put', '/api/user/upsert', 'Upsert', '按唯一键新增或修改user，by可选 email');
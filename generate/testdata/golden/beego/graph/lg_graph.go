@@ -0,0 +1,341 @@
+package graph
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"app/models"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// Resolver is the root resolver of the queries and mutations of all tables
+type Resolver struct{}
+
+// Schema parses schema.graphql with the root Resolver, it panics if they don't match
+func Schema() *graphql.Schema {
+	return graphql.MustParseSchema(schema, &Resolver{})
+}
+
+// Handler serves the GraphQL queries posted as JSON, e.g. beego.Handler("/graphql", graph.Handler())
+func Handler() http.Handler {
+	return &relay.Handler{Schema: Schema()}
+}
+
+// lgLoads 列表中关系的加载结果，同一个关系只查询一次
+type lgLoads struct {
+	mu    sync.Mutex
+	loads map[string]*lgLoad
+}
+
+type lgLoad struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// load 第一次读取name时调用fn，并发的读取等待同一个结果
+func (l *lgLoads) load(name string, fn func() (interface{}, error)) (interface{}, error) {
+	l.mu.Lock()
+	if l.loads == nil {
+		l.loads = make(map[string]*lgLoad)
+	}
+	ld, ok := l.loads[name]
+	if !ok {
+		ld = &lgLoad{}
+		l.loads[name] = ld
+	}
+	l.mu.Unlock()
+	ld.once.Do(func() { ld.value, ld.err = fn() })
+	return ld.value, ld.err
+}
+
+// lgGraphError carries the code and the invalid fields in the extensions of the GraphQL error
+type lgGraphError struct {
+	error
+	extensions map[string]interface{}
+}
+
+func (e *lgGraphError) Extensions() map[string]interface{} {
+	return e.extensions
+}
+
+// lgError adds the code of the errors of models, validation errors carry the invalid fields
+func lgError(err error) error {
+	var ve *models.LgValidationError
+	if errors.As(err, &ve) {
+		var fields []map[string]string
+		for _, fe := range ve.Errors {
+			fields = append(fields, map[string]string{"field": fe.Field, "message": fe.Message})
+		}
+		return &lgGraphError{err, map[string]interface{}{"code": "BAD_USER_INPUT", "fields": fields}}
+	}
+	if lgNotFound(err) {
+		return &lgGraphError{err, map[string]interface{}{"code": "NOT_FOUND"}}
+	}
+	return err
+}
+
+// lgNotFound 记录是否不存在
+func lgNotFound(err error) bool {
+	return err == orm.ErrNoRows
+}
+
+func lgID(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+func lgInt(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", id)
+	}
+	return n, nil
+}
+
+// lgTime 零值的时间为null
+func lgTime(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: t}
+}
+
+// lgIds 去重后用逗号连接，为query中__in的值
+func lgIds(ids []int) string {
+	seen := make(map[int]bool)
+	var l []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			l = append(l, strconv.Itoa(id))
+		}
+	}
+	return strings.Join(l, ",")
+}
+
+func lgStrings(l *[]string) []string {
+	if l == nil {
+		return nil
+	}
+	return *l
+}
+
+// lgPut 把过滤条件的值v加到query的key中，v为nil时忽略
+func lgPut(q map[string]string, key string, v interface{}) {
+	if reflect.ValueOf(v).IsNil() {
+		return
+	}
+	var l []string
+	switch v := v.(type) {
+	case *string:
+		l = []string{*v}
+	case *int32:
+		l = []string{strconv.FormatInt(int64(*v), 10)}
+	case *float64:
+		l = []string{strconv.FormatFloat(*v, 'f', -1, 64)}
+	case *bool:
+		l = []string{"0"}
+		if *v {
+			l = []string{"1"}
+		}
+	case *graphql.Time:
+		l = []string{v.Time.In(time.Local).Format("2006-01-02 15:04:05")}
+	case *graphql.ID:
+		l = []string{string(*v)}
+	case *[]string:
+		l = *v
+	case *[]int32:
+		for _, n := range *v {
+			l = append(l, strconv.FormatInt(int64(n), 10))
+		}
+	case *[]float64:
+		for _, n := range *v {
+			l = append(l, strconv.FormatFloat(n, 'f', -1, 64))
+		}
+	case *[]graphql.ID:
+		for _, id := range *v {
+			l = append(l, string(id))
+		}
+	}
+	q[key] = strings.Join(l, ",")
+}
+
+type lgStringFilter struct {
+	Exact, Iexact, Contains, Icontains, Startswith, Istartswith, Endswith, Iendswith *string
+	In                                                                               *[]string
+	Isnull                                                                           *bool
+}
+
+// query adds the conditions of f on key to q
+func (f *lgStringFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__iexact", f.Iexact)
+	lgPut(q, key+"__contains", f.Contains)
+	lgPut(q, key+"__icontains", f.Icontains)
+	lgPut(q, key+"__startswith", f.Startswith)
+	lgPut(q, key+"__istartswith", f.Istartswith)
+	lgPut(q, key+"__endswith", f.Endswith)
+	lgPut(q, key+"__iendswith", f.Iendswith)
+	lgPut(q, key+"__in", f.In)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgIntFilter struct {
+	Exact, Gt, Gte, Lt, Lte *int32
+	In                      *[]int32
+	Isnull                  *bool
+}
+
+func (f *lgIntFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__gt", f.Gt)
+	lgPut(q, key+"__gte", f.Gte)
+	lgPut(q, key+"__lt", f.Lt)
+	lgPut(q, key+"__lte", f.Lte)
+	lgPut(q, key+"__in", f.In)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgFloatFilter struct {
+	Exact, Gt, Gte, Lt, Lte *float64
+	In                      *[]float64
+	Isnull                  *bool
+}
+
+func (f *lgFloatFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__gt", f.Gt)
+	lgPut(q, key+"__gte", f.Gte)
+	lgPut(q, key+"__lt", f.Lt)
+	lgPut(q, key+"__lte", f.Lte)
+	lgPut(q, key+"__in", f.In)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgBooleanFilter struct {
+	Exact, Isnull *bool
+}
+
+func (f *lgBooleanFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgTimeFilter struct {
+	Exact, Gt, Gte, Lt, Lte *graphql.Time
+	Isnull                  *bool
+}
+
+func (f *lgTimeFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__gt", f.Gt)
+	lgPut(q, key+"__gte", f.Gte)
+	lgPut(q, key+"__lt", f.Lt)
+	lgPut(q, key+"__lte", f.Lte)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+type lgIDFilter struct {
+	Exact  *graphql.ID
+	In     *[]graphql.ID
+	Isnull *bool
+}
+
+func (f *lgIDFilter) query(q map[string]string, key string) {
+	if f == nil {
+		return
+	}
+	lgPut(q, key+"__exact", f.Exact)
+	lgPut(q, key+"__in", f.In)
+	lgPut(q, key+"__isnull", f.Isnull)
+}
+
+// lgCursor 游标为记录在查询结果中的位置
+func lgCursor(offset int64) string {
+	return base64.StdEncoding.EncodeToString([]byte("cursor:" + strconv.FormatInt(offset, 10)))
+}
+
+// lgOffset 返回after之后的位置，after为nil时为0
+func lgOffset(after *string) (int64, error) {
+	if after == nil {
+		return 0, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(*after)
+	if err == nil && strings.HasPrefix(string(b), "cursor:") {
+		if n, e := strconv.ParseInt(strings.TrimPrefix(string(b), "cursor:"), 10, 64); e == nil && n >= 0 {
+			return n + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor %q", *after)
+}
+
+// lgLimit first为nil时与REST的GetAll相同取10条
+func lgLimit(first *int32) (int64, error) {
+	if first == nil {
+		return 10, nil
+	}
+	if *first <= 0 {
+		return 0, errors.New("first must be positive")
+	}
+	return int64(*first), nil
+}
+
+// lgPageInfo is the page info of n records from offset of total
+type lgPageInfo struct {
+	offset int64
+	n      int
+	total  int64
+}
+
+func (p *lgPageInfo) HasNextPage() bool {
+	return p.offset+int64(p.n) < p.total
+}
+
+func (p *lgPageInfo) HasPreviousPage() bool {
+	return p.offset > 0
+}
+
+func (p *lgPageInfo) StartCursor() *string {
+	if p.n == 0 {
+		return nil
+	}
+	c := lgCursor(p.offset)
+	return &c
+}
+
+func (p *lgPageInfo) EndCursor() *string {
+	if p.n == 0 {
+		return nil
+	}
+	c := lgCursor(p.offset + int64(p.n) - 1)
+	return &c
+}
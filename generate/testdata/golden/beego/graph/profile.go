@@ -0,0 +1,292 @@
+package graph
+
+import (
+	"app/models"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// profileResolver resolves the fields of a Profile
+type profileResolver struct {
+	m     *models.Profile
+	batch *profileBatch
+}
+
+func (r *profileResolver) Id() graphql.ID {
+	return lgID(r.m.Id)
+}
+
+func (r *profileResolver) Bio() string {
+	return r.m.Bio
+}
+
+// User is loaded for all the records of the list with one query
+func (r *profileResolver) User() (*userResolver, error) {
+	if r.m.User == nil {
+		return nil, nil
+	}
+	v, err := r.batch.load("User", func() (interface{}, error) {
+		var ids []int
+		for _, m := range r.batch.ms {
+			if m.User != nil {
+				ids = append(ids, m.User.Id)
+			}
+		}
+		return userGroupsBy("id", ids, func(m *models.User) int { return m.Id })
+	})
+	if err != nil {
+		return nil, lgError(err)
+	}
+	if l := v.(map[int][]*userResolver)[r.m.User.Id]; len(l) > 0 {
+		return l[0], nil
+	}
+	return nil, nil
+}
+
+// profileBatch is the Profiles of a list, a relation is loaded for the whole
+// list the first time it's resolved instead of once per Profile
+type profileBatch struct {
+	lgLoads
+	ms []*models.Profile
+}
+
+// newProfileBatch wraps the Profiles returned by the functions of models
+func newProfileBatch(l []interface{}) *profileBatch {
+	b := &profileBatch{}
+	for _, v := range l {
+		m := v.(models.Profile)
+		b.ms = append(b.ms, &m)
+	}
+	return b
+}
+
+func (b *profileBatch) resolvers() []*profileResolver {
+	rv := make([]*profileResolver, len(b.ms))
+	for i, m := range b.ms {
+		rv[i] = &profileResolver{m: m, batch: b}
+	}
+	return rv
+}
+
+func (b *profileBatch) ids() []int {
+	ids := make([]int, len(b.ms))
+	for i, m := range b.ms {
+		ids[i] = m.Id
+	}
+	return ids
+}
+
+// profileOf resolves a single Profile
+func profileOf(m *models.Profile) *profileResolver {
+	return newProfileBatch([]interface{}{*m}).resolvers()[0]
+}
+
+// profileGroupsBy loads the Profiles whose key is in ids with one query, grouped by group
+func profileGroupsBy(key string, ids []int, group func(m *models.Profile) int) (map[int][]*profileResolver, error) {
+	rv := make(map[int][]*profileResolver)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	l, _, err := models.GetAllProfile(map[string]string{key + "__in": lgIds(ids)}, nil, nil, nil, 0, -1, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range newProfileBatch(l).resolvers() {
+		id := group(v.m)
+		rv[id] = append(rv[id], v)
+	}
+	return rv, nil
+}
+
+// profileConnection is a page of Profiles from offset
+type profileConnection struct {
+	nodes  []*profileResolver
+	offset int64
+	total  int64
+}
+
+func (c *profileConnection) Edges() []*profileEdge {
+	edges := make([]*profileEdge, len(c.nodes))
+	for i, n := range c.nodes {
+		edges[i] = &profileEdge{cursor: lgCursor(c.offset + int64(i)), node: n}
+	}
+	return edges
+}
+
+func (c *profileConnection) Nodes() []*profileResolver {
+	return c.nodes
+}
+
+func (c *profileConnection) PageInfo() *lgPageInfo {
+	return &lgPageInfo{offset: c.offset, n: len(c.nodes), total: c.total}
+}
+
+func (c *profileConnection) TotalCount() int32 {
+	return int32(c.total)
+}
+
+type profileEdge struct {
+	cursor string
+	node   *profileResolver
+}
+
+func (e *profileEdge) Cursor() string {
+	return e.cursor
+}
+
+func (e *profileEdge) Node() *profileResolver {
+	return e.node
+}
+
+// profileFilter is the ProfileFilter input
+type profileFilter struct {
+	Search   *string
+	Dsearch  *string
+	NotEmpty *string
+	Neq      *string
+	Id       *lgIDFilter
+	Bio      *lgStringFilter
+	User     *lgIDFilter
+}
+
+// query converts f to the query of GetAllProfile
+func (f *profileFilter) query() map[string]string {
+	q := make(map[string]string)
+	if f == nil {
+		return q
+	}
+	lgPut(q, "search", f.Search)
+	lgPut(q, "dsearch", f.Dsearch)
+	lgPut(q, "not_empty", f.NotEmpty)
+	lgPut(q, "neq", f.Neq)
+	f.Id.query(q, "id")
+	f.Bio.query(q, "bio")
+	f.User.query(q, "user__id")
+	return q
+}
+
+// profileInput is the ProfileInput input
+type profileInput struct {
+	Bio  *string
+	User *graphql.ID
+}
+
+// apply sets the fields present in the input to m and returns them
+func (in *profileInput) apply(m *models.Profile) (fields []string, err error) {
+	if in.Bio != nil {
+		m.Bio = *in.Bio
+		fields = append(fields, "Bio")
+	}
+	if in.User != nil {
+		id, err := lgInt(*in.User)
+		if err != nil {
+			return nil, err
+		}
+		m.User = &models.User{Id: id}
+		fields = append(fields, "User")
+	}
+	return fields, nil
+}
+
+// Profile gets a Profile by id, null if it doesn't exist
+func (r *Resolver) Profile(args struct{ Id graphql.ID }) (*profileResolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.GetProfileById(id)
+	if lgNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return profileOf(m), nil
+}
+
+// Profiles returns a page of Profiles matching filter after the cursor after
+func (r *Resolver) Profiles(args struct {
+	Filter *profileFilter
+	Sortby *[]string
+	Order  *[]string
+	First  *int32
+	After  *string
+}) (*profileConnection, error) {
+	limit, err := lgLimit(args.First)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := lgOffset(args.After)
+	if err != nil {
+		return nil, err
+	}
+	l, pager, err := models.GetAllProfile(args.Filter.query(), nil, lgStrings(args.Sortby), lgStrings(args.Order), offset, limit, nil, 1)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return &profileConnection{nodes: newProfileBatch(l).resolvers(), offset: offset, total: pager.Page.TotalCount}, nil
+}
+
+// CreateProfile validates the input and adds the Profile together with its relations
+func (r *Resolver) CreateProfile(args struct{ Input profileInput }) (*profileResolver, error) {
+	m := &models.Profile{}
+	if _, err := args.Input.apply(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, lgError(err)
+	}
+	if _, err := models.AddProfileHasMany(m); err != nil {
+		return nil, lgError(err)
+	}
+	m, err := models.GetProfileById(m.Id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return profileOf(m), nil
+}
+
+// UpdateProfile validates and updates the fields present in the input
+func (r *Resolver) UpdateProfile(args struct {
+	Id    graphql.ID
+	Input profileInput
+}) (*profileResolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.GetProfileById(id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	fields, err := args.Input.apply(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		if err := m.ValidateFields(fields...); err != nil {
+			return nil, lgError(err)
+		}
+		if err := models.PatchProfileById(m, fields); err != nil {
+			return nil, lgError(err)
+		}
+	}
+	if m, err = models.GetProfileById(id); err != nil {
+		return nil, lgError(err)
+	}
+	return profileOf(m), nil
+}
+
+// DeleteProfile deletes a Profile by id
+func (r *Resolver) DeleteProfile(args struct{ Id graphql.ID }) (bool, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := models.GetProfileById(id); err != nil {
+		return false, lgError(err)
+	}
+	if err := models.DeleteProfile(id); err != nil {
+		return false, lgError(err)
+	}
+	return true, nil
+}
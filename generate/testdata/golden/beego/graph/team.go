@@ -0,0 +1,274 @@
+package graph
+
+import (
+	"app/models"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// teamResolver resolves the fields of a Team
+type teamResolver struct {
+	m     *models.Team
+	batch *teamBatch
+}
+
+func (r *teamResolver) Id() graphql.ID {
+	return lgID(r.m.Id)
+}
+
+func (r *teamResolver) Name() string {
+	return r.m.Name
+}
+
+// Users is loaded for all the records of the list with one query
+func (r *teamResolver) Users() ([]*userResolver, error) {
+	v, err := r.batch.load("Users", func() (interface{}, error) {
+		return userGroupsBy("team__id", r.batch.ids(), func(m *models.User) int {
+			if m.Team == nil {
+				return 0
+			}
+			return m.Team.Id
+		})
+	})
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return v.(map[int][]*userResolver)[r.m.Id], nil
+}
+
+// teamBatch is the Teams of a list, a relation is loaded for the whole
+// list the first time it's resolved instead of once per Team
+type teamBatch struct {
+	lgLoads
+	ms []*models.Team
+}
+
+// newTeamBatch wraps the Teams returned by the functions of models
+func newTeamBatch(l []interface{}) *teamBatch {
+	b := &teamBatch{}
+	for _, v := range l {
+		m := v.(models.Team)
+		b.ms = append(b.ms, &m)
+	}
+	return b
+}
+
+func (b *teamBatch) resolvers() []*teamResolver {
+	rv := make([]*teamResolver, len(b.ms))
+	for i, m := range b.ms {
+		rv[i] = &teamResolver{m: m, batch: b}
+	}
+	return rv
+}
+
+func (b *teamBatch) ids() []int {
+	ids := make([]int, len(b.ms))
+	for i, m := range b.ms {
+		ids[i] = m.Id
+	}
+	return ids
+}
+
+// teamOf resolves a single Team
+func teamOf(m *models.Team) *teamResolver {
+	return newTeamBatch([]interface{}{*m}).resolvers()[0]
+}
+
+// teamGroupsBy loads the Teams whose key is in ids with one query, grouped by group
+func teamGroupsBy(key string, ids []int, group func(m *models.Team) int) (map[int][]*teamResolver, error) {
+	rv := make(map[int][]*teamResolver)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	l, _, err := models.GetAllTeam(map[string]string{key + "__in": lgIds(ids)}, nil, nil, nil, 0, -1, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range newTeamBatch(l).resolvers() {
+		id := group(v.m)
+		rv[id] = append(rv[id], v)
+	}
+	return rv, nil
+}
+
+// teamConnection is a page of Teams from offset
+type teamConnection struct {
+	nodes  []*teamResolver
+	offset int64
+	total  int64
+}
+
+func (c *teamConnection) Edges() []*teamEdge {
+	edges := make([]*teamEdge, len(c.nodes))
+	for i, n := range c.nodes {
+		edges[i] = &teamEdge{cursor: lgCursor(c.offset + int64(i)), node: n}
+	}
+	return edges
+}
+
+func (c *teamConnection) Nodes() []*teamResolver {
+	return c.nodes
+}
+
+func (c *teamConnection) PageInfo() *lgPageInfo {
+	return &lgPageInfo{offset: c.offset, n: len(c.nodes), total: c.total}
+}
+
+func (c *teamConnection) TotalCount() int32 {
+	return int32(c.total)
+}
+
+type teamEdge struct {
+	cursor string
+	node   *teamResolver
+}
+
+func (e *teamEdge) Cursor() string {
+	return e.cursor
+}
+
+func (e *teamEdge) Node() *teamResolver {
+	return e.node
+}
+
+// teamFilter is the TeamFilter input
+type teamFilter struct {
+	Search   *string
+	Dsearch  *string
+	NotEmpty *string
+	Neq      *string
+	Id       *lgIDFilter
+	Name     *lgStringFilter
+}
+
+// query converts f to the query of GetAllTeam
+func (f *teamFilter) query() map[string]string {
+	q := make(map[string]string)
+	if f == nil {
+		return q
+	}
+	lgPut(q, "search", f.Search)
+	lgPut(q, "dsearch", f.Dsearch)
+	lgPut(q, "not_empty", f.NotEmpty)
+	lgPut(q, "neq", f.Neq)
+	f.Id.query(q, "id")
+	f.Name.query(q, "name")
+	return q
+}
+
+// teamInput is the TeamInput input
+type teamInput struct {
+	Name *string
+}
+
+// apply sets the fields present in the input to m and returns them
+func (in *teamInput) apply(m *models.Team) (fields []string, err error) {
+	if in.Name != nil {
+		m.Name = *in.Name
+		fields = append(fields, "Name")
+	}
+	return fields, nil
+}
+
+// Team gets a Team by id, null if it doesn't exist
+func (r *Resolver) Team(args struct{ Id graphql.ID }) (*teamResolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.GetTeamById(id)
+	if lgNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return teamOf(m), nil
+}
+
+// Teams returns a page of Teams matching filter after the cursor after
+func (r *Resolver) Teams(args struct {
+	Filter *teamFilter
+	Sortby *[]string
+	Order  *[]string
+	First  *int32
+	After  *string
+}) (*teamConnection, error) {
+	limit, err := lgLimit(args.First)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := lgOffset(args.After)
+	if err != nil {
+		return nil, err
+	}
+	l, pager, err := models.GetAllTeam(args.Filter.query(), nil, lgStrings(args.Sortby), lgStrings(args.Order), offset, limit, nil, 1)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return &teamConnection{nodes: newTeamBatch(l).resolvers(), offset: offset, total: pager.Page.TotalCount}, nil
+}
+
+// CreateTeam validates the input and adds the Team together with its relations
+func (r *Resolver) CreateTeam(args struct{ Input teamInput }) (*teamResolver, error) {
+	m := &models.Team{}
+	if _, err := args.Input.apply(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, lgError(err)
+	}
+	if _, err := models.AddTeamHasMany(m); err != nil {
+		return nil, lgError(err)
+	}
+	m, err := models.GetTeamById(m.Id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return teamOf(m), nil
+}
+
+// UpdateTeam validates and updates the fields present in the input
+func (r *Resolver) UpdateTeam(args struct {
+	Id    graphql.ID
+	Input teamInput
+}) (*teamResolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.GetTeamById(id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	fields, err := args.Input.apply(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		if err := m.ValidateFields(fields...); err != nil {
+			return nil, lgError(err)
+		}
+		if err := models.PatchTeamById(m, fields); err != nil {
+			return nil, lgError(err)
+		}
+	}
+	if m, err = models.GetTeamById(id); err != nil {
+		return nil, lgError(err)
+	}
+	return teamOf(m), nil
+}
+
+// DeleteTeam deletes a Team by id
+func (r *Resolver) DeleteTeam(args struct{ Id graphql.ID }) (bool, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := models.GetTeamById(id); err != nil {
+		return false, lgError(err)
+	}
+	if err := models.DeleteTeam(id); err != nil {
+		return false, lgError(err)
+	}
+	return true, nil
+}
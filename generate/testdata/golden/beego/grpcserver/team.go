@@ -0,0 +1,131 @@
+package grpcserver
+
+import (
+	"context"
+
+	"app/models"
+	pb "app/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// TeamServer implements pb.TeamServiceServer by the functions of models
+type TeamServer struct {
+	pb.UnimplementedTeamServiceServer
+}
+
+// teamMaskFields update_mask的路径对应的字段
+var teamMaskFields = map[string]string{
+	"name": "Name",
+}
+
+// teamAllFields update_mask为空时修改的字段
+var teamAllFields = []string{
+	"Name",
+}
+
+// CreateTeam validates in and adds it together with its relations
+func (s *TeamServer) CreateTeam(ctx context.Context, in *pb.Team) (*pb.Team, error) {
+	m := teamFromProto(in)
+	if err := m.Validate(); err != nil {
+		return nil, lgStatus(err)
+	}
+	if _, err := models.AddTeamHasMany(m); err != nil {
+		return nil, lgStatus(err)
+	}
+	return teamToProto(m), nil
+}
+
+// GetTeam gets a Team by id and loads the relations in load
+func (s *TeamServer) GetTeam(ctx context.Context, in *pb.GetRequest) (*pb.Team, error) {
+	m, err := models.GetTeamById(int(in.Id))
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	for _, lo := range in.Load {
+		if _, err := m.LoadRelatedOf(lo); err != nil {
+			return nil, lgStatus(err)
+		}
+	}
+	return teamToProto(m), nil
+}
+
+// ListTeams returns a page of Teams, limit defaults to 10 like the REST GetAll
+func (s *TeamServer) ListTeams(ctx context.Context, in *pb.ListRequest) (*pb.TeamList, error) {
+	limit := in.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	l, pager, err := models.GetAllTeam(in.Query, nil, in.Sortby, in.Order, in.Offset, limit, in.Load, 1)
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	out := &pb.TeamList{Total: pager.Page.TotalCount}
+	for _, v := range l {
+		m := v.(models.Team)
+		out.Items = append(out.Items, teamToProto(&m))
+	}
+	return out, nil
+}
+
+// UpdateTeam updates the fields in update_mask of an existing Team
+func (s *TeamServer) UpdateTeam(ctx context.Context, in *pb.UpdateTeamRequest) (*pb.Team, error) {
+	if in.Team == nil {
+		return nil, status.Error(codes.InvalidArgument, "team is required")
+	}
+	fields, err := lgMaskFields(in.UpdateMask, teamMaskFields, teamAllFields)
+	if err != nil {
+		return nil, err
+	}
+	m := teamFromProto(in.Team)
+	if _, err := models.GetTeamById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := m.ValidateFields(fields...); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.PatchTeamById(m, fields); err != nil {
+		return nil, lgStatus(err)
+	}
+	if m, err = models.GetTeamById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	return teamToProto(m), nil
+}
+
+// DeleteTeam deletes a Team by id
+func (s *TeamServer) DeleteTeam(ctx context.Context, in *pb.DeleteRequest) (*emptypb.Empty, error) {
+	if _, err := models.GetTeamById(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.DeleteTeam(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// teamToProto converts m to a pb.Team, relations are converted when loaded
+func teamToProto(m *models.Team) *pb.Team {
+	p := &pb.Team{
+		Id:   int64(m.Id),
+		Name: m.Name,
+	}
+	for _, v := range m.Users {
+		p.Users = append(p.Users, userToProto(v))
+	}
+	return p
+}
+
+// teamFromProto converts p to a models.Team
+func teamFromProto(p *pb.Team) *models.Team {
+	m := &models.Team{
+		Id:   int(p.Id),
+		Name: p.Name,
+	}
+	for _, v := range p.Users {
+		m.Users = append(m.Users, userFromProto(v))
+	}
+	return m
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lgExportBatch 导出时每批读取的记录数
+const lgExportBatch = 500
+
+// lgTimeLayouts 导入时支持的时间格式
+var lgTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02", time.RFC3339, "2006/01/02 15:04:05", "2006/01/02", "15:04:05"}
+
+func lgParseTime(raw string) (t time.Time, err error) {
+	for _, layout := range lgTimeLayouts {
+		if t, err = time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// lgImportFields 把表头对应到字段，表头可以是字段名或列名，不区分大小写
+func lgImportFields(header []string, fields []string, columns []string) ([]string, error) {
+	var rv []string
+	for _, h := range header {
+		h = strings.TrimSpace(h)
+		field := ""
+		for i := range fields {
+			if strings.EqualFold(h, fields[i]) || strings.EqualFold(h, columns[i]) {
+				field = fields[i]
+				break
+			}
+		}
+		if field == "" {
+			return nil, errors.New("Error: unknown column '" + h + "'")
+		}
+		rv = append(rv, field)
+	}
+	return rv, nil
+}
+
+// lgMissingFields 表头缺少的必须字段，在解析各行之前报告
+func lgMissingFields(fields []string, required []string) error {
+	var missing []string
+	for _, r := range required {
+		found := false
+		for _, f := range fields {
+			found = found || f == r
+		}
+		if !found {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("Error: missing required columns " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// lgImportKeys 检查文件中唯一键的值是否重复，seen为各唯一键已出现的值到行
+type lgImportKeys struct {
+	keys  [][]int
+	seen  []map[string]int
+	names []string
+}
+
+// newLgImportKeys 只检查列都在表头中的唯一键
+func newLgImportKeys(fields []string, uniqueKeys [][]string) *lgImportKeys {
+	k := &lgImportKeys{}
+	for _, key := range uniqueKeys {
+		var cols []int
+		for _, name := range key {
+			for j, f := range fields {
+				if f == name {
+					cols = append(cols, j)
+				}
+			}
+		}
+		if len(cols) == len(key) {
+			k.keys = append(k.keys, cols)
+			k.seen = append(k.seen, make(map[string]int))
+			k.names = append(k.names, strings.Join(key, ", "))
+		}
+	}
+	return k
+}
+
+// check 返回与前面某行唯一键的值相同的错误，否则记录第index行的值
+func (k *lgImportKeys) check(index int, row []string) error {
+	values := make([]string, len(k.keys))
+	for i, cols := range k.keys {
+		var parts []string
+		for _, j := range cols {
+			if j < len(row) {
+				parts = append(parts, strings.TrimSpace(row[j]))
+			} else {
+				parts = append(parts, "")
+			}
+		}
+		values[i] = strings.Join(parts, "\x00")
+		if prev, ok := k.seen[i][values[i]]; ok {
+			return errors.New(k.names[i] + " duplicates row " + strconv.Itoa(prev))
+		}
+	}
+	for i, v := range values {
+		k.seen[i][v] = index
+	}
+	return nil
+}
+
+// lgEmptyRow 整行为空的不导入
+func lgEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
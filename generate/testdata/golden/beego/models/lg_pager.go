@@ -0,0 +1,23 @@
+package models
+
+type LgPage struct {
+	PageNo     int64
+	PageSize   int64
+	TotalPage  int64
+	TotalCount int64
+	FirstPage  bool
+	LastPage   bool
+}
+
+type LgPager struct {
+	Page LgPage
+	List interface{}
+}
+
+func (p *LgPager) PageUtil(count int64, pageNo int64, pageSize int64) LgPage {
+	tp := count / pageSize
+	if count%pageSize > 0 {
+		tp = count/pageSize + 1
+	}
+	return LgPage{PageNo: pageNo, PageSize: pageSize, TotalPage: tp, TotalCount: count, FirstPage: pageNo == 1, LastPage: pageNo == tp}
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ProfileExportFields lists the fields can be exported and imported
+var ProfileExportFields = []string{"Id", "Bio", "User"}
+
+// ProfileExportColumns lists the columns of ProfileExportFields
+var ProfileExportColumns = []string{"id", "bio", "user_id"}
+
+// ProfileImportRequired lists the fields an import file must have
+var ProfileImportRequired = []string{"User"}
+
+// ProfileImportUniqueKeys lists the fields of each unique key, which must not repeat in an import file
+var ProfileImportUniqueKeys = [][]string{}
+
+// ExportProfile reads all Profile matches the same query as GetAllProfile in the
+// order of Id batch by batch, and calls fn with each of them. Each batch starts after the last
+// Id of the previous one, so rows written during the export are neither skipped nor exported twice.
+func ExportProfile(query map[string]string, fn func(m *Profile) error) (err error) {
+	q := make(map[string]string, len(query)+1)
+	for k, v := range query {
+		q[k] = v
+	}
+	for {
+		// 读取所有字段，由ProfileCells取出fields
+		l, _, err := GetAllProfile(q, nil, []string{"Id"}, []string{"asc"}, 0, lgExportBatch, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, item := range l {
+			v := item.(Profile)
+			if err = fn(&v); err != nil {
+				return err
+			}
+			q["Id__gt"] = strconv.Itoa(v.Id)
+		}
+		if len(l) < lgExportBatch {
+			return nil
+		}
+	}
+}
+
+// ProfileCells returns the values of fields of m, relations are exported as their Id
+func ProfileCells(m *Profile, fields []string) []interface{} {
+	cells := make([]interface{}, len(fields))
+	for i, field := range fields {
+		switch field {
+		case "Id":
+			cells[i] = m.Id
+		case "Bio":
+			cells[i] = m.Bio
+		case "User":
+			if m.User != nil {
+				cells[i] = m.User.Id
+			}
+		}
+	}
+	return cells
+}
+
+// setProfileField parses raw as the value of field and checks it against the column
+func setProfileField(m *Profile, field string, raw string) error {
+	switch field {
+	case "Bio":
+		if raw == "" {
+			return nil
+		}
+		m.Bio = raw
+	case "User":
+		if raw == "" {
+			return errors.New("User is required")
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("User must be an Id")
+		}
+		m.User = &User{Id: v}
+	}
+	return nil
+}
+
+// ImportProfile parses rows under header as Profiles and inserts them by
+// AddMultiProfile. Nothing is inserted unless every row is valid. The header must
+// have the required fields, and the values of a unique key must not repeat in rows.
+func ImportProfile(header []string, rows [][]string) (result *LgBatchResult, err error) {
+	fields, err := lgImportFields(header, ProfileExportFields, ProfileExportColumns)
+	if err != nil {
+		return
+	}
+	if err = lgMissingFields(fields, ProfileImportRequired); err != nil {
+		return
+	}
+	keys := newLgImportKeys(fields, ProfileImportUniqueKeys)
+	result = &LgBatchResult{}
+	var ms []*Profile
+	for i, row := range rows {
+		if lgEmptyRow(row) {
+			continue
+		}
+		m := new(Profile)
+		var msgs []string
+		for j, field := range fields {
+			raw := ""
+			if j < len(row) {
+				raw = strings.TrimSpace(row[j])
+			}
+			if e := setProfileField(m, field, raw); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if len(msgs) == 0 {
+			if e := m.ValidateFields(fields...); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if e := keys.check(i, row); e != nil {
+			msgs = append(msgs, e.Error())
+		}
+		var rowErr error
+		if len(msgs) > 0 {
+			rowErr = errors.New(strings.Join(msgs, "; "))
+		}
+		result.Add(i, 0, rowErr)
+		ms = append(ms, m)
+	}
+	if result.Failed > 0 || len(ms) == 0 {
+		return
+	}
+	if _, err = AddMultiProfile(ms); err == nil {
+		result.Committed = true
+	}
+	return
+}
@@ -0,0 +1,52 @@
+// @APIVersion 1.0.0
+// @Title beego Test API
+// @Description beego has a very cool tools to autogenerate documents for your API
+// @Contact astaxie@gmail.com
+// @TermsOfServiceUrl http://beego.me/
+// @License Apache 2.0
+// @LicenseUrl http://www.apache.org/licenses/LICENSE-2.0.html
+package routers
+
+import (
+	"app/controllers"
+
+	"github.com/astaxie/beego"
+)
+
+func init() {
+	ns := beego.NewNamespace("/api",
+
+		beego.NSNamespace("/profile",
+			beego.NSInclude(
+				&controllers.ProfileController{},
+			),
+		),
+
+		beego.NSNamespace("/role",
+			beego.NSInclude(
+				&controllers.RoleController{},
+			),
+		),
+
+		beego.NSNamespace("/team",
+			beego.NSInclude(
+				&controllers.TeamController{},
+			),
+		),
+
+		beego.NSNamespace("/user",
+			beego.NSInclude(
+				&controllers.UserController{},
+			),
+		),
+
+		// posrouter
+
+		beego.NSNamespace("/rule_perm",
+			beego.NSInclude(
+				&controllers.RulePermController{},
+			),
+		),
+	)
+	beego.AddNamespace(ns)
+}
@@ -0,0 +1,436 @@
+package controllers
+
+import (
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/client/httplib"
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	JWT_PUBLIC_KEY                  []byte
+	appkey, appsecret, accessSecret string
+	openApiSign                     bool   = false
+	openJwt                         bool   = false
+	openPerm                        bool   = false
+	CENTER_SERVICE                  string = web.AppConfig.DefaultString("center_service", "")
+)
+
+func init() {
+	appkey = web.AppConfig.DefaultString("Appkey", "")
+	appsecret = web.AppConfig.DefaultString("Appsecret", "")
+	// accessSecret用于签名
+	accessSecret = web.AppConfig.DefaultString("AccessSecret", "")
+	// 三个开关，分别是 API签名验证、JWT合法性验证及解析、路由权限验证
+	openApiSign, _ = web.AppConfig.Bool("open_api_sign")
+	openJwt, _ = web.AppConfig.Bool("open_jwt")
+	openPerm, _ = web.AppConfig.Bool("open_perm")
+	// 当启用JWT时，才读取公钥
+	if openJwt {
+		f, err := os.Open("keys/jwt_public_key.pem")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+
+		fd, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		JWT_PUBLIC_KEY = fd
+	}
+
+	// 拦截器，拦截所有路由
+	web.InsertFilter("/*", web.BeforeExec, FilterRouter, web.WithReturnOnOutput(true), web.WithResetParams(false))
+}
+
+// Ignored FilterToken
+var ignoredTokenRouter = map[string]bool{
+	"post@/api/user/login":       true,
+	"post@/api/user/login/oauth": true,
+}
+
+// Ignored PermRouter
+var ignoredPermRouter = map[string]bool{
+	"post@/api/user/login":         true,
+	"post@/api/user/login/refresh": true,
+	"post@/api/user/login/oauth":   true,
+}
+
+type BaseController struct {
+	web.Controller
+}
+
+// JsonResult 用于返回ajax请求的基类
+type JsonResult struct {
+	Code    int
+	Message string
+}
+
+type JWTInfo struct {
+	Token    string
+	ExpireAt int64
+}
+
+// 返回json结果，并中断
+func (c *BaseController) jsonResult(code int, msg string, data interface{}) {
+	r := &JsonResult{Code: code, Message: msg}
+	c.Ctx.Output.SetStatus(400)
+	c.Data["json"] = map[string]interface{}{"Result": r, "Data": data}
+	c.ServeJSON()
+	c.StopRun()
+}
+
+// 返回json更多结果，并中断
+func (c *BaseController) jsonResultMore(code int, msg string, data interface{}, m interface{}) {
+	r := &JsonResult{Code: code, Message: msg}
+	c.Data["json"] = map[string]interface{}{"Result": r, "Data": data, "More": m}
+	c.ServeJSON()
+	c.StopRun()
+}
+
+// 返回json分页结果，并中断
+func (c *BaseController) jsonResultByPage(code int, msg string, data interface{}, p interface{}) {
+	r := &JsonResult{Code: code, Message: msg}
+	c.Data["json"] = map[string]interface{}{"Result": r, "Data": data, "Page": p}
+	c.ServeJSON()
+	c.StopRun()
+}
+
+// 返回json结果，设置状态码，并中断
+func (c *BaseController) jsonResponse(code int, msg string, data interface{}) {
+	c.Ctx.Output.SetStatus(code)
+	c.Data["json"] = map[string]interface{}{"Data": data, "Msg": msg}
+	c.ServeJSON()
+	c.StopRun()
+}
+
+// 获取请求中的JWT字符串
+func (base *BaseController) GetAccessToken() string {
+	actData := base.Ctx.Input.GetData("JWTToken")
+	act, ok := actData.(string)
+	if ok {
+		return act
+	}
+	return ""
+}
+
+// Parse JWTClaims in Ctx.Data["JWTClaims"]
+func (base *BaseController) ParseClaims() map[string]interface{} {
+	cl := base.Ctx.Input.GetData("JWTClaims")
+	if cl != nil {
+		clmap, ok := cl.(map[string]interface{})
+		if ok {
+			return clmap
+		}
+		return nil
+	}
+	return nil
+}
+
+// Parse JWTClaims in Ctx.Data["JWTClaims"]
+func parseClaims(ctx *context.Context) map[string]interface{} {
+	cl := ctx.Input.GetData("JWTClaims")
+	if cl != nil {
+		clmap, ok := cl.(map[string]interface{})
+		if ok {
+			return clmap
+		}
+		return nil
+	}
+	return nil
+}
+
+// Recover Route
+func RecoverRoute(ctx *context.Context) string {
+	route := strings.Split(ctx.Request.URL.RequestURI(), "?")[0]
+	// 将路径中的参数值替换为参数名
+	for k, v := range ctx.Input.Params() {
+		// 如果参数是 :splat等预定义的，则跳过
+		if k == ":splat" || k == ":path" || k == ":ext" {
+			continue
+		}
+		route = strings.Replace(route, "/"+v, "/"+k, 1)
+	}
+	// 路径格式均为 请求类型@路径
+	route = strings.ToLower(ctx.Request.Method) + "@" + route
+	return route
+}
+
+// 路由拦截器的Filter
+var FilterRouter = func(ctx *context.Context) {
+	if openApiSign {
+		signOk := VerifySign(ctx)
+		if !signOk {
+			return
+		}
+	}
+	if openJwt {
+		// 路径格式均为 请求类型@路径
+		route := RecoverRoute(ctx)
+		// 直接通过map查询是否忽略，RouteManifest由bee按控制器注释中的@IgnoredToken生成
+		if _, ok := ignoredTokenRouter[route]; ok || RouteManifest[route].IgnoredToken {
+			return
+		}
+		// 验证JWT是否有效
+		jwtOk := VerifyToken(ctx)
+		if jwtOk {
+			if openPerm {
+				// 直接通过map查询是否忽略
+				if _, ok := ignoredPermRouter[route]; ok || RouteManifest[route].IgnoredPerm {
+					return
+				}
+				// todo:或通过缓存查询是否已有权限查询记录
+				// 如果没有，向聚合平台查询，并缓存
+				permOk := VerifyPerm(route, ctx)
+				if permOk {
+					return
+				}
+			}
+		} else {
+			return
+		}
+	}
+}
+
+func VerifyToken(ctx *context.Context) bool {
+	authString := ctx.Input.Header("Authorization")
+	kv := strings.Split(authString, " ")
+	if len(kv) != 2 || kv[0] != "Bearer" {
+		logs.Error("Authorization格式不对或Token为空！")
+		http.Error(ctx.ResponseWriter, "Authorization格式不对或Token为空！", http.StatusUnauthorized)
+		return false
+	}
+	tokenString := kv[1]
+	ctx.Input.SetData("JWTToken", tokenString)
+
+	// Parse token
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// 必要的验证 RS256
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		//// 可选项验证  'aud' claim
+		//aud := "https://api.cn.atomintl.com"
+		//checkAud := token.Claims.(jwt.MapClaims).VerifyAudience(aud, false)
+		//if !checkAud {
+		//  return token, errors.New("Invalid audience.")
+		//}
+		// 必要的验证 'iss' claim
+		iss := "https://atomintl.auth0.com/"
+		checkIss := token.Claims.(jwt.MapClaims).VerifyIssuer(iss, false)
+		if !checkIss {
+			return token, errors.New("Invalid issuer.")
+		}
+
+		result, _ := jwt.ParseRSAPublicKeyFromPEM(JWT_PUBLIC_KEY)
+		//result := []byte(cert) // 不是正确的 PUBKEY 格式 都会 报  key is of invalid type
+		return result, nil
+	})
+	if err != nil {
+		logs.Error("Parse token error:", err)
+		if ve, ok := err.(*jwt.ValidationError); ok {
+			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
+				// That's not even a token
+				http.Error(ctx.ResponseWriter, "Token 格式有误！", http.StatusUnauthorized)
+				return false
+			} else if ve.Errors&(jwt.ValidationErrorExpired|jwt.ValidationErrorNotValidYet) != 0 {
+				// Token is either expired or not active yet
+				http.Error(ctx.ResponseWriter, "Token 已过期！", http.StatusUnauthorized)
+				return false
+			} else {
+				// Couldn't handle this token
+				http.Error(ctx.ResponseWriter, "验证Token的过程中发生其他错误！", http.StatusUnauthorized)
+				return false
+			}
+		} else {
+			// Couldn't handle this token
+			http.Error(ctx.ResponseWriter, "无法处理此Token！", http.StatusUnauthorized)
+			return false
+		}
+	}
+	if !token.Valid {
+		logs.Error("Token invalid:", tokenString)
+		http.Error(ctx.ResponseWriter, "Token 不合法:"+tokenString, http.StatusUnauthorized)
+		return false
+	}
+	// logs.Debug("Token:", token)
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		logs.Error("转换为jwt.MapClaims失败")
+		return false
+	}
+	var claimsMIF = make(map[string]interface{})
+	jsonM, _ := json.Marshal(&claims)
+	json.Unmarshal(jsonM, &claimsMIF)
+	ctx.Input.SetData("JWTClaims", claimsMIF)
+	return true
+}
+
+func VerifyPerm(route string, ctx *context.Context) bool {
+	var subT, subV string
+	cls := parseClaims(ctx)
+	if cls != nil {
+		subT = cls["sub_type"].(string)
+		subV = cls["sub_value"].(string)
+	}
+	if subT == "" {
+		http.Error(ctx.ResponseWriter, "JWT中的SubType不能为空！", http.StatusUnauthorized)
+		return false
+	}
+	v := &struct {
+		SubType  string
+		SubValue string
+		Perm     string
+		Ops      string
+		AppId    string
+	}{}
+	v.SubType = subT
+	v.SubValue = subV
+	v.Perm = route
+	v.Ops = "999"
+	req := httplib.Post(CENTER_SERVICE + "/rule_perm/check")
+	req.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	jsonM, _ := json.Marshal(v)
+	req.Body(jsonM)
+	resp, err := req.Response()
+	if err == nil {
+		if resp.StatusCode == 200 {
+			return true
+		} else {
+			http.Error(ctx.ResponseWriter, "没有权限！", http.StatusUnauthorized)
+			return false
+		}
+	} else {
+		http.Error(ctx.ResponseWriter, "请求权限检查出错！", http.StatusUnauthorized)
+		return false
+	}
+}
+
+// 验证签名
+func VerifySign(c *context.Context) bool {
+	_ = c.Request.ParseForm()
+	req := c.Request.Form
+	// bd := c.Input.CopyBody(1048576)
+	bd := c.Input.RequestBody
+	var app_key, sn, ts string
+
+	if v := c.Request.FormValue("app_key"); v != "" {
+		app_key = v
+	}
+	if v := c.Request.FormValue("sn"); v != "" {
+		sn = v
+	}
+	if v := c.Request.FormValue("ts"); v != "" {
+		ts = v
+	}
+
+	// 判断app_key
+	if app_key == "" || app_key != appkey {
+		http.Error(c.ResponseWriter, "app_key错误，请核对提交应用key!", http.StatusForbidden)
+		return false
+	}
+
+	// 验证过期时间
+	timestamp := time.Now().Unix()
+	exp := int64(600)
+	tsInt, _ := strconv.ParseInt(ts, 10, 64)
+	if tsInt > timestamp || timestamp-tsInt >= exp {
+		http.Error(c.ResponseWriter, "ts错误，请求已过期!", http.StatusForbidden)
+		return false
+	}
+
+	logs.Debug("调试sign值：", createSignMD5(req, bd, accessSecret))
+	// 验证签名
+	if sn == "" || sn != createSignMD5(req, bd, accessSecret) {
+		http.Error(c.ResponseWriter, "sn错误，请核对签名!", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (base *BaseController) GetAppRoleToken(roleCode, majorParms string) (*JWTInfo, error) {
+	var rjwt JWTInfo
+	v := &struct {
+		RoleCode   string
+		AppId      string
+		MajorParms string
+	}{}
+	v.AppId = appkey
+	v.RoleCode = roleCode
+	v.MajorParms = majorParms
+	req := httplib.Post(CENTER_SERVICE + "/rule_auth/login/app_role")
+	req.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	jsonM, _ := json.Marshal(v)
+	req.Body(jsonM)
+	resp, err := req.Response()
+	if err == nil {
+		if resp.StatusCode == 200 {
+			err := req.ToJSON(&rjwt)
+			if err != nil {
+				return nil, err
+			}
+			return &rjwt, nil
+		} else {
+			msgResult, _ := req.String()
+			return nil, errors.New(msgResult)
+		}
+	} else {
+		return nil, err
+	}
+}
+
+// 创建MD5签名
+func createSignMD5(params url.Values, body []byte, AS string) string {
+	// 自定义 MD5 组合
+	return EncodeStrMd5(AS + createEncryptStr(params) + EncodeByteMd5(body) + AS)
+}
+
+func createEncryptStr(params url.Values) string {
+	var key []string
+	var str = ""
+	for k := range params {
+		if k != "sn" && k != "debug" {
+			key = append(key, k)
+		}
+	}
+	sort.Strings(key)
+	for i := 0; i < len(key); i++ {
+		if i == 0 {
+			str = fmt.Sprintf("%v=%v", key[i], params.Get(key[i]))
+		} else {
+			str = str + fmt.Sprintf("&%v=%v", key[i], params.Get(key[i]))
+		}
+	}
+	return str
+}
+
+// 由于使用bee生成，简单加密避免引入过多包，直接在这定义
+// Encode string to md5 hex value
+func EncodeStrMd5(str string) string {
+	m := md5.New()
+	m.Write([]byte(str))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func EncodeByteMd5(b []byte) string {
+	m := md5.New()
+	m.Write(b)
+	return hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// lgSheetWriter 导出时逐行写入，csv直接写入响应，xlsx在Close时写入
+type lgSheetWriter interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+// newLgSheetWriter 按format创建导出的writer，并设置下载的响应头
+func newLgSheetWriter(ctx *context.Context, format string, name string) (lgSheetWriter, error) {
+	filename := name + "_" + time.Now().Format("20060102150405")
+	switch format {
+	case "", "csv":
+		ctx.Output.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Output.Header("Content-Disposition", "attachment; filename="+filename+".csv")
+		// 写入BOM，Excel才能识别utf-8
+		if _, err := ctx.ResponseWriter.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+		return &lgCSVWriter{w: csv.NewWriter(ctx.ResponseWriter)}, nil
+	case "xlsx":
+		f := excelize.NewFile()
+		sw, err := f.NewStreamWriter("Sheet1")
+		if err != nil {
+			return nil, err
+		}
+		ctx.Output.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		ctx.Output.Header("Content-Disposition", "attachment; filename="+filename+".xlsx")
+		return &lgXLSXWriter{f: f, sw: sw, w: ctx.ResponseWriter}, nil
+	}
+	return nil, errors.New("Error: Invalid format. Must be either [csv|xlsx]")
+}
+
+type lgCSVWriter struct {
+	w *csv.Writer
+}
+
+func (l *lgCSVWriter) Write(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = lgCellString(v)
+	}
+	return l.w.Write(record)
+}
+
+func (l *lgCSVWriter) Close() error {
+	l.w.Flush()
+	return l.w.Error()
+}
+
+type lgXLSXWriter struct {
+	f   *excelize.File
+	sw  *excelize.StreamWriter
+	w   io.Writer
+	row int
+}
+
+func (l *lgXLSXWriter) Write(row []interface{}) error {
+	l.row++
+	cell, err := excelize.CoordinatesToCellName(1, l.row)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		if t, ok := v.(time.Time); ok {
+			values[i] = lgCellString(t)
+		} else {
+			values[i] = v
+		}
+	}
+	return l.sw.SetRow(cell, values)
+}
+
+func (l *lgXLSXWriter) Close() error {
+	if err := l.sw.Flush(); err != nil {
+		return err
+	}
+	return l.f.Write(l.w)
+}
+
+// lgCellString 单元格的文本，零值时间为空
+func lgCellString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(v)
+}
+
+func lgContains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// readLgSheet 读取上传的csv或xlsx文件，返回表头和数据行
+func readLgSheet(r *http.Request, key string) (header []string, rows [][]string, err error) {
+	file, fh, err := r.FormFile(key)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var records [][]string
+	if strings.HasSuffix(strings.ToLower(fh.Filename), ".xlsx") {
+		var f *excelize.File
+		if f, err = excelize.OpenReader(file); err != nil {
+			return
+		}
+		if records, err = f.GetRows(f.GetSheetList()[0]); err != nil {
+			return
+		}
+	} else {
+		cr := csv.NewReader(file)
+		cr.FieldsPerRecord = -1
+		if records, err = cr.ReadAll(); err != nil {
+			return
+		}
+	}
+	if len(records) == 0 {
+		return nil, nil, errors.New("Error: empty file")
+	}
+	header = records[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\xEF\xBB\xBF")
+	}
+	return header, records[1:], nil
+}
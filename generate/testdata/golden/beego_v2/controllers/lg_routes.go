@@ -0,0 +1,68 @@
+// Code generated by bee from routers/router.go and the controller comments. DO NOT EDIT.
+
+package controllers
+
+// RouteInfo 路由对应的方法及鉴权要求
+type RouteInfo struct {
+	Controller   string
+	Method       string
+	Description  string
+	IgnoredToken bool
+	IgnoredPerm  bool
+}
+
+// RouteManifest 请求类型@路径 => 路由，方法注释中有@IgnoredToken、@IgnoredPerm时跳过JWT、权限验证
+var RouteManifest = map[string]RouteInfo{
+	"delete@/api/profile/":            {Controller: "ProfileController", Method: "DeleteMulti", Description: "批量删除profile，ids=1,2,3 或 query=k:v,k:v", IgnoredToken: false, IgnoredPerm: false},
+	"delete@/api/profile/:id":         {Controller: "ProfileController", Method: "Delete", Description: "删除profile", IgnoredToken: false, IgnoredPerm: false},
+	"delete@/api/role/":               {Controller: "RoleController", Method: "DeleteMulti", Description: "批量删除role，ids=1,2,3 或 query=k:v,k:v", IgnoredToken: false, IgnoredPerm: false},
+	"delete@/api/role/:id":            {Controller: "RoleController", Method: "Delete", Description: "删除role", IgnoredToken: false, IgnoredPerm: false},
+	"delete@/api/team/":               {Controller: "TeamController", Method: "DeleteMulti", Description: "批量删除team，ids=1,2,3 或 query=k:v,k:v", IgnoredToken: false, IgnoredPerm: false},
+	"delete@/api/team/:id":            {Controller: "TeamController", Method: "Delete", Description: "删除team", IgnoredToken: false, IgnoredPerm: false},
+	"delete@/api/user/":               {Controller: "UserController", Method: "DeleteMulti", Description: "批量删除user，ids=1,2,3 或 query=k:v,k:v", IgnoredToken: false, IgnoredPerm: false},
+	"delete@/api/user/:id":            {Controller: "UserController", Method: "Delete", Description: "删除user", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/profile/":               {Controller: "ProfileController", Method: "GetAll", Description: "搜索profile信息", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/profile/:id":            {Controller: "ProfileController", Method: "GetOne", Description: "获取profile信息", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/profile/export":         {Controller: "ProfileController", Method: "Export", Description: "导出profile，format=csv|xlsx，query、fields与GetAll一致，按Id排序", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/role/":                  {Controller: "RoleController", Method: "GetAll", Description: "搜索role信息", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/role/:id":               {Controller: "RoleController", Method: "GetOne", Description: "获取role信息", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/role/export":            {Controller: "RoleController", Method: "Export", Description: "导出role，format=csv|xlsx，query、fields与GetAll一致，按Id排序", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/rule_perm/:id":          {Controller: "RulePermController", Method: "GetOne", Description: "get RulePerm by id", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/team/":                  {Controller: "TeamController", Method: "GetAll", Description: "搜索team信息", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/team/:id":               {Controller: "TeamController", Method: "GetOne", Description: "获取team信息", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/team/export":            {Controller: "TeamController", Method: "Export", Description: "导出team，format=csv|xlsx，query、fields与GetAll一致，按Id排序", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/user/":                  {Controller: "UserController", Method: "GetAll", Description: "搜索user信息", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/user/:id":               {Controller: "UserController", Method: "GetOne", Description: "获取user信息", IgnoredToken: false, IgnoredPerm: false},
+	"get@/api/user/export":            {Controller: "UserController", Method: "Export", Description: "导出user，format=csv|xlsx，query、fields与GetAll一致，按Id排序", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/profile/":             {Controller: "ProfileController", Method: "PatchMulti", Description: "批量修改profile，请求体为带Id的数组，只修改出现的字段", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/profile/:id":          {Controller: "ProfileController", Method: "Patch", Description: "修改profile", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/profile/m2m/part/:id": {Controller: "ProfileController", Method: "PatchM2MPart", Description: "修改profile的关系", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/role/":                {Controller: "RoleController", Method: "PatchMulti", Description: "批量修改role，请求体为带Id的数组，只修改出现的字段", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/role/:id":             {Controller: "RoleController", Method: "Patch", Description: "修改role", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/role/m2m/part/:id":    {Controller: "RoleController", Method: "PatchM2MPart", Description: "修改role的关系", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/team/":                {Controller: "TeamController", Method: "PatchMulti", Description: "批量修改team，请求体为带Id的数组，只修改出现的字段", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/team/:id":             {Controller: "TeamController", Method: "Patch", Description: "修改team", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/team/m2m/part/:id":    {Controller: "TeamController", Method: "PatchM2MPart", Description: "修改team的关系", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/user/":                {Controller: "UserController", Method: "PatchMulti", Description: "批量修改user，请求体为带Id的数组，只修改出现的字段", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/user/:id":             {Controller: "UserController", Method: "Patch", Description: "修改user", IgnoredToken: false, IgnoredPerm: false},
+	"patch@/api/user/m2m/part/:id":    {Controller: "UserController", Method: "PatchM2MPart", Description: "修改user的关系", IgnoredToken: false, IgnoredPerm: false},
+	"post@/api/profile/":              {Controller: "ProfileController", Method: "Post", Description: "新建profile", IgnoredToken: false, IgnoredPerm: false},
+	"post@/api/profile/import":        {Controller: "ProfileController", Method: "Import", Description: "导入profile，上传字段名为file的csv或xlsx文件，第一行为表头", IgnoredToken: false, IgnoredPerm: false},
+	"post@/api/role/":                 {Controller: "RoleController", Method: "Post", Description: "新建role", IgnoredToken: false, IgnoredPerm: false},
+	"post@/api/role/import":           {Controller: "RoleController", Method: "Import", Description: "导入role，上传字段名为file的csv或xlsx文件，第一行为表头", IgnoredToken: false, IgnoredPerm: false},
+	"post@/api/rule_perm/":            {Controller: "RulePermController", Method: "Post", Description: "create RulePerm", IgnoredToken: false, IgnoredPerm: false},
+	"post@/api/team/":                 {Controller: "TeamController", Method: "Post", Description: "新建team", IgnoredToken: false, IgnoredPerm: false},
+	"post@/api/team/import":           {Controller: "TeamController", Method: "Import", Description: "导入team，上传字段名为file的csv或xlsx文件，第一行为表头", IgnoredToken: false, IgnoredPerm: false},
+	"post@/api/user/":                 {Controller: "UserController", Method: "Post", Description: "新建user", IgnoredToken: false, IgnoredPerm: false},
+	"post@/api/user/import":           {Controller: "UserController", Method: "Import", Description: "导入user，上传字段名为file的csv或xlsx文件，第一行为表头", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/profile/":               {Controller: "ProfileController", Method: "PutMulti", Description: "批量替换profile，请求体为带Id的数组，未出现的字段置为零值", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/profile/:id":            {Controller: "ProfileController", Method: "Put", Description: "修改profile", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/role/":                  {Controller: "RoleController", Method: "PutMulti", Description: "批量替换role，请求体为带Id的数组，未出现的字段置为零值", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/role/:id":               {Controller: "RoleController", Method: "Put", Description: "修改role", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/role/upsert":            {Controller: "RoleController", Method: "Upsert", Description: "按唯一键新增或修改role，by可选 name", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/team/":                  {Controller: "TeamController", Method: "PutMulti", Description: "批量替换team，请求体为带Id的数组，未出现的字段置为零值", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/team/:id":               {Controller: "TeamController", Method: "Put", Description: "修改team", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/user/":                  {Controller: "UserController", Method: "PutMulti", Description: "批量替换user，请求体为带Id的数组，未出现的字段置为零值", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/user/:id":               {Controller: "UserController", Method: "Put", Description: "修改user", IgnoredToken: false, IgnoredPerm: false},
+	"put@/api/user/upsert":            {Controller: "UserController", Method: "Upsert", Description: "按唯一键新增或修改user，by可选 email", IgnoredToken: false, IgnoredPerm: false},
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"app/models"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// @Description 导出team，format=csv|xlsx，query、fields与GetAll一致，按Id排序
+// @router /export [get]
+func (c *TeamController) Export() {
+	fields := models.TeamExportFields
+
+	query, err := c.parseQuery()
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+		c.ServeJSON()
+		return
+	}
+	// fields: col1,col2
+	if v := c.GetString("fields"); v != "" {
+		fields = strings.Split(v, ",")
+		for _, f := range fields {
+			if !lgContains(models.TeamExportFields, f) {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = "Error: invalid field " + f
+				c.ServeJSON()
+				return
+			}
+		}
+	}
+
+	w, err := newLgSheetWriter(c.Ctx, c.GetString("format"), "team")
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+		c.ServeJSON()
+		return
+	}
+	header := make([]interface{}, len(fields))
+	for i, f := range fields {
+		header[i] = f
+	}
+	if err = w.Write(header); err == nil {
+		err = models.ExportTeam(query, func(m *models.Team) error {
+			return w.Write(models.TeamCells(m, fields))
+		})
+	}
+	if e := w.Close(); err == nil {
+		err = e
+	}
+	// 响应已经开始写入，只能记录错误
+	if err != nil {
+		logs.Error("Export team failed:", err)
+	}
+}
+
+// @Description 导入team，上传字段名为file的csv或xlsx文件，第一行为表头
+// @router /import [post]
+func (c *TeamController) Import() {
+	// pos101
+	header, rows, err := readLgSheet(c.Ctx.Request, "file")
+	if err == nil {
+		// pos102
+		if result, err := models.ImportTeam(header, rows); err == nil {
+			// pos103
+			if !result.Committed {
+				c.Ctx.Output.SetStatus(400)
+			}
+			c.Data["json"] = result
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
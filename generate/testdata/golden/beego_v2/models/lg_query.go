@@ -0,0 +1,136 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// LgQueryCond 把query参数转换为orm的查询条件，没有条件时返回nil
+//
+//	not_empty:column                       非空
+//	search:column1>value1|column2>value2   模糊或搜索，多组之间用^分隔
+//	dsearch:column1>value1|column2>value2  精确或搜索，多组之间用^分隔
+//	neq:column1>value1                     不等于
+//	column__isnull:true                    是否为空
+//	column__in:value1,value2               在列表中，值用逗号分隔
+//	column__op:value                       其它orm支持的表达式
+func LgQueryCond(query map[string]string) *orm.Condition {
+	if len(query) == 0 {
+		return nil
+	}
+	cond := orm.NewCondition()
+	search_arr_str := ""
+	dsearch_arr_str := ""
+	var co_arr []*orm.Condition
+	for k, v := range query {
+		v = strings.Replace(v, ".", "__", -1)
+		switch k {
+		case "not_empty":
+			cond1 := cond.And(v+"__isnull", false).AndNot(v, "")
+			co_arr = append(co_arr, cond1)
+		case "search":
+			search_arr_str = v
+		case "dsearch":
+			dsearch_arr_str = v
+		case "neq":
+			filed := strings.Split(v, ">")
+			if len(filed) == 2 {
+				filed[0] = strings.Replace(filed[0], ".", "__", -1)
+				cond1 := cond.AndNot(filed[0], filed[1])
+				co_arr = append(co_arr, cond1)
+			}
+		default:
+			k = strings.Replace(k, ".", "__", -1)
+			if strings.Contains(k, "isnull") {
+				cond1 := cond.And(k, (v == "true" || v == "1"))
+				co_arr = append(co_arr, cond1)
+			} else if strings.HasSuffix(k, "__in") {
+				cond1 := cond.And(k, strings.Split(v, ","))
+				co_arr = append(co_arr, cond1)
+			} else {
+				cond2 := cond.And(k, v)
+				co_arr = append(co_arr, cond2)
+			}
+		}
+	}
+	co_arr = append(co_arr, lgSearchConds(cond, search_arr_str, "__contains")...)
+	co_arr = append(co_arr, lgSearchConds(cond, dsearch_arr_str, "")...)
+
+	var co2 *orm.Condition
+	for _, item := range co_arr {
+		if co2 == nil {
+			co2 = cond.AndCond(item)
+		} else {
+			co2 = co2.AndCond(item)
+		}
+	}
+	return co2
+}
+
+// LgOrderBy 把sortby和order转换为orm的排序字段，order只有一个时用于所有sortby
+func LgOrderBy(sortby []string, order []string) (sortFields []string, err error) {
+	if len(sortby) != 0 {
+		if len(sortby) == len(order) {
+			// 1) for each sort field, there is an associated order
+			for i, v := range sortby {
+				orderby := ""
+				if order[i] == "desc" {
+					orderby = "-" + v
+				} else if order[i] == "asc" {
+					orderby = v
+				} else {
+					return nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
+				}
+				sortFields = append(sortFields, orderby)
+			}
+		} else if len(order) == 1 {
+			// 2) there is exactly one order, all the sorted fields will be sorted by this order
+			for _, v := range sortby {
+				orderby := ""
+				if order[0] == "desc" {
+					orderby = "-" + v
+				} else if order[0] == "asc" {
+					orderby = v
+				} else {
+					return nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
+				}
+				sortFields = append(sortFields, orderby)
+			}
+		} else {
+			return nil, errors.New("Error: 'sortby', 'order' sizes mismatch or 'order' size is not 1")
+		}
+	} else {
+		if len(order) != 0 {
+			return nil, errors.New("Error: unused 'order' fields")
+		}
+	}
+	return
+}
+
+// lgSearchConds 解析 column1>value1|column2>value2^column3>value3，每组内为或，组之间为与
+func lgSearchConds(cond *orm.Condition, str string, op string) (co_arr []*orm.Condition) {
+	if str == "" {
+		return
+	}
+	for _, v := range strings.Split(str, "^") {
+		var co1 *orm.Condition
+		for _, item := range strings.Split(v, "|") {
+			filed := strings.Split(item, ">")
+			if len(filed) == 2 {
+				key := filed[0] + op
+				value := filed[1]
+				if co1 == nil {
+					co1 = cond.And(key, value)
+				} else {
+					co1 = co1.Or(key, value)
+				}
+			}
+		}
+		if co1 != nil {
+			co_arr = append(co_arr, co1)
+		}
+	}
+	return
+}
@@ -0,0 +1,40 @@
+package models
+
+import ()
+
+// Validate checks every field of m against its column and the unique keys
+func (m *Profile) Validate() error {
+	return m.ValidateFields()
+}
+
+// ValidateFields checks fields of m against their columns and the unique keys made
+// up of fields, all fields are checked when fields is empty.
+func (m *Profile) ValidateFields(fields ...string) error {
+	ve := &LgValidationError{}
+	m.validateColumns(ve, fields)
+	// 列的校验通过后再查询唯一键
+	if len(ve.Errors) == 0 {
+		m.validateUnique(ve, fields)
+	}
+	return ve.Err()
+}
+
+// ValidateColumns checks fields of m against their columns only, without querying
+// the unique keys, all fields are checked when fields is empty.
+func (m *Profile) ValidateColumns(fields ...string) error {
+	ve := &LgValidationError{}
+	m.validateColumns(ve, fields)
+	return ve.Err()
+}
+
+func (m *Profile) validateColumns(ve *LgValidationError, fields []string) {
+	if lgChecks(fields, "User") {
+		if m.User == nil || m.User.Id == 0 {
+			ve.Add("User", "User is required")
+		}
+	}
+}
+
+func (m *Profile) validateUnique(ve *LgValidationError, fields []string) {
+
+}
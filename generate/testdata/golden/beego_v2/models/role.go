@@ -0,0 +1,510 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/core/utils"
+)
+
+type Role struct {
+	Id    int     `orm:"column(id);auto;pk"`
+	Name  string  `orm:"column(name);size(32)"`
+	Users []*User `orm:"null;rel(m2m);rel_table(user_has_role)" description:"与[user] 多对多 关系，中间表是：user_has_role"`
+}
+
+func (t *Role) TableName() string {
+	return "role"
+}
+
+func init() {
+	orm.RegisterModel(new(Role))
+}
+
+func (t *Role) LoadRelatedOf(r string, args ...utils.KV) (int64, error) {
+	o := orm.NewOrm()
+	num, err := o.LoadRelated(t, r, args...)
+	return num, err
+}
+
+// AddRole insert a new Role into database and returns
+// last inserted Id on success.
+func AddRole(m *Role) (id int64, err error) {
+	o := orm.NewOrm()
+	id, err = o.Insert(m)
+	return
+}
+
+// AddMultiRole insert multi Roles into database and returns
+// sum success nums.
+func AddMultiRole(ms []*Role) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return
+	}
+	// 分批插入，在同一个事务中
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	successNums, err = o.InsertMulti(100, ms)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	err = o.Commit()
+	return
+}
+
+// AddRoleHasMany insert a new Role and some items into database and returns
+// last inserted Id on success.
+func AddRoleHasMany(m *Role) (id int64, err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	id, err = o.Insert(m)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	m.Id = int(id)
+
+	// every_rl
+	// m2m_add
+	if m.Users != nil {
+		if len(m.Users) != 0 {
+			m2m := o.QueryM2M(m, "Users")
+			_, err = m2m.Add(m.Users)
+			if err != nil {
+				o.Rollback()
+				return
+			}
+		}
+	}
+
+	o.Commit()
+	return
+}
+
+// GetRoleById retrieves Role by Id. Returns error if
+// Id doesn't exist
+func GetRoleById(id int) (v *Role, err error) {
+	o := orm.NewOrm()
+	v = &Role{Id: id}
+	if err = o.Read(v); err == nil {
+		return v, nil
+	}
+	return nil, err
+}
+
+// GetRoleCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetRoleCounts(query map[string]string) (count int64, err error) {
+	o := orm.NewOrm()
+	qs := o.QueryTable(new(Role))
+	// query k=v
+	for k, v := range query {
+		// rewrite dot-notation to Object__Attribute
+		k = strings.Replace(k, ".", "__", -1)
+		if strings.Contains(k, "isnull") {
+			qs = qs.Filter(k, (v == "true" || v == "1"))
+		} else {
+			qs = qs.Filter(k, v)
+		}
+	}
+	count, err = qs.Count()
+	return count, err
+}
+
+// GetAllRole retrieves all Role matches certain condition. Returns empty list if
+// no records exist
+func GetAllRole(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	o := orm.NewOrm()
+	qs := o.QueryTable(new(Role))
+	var count int64 = 0
+	// query k=v
+	if cond := LgQueryCond(query); cond != nil {
+		qs = qs.SetCond(cond)
+	}
+	// order by:
+	sortFields, err := LgOrderBy(sortby, order)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var l []Role
+	qs = qs.OrderBy(sortFields...)
+
+	if page == 1 {
+		count, _ = qs.Count()
+	}
+
+	if _, err = qs.Limit(limit, offset).All(&l, fields...); err == nil {
+		if len(fields) == 0 {
+			for _, v := range l {
+				for _, lo := range load {
+					v.LoadRelatedOf(lo)
+				}
+				ml = append(ml, v)
+			}
+		} else {
+			// trim unused fields
+			for _, v := range l {
+				m := make(map[string]interface{})
+				val := reflect.ValueOf(v)
+				for _, fname := range fields {
+					m[fname] = val.FieldByName(fname).Interface()
+				}
+				for _, lo := range load {
+					v.LoadRelatedOf(lo)
+				}
+				ml = append(ml, m)
+			}
+		}
+
+		if len(ml) == 0 {
+			ml = make([]interface{}, 0)
+		}
+
+		if page == 1 {
+			pager = &LgPager{}
+			pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+			pager.List = ml
+			return ml, pager, nil
+		} else {
+			return ml, nil, nil
+		}
+
+	}
+	return nil, nil, err
+}
+
+// UpdateRole updates Role by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateRoleById(m *Role) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	v := Role{Id: m.Id}
+
+	// every_rl
+	// m2m_update
+	if m.Users != nil {
+		m2m := o.QueryM2M(m, "Users")
+		_, err = m2m.Clear()
+		if err != nil {
+			o.Rollback()
+			return
+		}
+		if len(m.Users) != 0 {
+			_, err = m2m.Add(m.Users)
+			if err != nil {
+				o.Rollback()
+				return
+			}
+		}
+	}
+
+	// ascertain id exists in the database
+	if err = o.Read(&v); err == nil {
+		_, err = o.Update(m)
+		if err != nil {
+			o.Rollback()
+			return
+		}
+	}
+
+	o.Commit()
+	return
+}
+
+// PatchRole updates Role by Id and returns error if
+// the record to be updated doesn't exist
+func PatchRoleById(m *Role, fields []string) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	err = patchRole(o, m, fields)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	o.Commit()
+	return
+}
+
+// patchRole updates the given fields of Role inside the
+// transaction of o, relation fields are cleared and added again
+func patchRole(o orm.QueryExecutor, m *Role, fields []string) (err error) {
+	for index, fname := range fields {
+		if fname == "" {
+			continue
+		}
+		if index == -1 {
+			continue
+		}
+		// every_rl
+		// m2m_patch
+		if fname == "Users" {
+			if m.Users != nil {
+				m2m := o.QueryM2M(m, "Users")
+				_, err = m2m.Clear()
+				if err != nil {
+					return
+				}
+				if len(m.Users) != 0 {
+					_, err = m2m.Add(m.Users)
+					if err != nil {
+						return
+					}
+				}
+			}
+			fields = append(fields[:index], fields[index+1:]...)
+		}
+
+	}
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(fields) == 0 {
+		return
+	}
+
+	_, err = o.Update(m, fields...)
+	return
+}
+
+// UpdateMultiRole updates several Roles in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiRole(ms []*Role, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = o.Read(&Role{Id: m.Id}); itemErr == nil {
+				itemErr = patchRole(o, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(o)
+	return
+}
+
+// DeleteMultiRoleByIds deletes Roles by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiRoleByIds(ids []int) (result *LgBatchResult, err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = deleteRoleBatch(o, ids)
+	err = result.End(o)
+	return
+}
+
+// DeleteMultiRoleByQuery deletes all Roles matches the same query as
+// GetAllRole in one transaction.
+func DeleteMultiRoleByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	cond := LgQueryCond(query)
+	if cond == nil {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	var l []Role
+	if _, err = o.QueryTable(new(Role)).SetCond(cond).Limit(-1).All(&l, "Id"); err != nil {
+		o.Rollback()
+		return
+	}
+	ids := make([]int, 0, len(l))
+	for _, v := range l {
+		ids = append(ids, v.Id)
+	}
+	result = deleteRoleBatch(o, ids)
+	err = result.End(o)
+	return
+}
+
+// deleteRoleBatch deletes Roles one by one inside the transaction of o
+func deleteRoleBatch(o orm.QueryExecutor, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := o.Delete(&Role{Id: id})
+		if err == nil && num == 0 {
+			err = orm.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchRoleM2MPart updates Role by Id and returns error if
+// the record to be updated doesn't exist
+func PatchRoleM2MPartById(m *Role, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+
+	// every_m2m_part
+	// m2m_Users
+	if field == "Users" {
+		m2m := o.QueryM2M(m, "Users")
+		if lenDel != 0 {
+			for _, did := range DelIds {
+				delone := &User{Id: did}
+				if m2m.Exist(delone) {
+					_, err = m2m.Remove(delone)
+					if err != nil {
+						o.Rollback()
+						return
+					}
+				}
+			}
+		}
+		if lenAdd != 0 {
+			for _, aid := range AddIds {
+				addone := &User{Id: aid}
+				if !m2m.Exist(addone) {
+					_, err = m2m.Add(addone)
+					if err != nil {
+						o.Rollback()
+						return
+					}
+				}
+			}
+		}
+	}
+
+	o.Commit()
+	return
+}
+
+// DeleteRole deletes Role by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteRole(id int) (err error) {
+	o := orm.NewOrm()
+	v := Role{Id: id}
+	// ascertain id exists in the database
+	if err = o.Read(&v); err == nil {
+		var num int64
+		if num, err = o.Delete(&Role{Id: id}); err == nil {
+			fmt.Println("Number of records deleted in database:", num)
+		}
+	}
+	return
+}
+
+// upsertRoleArgs returns the values of the columns inserted by upsert
+func upsertRoleArgs(m *Role) []interface{} {
+
+	return []interface{}{m.Name}
+}
+
+// UpsertRoleByName inserts m, or updates the Role with the same
+// (name), and returns the Id on success. (name) is the only unique key
+// of the table, so the conflict handled by MySQL is always on it.
+func UpsertRoleByName(m *Role) (id int64, err error) {
+	return upsertRoleByName(orm.NewOrm(), m)
+}
+
+func upsertRoleByName(o orm.QueryExecutor, m *Role) (id int64, err error) {
+	args := upsertRoleArgs(m)
+	if o.Driver().Type() == orm.DRPostgres {
+		err = o.Raw("INSERT INTO \"role\" (\"name\") VALUES (?) ON CONFLICT (\"name\") DO UPDATE SET \"name\" = EXCLUDED.\"name\" RETURNING \"id\"", args...).QueryRow(&id)
+	} else {
+		res, e := o.Raw("INSERT INTO `role` (`name`) VALUES (?) ON DUPLICATE KEY UPDATE `id` = LAST_INSERT_ID(`id`)", args...).Exec()
+		if err = e; err == nil {
+			id, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return
+	}
+	// 重新读取，修改时m中的字段不一定与数据库一致
+	m.Id = int(id)
+	err = o.Read(m)
+	return
+}
+
+// RoleUniqueKeys lists the unique keys accepted by the by argument of UpsertRole
+var RoleUniqueKeys = []string{"name"}
+
+func upsertRoleFunc(by string) (func(orm.QueryExecutor, *Role) (int64, error), error) {
+	switch by {
+	case "", "name":
+		return upsertRoleByName, nil
+	}
+	return nil, errors.New("Error: unknown unique key '" + by + "', must be one of " + strings.Join(RoleUniqueKeys, " | "))
+}
+
+// UpsertRole inserts or updates m by the unique key by, the columns of by are
+// separated by ",", an empty by means the first unique key.
+func UpsertRole(m *Role, by string) (id int64, err error) {
+	upsert, err := upsertRoleFunc(by)
+	if err != nil {
+		return
+	}
+	// 唯一键冲突时修改，只校验列
+	if err = m.ValidateColumns(); err != nil {
+		return
+	}
+	return upsert(orm.NewOrm(), m)
+}
+
+// UpsertMultiRole inserts or updates several Roles by the unique key by in
+// one transaction. Nothing is committed unless every item succeeds, and the items after
+// the first failed one are skipped.
+func UpsertMultiRole(ms []*Role, by string) (result *LgBatchResult, err error) {
+	upsert, err := upsertRoleFunc(by)
+	if err != nil {
+		return
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		itemErr := m.ValidateColumns()
+		if itemErr == nil {
+			_, itemErr = upsert(o, m)
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(o)
+	return
+}
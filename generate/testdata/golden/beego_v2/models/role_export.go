@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// RoleExportFields lists the fields can be exported and imported
+var RoleExportFields = []string{"Id", "Name"}
+
+// RoleExportColumns lists the columns of RoleExportFields
+var RoleExportColumns = []string{"id", "name"}
+
+// RoleImportRequired lists the fields an import file must have
+var RoleImportRequired = []string{"Name"}
+
+// RoleImportUniqueKeys lists the fields of each unique key, which must not repeat in an import file
+var RoleImportUniqueKeys = [][]string{{"Name"}}
+
+// ExportRole reads all Role matches the same query as GetAllRole in the
+// order of Id batch by batch, and calls fn with each of them. Each batch starts after the last
+// Id of the previous one, so rows written during the export are neither skipped nor exported twice.
+func ExportRole(query map[string]string, fn func(m *Role) error) (err error) {
+	q := make(map[string]string, len(query)+1)
+	for k, v := range query {
+		q[k] = v
+	}
+	for {
+		// 读取所有字段，由RoleCells取出fields
+		l, _, err := GetAllRole(q, nil, []string{"Id"}, []string{"asc"}, 0, lgExportBatch, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, item := range l {
+			v := item.(Role)
+			if err = fn(&v); err != nil {
+				return err
+			}
+			q["Id__gt"] = strconv.Itoa(v.Id)
+		}
+		if len(l) < lgExportBatch {
+			return nil
+		}
+	}
+}
+
+// RoleCells returns the values of fields of m, relations are exported as their Id
+func RoleCells(m *Role, fields []string) []interface{} {
+	cells := make([]interface{}, len(fields))
+	for i, field := range fields {
+		switch field {
+		case "Id":
+			cells[i] = m.Id
+		case "Name":
+			cells[i] = m.Name
+		}
+	}
+	return cells
+}
+
+// setRoleField parses raw as the value of field and checks it against the column
+func setRoleField(m *Role, field string, raw string) error {
+	switch field {
+	case "Name":
+		if raw == "" {
+			return errors.New("Name is required")
+		}
+		m.Name = raw
+	}
+	return nil
+}
+
+// ImportRole parses rows under header as Roles and inserts them by
+// AddMultiRole. Nothing is inserted unless every row is valid. The header must
+// have the required fields, and the values of a unique key must not repeat in rows.
+func ImportRole(header []string, rows [][]string) (result *LgBatchResult, err error) {
+	fields, err := lgImportFields(header, RoleExportFields, RoleExportColumns)
+	if err != nil {
+		return
+	}
+	if err = lgMissingFields(fields, RoleImportRequired); err != nil {
+		return
+	}
+	keys := newLgImportKeys(fields, RoleImportUniqueKeys)
+	result = &LgBatchResult{}
+	var ms []*Role
+	for i, row := range rows {
+		if lgEmptyRow(row) {
+			continue
+		}
+		m := new(Role)
+		var msgs []string
+		for j, field := range fields {
+			raw := ""
+			if j < len(row) {
+				raw = strings.TrimSpace(row[j])
+			}
+			if e := setRoleField(m, field, raw); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if len(msgs) == 0 {
+			if e := m.ValidateFields(fields...); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if e := keys.check(i, row); e != nil {
+			msgs = append(msgs, e.Error())
+		}
+		var rowErr error
+		if len(msgs) > 0 {
+			rowErr = errors.New(strings.Join(msgs, "; "))
+		}
+		result.Add(i, 0, rowErr)
+		ms = append(ms, m)
+	}
+	if result.Failed > 0 || len(ms) == 0 {
+		return
+	}
+	if _, err = AddMultiRole(ms); err == nil {
+		result.Committed = true
+	}
+	return
+}
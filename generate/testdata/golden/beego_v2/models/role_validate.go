@@ -0,0 +1,50 @@
+package models
+
+import (
+	"unicode/utf8"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// Validate checks every field of m against its column and the unique keys
+func (m *Role) Validate() error {
+	return m.ValidateFields()
+}
+
+// ValidateFields checks fields of m against their columns and the unique keys made
+// up of fields, all fields are checked when fields is empty.
+func (m *Role) ValidateFields(fields ...string) error {
+	ve := &LgValidationError{}
+	m.validateColumns(ve, fields)
+	// 列的校验通过后再查询唯一键
+	if len(ve.Errors) == 0 {
+		m.validateUnique(ve, fields)
+	}
+	return ve.Err()
+}
+
+// ValidateColumns checks fields of m against their columns only, without querying
+// the unique keys, all fields are checked when fields is empty.
+func (m *Role) ValidateColumns(fields ...string) error {
+	ve := &LgValidationError{}
+	m.validateColumns(ve, fields)
+	return ve.Err()
+}
+
+func (m *Role) validateColumns(ve *LgValidationError, fields []string) {
+	if lgChecks(fields, "Name") {
+		if utf8.RuneCountInString(m.Name) > 32 {
+			ve.Add("Name", "Name exceeds 32 characters")
+		}
+	}
+}
+
+func (m *Role) validateUnique(ve *LgValidationError, fields []string) {
+	o := orm.NewOrm()
+	if lgChecks(fields, "Name") {
+		qs := o.QueryTable(new(Role)).Filter("Name", m.Name)
+		if lgExists(qs, m.Id) {
+			ve.Add("Name", "Name already exists")
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/core/utils"
+)
+
+type Team struct {
+	Id    int     `orm:"column(id);auto;pk"`
+	Name  string  `orm:"column(name);size(32)" description:"team name"`
+	Users []*User `orm:"null;reverse(many)" description:"与[user] 一对多 关系，该表为主表，该表id为关联字段。"`
+}
+
+func (t *Team) TableName() string {
+	return "team"
+}
+
+func init() {
+	orm.RegisterModel(new(Team))
+}
+
+func (t *Team) LoadRelatedOf(r string, args ...utils.KV) (int64, error) {
+	o := orm.NewOrm()
+	num, err := o.LoadRelated(t, r, args...)
+	return num, err
+}
+
+// AddTeam insert a new Team into database and returns
+// last inserted Id on success.
+func AddTeam(m *Team) (id int64, err error) {
+	o := orm.NewOrm()
+	id, err = o.Insert(m)
+	return
+}
+
+// AddMultiTeam insert multi Teams into database and returns
+// sum success nums.
+func AddMultiTeam(ms []*Team) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return
+	}
+	// 分批插入，在同一个事务中
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	successNums, err = o.InsertMulti(100, ms)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	err = o.Commit()
+	return
+}
+
+// AddTeamHasMany insert a new Team and some items into database and returns
+// last inserted Id on success.
+func AddTeamHasMany(m *Team) (id int64, err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	id, err = o.Insert(m)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	m.Id = int(id)
+
+	// every_rl
+	// o2m_add
+	if m.Users != nil {
+		if len(m.Users) != 0 {
+			for i, _ := range m.Users {
+				m.Users[i].Team = &Team{Id: m.Id}
+			}
+			_, err = o.InsertMulti(len(m.Users), m.Users)
+			if err != nil {
+				o.Rollback()
+				return
+			}
+		}
+	}
+
+	o.Commit()
+	return
+}
+
+// GetTeamById retrieves Team by Id. Returns error if
+// Id doesn't exist
+func GetTeamById(id int) (v *Team, err error) {
+	o := orm.NewOrm()
+	v = &Team{Id: id}
+	if err = o.Read(v); err == nil {
+		return v, nil
+	}
+	return nil, err
+}
+
+// GetTeamCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetTeamCounts(query map[string]string) (count int64, err error) {
+	o := orm.NewOrm()
+	qs := o.QueryTable(new(Team))
+	// query k=v
+	for k, v := range query {
+		// rewrite dot-notation to Object__Attribute
+		k = strings.Replace(k, ".", "__", -1)
+		if strings.Contains(k, "isnull") {
+			qs = qs.Filter(k, (v == "true" || v == "1"))
+		} else {
+			qs = qs.Filter(k, v)
+		}
+	}
+	count, err = qs.Count()
+	return count, err
+}
+
+// GetAllTeam retrieves all Team matches certain condition. Returns empty list if
+// no records exist
+func GetAllTeam(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	o := orm.NewOrm()
+	qs := o.QueryTable(new(Team))
+	var count int64 = 0
+	// query k=v
+	if cond := LgQueryCond(query); cond != nil {
+		qs = qs.SetCond(cond)
+	}
+	// order by:
+	sortFields, err := LgOrderBy(sortby, order)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var l []Team
+	qs = qs.OrderBy(sortFields...)
+
+	if page == 1 {
+		count, _ = qs.Count()
+	}
+
+	if _, err = qs.Limit(limit, offset).All(&l, fields...); err == nil {
+		if len(fields) == 0 {
+			for _, v := range l {
+				for _, lo := range load {
+					v.LoadRelatedOf(lo)
+				}
+				ml = append(ml, v)
+			}
+		} else {
+			// trim unused fields
+			for _, v := range l {
+				m := make(map[string]interface{})
+				val := reflect.ValueOf(v)
+				for _, fname := range fields {
+					m[fname] = val.FieldByName(fname).Interface()
+				}
+				for _, lo := range load {
+					v.LoadRelatedOf(lo)
+				}
+				ml = append(ml, m)
+			}
+		}
+
+		if len(ml) == 0 {
+			ml = make([]interface{}, 0)
+		}
+
+		if page == 1 {
+			pager = &LgPager{}
+			pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+			pager.List = ml
+			return ml, pager, nil
+		} else {
+			return ml, nil, nil
+		}
+
+	}
+	return nil, nil, err
+}
+
+// UpdateTeam updates Team by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateTeamById(m *Team) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	v := Team{Id: m.Id}
+
+	// every_rl
+	// o2m_update
+	if m.Users != nil {
+		if len(m.Users) != 0 {
+
+		}
+	}
+
+	// ascertain id exists in the database
+	if err = o.Read(&v); err == nil {
+		_, err = o.Update(m)
+		if err != nil {
+			o.Rollback()
+			return
+		}
+	}
+
+	o.Commit()
+	return
+}
+
+// PatchTeam updates Team by Id and returns error if
+// the record to be updated doesn't exist
+func PatchTeamById(m *Team, fields []string) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	err = patchTeam(o, m, fields)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	o.Commit()
+	return
+}
+
+// patchTeam updates the given fields of Team inside the
+// transaction of o, relation fields are cleared and added again
+func patchTeam(o orm.QueryExecutor, m *Team, fields []string) (err error) {
+	for index, fname := range fields {
+		if fname == "" {
+			continue
+		}
+		if index == -1 {
+			continue
+		}
+		// every_rl
+		// o2m_patch
+		if fname == "Users" {
+			if m.Users != nil {
+				if len(m.Users) != 0 {
+
+				}
+			}
+			fields = append(fields[:index], fields[index+1:]...)
+		}
+
+	}
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(fields) == 0 {
+		return
+	}
+
+	_, err = o.Update(m, fields...)
+	return
+}
+
+// UpdateMultiTeam updates several Teams in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiTeam(ms []*Team, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = o.Read(&Team{Id: m.Id}); itemErr == nil {
+				itemErr = patchTeam(o, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(o)
+	return
+}
+
+// DeleteMultiTeamByIds deletes Teams by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiTeamByIds(ids []int) (result *LgBatchResult, err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = deleteTeamBatch(o, ids)
+	err = result.End(o)
+	return
+}
+
+// DeleteMultiTeamByQuery deletes all Teams matches the same query as
+// GetAllTeam in one transaction.
+func DeleteMultiTeamByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	cond := LgQueryCond(query)
+	if cond == nil {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	var l []Team
+	if _, err = o.QueryTable(new(Team)).SetCond(cond).Limit(-1).All(&l, "Id"); err != nil {
+		o.Rollback()
+		return
+	}
+	ids := make([]int, 0, len(l))
+	for _, v := range l {
+		ids = append(ids, v.Id)
+	}
+	result = deleteTeamBatch(o, ids)
+	err = result.End(o)
+	return
+}
+
+// deleteTeamBatch deletes Teams one by one inside the transaction of o
+func deleteTeamBatch(o orm.QueryExecutor, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := o.Delete(&Team{Id: id})
+		if err == nil && num == 0 {
+			err = orm.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchTeamM2MPart updates Team by Id and returns error if
+// the record to be updated doesn't exist
+func PatchTeamM2MPartById(m *Team, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+
+	// every_m2m_part
+
+	o.Commit()
+	return
+}
+
+// DeleteTeam deletes Team by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteTeam(id int) (err error) {
+	o := orm.NewOrm()
+	v := Team{Id: id}
+	// ascertain id exists in the database
+	if err = o.Read(&v); err == nil {
+		var num int64
+		if num, err = o.Delete(&Team{Id: id}); err == nil {
+			fmt.Println("Number of records deleted in database:", num)
+		}
+	}
+	return
+}
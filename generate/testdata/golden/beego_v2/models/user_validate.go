@@ -0,0 +1,69 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// the values allowed by the enum and set columns
+var UserStatusValues = []string{"on", "off"}
+
+// Validate checks every field of m against its column and the unique keys
+func (m *User) Validate() error {
+	return m.ValidateFields()
+}
+
+// ValidateFields checks fields of m against their columns and the unique keys made
+// up of fields, all fields are checked when fields is empty.
+func (m *User) ValidateFields(fields ...string) error {
+	ve := &LgValidationError{}
+	m.validateColumns(ve, fields)
+	// 列的校验通过后再查询唯一键
+	if len(ve.Errors) == 0 {
+		m.validateUnique(ve, fields)
+	}
+	return ve.Err()
+}
+
+// ValidateColumns checks fields of m against their columns only, without querying
+// the unique keys, all fields are checked when fields is empty.
+func (m *User) ValidateColumns(fields ...string) error {
+	ve := &LgValidationError{}
+	m.validateColumns(ve, fields)
+	return ve.Err()
+}
+
+func (m *User) validateColumns(ve *LgValidationError, fields []string) {
+	if lgChecks(fields, "Name") {
+		if utf8.RuneCountInString(m.Name) > 64 {
+			ve.Add("Name", "Name exceeds 64 characters")
+		}
+	}
+	if lgChecks(fields, "Email") {
+		if utf8.RuneCountInString(m.Email) > 128 {
+			ve.Add("Email", "Email exceeds 128 characters")
+		}
+	}
+	if lgChecks(fields, "Status") {
+		if !lgEnumValid(m.Status, UserStatusValues) {
+			ve.Add("Status", "Status must be one of "+strings.Join(UserStatusValues, ", "))
+		}
+	}
+	if lgChecks(fields, "Team") {
+		if m.Team == nil || m.Team.Id == 0 {
+			ve.Add("Team", "Team is required")
+		}
+	}
+}
+
+func (m *User) validateUnique(ve *LgValidationError, fields []string) {
+	o := orm.NewOrm()
+	if lgChecks(fields, "Email") {
+		qs := o.QueryTable(new(User)).Filter("Email", m.Email)
+		if lgExists(qs, m.Id) {
+			ve.Add("Email", "Email already exists")
+		}
+	}
+}
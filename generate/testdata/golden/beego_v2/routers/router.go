@@ -0,0 +1,52 @@
+// @APIVersion 1.0.0
+// @Title beego Test API
+// @Description beego has a very cool tools to autogenerate documents for your API
+// @Contact astaxie@gmail.com
+// @TermsOfServiceUrl http://beego.me/
+// @License Apache 2.0
+// @LicenseUrl http://www.apache.org/licenses/LICENSE-2.0.html
+package routers
+
+import (
+	"app/controllers"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+func init() {
+	ns := web.NewNamespace("/api",
+
+		web.NSNamespace("/profile",
+			web.NSInclude(
+				&controllers.ProfileController{},
+			),
+		),
+
+		web.NSNamespace("/role",
+			web.NSInclude(
+				&controllers.RoleController{},
+			),
+		),
+
+		web.NSNamespace("/team",
+			web.NSInclude(
+				&controllers.TeamController{},
+			),
+		),
+
+		web.NSNamespace("/user",
+			web.NSInclude(
+				&controllers.UserController{},
+			),
+		),
+
+		// posrouter
+
+		web.NSNamespace("/rule_perm",
+			web.NSInclude(
+				&controllers.RulePermController{},
+			),
+		),
+	)
+	web.AddNamespace(ns)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"app/middleware"
+	"io/ioutil"
+
+	"github.com/labstack/echo/v4"
+)
+
+type echoContext struct {
+	c    echo.Context
+	body []byte
+}
+
+func (e *echoContext) Param(name string) string { return e.c.Param(name) }
+
+func (e *echoContext) Query(name string) string { return e.c.QueryParam(name) }
+
+func (e *echoContext) Body() []byte {
+	if e.body == nil {
+		e.body, _ = ioutil.ReadAll(e.c.Request().Body)
+	}
+	return e.body
+}
+
+func (e *echoContext) JSON(status int, v interface{}) { _ = e.c.JSON(status, v) }
+
+func (e *echoContext) Claims() map[string]interface{} {
+	clmap, _ := e.c.Get(middleware.ClaimsKey).(map[string]interface{})
+	return clmap
+}
+
+// Echo 把handler转换为echo.HandlerFunc
+func Echo(h func(Context)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		h(&echoContext{c: c})
+		return nil
+	}
+}
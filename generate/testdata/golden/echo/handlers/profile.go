@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"app/models"
+	"app/models/dto"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ProfileHandler operations for Profile
+type ProfileHandler struct{}
+
+// Post 新建profile，请求体为对象或数组
+func (h *ProfileHandler) Post(c Context) {
+	body := c.Body()
+	if lgIsObject(body) {
+		var req dto.ProfileCreateRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			lgError(c, err)
+			return
+		}
+		v := req.ToModel()
+		if err := v.Validate(); err != nil {
+			lgError(c, err)
+			return
+		}
+		if _, err := models.AddProfileHasMany(v); err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(201, dto.NewProfileResponse(v))
+		return
+	}
+
+	var reqs []*dto.ProfileCreateRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	vs := make([]*models.Profile, len(reqs))
+	for i, req := range reqs {
+		vs[i] = req.ToModel()
+	}
+	if err := models.LgValidateAll(len(vs), func(i int) error { return vs[i].Validate() }); err != nil {
+		lgError(c, err)
+		return
+	}
+	successNums, err := models.AddMultiProfile(vs)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(201, successNums)
+}
+
+// GetOne 获取profile信息，load=关系1,关系2
+func (h *ProfileHandler) GetOne(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v, err := models.GetProfileById(id)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	for _, lo := range lgSplit(c, "load") {
+		if _, err := v.LoadRelatedOf(lo); err != nil {
+			lgError(c, err)
+			return
+		}
+	}
+	c.JSON(200, dto.NewProfileResponse(v))
+}
+
+// GetAll 搜索profile信息，参数与beego的GetAll一致
+func (h *ProfileHandler) GetAll(c Context) {
+	query, err := lgParseQuery(c)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	if lgInt64(c, "getcounts", 0) == 1 {
+		nums, err := models.GetProfileCounts(query)
+		if err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(200, nums)
+		return
+	}
+
+	l, pager, err := models.GetAllProfile(query, lgSplit(c, "fields"), lgSplit(c, "sortby"), lgSplit(c, "order"),
+		lgInt64(c, "offset", 0), lgInt64(c, "limit", 10), lgSplit(c, "load"), lgInt64(c, "page", 0))
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	if pager != nil {
+		pager.List = dto.NewProfileResponses(l)
+		c.JSON(200, pager)
+	} else {
+		c.JSON(200, dto.NewProfileResponses(l))
+	}
+}
+
+// Put 修改profile，只修改请求体中的字段
+func (h *ProfileHandler) Put(c Context) {
+	h.update(c)
+}
+
+// Patch 修改profile，只修改请求体中的字段
+func (h *ProfileHandler) Patch(c Context) {
+	h.update(c)
+}
+
+func (h *ProfileHandler) update(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v := models.Profile{Id: id}
+	var req dto.ProfileUpdateRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		lgError(c, err)
+		return
+	}
+	fields := req.Apply(&v)
+	if len(fields) == 0 {
+		c.JSON(400, "没有匹配字段！")
+		return
+	}
+	if err := v.ValidateFields(fields...); err != nil {
+		lgError(c, err)
+		return
+	}
+	if err := models.PatchProfileById(&v, fields); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// PatchM2MPart 修改profile的关系，m2m_field=字段，请求体为{"Add": [], "Del": []}
+func (h *ProfileHandler) PatchM2MPart(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v := models.Profile{Id: id}
+	m2mField := c.Query("m2m_field")
+	if m2mField == "" {
+		c.JSON(400, "m2m_field不能为空！")
+		return
+	}
+	AddOrDelIds := struct {
+		Add []int
+		Del []int
+	}{}
+	if err := json.Unmarshal(c.Body(), &AddOrDelIds); err != nil {
+		lgError(c, err)
+		return
+	}
+	if err := models.PatchProfileM2MPartById(&v, m2mField, AddOrDelIds.Add, AddOrDelIds.Del); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// Delete 删除profile
+func (h *ProfileHandler) Delete(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := models.DeleteProfile(id); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// PutMulti 批量替换profile，请求体为带Id的数组，未出现的字段置为零值
+func (h *ProfileHandler) PutMulti(c Context) {
+	h.updateMulti(c, true)
+}
+
+// PatchMulti 批量修改profile，请求体为带Id的数组，只修改出现的字段
+func (h *ProfileHandler) PatchMulti(c Context) {
+	h.updateMulti(c, false)
+}
+
+// updateMulti full时修改所有字段，否则只修改请求体中出现的字段
+func (h *ProfileHandler) updateMulti(c Context, full bool) {
+	var reqs []*dto.ProfileUpdateRequest
+	if err := json.Unmarshal(c.Body(), &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	var vs []*models.Profile
+	var fields [][]string
+	for _, req := range reqs {
+		v := &models.Profile{Id: req.Id}
+		vs = append(vs, v)
+		if f := req.Apply(v); !full {
+			fields = append(fields, f)
+		} else {
+			fields = append(fields, dto.ProfileUpdateFields)
+		}
+	}
+	result, err := models.UpdateMultiProfile(vs, fields)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
+
+// DeleteMulti 批量删除profile，ids=1,2,3 或 query=k:v,k:v
+func (h *ProfileHandler) DeleteMulti(c Context) {
+	var result *models.LgBatchResult
+	var err error
+	if ids := lgSplit(c, "ids"); ids != nil {
+		var intIds []int
+		for _, idStr := range ids {
+			id, e := strconv.Atoi(strings.TrimSpace(idStr))
+			if e != nil {
+				lgError(c, errors.New("Error: invalid id "+idStr))
+				return
+			}
+			intIds = append(intIds, id)
+		}
+		result, err = models.DeleteMultiProfileByIds(intIds)
+	} else if c.Query("query") != "" {
+		var query map[string]string
+		if query, err = lgParseQuery(c); err == nil {
+			result, err = models.DeleteMultiProfileByQuery(query)
+		}
+	} else {
+		err = errors.New("Error: ids or query is required")
+	}
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"app/models"
+	"app/models/dto"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// TeamHandler operations for Team
+type TeamHandler struct{}
+
+// Post 新建team，请求体为对象或数组
+func (h *TeamHandler) Post(c Context) {
+	body := c.Body()
+	if lgIsObject(body) {
+		var req dto.TeamCreateRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			lgError(c, err)
+			return
+		}
+		v := req.ToModel()
+		if err := v.Validate(); err != nil {
+			lgError(c, err)
+			return
+		}
+		if _, err := models.AddTeamHasMany(v); err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(201, dto.NewTeamResponse(v))
+		return
+	}
+
+	var reqs []*dto.TeamCreateRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	vs := make([]*models.Team, len(reqs))
+	for i, req := range reqs {
+		vs[i] = req.ToModel()
+	}
+	if err := models.LgValidateAll(len(vs), func(i int) error { return vs[i].Validate() }); err != nil {
+		lgError(c, err)
+		return
+	}
+	successNums, err := models.AddMultiTeam(vs)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(201, successNums)
+}
+
+// GetOne 获取team信息，load=关系1,关系2
+func (h *TeamHandler) GetOne(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v, err := models.GetTeamById(id)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	for _, lo := range lgSplit(c, "load") {
+		if _, err := v.LoadRelatedOf(lo); err != nil {
+			lgError(c, err)
+			return
+		}
+	}
+	c.JSON(200, dto.NewTeamResponse(v))
+}
+
+// GetAll 搜索team信息，参数与beego的GetAll一致
+func (h *TeamHandler) GetAll(c Context) {
+	query, err := lgParseQuery(c)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	if lgInt64(c, "getcounts", 0) == 1 {
+		nums, err := models.GetTeamCounts(query)
+		if err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(200, nums)
+		return
+	}
+
+	l, pager, err := models.GetAllTeam(query, lgSplit(c, "fields"), lgSplit(c, "sortby"), lgSplit(c, "order"),
+		lgInt64(c, "offset", 0), lgInt64(c, "limit", 10), lgSplit(c, "load"), lgInt64(c, "page", 0))
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	if pager != nil {
+		pager.List = dto.NewTeamResponses(l)
+		c.JSON(200, pager)
+	} else {
+		c.JSON(200, dto.NewTeamResponses(l))
+	}
+}
+
+// Put 修改team，只修改请求体中的字段
+func (h *TeamHandler) Put(c Context) {
+	h.update(c)
+}
+
+// Patch 修改team，只修改请求体中的字段
+func (h *TeamHandler) Patch(c Context) {
+	h.update(c)
+}
+
+func (h *TeamHandler) update(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v := models.Team{Id: id}
+	var req dto.TeamUpdateRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		lgError(c, err)
+		return
+	}
+	fields := req.Apply(&v)
+	if len(fields) == 0 {
+		c.JSON(400, "没有匹配字段！")
+		return
+	}
+	if err := v.ValidateFields(fields...); err != nil {
+		lgError(c, err)
+		return
+	}
+	if err := models.PatchTeamById(&v, fields); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// PatchM2MPart 修改team的关系，m2m_field=字段，请求体为{"Add": [], "Del": []}
+func (h *TeamHandler) PatchM2MPart(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v := models.Team{Id: id}
+	m2mField := c.Query("m2m_field")
+	if m2mField == "" {
+		c.JSON(400, "m2m_field不能为空！")
+		return
+	}
+	AddOrDelIds := struct {
+		Add []int
+		Del []int
+	}{}
+	if err := json.Unmarshal(c.Body(), &AddOrDelIds); err != nil {
+		lgError(c, err)
+		return
+	}
+	if err := models.PatchTeamM2MPartById(&v, m2mField, AddOrDelIds.Add, AddOrDelIds.Del); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// Delete 删除team
+func (h *TeamHandler) Delete(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := models.DeleteTeam(id); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// PutMulti 批量替换team，请求体为带Id的数组，未出现的字段置为零值
+func (h *TeamHandler) PutMulti(c Context) {
+	h.updateMulti(c, true)
+}
+
+// PatchMulti 批量修改team，请求体为带Id的数组，只修改出现的字段
+func (h *TeamHandler) PatchMulti(c Context) {
+	h.updateMulti(c, false)
+}
+
+// updateMulti full时修改所有字段，否则只修改请求体中出现的字段
+func (h *TeamHandler) updateMulti(c Context, full bool) {
+	var reqs []*dto.TeamUpdateRequest
+	if err := json.Unmarshal(c.Body(), &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	var vs []*models.Team
+	var fields [][]string
+	for _, req := range reqs {
+		v := &models.Team{Id: req.Id}
+		vs = append(vs, v)
+		if f := req.Apply(v); !full {
+			fields = append(fields, f)
+		} else {
+			fields = append(fields, dto.TeamUpdateFields)
+		}
+	}
+	result, err := models.UpdateMultiTeam(vs, fields)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
+
+// DeleteMulti 批量删除team，ids=1,2,3 或 query=k:v,k:v
+func (h *TeamHandler) DeleteMulti(c Context) {
+	var result *models.LgBatchResult
+	var err error
+	if ids := lgSplit(c, "ids"); ids != nil {
+		var intIds []int
+		for _, idStr := range ids {
+			id, e := strconv.Atoi(strings.TrimSpace(idStr))
+			if e != nil {
+				lgError(c, errors.New("Error: invalid id "+idStr))
+				return
+			}
+			intIds = append(intIds, id)
+		}
+		result, err = models.DeleteMultiTeamByIds(intIds)
+	} else if c.Query("query") != "" {
+		var query map[string]string
+		if query, err = lgParseQuery(c); err == nil {
+			result, err = models.DeleteMultiTeamByQuery(query)
+		}
+	} else {
+		err = errors.New("Error: ids or query is required")
+	}
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
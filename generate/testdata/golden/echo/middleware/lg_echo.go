@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// Auth 签名、JWT及权限验证的echo中间件
+func Auth() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := verify(c.Response(), c.Request(), lgRoute(c.Request().Method, c.Path()))
+			if !ok {
+				return nil
+			}
+			if claims != nil {
+				c.Set(ClaimsKey, claims)
+			}
+			return next(c)
+		}
+	}
+}
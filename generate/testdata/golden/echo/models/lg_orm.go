@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego/orm"
+)
+
+// lgBegin 开启事务
+func lgBegin() (orm.Ormer, error) {
+	o := orm.NewOrm()
+	return o, o.Begin()
+}
+
+// LgTx runs fn in a transaction, which is rolled back when fn returns an error or panics
+func LgTx(fn func(o orm.Ormer) error) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			o.Rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(o); err != nil {
+		o.Rollback()
+		return
+	}
+	return o.Commit()
+}
+
+// End 全部成功时提交事务，否则回滚
+func (r *LgBatchResult) End(o orm.Ormer) (err error) {
+	if r.Failed > 0 {
+		return o.Rollback()
+	}
+	if err = o.Commit(); err == nil {
+		r.Committed = true
+	}
+	return
+}
+
+// lgExists 是否存在其它记录，id不为0时排除自身
+func lgExists(qs orm.QuerySeter, id int) bool {
+	if id != 0 {
+		qs = qs.Exclude("Id", id)
+	}
+	return qs.Exist()
+}
+
+// LgThroughIds 一次查询中间表through中column为ids的关联，返回id到refColumn的对应
+func LgThroughIds(through string, column string, refColumn string, ids []int) (map[int][]int, error) {
+	rv := make(map[int][]int)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	o := orm.NewOrm()
+	quote := "`"
+	if o.Driver().Type() == orm.DRPostgres {
+		quote = "\""
+	}
+	var lists []orm.ParamsList
+	query := "SELECT " + quote + column + quote + ", " + quote + refColumn + quote + " FROM " + quote + through + quote +
+		" WHERE " + quote + column + quote + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+	if _, err := o.Raw(query, ids).ValuesList(&lists); err != nil {
+		return nil, err
+	}
+	for _, l := range lists {
+		id, _ := strconv.Atoi(fmt.Sprint(l[0]))
+		refId, _ := strconv.Atoi(fmt.Sprint(l[1]))
+		rv[id] = append(rv[id], refId)
+	}
+	return rv, nil
+}
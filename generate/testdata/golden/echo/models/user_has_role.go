@@ -0,0 +1,7 @@
+package models
+
+type UserHasRole struct {
+	Id   int   `orm:"column(id);auto;pk"`
+	Role *Role `orm:"null;rel(fk)" description:"role_id为中间表关联字段。"`
+	User *User `orm:"null;rel(fk)" description:"user_id为中间表关联字段。"`
+}
@@ -0,0 +1,76 @@
+package routers
+
+import (
+	"app/handlers"
+	"app/middleware"
+
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+)
+
+// NewRouter 注册所有表的路由
+func NewRouter() *echo.Echo {
+	e := echo.New()
+	e.Use(echomw.Logger(), echomw.Recover(), middleware.Auth())
+	api := e.Group("/api")
+
+	{
+		h := &handlers.ProfileHandler{}
+		g := api.Group("/profile")
+		g.POST("", handlers.Echo(h.Post))
+		g.GET("/:id", handlers.Echo(h.GetOne))
+		g.GET("", handlers.Echo(h.GetAll))
+		g.PUT("/:id", handlers.Echo(h.Put))
+		g.PATCH("/:id", handlers.Echo(h.Patch))
+		g.PATCH("/m2m/part/:id", handlers.Echo(h.PatchM2MPart))
+		g.DELETE("/:id", handlers.Echo(h.Delete))
+		g.PUT("", handlers.Echo(h.PutMulti))
+		g.PATCH("", handlers.Echo(h.PatchMulti))
+		g.DELETE("", handlers.Echo(h.DeleteMulti))
+	}
+	{
+		h := &handlers.RoleHandler{}
+		g := api.Group("/role")
+		g.POST("", handlers.Echo(h.Post))
+		g.GET("/:id", handlers.Echo(h.GetOne))
+		g.GET("", handlers.Echo(h.GetAll))
+		g.PUT("/:id", handlers.Echo(h.Put))
+		g.PATCH("/:id", handlers.Echo(h.Patch))
+		g.PATCH("/m2m/part/:id", handlers.Echo(h.PatchM2MPart))
+		g.DELETE("/:id", handlers.Echo(h.Delete))
+		g.PUT("", handlers.Echo(h.PutMulti))
+		g.PATCH("", handlers.Echo(h.PatchMulti))
+		g.DELETE("", handlers.Echo(h.DeleteMulti))
+		g.PUT("/upsert", handlers.Echo(h.Upsert))
+	}
+	{
+		h := &handlers.TeamHandler{}
+		g := api.Group("/team")
+		g.POST("", handlers.Echo(h.Post))
+		g.GET("/:id", handlers.Echo(h.GetOne))
+		g.GET("", handlers.Echo(h.GetAll))
+		g.PUT("/:id", handlers.Echo(h.Put))
+		g.PATCH("/:id", handlers.Echo(h.Patch))
+		g.PATCH("/m2m/part/:id", handlers.Echo(h.PatchM2MPart))
+		g.DELETE("/:id", handlers.Echo(h.Delete))
+		g.PUT("", handlers.Echo(h.PutMulti))
+		g.PATCH("", handlers.Echo(h.PatchMulti))
+		g.DELETE("", handlers.Echo(h.DeleteMulti))
+	}
+	{
+		h := &handlers.UserHandler{}
+		g := api.Group("/user")
+		g.POST("", handlers.Echo(h.Post))
+		g.GET("/:id", handlers.Echo(h.GetOne))
+		g.GET("", handlers.Echo(h.GetAll))
+		g.PUT("/:id", handlers.Echo(h.Put))
+		g.PATCH("/:id", handlers.Echo(h.Patch))
+		g.PATCH("/m2m/part/:id", handlers.Echo(h.PatchM2MPart))
+		g.DELETE("/:id", handlers.Echo(h.Delete))
+		g.PUT("", handlers.Echo(h.PutMulti))
+		g.PATCH("", handlers.Echo(h.PatchMulti))
+		g.DELETE("", handlers.Echo(h.DeleteMulti))
+		g.PUT("/upsert", handlers.Echo(h.Upsert))
+	}
+	return e
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"app/middleware"
+	"io/ioutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ginContext struct {
+	c    *gin.Context
+	body []byte
+}
+
+func (g *ginContext) Param(name string) string { return g.c.Param(name) }
+
+func (g *ginContext) Query(name string) string { return g.c.Query(name) }
+
+func (g *ginContext) Body() []byte {
+	if g.body == nil {
+		g.body, _ = ioutil.ReadAll(g.c.Request.Body)
+	}
+	return g.body
+}
+
+func (g *ginContext) JSON(status int, v interface{}) { g.c.JSON(status, v) }
+
+func (g *ginContext) Claims() map[string]interface{} {
+	cl, _ := g.c.Get(middleware.ClaimsKey)
+	clmap, _ := cl.(map[string]interface{})
+	return clmap
+}
+
+// Gin 把handler转换为gin.HandlerFunc
+func Gin(h func(Context)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h(&ginContext{c: c})
+	}
+}
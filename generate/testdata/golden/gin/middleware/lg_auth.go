@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ClaimsKey JWT解析后的claims保存在上下文中的键
+const ClaimsKey = "JWTClaims"
+
+var (
+	JWT_PUBLIC_KEY       []byte
+	appkey, accessSecret string
+	openApiSign          bool
+	openJwt              bool
+	openPerm             bool
+	CENTER_SERVICE       string
+)
+
+func init() {
+	appkey = os.Getenv("APPKEY")
+	// accessSecret用于签名
+	accessSecret = os.Getenv("ACCESS_SECRET")
+	CENTER_SERVICE = os.Getenv("CENTER_SERVICE")
+	// 三个开关，分别是 API签名验证、JWT合法性验证及解析、路由权限验证
+	openApiSign, _ = strconv.ParseBool(os.Getenv("OPEN_API_SIGN"))
+	openJwt, _ = strconv.ParseBool(os.Getenv("OPEN_JWT"))
+	openPerm, _ = strconv.ParseBool(os.Getenv("OPEN_PERM"))
+	// 当启用JWT时，才读取公钥
+	if openJwt {
+		keyFile := os.Getenv("JWT_PUBLIC_KEY_FILE")
+		if keyFile == "" {
+			keyFile = "keys/jwt_public_key.pem"
+		}
+		fd, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			panic(err)
+		}
+		JWT_PUBLIC_KEY = fd
+	}
+}
+
+// Ignored FilterToken
+var IgnoredTokenRouter = map[string]bool{
+	"post@/api/user/login":       true,
+	"post@/api/user/login/oauth": true,
+}
+
+// Ignored PermRouter
+var IgnoredPermRouter = map[string]bool{
+	"post@/api/user/login":         true,
+	"post@/api/user/login/refresh": true,
+	"post@/api/user/login/oauth":   true,
+}
+
+// lgRoute 路径格式均为 请求类型@路径，路径参数为 :name
+func lgRoute(method string, pattern string) string {
+	return strings.ToLower(method) + "@" + pattern
+}
+
+// verify 依次验证签名、JWT及权限，失败时已写入错误响应，返回false
+func verify(w http.ResponseWriter, r *http.Request, route string) (claims map[string]interface{}, ok bool) {
+	if openApiSign && !VerifySign(w, r) {
+		return nil, false
+	}
+	if !openJwt {
+		return nil, true
+	}
+	// 直接通过map查询是否忽略
+	if IgnoredTokenRouter[route] {
+		return nil, true
+	}
+	if claims, ok = VerifyToken(w, r); !ok {
+		return
+	}
+	if !openPerm || IgnoredPermRouter[route] {
+		return claims, true
+	}
+	return claims, VerifyPerm(w, route, claims)
+}
+
+func VerifyToken(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	kv := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(kv) != 2 || kv[0] != "Bearer" {
+		log.Println("Authorization格式不对或Token为空！")
+		http.Error(w, "Authorization格式不对或Token为空！", http.StatusUnauthorized)
+		return nil, false
+	}
+	tokenString := kv[1]
+
+	// Parse token
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// 必要的验证 RS256
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		// 必要的验证 'iss' claim
+		iss := "https://atomintl.auth0.com/"
+		checkIss := token.Claims.(jwt.MapClaims).VerifyIssuer(iss, false)
+		if !checkIss {
+			return token, errors.New("Invalid issuer.")
+		}
+		return jwt.ParseRSAPublicKeyFromPEM(JWT_PUBLIC_KEY)
+	})
+	if err != nil {
+		log.Println("Parse token error:", err)
+		if ve, ok := err.(*jwt.ValidationError); ok {
+			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
+				http.Error(w, "Token 格式有误！", http.StatusUnauthorized)
+			} else if ve.Errors&(jwt.ValidationErrorExpired|jwt.ValidationErrorNotValidYet) != 0 {
+				http.Error(w, "Token 已过期！", http.StatusUnauthorized)
+			} else {
+				http.Error(w, "验证Token的过程中发生其他错误！", http.StatusUnauthorized)
+			}
+		} else {
+			http.Error(w, "无法处理此Token！", http.StatusUnauthorized)
+		}
+		return nil, false
+	}
+	if !token.Valid {
+		log.Println("Token invalid:", tokenString)
+		http.Error(w, "Token 不合法:"+tokenString, http.StatusUnauthorized)
+		return nil, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		log.Println("转换为jwt.MapClaims失败")
+		http.Error(w, "无法处理此Token！", http.StatusUnauthorized)
+		return nil, false
+	}
+	var claimsMIF = make(map[string]interface{})
+	jsonM, _ := json.Marshal(&claims)
+	json.Unmarshal(jsonM, &claimsMIF)
+	claimsMIF["JWTToken"] = tokenString
+	return claimsMIF, true
+}
+
+func VerifyPerm(w http.ResponseWriter, route string, cls map[string]interface{}) bool {
+	subT, _ := cls["sub_type"].(string)
+	subV, _ := cls["sub_value"].(string)
+	if subT == "" {
+		http.Error(w, "JWT中的SubType不能为空！", http.StatusUnauthorized)
+		return false
+	}
+	v := &struct {
+		SubType  string
+		SubValue string
+		Perm     string
+		Ops      string
+		AppId    string
+	}{SubType: subT, SubValue: subV, Perm: route, Ops: "999"}
+	jsonM, _ := json.Marshal(v)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Post(CENTER_SERVICE+"/rule_perm/check", "application/json", bytes.NewReader(jsonM))
+	if err != nil {
+		http.Error(w, "请求权限检查出错！", http.StatusUnauthorized)
+		return false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		http.Error(w, "没有权限！", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// 验证签名，读取请求体后重新放回
+func VerifySign(w http.ResponseWriter, r *http.Request) bool {
+	bd, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(bd))
+	req := r.URL.Query()
+	app_key, sn, ts := req.Get("app_key"), req.Get("sn"), req.Get("ts")
+
+	// 判断app_key
+	if app_key == "" || app_key != appkey {
+		http.Error(w, "app_key错误，请核对提交应用key!", http.StatusForbidden)
+		return false
+	}
+
+	// 验证过期时间
+	timestamp := time.Now().Unix()
+	exp := int64(600)
+	tsInt, _ := strconv.ParseInt(ts, 10, 64)
+	if tsInt > timestamp || timestamp-tsInt >= exp {
+		http.Error(w, "ts错误，请求已过期!", http.StatusForbidden)
+		return false
+	}
+
+	// 验证签名
+	if sn == "" || sn != createSignMD5(req, bd, accessSecret) {
+		http.Error(w, "sn错误，请核对签名!", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// 创建MD5签名
+func createSignMD5(params url.Values, body []byte, AS string) string {
+	// 自定义 MD5 组合
+	return EncodeStrMd5(AS + createEncryptStr(params) + EncodeByteMd5(body) + AS)
+}
+
+func createEncryptStr(params url.Values) string {
+	var key []string
+	for k := range params {
+		if k != "sn" && k != "debug" {
+			key = append(key, k)
+		}
+	}
+	sort.Strings(key)
+	var pairs []string
+	for _, k := range key {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", k, params.Get(k)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// Encode string to md5 hex value
+func EncodeStrMd5(str string) string {
+	return EncodeByteMd5([]byte(str))
+}
+
+func EncodeByteMd5(b []byte) string {
+	m := md5.New()
+	m.Write(b)
+	return hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Auth 签名、JWT及权限验证的gin中间件
+func Auth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := verify(c.Writer, c.Request, lgRoute(c.Request.Method, c.FullPath()))
+		if !ok {
+			c.Abort()
+			return
+		}
+		if claims != nil {
+			c.Set(ClaimsKey, claims)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+// LgBatchItem 批量操作中单条记录的结果，Skipped为前面的记录失败后未执行
+type LgBatchItem struct {
+	Index   int
+	Id      int
+	Ok      bool
+	Skipped bool
+	Error   string
+}
+
+// LgBatchResult 批量操作的结果，所有记录在同一个事务中执行，有一条失败则全部回滚，
+// 其后的记录不再执行
+type LgBatchResult struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Committed bool
+	Items     []*LgBatchItem
+}
+
+// Add 记录第index条记录的执行结果
+func (r *LgBatchResult) Add(index int, id int, err error) {
+	item := &LgBatchItem{Index: index, Id: id, Ok: err == nil}
+	if err != nil {
+		item.Error = err.Error()
+		r.Failed++
+	} else {
+		r.Succeeded++
+	}
+	r.Total++
+	r.Items = append(r.Items, item)
+}
+
+// Skip 已有记录失败时跳过第index条记录并返回true。PostgreSQL的事务出错后不能再执行语句，
+// 事务也会回滚，因此不再执行其后的记录
+func (r *LgBatchResult) Skip(index int, id int) bool {
+	if r.Failed == 0 {
+		return false
+	}
+	r.Items = append(r.Items, &LgBatchItem{Index: index, Id: id, Skipped: true, Error: "skipped, an earlier item failed"})
+	r.Skipped++
+	r.Total++
+	return true
+}
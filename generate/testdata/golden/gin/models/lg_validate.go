@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// LgFieldError 字段的校验错误
+type LgFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// LgValidationError 校验错误，controller直接作为json返回
+type LgValidationError struct {
+	Errors []*LgFieldError `json:"errors"`
+}
+
+func (e *LgValidationError) Error() string {
+	var msgs []string
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add 添加一个字段的错误
+func (e *LgValidationError) Add(field string, message string) {
+	e.Errors = append(e.Errors, &LgFieldError{Field: field, Message: message})
+}
+
+// Err 没有错误时返回nil
+func (e *LgValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// LgValidateAll 校验n个对象，字段名及错误信息前加上下标
+func LgValidateAll(n int, validate func(i int) error) error {
+	ve := &LgValidationError{}
+	for i := 0; i < n; i++ {
+		err := validate(i)
+		if err == nil {
+			continue
+		}
+		e, ok := err.(*LgValidationError)
+		if !ok {
+			return err
+		}
+		for _, fe := range e.Errors {
+			ve.Add(fmt.Sprintf("[%d].%s", i, fe.Field), fmt.Sprintf("[%d] %s", i, fe.Message))
+		}
+	}
+	return ve.Err()
+}
+
+// lgChecks fields为空时校验所有字段，否则只校验fields中的字段
+func lgChecks(fields []string, names ...string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	for _, name := range names {
+		found := false
+		for _, f := range fields {
+			if f == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func lgEnumValid(v string, values []string) bool {
+	for _, value := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// lgSetValid set的值为逗号分隔的可选值，可以为空
+func lgSetValid(v string, values []string) bool {
+	if v == "" {
+		return true
+	}
+	for _, item := range strings.Split(v, ",") {
+		if !lgEnumValid(item, values) {
+			return false
+		}
+	}
+	return true
+}
+
+// lgDecimalValid 整数部分不超过digits-decimals位，小数部分不超过decimals位
+func lgDecimalValid(v float64, digits int, decimals int) bool {
+	if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) >= math.Pow10(digits-decimals) {
+		return false
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.Index(s, "."); i >= 0 {
+		return len(s)-i-1 <= decimals
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package routers
+
+import (
+	"app/handlers"
+	"app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewRouter 注册所有表的路由
+func NewRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Logger(), gin.Recovery(), middleware.Auth())
+	api := r.Group("/api")
+
+	{
+		h := &handlers.ProfileHandler{}
+		g := api.Group("/profile")
+		g.POST("", handlers.Gin(h.Post))
+		g.GET("/:id", handlers.Gin(h.GetOne))
+		g.GET("", handlers.Gin(h.GetAll))
+		g.PUT("/:id", handlers.Gin(h.Put))
+		g.PATCH("/:id", handlers.Gin(h.Patch))
+		g.PATCH("/m2m/part/:id", handlers.Gin(h.PatchM2MPart))
+		g.DELETE("/:id", handlers.Gin(h.Delete))
+		g.PUT("", handlers.Gin(h.PutMulti))
+		g.PATCH("", handlers.Gin(h.PatchMulti))
+		g.DELETE("", handlers.Gin(h.DeleteMulti))
+	}
+	{
+		h := &handlers.RoleHandler{}
+		g := api.Group("/role")
+		g.POST("", handlers.Gin(h.Post))
+		g.GET("/:id", handlers.Gin(h.GetOne))
+		g.GET("", handlers.Gin(h.GetAll))
+		g.PUT("/:id", handlers.Gin(h.Put))
+		g.PATCH("/:id", handlers.Gin(h.Patch))
+		g.PATCH("/m2m/part/:id", handlers.Gin(h.PatchM2MPart))
+		g.DELETE("/:id", handlers.Gin(h.Delete))
+		g.PUT("", handlers.Gin(h.PutMulti))
+		g.PATCH("", handlers.Gin(h.PatchMulti))
+		g.DELETE("", handlers.Gin(h.DeleteMulti))
+		g.PUT("/upsert", handlers.Gin(h.Upsert))
+	}
+	{
+		h := &handlers.TeamHandler{}
+		g := api.Group("/team")
+		g.POST("", handlers.Gin(h.Post))
+		g.GET("/:id", handlers.Gin(h.GetOne))
+		g.GET("", handlers.Gin(h.GetAll))
+		g.PUT("/:id", handlers.Gin(h.Put))
+		g.PATCH("/:id", handlers.Gin(h.Patch))
+		g.PATCH("/m2m/part/:id", handlers.Gin(h.PatchM2MPart))
+		g.DELETE("/:id", handlers.Gin(h.Delete))
+		g.PUT("", handlers.Gin(h.PutMulti))
+		g.PATCH("", handlers.Gin(h.PatchMulti))
+		g.DELETE("", handlers.Gin(h.DeleteMulti))
+	}
+	{
+		h := &handlers.UserHandler{}
+		g := api.Group("/user")
+		g.POST("", handlers.Gin(h.Post))
+		g.GET("/:id", handlers.Gin(h.GetOne))
+		g.GET("", handlers.Gin(h.GetAll))
+		g.PUT("/:id", handlers.Gin(h.Put))
+		g.PATCH("/:id", handlers.Gin(h.Patch))
+		g.PATCH("/m2m/part/:id", handlers.Gin(h.PatchM2MPart))
+		g.DELETE("/:id", handlers.Gin(h.Delete))
+		g.PUT("", handlers.Gin(h.PutMulti))
+		g.PATCH("", handlers.Gin(h.PatchMulti))
+		g.DELETE("", handlers.Gin(h.DeleteMulti))
+		g.PUT("/upsert", handlers.Gin(h.Upsert))
+	}
+	return r
+}
@@ -0,0 +1,517 @@
+package controllers
+
+import (
+	"app/models"
+	"app/models/dto"
+	"encoding/json"
+	"errors"
+	"github.com/tidwall/gjson"
+	"strconv"
+	"strings"
+	// posimport
+)
+
+// RoleController operations for Role
+type RoleController struct {
+	BaseController
+}
+
+// URLMapping ...
+func (c *RoleController) URLMapping() {
+	c.Mapping("Post", c.Post)
+	c.Mapping("GetOne", c.GetOne)
+	c.Mapping("GetAll", c.GetAll)
+	c.Mapping("Put", c.Put)
+	c.Mapping("Patch", c.Patch)
+	c.Mapping("PatchM2MPart", c.PatchM2MPart)
+	c.Mapping("Delete", c.Delete)
+	c.Mapping("PutMulti", c.PutMulti)
+	c.Mapping("PatchMulti", c.PatchMulti)
+	c.Mapping("DeleteMulti", c.DeleteMulti)
+	c.Mapping("Upsert", c.Upsert)
+	c.Mapping("Export", c.Export)
+	c.Mapping("Import", c.Import)
+}
+
+// @Description 新建role
+// @router / [post]
+func (c *RoleController) Post() {
+	// pos11
+	jr := gjson.ParseBytes(c.Ctx.Input.RequestBody)
+	if jr.IsObject() {
+		var req dto.RoleCreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+			v := req.ToModel()
+			if err := v.Validate(); err != nil {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err
+				c.ServeJSON()
+				return
+			}
+			// pos12
+			if _, err := models.AddRoleHasMany(v); err == nil {
+				// pos13
+				c.Ctx.Output.SetStatus(201)
+				c.Data["json"] = dto.NewRoleResponse(v)
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		var reqs []*dto.RoleCreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &reqs); err == nil {
+			vs := make([]*models.Role, len(reqs))
+			for i, req := range reqs {
+				vs[i] = req.ToModel()
+			}
+			if err := models.LgValidateAll(len(vs), func(i int) error { return vs[i].Validate() }); err != nil {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err
+				c.ServeJSON()
+				return
+			}
+			if successNums, err := models.AddMultiRole(vs); err == nil {
+				c.Ctx.Output.SetStatus(201)
+				c.Data["json"] = successNums
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	}
+	c.ServeJSON()
+}
+
+// @Description 获取role信息
+// @router /:id [get]
+func (c *RoleController) GetOne() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	v, err := models.GetRoleById(id)
+	var load []string
+
+	if v := c.GetString("load"); v != "" {
+		load = strings.Split(v, ",")
+	}
+	// pos21
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	} else {
+		// pos22
+		if len(load) != 0 {
+			for _, lo := range load {
+				_, err := v.LoadRelatedOf(lo)
+				if err != nil {
+					c.Ctx.Output.SetStatus(400)
+					c.Data["json"] = err.Error()
+					c.ServeJSON()
+					return
+				}
+			}
+		}
+		c.Data["json"] = dto.NewRoleResponse(v)
+	}
+	c.ServeJSON()
+}
+
+// GetAll ...
+// @Title Get All
+// @Description 搜索role信息
+// @Param	query	query	string	false	"Filter. e.g. col1:v1,col2:v2 ..."
+// @Param	fields	query	string	false	"Fields returned. e.g. col1,col2 ..."
+// @Param	sortby	query	string	false	"Sorted-by fields. e.g. col1,col2 ..."
+// @Param	order	query	string	false	"Order corresponding to each sortby field, if single value, apply to all sortby fields. e.g. desc,asc ..."
+// @Param	limit	query	string	false	"Limit the size of result set. Must be an integer"
+// @Param	offset	query	string	false	"Start position of result set. Must be an integer"
+// @Param	page	query	string	false	"Page number of result set. Must be an integer"
+// @Param	load	query	string	false	"LoadRelatedOf. e.g. As,Bs,C ..."
+// @Param	getcounts	query	int	false	"GetCounts. e.g. 传1时仅返回记录数"
+// @Success 200 {object} dto.RoleResponse
+// @Failure 403
+// @router / [get]
+func (c *RoleController) GetAll() {
+	var fields []string
+	var sortby []string
+	var order []string
+	var load []string
+	var limit int64 = 10
+	var page int64 = 0
+	var offset int64
+	var getcounts int = 0
+
+	// getcounts: 0 (default is 0)
+	if v, err := c.GetInt("getcounts"); err == nil {
+		getcounts = v
+	}
+
+	// query: k:v,k:v
+	query, err := c.parseQuery()
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+		c.ServeJSON()
+		return
+	}
+
+	if getcounts == 1 {
+		nums, err := models.GetRoleCounts(query)
+		if err != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		} else {
+			c.Data["json"] = nums
+		}
+		c.ServeJSON()
+		return
+	}
+
+	// fields: col1,col2,entity.col3
+	if v := c.GetString("fields"); v != "" {
+		fields = strings.Split(v, ",")
+	}
+	if v := c.GetString("load"); v != "" {
+		load = strings.Split(v, ",")
+	}
+
+	// order: desc,asc
+	if v := c.GetString("order"); v != "" {
+		order = strings.Split(v, ",")
+	}
+	// sortby: col1,col2
+	if v := c.GetString("sortby"); v != "" {
+		sortby = strings.Split(v, ",")
+	}
+	if v, err := c.GetInt64("page"); err == nil {
+		page = v
+	}
+	// limit: 10 (default is 10)
+	if v, err := c.GetInt64("limit"); err == nil {
+		limit = v
+	}
+	// offset: 0 (default is 0)
+	if v, err := c.GetInt64("offset"); err == nil {
+		offset = v
+	}
+
+	l, pager, err := models.GetAllRole(query, fields, sortby, order, offset, limit, load, page)
+	// pos31
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	} else {
+		if pager != nil {
+			pager.List = dto.NewRoleResponses(l)
+			c.Data["json"] = pager
+		} else {
+			c.Data["json"] = dto.NewRoleResponses(l)
+		}
+	}
+	c.ServeJSON()
+}
+
+// @Description 修改role
+// @router /:id [put]
+func (c *RoleController) Put() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	v := models.Role{Id: id}
+	var req dto.RoleUpdateRequest
+
+	// pos41
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+		fileds := req.Apply(&v)
+		if len(fileds) == 0 {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = "没有匹配字段！"
+			c.ServeJSON()
+			return
+		}
+		if err := v.ValidateFields(fileds...); err != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err
+			c.ServeJSON()
+			return
+		}
+		// pos42
+		if err := models.PatchRoleById(&v, fileds); err == nil {
+			// pos43
+			c.Data["json"] = "OK"
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 修改role
+// @router /:id [Patch]
+func (c *RoleController) Patch() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	v := models.Role{Id: id}
+	var req dto.RoleUpdateRequest
+
+	// pos51
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+		fileds := req.Apply(&v)
+		if len(fileds) == 0 {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = "没有匹配字段！"
+			c.ServeJSON()
+			return
+		}
+		if err := v.ValidateFields(fileds...); err != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err
+			c.ServeJSON()
+			return
+		}
+		// pos52
+		if err := models.PatchRoleById(&v, fileds); err == nil {
+			// pos53
+			c.Data["json"] = "OK"
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 修改role的关系
+// @router /m2m/part/:id [Patch]
+func (c *RoleController) PatchM2MPart() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	v := models.Role{Id: id}
+
+	var m2mField string
+	// field
+	if v := c.GetString("m2m_field"); v != "" {
+		m2mField = v
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = "m2m_field不能为空！"
+		c.ServeJSON()
+		return
+	}
+	AddOrDelIds := struct {
+		Add []int
+		Del []int
+	}{}
+
+	// pos_m2m_1
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &AddOrDelIds); err == nil {
+		// pos_m2m_2
+		if err := models.PatchRoleM2MPartById(&v, m2mField, AddOrDelIds.Add, AddOrDelIds.Del); err == nil {
+			// pos_m2m_3
+			c.Data["json"] = "OK"
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 删除role
+// @router /:id [delete]
+func (c *RoleController) Delete() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	if err := models.DeleteRole(id); err == nil {
+		c.Data["json"] = "OK"
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 批量替换role，请求体为带Id的数组，未出现的字段置为零值
+// @router / [put]
+func (c *RoleController) PutMulti() {
+	// pos61
+	if vs, fields, err := c.unmarshalMulti(true); err == nil {
+		// pos62
+		if result, err := models.UpdateMultiRole(vs, fields); err == nil {
+			// pos63
+			if !result.Committed {
+				c.Ctx.Output.SetStatus(400)
+			}
+			c.Data["json"] = result
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 批量修改role，请求体为带Id的数组，只修改出现的字段
+// @router / [patch]
+func (c *RoleController) PatchMulti() {
+	// pos71
+	if vs, fields, err := c.unmarshalMulti(false); err == nil {
+		// pos72
+		if result, err := models.UpdateMultiRole(vs, fields); err == nil {
+			// pos73
+			if !result.Committed {
+				c.Ctx.Output.SetStatus(400)
+			}
+			c.Data["json"] = result
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 批量删除role，ids=1,2,3 或 query=k:v,k:v
+// @router / [delete]
+func (c *RoleController) DeleteMulti() {
+	var result *models.LgBatchResult
+	var err error
+	// pos81
+	if v := c.GetString("ids"); v != "" {
+		var ids []int
+		for _, idStr := range strings.Split(v, ",") {
+			id, e := strconv.Atoi(strings.TrimSpace(idStr))
+			if e != nil {
+				err = errors.New("Error: invalid id " + idStr)
+				break
+			}
+			ids = append(ids, id)
+		}
+		if err == nil {
+			result, err = models.DeleteMultiRoleByIds(ids)
+		}
+	} else if v := c.GetString("query"); v != "" {
+		var query map[string]string
+		if query, err = c.parseQuery(); err == nil {
+			result, err = models.DeleteMultiRoleByQuery(query)
+		}
+	} else {
+		err = errors.New("ids和query不能同时为空！")
+	}
+	if err == nil {
+		// pos82
+		if !result.Committed {
+			c.Ctx.Output.SetStatus(400)
+		}
+		c.Data["json"] = result
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// parseQuery 解析query参数: k:v,k:v
+func (c *RoleController) parseQuery() (map[string]string, error) {
+	var query = make(map[string]string)
+	if v := c.GetString("query"); v != "" {
+		for _, cond := range strings.Split(v, ",") {
+			kv := strings.SplitN(cond, ":", 2)
+			if len(kv) != 2 {
+				return nil, errors.New("Error: invalid query key/value pair")
+			}
+			k, v := kv[0], kv[1]
+			query[k] = v
+		}
+	}
+	return query, nil
+}
+
+// unmarshalMulti 解析批量修改的请求体，返回每条记录及要修改的字段：
+// full时为所有字段（请求体中没有的置为零值），否则为请求体中出现的字段
+func (c *RoleController) unmarshalMulti(full bool) (vs []*models.Role, fields [][]string, err error) {
+	var reqs []*dto.RoleUpdateRequest
+	if err = json.Unmarshal(c.Ctx.Input.RequestBody, &reqs); err != nil {
+		return
+	}
+	for _, req := range reqs {
+		v := &models.Role{Id: req.Id}
+		vs = append(vs, v)
+		if f := req.Apply(v); !full {
+			fields = append(fields, f)
+		} else {
+			fields = append(fields, dto.RoleUpdateFields)
+		}
+	}
+	return
+}
+
+// @Description 按唯一键新增或修改role，by可选 name
+// @router /upsert [put]
+func (c *RoleController) Upsert() {
+	by := c.GetString("by")
+	// pos91
+	jr := gjson.ParseBytes(c.Ctx.Input.RequestBody)
+	if jr.IsObject() {
+		var req dto.RoleCreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+			v := req.ToModel()
+			// pos92
+			if _, err := models.UpsertRole(v, by); err == nil {
+				// pos93
+				c.Data["json"] = dto.NewRoleResponse(v)
+			} else if ve, ok := err.(*models.LgValidationError); ok {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = ve
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		var reqs []*dto.RoleCreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &reqs); err == nil {
+			vs := make([]*models.Role, len(reqs))
+			for i, req := range reqs {
+				vs[i] = req.ToModel()
+			}
+			if result, err := models.UpsertMultiRole(vs, by); err == nil {
+				if !result.Committed {
+					c.Ctx.Output.SetStatus(400)
+				}
+				c.Data["json"] = result
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	}
+	c.ServeJSON()
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	// posimport
+
+	"strconv"
+
+	"github.com/astaxie/beego"
+)
+
+// RulePermController operations for RulePerm
+type RulePermController struct {
+	beego.Controller
+}
+
+// URLMapping ...
+func (c *RulePermController) URLMapping() {
+	c.Mapping("Post", c.Post)
+	c.Mapping("GetOne", c.GetOne)
+}
+
+// @Description create RulePerm
+// @router / [post]
+func (c *RulePermController) Post() {
+	// pos11
+	c.ServeJSON()
+}
+
+// @Description get RulePerm by id
+// @router /:id [get]
+func (c *RulePermController) GetOne() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	if id == 0 {
+
+	}
+	// pos21
+	c.ServeJSON()
+}
@@ -0,0 +1,542 @@
+package controllers
+
+import (
+	"app/models"
+	"app/models/dto"
+	"encoding/json"
+	"errors"
+	"github.com/tidwall/gjson"
+	"strconv"
+	"strings"
+	// posimport
+)
+
+// UserController operations for User
+type UserController struct {
+	BaseController
+}
+
+// URLMapping ...
+func (c *UserController) URLMapping() {
+	c.Mapping("Post", c.Post)
+	c.Mapping("GetOne", c.GetOne)
+	c.Mapping("GetAll", c.GetAll)
+	c.Mapping("Put", c.Put)
+	c.Mapping("Patch", c.Patch)
+	c.Mapping("PatchM2MPart", c.PatchM2MPart)
+	c.Mapping("Delete", c.Delete)
+	c.Mapping("PutMulti", c.PutMulti)
+	c.Mapping("PatchMulti", c.PatchMulti)
+	c.Mapping("DeleteMulti", c.DeleteMulti)
+	c.Mapping("Upsert", c.Upsert)
+	c.Mapping("Export", c.Export)
+	c.Mapping("Import", c.Import)
+}
+
+// @Description 新建user
+// @router / [post]
+func (c *UserController) Post() {
+	// rule:begin User.Post.begin
+	if claims := c.ParseClaims(); claims == nil || !(claims["role"] == "admin" || claims["role"] == "editor") {
+		c.Ctx.Output.SetStatus(403)
+		c.Data["json"] = "没有权限！"
+		c.ServeJSON()
+		return
+	}
+	// rule:end User.Post.begin
+	// pos11
+	jr := gjson.ParseBytes(c.Ctx.Input.RequestBody)
+	if jr.IsObject() {
+		var req dto.UserCreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+			v := req.ToModel()
+			if err := v.Validate(); err != nil {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err
+				c.ServeJSON()
+				return
+			}
+			// pos12
+			// rule:begin User.Post.before_save
+			v.Name = strings.TrimSpace(v.Name)
+			if !(v.Age >= 18) {
+				c.Ctx.Output.SetStatus(422)
+				c.Data["json"] = "未满18岁"
+				c.ServeJSON()
+				return
+			}
+			// rule:end User.Post.before_save
+			if _, err := models.AddUserHasMany(v); err == nil {
+				// pos13
+				c.Ctx.Output.SetStatus(201)
+				c.Data["json"] = dto.NewUserResponse(v)
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		var reqs []*dto.UserCreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &reqs); err == nil {
+			vs := make([]*models.User, len(reqs))
+			for i, req := range reqs {
+				vs[i] = req.ToModel()
+			}
+			if err := models.LgValidateAll(len(vs), func(i int) error { return vs[i].Validate() }); err != nil {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err
+				c.ServeJSON()
+				return
+			}
+			if successNums, err := models.AddMultiUser(vs); err == nil {
+				c.Ctx.Output.SetStatus(201)
+				c.Data["json"] = successNums
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	}
+	c.ServeJSON()
+}
+
+// @Description 获取user信息
+// @router /:id [get]
+func (c *UserController) GetOne() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	v, err := models.GetUserById(id)
+	var load []string
+
+	if v := c.GetString("load"); v != "" {
+		load = strings.Split(v, ",")
+	}
+	// pos21
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	} else {
+		// pos22
+		if len(load) != 0 {
+			for _, lo := range load {
+				_, err := v.LoadRelatedOf(lo)
+				if err != nil {
+					c.Ctx.Output.SetStatus(400)
+					c.Data["json"] = err.Error()
+					c.ServeJSON()
+					return
+				}
+			}
+		}
+		c.Data["json"] = dto.NewUserResponse(v)
+	}
+	c.ServeJSON()
+}
+
+// GetAll ...
+// @Title Get All
+// @Description 搜索user信息
+// @Param	query	query	string	false	"Filter. e.g. col1:v1,col2:v2 ..."
+// @Param	fields	query	string	false	"Fields returned. e.g. col1,col2 ..."
+// @Param	sortby	query	string	false	"Sorted-by fields. e.g. col1,col2 ..."
+// @Param	order	query	string	false	"Order corresponding to each sortby field, if single value, apply to all sortby fields. e.g. desc,asc ..."
+// @Param	limit	query	string	false	"Limit the size of result set. Must be an integer"
+// @Param	offset	query	string	false	"Start position of result set. Must be an integer"
+// @Param	page	query	string	false	"Page number of result set. Must be an integer"
+// @Param	load	query	string	false	"LoadRelatedOf. e.g. As,Bs,C ..."
+// @Param	getcounts	query	int	false	"GetCounts. e.g. 传1时仅返回记录数"
+// @Success 200 {object} dto.UserResponse
+// @Failure 403
+// @router / [get]
+func (c *UserController) GetAll() {
+	var fields []string
+	var sortby []string
+	var order []string
+	var load []string
+	var limit int64 = 10
+	var page int64 = 0
+	var offset int64
+	var getcounts int = 0
+
+	// getcounts: 0 (default is 0)
+	if v, err := c.GetInt("getcounts"); err == nil {
+		getcounts = v
+	}
+
+	// query: k:v,k:v
+	query, err := c.parseQuery()
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+		c.ServeJSON()
+		return
+	}
+
+	if getcounts == 1 {
+		nums, err := models.GetUserCounts(query)
+		if err != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		} else {
+			c.Data["json"] = nums
+		}
+		c.ServeJSON()
+		return
+	}
+
+	// fields: col1,col2,entity.col3
+	if v := c.GetString("fields"); v != "" {
+		fields = strings.Split(v, ",")
+	}
+	if v := c.GetString("load"); v != "" {
+		load = strings.Split(v, ",")
+	}
+
+	// order: desc,asc
+	if v := c.GetString("order"); v != "" {
+		order = strings.Split(v, ",")
+	}
+	// sortby: col1,col2
+	if v := c.GetString("sortby"); v != "" {
+		sortby = strings.Split(v, ",")
+	}
+	if v, err := c.GetInt64("page"); err == nil {
+		page = v
+	}
+	// limit: 10 (default is 10)
+	if v, err := c.GetInt64("limit"); err == nil {
+		limit = v
+	}
+	// offset: 0 (default is 0)
+	if v, err := c.GetInt64("offset"); err == nil {
+		offset = v
+	}
+
+	l, pager, err := models.GetAllUser(query, fields, sortby, order, offset, limit, load, page)
+	// pos31
+	if err != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	} else {
+		if pager != nil {
+			pager.List = dto.NewUserResponses(l)
+			c.Data["json"] = pager
+		} else {
+			c.Data["json"] = dto.NewUserResponses(l)
+		}
+	}
+	c.ServeJSON()
+}
+
+// @Description 修改user
+// @router /:id [put]
+func (c *UserController) Put() {
+	// rule:begin User.Put.begin
+	if claims := c.ParseClaims(); claims == nil || !(claims["uid"] != nil) {
+		c.Ctx.Output.SetStatus(403)
+		c.Data["json"] = "没有权限！"
+		c.ServeJSON()
+		return
+	}
+	// rule:end User.Put.begin
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	v := models.User{Id: id}
+	var req dto.UserUpdateRequest
+
+	// pos41
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+		fileds := req.Apply(&v)
+		if len(fileds) == 0 {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = "没有匹配字段！"
+			c.ServeJSON()
+			return
+		}
+		if err := v.ValidateFields(fileds...); err != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err
+			c.ServeJSON()
+			return
+		}
+		// pos42
+		if err := models.PatchUserById(&v, fileds); err == nil {
+			// pos43
+			c.Data["json"] = "OK"
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 修改user
+// @router /:id [Patch]
+func (c *UserController) Patch() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	v := models.User{Id: id}
+	var req dto.UserUpdateRequest
+
+	// pos51
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+		fileds := req.Apply(&v)
+		if len(fileds) == 0 {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = "没有匹配字段！"
+			c.ServeJSON()
+			return
+		}
+		if err := v.ValidateFields(fileds...); err != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err
+			c.ServeJSON()
+			return
+		}
+		// pos52
+		if err := models.PatchUserById(&v, fileds); err == nil {
+			// pos53
+			c.Data["json"] = "OK"
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 修改user的关系
+// @router /m2m/part/:id [Patch]
+func (c *UserController) PatchM2MPart() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	v := models.User{Id: id}
+
+	var m2mField string
+	// field
+	if v := c.GetString("m2m_field"); v != "" {
+		m2mField = v
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = "m2m_field不能为空！"
+		c.ServeJSON()
+		return
+	}
+	AddOrDelIds := struct {
+		Add []int
+		Del []int
+	}{}
+
+	// pos_m2m_1
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &AddOrDelIds); err == nil {
+		// pos_m2m_2
+		if err := models.PatchUserM2MPartById(&v, m2mField, AddOrDelIds.Add, AddOrDelIds.Del); err == nil {
+			// pos_m2m_3
+			c.Data["json"] = "OK"
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 删除user
+// @router /:id [delete]
+func (c *UserController) Delete() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.Atoi(idStr)
+	if err := models.DeleteUser(id); err == nil {
+		c.Data["json"] = "OK"
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 批量替换user，请求体为带Id的数组，未出现的字段置为零值
+// @router / [put]
+func (c *UserController) PutMulti() {
+	// pos61
+	if vs, fields, err := c.unmarshalMulti(true); err == nil {
+		// pos62
+		if result, err := models.UpdateMultiUser(vs, fields); err == nil {
+			// pos63
+			if !result.Committed {
+				c.Ctx.Output.SetStatus(400)
+			}
+			c.Data["json"] = result
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 批量修改user，请求体为带Id的数组，只修改出现的字段
+// @router / [patch]
+func (c *UserController) PatchMulti() {
+	// pos71
+	if vs, fields, err := c.unmarshalMulti(false); err == nil {
+		// pos72
+		if result, err := models.UpdateMultiUser(vs, fields); err == nil {
+			// pos73
+			if !result.Committed {
+				c.Ctx.Output.SetStatus(400)
+			}
+			c.Data["json"] = result
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// @Description 批量删除user，ids=1,2,3 或 query=k:v,k:v
+// @router / [delete]
+func (c *UserController) DeleteMulti() {
+	var result *models.LgBatchResult
+	var err error
+	// pos81
+	if v := c.GetString("ids"); v != "" {
+		var ids []int
+		for _, idStr := range strings.Split(v, ",") {
+			id, e := strconv.Atoi(strings.TrimSpace(idStr))
+			if e != nil {
+				err = errors.New("Error: invalid id " + idStr)
+				break
+			}
+			ids = append(ids, id)
+		}
+		if err == nil {
+			result, err = models.DeleteMultiUserByIds(ids)
+		}
+	} else if v := c.GetString("query"); v != "" {
+		var query map[string]string
+		if query, err = c.parseQuery(); err == nil {
+			result, err = models.DeleteMultiUserByQuery(query)
+		}
+	} else {
+		err = errors.New("ids和query不能同时为空！")
+	}
+	if err == nil {
+		// pos82
+		if !result.Committed {
+			c.Ctx.Output.SetStatus(400)
+		}
+		c.Data["json"] = result
+	} else {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// parseQuery 解析query参数: k:v,k:v
+func (c *UserController) parseQuery() (map[string]string, error) {
+	var query = make(map[string]string)
+	if v := c.GetString("query"); v != "" {
+		for _, cond := range strings.Split(v, ",") {
+			kv := strings.SplitN(cond, ":", 2)
+			if len(kv) != 2 {
+				return nil, errors.New("Error: invalid query key/value pair")
+			}
+			k, v := kv[0], kv[1]
+			query[k] = v
+		}
+	}
+	return query, nil
+}
+
+// unmarshalMulti 解析批量修改的请求体，返回每条记录及要修改的字段：
+// full时为所有字段（请求体中没有的置为零值），否则为请求体中出现的字段
+func (c *UserController) unmarshalMulti(full bool) (vs []*models.User, fields [][]string, err error) {
+	var reqs []*dto.UserUpdateRequest
+	if err = json.Unmarshal(c.Ctx.Input.RequestBody, &reqs); err != nil {
+		return
+	}
+	for _, req := range reqs {
+		v := &models.User{Id: req.Id}
+		vs = append(vs, v)
+		if f := req.Apply(v); !full {
+			fields = append(fields, f)
+		} else {
+			fields = append(fields, dto.UserUpdateFields)
+		}
+	}
+	return
+}
+
+// @Description 按唯一键新增或修改user，by可选 email
+// @router /upsert [put]
+func (c *UserController) Upsert() {
+	by := c.GetString("by")
+	// pos91
+	jr := gjson.ParseBytes(c.Ctx.Input.RequestBody)
+	if jr.IsObject() {
+		var req dto.UserCreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err == nil {
+			v := req.ToModel()
+			// pos92
+			if _, err := models.UpsertUser(v, by); err == nil {
+				// pos93
+				c.Data["json"] = dto.NewUserResponse(v)
+			} else if ve, ok := err.(*models.LgValidationError); ok {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = ve
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		var reqs []*dto.UserCreateRequest
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &reqs); err == nil {
+			vs := make([]*models.User, len(reqs))
+			for i, req := range reqs {
+				vs[i] = req.ToModel()
+			}
+			if result, err := models.UpsertMultiUser(vs, by); err == nil {
+				if !result.Committed {
+					c.Ctx.Output.SetStatus(400)
+				}
+				c.Data["json"] = result
+			} else {
+				c.Ctx.Output.SetStatus(400)
+				c.Data["json"] = err.Error()
+			}
+		} else {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = err.Error()
+		}
+	}
+	c.ServeJSON()
+}
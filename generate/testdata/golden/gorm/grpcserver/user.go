@@ -0,0 +1,161 @@
+package grpcserver
+
+import (
+	"context"
+
+	"app/models"
+	pb "app/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// UserServer implements pb.UserServiceServer by the functions of models
+type UserServer struct {
+	pb.UnimplementedUserServiceServer
+}
+
+// userMaskFields update_mask的路径对应的字段
+var userMaskFields = map[string]string{
+	"name":    "Name",
+	"email":   "Email",
+	"status":  "Status",
+	"age":     "Age",
+	"team":    "Team",
+	"team.id": "Team",
+	"roles":   "Roles",
+}
+
+// userAllFields update_mask为空时修改的字段
+var userAllFields = []string{
+	"Name",
+	"Email",
+	"Status",
+	"Age",
+	"Team",
+}
+
+// CreateUser validates in and adds it together with its relations
+func (s *UserServer) CreateUser(ctx context.Context, in *pb.User) (*pb.User, error) {
+	m := userFromProto(in)
+	if err := m.Validate(); err != nil {
+		return nil, lgStatus(err)
+	}
+	if _, err := models.AddUserHasMany(m); err != nil {
+		return nil, lgStatus(err)
+	}
+	return userToProto(m), nil
+}
+
+// GetUser gets a User by id and loads the relations in load
+func (s *UserServer) GetUser(ctx context.Context, in *pb.GetRequest) (*pb.User, error) {
+	m, err := models.GetUserById(int(in.Id))
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	for _, lo := range in.Load {
+		if _, err := m.LoadRelatedOf(lo); err != nil {
+			return nil, lgStatus(err)
+		}
+	}
+	return userToProto(m), nil
+}
+
+// ListUsers returns a page of Users, limit defaults to 10 like the REST GetAll
+func (s *UserServer) ListUsers(ctx context.Context, in *pb.ListRequest) (*pb.UserList, error) {
+	limit := in.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	l, pager, err := models.GetAllUser(in.Query, nil, in.Sortby, in.Order, in.Offset, limit, in.Load, 1)
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	out := &pb.UserList{Total: pager.Page.TotalCount}
+	for _, v := range l {
+		m := v.(models.User)
+		out.Items = append(out.Items, userToProto(&m))
+	}
+	return out, nil
+}
+
+// UpdateUser updates the fields in update_mask of an existing User
+func (s *UserServer) UpdateUser(ctx context.Context, in *pb.UpdateUserRequest) (*pb.User, error) {
+	if in.User == nil {
+		return nil, status.Error(codes.InvalidArgument, "user is required")
+	}
+	fields, err := lgMaskFields(in.UpdateMask, userMaskFields, userAllFields)
+	if err != nil {
+		return nil, err
+	}
+	m := userFromProto(in.User)
+	if _, err := models.GetUserById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := m.ValidateFields(fields...); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.PatchUserById(m, fields); err != nil {
+		return nil, lgStatus(err)
+	}
+	if m, err = models.GetUserById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	return userToProto(m), nil
+}
+
+// DeleteUser deletes a User by id
+func (s *UserServer) DeleteUser(ctx context.Context, in *pb.DeleteRequest) (*emptypb.Empty, error) {
+	if _, err := models.GetUserById(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.DeleteUser(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// userToProto converts m to a pb.User, relations are converted when loaded
+func userToProto(m *models.User) *pb.User {
+	p := &pb.User{
+		Id:        int64(m.Id),
+		Name:      m.Name,
+		Email:     m.Email,
+		Status:    m.Status,
+		Age:       uint32(m.Age),
+		CreatedAt: lgTimestamp(m.CreatedAt),
+	}
+	if m.Team != nil {
+		p.Team = teamToProto(m.Team)
+	}
+	if m.Profile != nil {
+		p.Profile = profileToProto(m.Profile)
+	}
+	for _, v := range m.Roles {
+		p.Roles = append(p.Roles, roleToProto(v))
+	}
+	return p
+}
+
+// userFromProto converts p to a models.User
+func userFromProto(p *pb.User) *models.User {
+	m := &models.User{
+		Id:        int(p.Id),
+		Name:      p.Name,
+		Email:     p.Email,
+		Status:    p.Status,
+		Age:       uint8(p.Age),
+		CreatedAt: lgTime(p.CreatedAt),
+	}
+	if p.Team != nil {
+		m.Team = &models.Team{Id: int(p.Team.Id)}
+	}
+	if p.Profile != nil {
+		m.Profile = profileFromProto(p.Profile)
+	}
+	for _, v := range p.Roles {
+		m.Roles = append(m.Roles, &models.Role{Id: int(v.Id)})
+	}
+	return m
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// DB is the connection used by the models, opened by OpenDB. Assign it directly
+// to use a database other than MySQL.
+var DB *gorm.DB
+
+// OpenDB opens DB on the MySQL dsn, parseTime is always on for the time.Time fields
+func OpenDB(dsn string) error {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return err
+	}
+	cfg.ParseTime = true
+	DB, err = gorm.Open(gormmysql.Open(cfg.FormatDSN()), &gorm.Config{})
+	return err
+}
+
+func lgPostgres() bool {
+	return DB.Dialector.Name() == "postgres"
+}
+
+// lgQuote 按数据库引用表名、列名
+func lgQuote(name string) string {
+	if lgPostgres() {
+		return "\"" + name + "\""
+	}
+	return "`" + name + "`"
+}
+
+// lgWhere where为空时不加条件
+func lgWhere(db *gorm.DB, where string, args []interface{}) *gorm.DB {
+	if where == "" {
+		return db
+	}
+	return db.Where(where, args...)
+}
+
+// End 全部成功时提交事务，否则回滚
+func (r *LgBatchResult) End(tx *gorm.DB) (err error) {
+	if r.Failed > 0 {
+		return tx.Rollback().Error
+	}
+	if err = tx.Commit().Error; err == nil {
+		r.Committed = true
+	}
+	return
+}
+
+// lgExists 是否存在columns的值为args的其它记录，id不为0时排除自身
+func lgExists(table string, pk string, columns []string, args []interface{}, id int) bool {
+	var conds []string
+	for _, column := range columns {
+		conds = append(conds, lgQuote(column)+" = ?")
+	}
+	if id != 0 {
+		conds = append(conds, lgQuote(pk)+" <> ?")
+		args = append(args, id)
+	}
+	var count int64
+	DB.Table(table).Where(strings.Join(conds, " AND "), args...).Count(&count)
+	return count > 0
+}
+
+// LgThroughIds 一次查询中间表through中column为ids的关联，返回id到refColumn的对应
+func LgThroughIds(through string, column string, refColumn string, ids []int) (map[int][]int, error) {
+	rv := make(map[int][]int)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	rows, err := DB.Table(through).Select(lgQuote(column)+", "+lgQuote(refColumn)).Where(lgQuote(column)+" IN ?", ids).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, refId int
+		if err := rows.Scan(&id, &refId); err != nil {
+			return nil, err
+		}
+		rv[id] = append(rv[id], refId)
+	}
+	return rv, rows.Err()
+}
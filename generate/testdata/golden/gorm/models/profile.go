@@ -0,0 +1,331 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Profile struct {
+	Id     int    `gorm:"column:id;primaryKey;autoIncrement"`
+	Bio    string `gorm:"column:bio"`
+	User   *User  `gorm:"foreignKey:UserId" description:"与[user] 一对一 关系，该表为扩展表，该表user_id为关联字段。"`
+	UserId *int   `gorm:"column:user_id" json:"-"`
+}
+
+func (t *Profile) TableName() string {
+	return "profile"
+}
+
+// ProfileColumns maps the fields and columns accepted by query and sortby to the columns
+var ProfileColumns = map[string]string{
+	"Id":       "id",
+	"id":       "id",
+	"Bio":      "bio",
+	"bio":      "bio",
+	"User":     "user_id",
+	"user_id":  "user_id",
+	"User__Id": "user_id",
+	"user__id": "user_id",
+}
+
+// BeforeSave keeps the foreign key columns in line with the relation fields
+func (t *Profile) BeforeSave(tx *gorm.DB) error {
+	if t.User != nil {
+		id := t.User.Id
+		t.UserId = &id
+	}
+	return nil
+}
+
+// AfterFind sets the relation fields to their Id like beego orm, Preload loads the rest
+func (t *Profile) AfterFind(tx *gorm.DB) error {
+	if t.User == nil && t.UserId != nil {
+		t.User = &User{Id: *t.UserId}
+	}
+	return nil
+}
+
+// LoadRelatedOf loads the relation r of t by Preload, args are the conditions of r
+func (t *Profile) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	if err := DB.Preload(r, args...).Take(t).Error; err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// AddProfile insert a new Profile into database and returns
+// last inserted Id on success.
+func AddProfile(m *Profile) (id int64, err error) {
+	err = DB.Omit(clause.Associations).Create(m).Error
+	id = int64(m.Id)
+	return
+}
+
+// AddMultiProfile insert multi Profiles into database and returns
+// sum success nums.
+func AddMultiProfile(ms []*Profile) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 分批插入，在同一个事务中
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit(clause.Associations).CreateInBatches(ms, 100).Error
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// AddProfileHasMany insert a new Profile and some items into database and returns
+// last inserted Id on success.
+func AddProfileHasMany(m *Profile) (id int64, err error) {
+	// 一对多、一对一的对象一起新增，多对多只新增中间表
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit("User").Create(m).Error
+	})
+	id = int64(m.Id)
+	return
+}
+
+// GetProfileById retrieves Profile by Id. Returns error if
+// Id doesn't exist
+func GetProfileById(id int) (v *Profile, err error) {
+	v = &Profile{}
+	if err = DB.Take(v, id).Error; err == nil {
+		return v, nil
+	}
+	return nil, err
+}
+
+// GetProfileCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetProfileCounts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, ProfileColumns)
+	if err != nil {
+		return
+	}
+	err = lgWhere(DB.Model(&Profile{}), where, args).Count(&count).Error
+	return
+}
+
+// GetAllProfile retrieves all Profile matches certain condition. Returns empty list if
+// no records exist
+func GetAllProfile(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, ProfileColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, ProfileColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf(Profile{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	qs := lgWhere(DB.Model(&Profile{}), where, args).Session(&gorm.Session{})
+	var count int64 = 0
+	if page == 1 {
+		qs.Count(&count)
+	}
+	if orderBy != "" {
+		qs = qs.Order(orderBy)
+	}
+	for _, lo := range load {
+		qs = qs.Preload(lo)
+	}
+
+	var l []Profile
+	if err = qs.Limit(int(limit)).Offset(int(offset)).Find(&l).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			ml = append(ml, v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// UpdateProfile updates Profile by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateProfileById(m *Profile) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) (err error) {
+		// ascertain id exists in the database
+		if err = tx.Take(&Profile{}, m.Id).Error; err != nil {
+			return
+		}
+
+		return tx.Model(m).Select("*").Omit(clause.Associations).Updates(m).Error
+	})
+}
+
+// PatchProfile updates Profile by Id and returns error if
+// the record to be updated doesn't exist
+func PatchProfileById(m *Profile, fields []string) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return patchProfile(tx, m, fields)
+	})
+}
+
+// patchProfile updates the given fields of Profile inside the
+// transaction of tx, many to many fields are replaced
+func patchProfile(tx *gorm.DB, m *Profile, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+		case "User":
+			columns = append(columns, "UserId")
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(columns) == 0 {
+		return
+	}
+	return tx.Model(m).Select(columns).Omit(clause.Associations).Updates(m).Error
+}
+
+// UpdateMultiProfile updates several Profiles in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiProfile(ms []*Profile, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = tx.Take(&Profile{}, m.Id).Error; itemErr == nil {
+				itemErr = patchProfile(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiProfileByIds deletes Profiles by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiProfileByIds(ids []int) (result *LgBatchResult, err error) {
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = deleteProfileBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiProfileByQuery deletes all Profiles matches the same query as
+// GetAllProfile in one transaction.
+func DeleteMultiProfileByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, ProfileColumns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Model(&Profile{}).Where(where, args...).Pluck("id", &ids).Error; err != nil {
+		tx.Rollback()
+		return
+	}
+	result = deleteProfileBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// deleteProfileBatch deletes Profiles one by one inside the transaction of tx
+func deleteProfileBatch(tx *gorm.DB, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		res := tx.Delete(&Profile{}, id)
+		err := res.Error
+		if err == nil && res.RowsAffected == 0 {
+			err = gorm.ErrRecordNotFound
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchProfileM2MPart updates Profile by Id and returns error if
+// the record to be updated doesn't exist
+func PatchProfileM2MPartById(m *Profile, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		switch field {
+
+		}
+		return nil
+	})
+}
+
+// DeleteProfile deletes Profile by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteProfile(id int) (err error) {
+	v := Profile{}
+	// ascertain id exists in the database
+	if err = DB.Take(&v, id).Error; err == nil {
+		res := DB.Delete(&Profile{}, id)
+		if err = res.Error; err == nil {
+			fmt.Println("Number of records deleted in database:", res.RowsAffected)
+		}
+	}
+	return
+}
@@ -0,0 +1,423 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"strings"
+)
+
+type Role struct {
+	Id    int     `gorm:"column:id;primaryKey;autoIncrement"`
+	Name  string  `gorm:"column:name;size:32;not null"`
+	Users []*User `gorm:"many2many:user_has_role;joinForeignKey:RoleId;joinReferences:UserId" description:"与[user] 多对多 关系，中间表是：user_has_role"`
+}
+
+func (t *Role) TableName() string {
+	return "role"
+}
+
+// RoleColumns maps the fields and columns accepted by query and sortby to the columns
+var RoleColumns = map[string]string{
+	"Id":   "id",
+	"id":   "id",
+	"Name": "name",
+	"name": "name",
+}
+
+// LoadRelatedOf loads the relation r of t by Preload, args are the conditions of r
+func (t *Role) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	if err := DB.Preload(r, args...).Take(t).Error; err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// AddRole insert a new Role into database and returns
+// last inserted Id on success.
+func AddRole(m *Role) (id int64, err error) {
+	err = DB.Omit(clause.Associations).Create(m).Error
+	id = int64(m.Id)
+	return
+}
+
+// AddMultiRole insert multi Roles into database and returns
+// sum success nums.
+func AddMultiRole(ms []*Role) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 分批插入，在同一个事务中
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit(clause.Associations).CreateInBatches(ms, 100).Error
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// AddRoleHasMany insert a new Role and some items into database and returns
+// last inserted Id on success.
+func AddRoleHasMany(m *Role) (id int64, err error) {
+	// 一对多、一对一的对象一起新增，多对多只新增中间表
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit("Users.*").Create(m).Error
+	})
+	id = int64(m.Id)
+	return
+}
+
+// GetRoleById retrieves Role by Id. Returns error if
+// Id doesn't exist
+func GetRoleById(id int) (v *Role, err error) {
+	v = &Role{}
+	if err = DB.Take(v, id).Error; err == nil {
+		return v, nil
+	}
+	return nil, err
+}
+
+// GetRoleCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetRoleCounts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, RoleColumns)
+	if err != nil {
+		return
+	}
+	err = lgWhere(DB.Model(&Role{}), where, args).Count(&count).Error
+	return
+}
+
+// GetAllRole retrieves all Role matches certain condition. Returns empty list if
+// no records exist
+func GetAllRole(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, RoleColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, RoleColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf(Role{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	qs := lgWhere(DB.Model(&Role{}), where, args).Session(&gorm.Session{})
+	var count int64 = 0
+	if page == 1 {
+		qs.Count(&count)
+	}
+	if orderBy != "" {
+		qs = qs.Order(orderBy)
+	}
+	for _, lo := range load {
+		qs = qs.Preload(lo)
+	}
+
+	var l []Role
+	if err = qs.Limit(int(limit)).Offset(int(offset)).Find(&l).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			ml = append(ml, v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// UpdateRole updates Role by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateRoleById(m *Role) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) (err error) {
+		// ascertain id exists in the database
+		if err = tx.Take(&Role{}, m.Id).Error; err != nil {
+			return
+		}
+		// m2m_update
+		if m.Users != nil {
+			if err = tx.Model(m).Omit("Users.*").Association("Users").Replace(m.Users); err != nil {
+				return
+			}
+		}
+		return tx.Model(m).Select("*").Omit(clause.Associations).Updates(m).Error
+	})
+}
+
+// PatchRole updates Role by Id and returns error if
+// the record to be updated doesn't exist
+func PatchRoleById(m *Role, fields []string) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return patchRole(tx, m, fields)
+	})
+}
+
+// patchRole updates the given fields of Role inside the
+// transaction of tx, many to many fields are replaced
+func patchRole(tx *gorm.DB, m *Role, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+		case "Users":
+			// m2m_patch
+			if m.Users != nil {
+				if err = tx.Model(m).Omit("Users.*").Association("Users").Replace(m.Users); err != nil {
+					return
+				}
+			}
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(columns) == 0 {
+		return
+	}
+	return tx.Model(m).Select(columns).Omit(clause.Associations).Updates(m).Error
+}
+
+// UpdateMultiRole updates several Roles in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiRole(ms []*Role, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = tx.Take(&Role{}, m.Id).Error; itemErr == nil {
+				itemErr = patchRole(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiRoleByIds deletes Roles by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiRoleByIds(ids []int) (result *LgBatchResult, err error) {
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = deleteRoleBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiRoleByQuery deletes all Roles matches the same query as
+// GetAllRole in one transaction.
+func DeleteMultiRoleByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, RoleColumns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Model(&Role{}).Where(where, args...).Pluck("id", &ids).Error; err != nil {
+		tx.Rollback()
+		return
+	}
+	result = deleteRoleBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// deleteRoleBatch deletes Roles one by one inside the transaction of tx
+func deleteRoleBatch(tx *gorm.DB, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		res := tx.Delete(&Role{}, id)
+		err := res.Error
+		if err == nil && res.RowsAffected == 0 {
+			err = gorm.ErrRecordNotFound
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchRoleM2MPart updates Role by Id and returns error if
+// the record to be updated doesn't exist
+func PatchRoleM2MPartById(m *Role, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		switch field {
+		case "Users":
+			for _, did := range DelIds {
+				if err := tx.Model(m).Association("Users").Delete(&User{Id: did}); err != nil {
+					return err
+				}
+			}
+			for _, aid := range AddIds {
+				// 已关联的不再加入
+				if tx.Model(m).Where(lgQuote("user")+"."+lgQuote("id")+" = ?", aid).Association("Users").Count() > 0 {
+					continue
+				}
+				if err := tx.Model(m).Omit("Users.*").Association("Users").Append(&User{Id: aid}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteRole deletes Role by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteRole(id int) (err error) {
+	v := Role{}
+	// ascertain id exists in the database
+	if err = DB.Take(&v, id).Error; err == nil {
+		res := DB.Delete(&Role{}, id)
+		if err = res.Error; err == nil {
+			fmt.Println("Number of records deleted in database:", res.RowsAffected)
+		}
+	}
+	return
+}
+
+// upsertRoleArgs returns the values of the columns inserted by upsert
+func upsertRoleArgs(m *Role) []interface{} {
+
+	return []interface{}{m.Name}
+}
+
+// UpsertRoleByName inserts m, or updates the Role with the same
+// (name), and returns the Id on success. (name) is the only unique key
+// of the table, so the conflict handled by MySQL is always on it.
+func UpsertRoleByName(m *Role) (id int64, err error) {
+	err = DB.Transaction(func(tx *gorm.DB) (err error) {
+		id, err = upsertRoleByName(tx, m)
+		return
+	})
+	return
+}
+
+func upsertRoleByName(tx *gorm.DB, m *Role) (id int64, err error) {
+	args := upsertRoleArgs(m)
+	if tx.Dialector.Name() == "postgres" {
+		err = tx.Raw("INSERT INTO \"role\" (\"name\") VALUES (?) ON CONFLICT (\"name\") DO UPDATE SET \"name\" = EXCLUDED.\"name\" RETURNING \"id\"", args...).Row().Scan(&id)
+	} else if err = tx.Exec("INSERT INTO `role` (`name`) VALUES (?) ON DUPLICATE KEY UPDATE `id` = LAST_INSERT_ID(`id`)", args...).Error; err == nil {
+		// LAST_INSERT_ID按连接保存，所以要在事务中读取
+		err = tx.Raw("SELECT LAST_INSERT_ID()").Row().Scan(&id)
+	}
+	if err != nil {
+		return
+	}
+	// 重新读取，修改时m中的字段不一定与数据库一致
+	m.Id = int(id)
+	err = tx.Take(m).Error
+	return
+}
+
+// RoleUniqueKeys lists the unique keys accepted by the by argument of UpsertRole
+var RoleUniqueKeys = []string{"name"}
+
+func upsertRoleFunc(by string) (func(*gorm.DB, *Role) (int64, error), error) {
+	switch by {
+	case "", "name":
+		return upsertRoleByName, nil
+	}
+	return nil, errors.New("Error: unknown unique key '" + by + "', must be one of " + strings.Join(RoleUniqueKeys, " | "))
+}
+
+// UpsertRole inserts or updates m by the unique key by, the columns of by are
+// separated by ",", an empty by means the first unique key.
+func UpsertRole(m *Role, by string) (id int64, err error) {
+	upsert, err := upsertRoleFunc(by)
+	if err != nil {
+		return
+	}
+	// 唯一键冲突时修改，只校验列
+	if err = m.ValidateColumns(); err != nil {
+		return
+	}
+	err = DB.Transaction(func(tx *gorm.DB) (err error) {
+		id, err = upsert(tx, m)
+		return
+	})
+	return
+}
+
+// UpsertMultiRole inserts or updates several Roles by the unique key by in
+// one transaction. Nothing is committed unless every item succeeds, and the items after
+// the first failed one are skipped.
+func UpsertMultiRole(ms []*Role, by string) (result *LgBatchResult, err error) {
+	upsert, err := upsertRoleFunc(by)
+	if err != nil {
+		return
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		itemErr := m.ValidateColumns()
+		if itemErr == nil {
+			_, itemErr = upsert(tx, m)
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
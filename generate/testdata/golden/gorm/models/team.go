@@ -0,0 +1,309 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Team struct {
+	Id    int     `gorm:"column:id;primaryKey;autoIncrement"`
+	Name  string  `gorm:"column:name;size:32;not null" description:"team name"`
+	Users []*User `gorm:"foreignKey:TeamId" description:"与[user] 一对多 关系，该表为主表，该表id为关联字段。"`
+}
+
+func (t *Team) TableName() string {
+	return "team"
+}
+
+// TeamColumns maps the fields and columns accepted by query and sortby to the columns
+var TeamColumns = map[string]string{
+	"Id":   "id",
+	"id":   "id",
+	"Name": "name",
+	"name": "name",
+}
+
+// LoadRelatedOf loads the relation r of t by Preload, args are the conditions of r
+func (t *Team) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	if err := DB.Preload(r, args...).Take(t).Error; err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// AddTeam insert a new Team into database and returns
+// last inserted Id on success.
+func AddTeam(m *Team) (id int64, err error) {
+	err = DB.Omit(clause.Associations).Create(m).Error
+	id = int64(m.Id)
+	return
+}
+
+// AddMultiTeam insert multi Teams into database and returns
+// sum success nums.
+func AddMultiTeam(ms []*Team) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 分批插入，在同一个事务中
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit(clause.Associations).CreateInBatches(ms, 100).Error
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// AddTeamHasMany insert a new Team and some items into database and returns
+// last inserted Id on success.
+func AddTeamHasMany(m *Team) (id int64, err error) {
+	// 一对多、一对一的对象一起新增，多对多只新增中间表
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit().Create(m).Error
+	})
+	id = int64(m.Id)
+	return
+}
+
+// GetTeamById retrieves Team by Id. Returns error if
+// Id doesn't exist
+func GetTeamById(id int) (v *Team, err error) {
+	v = &Team{}
+	if err = DB.Take(v, id).Error; err == nil {
+		return v, nil
+	}
+	return nil, err
+}
+
+// GetTeamCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetTeamCounts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, TeamColumns)
+	if err != nil {
+		return
+	}
+	err = lgWhere(DB.Model(&Team{}), where, args).Count(&count).Error
+	return
+}
+
+// GetAllTeam retrieves all Team matches certain condition. Returns empty list if
+// no records exist
+func GetAllTeam(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, TeamColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, TeamColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf(Team{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	qs := lgWhere(DB.Model(&Team{}), where, args).Session(&gorm.Session{})
+	var count int64 = 0
+	if page == 1 {
+		qs.Count(&count)
+	}
+	if orderBy != "" {
+		qs = qs.Order(orderBy)
+	}
+	for _, lo := range load {
+		qs = qs.Preload(lo)
+	}
+
+	var l []Team
+	if err = qs.Limit(int(limit)).Offset(int(offset)).Find(&l).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			ml = append(ml, v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// UpdateTeam updates Team by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateTeamById(m *Team) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) (err error) {
+		// ascertain id exists in the database
+		if err = tx.Take(&Team{}, m.Id).Error; err != nil {
+			return
+		}
+
+		return tx.Model(m).Select("*").Omit(clause.Associations).Updates(m).Error
+	})
+}
+
+// PatchTeam updates Team by Id and returns error if
+// the record to be updated doesn't exist
+func PatchTeamById(m *Team, fields []string) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return patchTeam(tx, m, fields)
+	})
+}
+
+// patchTeam updates the given fields of Team inside the
+// transaction of tx, many to many fields are replaced
+func patchTeam(tx *gorm.DB, m *Team, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+		case "Users":
+			// 反向的关系在关联表中，不修改
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(columns) == 0 {
+		return
+	}
+	return tx.Model(m).Select(columns).Omit(clause.Associations).Updates(m).Error
+}
+
+// UpdateMultiTeam updates several Teams in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiTeam(ms []*Team, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = tx.Take(&Team{}, m.Id).Error; itemErr == nil {
+				itemErr = patchTeam(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiTeamByIds deletes Teams by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiTeamByIds(ids []int) (result *LgBatchResult, err error) {
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = deleteTeamBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiTeamByQuery deletes all Teams matches the same query as
+// GetAllTeam in one transaction.
+func DeleteMultiTeamByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, TeamColumns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Model(&Team{}).Where(where, args...).Pluck("id", &ids).Error; err != nil {
+		tx.Rollback()
+		return
+	}
+	result = deleteTeamBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// deleteTeamBatch deletes Teams one by one inside the transaction of tx
+func deleteTeamBatch(tx *gorm.DB, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		res := tx.Delete(&Team{}, id)
+		err := res.Error
+		if err == nil && res.RowsAffected == 0 {
+			err = gorm.ErrRecordNotFound
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchTeamM2MPart updates Team by Id and returns error if
+// the record to be updated doesn't exist
+func PatchTeamM2MPartById(m *Team, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		switch field {
+
+		}
+		return nil
+	})
+}
+
+// DeleteTeam deletes Team by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteTeam(id int) (err error) {
+	v := Team{}
+	// ascertain id exists in the database
+	if err = DB.Take(&v, id).Error; err == nil {
+		res := DB.Delete(&Team{}, id)
+		if err = res.Error; err == nil {
+			fmt.Println("Number of records deleted in database:", res.RowsAffected)
+		}
+	}
+	return
+}
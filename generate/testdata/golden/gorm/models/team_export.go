@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// TeamExportFields lists the fields can be exported and imported
+var TeamExportFields = []string{"Id", "Name"}
+
+// TeamExportColumns lists the columns of TeamExportFields
+var TeamExportColumns = []string{"id", "name"}
+
+// TeamImportRequired lists the fields an import file must have
+var TeamImportRequired = []string{"Name"}
+
+// TeamImportUniqueKeys lists the fields of each unique key, which must not repeat in an import file
+var TeamImportUniqueKeys = [][]string{}
+
+// ExportTeam reads all Team matches the same query as GetAllTeam in the
+// order of Id batch by batch, and calls fn with each of them. Each batch starts after the last
+// Id of the previous one, so rows written during the export are neither skipped nor exported twice.
+func ExportTeam(query map[string]string, fn func(m *Team) error) (err error) {
+	q := make(map[string]string, len(query)+1)
+	for k, v := range query {
+		q[k] = v
+	}
+	for {
+		// 读取所有字段，由TeamCells取出fields
+		l, _, err := GetAllTeam(q, nil, []string{"Id"}, []string{"asc"}, 0, lgExportBatch, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, item := range l {
+			v := item.(Team)
+			if err = fn(&v); err != nil {
+				return err
+			}
+			q["Id__gt"] = strconv.Itoa(v.Id)
+		}
+		if len(l) < lgExportBatch {
+			return nil
+		}
+	}
+}
+
+// TeamCells returns the values of fields of m, relations are exported as their Id
+func TeamCells(m *Team, fields []string) []interface{} {
+	cells := make([]interface{}, len(fields))
+	for i, field := range fields {
+		switch field {
+		case "Id":
+			cells[i] = m.Id
+		case "Name":
+			cells[i] = m.Name
+		}
+	}
+	return cells
+}
+
+// setTeamField parses raw as the value of field and checks it against the column
+func setTeamField(m *Team, field string, raw string) error {
+	switch field {
+	case "Name":
+		if raw == "" {
+			return errors.New("Name is required")
+		}
+		m.Name = raw
+	}
+	return nil
+}
+
+// ImportTeam parses rows under header as Teams and inserts them by
+// AddMultiTeam. Nothing is inserted unless every row is valid. The header must
+// have the required fields, and the values of a unique key must not repeat in rows.
+func ImportTeam(header []string, rows [][]string) (result *LgBatchResult, err error) {
+	fields, err := lgImportFields(header, TeamExportFields, TeamExportColumns)
+	if err != nil {
+		return
+	}
+	if err = lgMissingFields(fields, TeamImportRequired); err != nil {
+		return
+	}
+	keys := newLgImportKeys(fields, TeamImportUniqueKeys)
+	result = &LgBatchResult{}
+	var ms []*Team
+	for i, row := range rows {
+		if lgEmptyRow(row) {
+			continue
+		}
+		m := new(Team)
+		var msgs []string
+		for j, field := range fields {
+			raw := ""
+			if j < len(row) {
+				raw = strings.TrimSpace(row[j])
+			}
+			if e := setTeamField(m, field, raw); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if len(msgs) == 0 {
+			if e := m.ValidateFields(fields...); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if e := keys.check(i, row); e != nil {
+			msgs = append(msgs, e.Error())
+		}
+		var rowErr error
+		if len(msgs) > 0 {
+			rowErr = errors.New(strings.Join(msgs, "; "))
+		}
+		result.Add(i, 0, rowErr)
+		ms = append(ms, m)
+	}
+	if result.Failed > 0 || len(ms) == 0 {
+		return
+	}
+	if _, err = AddMultiTeam(ms); err == nil {
+		result.Committed = true
+	}
+	return
+}
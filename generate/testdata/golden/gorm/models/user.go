@@ -0,0 +1,473 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type User struct {
+	Id        int       `gorm:"column:id;primaryKey;autoIncrement"`
+	Name      string    `gorm:"column:name;size:64;not null" description:"user name"`
+	Email     string    `gorm:"column:email;size:128;not null"`
+	Status    string    `gorm:"column:status;not null;default:on"`
+	Age       uint8     `gorm:"column:age"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;not null;autoCreateTime;default:CURRENT_TIMESTAMP"`
+	Profile   *Profile  `gorm:"foreignKey:UserId" description:"与[profile] 一对一 关系，该表为主表，该表id为关联字段。"`
+	Roles     []*Role   `gorm:"many2many:user_has_role;joinForeignKey:UserId;joinReferences:RoleId" description:"与[role] 多对多 关系，中间表是：user_has_role"`
+	Team      *Team     `gorm:"foreignKey:TeamId" description:"与[team] 一对多 关系，该表为从表，该表team_id为关联字段。"`
+	TeamId    *int      `gorm:"column:team_id" json:"-"`
+}
+
+func (t *User) TableName() string {
+	return "user"
+}
+
+// UserColumns maps the fields and columns accepted by query and sortby to the columns
+var UserColumns = map[string]string{
+	"Id":         "id",
+	"id":         "id",
+	"Name":       "name",
+	"name":       "name",
+	"Email":      "email",
+	"email":      "email",
+	"Status":     "status",
+	"status":     "status",
+	"Age":        "age",
+	"age":        "age",
+	"CreatedAt":  "created_at",
+	"created_at": "created_at",
+	"Team":       "team_id",
+	"team_id":    "team_id",
+	"Team__Id":   "team_id",
+	"team__id":   "team_id",
+}
+
+// BeforeSave keeps the foreign key columns in line with the relation fields
+func (t *User) BeforeSave(tx *gorm.DB) error {
+	if t.Team != nil {
+		id := t.Team.Id
+		t.TeamId = &id
+	}
+	return nil
+}
+
+// AfterFind sets the relation fields to their Id like beego orm, Preload loads the rest
+func (t *User) AfterFind(tx *gorm.DB) error {
+	if t.Team == nil && t.TeamId != nil {
+		t.Team = &Team{Id: *t.TeamId}
+	}
+	return nil
+}
+
+// LoadRelatedOf loads the relation r of t by Preload, args are the conditions of r
+func (t *User) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	if err := DB.Preload(r, args...).Take(t).Error; err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// AddUser insert a new User into database and returns
+// last inserted Id on success.
+func AddUser(m *User) (id int64, err error) {
+	err = DB.Omit(clause.Associations).Create(m).Error
+	id = int64(m.Id)
+	return
+}
+
+// AddMultiUser insert multi Users into database and returns
+// sum success nums.
+func AddMultiUser(ms []*User) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 分批插入，在同一个事务中
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit(clause.Associations).CreateInBatches(ms, 100).Error
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// AddUserHasMany insert a new User and some items into database and returns
+// last inserted Id on success.
+func AddUserHasMany(m *User) (id int64, err error) {
+	// 一对多、一对一的对象一起新增，多对多只新增中间表
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit("Roles.*", "Team").Create(m).Error
+	})
+	id = int64(m.Id)
+	return
+}
+
+// GetUserById retrieves User by Id. Returns error if
+// Id doesn't exist
+func GetUserById(id int) (v *User, err error) {
+	v = &User{}
+	if err = DB.Take(v, id).Error; err == nil {
+		return v, nil
+	}
+	return nil, err
+}
+
+// GetUserCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetUserCounts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, UserColumns)
+	if err != nil {
+		return
+	}
+	err = lgWhere(DB.Model(&User{}), where, args).Count(&count).Error
+	return
+}
+
+// GetAllUser retrieves all User matches certain condition. Returns empty list if
+// no records exist
+func GetAllUser(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, UserColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, UserColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf(User{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	qs := lgWhere(DB.Model(&User{}), where, args).Session(&gorm.Session{})
+	var count int64 = 0
+	if page == 1 {
+		qs.Count(&count)
+	}
+	if orderBy != "" {
+		qs = qs.Order(orderBy)
+	}
+	for _, lo := range load {
+		qs = qs.Preload(lo)
+	}
+
+	var l []User
+	if err = qs.Limit(int(limit)).Offset(int(offset)).Find(&l).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			ml = append(ml, v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// UpdateUser updates User by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateUserById(m *User) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) (err error) {
+		// ascertain id exists in the database
+		if err = tx.Take(&User{}, m.Id).Error; err != nil {
+			return
+		}
+		// m2m_update
+		if m.Roles != nil {
+			if err = tx.Model(m).Omit("Roles.*").Association("Roles").Replace(m.Roles); err != nil {
+				return
+			}
+		}
+		return tx.Model(m).Select("*").Omit(clause.Associations, "CreatedAt").Updates(m).Error
+	})
+}
+
+// PatchUser updates User by Id and returns error if
+// the record to be updated doesn't exist
+func PatchUserById(m *User, fields []string) (err error) {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return patchUser(tx, m, fields)
+	})
+}
+
+// patchUser updates the given fields of User inside the
+// transaction of tx, many to many fields are replaced
+func patchUser(tx *gorm.DB, m *User, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+		case "Roles":
+			// m2m_patch
+			if m.Roles != nil {
+				if err = tx.Model(m).Omit("Roles.*").Association("Roles").Replace(m.Roles); err != nil {
+					return
+				}
+			}
+		case "Team":
+			columns = append(columns, "TeamId")
+		case "Profile":
+			// 反向的关系在关联表中，不修改
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(columns) == 0 {
+		return
+	}
+	return tx.Model(m).Select(columns).Omit(clause.Associations).Updates(m).Error
+}
+
+// UpdateMultiUser updates several Users in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiUser(ms []*User, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = tx.Take(&User{}, m.Id).Error; itemErr == nil {
+				itemErr = patchUser(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiUserByIds deletes Users by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiUserByIds(ids []int) (result *LgBatchResult, err error) {
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = deleteUserBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiUserByQuery deletes all Users matches the same query as
+// GetAllUser in one transaction.
+func DeleteMultiUserByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, UserColumns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Model(&User{}).Where(where, args...).Pluck("id", &ids).Error; err != nil {
+		tx.Rollback()
+		return
+	}
+	result = deleteUserBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// deleteUserBatch deletes Users one by one inside the transaction of tx
+func deleteUserBatch(tx *gorm.DB, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		res := tx.Delete(&User{}, id)
+		err := res.Error
+		if err == nil && res.RowsAffected == 0 {
+			err = gorm.ErrRecordNotFound
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchUserM2MPart updates User by Id and returns error if
+// the record to be updated doesn't exist
+func PatchUserM2MPartById(m *User, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		switch field {
+		case "Roles":
+			for _, did := range DelIds {
+				if err := tx.Model(m).Association("Roles").Delete(&Role{Id: did}); err != nil {
+					return err
+				}
+			}
+			for _, aid := range AddIds {
+				// 已关联的不再加入
+				if tx.Model(m).Where(lgQuote("role")+"."+lgQuote("id")+" = ?", aid).Association("Roles").Count() > 0 {
+					continue
+				}
+				if err := tx.Model(m).Omit("Roles.*").Association("Roles").Append(&Role{Id: aid}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteUser deletes User by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteUser(id int) (err error) {
+	v := User{}
+	// ascertain id exists in the database
+	if err = DB.Take(&v, id).Error; err == nil {
+		res := DB.Delete(&User{}, id)
+		if err = res.Error; err == nil {
+			fmt.Println("Number of records deleted in database:", res.RowsAffected)
+		}
+	}
+	return
+}
+
+// upsertUserArgs returns the values of the columns inserted by upsert
+func upsertUserArgs(m *User) []interface{} {
+	now := time.Now()
+	m.CreatedAt = now
+	var createdAt interface{}
+	if !m.CreatedAt.IsZero() {
+		createdAt = m.CreatedAt
+	}
+	var teamId interface{}
+	if m.Team != nil {
+		teamId = m.Team.Id
+	}
+	return []interface{}{m.Name, m.Email, m.Status, m.Age, createdAt, teamId}
+}
+
+// UpsertUserByEmail inserts m, or updates the User with the same
+// (email), and returns the Id on success. (email) is the only unique key
+// of the table, so the conflict handled by MySQL is always on it.
+func UpsertUserByEmail(m *User) (id int64, err error) {
+	err = DB.Transaction(func(tx *gorm.DB) (err error) {
+		id, err = upsertUserByEmail(tx, m)
+		return
+	})
+	return
+}
+
+func upsertUserByEmail(tx *gorm.DB, m *User) (id int64, err error) {
+	args := upsertUserArgs(m)
+	if tx.Dialector.Name() == "postgres" {
+		err = tx.Raw("INSERT INTO \"user\" (\"name\", \"email\", \"status\", \"age\", \"created_at\", \"team_id\") VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (\"email\") DO UPDATE SET \"name\" = EXCLUDED.\"name\", \"status\" = EXCLUDED.\"status\", \"age\" = EXCLUDED.\"age\", \"team_id\" = EXCLUDED.\"team_id\" RETURNING \"id\"", args...).Row().Scan(&id)
+	} else if err = tx.Exec("INSERT INTO `user` (`name`, `email`, `status`, `age`, `created_at`, `team_id`) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE `id` = LAST_INSERT_ID(`id`), `name` = VALUES(`name`), `status` = VALUES(`status`), `age` = VALUES(`age`), `team_id` = VALUES(`team_id`)", args...).Error; err == nil {
+		// LAST_INSERT_ID按连接保存，所以要在事务中读取
+		err = tx.Raw("SELECT LAST_INSERT_ID()").Row().Scan(&id)
+	}
+	if err != nil {
+		return
+	}
+	// 重新读取，修改时m中的字段不一定与数据库一致
+	m.Id = int(id)
+	err = tx.Take(m).Error
+	return
+}
+
+// UserUniqueKeys lists the unique keys accepted by the by argument of UpsertUser
+var UserUniqueKeys = []string{"email"}
+
+func upsertUserFunc(by string) (func(*gorm.DB, *User) (int64, error), error) {
+	switch by {
+	case "", "email":
+		return upsertUserByEmail, nil
+	}
+	return nil, errors.New("Error: unknown unique key '" + by + "', must be one of " + strings.Join(UserUniqueKeys, " | "))
+}
+
+// UpsertUser inserts or updates m by the unique key by, the columns of by are
+// separated by ",", an empty by means the first unique key.
+func UpsertUser(m *User, by string) (id int64, err error) {
+	upsert, err := upsertUserFunc(by)
+	if err != nil {
+		return
+	}
+	// 唯一键冲突时修改，只校验列
+	if err = m.ValidateColumns(); err != nil {
+		return
+	}
+	err = DB.Transaction(func(tx *gorm.DB) (err error) {
+		id, err = upsert(tx, m)
+		return
+	})
+	return
+}
+
+// UpsertMultiUser inserts or updates several Users by the unique key by in
+// one transaction. Nothing is committed unless every item succeeds, and the items after
+// the first failed one are skipped.
+func UpsertMultiUser(ms []*User, by string) (result *LgBatchResult, err error) {
+	upsert, err := upsertUserFunc(by)
+	if err != nil {
+		return
+	}
+	tx := DB.Begin()
+	if err = tx.Error; err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		itemErr := m.ValidateColumns()
+		if itemErr == nil {
+			_, itemErr = upsert(tx, m)
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
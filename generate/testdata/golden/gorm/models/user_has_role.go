@@ -0,0 +1,9 @@
+package models
+
+type UserHasRole struct {
+	Id     int   `gorm:"column:id;primaryKey;autoIncrement"`
+	Role   *Role `gorm:"foreignKey:RoleId" description:"role_id为中间表关联字段。"`
+	RoleId *int  `gorm:"column:role_id" json:"-"`
+	User   *User `gorm:"foreignKey:UserId" description:"user_id为中间表关联字段。"`
+	UserId *int  `gorm:"column:user_id" json:"-"`
+}
@@ -0,0 +1,299 @@
+package graph
+
+import (
+	"app/models"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// roleResolver resolves the fields of a Role
+type roleResolver struct {
+	m     *models.Role
+	batch *roleBatch
+}
+
+func (r *roleResolver) Id() graphql.ID {
+	return lgID(r.m.Id)
+}
+
+func (r *roleResolver) Name() string {
+	return r.m.Name
+}
+
+// Users is loaded for all the records of the list with one query
+func (r *roleResolver) Users() ([]*userResolver, error) {
+	v, err := r.batch.load("Users", func() (interface{}, error) {
+		links, err := models.LgThroughIds("user_has_role", "role_id", "user_id", r.batch.ids())
+		if err != nil {
+			return nil, err
+		}
+		var ids []int
+		for _, l := range links {
+			ids = append(ids, l...)
+		}
+		refs, err := userGroupsBy("id", ids, func(m *models.User) int { return m.Id })
+		if err != nil {
+			return nil, err
+		}
+		rv := make(map[int][]*userResolver)
+		for id, l := range links {
+			for _, refId := range l {
+				rv[id] = append(rv[id], refs[refId]...)
+			}
+		}
+		return rv, nil
+	})
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return v.(map[int][]*userResolver)[r.m.Id], nil
+}
+
+// roleBatch is the Roles of a list, a relation is loaded for the whole
+// list the first time it's resolved instead of once per Role
+type roleBatch struct {
+	lgLoads
+	ms []*models.Role
+}
+
+// newRoleBatch wraps the Roles returned by the functions of models
+func newRoleBatch(l []interface{}) *roleBatch {
+	b := &roleBatch{}
+	for _, v := range l {
+		m := v.(models.Role)
+		b.ms = append(b.ms, &m)
+	}
+	return b
+}
+
+func (b *roleBatch) resolvers() []*roleResolver {
+	rv := make([]*roleResolver, len(b.ms))
+	for i, m := range b.ms {
+		rv[i] = &roleResolver{m: m, batch: b}
+	}
+	return rv
+}
+
+func (b *roleBatch) ids() []int {
+	ids := make([]int, len(b.ms))
+	for i, m := range b.ms {
+		ids[i] = m.Id
+	}
+	return ids
+}
+
+// roleOf resolves a single Role
+func roleOf(m *models.Role) *roleResolver {
+	return newRoleBatch([]interface{}{*m}).resolvers()[0]
+}
+
+// roleGroupsBy loads the Roles whose key is in ids with one query, grouped by group
+func roleGroupsBy(key string, ids []int, group func(m *models.Role) int) (map[int][]*roleResolver, error) {
+	rv := make(map[int][]*roleResolver)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	l, _, err := models.GetAllRole(map[string]string{key + "__in": lgIds(ids)}, nil, nil, nil, 0, -1, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range newRoleBatch(l).resolvers() {
+		id := group(v.m)
+		rv[id] = append(rv[id], v)
+	}
+	return rv, nil
+}
+
+// roleConnection is a page of Roles from offset
+type roleConnection struct {
+	nodes  []*roleResolver
+	offset int64
+	total  int64
+}
+
+func (c *roleConnection) Edges() []*roleEdge {
+	edges := make([]*roleEdge, len(c.nodes))
+	for i, n := range c.nodes {
+		edges[i] = &roleEdge{cursor: lgCursor(c.offset + int64(i)), node: n}
+	}
+	return edges
+}
+
+func (c *roleConnection) Nodes() []*roleResolver {
+	return c.nodes
+}
+
+func (c *roleConnection) PageInfo() *lgPageInfo {
+	return &lgPageInfo{offset: c.offset, n: len(c.nodes), total: c.total}
+}
+
+func (c *roleConnection) TotalCount() int32 {
+	return int32(c.total)
+}
+
+type roleEdge struct {
+	cursor string
+	node   *roleResolver
+}
+
+func (e *roleEdge) Cursor() string {
+	return e.cursor
+}
+
+func (e *roleEdge) Node() *roleResolver {
+	return e.node
+}
+
+// roleFilter is the RoleFilter input
+type roleFilter struct {
+	Search   *string
+	Dsearch  *string
+	NotEmpty *string
+	Neq      *string
+	Id       *lgIDFilter
+	Name     *lgStringFilter
+}
+
+// query converts f to the query of GetAllRole
+func (f *roleFilter) query() map[string]string {
+	q := make(map[string]string)
+	if f == nil {
+		return q
+	}
+	lgPut(q, "search", f.Search)
+	lgPut(q, "dsearch", f.Dsearch)
+	lgPut(q, "not_empty", f.NotEmpty)
+	lgPut(q, "neq", f.Neq)
+	f.Id.query(q, "id")
+	f.Name.query(q, "name")
+	return q
+}
+
+// roleInput is the RoleInput input
+type roleInput struct {
+	Name  *string
+	Users *[]graphql.ID
+}
+
+// apply sets the fields present in the input to m and returns them
+func (in *roleInput) apply(m *models.Role) (fields []string, err error) {
+	if in.Name != nil {
+		m.Name = *in.Name
+		fields = append(fields, "Name")
+	}
+	if in.Users != nil {
+		m.Users = nil
+		for _, v := range *in.Users {
+			id, err := lgInt(v)
+			if err != nil {
+				return nil, err
+			}
+			m.Users = append(m.Users, &models.User{Id: id})
+		}
+		fields = append(fields, "Users")
+	}
+	return fields, nil
+}
+
+// Role gets a Role by id, null if it doesn't exist
+func (r *Resolver) Role(args struct{ Id graphql.ID }) (*roleResolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.GetRoleById(id)
+	if lgNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return roleOf(m), nil
+}
+
+// Roles returns a page of Roles matching filter after the cursor after
+func (r *Resolver) Roles(args struct {
+	Filter *roleFilter
+	Sortby *[]string
+	Order  *[]string
+	First  *int32
+	After  *string
+}) (*roleConnection, error) {
+	limit, err := lgLimit(args.First)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := lgOffset(args.After)
+	if err != nil {
+		return nil, err
+	}
+	l, pager, err := models.GetAllRole(args.Filter.query(), nil, lgStrings(args.Sortby), lgStrings(args.Order), offset, limit, nil, 1)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return &roleConnection{nodes: newRoleBatch(l).resolvers(), offset: offset, total: pager.Page.TotalCount}, nil
+}
+
+// CreateRole validates the input and adds the Role together with its relations
+func (r *Resolver) CreateRole(args struct{ Input roleInput }) (*roleResolver, error) {
+	m := &models.Role{}
+	if _, err := args.Input.apply(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, lgError(err)
+	}
+	if _, err := models.AddRoleHasMany(m); err != nil {
+		return nil, lgError(err)
+	}
+	m, err := models.GetRoleById(m.Id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return roleOf(m), nil
+}
+
+// UpdateRole validates and updates the fields present in the input
+func (r *Resolver) UpdateRole(args struct {
+	Id    graphql.ID
+	Input roleInput
+}) (*roleResolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.GetRoleById(id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	fields, err := args.Input.apply(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		if err := m.ValidateFields(fields...); err != nil {
+			return nil, lgError(err)
+		}
+		if err := models.PatchRoleById(m, fields); err != nil {
+			return nil, lgError(err)
+		}
+	}
+	if m, err = models.GetRoleById(id); err != nil {
+		return nil, lgError(err)
+	}
+	return roleOf(m), nil
+}
+
+// DeleteRole deletes a Role by id
+func (r *Resolver) DeleteRole(args struct{ Id graphql.ID }) (bool, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := models.GetRoleById(id); err != nil {
+		return false, lgError(err)
+	}
+	if err := models.DeleteRole(id); err != nil {
+		return false, lgError(err)
+	}
+	return true, nil
+}
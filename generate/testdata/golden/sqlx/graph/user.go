@@ -0,0 +1,391 @@
+package graph
+
+import (
+	"app/models"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// userResolver resolves the fields of a User
+type userResolver struct {
+	m     *models.User
+	batch *userBatch
+}
+
+func (r *userResolver) Id() graphql.ID {
+	return lgID(r.m.Id)
+}
+
+func (r *userResolver) Name() string {
+	return r.m.Name
+}
+
+func (r *userResolver) Email() string {
+	return r.m.Email
+}
+
+func (r *userResolver) Status() string {
+	return r.m.Status
+}
+
+func (r *userResolver) Age() int32 {
+	return int32(r.m.Age)
+}
+
+func (r *userResolver) CreatedAt() *graphql.Time {
+	return lgTime(r.m.CreatedAt)
+}
+
+// Team is loaded for all the records of the list with one query
+func (r *userResolver) Team() (*teamResolver, error) {
+	if r.m.Team == nil {
+		return nil, nil
+	}
+	v, err := r.batch.load("Team", func() (interface{}, error) {
+		var ids []int
+		for _, m := range r.batch.ms {
+			if m.Team != nil {
+				ids = append(ids, m.Team.Id)
+			}
+		}
+		return teamGroupsBy("id", ids, func(m *models.Team) int { return m.Id })
+	})
+	if err != nil {
+		return nil, lgError(err)
+	}
+	if l := v.(map[int][]*teamResolver)[r.m.Team.Id]; len(l) > 0 {
+		return l[0], nil
+	}
+	return nil, nil
+}
+
+// Profile is loaded for all the records of the list with one query
+func (r *userResolver) Profile() (*profileResolver, error) {
+	v, err := r.batch.load("Profile", func() (interface{}, error) {
+		return profileGroupsBy("user__id", r.batch.ids(), func(m *models.Profile) int {
+			if m.User == nil {
+				return 0
+			}
+			return m.User.Id
+		})
+	})
+	if err != nil {
+		return nil, lgError(err)
+	}
+	if l := v.(map[int][]*profileResolver)[r.m.Id]; len(l) > 0 {
+		return l[0], nil
+	}
+	return nil, nil
+}
+
+// Roles is loaded for all the records of the list with one query
+func (r *userResolver) Roles() ([]*roleResolver, error) {
+	v, err := r.batch.load("Roles", func() (interface{}, error) {
+		links, err := models.LgThroughIds("user_has_role", "user_id", "role_id", r.batch.ids())
+		if err != nil {
+			return nil, err
+		}
+		var ids []int
+		for _, l := range links {
+			ids = append(ids, l...)
+		}
+		refs, err := roleGroupsBy("id", ids, func(m *models.Role) int { return m.Id })
+		if err != nil {
+			return nil, err
+		}
+		rv := make(map[int][]*roleResolver)
+		for id, l := range links {
+			for _, refId := range l {
+				rv[id] = append(rv[id], refs[refId]...)
+			}
+		}
+		return rv, nil
+	})
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return v.(map[int][]*roleResolver)[r.m.Id], nil
+}
+
+// userBatch is the Users of a list, a relation is loaded for the whole
+// list the first time it's resolved instead of once per User
+type userBatch struct {
+	lgLoads
+	ms []*models.User
+}
+
+// newUserBatch wraps the Users returned by the functions of models
+func newUserBatch(l []interface{}) *userBatch {
+	b := &userBatch{}
+	for _, v := range l {
+		m := v.(models.User)
+		b.ms = append(b.ms, &m)
+	}
+	return b
+}
+
+func (b *userBatch) resolvers() []*userResolver {
+	rv := make([]*userResolver, len(b.ms))
+	for i, m := range b.ms {
+		rv[i] = &userResolver{m: m, batch: b}
+	}
+	return rv
+}
+
+func (b *userBatch) ids() []int {
+	ids := make([]int, len(b.ms))
+	for i, m := range b.ms {
+		ids[i] = m.Id
+	}
+	return ids
+}
+
+// userOf resolves a single User
+func userOf(m *models.User) *userResolver {
+	return newUserBatch([]interface{}{*m}).resolvers()[0]
+}
+
+// userGroupsBy loads the Users whose key is in ids with one query, grouped by group
+func userGroupsBy(key string, ids []int, group func(m *models.User) int) (map[int][]*userResolver, error) {
+	rv := make(map[int][]*userResolver)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	l, _, err := models.GetAllUser(map[string]string{key + "__in": lgIds(ids)}, nil, nil, nil, 0, -1, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range newUserBatch(l).resolvers() {
+		id := group(v.m)
+		rv[id] = append(rv[id], v)
+	}
+	return rv, nil
+}
+
+// userConnection is a page of Users from offset
+type userConnection struct {
+	nodes  []*userResolver
+	offset int64
+	total  int64
+}
+
+func (c *userConnection) Edges() []*userEdge {
+	edges := make([]*userEdge, len(c.nodes))
+	for i, n := range c.nodes {
+		edges[i] = &userEdge{cursor: lgCursor(c.offset + int64(i)), node: n}
+	}
+	return edges
+}
+
+func (c *userConnection) Nodes() []*userResolver {
+	return c.nodes
+}
+
+func (c *userConnection) PageInfo() *lgPageInfo {
+	return &lgPageInfo{offset: c.offset, n: len(c.nodes), total: c.total}
+}
+
+func (c *userConnection) TotalCount() int32 {
+	return int32(c.total)
+}
+
+type userEdge struct {
+	cursor string
+	node   *userResolver
+}
+
+func (e *userEdge) Cursor() string {
+	return e.cursor
+}
+
+func (e *userEdge) Node() *userResolver {
+	return e.node
+}
+
+// userFilter is the UserFilter input
+type userFilter struct {
+	Search    *string
+	Dsearch   *string
+	NotEmpty  *string
+	Neq       *string
+	Id        *lgIDFilter
+	Name      *lgStringFilter
+	Email     *lgStringFilter
+	Status    *lgStringFilter
+	Age       *lgIntFilter
+	CreatedAt *lgTimeFilter
+	Team      *lgIDFilter
+}
+
+// query converts f to the query of GetAllUser
+func (f *userFilter) query() map[string]string {
+	q := make(map[string]string)
+	if f == nil {
+		return q
+	}
+	lgPut(q, "search", f.Search)
+	lgPut(q, "dsearch", f.Dsearch)
+	lgPut(q, "not_empty", f.NotEmpty)
+	lgPut(q, "neq", f.Neq)
+	f.Id.query(q, "id")
+	f.Name.query(q, "name")
+	f.Email.query(q, "email")
+	f.Status.query(q, "status")
+	f.Age.query(q, "age")
+	f.CreatedAt.query(q, "created_at")
+	f.Team.query(q, "team__id")
+	return q
+}
+
+// userInput is the UserInput input
+type userInput struct {
+	Name   *string
+	Email  *string
+	Status *string
+	Age    *int32
+	Team   *graphql.ID
+	Roles  *[]graphql.ID
+}
+
+// apply sets the fields present in the input to m and returns them
+func (in *userInput) apply(m *models.User) (fields []string, err error) {
+	if in.Name != nil {
+		m.Name = *in.Name
+		fields = append(fields, "Name")
+	}
+	if in.Email != nil {
+		m.Email = *in.Email
+		fields = append(fields, "Email")
+	}
+	if in.Status != nil {
+		m.Status = *in.Status
+		fields = append(fields, "Status")
+	}
+	if in.Age != nil {
+		m.Age = uint8(*in.Age)
+		fields = append(fields, "Age")
+	}
+	if in.Team != nil {
+		id, err := lgInt(*in.Team)
+		if err != nil {
+			return nil, err
+		}
+		m.Team = &models.Team{Id: id}
+		fields = append(fields, "Team")
+	}
+	if in.Roles != nil {
+		m.Roles = nil
+		for _, v := range *in.Roles {
+			id, err := lgInt(v)
+			if err != nil {
+				return nil, err
+			}
+			m.Roles = append(m.Roles, &models.Role{Id: id})
+		}
+		fields = append(fields, "Roles")
+	}
+	return fields, nil
+}
+
+// User gets a User by id, null if it doesn't exist
+func (r *Resolver) User(args struct{ Id graphql.ID }) (*userResolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.GetUserById(id)
+	if lgNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return userOf(m), nil
+}
+
+// Users returns a page of Users matching filter after the cursor after
+func (r *Resolver) Users(args struct {
+	Filter *userFilter
+	Sortby *[]string
+	Order  *[]string
+	First  *int32
+	After  *string
+}) (*userConnection, error) {
+	limit, err := lgLimit(args.First)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := lgOffset(args.After)
+	if err != nil {
+		return nil, err
+	}
+	l, pager, err := models.GetAllUser(args.Filter.query(), nil, lgStrings(args.Sortby), lgStrings(args.Order), offset, limit, nil, 1)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return &userConnection{nodes: newUserBatch(l).resolvers(), offset: offset, total: pager.Page.TotalCount}, nil
+}
+
+// CreateUser validates the input and adds the User together with its relations
+func (r *Resolver) CreateUser(args struct{ Input userInput }) (*userResolver, error) {
+	m := &models.User{}
+	if _, err := args.Input.apply(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, lgError(err)
+	}
+	if _, err := models.AddUserHasMany(m); err != nil {
+		return nil, lgError(err)
+	}
+	m, err := models.GetUserById(m.Id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	return userOf(m), nil
+}
+
+// UpdateUser validates and updates the fields present in the input
+func (r *Resolver) UpdateUser(args struct {
+	Id    graphql.ID
+	Input userInput
+}) (*userResolver, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := models.GetUserById(id)
+	if err != nil {
+		return nil, lgError(err)
+	}
+	fields, err := args.Input.apply(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		if err := m.ValidateFields(fields...); err != nil {
+			return nil, lgError(err)
+		}
+		if err := models.PatchUserById(m, fields); err != nil {
+			return nil, lgError(err)
+		}
+	}
+	if m, err = models.GetUserById(id); err != nil {
+		return nil, lgError(err)
+	}
+	return userOf(m), nil
+}
+
+// DeleteUser deletes a User by id
+func (r *Resolver) DeleteUser(args struct{ Id graphql.ID }) (bool, error) {
+	id, err := lgInt(args.Id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := models.GetUserById(id); err != nil {
+		return false, lgError(err)
+	}
+	if err := models.DeleteUser(id); err != nil {
+		return false, lgError(err)
+	}
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"app/models"
+	pb "app/proto"
+
+	"database/sql"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Register registers the services of all tables on s
+func Register(s *grpc.Server) {
+	pb.RegisterProfileServiceServer(s, &ProfileServer{})
+	pb.RegisterRoleServiceServer(s, &RoleServer{})
+	pb.RegisterTeamServiceServer(s, &TeamServer{})
+	pb.RegisterUserServiceServer(s, &UserServer{})
+}
+
+// lgStatus converts the errors of models to gRPC status errors, validation errors
+// carry the invalid fields as BadRequest details
+func lgStatus(err error) error {
+	var ve *models.LgValidationError
+	if errors.As(err, &ve) {
+		br := &errdetails.BadRequest{}
+		for _, fe := range ve.Errors {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message})
+		}
+		st := status.New(codes.InvalidArgument, ve.Error())
+		if ds, e := st.WithDetails(br); e == nil {
+			st = ds
+		}
+		return st.Err()
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// lgMaskFields 把update_mask的路径换成model的字段，为空时返回all
+func lgMaskFields(mask *fieldmaskpb.FieldMask, fields map[string]string, all []string) ([]string, error) {
+	if len(mask.GetPaths()) == 0 {
+		return all, nil
+	}
+	var rv []string
+	for _, p := range mask.GetPaths() {
+		f, ok := fields[p]
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("unknown field %q in update_mask", p))
+		}
+		rv = append(rv, f)
+	}
+	return rv, nil
+}
+
+// lgTimestamp 零值的时间为nil
+func lgTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// lgTime nil为零值的时间
+func lgTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime().In(time.Local)
+}
@@ -0,0 +1,134 @@
+package grpcserver
+
+import (
+	"context"
+
+	"app/models"
+	pb "app/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// ProfileServer implements pb.ProfileServiceServer by the functions of models
+type ProfileServer struct {
+	pb.UnimplementedProfileServiceServer
+}
+
+// profileMaskFields update_mask的路径对应的字段
+var profileMaskFields = map[string]string{
+	"bio":     "Bio",
+	"user":    "User",
+	"user.id": "User",
+}
+
+// profileAllFields update_mask为空时修改的字段
+var profileAllFields = []string{
+	"Bio",
+	"User",
+}
+
+// CreateProfile validates in and adds it together with its relations
+func (s *ProfileServer) CreateProfile(ctx context.Context, in *pb.Profile) (*pb.Profile, error) {
+	m := profileFromProto(in)
+	if err := m.Validate(); err != nil {
+		return nil, lgStatus(err)
+	}
+	if _, err := models.AddProfileHasMany(m); err != nil {
+		return nil, lgStatus(err)
+	}
+	return profileToProto(m), nil
+}
+
+// GetProfile gets a Profile by id and loads the relations in load
+func (s *ProfileServer) GetProfile(ctx context.Context, in *pb.GetRequest) (*pb.Profile, error) {
+	m, err := models.GetProfileById(int(in.Id))
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	for _, lo := range in.Load {
+		if _, err := m.LoadRelatedOf(lo); err != nil {
+			return nil, lgStatus(err)
+		}
+	}
+	return profileToProto(m), nil
+}
+
+// ListProfiles returns a page of Profiles, limit defaults to 10 like the REST GetAll
+func (s *ProfileServer) ListProfiles(ctx context.Context, in *pb.ListRequest) (*pb.ProfileList, error) {
+	limit := in.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	l, pager, err := models.GetAllProfile(in.Query, nil, in.Sortby, in.Order, in.Offset, limit, in.Load, 1)
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	out := &pb.ProfileList{Total: pager.Page.TotalCount}
+	for _, v := range l {
+		m := v.(models.Profile)
+		out.Items = append(out.Items, profileToProto(&m))
+	}
+	return out, nil
+}
+
+// UpdateProfile updates the fields in update_mask of an existing Profile
+func (s *ProfileServer) UpdateProfile(ctx context.Context, in *pb.UpdateProfileRequest) (*pb.Profile, error) {
+	if in.Profile == nil {
+		return nil, status.Error(codes.InvalidArgument, "profile is required")
+	}
+	fields, err := lgMaskFields(in.UpdateMask, profileMaskFields, profileAllFields)
+	if err != nil {
+		return nil, err
+	}
+	m := profileFromProto(in.Profile)
+	if _, err := models.GetProfileById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := m.ValidateFields(fields...); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.PatchProfileById(m, fields); err != nil {
+		return nil, lgStatus(err)
+	}
+	if m, err = models.GetProfileById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	return profileToProto(m), nil
+}
+
+// DeleteProfile deletes a Profile by id
+func (s *ProfileServer) DeleteProfile(ctx context.Context, in *pb.DeleteRequest) (*emptypb.Empty, error) {
+	if _, err := models.GetProfileById(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.DeleteProfile(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// profileToProto converts m to a pb.Profile, relations are converted when loaded
+func profileToProto(m *models.Profile) *pb.Profile {
+	p := &pb.Profile{
+		Id:  int64(m.Id),
+		Bio: m.Bio,
+	}
+	if m.User != nil {
+		p.User = userToProto(m.User)
+	}
+	return p
+}
+
+// profileFromProto converts p to a models.Profile
+func profileFromProto(p *pb.Profile) *models.Profile {
+	m := &models.Profile{
+		Id:  int(p.Id),
+		Bio: p.Bio,
+	}
+	if p.User != nil {
+		m.User = &models.User{Id: int(p.User.Id)}
+	}
+	return m
+}
@@ -0,0 +1,132 @@
+package grpcserver
+
+import (
+	"context"
+
+	"app/models"
+	pb "app/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// RoleServer implements pb.RoleServiceServer by the functions of models
+type RoleServer struct {
+	pb.UnimplementedRoleServiceServer
+}
+
+// roleMaskFields update_mask的路径对应的字段
+var roleMaskFields = map[string]string{
+	"name":  "Name",
+	"users": "Users",
+}
+
+// roleAllFields update_mask为空时修改的字段
+var roleAllFields = []string{
+	"Name",
+}
+
+// CreateRole validates in and adds it together with its relations
+func (s *RoleServer) CreateRole(ctx context.Context, in *pb.Role) (*pb.Role, error) {
+	m := roleFromProto(in)
+	if err := m.Validate(); err != nil {
+		return nil, lgStatus(err)
+	}
+	if _, err := models.AddRoleHasMany(m); err != nil {
+		return nil, lgStatus(err)
+	}
+	return roleToProto(m), nil
+}
+
+// GetRole gets a Role by id and loads the relations in load
+func (s *RoleServer) GetRole(ctx context.Context, in *pb.GetRequest) (*pb.Role, error) {
+	m, err := models.GetRoleById(int(in.Id))
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	for _, lo := range in.Load {
+		if _, err := m.LoadRelatedOf(lo); err != nil {
+			return nil, lgStatus(err)
+		}
+	}
+	return roleToProto(m), nil
+}
+
+// ListRoles returns a page of Roles, limit defaults to 10 like the REST GetAll
+func (s *RoleServer) ListRoles(ctx context.Context, in *pb.ListRequest) (*pb.RoleList, error) {
+	limit := in.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	l, pager, err := models.GetAllRole(in.Query, nil, in.Sortby, in.Order, in.Offset, limit, in.Load, 1)
+	if err != nil {
+		return nil, lgStatus(err)
+	}
+	out := &pb.RoleList{Total: pager.Page.TotalCount}
+	for _, v := range l {
+		m := v.(models.Role)
+		out.Items = append(out.Items, roleToProto(&m))
+	}
+	return out, nil
+}
+
+// UpdateRole updates the fields in update_mask of an existing Role
+func (s *RoleServer) UpdateRole(ctx context.Context, in *pb.UpdateRoleRequest) (*pb.Role, error) {
+	if in.Role == nil {
+		return nil, status.Error(codes.InvalidArgument, "role is required")
+	}
+	fields, err := lgMaskFields(in.UpdateMask, roleMaskFields, roleAllFields)
+	if err != nil {
+		return nil, err
+	}
+	m := roleFromProto(in.Role)
+	if _, err := models.GetRoleById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := m.ValidateFields(fields...); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.PatchRoleById(m, fields); err != nil {
+		return nil, lgStatus(err)
+	}
+	if m, err = models.GetRoleById(m.Id); err != nil {
+		return nil, lgStatus(err)
+	}
+	return roleToProto(m), nil
+}
+
+// DeleteRole deletes a Role by id
+func (s *RoleServer) DeleteRole(ctx context.Context, in *pb.DeleteRequest) (*emptypb.Empty, error) {
+	if _, err := models.GetRoleById(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	if err := models.DeleteRole(int(in.Id)); err != nil {
+		return nil, lgStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// roleToProto converts m to a pb.Role, relations are converted when loaded
+func roleToProto(m *models.Role) *pb.Role {
+	p := &pb.Role{
+		Id:   int64(m.Id),
+		Name: m.Name,
+	}
+	for _, v := range m.Users {
+		p.Users = append(p.Users, userToProto(v))
+	}
+	return p
+}
+
+// roleFromProto converts p to a models.Role
+func roleFromProto(p *pb.Role) *models.Role {
+	m := &models.Role{
+		Id:   int(p.Id),
+		Name: p.Name,
+	}
+	for _, v := range p.Users {
+		m.Users = append(m.Users, &models.User{Id: int(v.Id)})
+	}
+	return m
+}
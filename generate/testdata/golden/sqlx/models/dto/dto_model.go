@@ -0,0 +1,433 @@
+package dto
+
+import (
+	"time"
+
+	"app/models"
+)
+
+// ProfileCreateRequest is the body to create a Profile, [profile] profile表
+type ProfileCreateRequest struct {
+	Bio    string `json:"bio"`
+	UserId int    `json:"userId"`
+}
+
+// ProfileUpdateRequest is the body to update a Profile, only the fields present
+// are updated. Id is only used by the batch update.
+type ProfileUpdateRequest struct {
+	Id     int     `json:"id,omitempty"`
+	Bio    *string `json:"bio,omitempty"`
+	UserId *int    `json:"userId,omitempty"`
+}
+
+// ProfileResponse is the Profile returned to the client, with the relations loaded by load
+type ProfileResponse struct {
+	Id     int    `json:"id"`
+	Bio    string `json:"bio"`
+	UserId int    `json:"userId"`
+}
+
+// ToModel converts r to a models.Profile
+func (r *ProfileCreateRequest) ToModel() *models.Profile {
+	m := &models.Profile{
+		Bio: r.Bio,
+	}
+	if r.UserId != 0 {
+		m.User = &models.User{Id: r.UserId}
+	}
+	return m
+}
+
+// ProfileUpdateFields are the fields replaced by a full update, such as the batch PUT
+var ProfileUpdateFields = []string{
+	"Bio",
+	"User",
+}
+
+// Apply sets the fields present in r on m, and returns the names of these fields
+func (r *ProfileUpdateRequest) Apply(m *models.Profile) (fields []string) {
+	if r.Bio != nil {
+		m.Bio = *r.Bio
+		fields = append(fields, "Bio")
+	}
+	if r.UserId != nil {
+		m.User = &models.User{Id: *r.UserId}
+		fields = append(fields, "User")
+	}
+	return
+}
+
+// NewProfileResponse converts m to a ProfileResponse
+func NewProfileResponse(m *models.Profile) *ProfileResponse {
+	r := &ProfileResponse{
+		Id:  m.Id,
+		Bio: m.Bio,
+	}
+	if m.User != nil {
+		r.UserId = m.User.Id
+	}
+	return r
+}
+
+// NewProfileResponses converts the result of models.GetAllProfile, the items
+// trimmed by fields are keyed by the json names of ProfileResponse
+func NewProfileResponses(ml []interface{}) []interface{} {
+	rs := make([]interface{}, len(ml))
+	for i, v := range ml {
+		switch v := v.(type) {
+		case models.Profile:
+			rs[i] = NewProfileResponse(&v)
+		case map[string]interface{}:
+			rs[i] = newProfileFields(v)
+		default:
+			rs[i] = v
+		}
+	}
+	return rs
+}
+
+// newProfileFields renames the fields of m to the json names of ProfileResponse
+func newProfileFields(m map[string]interface{}) map[string]interface{} {
+	r := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		switch k {
+		case "Id":
+			r["id"] = v
+		case "Bio":
+			r["bio"] = v
+		case "User":
+			if v, ok := v.(*models.User); ok && v != nil {
+				r["userId"] = v.Id
+			} else {
+				r["userId"] = 0
+			}
+		default:
+			r[k] = v
+		}
+	}
+	return r
+}
+
+// RoleCreateRequest is the body to create a Role, [role] role表
+type RoleCreateRequest struct {
+	Name string `json:"name"`
+}
+
+// RoleUpdateRequest is the body to update a Role, only the fields present
+// are updated. Id is only used by the batch update.
+type RoleUpdateRequest struct {
+	Id   int     `json:"id,omitempty"`
+	Name *string `json:"name,omitempty"`
+}
+
+// RoleResponse is the Role returned to the client, with the relations loaded by load
+type RoleResponse struct {
+	Id    int             `json:"id"`
+	Name  string          `json:"name"`
+	Users []*UserResponse `json:"users,omitempty"`
+}
+
+// ToModel converts r to a models.Role
+func (r *RoleCreateRequest) ToModel() *models.Role {
+	m := &models.Role{
+		Name: r.Name,
+	}
+
+	return m
+}
+
+// RoleUpdateFields are the fields replaced by a full update, such as the batch PUT
+var RoleUpdateFields = []string{
+	"Name",
+}
+
+// Apply sets the fields present in r on m, and returns the names of these fields
+func (r *RoleUpdateRequest) Apply(m *models.Role) (fields []string) {
+	if r.Name != nil {
+		m.Name = *r.Name
+		fields = append(fields, "Name")
+	}
+	return
+}
+
+// NewRoleResponse converts m to a RoleResponse
+func NewRoleResponse(m *models.Role) *RoleResponse {
+	r := &RoleResponse{
+		Id:   m.Id,
+		Name: m.Name,
+	}
+	for _, v := range m.Users {
+		r.Users = append(r.Users, NewUserResponse(v))
+	}
+	return r
+}
+
+// NewRoleResponses converts the result of models.GetAllRole, the items
+// trimmed by fields are keyed by the json names of RoleResponse
+func NewRoleResponses(ml []interface{}) []interface{} {
+	rs := make([]interface{}, len(ml))
+	for i, v := range ml {
+		switch v := v.(type) {
+		case models.Role:
+			rs[i] = NewRoleResponse(&v)
+		case map[string]interface{}:
+			rs[i] = newRoleFields(v)
+		default:
+			rs[i] = v
+		}
+	}
+	return rs
+}
+
+// newRoleFields renames the fields of m to the json names of RoleResponse
+func newRoleFields(m map[string]interface{}) map[string]interface{} {
+	r := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		switch k {
+		case "Id":
+			r["id"] = v
+		case "Name":
+			r["name"] = v
+		default:
+			r[k] = v
+		}
+	}
+	return r
+}
+
+// TeamCreateRequest is the body to create a Team, [team] team表
+type TeamCreateRequest struct {
+	Name string `json:"name"` // team name
+}
+
+// TeamUpdateRequest is the body to update a Team, only the fields present
+// are updated. Id is only used by the batch update.
+type TeamUpdateRequest struct {
+	Id   int     `json:"id,omitempty"`
+	Name *string `json:"name,omitempty"` // team name
+}
+
+// TeamResponse is the Team returned to the client, with the relations loaded by load
+type TeamResponse struct {
+	Id    int             `json:"id"`
+	Name  string          `json:"name"` // team name
+	Users []*UserResponse `json:"users,omitempty"`
+}
+
+// ToModel converts r to a models.Team
+func (r *TeamCreateRequest) ToModel() *models.Team {
+	m := &models.Team{
+		Name: r.Name,
+	}
+
+	return m
+}
+
+// TeamUpdateFields are the fields replaced by a full update, such as the batch PUT
+var TeamUpdateFields = []string{
+	"Name",
+}
+
+// Apply sets the fields present in r on m, and returns the names of these fields
+func (r *TeamUpdateRequest) Apply(m *models.Team) (fields []string) {
+	if r.Name != nil {
+		m.Name = *r.Name
+		fields = append(fields, "Name")
+	}
+	return
+}
+
+// NewTeamResponse converts m to a TeamResponse
+func NewTeamResponse(m *models.Team) *TeamResponse {
+	r := &TeamResponse{
+		Id:   m.Id,
+		Name: m.Name,
+	}
+	for _, v := range m.Users {
+		r.Users = append(r.Users, NewUserResponse(v))
+	}
+	return r
+}
+
+// NewTeamResponses converts the result of models.GetAllTeam, the items
+// trimmed by fields are keyed by the json names of TeamResponse
+func NewTeamResponses(ml []interface{}) []interface{} {
+	rs := make([]interface{}, len(ml))
+	for i, v := range ml {
+		switch v := v.(type) {
+		case models.Team:
+			rs[i] = NewTeamResponse(&v)
+		case map[string]interface{}:
+			rs[i] = newTeamFields(v)
+		default:
+			rs[i] = v
+		}
+	}
+	return rs
+}
+
+// newTeamFields renames the fields of m to the json names of TeamResponse
+func newTeamFields(m map[string]interface{}) map[string]interface{} {
+	r := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		switch k {
+		case "Id":
+			r["id"] = v
+		case "Name":
+			r["name"] = v
+		default:
+			r[k] = v
+		}
+	}
+	return r
+}
+
+// UserCreateRequest is the body to create a User, [user] user表
+type UserCreateRequest struct {
+	Name   string `json:"name"` // user name
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Age    uint8  `json:"age"`
+	TeamId int    `json:"teamId"`
+}
+
+// UserUpdateRequest is the body to update a User, only the fields present
+// are updated. Id is only used by the batch update.
+type UserUpdateRequest struct {
+	Id     int     `json:"id,omitempty"`
+	Name   *string `json:"name,omitempty"` // user name
+	Email  *string `json:"email,omitempty"`
+	Status *string `json:"status,omitempty"`
+	Age    *uint8  `json:"age,omitempty"`
+	TeamId *int    `json:"teamId,omitempty"`
+}
+
+// UserResponse is the User returned to the client, with the relations loaded by load
+type UserResponse struct {
+	Id        int              `json:"id"`
+	Name      string           `json:"name"` // user name
+	Email     string           `json:"email"`
+	Status    string           `json:"status"`
+	Age       uint8            `json:"age"`
+	CreatedAt time.Time        `json:"createdAt"`
+	TeamId    int              `json:"teamId"`
+	Profile   *ProfileResponse `json:"profile,omitempty"`
+	Roles     []*RoleResponse  `json:"roles,omitempty"`
+}
+
+// ToModel converts r to a models.User
+func (r *UserCreateRequest) ToModel() *models.User {
+	m := &models.User{
+		Name:   r.Name,
+		Email:  r.Email,
+		Status: r.Status,
+		Age:    r.Age,
+	}
+	if r.TeamId != 0 {
+		m.Team = &models.Team{Id: r.TeamId}
+	}
+	return m
+}
+
+// UserUpdateFields are the fields replaced by a full update, such as the batch PUT
+var UserUpdateFields = []string{
+	"Name",
+	"Email",
+	"Status",
+	"Age",
+	"Team",
+}
+
+// Apply sets the fields present in r on m, and returns the names of these fields
+func (r *UserUpdateRequest) Apply(m *models.User) (fields []string) {
+	if r.Name != nil {
+		m.Name = *r.Name
+		fields = append(fields, "Name")
+	}
+	if r.Email != nil {
+		m.Email = *r.Email
+		fields = append(fields, "Email")
+	}
+	if r.Status != nil {
+		m.Status = *r.Status
+		fields = append(fields, "Status")
+	}
+	if r.Age != nil {
+		m.Age = *r.Age
+		fields = append(fields, "Age")
+	}
+	if r.TeamId != nil {
+		m.Team = &models.Team{Id: *r.TeamId}
+		fields = append(fields, "Team")
+	}
+	return
+}
+
+// NewUserResponse converts m to a UserResponse
+func NewUserResponse(m *models.User) *UserResponse {
+	r := &UserResponse{
+		Id:        m.Id,
+		Name:      m.Name,
+		Email:     m.Email,
+		Status:    m.Status,
+		Age:       m.Age,
+		CreatedAt: m.CreatedAt,
+	}
+	if m.Team != nil {
+		r.TeamId = m.Team.Id
+	}
+	if m.Profile != nil {
+		r.Profile = NewProfileResponse(m.Profile)
+	}
+	for _, v := range m.Roles {
+		r.Roles = append(r.Roles, NewRoleResponse(v))
+	}
+	return r
+}
+
+// NewUserResponses converts the result of models.GetAllUser, the items
+// trimmed by fields are keyed by the json names of UserResponse
+func NewUserResponses(ml []interface{}) []interface{} {
+	rs := make([]interface{}, len(ml))
+	for i, v := range ml {
+		switch v := v.(type) {
+		case models.User:
+			rs[i] = NewUserResponse(&v)
+		case map[string]interface{}:
+			rs[i] = newUserFields(v)
+		default:
+			rs[i] = v
+		}
+	}
+	return rs
+}
+
+// newUserFields renames the fields of m to the json names of UserResponse
+func newUserFields(m map[string]interface{}) map[string]interface{} {
+	r := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		switch k {
+		case "Id":
+			r["id"] = v
+		case "Name":
+			r["name"] = v
+		case "Email":
+			r["email"] = v
+		case "Status":
+			r["status"] = v
+		case "Age":
+			r["age"] = v
+		case "CreatedAt":
+			r["createdAt"] = v
+		case "Team":
+			if v, ok := v.(*models.Team); ok && v != nil {
+				r["teamId"] = v.Id
+			} else {
+				r["teamId"] = 0
+			}
+		default:
+			r[k] = v
+		}
+	}
+	return r
+}
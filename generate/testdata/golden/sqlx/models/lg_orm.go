@@ -0,0 +1,275 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// DB is the connection used by the models, opened by OpenDB. Assign it directly
+// to use a database other than MySQL.
+var DB *sqlx.DB
+
+// OpenDB opens DB on the MySQL dsn, parseTime is always on for the time.Time fields
+func OpenDB(dsn string) error {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return err
+	}
+	cfg.ParseTime = true
+	DB, err = sqlx.Open("mysql", cfg.FormatDSN())
+	return err
+}
+
+func lgPostgres() bool {
+	switch DB.DriverName() {
+	case "postgres", "pgx":
+		return true
+	}
+	return false
+}
+
+// lgQuote 按数据库引用表名、列名
+func lgQuote(name string) string {
+	if lgPostgres() {
+		return "\"" + name + "\""
+	}
+	return "`" + name + "`"
+}
+
+func lgColumnList(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = lgQuote(column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func lgSelect(table string, columns []string) string {
+	return "SELECT " + lgColumnList(columns) + " FROM " + lgQuote(table)
+}
+
+// lgWhere where为空时不加条件
+func lgWhere(where string) string {
+	if where == "" {
+		return ""
+	}
+	return " WHERE " + where
+}
+
+// lgLimit limit不大于0时不限制条数
+func lgLimit(limit int64, offset int64) string {
+	if limit <= 0 {
+		if offset <= 0 {
+			return ""
+		}
+		limit = math.MaxInt64
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+}
+
+type lgScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// lgNullable 可为空的列，NULL时取零值
+type lgNullable struct {
+	dest interface{}
+}
+
+func lgNull(dest interface{}) sql.Scanner {
+	return lgNullable{dest}
+}
+
+func (n lgNullable) Scan(src interface{}) error {
+	v := reflect.ValueOf(n.dest).Elem()
+	if src == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	// 非NULL时按database/sql的规则转换
+	switch v.Kind() {
+	case reflect.String:
+		var s sql.NullString
+		if err := s.Scan(src); err != nil {
+			return err
+		}
+		v.SetString(s.String)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i sql.NullInt64
+		if err := i.Scan(src); err != nil {
+			return err
+		}
+		v.SetInt(i.Int64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var i sql.NullInt64
+		if err := i.Scan(src); err != nil {
+			return err
+		}
+		v.SetUint(uint64(i.Int64))
+	case reflect.Float32, reflect.Float64:
+		var f sql.NullFloat64
+		if err := f.Scan(src); err != nil {
+			return err
+		}
+		v.SetFloat(f.Float64)
+	case reflect.Bool:
+		var b sql.NullBool
+		if err := b.Scan(src); err != nil {
+			return err
+		}
+		v.SetBool(b.Bool)
+	default:
+		var t sql.NullTime
+		if err := t.Scan(src); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t.Time))
+	}
+	return nil
+}
+
+// lgTime 零值的时间写入NULL
+func lgTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+func lgMarks(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// lgInsert 新增一行并返回主键，PostgreSQL通过RETURNING取得
+func lgInsert(q sqlx.Ext, table string, pk string, columns []string, args []interface{}) (id int64, err error) {
+	query := "INSERT INTO " + lgQuote(table) + " (" + lgColumnList(columns) + ") VALUES (" + lgMarks(len(columns)) + ")"
+	if lgPostgres() {
+		err = q.QueryRowx(q.Rebind(query+" RETURNING "+lgQuote(pk)), args...).Scan(&id)
+		return
+	}
+	res, err := q.Exec(q.Rebind(query), args...)
+	if err != nil {
+		return
+	}
+	return res.LastInsertId()
+}
+
+// lgUpdate 按主键修改columns，返回修改的行数
+func lgUpdate(q sqlx.Ext, table string, pk string, id int, columns []string, args []interface{}) (int64, error) {
+	sets := make([]string, len(columns))
+	for i, column := range columns {
+		sets[i] = lgQuote(column) + " = ?"
+	}
+	query := "UPDATE " + lgQuote(table) + " SET " + strings.Join(sets, ", ") + " WHERE " + lgQuote(pk) + " = ?"
+	res, err := q.Exec(q.Rebind(query), append(args, id)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// lgDelete 按主键删除，返回删除的行数
+func lgDelete(q sqlx.Ext, table string, pk string, id int) (int64, error) {
+	res, err := q.Exec(q.Rebind("DELETE FROM "+lgQuote(table)+" WHERE "+lgQuote(pk)+" = ?"), id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// lgLinked 中间表through中是否已有id与refId的关联
+func lgLinked(q sqlx.Ext, through string, column string, refColumn string, id int, refId int) (bool, error) {
+	var count int64
+	err := sqlx.Get(q, &count, q.Rebind("SELECT COUNT(*) FROM "+lgQuote(through)+" WHERE "+lgQuote(column)+" = ? AND "+lgQuote(refColumn)+" = ?"), id, refId)
+	return count > 0, err
+}
+
+// lgLink 在中间表through中加入id与refId的关联
+func lgLink(q sqlx.Ext, through string, column string, refColumn string, id int, refId int) error {
+	_, err := q.Exec(q.Rebind("INSERT INTO "+lgQuote(through)+" ("+lgColumnList([]string{column, refColumn})+") VALUES (?, ?)"), id, refId)
+	return err
+}
+
+// lgUnlink 删除中间表through中id与refId的关联
+func lgUnlink(q sqlx.Ext, through string, column string, refColumn string, id int, refId int) error {
+	_, err := q.Exec(q.Rebind("DELETE FROM "+lgQuote(through)+" WHERE "+lgQuote(column)+" = ? AND "+lgQuote(refColumn)+" = ?"), id, refId)
+	return err
+}
+
+// lgUnlinkAll 删除中间表through中id的所有关联
+func lgUnlinkAll(q sqlx.Ext, through string, column string, id int) error {
+	_, err := q.Exec(q.Rebind("DELETE FROM "+lgQuote(through)+" WHERE "+lgQuote(column)+" = ?"), id)
+	return err
+}
+
+// LgThroughIds 一次查询中间表through中column为ids的关联，返回id到refColumn的对应
+func LgThroughIds(through string, column string, refColumn string, ids []int) (map[int][]int, error) {
+	rv := make(map[int][]int)
+	if len(ids) == 0 {
+		return rv, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	query := "SELECT " + lgColumnList([]string{column, refColumn}) + " FROM " + lgQuote(through) + " WHERE " + lgQuote(column) + " IN (" + lgMarks(len(ids)) + ")"
+	rows, err := DB.Query(DB.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, refId int
+		if err := rows.Scan(&id, &refId); err != nil {
+			return nil, err
+		}
+		rv[id] = append(rv[id], refId)
+	}
+	return rv, rows.Err()
+}
+
+// lgTransaction 在事务中执行fn，fn返回错误时回滚
+func lgTransaction(fn func(tx *sqlx.Tx) error) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// End 全部成功时提交事务，否则回滚
+func (r *LgBatchResult) End(tx *sqlx.Tx) (err error) {
+	if r.Failed > 0 {
+		return tx.Rollback()
+	}
+	if err = tx.Commit(); err == nil {
+		r.Committed = true
+	}
+	return
+}
+
+// lgExists 是否存在columns的值为args的其它记录，id不为0时排除自身
+func lgExists(table string, pk string, columns []string, args []interface{}, id int) bool {
+	var conds []string
+	for _, column := range columns {
+		conds = append(conds, lgQuote(column)+" = ?")
+	}
+	if id != 0 {
+		conds = append(conds, lgQuote(pk)+" <> ?")
+		args = append(args, id)
+	}
+	var count int64
+	DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote(table)+" WHERE "+strings.Join(conds, " AND ")), args...)
+	return count > 0
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// lgOps 支持的表达式及对应的SQL，i开头的忽略大小写
+var lgOps = map[string]string{
+	"exact":       "= ?",
+	"iexact":      "= LOWER(?)",
+	"contains":    "LIKE ?",
+	"icontains":   "LIKE LOWER(?)",
+	"startswith":  "LIKE ?",
+	"istartswith": "LIKE LOWER(?)",
+	"endswith":    "LIKE ?",
+	"iendswith":   "LIKE LOWER(?)",
+	"gt":          "> ?",
+	"gte":         ">= ?",
+	"lt":          "< ?",
+	"lte":         "<= ?",
+	"in":          "IN",
+	"isnull":      "IS NULL",
+}
+
+// LgQueryWhere 把query参数转换为SQL条件及参数，没有条件时返回空字符串
+// columns为可用的字段名、列名到列的对应，其它字段返回错误
+//
+//	not_empty:column                       非空
+//	search:column1>value1|column2>value2   模糊或搜索，多组之间用^分隔
+//	dsearch:column1>value1|column2>value2  精确或搜索，多组之间用^分隔
+//	neq:column1>value1                     不等于
+//	column__isnull:true                    是否为空
+//	column__op:value                       op见lgOps，in的值用逗号分隔
+func LgQueryWhere(query map[string]string, columns map[string]string) (where string, args []interface{}, err error) {
+	// 按key排序，相同的query生成相同的SQL
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var conds []string
+	for _, k := range keys {
+		v := query[k]
+		switch k {
+		case "not_empty":
+			column, e := lgColumn(columns, v)
+			if e != nil {
+				return "", nil, e
+			}
+			conds = append(conds, column+" IS NOT NULL AND "+column+" <> ?")
+			args = append(args, "")
+		case "search", "dsearch":
+			op := "contains"
+			if k == "dsearch" {
+				op = "exact"
+			}
+			for _, group := range strings.Split(v, "^") {
+				var ors []string
+				for _, item := range strings.Split(group, "|") {
+					filed := strings.Split(item, ">")
+					if len(filed) != 2 {
+						continue
+					}
+					cond, a, e := lgExpr(columns, filed[0], op, filed[1])
+					if e != nil {
+						return "", nil, e
+					}
+					ors = append(ors, cond)
+					args = append(args, a...)
+				}
+				if len(ors) > 0 {
+					conds = append(conds, strings.Join(ors, " OR "))
+				}
+			}
+		case "neq":
+			filed := strings.Split(v, ">")
+			if len(filed) == 2 {
+				column, e := lgColumn(columns, filed[0])
+				if e != nil {
+					return "", nil, e
+				}
+				conds = append(conds, column+" <> ?")
+				args = append(args, filed[1])
+			}
+		default:
+			name, op := k, "exact"
+			if i := strings.LastIndex(k, "__"); i >= 0 {
+				if _, ok := lgOps[k[i+2:]]; ok {
+					name, op = k[:i], k[i+2:]
+				}
+			}
+			cond, a, e := lgExpr(columns, name, op, v)
+			if e != nil {
+				return "", nil, e
+			}
+			conds = append(conds, cond)
+			args = append(args, a...)
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return "(" + strings.Join(conds, ") AND (") + ")", args, nil
+}
+
+// lgExpr 返回name op value的SQL条件
+func lgExpr(columns map[string]string, name string, op string, value string) (cond string, args []interface{}, err error) {
+	column, err := lgColumn(columns, name)
+	if err != nil {
+		return
+	}
+	escaped := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+	switch op {
+	case "isnull":
+		if value == "true" || value == "1" {
+			return column + " IS NULL", nil, nil
+		}
+		return column + " IS NOT NULL", nil, nil
+	case "in":
+		values := strings.Split(value, ",")
+		marks := make([]string, len(values))
+		for i, v := range values {
+			marks[i] = "?"
+			args = append(args, v)
+		}
+		return column + " IN (" + strings.Join(marks, ", ") + ")", args, nil
+	case "contains", "icontains":
+		value = "%" + escaped + "%"
+	case "startswith", "istartswith":
+		value = escaped + "%"
+	case "endswith", "iendswith":
+		value = "%" + escaped
+	}
+	if strings.HasPrefix(op, "i") {
+		column = "LOWER(" + column + ")"
+	}
+	return column + " " + lgOps[op], []interface{}{value}, nil
+}
+
+// lgColumn 返回字段对应的列，dot-notation的Team.Id与Team__Id相同
+func lgColumn(columns map[string]string, name string) (string, error) {
+	column, ok := columns[strings.Replace(name, ".", "__", -1)]
+	if !ok {
+		return "", errors.New("Error: unknown field '" + name + "'")
+	}
+	return lgQuote(column), nil
+}
+
+// LgOrderBy 把sortby和order转换为排序字段，降序的字段以-开头，order只有一个时用于所有sortby
+func LgOrderBy(sortby []string, order []string) (sortFields []string, err error) {
+	if len(sortby) != 0 {
+		if len(sortby) == len(order) {
+			// 1) for each sort field, there is an associated order
+			for i, v := range sortby {
+				orderby := ""
+				if order[i] == "desc" {
+					orderby = "-" + v
+				} else if order[i] == "asc" {
+					orderby = v
+				} else {
+					return nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
+				}
+				sortFields = append(sortFields, orderby)
+			}
+		} else if len(order) == 1 {
+			// 2) there is exactly one order, all the sorted fields will be sorted by this order
+			for _, v := range sortby {
+				orderby := ""
+				if order[0] == "desc" {
+					orderby = "-" + v
+				} else if order[0] == "asc" {
+					orderby = v
+				} else {
+					return nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
+				}
+				sortFields = append(sortFields, orderby)
+			}
+		} else {
+			return nil, errors.New("Error: 'sortby', 'order' sizes mismatch or 'order' size is not 1")
+		}
+	} else {
+		if len(order) != 0 {
+			return nil, errors.New("Error: unused 'order' fields")
+		}
+	}
+	return
+}
+
+// LgOrderSQL 把sortby和order转换为ORDER BY后的SQL，没有排序时返回空字符串
+func LgOrderSQL(sortby []string, order []string, columns map[string]string) (string, error) {
+	sortFields, err := LgOrderBy(sortby, order)
+	if err != nil {
+		return "", err
+	}
+	var rv []string
+	for _, v := range sortFields {
+		direction := " ASC"
+		if strings.HasPrefix(v, "-") {
+			v, direction = v[1:], " DESC"
+		}
+		column, err := lgColumn(columns, v)
+		if err != nil {
+			return "", err
+		}
+		rv = append(rv, column+direction)
+	}
+	return strings.Join(rv, ", "), nil
+}
+
+// lgCheckFields fields必须是t的字段
+func lgCheckFields(t reflect.Type, fields []string) error {
+	for _, fname := range fields {
+		if _, ok := t.FieldByName(fname); !ok {
+			return errors.New("Error: unknown field '" + fname + "'")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,410 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Profile struct {
+	Id   int    `db:"id"`
+	Bio  string `db:"bio"`
+	User *User  `db:"-" description:"与[user] 一对一 关系，该表为扩展表，该表user_id为关联字段。"`
+}
+
+func (t *Profile) TableName() string {
+	return "profile"
+}
+
+// ProfileColumns maps the fields and columns accepted by query and sortby to the columns
+var ProfileColumns = map[string]string{
+	"Id":       "id",
+	"id":       "id",
+	"Bio":      "bio",
+	"bio":      "bio",
+	"User":     "user_id",
+	"user_id":  "user_id",
+	"User__Id": "user_id",
+	"user__id": "user_id",
+}
+
+// the columns read by scanProfile, and the fields written by insert and update
+var (
+	profileColumnList   = []string{"id", "bio", "user_id"}
+	profileInsertFields = []string{"Bio", "User"}
+	profileUpdateFields = []string{"Bio", "User"}
+)
+
+// scanProfile reads a row in the order of profileColumnList, NULL is read as the zero value
+func scanProfile(s lgScanner) (*Profile, error) {
+	m := &Profile{}
+	var userId sql.NullInt64
+	if err := s.Scan(&m.Id, lgNull(&m.Bio), &userId); err != nil {
+		return nil, err
+	}
+	if userId.Valid {
+		m.User = &User{Id: int(userId.Int64)}
+	}
+	return m, nil
+}
+
+// queryProfile reads the Profiles selected by tail, the SQL after FROM
+func queryProfile(q sqlx.Queryer, tail string, args ...interface{}) (ms []*Profile, err error) {
+	rows, err := q.Query(DB.Rebind(lgSelect("profile", profileColumnList)+tail), args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		m, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, rows.Err()
+}
+
+// getProfile reads the Profile by Id, returns sql.ErrNoRows if it doesn't exist
+func getProfile(q sqlx.Queryer, id int) (*Profile, error) {
+	return scanProfile(q.QueryRowx(DB.Rebind(lgSelect("profile", profileColumnList)+" WHERE "+lgQuote("id")+" = ?"), id))
+}
+
+// valuesProfile returns the columns and values of fields of m
+func valuesProfile(m *Profile, fields []string) (columns []string, args []interface{}, err error) {
+	for _, field := range fields {
+		switch field {
+		case "Id":
+			columns = append(columns, "id")
+			args = append(args, m.Id)
+		case "Bio":
+			columns = append(columns, "bio")
+			args = append(args, m.Bio)
+		case "User":
+			columns = append(columns, "user_id")
+			var v interface{}
+			if m.User != nil {
+				v = m.User.Id
+			}
+			args = append(args, v)
+		default:
+			return nil, nil, errors.New("Error: unknown field '" + field + "'")
+		}
+	}
+	return
+}
+
+// insertProfile inserts m by q, a connection or a transaction
+func insertProfile(q sqlx.Ext, m *Profile) (id int64, err error) {
+
+	columns, args, err := valuesProfile(m, profileInsertFields)
+	if err != nil {
+		return
+	}
+	if id, err = lgInsert(q, "profile", "id", columns, args); err == nil {
+		m.Id = int(id)
+	}
+	return
+}
+
+// updateProfile updates fields of m by q, the auto_now fields are always updated
+func updateProfile(q sqlx.Ext, m *Profile, fields []string) error {
+
+	columns, args, err := valuesProfile(m, fields)
+	if err != nil {
+		return err
+	}
+	_, err = lgUpdate(q, "profile", "id", m.Id, columns, args)
+	return err
+}
+
+// LoadRelatedOf loads the relation r of t
+func (t *Profile) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	switch r {
+	case "User":
+		if t.User == nil {
+			return 0, nil
+		}
+		v, err := getUser(DB, t.User.Id)
+		if err != nil {
+			return 0, err
+		}
+		t.User = v
+		return 1, nil
+	}
+	return 0, errors.New("Error: unknown relation '" + r + "'")
+}
+
+// AddProfile insert a new Profile into database and returns
+// last inserted Id on success.
+func AddProfile(m *Profile) (id int64, err error) {
+	return insertProfile(DB, m)
+}
+
+// AddMultiProfile insert multi Profiles into database and returns
+// sum success nums.
+func AddMultiProfile(ms []*Profile) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 逐条插入，在同一个事务中
+	err = lgTransaction(func(tx *sqlx.Tx) error {
+		for _, m := range ms {
+			if _, err := insertProfile(tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// AddProfileHasMany insert a new Profile and some items into database and returns
+// last inserted Id on success.
+func AddProfileHasMany(m *Profile) (id int64, err error) {
+	err = lgTransaction(func(tx *sqlx.Tx) (err error) {
+		if id, err = insertProfile(tx, m); err != nil {
+			return
+		}
+
+		return nil
+	})
+	return
+}
+
+// GetProfileById retrieves Profile by Id. Returns error if
+// Id doesn't exist
+func GetProfileById(id int) (v *Profile, err error) {
+	return getProfile(DB, id)
+}
+
+// GetProfileCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetProfileCounts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, ProfileColumns)
+	if err != nil {
+		return
+	}
+	err = DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("profile")+lgWhere(where)), args...)
+	return
+}
+
+// GetAllProfile retrieves all Profile matches certain condition. Returns empty list if
+// no records exist
+func GetAllProfile(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, ProfileColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, ProfileColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf(Profile{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	var count int64 = 0
+	if page == 1 {
+		DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("profile")+lgWhere(where)), args...)
+	}
+	tail := lgWhere(where)
+	if orderBy != "" {
+		tail += " ORDER BY " + orderBy
+	}
+
+	l, err := queryProfile(DB, tail+lgLimit(limit, offset), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			for _, lo := range load {
+				v.LoadRelatedOf(lo)
+			}
+			ml = append(ml, *v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(*v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// UpdateProfile updates Profile by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateProfileById(m *Profile) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) (err error) {
+		// ascertain id exists in the database
+		if _, err = getProfile(tx, m.Id); err != nil {
+			return
+		}
+
+		return updateProfile(tx, m, profileUpdateFields)
+	})
+}
+
+// PatchProfile updates Profile by Id and returns error if
+// the record to be updated doesn't exist
+func PatchProfileById(m *Profile, fields []string) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		return patchProfile(tx, m, fields)
+	})
+}
+
+// patchProfile updates the given fields of Profile inside the
+// transaction of tx, many to many fields are replaced
+func patchProfile(tx *sqlx.Tx, m *Profile, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新
+	if len(columns) == 0 {
+		return
+	}
+	return updateProfile(tx, m, columns)
+}
+
+// UpdateMultiProfile updates several Profiles in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiProfile(ms []*Profile, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if _, itemErr = getProfile(tx, m.Id); itemErr == nil {
+				itemErr = patchProfile(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiProfileByIds deletes Profiles by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiProfileByIds(ids []int) (result *LgBatchResult, err error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = deleteProfileBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiProfileByQuery deletes all Profiles matches the same query as
+// GetAllProfile in one transaction.
+func DeleteMultiProfileByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, ProfileColumns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Select(&ids, DB.Rebind("SELECT "+lgQuote("id")+" FROM "+lgQuote("profile")+lgWhere(where)), args...); err != nil {
+		tx.Rollback()
+		return
+	}
+	result = deleteProfileBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// deleteProfileBatch deletes Profiles one by one inside the transaction of tx
+func deleteProfileBatch(tx *sqlx.Tx, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := lgDelete(tx, "profile", "id", id)
+		if err == nil && num == 0 {
+			err = sql.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchProfileM2MPart updates Profile by Id and returns error if
+// the record to be updated doesn't exist
+func PatchProfileM2MPartById(m *Profile, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		switch field {
+
+		}
+		return nil
+	})
+}
+
+// DeleteProfile deletes Profile by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteProfile(id int) (err error) {
+	// ascertain id exists in the database
+	if _, err = getProfile(DB, id); err == nil {
+		var num int64
+		if num, err = lgDelete(DB, "profile", "id", id); err == nil {
+			fmt.Println("Number of records deleted in database:", num)
+		}
+	}
+	return
+}
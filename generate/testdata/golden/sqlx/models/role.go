@@ -0,0 +1,521 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"reflect"
+	"strings"
+)
+
+type Role struct {
+	Id    int     `db:"id"`
+	Name  string  `db:"name"`
+	Users []*User `db:"-" description:"与[user] 多对多 关系，中间表是：user_has_role"`
+}
+
+func (t *Role) TableName() string {
+	return "role"
+}
+
+// RoleColumns maps the fields and columns accepted by query and sortby to the columns
+var RoleColumns = map[string]string{
+	"Id":   "id",
+	"id":   "id",
+	"Name": "name",
+	"name": "name",
+}
+
+// the columns read by scanRole, and the fields written by insert and update
+var (
+	roleColumnList   = []string{"id", "name"}
+	roleInsertFields = []string{"Name"}
+	roleUpdateFields = []string{"Name"}
+)
+
+// scanRole reads a row in the order of roleColumnList, NULL is read as the zero value
+func scanRole(s lgScanner) (*Role, error) {
+	m := &Role{}
+
+	if err := s.Scan(&m.Id, &m.Name); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// queryRole reads the Roles selected by tail, the SQL after FROM
+func queryRole(q sqlx.Queryer, tail string, args ...interface{}) (ms []*Role, err error) {
+	rows, err := q.Query(DB.Rebind(lgSelect("role", roleColumnList)+tail), args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		m, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, rows.Err()
+}
+
+// getRole reads the Role by Id, returns sql.ErrNoRows if it doesn't exist
+func getRole(q sqlx.Queryer, id int) (*Role, error) {
+	return scanRole(q.QueryRowx(DB.Rebind(lgSelect("role", roleColumnList)+" WHERE "+lgQuote("id")+" = ?"), id))
+}
+
+// valuesRole returns the columns and values of fields of m
+func valuesRole(m *Role, fields []string) (columns []string, args []interface{}, err error) {
+	for _, field := range fields {
+		switch field {
+		case "Id":
+			columns = append(columns, "id")
+			args = append(args, m.Id)
+		case "Name":
+			columns = append(columns, "name")
+			args = append(args, m.Name)
+		default:
+			return nil, nil, errors.New("Error: unknown field '" + field + "'")
+		}
+	}
+	return
+}
+
+// insertRole inserts m by q, a connection or a transaction
+func insertRole(q sqlx.Ext, m *Role) (id int64, err error) {
+
+	columns, args, err := valuesRole(m, roleInsertFields)
+	if err != nil {
+		return
+	}
+	if id, err = lgInsert(q, "role", "id", columns, args); err == nil {
+		m.Id = int(id)
+	}
+	return
+}
+
+// updateRole updates fields of m by q, the auto_now fields are always updated
+func updateRole(q sqlx.Ext, m *Role, fields []string) error {
+
+	columns, args, err := valuesRole(m, fields)
+	if err != nil {
+		return err
+	}
+	_, err = lgUpdate(q, "role", "id", m.Id, columns, args)
+	return err
+}
+
+// LoadRelatedOf loads the relation r of t
+func (t *Role) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	switch r {
+	case "Users":
+		l, err := queryUser(DB, " WHERE "+lgQuote("id")+" IN (SELECT "+lgQuote("user_id")+" FROM "+lgQuote("user_has_role")+" WHERE "+lgQuote("role_id")+" = ?)", t.Id)
+		if err != nil {
+			return 0, err
+		}
+		t.Users = l
+		return int64(len(l)), nil
+	}
+	return 0, errors.New("Error: unknown relation '" + r + "'")
+}
+
+// AddRole insert a new Role into database and returns
+// last inserted Id on success.
+func AddRole(m *Role) (id int64, err error) {
+	return insertRole(DB, m)
+}
+
+// AddMultiRole insert multi Roles into database and returns
+// sum success nums.
+func AddMultiRole(ms []*Role) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 逐条插入，在同一个事务中
+	err = lgTransaction(func(tx *sqlx.Tx) error {
+		for _, m := range ms {
+			if _, err := insertRole(tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// AddRoleHasMany insert a new Role and some items into database and returns
+// last inserted Id on success.
+func AddRoleHasMany(m *Role) (id int64, err error) {
+	err = lgTransaction(func(tx *sqlx.Tx) (err error) {
+		if id, err = insertRole(tx, m); err != nil {
+			return
+		}
+		// m2m_add
+		for _, v := range m.Users {
+			if err := lgLink(tx, "user_has_role", "role_id", "user_id", m.Id, v.Id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// GetRoleById retrieves Role by Id. Returns error if
+// Id doesn't exist
+func GetRoleById(id int) (v *Role, err error) {
+	return getRole(DB, id)
+}
+
+// GetRoleCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetRoleCounts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, RoleColumns)
+	if err != nil {
+		return
+	}
+	err = DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("role")+lgWhere(where)), args...)
+	return
+}
+
+// GetAllRole retrieves all Role matches certain condition. Returns empty list if
+// no records exist
+func GetAllRole(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, RoleColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, RoleColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf(Role{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	var count int64 = 0
+	if page == 1 {
+		DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("role")+lgWhere(where)), args...)
+	}
+	tail := lgWhere(where)
+	if orderBy != "" {
+		tail += " ORDER BY " + orderBy
+	}
+
+	l, err := queryRole(DB, tail+lgLimit(limit, offset), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			for _, lo := range load {
+				v.LoadRelatedOf(lo)
+			}
+			ml = append(ml, *v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(*v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// UpdateRole updates Role by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateRoleById(m *Role) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) (err error) {
+		// ascertain id exists in the database
+		if _, err = getRole(tx, m.Id); err != nil {
+			return
+		}
+		// m2m_update
+		if m.Users != nil {
+			if err = lgUnlinkAll(tx, "user_has_role", "role_id", m.Id); err != nil {
+				return
+			}
+			for _, v := range m.Users {
+				if err = lgLink(tx, "user_has_role", "role_id", "user_id", m.Id, v.Id); err != nil {
+					return
+				}
+			}
+		}
+		return updateRole(tx, m, roleUpdateFields)
+	})
+}
+
+// PatchRole updates Role by Id and returns error if
+// the record to be updated doesn't exist
+func PatchRoleById(m *Role, fields []string) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		return patchRole(tx, m, fields)
+	})
+}
+
+// patchRole updates the given fields of Role inside the
+// transaction of tx, many to many fields are replaced
+func patchRole(tx *sqlx.Tx, m *Role, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+		case "Users":
+			// m2m_patch
+			if m.Users != nil {
+				if err = lgUnlinkAll(tx, "user_has_role", "role_id", m.Id); err != nil {
+					return
+				}
+				for _, v := range m.Users {
+					if err = lgLink(tx, "user_has_role", "role_id", "user_id", m.Id, v.Id); err != nil {
+						return
+					}
+				}
+			}
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新
+	if len(columns) == 0 {
+		return
+	}
+	return updateRole(tx, m, columns)
+}
+
+// UpdateMultiRole updates several Roles in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiRole(ms []*Role, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if _, itemErr = getRole(tx, m.Id); itemErr == nil {
+				itemErr = patchRole(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiRoleByIds deletes Roles by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiRoleByIds(ids []int) (result *LgBatchResult, err error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = deleteRoleBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiRoleByQuery deletes all Roles matches the same query as
+// GetAllRole in one transaction.
+func DeleteMultiRoleByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, RoleColumns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Select(&ids, DB.Rebind("SELECT "+lgQuote("id")+" FROM "+lgQuote("role")+lgWhere(where)), args...); err != nil {
+		tx.Rollback()
+		return
+	}
+	result = deleteRoleBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// deleteRoleBatch deletes Roles one by one inside the transaction of tx
+func deleteRoleBatch(tx *sqlx.Tx, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := lgDelete(tx, "role", "id", id)
+		if err == nil && num == 0 {
+			err = sql.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchRoleM2MPart updates Role by Id and returns error if
+// the record to be updated doesn't exist
+func PatchRoleM2MPartById(m *Role, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		switch field {
+		case "Users":
+			for _, did := range DelIds {
+				if err := lgUnlink(tx, "user_has_role", "role_id", "user_id", m.Id, did); err != nil {
+					return err
+				}
+			}
+			for _, aid := range AddIds {
+				// 已关联的不再加入
+				linked, err := lgLinked(tx, "user_has_role", "role_id", "user_id", m.Id, aid)
+				if err == nil && !linked {
+					err = lgLink(tx, "user_has_role", "role_id", "user_id", m.Id, aid)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteRole deletes Role by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteRole(id int) (err error) {
+	// ascertain id exists in the database
+	if _, err = getRole(DB, id); err == nil {
+		var num int64
+		if num, err = lgDelete(DB, "role", "id", id); err == nil {
+			fmt.Println("Number of records deleted in database:", num)
+		}
+	}
+	return
+}
+
+// upsertRoleArgs returns the values of the columns inserted by upsert
+func upsertRoleArgs(m *Role) []interface{} {
+
+	return []interface{}{m.Name}
+}
+
+// UpsertRoleByName inserts m, or updates the Role with the same
+// (name), and returns the Id on success. (name) is the only unique key
+// of the table, so the conflict handled by MySQL is always on it.
+func UpsertRoleByName(m *Role) (id int64, err error) {
+	return upsertRoleByName(DB, m)
+}
+
+func upsertRoleByName(q sqlx.Ext, m *Role) (id int64, err error) {
+	args := upsertRoleArgs(m)
+	if lgPostgres() {
+		err = q.QueryRowx(q.Rebind("INSERT INTO \"role\" (\"name\") VALUES (?) ON CONFLICT (\"name\") DO UPDATE SET \"name\" = EXCLUDED.\"name\" RETURNING \"id\""), args...).Scan(&id)
+	} else {
+		res, e := q.Exec("INSERT INTO `role` (`name`) VALUES (?) ON DUPLICATE KEY UPDATE `id` = LAST_INSERT_ID(`id`)", args...)
+		if err = e; err == nil {
+			id, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return
+	}
+	// 重新读取，修改时m中的字段不一定与数据库一致
+	v, err := getRole(q, int(id))
+	if err == nil {
+		*m = *v
+	}
+	return
+}
+
+// RoleUniqueKeys lists the unique keys accepted by the by argument of UpsertRole
+var RoleUniqueKeys = []string{"name"}
+
+func upsertRoleFunc(by string) (func(sqlx.Ext, *Role) (int64, error), error) {
+	switch by {
+	case "", "name":
+		return upsertRoleByName, nil
+	}
+	return nil, errors.New("Error: unknown unique key '" + by + "', must be one of " + strings.Join(RoleUniqueKeys, " | "))
+}
+
+// UpsertRole inserts or updates m by the unique key by, the columns of by are
+// separated by ",", an empty by means the first unique key.
+func UpsertRole(m *Role, by string) (id int64, err error) {
+	upsert, err := upsertRoleFunc(by)
+	if err != nil {
+		return
+	}
+	// 唯一键冲突时修改，只校验列
+	if err = m.ValidateColumns(); err != nil {
+		return
+	}
+	return upsert(DB, m)
+}
+
+// UpsertMultiRole inserts or updates several Roles by the unique key by in
+// one transaction. Nothing is committed unless every item succeeds, and the items after
+// the first failed one are skipped.
+func UpsertMultiRole(ms []*Role, by string) (result *LgBatchResult, err error) {
+	upsert, err := upsertRoleFunc(by)
+	if err != nil {
+		return
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		itemErr := m.ValidateColumns()
+		if itemErr == nil {
+			_, itemErr = upsert(tx, m)
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
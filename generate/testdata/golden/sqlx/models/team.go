@@ -0,0 +1,401 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Team struct {
+	Id    int     `db:"id"`
+	Name  string  `db:"name" description:"team name"`
+	Users []*User `db:"-" description:"与[user] 一对多 关系，该表为主表，该表id为关联字段。"`
+}
+
+func (t *Team) TableName() string {
+	return "team"
+}
+
+// TeamColumns maps the fields and columns accepted by query and sortby to the columns
+var TeamColumns = map[string]string{
+	"Id":   "id",
+	"id":   "id",
+	"Name": "name",
+	"name": "name",
+}
+
+// the columns read by scanTeam, and the fields written by insert and update
+var (
+	teamColumnList   = []string{"id", "name"}
+	teamInsertFields = []string{"Name"}
+	teamUpdateFields = []string{"Name"}
+)
+
+// scanTeam reads a row in the order of teamColumnList, NULL is read as the zero value
+func scanTeam(s lgScanner) (*Team, error) {
+	m := &Team{}
+
+	if err := s.Scan(&m.Id, &m.Name); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// queryTeam reads the Teams selected by tail, the SQL after FROM
+func queryTeam(q sqlx.Queryer, tail string, args ...interface{}) (ms []*Team, err error) {
+	rows, err := q.Query(DB.Rebind(lgSelect("team", teamColumnList)+tail), args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		m, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, rows.Err()
+}
+
+// getTeam reads the Team by Id, returns sql.ErrNoRows if it doesn't exist
+func getTeam(q sqlx.Queryer, id int) (*Team, error) {
+	return scanTeam(q.QueryRowx(DB.Rebind(lgSelect("team", teamColumnList)+" WHERE "+lgQuote("id")+" = ?"), id))
+}
+
+// valuesTeam returns the columns and values of fields of m
+func valuesTeam(m *Team, fields []string) (columns []string, args []interface{}, err error) {
+	for _, field := range fields {
+		switch field {
+		case "Id":
+			columns = append(columns, "id")
+			args = append(args, m.Id)
+		case "Name":
+			columns = append(columns, "name")
+			args = append(args, m.Name)
+		default:
+			return nil, nil, errors.New("Error: unknown field '" + field + "'")
+		}
+	}
+	return
+}
+
+// insertTeam inserts m by q, a connection or a transaction
+func insertTeam(q sqlx.Ext, m *Team) (id int64, err error) {
+
+	columns, args, err := valuesTeam(m, teamInsertFields)
+	if err != nil {
+		return
+	}
+	if id, err = lgInsert(q, "team", "id", columns, args); err == nil {
+		m.Id = int(id)
+	}
+	return
+}
+
+// updateTeam updates fields of m by q, the auto_now fields are always updated
+func updateTeam(q sqlx.Ext, m *Team, fields []string) error {
+
+	columns, args, err := valuesTeam(m, fields)
+	if err != nil {
+		return err
+	}
+	_, err = lgUpdate(q, "team", "id", m.Id, columns, args)
+	return err
+}
+
+// LoadRelatedOf loads the relation r of t
+func (t *Team) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	switch r {
+	case "Users":
+		l, err := queryUser(DB, " WHERE "+lgQuote("team_id")+" = ?", t.Id)
+		if err != nil {
+			return 0, err
+		}
+		t.Users = l
+		return int64(len(l)), nil
+	}
+	return 0, errors.New("Error: unknown relation '" + r + "'")
+}
+
+// AddTeam insert a new Team into database and returns
+// last inserted Id on success.
+func AddTeam(m *Team) (id int64, err error) {
+	return insertTeam(DB, m)
+}
+
+// AddMultiTeam insert multi Teams into database and returns
+// sum success nums.
+func AddMultiTeam(ms []*Team) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 逐条插入，在同一个事务中
+	err = lgTransaction(func(tx *sqlx.Tx) error {
+		for _, m := range ms {
+			if _, err := insertTeam(tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// AddTeamHasMany insert a new Team and some items into database and returns
+// last inserted Id on success.
+func AddTeamHasMany(m *Team) (id int64, err error) {
+	err = lgTransaction(func(tx *sqlx.Tx) (err error) {
+		if id, err = insertTeam(tx, m); err != nil {
+			return
+		}
+		// o2m_add
+		for _, v := range m.Users {
+			v.Team = &Team{Id: m.Id}
+			if _, err := insertUser(tx, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// GetTeamById retrieves Team by Id. Returns error if
+// Id doesn't exist
+func GetTeamById(id int) (v *Team, err error) {
+	return getTeam(DB, id)
+}
+
+// GetTeamCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetTeamCounts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, TeamColumns)
+	if err != nil {
+		return
+	}
+	err = DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("team")+lgWhere(where)), args...)
+	return
+}
+
+// GetAllTeam retrieves all Team matches certain condition. Returns empty list if
+// no records exist
+func GetAllTeam(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, TeamColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, TeamColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf(Team{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	var count int64 = 0
+	if page == 1 {
+		DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("team")+lgWhere(where)), args...)
+	}
+	tail := lgWhere(where)
+	if orderBy != "" {
+		tail += " ORDER BY " + orderBy
+	}
+
+	l, err := queryTeam(DB, tail+lgLimit(limit, offset), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			for _, lo := range load {
+				v.LoadRelatedOf(lo)
+			}
+			ml = append(ml, *v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(*v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// UpdateTeam updates Team by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateTeamById(m *Team) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) (err error) {
+		// ascertain id exists in the database
+		if _, err = getTeam(tx, m.Id); err != nil {
+			return
+		}
+
+		return updateTeam(tx, m, teamUpdateFields)
+	})
+}
+
+// PatchTeam updates Team by Id and returns error if
+// the record to be updated doesn't exist
+func PatchTeamById(m *Team, fields []string) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		return patchTeam(tx, m, fields)
+	})
+}
+
+// patchTeam updates the given fields of Team inside the
+// transaction of tx, many to many fields are replaced
+func patchTeam(tx *sqlx.Tx, m *Team, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+		case "Users":
+			// 反向的关系在关联表中，不修改
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新
+	if len(columns) == 0 {
+		return
+	}
+	return updateTeam(tx, m, columns)
+}
+
+// UpdateMultiTeam updates several Teams in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiTeam(ms []*Team, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if _, itemErr = getTeam(tx, m.Id); itemErr == nil {
+				itemErr = patchTeam(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiTeamByIds deletes Teams by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiTeamByIds(ids []int) (result *LgBatchResult, err error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = deleteTeamBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiTeamByQuery deletes all Teams matches the same query as
+// GetAllTeam in one transaction.
+func DeleteMultiTeamByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, TeamColumns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Select(&ids, DB.Rebind("SELECT "+lgQuote("id")+" FROM "+lgQuote("team")+lgWhere(where)), args...); err != nil {
+		tx.Rollback()
+		return
+	}
+	result = deleteTeamBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// deleteTeamBatch deletes Teams one by one inside the transaction of tx
+func deleteTeamBatch(tx *sqlx.Tx, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := lgDelete(tx, "team", "id", id)
+		if err == nil && num == 0 {
+			err = sql.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchTeamM2MPart updates Team by Id and returns error if
+// the record to be updated doesn't exist
+func PatchTeamM2MPartById(m *Team, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		switch field {
+
+		}
+		return nil
+	})
+}
+
+// DeleteTeam deletes Team by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteTeam(id int) (err error) {
+	// ascertain id exists in the database
+	if _, err = getTeam(DB, id); err == nil {
+		var num int64
+		if num, err = lgDelete(DB, "team", "id", id); err == nil {
+			fmt.Println("Number of records deleted in database:", num)
+		}
+	}
+	return
+}
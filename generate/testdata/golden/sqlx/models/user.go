@@ -0,0 +1,598 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type User struct {
+	Id        int       `db:"id"`
+	Name      string    `db:"name" description:"user name"`
+	Email     string    `db:"email"`
+	Status    string    `db:"status"`
+	Age       uint8     `db:"age"`
+	CreatedAt time.Time `db:"created_at"`
+	Profile   *Profile  `db:"-" description:"与[profile] 一对一 关系，该表为主表，该表id为关联字段。"`
+	Roles     []*Role   `db:"-" description:"与[role] 多对多 关系，中间表是：user_has_role"`
+	Team      *Team     `db:"-" description:"与[team] 一对多 关系，该表为从表，该表team_id为关联字段。"`
+}
+
+func (t *User) TableName() string {
+	return "user"
+}
+
+// UserColumns maps the fields and columns accepted by query and sortby to the columns
+var UserColumns = map[string]string{
+	"Id":         "id",
+	"id":         "id",
+	"Name":       "name",
+	"name":       "name",
+	"Email":      "email",
+	"email":      "email",
+	"Status":     "status",
+	"status":     "status",
+	"Age":        "age",
+	"age":        "age",
+	"CreatedAt":  "created_at",
+	"created_at": "created_at",
+	"Team":       "team_id",
+	"team_id":    "team_id",
+	"Team__Id":   "team_id",
+	"team__id":   "team_id",
+}
+
+// the columns read by scanUser, and the fields written by insert and update
+var (
+	userColumnList   = []string{"id", "name", "email", "status", "age", "created_at", "team_id"}
+	userInsertFields = []string{"Name", "Email", "Status", "Age", "CreatedAt", "Team"}
+	userUpdateFields = []string{"Name", "Email", "Status", "Age", "Team"}
+)
+
+// scanUser reads a row in the order of userColumnList, NULL is read as the zero value
+func scanUser(s lgScanner) (*User, error) {
+	m := &User{}
+	var teamId sql.NullInt64
+	if err := s.Scan(&m.Id, &m.Name, &m.Email, &m.Status, lgNull(&m.Age), &m.CreatedAt, &teamId); err != nil {
+		return nil, err
+	}
+	if teamId.Valid {
+		m.Team = &Team{Id: int(teamId.Int64)}
+	}
+	return m, nil
+}
+
+// queryUser reads the Users selected by tail, the SQL after FROM
+func queryUser(q sqlx.Queryer, tail string, args ...interface{}) (ms []*User, err error) {
+	rows, err := q.Query(DB.Rebind(lgSelect("user", userColumnList)+tail), args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		m, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, rows.Err()
+}
+
+// getUser reads the User by Id, returns sql.ErrNoRows if it doesn't exist
+func getUser(q sqlx.Queryer, id int) (*User, error) {
+	return scanUser(q.QueryRowx(DB.Rebind(lgSelect("user", userColumnList)+" WHERE "+lgQuote("id")+" = ?"), id))
+}
+
+// valuesUser returns the columns and values of fields of m
+func valuesUser(m *User, fields []string) (columns []string, args []interface{}, err error) {
+	for _, field := range fields {
+		switch field {
+		case "Id":
+			columns = append(columns, "id")
+			args = append(args, m.Id)
+		case "Name":
+			columns = append(columns, "name")
+			args = append(args, m.Name)
+		case "Email":
+			columns = append(columns, "email")
+			args = append(args, m.Email)
+		case "Status":
+			columns = append(columns, "status")
+			args = append(args, m.Status)
+		case "Age":
+			columns = append(columns, "age")
+			args = append(args, m.Age)
+		case "CreatedAt":
+			columns = append(columns, "created_at")
+			args = append(args, lgTime(m.CreatedAt))
+		case "Team":
+			columns = append(columns, "team_id")
+			var v interface{}
+			if m.Team != nil {
+				v = m.Team.Id
+			}
+			args = append(args, v)
+		default:
+			return nil, nil, errors.New("Error: unknown field '" + field + "'")
+		}
+	}
+	return
+}
+
+// insertUser inserts m by q, a connection or a transaction
+func insertUser(q sqlx.Ext, m *User) (id int64, err error) {
+	now := time.Now()
+	m.CreatedAt = now
+	columns, args, err := valuesUser(m, userInsertFields)
+	if err != nil {
+		return
+	}
+	if id, err = lgInsert(q, "user", "id", columns, args); err == nil {
+		m.Id = int(id)
+	}
+	return
+}
+
+// updateUser updates fields of m by q, the auto_now fields are always updated
+func updateUser(q sqlx.Ext, m *User, fields []string) error {
+
+	columns, args, err := valuesUser(m, fields)
+	if err != nil {
+		return err
+	}
+	_, err = lgUpdate(q, "user", "id", m.Id, columns, args)
+	return err
+}
+
+// LoadRelatedOf loads the relation r of t
+func (t *User) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	switch r {
+	case "Profile":
+		l, err := queryProfile(DB, " WHERE "+lgQuote("user_id")+" = ?", t.Id)
+		if err != nil {
+			return 0, err
+		}
+		if len(l) > 0 {
+			t.Profile = l[0]
+		}
+		return int64(len(l)), nil
+	case "Roles":
+		l, err := queryRole(DB, " WHERE "+lgQuote("id")+" IN (SELECT "+lgQuote("role_id")+" FROM "+lgQuote("user_has_role")+" WHERE "+lgQuote("user_id")+" = ?)", t.Id)
+		if err != nil {
+			return 0, err
+		}
+		t.Roles = l
+		return int64(len(l)), nil
+	case "Team":
+		if t.Team == nil {
+			return 0, nil
+		}
+		v, err := getTeam(DB, t.Team.Id)
+		if err != nil {
+			return 0, err
+		}
+		t.Team = v
+		return 1, nil
+	}
+	return 0, errors.New("Error: unknown relation '" + r + "'")
+}
+
+// AddUser insert a new User into database and returns
+// last inserted Id on success.
+func AddUser(m *User) (id int64, err error) {
+	return insertUser(DB, m)
+}
+
+// AddMultiUser insert multi Users into database and returns
+// sum success nums.
+func AddMultiUser(ms []*User) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	// 逐条插入，在同一个事务中
+	err = lgTransaction(func(tx *sqlx.Tx) error {
+		for _, m := range ms {
+			if _, err := insertUser(tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		successNums = int64(len(ms))
+	}
+	return
+}
+
+// AddUserHasMany insert a new User and some items into database and returns
+// last inserted Id on success.
+func AddUserHasMany(m *User) (id int64, err error) {
+	err = lgTransaction(func(tx *sqlx.Tx) (err error) {
+		if id, err = insertUser(tx, m); err != nil {
+			return
+		}
+		if m.Profile != nil {
+			m.Profile.User = &User{Id: m.Id}
+			if _, err := insertProfile(tx, m.Profile); err != nil {
+				return err
+			}
+		}
+		// m2m_add
+		for _, v := range m.Roles {
+			if err := lgLink(tx, "user_has_role", "user_id", "role_id", m.Id, v.Id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// GetUserById retrieves User by Id. Returns error if
+// Id doesn't exist
+func GetUserById(id int) (v *User, err error) {
+	return getUser(DB, id)
+}
+
+// GetUserCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetUserCounts(query map[string]string) (count int64, err error) {
+	where, args, err := LgQueryWhere(query, UserColumns)
+	if err != nil {
+		return
+	}
+	err = DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("user")+lgWhere(where)), args...)
+	return
+}
+
+// GetAllUser retrieves all User matches certain condition. Returns empty list if
+// no records exist
+func GetAllUser(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	where, args, err := LgQueryWhere(query, UserColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	// order by:
+	orderBy, err := LgOrderSQL(sortby, order, UserColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = lgCheckFields(reflect.TypeOf(User{}), fields); err != nil {
+		return nil, nil, err
+	}
+
+	var count int64 = 0
+	if page == 1 {
+		DB.Get(&count, DB.Rebind("SELECT COUNT(*) FROM "+lgQuote("user")+lgWhere(where)), args...)
+	}
+	tail := lgWhere(where)
+	if orderBy != "" {
+		tail += " ORDER BY " + orderBy
+	}
+
+	l, err := queryUser(DB, tail+lgLimit(limit, offset), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		for _, v := range l {
+			for _, lo := range load {
+				v.LoadRelatedOf(lo)
+			}
+			ml = append(ml, *v)
+		}
+	} else {
+		// trim unused fields
+		for _, v := range l {
+			m := make(map[string]interface{})
+			val := reflect.ValueOf(*v)
+			for _, fname := range fields {
+				m[fname] = val.FieldByName(fname).Interface()
+			}
+			ml = append(ml, m)
+		}
+	}
+
+	if len(ml) == 0 {
+		ml = make([]interface{}, 0)
+	}
+
+	if page == 1 {
+		pager = &LgPager{}
+		pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+		pager.List = ml
+		return ml, pager, nil
+	}
+	return ml, nil, nil
+}
+
+// UpdateUser updates User by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateUserById(m *User) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) (err error) {
+		// ascertain id exists in the database
+		if _, err = getUser(tx, m.Id); err != nil {
+			return
+		}
+		// m2m_update
+		if m.Roles != nil {
+			if err = lgUnlinkAll(tx, "user_has_role", "user_id", m.Id); err != nil {
+				return
+			}
+			for _, v := range m.Roles {
+				if err = lgLink(tx, "user_has_role", "user_id", "role_id", m.Id, v.Id); err != nil {
+					return
+				}
+			}
+		}
+		return updateUser(tx, m, userUpdateFields)
+	})
+}
+
+// PatchUser updates User by Id and returns error if
+// the record to be updated doesn't exist
+func PatchUserById(m *User, fields []string) (err error) {
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		return patchUser(tx, m, fields)
+	})
+}
+
+// patchUser updates the given fields of User inside the
+// transaction of tx, many to many fields are replaced
+func patchUser(tx *sqlx.Tx, m *User, fields []string) (err error) {
+	var columns []string
+	for _, fname := range fields {
+		switch fname {
+		case "Roles":
+			// m2m_patch
+			if m.Roles != nil {
+				if err = lgUnlinkAll(tx, "user_has_role", "user_id", m.Id); err != nil {
+					return
+				}
+				for _, v := range m.Roles {
+					if err = lgLink(tx, "user_has_role", "user_id", "role_id", m.Id, v.Id); err != nil {
+						return
+					}
+				}
+			}
+		case "Profile":
+			// 反向的关系在关联表中，不修改
+		default:
+			columns = append(columns, fname)
+		}
+	}
+	// 只有关系字段时不能再更新
+	if len(columns) == 0 {
+		return
+	}
+	return updateUser(tx, m, columns)
+}
+
+// UpdateMultiUser updates several Users in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiUser(ms []*User, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if _, itemErr = getUser(tx, m.Id); itemErr == nil {
+				itemErr = patchUser(tx, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiUserByIds deletes Users by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiUserByIds(ids []int) (result *LgBatchResult, err error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = deleteUserBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// DeleteMultiUserByQuery deletes all Users matches the same query as
+// GetAllUser in one transaction.
+func DeleteMultiUserByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	where, args, err := LgQueryWhere(query, UserColumns)
+	if err != nil {
+		return
+	}
+	if where == "" {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	var ids []int
+	if err = tx.Select(&ids, DB.Rebind("SELECT "+lgQuote("id")+" FROM "+lgQuote("user")+lgWhere(where)), args...); err != nil {
+		tx.Rollback()
+		return
+	}
+	result = deleteUserBatch(tx, ids)
+	err = result.End(tx)
+	return
+}
+
+// deleteUserBatch deletes Users one by one inside the transaction of tx
+func deleteUserBatch(tx *sqlx.Tx, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := lgDelete(tx, "user", "id", id)
+		if err == nil && num == 0 {
+			err = sql.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchUserM2MPart updates User by Id and returns error if
+// the record to be updated doesn't exist
+func PatchUserM2MPartById(m *User, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	return lgTransaction(func(tx *sqlx.Tx) error {
+		switch field {
+		case "Roles":
+			for _, did := range DelIds {
+				if err := lgUnlink(tx, "user_has_role", "user_id", "role_id", m.Id, did); err != nil {
+					return err
+				}
+			}
+			for _, aid := range AddIds {
+				// 已关联的不再加入
+				linked, err := lgLinked(tx, "user_has_role", "user_id", "role_id", m.Id, aid)
+				if err == nil && !linked {
+					err = lgLink(tx, "user_has_role", "user_id", "role_id", m.Id, aid)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteUser deletes User by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteUser(id int) (err error) {
+	// ascertain id exists in the database
+	if _, err = getUser(DB, id); err == nil {
+		var num int64
+		if num, err = lgDelete(DB, "user", "id", id); err == nil {
+			fmt.Println("Number of records deleted in database:", num)
+		}
+	}
+	return
+}
+
+// upsertUserArgs returns the values of the columns inserted by upsert
+func upsertUserArgs(m *User) []interface{} {
+	now := time.Now()
+	m.CreatedAt = now
+	var createdAt interface{}
+	if !m.CreatedAt.IsZero() {
+		createdAt = m.CreatedAt
+	}
+	var teamId interface{}
+	if m.Team != nil {
+		teamId = m.Team.Id
+	}
+	return []interface{}{m.Name, m.Email, m.Status, m.Age, createdAt, teamId}
+}
+
+// UpsertUserByEmail inserts m, or updates the User with the same
+// (email), and returns the Id on success. (email) is the only unique key
+// of the table, so the conflict handled by MySQL is always on it.
+func UpsertUserByEmail(m *User) (id int64, err error) {
+	return upsertUserByEmail(DB, m)
+}
+
+func upsertUserByEmail(q sqlx.Ext, m *User) (id int64, err error) {
+	args := upsertUserArgs(m)
+	if lgPostgres() {
+		err = q.QueryRowx(q.Rebind("INSERT INTO \"user\" (\"name\", \"email\", \"status\", \"age\", \"created_at\", \"team_id\") VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (\"email\") DO UPDATE SET \"name\" = EXCLUDED.\"name\", \"status\" = EXCLUDED.\"status\", \"age\" = EXCLUDED.\"age\", \"team_id\" = EXCLUDED.\"team_id\" RETURNING \"id\""), args...).Scan(&id)
+	} else {
+		res, e := q.Exec("INSERT INTO `user` (`name`, `email`, `status`, `age`, `created_at`, `team_id`) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE `id` = LAST_INSERT_ID(`id`), `name` = VALUES(`name`), `status` = VALUES(`status`), `age` = VALUES(`age`), `team_id` = VALUES(`team_id`)", args...)
+		if err = e; err == nil {
+			id, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return
+	}
+	// 重新读取，修改时m中的字段不一定与数据库一致
+	v, err := getUser(q, int(id))
+	if err == nil {
+		*m = *v
+	}
+	return
+}
+
+// UserUniqueKeys lists the unique keys accepted by the by argument of UpsertUser
+var UserUniqueKeys = []string{"email"}
+
+func upsertUserFunc(by string) (func(sqlx.Ext, *User) (int64, error), error) {
+	switch by {
+	case "", "email":
+		return upsertUserByEmail, nil
+	}
+	return nil, errors.New("Error: unknown unique key '" + by + "', must be one of " + strings.Join(UserUniqueKeys, " | "))
+}
+
+// UpsertUser inserts or updates m by the unique key by, the columns of by are
+// separated by ",", an empty by means the first unique key.
+func UpsertUser(m *User, by string) (id int64, err error) {
+	upsert, err := upsertUserFunc(by)
+	if err != nil {
+		return
+	}
+	// 唯一键冲突时修改，只校验列
+	if err = m.ValidateColumns(); err != nil {
+		return
+	}
+	return upsert(DB, m)
+}
+
+// UpsertMultiUser inserts or updates several Users by the unique key by in
+// one transaction. Nothing is committed unless every item succeeds, and the items after
+// the first failed one are skipped.
+func UpsertMultiUser(ms []*User, by string) (result *LgBatchResult, err error) {
+	upsert, err := upsertUserFunc(by)
+	if err != nil {
+		return
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		itemErr := m.ValidateColumns()
+		if itemErr == nil {
+			_, itemErr = upsert(tx, m)
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(tx)
+	return
+}
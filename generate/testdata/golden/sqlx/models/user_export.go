@@ -0,0 +1,174 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// UserExportFields lists the fields can be exported and imported
+var UserExportFields = []string{"Id", "Name", "Email", "Status", "Age", "CreatedAt", "Team"}
+
+// UserExportColumns lists the columns of UserExportFields
+var UserExportColumns = []string{"id", "name", "email", "status", "age", "created_at", "team_id"}
+
+// UserImportRequired lists the fields an import file must have
+var UserImportRequired = []string{"Name", "Email", "Team"}
+
+// UserImportUniqueKeys lists the fields of each unique key, which must not repeat in an import file
+var UserImportUniqueKeys = [][]string{{"Email"}}
+
+// ExportUser reads all User matches the same query as GetAllUser in the
+// order of Id batch by batch, and calls fn with each of them. Each batch starts after the last
+// Id of the previous one, so rows written during the export are neither skipped nor exported twice.
+func ExportUser(query map[string]string, fn func(m *User) error) (err error) {
+	q := make(map[string]string, len(query)+1)
+	for k, v := range query {
+		q[k] = v
+	}
+	for {
+		// 读取所有字段，由UserCells取出fields
+		l, _, err := GetAllUser(q, nil, []string{"Id"}, []string{"asc"}, 0, lgExportBatch, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, item := range l {
+			v := item.(User)
+			if err = fn(&v); err != nil {
+				return err
+			}
+			q["Id__gt"] = strconv.Itoa(v.Id)
+		}
+		if len(l) < lgExportBatch {
+			return nil
+		}
+	}
+}
+
+// UserCells returns the values of fields of m, relations are exported as their Id
+func UserCells(m *User, fields []string) []interface{} {
+	cells := make([]interface{}, len(fields))
+	for i, field := range fields {
+		switch field {
+		case "Id":
+			cells[i] = m.Id
+		case "Name":
+			cells[i] = m.Name
+		case "Email":
+			cells[i] = m.Email
+		case "Status":
+			cells[i] = m.Status
+		case "Age":
+			cells[i] = m.Age
+		case "CreatedAt":
+			cells[i] = m.CreatedAt
+		case "Team":
+			if m.Team != nil {
+				cells[i] = m.Team.Id
+			}
+		}
+	}
+	return cells
+}
+
+// setUserField parses raw as the value of field and checks it against the column
+func setUserField(m *User, field string, raw string) error {
+	switch field {
+	case "Name":
+		if raw == "" {
+			return errors.New("Name is required")
+		}
+		m.Name = raw
+	case "Email":
+		if raw == "" {
+			return errors.New("Email is required")
+		}
+		m.Email = raw
+	case "Status":
+		if raw == "" {
+			raw = "on"
+		}
+		m.Status = raw
+	case "Age":
+		if raw == "" {
+			return nil
+		}
+		v, err := strconv.ParseUint(raw, 10, 8)
+		if err != nil {
+			return errors.New("Age must be an unsigned integer")
+		}
+		m.Age = uint8(v)
+	case "CreatedAt":
+		if raw == "" {
+			return nil
+		}
+		v, err := lgParseTime(raw)
+		if err != nil {
+			return errors.New("CreatedAt must be a time, e.g. 2006-01-02 15:04:05")
+		}
+		m.CreatedAt = v
+	case "Team":
+		if raw == "" {
+			return errors.New("Team is required")
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("Team must be an Id")
+		}
+		m.Team = &Team{Id: v}
+	}
+	return nil
+}
+
+// ImportUser parses rows under header as Users and inserts them by
+// AddMultiUser. Nothing is inserted unless every row is valid. The header must
+// have the required fields, and the values of a unique key must not repeat in rows.
+func ImportUser(header []string, rows [][]string) (result *LgBatchResult, err error) {
+	fields, err := lgImportFields(header, UserExportFields, UserExportColumns)
+	if err != nil {
+		return
+	}
+	if err = lgMissingFields(fields, UserImportRequired); err != nil {
+		return
+	}
+	keys := newLgImportKeys(fields, UserImportUniqueKeys)
+	result = &LgBatchResult{}
+	var ms []*User
+	for i, row := range rows {
+		if lgEmptyRow(row) {
+			continue
+		}
+		m := new(User)
+		var msgs []string
+		for j, field := range fields {
+			raw := ""
+			if j < len(row) {
+				raw = strings.TrimSpace(row[j])
+			}
+			if e := setUserField(m, field, raw); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if len(msgs) == 0 {
+			if e := m.ValidateFields(fields...); e != nil {
+				msgs = append(msgs, e.Error())
+			}
+		}
+		if e := keys.check(i, row); e != nil {
+			msgs = append(msgs, e.Error())
+		}
+		var rowErr error
+		if len(msgs) > 0 {
+			rowErr = errors.New(strings.Join(msgs, "; "))
+		}
+		result.Add(i, 0, rowErr)
+		ms = append(ms, m)
+	}
+	if result.Failed > 0 || len(ms) == 0 {
+		return
+	}
+	if _, err = AddMultiUser(ms); err == nil {
+		result.Committed = true
+	}
+	return
+}
@@ -0,0 +1,7 @@
+package models
+
+type UserHasRole struct {
+	Id   int   `db:"id"`
+	Role *Role `db:"-" description:"role_id为中间表关联字段。"`
+	User *User `db:"-" description:"user_id为中间表关联字段。"`
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"app/models"
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Context 请求上下文，handler不依赖具体的web框架，由lg_gin.go等适配文件实现
+type Context interface {
+	// Param 路径参数
+	Param(name string) string
+	// Query 查询参数
+	Query(name string) string
+	// Body 请求体，可以多次读取
+	Body() []byte
+	// JSON 以json返回v
+	JSON(status int, v interface{})
+	// Claims JWT解析后的claims，未启用JWT时为nil
+	Claims() map[string]interface{}
+}
+
+// lgError 返回400，校验错误以json对象返回，其它错误返回错误信息
+func lgError(c Context, err error) {
+	if ve, ok := err.(*models.LgValidationError); ok {
+		c.JSON(400, ve)
+		return
+	}
+	c.JSON(400, err.Error())
+}
+
+// lgIsObject 请求体是否为对象，否则作为数组处理
+func lgIsObject(body []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+// lgSplit 逗号分隔的查询参数
+func lgSplit(c Context, name string) []string {
+	if v := c.Query(name); v != "" {
+		return strings.Split(v, ",")
+	}
+	return nil
+}
+
+// lgInt64 整数的查询参数，没有或格式不对时返回def
+func lgInt64(c Context, name string, def int64) int64 {
+	if v, err := strconv.ParseInt(c.Query(name), 10, 64); err == nil {
+		return v
+	}
+	return def
+}
+
+// lgParseQuery query: k:v,k:v
+func lgParseQuery(c Context) (map[string]string, error) {
+	var query = make(map[string]string)
+	for _, cond := range lgSplit(c, "query") {
+		kv := strings.SplitN(cond, ":", 2)
+		if len(kv) != 2 {
+			return nil, errors.New("Error: invalid query key/value pair")
+		}
+		query[kv[0]] = kv[1]
+	}
+	return query, nil
+}
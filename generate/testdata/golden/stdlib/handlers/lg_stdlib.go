@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"app/middleware"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+type stdContext struct {
+	w    http.ResponseWriter
+	r    *http.Request
+	body []byte
+}
+
+func (s *stdContext) Param(name string) string { return s.r.PathValue(name) }
+
+func (s *stdContext) Query(name string) string { return s.r.URL.Query().Get(name) }
+
+func (s *stdContext) Body() []byte {
+	if s.body == nil {
+		s.body, _ = ioutil.ReadAll(s.r.Body)
+	}
+	return s.body
+}
+
+func (s *stdContext) JSON(status int, v interface{}) {
+	s.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	s.w.WriteHeader(status)
+	_ = json.NewEncoder(s.w).Encode(v)
+}
+
+func (s *stdContext) Claims() map[string]interface{} { return middleware.Claims(s.r) }
+
+// Std 把handler转换为http.Handler
+func Std(h func(Context)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h(&stdContext{w: w, r: r})
+	})
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"app/models"
+	"app/models/dto"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// RoleHandler operations for Role
+type RoleHandler struct{}
+
+// Post 新建role，请求体为对象或数组
+func (h *RoleHandler) Post(c Context) {
+	body := c.Body()
+	if lgIsObject(body) {
+		var req dto.RoleCreateRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			lgError(c, err)
+			return
+		}
+		v := req.ToModel()
+		if err := v.Validate(); err != nil {
+			lgError(c, err)
+			return
+		}
+		if _, err := models.AddRoleHasMany(v); err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(201, dto.NewRoleResponse(v))
+		return
+	}
+
+	var reqs []*dto.RoleCreateRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	vs := make([]*models.Role, len(reqs))
+	for i, req := range reqs {
+		vs[i] = req.ToModel()
+	}
+	if err := models.LgValidateAll(len(vs), func(i int) error { return vs[i].Validate() }); err != nil {
+		lgError(c, err)
+		return
+	}
+	successNums, err := models.AddMultiRole(vs)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(201, successNums)
+}
+
+// GetOne 获取role信息，load=关系1,关系2
+func (h *RoleHandler) GetOne(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v, err := models.GetRoleById(id)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	for _, lo := range lgSplit(c, "load") {
+		if _, err := v.LoadRelatedOf(lo); err != nil {
+			lgError(c, err)
+			return
+		}
+	}
+	c.JSON(200, dto.NewRoleResponse(v))
+}
+
+// GetAll 搜索role信息，参数与beego的GetAll一致
+func (h *RoleHandler) GetAll(c Context) {
+	query, err := lgParseQuery(c)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	if lgInt64(c, "getcounts", 0) == 1 {
+		nums, err := models.GetRoleCounts(query)
+		if err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(200, nums)
+		return
+	}
+
+	l, pager, err := models.GetAllRole(query, lgSplit(c, "fields"), lgSplit(c, "sortby"), lgSplit(c, "order"),
+		lgInt64(c, "offset", 0), lgInt64(c, "limit", 10), lgSplit(c, "load"), lgInt64(c, "page", 0))
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	if pager != nil {
+		pager.List = dto.NewRoleResponses(l)
+		c.JSON(200, pager)
+	} else {
+		c.JSON(200, dto.NewRoleResponses(l))
+	}
+}
+
+// Put 修改role，只修改请求体中的字段
+func (h *RoleHandler) Put(c Context) {
+	h.update(c)
+}
+
+// Patch 修改role，只修改请求体中的字段
+func (h *RoleHandler) Patch(c Context) {
+	h.update(c)
+}
+
+func (h *RoleHandler) update(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v := models.Role{Id: id}
+	var req dto.RoleUpdateRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		lgError(c, err)
+		return
+	}
+	fields := req.Apply(&v)
+	if len(fields) == 0 {
+		c.JSON(400, "没有匹配字段！")
+		return
+	}
+	if err := v.ValidateFields(fields...); err != nil {
+		lgError(c, err)
+		return
+	}
+	if err := models.PatchRoleById(&v, fields); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// PatchM2MPart 修改role的关系，m2m_field=字段，请求体为{"Add": [], "Del": []}
+func (h *RoleHandler) PatchM2MPart(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	v := models.Role{Id: id}
+	m2mField := c.Query("m2m_field")
+	if m2mField == "" {
+		c.JSON(400, "m2m_field不能为空！")
+		return
+	}
+	AddOrDelIds := struct {
+		Add []int
+		Del []int
+	}{}
+	if err := json.Unmarshal(c.Body(), &AddOrDelIds); err != nil {
+		lgError(c, err)
+		return
+	}
+	if err := models.PatchRoleM2MPartById(&v, m2mField, AddOrDelIds.Add, AddOrDelIds.Del); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// Delete 删除role
+func (h *RoleHandler) Delete(c Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := models.DeleteRole(id); err != nil {
+		lgError(c, err)
+		return
+	}
+	c.JSON(200, "OK")
+}
+
+// PutMulti 批量替换role，请求体为带Id的数组，未出现的字段置为零值
+func (h *RoleHandler) PutMulti(c Context) {
+	h.updateMulti(c, true)
+}
+
+// PatchMulti 批量修改role，请求体为带Id的数组，只修改出现的字段
+func (h *RoleHandler) PatchMulti(c Context) {
+	h.updateMulti(c, false)
+}
+
+// updateMulti full时修改所有字段，否则只修改请求体中出现的字段
+func (h *RoleHandler) updateMulti(c Context, full bool) {
+	var reqs []*dto.RoleUpdateRequest
+	if err := json.Unmarshal(c.Body(), &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	var vs []*models.Role
+	var fields [][]string
+	for _, req := range reqs {
+		v := &models.Role{Id: req.Id}
+		vs = append(vs, v)
+		if f := req.Apply(v); !full {
+			fields = append(fields, f)
+		} else {
+			fields = append(fields, dto.RoleUpdateFields)
+		}
+	}
+	result, err := models.UpdateMultiRole(vs, fields)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
+
+// DeleteMulti 批量删除role，ids=1,2,3 或 query=k:v,k:v
+func (h *RoleHandler) DeleteMulti(c Context) {
+	var result *models.LgBatchResult
+	var err error
+	if ids := lgSplit(c, "ids"); ids != nil {
+		var intIds []int
+		for _, idStr := range ids {
+			id, e := strconv.Atoi(strings.TrimSpace(idStr))
+			if e != nil {
+				lgError(c, errors.New("Error: invalid id "+idStr))
+				return
+			}
+			intIds = append(intIds, id)
+		}
+		result, err = models.DeleteMultiRoleByIds(intIds)
+	} else if c.Query("query") != "" {
+		var query map[string]string
+		if query, err = lgParseQuery(c); err == nil {
+			result, err = models.DeleteMultiRoleByQuery(query)
+		}
+	} else {
+		err = errors.New("Error: ids or query is required")
+	}
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
+
+// Upsert 按唯一键新增或修改role，by可选 name
+func (h *RoleHandler) Upsert(c Context) {
+	by := c.Query("by")
+	body := c.Body()
+	if lgIsObject(body) {
+		var req dto.RoleCreateRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			lgError(c, err)
+			return
+		}
+		v := req.ToModel()
+		if _, err := models.UpsertRole(v, by); err != nil {
+			lgError(c, err)
+			return
+		}
+		c.JSON(200, dto.NewRoleResponse(v))
+		return
+	}
+
+	var reqs []*dto.RoleCreateRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		lgError(c, err)
+		return
+	}
+	vs := make([]*models.Role, len(reqs))
+	for i, req := range reqs {
+		vs[i] = req.ToModel()
+	}
+	result, err := models.UpsertMultiRole(vs, by)
+	if err != nil {
+		lgError(c, err)
+		return
+	}
+	status := 200
+	if !result.Committed {
+		status = 400
+	}
+	c.JSON(status, result)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"app/routers"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/astaxie/beego/orm"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	orm.RegisterDriver("mysql", orm.DRMySQL)
+	orm.RegisterDataBase("default", "mysql", os.Getenv("SQLCONN"), 1000, 2000)
+}
+
+func main() {
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+	log.Fatal(http.ListenAndServe(addr, routers.NewRouter()))
+}
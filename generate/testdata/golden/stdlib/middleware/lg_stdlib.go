@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type claimsKey struct{}
+
+var stdParam = regexp.MustCompile(`\{(\w+)\.*\}`)
+
+// Claims 返回Auth保存在请求中的JWT claims
+func Claims(r *http.Request) map[string]interface{} {
+	clmap, _ := r.Context().Value(claimsKey{}).(map[string]interface{})
+	return clmap
+}
+
+// Auth 签名、JWT及权限验证，需要注册在每个路由上才能取得路由的pattern
+func Auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// GET /api/user/{id} => /api/user/:id
+		pattern := r.Pattern
+		if i := strings.Index(pattern, " "); i >= 0 {
+			pattern = pattern[i+1:]
+		}
+		pattern = stdParam.ReplaceAllString(pattern, ":$1")
+		claims, ok := verify(w, r, lgRoute(r.Method, pattern))
+		if !ok {
+			return
+		}
+		if claims != nil {
+			r = r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,353 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/astaxie/beego/orm"
+)
+
+type Profile struct {
+	Id   int    `orm:"column(id);auto;pk"`
+	Bio  string `orm:"column(bio);null"`
+	User *User  `orm:"null;rel(one)" description:"与[user] 一对一 关系，该表为扩展表，该表user_id为关联字段。"`
+}
+
+func (t *Profile) TableName() string {
+	return "profile"
+}
+
+func init() {
+	orm.RegisterModel(new(Profile))
+}
+
+func (t *Profile) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	o := orm.NewOrm()
+	num, err := o.LoadRelated(t, r, args)
+	return num, err
+}
+
+// AddProfile insert a new Profile into database and returns
+// last inserted Id on success.
+func AddProfile(m *Profile) (id int64, err error) {
+	o := orm.NewOrm()
+	id, err = o.Insert(m)
+	return
+}
+
+// AddMultiProfile insert multi Profiles into database and returns
+// sum success nums.
+func AddMultiProfile(ms []*Profile) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return
+	}
+	// 分批插入，在同一个事务中
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	successNums, err = o.InsertMulti(100, ms)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	err = o.Commit()
+	return
+}
+
+// AddProfileHasMany insert a new Profile and some items into database and returns
+// last inserted Id on success.
+func AddProfileHasMany(m *Profile) (id int64, err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	id, err = o.Insert(m)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	m.Id = int(id)
+
+	// every_rl
+
+	o.Commit()
+	return
+}
+
+// GetProfileById retrieves Profile by Id. Returns error if
+// Id doesn't exist
+func GetProfileById(id int) (v *Profile, err error) {
+	o := orm.NewOrm()
+	v = &Profile{Id: id}
+	if err = o.Read(v); err == nil {
+		return v, nil
+	}
+	return nil, err
+}
+
+// GetProfileCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetProfileCounts(query map[string]string) (count int64, err error) {
+	o := orm.NewOrm()
+	qs := o.QueryTable(new(Profile))
+	// query k=v
+	for k, v := range query {
+		// rewrite dot-notation to Object__Attribute
+		k = strings.Replace(k, ".", "__", -1)
+		if strings.Contains(k, "isnull") {
+			qs = qs.Filter(k, (v == "true" || v == "1"))
+		} else {
+			qs = qs.Filter(k, v)
+		}
+	}
+	count, err = qs.Count()
+	return count, err
+}
+
+// GetAllProfile retrieves all Profile matches certain condition. Returns empty list if
+// no records exist
+func GetAllProfile(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	o := orm.NewOrm()
+	qs := o.QueryTable(new(Profile))
+	var count int64 = 0
+	// query k=v
+	if cond := LgQueryCond(query); cond != nil {
+		qs = qs.SetCond(cond)
+	}
+	// order by:
+	sortFields, err := LgOrderBy(sortby, order)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var l []Profile
+	qs = qs.OrderBy(sortFields...)
+
+	if page == 1 {
+		count, _ = qs.Count()
+	}
+
+	if _, err = qs.Limit(limit, offset).All(&l, fields...); err == nil {
+		if len(fields) == 0 {
+			for _, v := range l {
+				for _, lo := range load {
+					v.LoadRelatedOf(lo)
+				}
+				ml = append(ml, v)
+			}
+		} else {
+			// trim unused fields
+			for _, v := range l {
+				m := make(map[string]interface{})
+				val := reflect.ValueOf(v)
+				for _, fname := range fields {
+					m[fname] = val.FieldByName(fname).Interface()
+				}
+				for _, lo := range load {
+					v.LoadRelatedOf(lo)
+				}
+				ml = append(ml, m)
+			}
+		}
+
+		if len(ml) == 0 {
+			ml = make([]interface{}, 0)
+		}
+
+		if page == 1 {
+			pager = &LgPager{}
+			pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+			pager.List = ml
+			return ml, pager, nil
+		} else {
+			return ml, nil, nil
+		}
+
+	}
+	return nil, nil, err
+}
+
+// UpdateProfile updates Profile by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateProfileById(m *Profile) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	v := Profile{Id: m.Id}
+
+	// every_rl
+
+	// ascertain id exists in the database
+	if err = o.Read(&v); err == nil {
+		_, err = o.Update(m)
+		if err != nil {
+			o.Rollback()
+			return
+		}
+	}
+
+	o.Commit()
+	return
+}
+
+// PatchProfile updates Profile by Id and returns error if
+// the record to be updated doesn't exist
+func PatchProfileById(m *Profile, fields []string) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	err = patchProfile(o, m, fields)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	o.Commit()
+	return
+}
+
+// patchProfile updates the given fields of Profile inside the
+// transaction of o, relation fields are cleared and added again
+func patchProfile(o orm.Ormer, m *Profile, fields []string) (err error) {
+	for index, fname := range fields {
+		if fname == "" {
+			continue
+		}
+		if index == -1 {
+			continue
+		}
+		// every_rl
+
+	}
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(fields) == 0 {
+		return
+	}
+
+	_, err = o.Update(m, fields...)
+	return
+}
+
+// UpdateMultiProfile updates several Profiles in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiProfile(ms []*Profile, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = o.Read(&Profile{Id: m.Id}); itemErr == nil {
+				itemErr = patchProfile(o, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(o)
+	return
+}
+
+// DeleteMultiProfileByIds deletes Profiles by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiProfileByIds(ids []int) (result *LgBatchResult, err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = deleteProfileBatch(o, ids)
+	err = result.End(o)
+	return
+}
+
+// DeleteMultiProfileByQuery deletes all Profiles matches the same query as
+// GetAllProfile in one transaction.
+func DeleteMultiProfileByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	cond := LgQueryCond(query)
+	if cond == nil {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	var l []Profile
+	if _, err = o.QueryTable(new(Profile)).SetCond(cond).Limit(-1).All(&l, "Id"); err != nil {
+		o.Rollback()
+		return
+	}
+	ids := make([]int, 0, len(l))
+	for _, v := range l {
+		ids = append(ids, v.Id)
+	}
+	result = deleteProfileBatch(o, ids)
+	err = result.End(o)
+	return
+}
+
+// deleteProfileBatch deletes Profiles one by one inside the transaction of o
+func deleteProfileBatch(o orm.Ormer, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := o.Delete(&Profile{Id: id})
+		if err == nil && num == 0 {
+			err = orm.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchProfileM2MPart updates Profile by Id and returns error if
+// the record to be updated doesn't exist
+func PatchProfileM2MPartById(m *Profile, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+
+	// every_m2m_part
+
+	o.Commit()
+	return
+}
+
+// DeleteProfile deletes Profile by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteProfile(id int) (err error) {
+	o := orm.NewOrm()
+	v := Profile{Id: id}
+	// ascertain id exists in the database
+	if err = o.Read(&v); err == nil {
+		var num int64
+		if num, err = o.Delete(&Profile{Id: id}); err == nil {
+			fmt.Println("Number of records deleted in database:", num)
+		}
+	}
+	return
+}
@@ -0,0 +1,533 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/orm"
+)
+
+type User struct {
+	Id        int       `orm:"column(id);auto;pk"`
+	Name      string    `orm:"column(name);size(64)" description:"user name"`
+	Email     string    `orm:"column(email);size(128)"`
+	Status    string    `orm:"column(status);default(on)"`
+	Age       uint8     `orm:"column(age);null"`
+	CreatedAt time.Time `orm:"column(created_at);type(timestamp);auto_now_add;default(CURRENT_TIMESTAMP)"`
+	Profile   *Profile  `orm:"null;reverse(one)" description:"与[profile] 一对一 关系，该表为主表，该表id为关联字段。"`
+	Roles     []*Role   `orm:"null;reverse(many)" description:"与[role] 多对多 关系，中间表是：user_has_role"`
+	Team      *Team     `orm:"null;rel(fk)" description:"与[team] 一对多 关系，该表为从表，该表team_id为关联字段。"`
+}
+
+func (t *User) TableName() string {
+	return "user"
+}
+
+func init() {
+	orm.RegisterModel(new(User))
+}
+
+func (t *User) LoadRelatedOf(r string, args ...interface{}) (int64, error) {
+	o := orm.NewOrm()
+	num, err := o.LoadRelated(t, r, args)
+	return num, err
+}
+
+// AddUser insert a new User into database and returns
+// last inserted Id on success.
+func AddUser(m *User) (id int64, err error) {
+	o := orm.NewOrm()
+	id, err = o.Insert(m)
+	return
+}
+
+// AddMultiUser insert multi Users into database and returns
+// sum success nums.
+func AddMultiUser(ms []*User) (successNums int64, err error) {
+	if len(ms) == 0 {
+		return
+	}
+	// 分批插入，在同一个事务中
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	successNums, err = o.InsertMulti(100, ms)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	err = o.Commit()
+	return
+}
+
+// AddUserHasMany insert a new User and some items into database and returns
+// last inserted Id on success.
+func AddUserHasMany(m *User) (id int64, err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	id, err = o.Insert(m)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	m.Id = int(id)
+
+	// every_rl
+
+	if m.Profile != nil {
+		_, err = o.Insert(m.Profile)
+		if err != nil {
+			o.Rollback()
+			return
+		}
+	}
+	// m2m_add
+	if m.Roles != nil {
+		if len(m.Roles) != 0 {
+			m2m := o.QueryM2M(m, "Roles")
+			_, err = m2m.Add(m.Roles)
+			if err != nil {
+				o.Rollback()
+				return
+			}
+		}
+	}
+
+	o.Commit()
+	return
+}
+
+// GetUserById retrieves User by Id. Returns error if
+// Id doesn't exist
+func GetUserById(id int) (v *User, err error) {
+	o := orm.NewOrm()
+	v = &User{Id: id}
+	if err = o.Read(v); err == nil {
+		return v, nil
+	}
+	return nil, err
+}
+
+// GetUserCounts retrieves counts matches certain condition. Returns empty list if
+// no records exist
+func GetUserCounts(query map[string]string) (count int64, err error) {
+	o := orm.NewOrm()
+	qs := o.QueryTable(new(User))
+	// query k=v
+	for k, v := range query {
+		// rewrite dot-notation to Object__Attribute
+		k = strings.Replace(k, ".", "__", -1)
+		if strings.Contains(k, "isnull") {
+			qs = qs.Filter(k, (v == "true" || v == "1"))
+		} else {
+			qs = qs.Filter(k, v)
+		}
+	}
+	count, err = qs.Count()
+	return count, err
+}
+
+// GetAllUser retrieves all User matches certain condition. Returns empty list if
+// no records exist
+func GetAllUser(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64, load []string, page int64) (ml []interface{}, pager *LgPager, err error) {
+	o := orm.NewOrm()
+	qs := o.QueryTable(new(User))
+	var count int64 = 0
+	// query k=v
+	if cond := LgQueryCond(query); cond != nil {
+		qs = qs.SetCond(cond)
+	}
+	// order by:
+	sortFields, err := LgOrderBy(sortby, order)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var l []User
+	qs = qs.OrderBy(sortFields...)
+
+	if page == 1 {
+		count, _ = qs.Count()
+	}
+
+	if _, err = qs.Limit(limit, offset).All(&l, fields...); err == nil {
+		if len(fields) == 0 {
+			for _, v := range l {
+				for _, lo := range load {
+					v.LoadRelatedOf(lo)
+				}
+				ml = append(ml, v)
+			}
+		} else {
+			// trim unused fields
+			for _, v := range l {
+				m := make(map[string]interface{})
+				val := reflect.ValueOf(v)
+				for _, fname := range fields {
+					m[fname] = val.FieldByName(fname).Interface()
+				}
+				for _, lo := range load {
+					v.LoadRelatedOf(lo)
+				}
+				ml = append(ml, m)
+			}
+		}
+
+		if len(ml) == 0 {
+			ml = make([]interface{}, 0)
+		}
+
+		if page == 1 {
+			pager = &LgPager{}
+			pager.Page = pager.PageUtil(count, offset/limit+1, limit)
+			pager.List = ml
+			return ml, pager, nil
+		} else {
+			return ml, nil, nil
+		}
+
+	}
+	return nil, nil, err
+}
+
+// UpdateUser updates User by Id and returns error if
+// the record to be updated doesn't exist
+func UpdateUserById(m *User) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	v := User{Id: m.Id}
+
+	// every_rl
+	// m2m_update
+	if m.Roles != nil {
+		m2m := o.QueryM2M(m, "Roles")
+		_, err = m2m.Clear()
+		if err != nil {
+			o.Rollback()
+			return
+		}
+		if len(m.Roles) != 0 {
+			_, err = m2m.Add(m.Roles)
+			if err != nil {
+				o.Rollback()
+				return
+			}
+		}
+	}
+
+	// ascertain id exists in the database
+	if err = o.Read(&v); err == nil {
+		_, err = o.Update(m)
+		if err != nil {
+			o.Rollback()
+			return
+		}
+	}
+
+	o.Commit()
+	return
+}
+
+// PatchUser updates User by Id and returns error if
+// the record to be updated doesn't exist
+func PatchUserById(m *User, fields []string) (err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	err = patchUser(o, m, fields)
+	if err != nil {
+		o.Rollback()
+		return
+	}
+	o.Commit()
+	return
+}
+
+// patchUser updates the given fields of User inside the
+// transaction of o, relation fields are cleared and added again
+func patchUser(o orm.Ormer, m *User, fields []string) (err error) {
+	for index, fname := range fields {
+		if fname == "" {
+			continue
+		}
+		if index == -1 {
+			continue
+		}
+		// every_rl
+		// m2m_patch
+		if fname == "Roles" {
+			if m.Roles != nil {
+				m2m := o.QueryM2M(m, "Roles")
+				_, err = m2m.Clear()
+				if err != nil {
+					return
+				}
+				if len(m.Roles) != 0 {
+					_, err = m2m.Add(m.Roles)
+					if err != nil {
+						return
+					}
+				}
+			}
+			fields = append(fields[:index], fields[index+1:]...)
+		}
+
+	}
+	// 只有关系字段时不能再更新，否则会把所有字段更新为零值
+	if len(fields) == 0 {
+		return
+	}
+
+	_, err = o.Update(m, fields...)
+	return
+}
+
+// UpdateMultiUser updates several Users in one transaction, fields[i] are
+// the fields to update of ms[i]. Nothing is committed unless every item succeeds, and the
+// items after the first failed one are skipped.
+func UpdateMultiUser(ms []*User, fields [][]string) (result *LgBatchResult, err error) {
+	if len(ms) != len(fields) {
+		return nil, errors.New("Error: 'ms', 'fields' sizes mismatch")
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		var itemErr error
+		if len(fields[i]) == 0 {
+			itemErr = errors.New("没有匹配字段！")
+		} else if itemErr = m.ValidateFields(fields[i]...); itemErr == nil {
+			if itemErr = o.Read(&User{Id: m.Id}); itemErr == nil {
+				itemErr = patchUser(o, m, fields[i])
+			}
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(o)
+	return
+}
+
+// DeleteMultiUserByIds deletes Users by Ids in one transaction. Nothing is
+// committed unless every record exists and is deleted, and the Ids after the first failed
+// one are skipped.
+func DeleteMultiUserByIds(ids []int) (result *LgBatchResult, err error) {
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = deleteUserBatch(o, ids)
+	err = result.End(o)
+	return
+}
+
+// DeleteMultiUserByQuery deletes all Users matches the same query as
+// GetAllUser in one transaction.
+func DeleteMultiUserByQuery(query map[string]string) (result *LgBatchResult, err error) {
+	cond := LgQueryCond(query)
+	if cond == nil {
+		return nil, errors.New("Error: query can not be empty")
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	var l []User
+	if _, err = o.QueryTable(new(User)).SetCond(cond).Limit(-1).All(&l, "Id"); err != nil {
+		o.Rollback()
+		return
+	}
+	ids := make([]int, 0, len(l))
+	for _, v := range l {
+		ids = append(ids, v.Id)
+	}
+	result = deleteUserBatch(o, ids)
+	err = result.End(o)
+	return
+}
+
+// deleteUserBatch deletes Users one by one inside the transaction of o
+func deleteUserBatch(o orm.Ormer, ids []int) (result *LgBatchResult) {
+	result = &LgBatchResult{}
+	for i, id := range ids {
+		if result.Skip(i, id) {
+			continue
+		}
+		num, err := o.Delete(&User{Id: id})
+		if err == nil && num == 0 {
+			err = orm.ErrNoRows
+		}
+		result.Add(i, id, err)
+	}
+	return
+}
+
+// PatchUserM2MPart updates User by Id and returns error if
+// the record to be updated doesn't exist
+func PatchUserM2MPartById(m *User, field string, AddIds, DelIds []int) (err error) {
+	lenDel := len(DelIds)
+	lenAdd := len(AddIds)
+	if lenDel == 0 && lenAdd == 0 {
+		err = errors.New("Add和Del不能同时为[]！")
+		return
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+
+	// every_m2m_part
+	// m2m_Roles
+	if field == "Roles" {
+		m2m := o.QueryM2M(m, "Roles")
+		if lenDel != 0 {
+			for _, did := range DelIds {
+				delone := &Role{Id: did}
+				if m2m.Exist(delone) {
+					_, err = m2m.Remove(delone)
+					if err != nil {
+						o.Rollback()
+						return
+					}
+				}
+			}
+		}
+		if lenAdd != 0 {
+			for _, aid := range AddIds {
+				addone := &Role{Id: aid}
+				if !m2m.Exist(addone) {
+					_, err = m2m.Add(addone)
+					if err != nil {
+						o.Rollback()
+						return
+					}
+				}
+			}
+		}
+	}
+
+	o.Commit()
+	return
+}
+
+// DeleteUser deletes User by Id and returns error if
+// the record to be deleted doesn't exist
+func DeleteUser(id int) (err error) {
+	o := orm.NewOrm()
+	v := User{Id: id}
+	// ascertain id exists in the database
+	if err = o.Read(&v); err == nil {
+		var num int64
+		if num, err = o.Delete(&User{Id: id}); err == nil {
+			fmt.Println("Number of records deleted in database:", num)
+		}
+	}
+	return
+}
+
+// upsertUserArgs returns the values of the columns inserted by upsert
+func upsertUserArgs(m *User) []interface{} {
+	now := time.Now()
+	m.CreatedAt = now
+	var createdAt interface{}
+	if !m.CreatedAt.IsZero() {
+		createdAt = m.CreatedAt
+	}
+	var teamId interface{}
+	if m.Team != nil {
+		teamId = m.Team.Id
+	}
+	return []interface{}{m.Name, m.Email, m.Status, m.Age, createdAt, teamId}
+}
+
+// UpsertUserByEmail inserts m, or updates the User with the same
+// (email), and returns the Id on success. (email) is the only unique key
+// of the table, so the conflict handled by MySQL is always on it.
+func UpsertUserByEmail(m *User) (id int64, err error) {
+	return upsertUserByEmail(orm.NewOrm(), m)
+}
+
+func upsertUserByEmail(o orm.Ormer, m *User) (id int64, err error) {
+	args := upsertUserArgs(m)
+	if o.Driver().Type() == orm.DRPostgres {
+		err = o.Raw("INSERT INTO \"user\" (\"name\", \"email\", \"status\", \"age\", \"created_at\", \"team_id\") VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (\"email\") DO UPDATE SET \"name\" = EXCLUDED.\"name\", \"status\" = EXCLUDED.\"status\", \"age\" = EXCLUDED.\"age\", \"team_id\" = EXCLUDED.\"team_id\" RETURNING \"id\"", args...).QueryRow(&id)
+	} else {
+		res, e := o.Raw("INSERT INTO `user` (`name`, `email`, `status`, `age`, `created_at`, `team_id`) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE `id` = LAST_INSERT_ID(`id`), `name` = VALUES(`name`), `status` = VALUES(`status`), `age` = VALUES(`age`), `team_id` = VALUES(`team_id`)", args...).Exec()
+		if err = e; err == nil {
+			id, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return
+	}
+	// 重新读取，修改时m中的字段不一定与数据库一致
+	m.Id = int(id)
+	err = o.Read(m)
+	return
+}
+
+// UserUniqueKeys lists the unique keys accepted by the by argument of UpsertUser
+var UserUniqueKeys = []string{"email"}
+
+func upsertUserFunc(by string) (func(orm.Ormer, *User) (int64, error), error) {
+	switch by {
+	case "", "email":
+		return upsertUserByEmail, nil
+	}
+	return nil, errors.New("Error: unknown unique key '" + by + "', must be one of " + strings.Join(UserUniqueKeys, " | "))
+}
+
+// UpsertUser inserts or updates m by the unique key by, the columns of by are
+// separated by ",", an empty by means the first unique key.
+func UpsertUser(m *User, by string) (id int64, err error) {
+	upsert, err := upsertUserFunc(by)
+	if err != nil {
+		return
+	}
+	// 唯一键冲突时修改，只校验列
+	if err = m.ValidateColumns(); err != nil {
+		return
+	}
+	return upsert(orm.NewOrm(), m)
+}
+
+// UpsertMultiUser inserts or updates several Users by the unique key by in
+// one transaction. Nothing is committed unless every item succeeds, and the items after
+// the first failed one are skipped.
+func UpsertMultiUser(ms []*User, by string) (result *LgBatchResult, err error) {
+	upsert, err := upsertUserFunc(by)
+	if err != nil {
+		return
+	}
+	o, err := lgBegin()
+	if err != nil {
+		return
+	}
+	result = &LgBatchResult{}
+	for i, m := range ms {
+		if result.Skip(i, m.Id) {
+			continue
+		}
+		itemErr := m.ValidateColumns()
+		if itemErr == nil {
+			_, itemErr = upsert(o, m)
+		}
+		result.Add(i, m.Id, itemErr)
+	}
+	err = result.End(o)
+	return
+}
@@ -0,0 +1,68 @@
+package routers
+
+import (
+	"app/handlers"
+	"app/middleware"
+	"net/http"
+)
+
+// NewRouter 注册所有表的路由
+func NewRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	{
+		h := &handlers.ProfileHandler{}
+		mux.Handle("POST /api/profile", middleware.Auth(handlers.Std(h.Post)))
+		mux.Handle("GET /api/profile/{id}", middleware.Auth(handlers.Std(h.GetOne)))
+		mux.Handle("GET /api/profile", middleware.Auth(handlers.Std(h.GetAll)))
+		mux.Handle("PUT /api/profile/{id}", middleware.Auth(handlers.Std(h.Put)))
+		mux.Handle("PATCH /api/profile/{id}", middleware.Auth(handlers.Std(h.Patch)))
+		mux.Handle("PATCH /api/profile/m2m/part/{id}", middleware.Auth(handlers.Std(h.PatchM2MPart)))
+		mux.Handle("DELETE /api/profile/{id}", middleware.Auth(handlers.Std(h.Delete)))
+		mux.Handle("PUT /api/profile", middleware.Auth(handlers.Std(h.PutMulti)))
+		mux.Handle("PATCH /api/profile", middleware.Auth(handlers.Std(h.PatchMulti)))
+		mux.Handle("DELETE /api/profile", middleware.Auth(handlers.Std(h.DeleteMulti)))
+	}
+	{
+		h := &handlers.RoleHandler{}
+		mux.Handle("POST /api/role", middleware.Auth(handlers.Std(h.Post)))
+		mux.Handle("GET /api/role/{id}", middleware.Auth(handlers.Std(h.GetOne)))
+		mux.Handle("GET /api/role", middleware.Auth(handlers.Std(h.GetAll)))
+		mux.Handle("PUT /api/role/{id}", middleware.Auth(handlers.Std(h.Put)))
+		mux.Handle("PATCH /api/role/{id}", middleware.Auth(handlers.Std(h.Patch)))
+		mux.Handle("PATCH /api/role/m2m/part/{id}", middleware.Auth(handlers.Std(h.PatchM2MPart)))
+		mux.Handle("DELETE /api/role/{id}", middleware.Auth(handlers.Std(h.Delete)))
+		mux.Handle("PUT /api/role", middleware.Auth(handlers.Std(h.PutMulti)))
+		mux.Handle("PATCH /api/role", middleware.Auth(handlers.Std(h.PatchMulti)))
+		mux.Handle("DELETE /api/role", middleware.Auth(handlers.Std(h.DeleteMulti)))
+		mux.Handle("PUT /api/role/upsert", middleware.Auth(handlers.Std(h.Upsert)))
+	}
+	{
+		h := &handlers.TeamHandler{}
+		mux.Handle("POST /api/team", middleware.Auth(handlers.Std(h.Post)))
+		mux.Handle("GET /api/team/{id}", middleware.Auth(handlers.Std(h.GetOne)))
+		mux.Handle("GET /api/team", middleware.Auth(handlers.Std(h.GetAll)))
+		mux.Handle("PUT /api/team/{id}", middleware.Auth(handlers.Std(h.Put)))
+		mux.Handle("PATCH /api/team/{id}", middleware.Auth(handlers.Std(h.Patch)))
+		mux.Handle("PATCH /api/team/m2m/part/{id}", middleware.Auth(handlers.Std(h.PatchM2MPart)))
+		mux.Handle("DELETE /api/team/{id}", middleware.Auth(handlers.Std(h.Delete)))
+		mux.Handle("PUT /api/team", middleware.Auth(handlers.Std(h.PutMulti)))
+		mux.Handle("PATCH /api/team", middleware.Auth(handlers.Std(h.PatchMulti)))
+		mux.Handle("DELETE /api/team", middleware.Auth(handlers.Std(h.DeleteMulti)))
+	}
+	{
+		h := &handlers.UserHandler{}
+		mux.Handle("POST /api/user", middleware.Auth(handlers.Std(h.Post)))
+		mux.Handle("GET /api/user/{id}", middleware.Auth(handlers.Std(h.GetOne)))
+		mux.Handle("GET /api/user", middleware.Auth(handlers.Std(h.GetAll)))
+		mux.Handle("PUT /api/user/{id}", middleware.Auth(handlers.Std(h.Put)))
+		mux.Handle("PATCH /api/user/{id}", middleware.Auth(handlers.Std(h.Patch)))
+		mux.Handle("PATCH /api/user/m2m/part/{id}", middleware.Auth(handlers.Std(h.PatchM2MPart)))
+		mux.Handle("DELETE /api/user/{id}", middleware.Auth(handlers.Std(h.Delete)))
+		mux.Handle("PUT /api/user", middleware.Auth(handlers.Std(h.PutMulti)))
+		mux.Handle("PATCH /api/user", middleware.Auth(handlers.Std(h.PatchMulti)))
+		mux.Handle("DELETE /api/user", middleware.Auth(handlers.Std(h.DeleteMulti)))
+		mux.Handle("PUT /api/user/upsert", middleware.Auth(handlers.Std(h.Upsert)))
+	}
+	return mux
+}
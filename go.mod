@@ -9,4 +9,5 @@ require (
 	github.com/lib/pq v1.9.0
 	github.com/spf13/viper v1.7.1
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,301 @@
+package migrate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Change 迁移中的一条DDL语句
+type Change struct {
+	SQL         string
+	Destructive bool   // 删除表、列或修改列类型，可能丢失数据
+	Comment     string // Destructive的说明
+}
+
+// Diff 返回将from架构变为to架构的DDL语句。表、列、索引及外键都按名字对应，
+// 改名视为删除后新建
+func Diff(from, to *Schema) []*Change {
+	driver := to.Driver
+	if driver == "" {
+		driver = from.Driver
+	}
+	df := &differ{d: dialect(driver)}
+
+	// 先删除外键及索引，待删除表的外键也先删除，这样删除表时不必考虑顺序
+	for _, ft := range from.Tables {
+		tt := to.Table(ft.Name)
+		for _, fk := range ft.ForeignKeys {
+			if tt == nil || !reflect.DeepEqual(fk, tt.ForeignKey(fk.Name)) {
+				df.add(df.d.dropForeignKey(ft.Name, fk))
+			}
+		}
+	}
+	for _, ft := range from.Tables {
+		if tt := to.Table(ft.Name); tt != nil {
+			for _, idx := range ft.Indexes {
+				if !reflect.DeepEqual(idx, tt.Index(idx.Name)) {
+					df.add(df.d.dropIndex(ft.Name, idx))
+				}
+			}
+		}
+	}
+	for _, tt := range to.Tables {
+		if ft := from.Table(tt.Name); ft == nil {
+			df.add(df.d.createTable(tt))
+		} else {
+			df.alterColumns(ft, tt)
+		}
+	}
+	for _, tt := range to.Tables {
+		ft := from.Table(tt.Name)
+		for _, idx := range tt.Indexes {
+			// 新建表的主键在CREATE TABLE中
+			if ft == nil && !idx.Primary || ft != nil && !reflect.DeepEqual(idx, ft.Index(idx.Name)) {
+				df.add(df.d.addIndex(tt.Name, idx))
+			}
+		}
+	}
+	for _, ft := range from.Tables {
+		if to.Table(ft.Name) == nil {
+			df.destruct("drops table "+ft.Name+" and all its data", "DROP TABLE %s", df.d.ident(ft.Name))
+		}
+	}
+	// 外键最后新建，此时引用的表及索引都已存在
+	for _, tt := range to.Tables {
+		ft := from.Table(tt.Name)
+		for _, fk := range tt.ForeignKeys {
+			if ft == nil || !reflect.DeepEqual(fk, ft.ForeignKey(fk.Name)) {
+				df.add(df.d.addForeignKey(tt.Name, fk))
+			}
+		}
+	}
+	return df.changes
+}
+
+// SQL 将语句拼接为迁移文件的内容，可能丢失数据的语句前有DESTRUCTIVE注释
+func SQL(changes []*Change) string {
+	var b strings.Builder
+	for _, c := range changes {
+		if c.Destructive {
+			b.WriteString("-- DESTRUCTIVE: " + c.Comment + "\n")
+		}
+		b.WriteString(c.SQL + ";\n")
+	}
+	return b.String()
+}
+
+type differ struct {
+	d       dialect
+	changes []*Change
+}
+
+func (df *differ) add(stmts ...string) {
+	for _, s := range stmts {
+		df.changes = append(df.changes, &Change{SQL: s})
+	}
+}
+
+func (df *differ) destruct(comment, format string, args ...interface{}) {
+	df.changes = append(df.changes, &Change{SQL: fmt.Sprintf(format, args...), Destructive: true, Comment: comment})
+}
+
+// alterColumns 新增、修改及删除已有表的列
+func (df *differ) alterColumns(ft, tt *SchemaTable) {
+	table := df.d.ident(tt.Name)
+	for i, tc := range tt.Columns {
+		fc := ft.Column(tc.Name)
+		if fc == nil {
+			pos := ""
+			if df.d == "mysql" {
+				pos = " FIRST"
+				if i > 0 {
+					pos = " AFTER " + df.d.ident(tt.Columns[i-1].Name)
+				}
+			}
+			df.add("ALTER TABLE " + table + " ADD COLUMN " + df.d.columnDef(tc) + pos)
+			continue
+		}
+		if reflect.DeepEqual(fc, tc) {
+			continue
+		}
+		stmts := df.d.modifyColumn(tt.Name, fc, tc)
+		if fc.Type != tc.Type {
+			df.destruct(fmt.Sprintf("changes the type of %s.%s from %s to %s, existing values may be truncated",
+				tt.Name, tc.Name, fc.Type, tc.Type), "%s", stmts[0])
+			stmts = stmts[1:]
+		}
+		df.add(stmts...)
+	}
+	for _, fc := range ft.Columns {
+		if tt.Column(fc.Name) == nil {
+			df.destruct("drops column "+ft.Name+"."+fc.Name+" and its data",
+				"ALTER TABLE %s DROP COLUMN %s", table, df.d.ident(fc.Name))
+		}
+	}
+}
+
+// dialect 生成DDL的数据库，mysql或postgres
+type dialect string
+
+func (d dialect) ident(name string) string {
+	if d == "postgres" {
+		return postgresIdent(name)
+	}
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func (d dialect) idents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.ident(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func postgresIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+var (
+	literalRegex   = regexp.MustCompile(`^-?\d+(\.\d+)?$|^[bx]?'.*'$`)
+	timestampRegex = regexp.MustCompile(`(?i)^(current_timestamp|now)(\(\d*\))?$`)
+	// serialTypes PostgreSQL中默认值为nextval的整数列在新建时用serial，由其创建序列
+	serialTypes = map[string]string{"integer": "serial", "bigint": "bigserial", "smallint": "smallserial"}
+)
+
+// columnDef 新建表或新增列时列的定义
+func (d dialect) columnDef(c *SchemaColumn) string {
+	typ, def := c.Type, c.Default
+	if d == "postgres" && def != nil && strings.HasPrefix(*def, "nextval(") && serialTypes[typ] != "" {
+		typ, def = serialTypes[typ], nil
+	}
+	s := d.ident(c.Name) + " " + typ
+	if !c.Nullable {
+		s += " NOT NULL"
+	} else if d == "mysql" {
+		// 未开启explicit_defaults_for_timestamp时，MySQL的timestamp列默认为NOT NULL
+		s += " NULL"
+	}
+	if def != nil {
+		s += " DEFAULT " + d.defaultValue(*def)
+	}
+	if c.Extra != "" {
+		s += " " + c.Extra
+	}
+	if c.Comment != "" && d == "mysql" {
+		s += " COMMENT " + quote(c.Comment)
+	}
+	return s
+}
+
+// defaultValue MySQL的COLUMN_DEFAULT不带引号，PostgreSQL的默认值已是表达式
+func (d dialect) defaultValue(v string) string {
+	if d == "postgres" || literalRegex.MatchString(v) || timestampRegex.MatchString(v) ||
+		strings.HasPrefix(v, "(") || v == "NULL" {
+		return v
+	}
+	return quote(v)
+}
+
+func (d dialect) createTable(t *SchemaTable) string {
+	var defs []string
+	for _, c := range t.Columns {
+		defs = append(defs, "  "+d.columnDef(c))
+	}
+	for _, idx := range t.Indexes {
+		if !idx.Primary {
+			continue
+		}
+		if d == "postgres" {
+			defs = append(defs, "  CONSTRAINT "+d.ident(idx.Name)+" PRIMARY KEY ("+d.idents(idx.Columns)+")")
+		} else {
+			defs = append(defs, "  PRIMARY KEY ("+d.idents(idx.Columns)+")")
+		}
+	}
+	return "CREATE TABLE " + d.ident(t.Name) + " (\n" + strings.Join(defs, ",\n") + "\n)"
+}
+
+// modifyColumn 修改列的语句，类型改变时第一条语句修改类型
+func (d dialect) modifyColumn(table string, from, to *SchemaColumn) []string {
+	if d == "mysql" {
+		return []string{"ALTER TABLE " + d.ident(table) + " MODIFY COLUMN " + d.columnDef(to)}
+	}
+	prefix := "ALTER TABLE " + d.ident(table) + " ALTER COLUMN " + d.ident(to.Name) + " "
+	var stmts []string
+	if from.Type != to.Type {
+		stmts = append(stmts, prefix+"TYPE "+to.Type+" USING "+d.ident(to.Name)+"::"+to.Type)
+	}
+	if from.Nullable != to.Nullable {
+		if to.Nullable {
+			stmts = append(stmts, prefix+"DROP NOT NULL")
+		} else {
+			stmts = append(stmts, prefix+"SET NOT NULL")
+		}
+	}
+	if !reflect.DeepEqual(from.Default, to.Default) {
+		if to.Default == nil {
+			stmts = append(stmts, prefix+"DROP DEFAULT")
+		} else {
+			stmts = append(stmts, prefix+"SET DEFAULT "+*to.Default)
+		}
+	}
+	if from.Extra != to.Extra {
+		switch {
+		case to.Extra == "":
+			stmts = append(stmts, prefix+"DROP IDENTITY")
+		case from.Extra == "":
+			stmts = append(stmts, prefix+"ADD "+to.Extra)
+		default:
+			stmts = append(stmts, prefix+"SET "+strings.TrimSuffix(to.Extra, " AS IDENTITY"))
+		}
+	}
+	return stmts
+}
+
+func (d dialect) addIndex(table string, idx *SchemaIndex) string {
+	cols := " (" + d.idents(idx.Columns) + ")"
+	switch {
+	case idx.Primary && d == "mysql":
+		return "ALTER TABLE " + d.ident(table) + " ADD PRIMARY KEY" + cols
+	case idx.Primary:
+		return "ALTER TABLE " + d.ident(table) + " ADD CONSTRAINT " + d.ident(idx.Name) + " PRIMARY KEY" + cols
+	case idx.Constraint:
+		return "ALTER TABLE " + d.ident(table) + " ADD CONSTRAINT " + d.ident(idx.Name) + " UNIQUE" + cols
+	case idx.Unique:
+		return "CREATE UNIQUE INDEX " + d.ident(idx.Name) + " ON " + d.ident(table) + cols
+	}
+	return "CREATE INDEX " + d.ident(idx.Name) + " ON " + d.ident(table) + cols
+}
+
+func (d dialect) dropIndex(table string, idx *SchemaIndex) string {
+	switch {
+	case idx.Primary && d == "mysql":
+		return "ALTER TABLE " + d.ident(table) + " DROP PRIMARY KEY"
+	case idx.Primary || idx.Constraint:
+		return "ALTER TABLE " + d.ident(table) + " DROP CONSTRAINT " + d.ident(idx.Name)
+	case d == "mysql":
+		return "DROP INDEX " + d.ident(idx.Name) + " ON " + d.ident(table)
+	}
+	return "DROP INDEX " + d.ident(idx.Name)
+}
+
+func (d dialect) addForeignKey(table string, fk *SchemaForeignKey) string {
+	s := "ALTER TABLE " + d.ident(table) + " ADD CONSTRAINT " + d.ident(fk.Name) +
+		" FOREIGN KEY (" + d.idents(fk.Columns) + ") REFERENCES " + d.ident(fk.RefTable) + " (" + d.idents(fk.RefColumns) + ")"
+	if fk.OnDelete != "" {
+		s += " ON DELETE " + fk.OnDelete
+	}
+	if fk.OnUpdate != "" {
+		s += " ON UPDATE " + fk.OnUpdate
+	}
+	return s
+}
+
+func (d dialect) dropForeignKey(table string, fk *SchemaForeignKey) string {
+	if d == "mysql" {
+		return "ALTER TABLE " + d.ident(table) + " DROP FOREIGN KEY " + d.ident(fk.Name)
+	}
+	return "ALTER TABLE " + d.ident(table) + " DROP CONSTRAINT " + d.ident(fk.Name)
+}
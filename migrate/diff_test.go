@@ -0,0 +1,126 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func intColumn(name string) *SchemaColumn {
+	return &SchemaColumn{Name: name, Type: "int"}
+}
+
+func primary(columns ...string) *SchemaIndex {
+	return &SchemaIndex{Name: "PRIMARY", Columns: columns, Primary: true}
+}
+
+// checkChanges 比较语句及其是否destructive，destructive的语句前为"! "
+func checkChanges(t *testing.T, changes []*Change, want []string) {
+	t.Helper()
+	var got []string
+	for _, c := range changes {
+		s := c.SQL
+		if c.Destructive {
+			s = "! " + s
+		}
+		got = append(got, s)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDiffColumns(t *testing.T) {
+	from := &Schema{Driver: "mysql", Tables: []*SchemaTable{{
+		Name: "user",
+		Columns: []*SchemaColumn{
+			intColumn("id"),
+			{Name: "name", Type: "varchar(32)"},
+			{Name: "age", Type: "int", Nullable: true},
+		},
+		Indexes: []*SchemaIndex{primary("id")},
+	}}}
+	to := &Schema{Driver: "mysql", Tables: []*SchemaTable{{
+		Name: "user",
+		Columns: []*SchemaColumn{
+			intColumn("id"),
+			{Name: "name", Type: "varchar(64)"},
+			{Name: "email", Type: "varchar(128)", Comment: "login"},
+		},
+		Indexes: []*SchemaIndex{primary("id"), {Name: "uk_email", Columns: []string{"email"}, Unique: true}},
+	}}}
+	checkChanges(t, Diff(from, to), []string{
+		"! ALTER TABLE `user` MODIFY COLUMN `name` varchar(64) NOT NULL",
+		"ALTER TABLE `user` ADD COLUMN `email` varchar(128) NOT NULL COMMENT 'login' AFTER `name`",
+		"! ALTER TABLE `user` DROP COLUMN `age`",
+		"CREATE UNIQUE INDEX `uk_email` ON `user` (`email`)",
+	})
+	checkChanges(t, Diff(to, from), []string{
+		"DROP INDEX `uk_email` ON `user`",
+		"! ALTER TABLE `user` MODIFY COLUMN `name` varchar(32) NOT NULL",
+		"ALTER TABLE `user` ADD COLUMN `age` int NULL AFTER `name`",
+		"! ALTER TABLE `user` DROP COLUMN `email`",
+	})
+}
+
+func TestDiffPostgresColumns(t *testing.T) {
+	from := &Schema{Driver: "postgres", Tables: []*SchemaTable{{
+		Name:    "user",
+		Columns: []*SchemaColumn{intColumn("id"), {Name: "name", Type: "varchar(32)", Nullable: true}},
+	}}}
+	def := "''::character varying"
+	to := &Schema{Driver: "postgres", Tables: []*SchemaTable{{
+		Name:    "user",
+		Columns: []*SchemaColumn{intColumn("id"), {Name: "name", Type: "text", Default: &def}},
+	}}}
+	// 只有修改类型的语句是destructive
+	checkChanges(t, Diff(from, to), []string{
+		`! ALTER TABLE "user" ALTER COLUMN "name" TYPE text USING "name"::text`,
+		`ALTER TABLE "user" ALTER COLUMN "name" SET NOT NULL`,
+		`ALTER TABLE "user" ALTER COLUMN "name" SET DEFAULT ''::character varying`,
+	})
+}
+
+// TestDiffOrder 外键最先删除、最后新建，索引在列修改前删除、修改后新建，删除表在新建外键前
+func TestDiffOrder(t *testing.T) {
+	from := &Schema{Driver: "mysql", Tables: []*SchemaTable{
+		{Name: "team", Columns: []*SchemaColumn{intColumn("id")}, Indexes: []*SchemaIndex{primary("id")}},
+		{
+			Name:    "user",
+			Columns: []*SchemaColumn{intColumn("id"), intColumn("team_id")},
+			Indexes: []*SchemaIndex{primary("id"), {Name: "idx_team", Columns: []string{"team_id"}}},
+			ForeignKeys: []*SchemaForeignKey{
+				{Name: "fk_user_team", Columns: []string{"team_id"}, RefTable: "team", RefColumns: []string{"id"}},
+			},
+		},
+	}}
+	to := &Schema{Driver: "mysql", Tables: []*SchemaTable{
+		{Name: "org", Columns: []*SchemaColumn{intColumn("id")}, Indexes: []*SchemaIndex{primary("id")}},
+		{
+			Name:    "user",
+			Columns: []*SchemaColumn{intColumn("id"), intColumn("org_id")},
+			Indexes: []*SchemaIndex{primary("id"), {Name: "idx_org", Columns: []string{"org_id"}}},
+			ForeignKeys: []*SchemaForeignKey{
+				{Name: "fk_user_org", Columns: []string{"org_id"}, RefTable: "org", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+			},
+		},
+	}}
+	checkChanges(t, Diff(from, to), []string{
+		"ALTER TABLE `user` DROP FOREIGN KEY `fk_user_team`",
+		"DROP INDEX `idx_team` ON `user`",
+		"CREATE TABLE `org` (\n  `id` int NOT NULL,\n  PRIMARY KEY (`id`)\n)",
+		"ALTER TABLE `user` ADD COLUMN `org_id` int NOT NULL AFTER `id`",
+		"! ALTER TABLE `user` DROP COLUMN `team_id`",
+		"CREATE INDEX `idx_org` ON `user` (`org_id`)",
+		"! DROP TABLE `team`",
+		"ALTER TABLE `user` ADD CONSTRAINT `fk_user_org` FOREIGN KEY (`org_id`) REFERENCES `org` (`id`) ON DELETE CASCADE",
+	})
+}
+
+func TestDiffSame(t *testing.T) {
+	s := &Schema{Driver: "mysql", Tables: []*SchemaTable{
+		{Name: "team", Columns: []*SchemaColumn{intColumn("id")}, Indexes: []*SchemaIndex{primary("id")}},
+	}}
+	if changes := Diff(s, s); len(changes) != 0 {
+		t.Errorf("Diff of the same schema has %d changes", len(changes))
+	}
+}
@@ -0,0 +1,167 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Fixture 一个表的固定数据，行中的列保持文件中的顺序
+type Fixture struct {
+	File  string
+	Table string
+	Rows  []yaml.MapSlice
+}
+
+// LoadFixtures 读取YAML固定数据，paths中的目录取其中的.yml、.yaml文件，按文件名排序。
+// 文件为行的列表时表名为文件名，如team.yml；也可以是表名到行的列表的映射，按文件中的顺序插入
+func LoadFixtures(paths []string) (fixtures []*Fixture, err error) {
+	var files []string
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		var inDir []string
+		for _, ext := range []string{"*.yml", "*.yaml"} {
+			matches, _ := filepath.Glob(filepath.Join(p, ext))
+			inDir = append(inDir, matches...)
+		}
+		sort.Strings(inDir)
+		files = append(files, inDir...)
+	}
+	for _, f := range files {
+		fs, err := loadFixtureFile(f)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, fs...)
+	}
+	return fixtures, nil
+}
+
+func loadFixtureFile(fpath string) ([]*Fixture, error) {
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	var rows []yaml.MapSlice
+	if err = yaml.Unmarshal(data, &rows); err == nil {
+		table := strings.TrimSuffix(filepath.Base(fpath), filepath.Ext(fpath))
+		return []*Fixture{{File: fpath, Table: table, Rows: rows}}, nil
+	}
+	var tables yaml.MapSlice
+	if err = yaml.Unmarshal(data, &tables); err != nil {
+		return nil, fmt.Errorf("%s: %s", fpath, err)
+	}
+	var fixtures []*Fixture
+	for _, item := range tables {
+		f := &Fixture{File: fpath, Table: fmt.Sprint(item.Key)}
+		// 重新编码后按行的列表解析，保持列的顺序
+		out, _ := yaml.Marshal(item.Value)
+		if err = yaml.Unmarshal(out, &f.Rows); err != nil {
+			return nil, fmt.Errorf("%s: table %s must be a list of rows: %s", fpath, f.Table, err)
+		}
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
+
+// InsertFixtures 在一个事务中插入固定数据，clean为true时先删除这些表中已有的行。
+// MySQL插入时不检查外键，PostgreSQL插入后将id列的序列设为最大的id
+func InsertFixtures(db *sql.DB, driver string, fixtures []*Fixture, clean bool) (err error) {
+	d := dialect(driver)
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if d == "mysql" {
+		if _, err = tx.Exec("SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+			return err
+		}
+	}
+	if clean {
+		cleaned := make(map[string]bool)
+		for i := len(fixtures) - 1; i >= 0; i-- {
+			if table := fixtures[i].Table; !cleaned[table] {
+				cleaned[table] = true
+				if _, err = tx.Exec("DELETE FROM " + d.ident(table)); err != nil {
+					return fmt.Errorf("could not clean %s: %s", table, err)
+				}
+			}
+		}
+	}
+	hasId := make(map[string]bool)
+	for _, f := range fixtures {
+		for i, row := range f.Rows {
+			var cols, marks []string
+			var args []interface{}
+			for _, item := range row {
+				col := fmt.Sprint(item.Key)
+				v, err := fixtureValue(item.Value)
+				if err != nil {
+					return fmt.Errorf("%s: %s row %d column %s: %s", f.File, f.Table, i+1, col, err)
+				}
+				if col == "id" {
+					hasId[f.Table] = true
+				}
+				cols = append(cols, d.ident(col))
+				args = append(args, v)
+				if d == "postgres" {
+					marks = append(marks, fmt.Sprintf("$%d", len(args)))
+				} else {
+					marks = append(marks, "?")
+				}
+			}
+			_, err = tx.Exec("INSERT INTO "+d.ident(f.Table)+" ("+strings.Join(cols, ", ")+") VALUES ("+strings.Join(marks, ", ")+")", args...)
+			if err != nil {
+				return fmt.Errorf("%s: %s row %d: %s", f.File, f.Table, i+1, err)
+			}
+		}
+	}
+	if d == "mysql" {
+		if _, err = tx.Exec("SET FOREIGN_KEY_CHECKS = 1"); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range fixtures {
+			if !hasId[f.Table] {
+				continue
+			}
+			delete(hasId, f.Table)
+			// 没有序列时pg_get_serial_sequence为NULL，setval不执行
+			_, err = tx.Exec("SELECT setval(pg_get_serial_sequence($1, 'id'), MAX(id)) FROM "+d.ident(f.Table), d.ident(f.Table))
+			if err != nil {
+				return fmt.Errorf("could not reset the id sequence of %s: %s", f.Table, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// fixtureValue YAML中的标量，时间格式化为数据库可接受的字符串
+func fixtureValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, string, bool, int, int64, uint64, float64:
+		return v, nil
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05"), nil
+	}
+	return nil, fmt.Errorf("unsupported value %v, must be a scalar", v)
+}
@@ -0,0 +1,582 @@
+// Package migrate 管理database.dir中带时间戳的SQL及Go迁移文件，
+// 已执行的版本记录在bee_migrations表中
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// Table 记录已执行迁移的表
+const Table = "bee_migrations"
+
+// VersionLayout 迁移版本的时间戳格式
+const VersionLayout = "20060102150405"
+
+// Drivers 支持的数据库
+var Drivers = []string{"mysql", "postgres"}
+
+// migrationFileRegex 迁移文件名：版本_名字.up.sql、版本_名字.down.sql、版本_名字.go
+var migrationFileRegex = regexp.MustCompile(`^(\d{14})_(\w+?)(\.up\.sql|\.down\.sql|\.go)$`)
+
+// Migration 迁移目录中的一个迁移
+type Migration struct {
+	Version string
+	Name    string
+	Up      string // up的SQL文件，Go迁移为Go文件
+	Down    string // down的SQL文件，Go迁移为Go文件
+	Go      bool
+}
+
+// Status 迁移的执行状态
+type Status struct {
+	*Migration
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // 已执行但迁移文件已不存在
+}
+
+// Migrator 在一个数据库上执行迁移目录中的迁移
+type Migrator struct {
+	Driver string
+	Dir    string
+	conn   string
+	db     *sql.DB
+}
+
+// Open 连接数据库，没有bee_migrations表时创建
+func Open(driver, conn, dir string) (*Migrator, error) {
+	db, err := Connect(driver, conn)
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{Driver: driver, Dir: dir, conn: conn, db: db}
+	_, err = db.Exec("CREATE TABLE IF NOT EXISTS " + Table + " (" +
+		"version VARCHAR(14) NOT NULL PRIMARY KEY, " +
+		"name VARCHAR(255) NOT NULL, " +
+		"applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create table %s: %s", Table, err)
+	}
+	return m, nil
+}
+
+// Close 关闭数据库连接
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// DB 返回迁移使用的数据库连接
+func (m *Migrator) DB() *sql.DB {
+	return m.db
+}
+
+// Create 在dir中新建名为name的迁移，goFile为true时新建Go迁移，返回新建的文件
+func Create(dir, name string, goFile bool, driver string) ([]string, error) {
+	name = strings.Trim(regexp.MustCompile(`\W+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("migration name is empty")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, time.Now().Format(VersionLayout)+"_"+name)
+	files := map[string]string{
+		base + ".up.sql":   "-- " + name + " up\n",
+		base + ".down.sql": "-- " + name + " down\n",
+	}
+	if goFile {
+		files = map[string]string{base + ".go": strings.Replace(GoMigrationTPL, "{{driverImport}}", driverImports[driver], -1)}
+	}
+	var rv []string
+	for fpath := range files {
+		if _, err := os.Stat(fpath); err == nil {
+			return nil, fmt.Errorf("migration '%s' already exists", fpath)
+		}
+		rv = append(rv, fpath)
+	}
+	sort.Strings(rv)
+	for _, fpath := range rv {
+		if err := ioutil.WriteFile(fpath, []byte(files[fpath]), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+// CreateSQL 新建名为name、内容为up及down的SQL迁移，返回新建的文件
+func CreateSQL(dir, name string, up, down string) ([]string, error) {
+	files, err := Create(dir, name, false, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, fpath := range files {
+		content := up
+		if strings.HasSuffix(fpath, ".down.sql") {
+			content = down
+		}
+		if err := ioutil.WriteFile(fpath, []byte(content), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// Migrations 按版本排序返回迁移目录中的迁移，目录不存在时为空
+func Migrations(dir string) ([]*Migration, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[string]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		mg, ok := byVersion[match[1]]
+		if !ok {
+			mg = &Migration{Version: match[1], Name: match[2]}
+			byVersion[match[1]] = mg
+		} else if mg.Name != match[2] {
+			return nil, fmt.Errorf("version %s is used by both %s and %s", match[1], mg.Name, match[2])
+		}
+		fpath := filepath.Join(dir, entry.Name())
+		// 同一版本既有Go又有SQL文件时，执行哪一个取决于目录的顺序
+		if mg.Go != (match[3] == ".go") && (mg.Up != "" || mg.Down != "") {
+			return nil, fmt.Errorf("migration %s_%s has both Go and SQL files", mg.Version, mg.Name)
+		}
+		switch match[3] {
+		case ".up.sql":
+			mg.Up = fpath
+		case ".down.sql":
+			mg.Down = fpath
+		case ".go":
+			mg.Up, mg.Down, mg.Go = fpath, fpath, true
+		}
+	}
+	var rv []*Migration
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, fmt.Errorf("migration %s_%s has no up file", mg.Version, mg.Name)
+		}
+		rv = append(rv, mg)
+	}
+	sort.Slice(rv, func(i, j int) bool { return rv[i].Version < rv[j].Version })
+	return rv, nil
+}
+
+// Status 返回所有迁移及已执行但文件已删除的版本的状态
+func (m *Migrator) Status() ([]*Status, error) {
+	migrations, err := Migrations(m.Dir)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var rv []*Status
+	for _, mg := range migrations {
+		st := &Status{Migration: mg}
+		if a, ok := applied[mg.Version]; ok {
+			st.Applied, st.AppliedAt = true, a.AppliedAt
+			delete(applied, mg.Version)
+		}
+		rv = append(rv, st)
+	}
+	for _, a := range applied {
+		rv = append(rv, a)
+	}
+	sort.Slice(rv, func(i, j int) bool { return rv[i].Version < rv[j].Version })
+	return rv, nil
+}
+
+// applied 读取bee_migrations中已执行的版本
+func (m *Migrator) applied() (map[string]*Status, error) {
+	rows, err := m.db.Query("SELECT version, name, applied_at FROM " + Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rv := make(map[string]*Status)
+	for rows.Next() {
+		st := &Status{Migration: &Migration{}, Applied: true, Missing: true}
+		var appliedAt interface{}
+		if err := rows.Scan(&st.Version, &st.Name, &appliedAt); err != nil {
+			return nil, err
+		}
+		st.AppliedAt = parseTime(appliedAt)
+		rv[st.Version] = st
+	}
+	return rv, rows.Err()
+}
+
+// Up 按版本顺序执行未执行的迁移，n大于0时最多执行n个，返回执行的迁移
+func (m *Migrator) Up(n int) ([]*Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var done []*Migration
+	for _, st := range statuses {
+		if st.Applied {
+			continue
+		}
+		if n > 0 && len(done) == n {
+			break
+		}
+		if err := m.run(st.Migration, true); err != nil {
+			return done, err
+		}
+		done = append(done, st.Migration)
+	}
+	return done, nil
+}
+
+// Down 按版本倒序回滚已执行的迁移，n大于0时最多回滚n个，否则回滚最后一个，返回回滚的迁移
+func (m *Migrator) Down(n int) ([]*Migration, error) {
+	if n <= 0 {
+		n = 1
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var done []*Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < n; i-- {
+		st := statuses[i]
+		if !st.Applied {
+			continue
+		}
+		if err := m.run(st.Migration, false); err != nil {
+			return done, err
+		}
+		done = append(done, st.Migration)
+	}
+	return done, nil
+}
+
+// Redo 回滚并重新执行最后一个已执行的迁移
+func (m *Migrator) Redo() (*Migration, error) {
+	done, err := m.Down(1)
+	if err != nil {
+		return nil, err
+	}
+	if len(done) == 0 {
+		return nil, errors.New("no migration has been applied")
+	}
+	if err := m.run(done[0], true); err != nil {
+		return nil, err
+	}
+	return done[0], nil
+}
+
+// To 执行或回滚迁移，使不大于version的迁移都已执行，大于version的都已回滚
+func (m *Migrator) To(version string) (up []*Migration, down []*Migration, err error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, nil, err
+	}
+	found := version == "0"
+	for _, st := range statuses {
+		found = found || st.Version == version
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("unknown version %s", version)
+	}
+	for i := len(statuses) - 1; i >= 0; i-- {
+		st := statuses[i]
+		if st.Applied && st.Version > version {
+			if err = m.run(st.Migration, false); err != nil {
+				return
+			}
+			down = append(down, st.Migration)
+		}
+	}
+	for _, st := range statuses {
+		if !st.Applied && st.Version <= version {
+			if err = m.run(st.Migration, true); err != nil {
+				return
+			}
+			up = append(up, st.Migration)
+		}
+	}
+	return
+}
+
+// MarkApplied 不执行迁移文件fpath，只在bee_migrations中记录其已执行，用于变化已在数据库中的迁移
+func (m *Migrator) MarkApplied(fpath string) error {
+	match := migrationFileRegex.FindStringSubmatch(filepath.Base(fpath))
+	if match == nil {
+		return fmt.Errorf("'%s' is not a migration file", fpath)
+	}
+	_, err := m.db.Exec(recordSQL(&Migration{Version: match[1], Name: match[2]}, true))
+	return err
+}
+
+// recordSQL 执行或回滚迁移后修改bee_migrations的语句
+func recordSQL(mg *Migration, up bool) string {
+	if up {
+		return "INSERT INTO " + Table + " (version, name) VALUES (" + quote(mg.Version) + ", " + quote(mg.Name) + ")"
+	}
+	return "DELETE FROM " + Table + " WHERE version = " + quote(mg.Version)
+}
+
+// run 在一个事务中执行迁移并修改bee_migrations。MySQL隐式提交DDL，失败时提示可能已部分执行
+func (m *Migrator) run(mg *Migration, up bool) error {
+	err := m.exec(mg, up)
+	if err != nil && m.Driver == "mysql" {
+		err = fmt.Errorf("%s\nMySQL commits DDL statements implicitly, the statements of %s_%s before the failed one "+
+			"may have been applied although it is not recorded in %s. Check the database before running it again",
+			err, mg.Version, mg.Name, Table)
+	}
+	return err
+}
+
+// exec 执行迁移的SQL文件或Go迁移，并在同一个事务中修改bee_migrations
+func (m *Migrator) exec(mg *Migration, up bool) error {
+	record := recordSQL(mg, up)
+	if mg.Go {
+		return m.runGo(mg, up, record)
+	}
+	fpath := mg.Up
+	if !up {
+		fpath = mg.Down
+	}
+	if fpath == "" {
+		return fmt.Errorf("migration %s_%s has no down file", mg.Version, mg.Name)
+	}
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range append(SplitStatements(string(data), m.Driver), record) {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %s\n%s", filepath.Base(fpath), err, stmt)
+		}
+	}
+	return tx.Commit()
+}
+
+// runGo 用go run执行Go迁移，迁移与record在Go迁移的同一个事务中执行
+func (m *Migrator) runGo(mg *Migration, up bool, record string) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	cmd := exec.Command("go", "run", mg.Up, direction)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"BEE_MIGRATE_DRIVER="+m.Driver,
+		"BEE_MIGRATE_CONN="+m.conn,
+		"BEE_MIGRATE_RECORD="+record,
+	)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %s", filepath.Base(mg.Up), err)
+	}
+	return nil
+}
+
+// SplitStatements 按分号拆分driver的SQL语句，不拆分引号、注释及PostgreSQL的$$中的分号。
+// MySQL的字符串中\\转义下一个字符，PostgreSQL只有E'...'中如此
+func SplitStatements(src string, driver string) (stmts []string) {
+	var b strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(b.String()); s != "" && !onlyComments(s) {
+			stmts = append(stmts, s)
+		}
+		b.Reset()
+	}
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			escape := c != '`' && driver != "postgres" || c == '\'' && postgresEscapeString(src, i)
+			j := i + 1
+			for ; j < len(src); j++ {
+				if src[j] == '\\' && escape {
+					j++
+				} else if src[j] == c {
+					break
+				}
+			}
+			b.WriteString(src[i:min(j+1, len(src))])
+			i = j
+		case c == '-' && strings.HasPrefix(src[i:], "--"):
+			j := strings.IndexByte(src[i:], '\n')
+			if j < 0 {
+				j = len(src) - i
+			}
+			b.WriteString(src[i : i+j])
+			i += j - 1
+		case c == '/' && strings.HasPrefix(src[i:], "/*"):
+			j := strings.Index(src[i+2:], "*/")
+			end := len(src)
+			if j >= 0 {
+				end = i + 2 + j + 2
+			}
+			b.WriteString(src[i:end])
+			i = end - 1
+		case c == '$':
+			if tag := dollarTagRegex.FindString(src[i:]); tag != "" {
+				j := strings.Index(src[i+len(tag):], tag)
+				end := len(src)
+				if j >= 0 {
+					end = i + len(tag) + j + len(tag)
+				}
+				b.WriteString(src[i:end])
+				i = end - 1
+			} else {
+				b.WriteByte(c)
+			}
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return
+}
+
+var dollarTagRegex = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// postgresEscapeString src[i]的引号是否开始E'...'字符串
+func postgresEscapeString(src string, i int) bool {
+	if i == 0 || src[i-1] != 'E' && src[i-1] != 'e' {
+		return false
+	}
+	if i == 1 {
+		return true
+	}
+	p := src[i-2]
+	return !(p == '_' || p >= '0' && p <= '9' || p >= 'a' && p <= 'z' || p >= 'A' && p <= 'Z')
+}
+
+// onlyComments SQL中是否只有注释
+func onlyComments(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// quote SQL字符串常量
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// parseTime applied_at在MySQL没有parseTime时为[]byte
+func parseTime(v interface{}) time.Time {
+	switch v := v.(type) {
+	case time.Time:
+		return v
+	case []byte:
+		t, _ := time.ParseInLocation("2006-01-02 15:04:05", string(v), time.Local)
+		return t
+	case string:
+		t, _ := time.ParseInLocation("2006-01-02 15:04:05", v, time.Local)
+		return t
+	}
+	return time.Time{}
+}
+
+func inStrings(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// driverImports Go迁移中数据库驱动的import
+var driverImports = map[string]string{
+	"mysql":    `_ "github.com/go-sql-driver/mysql"`,
+	"postgres": `_ "github.com/lib/pq"`,
+}
+
+// GoMigrationTPL Go迁移的模板，由bee migrate用go run执行
+const GoMigrationTPL = `//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"database/sql"
+	"log"
+	"os"
+
+	{{driverImport}}
+)
+
+// up applies the migration inside tx
+func up(tx *sql.Tx) error {
+	return nil
+}
+
+// down reverts the migration inside tx
+func down(tx *sql.Tx) error {
+	return nil
+}
+
+// main is run by bee migrate, which passes the connection and the statement recording
+// the migration in bee_migrations, run in the same transaction as up or down
+func main() {
+	db, err := sql.Open(os.Getenv("BEE_MIGRATE_DRIVER"), os.Getenv("BEE_MIGRATE_CONN"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fn := up
+	if len(os.Args) > 1 && os.Args[1] == "down" {
+		fn = down
+	}
+	if err = fn(tx); err == nil {
+		_, err = tx.Exec(os.Getenv("BEE_MIGRATE_RECORD"))
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		log.Fatal(err)
+	}
+}
+`
@@ -0,0 +1,326 @@
+package migrate
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		src    string
+		want   []string
+	}{
+		{"statements and comments", "mysql",
+			"-- users;\nCREATE TABLE a (id int);\n/* b; */ DROP TABLE b;\n-- end;\n",
+			[]string{"-- users;\nCREATE TABLE a (id int)", "/* b; */ DROP TABLE b"}},
+		{"mysql backslash", "mysql",
+			`INSERT INTO a VALUES ('it\'s; ok', "a\";b"); DELETE FROM a`,
+			[]string{`INSERT INTO a VALUES ('it\'s; ok', "a\";b")`, "DELETE FROM a"}},
+		// PostgreSQL的'...'中\不转义
+		{"postgres backslash", "postgres",
+			`INSERT INTO a VALUES ('C:\'); DELETE FROM a`,
+			[]string{`INSERT INTO a VALUES ('C:\')`, "DELETE FROM a"}},
+		{"postgres escape string", "postgres",
+			`INSERT INTO a VALUES (E'it\'s; ok', e'\\'); SELECT type'x;y'`,
+			[]string{`INSERT INTO a VALUES (E'it\'s; ok', e'\\')`, "SELECT type'x;y'"}},
+		{"doubled quotes", "postgres",
+			`INSERT INTO a VALUES ('it''s; ok'); DELETE FROM a`,
+			[]string{`INSERT INTO a VALUES ('it''s; ok')`, "DELETE FROM a"}},
+		{"dollar quoted", "postgres",
+			"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql;\nSELECT f()",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql", "SELECT f()"}},
+	}
+	for _, tt := range tests {
+		if got := SplitStatements(tt.src, tt.driver); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: SplitStatements() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// writeMigrations 在临时目录中新建files，返回目录
+func writeMigrations(t *testing.T, files ...string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "bee-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for _, f := range files {
+		stmt := "SELECT '" + f + "'"
+		if strings.HasSuffix(f, ".go") {
+			stmt = "package main\n"
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, f), []byte(stmt), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMigrations(t *testing.T) {
+	dir := writeMigrations(t,
+		"20200102000000_second.up.sql",
+		"20200101000000_first.down.sql",
+		"20200103000000_third.go",
+		"20200101000000_first.up.sql",
+		"README.md",
+	)
+	migrations, err := Migrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, mg := range migrations {
+		got = append(got, mg.Version+"_"+mg.Name)
+	}
+	want := []string{"20200101000000_first", "20200102000000_second", "20200103000000_third"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Migrations() = %v, want %v", got, want)
+	}
+	if mg := migrations[1]; mg.Down != "" || mg.Go {
+		t.Errorf("second: Down = %q, Go = %v", mg.Down, mg.Go)
+	}
+	if mg := migrations[2]; !mg.Go || mg.Up != mg.Down {
+		t.Errorf("third: Up = %q, Down = %q, Go = %v", mg.Up, mg.Down, mg.Go)
+	}
+
+	bad := []struct {
+		name  string
+		files []string
+		err   string
+	}{
+		{"go and sql", []string{"20200101000000_first.go", "20200101000000_first.up.sql"}, "has both Go and SQL files"},
+		{"sql and go", []string{"20200101000000_first.down.sql", "20200101000000_first.go"}, "has both Go and SQL files"},
+		{"no up file", []string{"20200101000000_first.down.sql"}, "has no up file"},
+		{"same version", []string{"20200101000000_first.up.sql", "20200101000000_other.up.sql"}, "is used by both"},
+	}
+	for _, tt := range bad {
+		_, err := Migrations(writeMigrations(t, tt.files...))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: Migrations() error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+// fakeDB 记录bee_migrations中的版本及执行的语句，statement中含fail的语句失败
+type fakeDB struct {
+	mu      sync.Mutex
+	applied map[string]string
+	stmts   []string
+}
+
+var (
+	fakeDBs     = make(map[string]*fakeDB)
+	fakeDBsOnce sync.Once
+	insertRegex = regexp.MustCompile(`^INSERT INTO bee_migrations \(version, name\) VALUES \('(\d+)', '(\w+)'\)$`)
+	deleteRegex = regexp.MustCompile(`^DELETE FROM bee_migrations WHERE version = '(\d+)'$`)
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{db: fakeDBs[name]}, nil
+}
+
+// fakeConn 事务中的修改在Commit时才生效
+type fakeConn struct {
+	db      *fakeDB
+	pending []string
+	inTx    bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.inTx, c.pending = true, nil
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, q := range c.pending {
+		c.db.apply(q)
+	}
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+func (db *fakeDB) apply(query string) {
+	db.stmts = append(db.stmts, query)
+	if m := insertRegex.FindStringSubmatch(query); m != nil {
+		db.applied[m[1]] = m[2]
+	} else if m := deleteRegex.FindStringSubmatch(query); m != nil {
+		delete(db.applied, m[1])
+	}
+}
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errors.New("syntax error")
+	}
+	if s.c.inTx {
+		s.c.pending = append(s.c.pending, s.query)
+	} else {
+		s.c.db.mu.Lock()
+		s.c.db.apply(s.query)
+		s.c.db.mu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.db.mu.Lock()
+	defer s.c.db.mu.Unlock()
+	rows := &fakeRows{}
+	for version, name := range s.c.db.applied {
+		rows.values = append(rows.values, []driver.Value{version, name, "2020-01-01 00:00:00"})
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version", "name", "applied_at"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// fakeMigrator 在dir上使用新的fakeDB，applied为已执行的版本
+func fakeMigrator(t *testing.T, driverName string, dir string, applied ...string) (*Migrator, *fakeDB) {
+	t.Helper()
+	fakeDBsOnce.Do(func() { sql.Register("beemigratefake", fakeDriver{}) })
+	fdb := &fakeDB{applied: make(map[string]string)}
+	for _, v := range applied {
+		fdb.applied[v] = "applied"
+	}
+	fakeDBs[t.Name()] = fdb
+	db, err := sql.Open("beemigratefake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &Migrator{Driver: driverName, Dir: dir, db: db}, fdb
+}
+
+func versions(migrations []*Migration) []string {
+	var rv []string
+	for _, mg := range migrations {
+		rv = append(rv, mg.Version)
+	}
+	return rv
+}
+
+func appliedVersions(db *fakeDB) []string {
+	var rv []string
+	for v := range db.applied {
+		rv = append(rv, v)
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+func TestTo(t *testing.T) {
+	dir := writeMigrations(t,
+		"20200101000000_a.up.sql", "20200101000000_a.down.sql",
+		"20200102000000_b.up.sql", "20200102000000_b.down.sql",
+		"20200103000000_c.up.sql", "20200103000000_c.down.sql",
+		"20200104000000_d.up.sql", "20200104000000_d.down.sql",
+	)
+	// b、d已执行：回滚d，执行a、c
+	m, db := fakeMigrator(t, "postgres", dir, "20200102000000", "20200104000000")
+	up, down, err := m.To("20200103000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := versions(down), []string{"20200104000000"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("To() rolled back %v, want %v", got, want)
+	}
+	if got, want := versions(up), []string{"20200101000000", "20200103000000"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("To() applied %v, want %v", got, want)
+	}
+	if got, want := appliedVersions(db), []string{"20200101000000", "20200102000000", "20200103000000"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bee_migrations has %v, want %v", got, want)
+	}
+	// 回滚在执行之前，按版本倒序
+	want := []string{
+		"SELECT '20200104000000_d.down.sql'",
+		"DELETE FROM bee_migrations WHERE version = '20200104000000'",
+		"SELECT '20200101000000_a.up.sql'",
+		"INSERT INTO bee_migrations (version, name) VALUES ('20200101000000', 'a')",
+		"SELECT '20200103000000_c.up.sql'",
+		"INSERT INTO bee_migrations (version, name) VALUES ('20200103000000', 'c')",
+	}
+	if !reflect.DeepEqual(db.stmts, want) {
+		t.Errorf("To() executed:\n%s\nwant:\n%s", strings.Join(db.stmts, "\n"), strings.Join(want, "\n"))
+	}
+
+	up, down, err = m.To("0")
+	if err != nil || len(up) != 0 || len(down) != 3 || len(db.applied) != 0 {
+		t.Errorf("To(0) = %v, %v, %v, bee_migrations has %v", versions(up), versions(down), err, appliedVersions(db))
+	}
+	if _, _, err := m.To("20200105000000"); err == nil {
+		t.Error("To() of an unknown version succeeded")
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	dir := writeMigrations(t, "20200101000000_a.up.sql")
+	if err := ioutil.WriteFile(filepath.Join(dir, "20200101000000_a.up.sql"),
+		[]byte("CREATE TABLE a (id int);\nfail;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, driverName := range Drivers {
+		m, db := fakeMigrator(t, driverName, dir)
+		_, err := m.Up(0)
+		if err == nil {
+			t.Fatalf("%s: Up() succeeded", driverName)
+		}
+		// 事务回滚，不记录迁移；只有MySQL提示DDL已隐式提交
+		if len(db.applied) != 0 || len(db.stmts) != 0 {
+			t.Errorf("%s: the failed migration left %v", driverName, db.stmts)
+		}
+		if implicit := strings.Contains(err.Error(), "commits DDL statements implicitly"); implicit != (driverName == "mysql") {
+			t.Errorf("%s: Up() error = %v", driverName, err)
+		}
+	}
+}
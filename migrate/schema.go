@@ -0,0 +1,384 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Schema 数据库架构，bee migrate snapshot将其保存为YAML快照
+type Schema struct {
+	Driver string         `yaml:"driver"`
+	Tables []*SchemaTable `yaml:"tables"`
+}
+
+// SchemaTable 表的结构，列按定义顺序，索引及外键按名字排序
+type SchemaTable struct {
+	Name        string              `yaml:"name"`
+	Columns     []*SchemaColumn     `yaml:"columns"`
+	Indexes     []*SchemaIndex      `yaml:"indexes,omitempty"`
+	ForeignKeys []*SchemaForeignKey `yaml:"foreign_keys,omitempty"`
+}
+
+// SchemaColumn 列的结构，Type为数据库中的完整类型，如varchar(255)、int unsigned
+type SchemaColumn struct {
+	Name     string  `yaml:"name"`
+	Type     string  `yaml:"type"`
+	Nullable bool    `yaml:"nullable,omitempty"`
+	Default  *string `yaml:"default,omitempty"` // nil为没有默认值
+	Extra    string  `yaml:"extra,omitempty"`   // 如auto_increment、on update CURRENT_TIMESTAMP
+	Comment  string  `yaml:"comment,omitempty"` // 只用于MySQL
+}
+
+// SchemaIndex 索引，包括主键
+type SchemaIndex struct {
+	Name       string   `yaml:"name"`
+	Columns    []string `yaml:"columns"`
+	Primary    bool     `yaml:"primary,omitempty"`
+	Unique     bool     `yaml:"unique,omitempty"`
+	Constraint bool     `yaml:"constraint,omitempty"` // PostgreSQL的UNIQUE约束，需用DROP CONSTRAINT删除
+}
+
+// SchemaForeignKey 外键
+type SchemaForeignKey struct {
+	Name       string   `yaml:"name"`
+	Columns    []string `yaml:"columns"`
+	RefTable   string   `yaml:"ref_table"`
+	RefColumns []string `yaml:"ref_columns"`
+	OnUpdate   string   `yaml:"on_update,omitempty"`
+	OnDelete   string   `yaml:"on_delete,omitempty"`
+}
+
+// Table 返回名为name的表，不存在时为nil
+func (s *Schema) Table(name string) *SchemaTable {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Column 返回名为name的列，不存在时为nil
+func (t *SchemaTable) Column(name string) *SchemaColumn {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Index 返回名为name的索引，不存在时为nil
+func (t *SchemaTable) Index(name string) *SchemaIndex {
+	for _, i := range t.Indexes {
+		if i.Name == name {
+			return i
+		}
+	}
+	return nil
+}
+
+// ForeignKey 返回名为name的外键，不存在时为nil
+func (t *SchemaTable) ForeignKey(name string) *SchemaForeignKey {
+	for _, fk := range t.ForeignKeys {
+		if fk.Name == name {
+			return fk
+		}
+	}
+	return nil
+}
+
+// Connect 连接数据库，不同于Open不会创建bee_migrations表
+func Connect(driver, conn string) (*sql.DB, error) {
+	if !inStrings(Drivers, driver) {
+		return nil, fmt.Errorf("unsupported driver '%s', must be one of %v", driver, Drivers)
+	}
+	db, err := sql.Open(driver, conn)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// LoadSchema 读取YAML快照
+func LoadSchema(fpath string) (*Schema, error) {
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{}
+	if err = yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("could not parse schema snapshot '%s': %s", fpath, err)
+	}
+	return s, nil
+}
+
+// Save 将架构保存为YAML快照
+func (s *Schema) Save(fpath string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fpath, data, 0644)
+}
+
+// ReadSchema 从information_schema(MySQL)或pg_catalog(PostgreSQL)读取当前数据库的架构，
+// 不包括bee_migrations表
+func ReadSchema(db *sql.DB, driver string) (*Schema, error) {
+	var r schemaReader = mysqlSchema{}
+	if driver == "postgres" {
+		r = postgresSchema{}
+	}
+	names, err := queryStrings(db, r.tablesSQL())
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{Driver: driver}
+	for _, name := range names {
+		if name == Table {
+			continue
+		}
+		t := &SchemaTable{Name: name}
+		if t.Columns, err = r.columns(db, name); err != nil {
+			return nil, fmt.Errorf("could not read the columns of %s: %s", name, err)
+		}
+		if t.Indexes, err = r.indexes(db, name); err != nil {
+			return nil, fmt.Errorf("could not read the indexes of %s: %s", name, err)
+		}
+		if t.ForeignKeys, err = r.foreignKeys(db, name); err != nil {
+			return nil, fmt.Errorf("could not read the foreign keys of %s: %s", name, err)
+		}
+		sort.Slice(t.Indexes, func(i, j int) bool { return t.Indexes[i].Name < t.Indexes[j].Name })
+		sort.Slice(t.ForeignKeys, func(i, j int) bool { return t.ForeignKeys[i].Name < t.ForeignKeys[j].Name })
+		s.Tables = append(s.Tables, t)
+	}
+	sort.Slice(s.Tables, func(i, j int) bool { return s.Tables[i].Name < s.Tables[j].Name })
+	return s, nil
+}
+
+// schemaReader 各数据库读取架构的方式
+type schemaReader interface {
+	tablesSQL() string
+	columns(db *sql.DB, table string) ([]*SchemaColumn, error)
+	indexes(db *sql.DB, table string) ([]*SchemaIndex, error)
+	foreignKeys(db *sql.DB, table string) ([]*SchemaForeignKey, error)
+}
+
+type mysqlSchema struct{}
+
+func (mysqlSchema) tablesSQL() string {
+	return "SELECT TABLE_NAME FROM information_schema.TABLES " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME"
+}
+
+func (mysqlSchema) columns(db *sql.DB, table string) (cols []*SchemaColumn, err error) {
+	rows, err := db.Query("SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA, COLUMN_COMMENT "+
+		"FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? "+
+		"ORDER BY ORDINAL_POSITION", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c := &SchemaColumn{}
+		var nullable string
+		var def sql.NullString
+		if err = rows.Scan(&c.Name, &c.Type, &nullable, &def, &c.Extra, &c.Comment); err != nil {
+			return nil, err
+		}
+		c.Nullable = nullable == "YES"
+		// MySQL 8的表达式默认值在EXTRA中有DEFAULT_GENERATED，新建时需加括号
+		if def.Valid && strings.Contains(c.Extra, "DEFAULT_GENERATED") && !timestampRegex.MatchString(def.String) {
+			def.String = "(" + def.String + ")"
+		}
+		if def.Valid {
+			c.Default = &def.String
+		}
+		c.Extra = strings.TrimSpace(strings.Replace(c.Extra, "DEFAULT_GENERATED", "", 1))
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+func (mysqlSchema) indexes(db *sql.DB, table string) ([]*SchemaIndex, error) {
+	rows, err := db.Query("SELECT INDEX_NAME, NON_UNIQUE, COLUMN_NAME FROM information_schema.STATISTICS "+
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var idxs []*SchemaIndex
+	for rows.Next() {
+		var name, col string
+		var nonUnique int
+		if err = rows.Scan(&name, &nonUnique, &col); err != nil {
+			return nil, err
+		}
+		if len(idxs) == 0 || idxs[len(idxs)-1].Name != name {
+			idxs = append(idxs, &SchemaIndex{Name: name, Primary: name == "PRIMARY", Unique: nonUnique == 0})
+		}
+		idx := idxs[len(idxs)-1]
+		idx.Columns = append(idx.Columns, col)
+	}
+	return idxs, rows.Err()
+}
+
+func (mysqlSchema) foreignKeys(db *sql.DB, table string) ([]*SchemaForeignKey, error) {
+	rows, err := db.Query("SELECT k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME, "+
+		"r.UPDATE_RULE, r.DELETE_RULE FROM information_schema.KEY_COLUMN_USAGE k "+
+		"JOIN information_schema.REFERENTIAL_CONSTRAINTS r "+
+		"ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME "+
+		"WHERE k.TABLE_SCHEMA = DATABASE() AND k.TABLE_NAME = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL "+
+		"ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var fks []*SchemaForeignKey
+	for rows.Next() {
+		var name, col, refTable, refCol, onUpdate, onDelete string
+		if err = rows.Scan(&name, &col, &refTable, &refCol, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		if len(fks) == 0 || fks[len(fks)-1].Name != name {
+			fks = append(fks, &SchemaForeignKey{Name: name, RefTable: refTable,
+				OnUpdate: fkRule(onUpdate), OnDelete: fkRule(onDelete)})
+		}
+		fk := fks[len(fks)-1]
+		fk.Columns = append(fk.Columns, col)
+		fk.RefColumns = append(fk.RefColumns, refCol)
+	}
+	return fks, rows.Err()
+}
+
+type postgresSchema struct{}
+
+func (postgresSchema) tablesSQL() string {
+	return "SELECT table_name FROM information_schema.tables " +
+		"WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name"
+}
+
+func (postgresSchema) columns(db *sql.DB, table string) (cols []*SchemaColumn, err error) {
+	rows, err := db.Query("SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull, "+
+		"pg_get_expr(d.adbin, d.adrelid), a.attidentity "+
+		"FROM pg_attribute a LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum "+
+		"WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped ORDER BY a.attnum",
+		postgresIdent(table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c := &SchemaColumn{}
+		var def sql.NullString
+		var identity string
+		if err = rows.Scan(&c.Name, &c.Type, &c.Nullable, &def, &identity); err != nil {
+			return nil, err
+		}
+		if def.Valid {
+			c.Default = &def.String
+		}
+		switch identity {
+		case "a":
+			c.Extra = "GENERATED ALWAYS AS IDENTITY"
+		case "d":
+			c.Extra = "GENERATED BY DEFAULT AS IDENTITY"
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+func (postgresSchema) indexes(db *sql.DB, table string) ([]*SchemaIndex, error) {
+	// 表达式索引没有列，不在快照中
+	rows, err := db.Query("SELECT i.relname, ix.indisprimary, ix.indisunique, "+
+		"EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conindid = ix.indexrelid AND c.contype = 'u'), a.attname "+
+		"FROM pg_index ix JOIN pg_class i ON i.oid = ix.indexrelid "+
+		"JOIN unnest(ix.indkey) WITH ORDINALITY k(attnum, n) ON true "+
+		"JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = k.attnum "+
+		"WHERE ix.indrelid = $1::regclass ORDER BY i.relname, k.n", postgresIdent(table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var idxs []*SchemaIndex
+	for rows.Next() {
+		idx := &SchemaIndex{}
+		var col string
+		if err = rows.Scan(&idx.Name, &idx.Primary, &idx.Unique, &idx.Constraint, &col); err != nil {
+			return nil, err
+		}
+		if len(idxs) == 0 || idxs[len(idxs)-1].Name != idx.Name {
+			idxs = append(idxs, idx)
+		}
+		idx = idxs[len(idxs)-1]
+		idx.Columns = append(idx.Columns, col)
+	}
+	return idxs, rows.Err()
+}
+
+func (postgresSchema) foreignKeys(db *sql.DB, table string) ([]*SchemaForeignKey, error) {
+	cols := func(rel, keys string) string {
+		return "(SELECT string_agg(a.attname, ',' ORDER BY k.n) FROM unnest(c." + keys + ") WITH ORDINALITY k(attnum, n) " +
+			"JOIN pg_attribute a ON a.attrelid = c." + rel + " AND a.attnum = k.attnum)"
+	}
+	rows, err := db.Query("SELECT c.conname, "+cols("conrelid", "conkey")+", r.relname, "+cols("confrelid", "confkey")+", "+
+		"c.confupdtype, c.confdeltype FROM pg_constraint c JOIN pg_class r ON r.oid = c.confrelid "+
+		"WHERE c.contype = 'f' AND c.conrelid = $1::regclass ORDER BY c.conname", postgresIdent(table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	// pg_constraint中引用动作的代码
+	actions := map[string]string{"a": "NO ACTION", "r": "RESTRICT", "c": "CASCADE", "n": "SET NULL", "d": "SET DEFAULT"}
+	var fks []*SchemaForeignKey
+	for rows.Next() {
+		fk := &SchemaForeignKey{}
+		var columns, refColumns, onUpdate, onDelete string
+		if err = rows.Scan(&fk.Name, &columns, &fk.RefTable, &refColumns, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		fk.Columns, fk.RefColumns = strings.Split(columns, ","), strings.Split(refColumns, ",")
+		fk.OnUpdate, fk.OnDelete = fkRule(actions[onUpdate]), fkRule(actions[onDelete])
+		fks = append(fks, fk)
+	}
+	return fks, rows.Err()
+}
+
+// fkRule 默认的引用动作在快照中省略
+func fkRule(rule string) string {
+	if rule == "NO ACTION" || rule == "RESTRICT" {
+		return ""
+	}
+	return rule
+}
+
+func queryStrings(db *sql.DB, query string, args ...interface{}) (rv []string, err error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		rv = append(rv, s)
+	}
+	return rv, rows.Err()
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"bee/config"
+)
+
+// ScriptCommand name为Beefile中scripts的名字时返回该script，否则name本身即为shell命令
+func ScriptCommand(name string) string {
+	if script, ok := config.Conf.Scripts[name]; ok {
+		return script
+	}
+	return name
+}
+
+// RunScript 在currpath中用shell执行script（Beefile中scripts的名字或shell命令），带上Beefile中的envs。
+// 输出写到标准输出及标准错误，出错时error中带有标准错误的内容
+func RunScript(ctx context.Context, script, currpath string) error {
+	command := ScriptCommand(script)
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	c.Dir = currpath
+	c.Env = append(os.Environ(), config.Conf.Envs...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if err := c.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return fmt.Errorf("%w: %s", err, s)
+		}
+		return err
+	}
+	return nil
+}
@@ -19,10 +19,12 @@ import (
 	"bee/logger/colors"
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"text/template"
@@ -236,3 +238,27 @@ func defaultGOPATH() string {
 	}
 	return ""
 }
+
+var beegoModRegex = regexp.MustCompile(`(?m)^\s*(?:require\s+)?(github\.com/beego/beego/v2|github\.com/astaxie/beego)\s+(v\S+)`)
+
+// GetBeegoModVersion returns the beego module and its version required by the
+// go.mod of dir or of its parents, e.g. github.com/beego/beego/v2 and v2.3.8.
+// Beego v2 wins when both are required.
+func GetBeegoModVersion(dir string) (module string, version string) {
+	for {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			for _, m := range beegoModRegex.FindAllStringSubmatch(string(data), -1) {
+				if module == "" || m[1] == "github.com/beego/beego/v2" {
+					module, version = m[1], m[2]
+				}
+			}
+			return
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		dir = parent
+	}
+}